@@ -12,6 +12,7 @@
 
 import 'dart:core' as $core;
 
+import 'package:fixnum/fixnum.dart' as $fixnum;
 import 'package:protobuf/protobuf.dart' as $pb;
 
 export 'package:protobuf/protobuf.dart' show GeneratedMessageGenericExtensions;
@@ -479,237 +480,2667 @@ class ConfigSources extends $pb.GeneratedMessage {
   LandscapeSource ensureLandscapeSource() => $_ensure(1);
 }
 
-class DistroInfo extends $pb.GeneratedMessage {
-  factory DistroInfo({
+class DistroContract extends $pb.GeneratedMessage {
+  factory DistroContract({
+    $core.String? wslName,
+    $core.String? token,
+    $core.String? pattern,
+  }) {
+    final result = create();
+    if (wslName != null) result.wslName = wslName;
+    if (token != null) result.token = token;
+    if (pattern != null) result.pattern = pattern;
+    return result;
+  }
+
+  DistroContract._();
+
+  factory DistroContract.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory DistroContract.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'DistroContract',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..aOS(2, _omitFieldNames ? '' : 'token')
+    ..aOS(3, _omitFieldNames ? '' : 'pattern')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DistroContract clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DistroContract copyWith(void Function(DistroContract) updates) =>
+      super.copyWith((message) => updates(message as DistroContract))
+          as DistroContract;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DistroContract create() => DistroContract._();
+  @$core.override
+  DistroContract createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static DistroContract getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<DistroContract>(create);
+  static DistroContract? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get token => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set token($core.String value) => $_setString(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasToken() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearToken() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get pattern => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set pattern($core.String value) => $_setString(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasPattern() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearPattern() => $_clearField(3);
+}
+
+class DistroContracts extends $pb.GeneratedMessage {
+  factory DistroContracts({
+    $core.Iterable<DistroContract>? distros,
+  }) {
+    final result = create();
+    if (distros != null) result.distros.addAll(distros);
+    return result;
+  }
+
+  DistroContracts._();
+
+  factory DistroContracts.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory DistroContracts.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'DistroContracts',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pc<DistroContract>(
+        1, _omitFieldNames ? '' : 'distros', $pb.PbFieldType.PM,
+        subBuilder: DistroContract.create)
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DistroContracts clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DistroContracts copyWith(void Function(DistroContracts) updates) =>
+      super.copyWith((message) => updates(message as DistroContracts))
+          as DistroContracts;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DistroContracts create() => DistroContracts._();
+  @$core.override
+  DistroContracts createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static DistroContracts getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<DistroContracts>(create);
+  static DistroContracts? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<DistroContract> get distros => $_getList(0);
+}
+
+class DistroLabels extends $pb.GeneratedMessage {
+  factory DistroLabels({
+    $core.String? wslName,
+    $core.Iterable<$core.String>? labels,
+  }) {
+    final result = create();
+    if (wslName != null) result.wslName = wslName;
+    if (labels != null) result.labels.addAll(labels);
+    return result;
+  }
+
+  DistroLabels._();
+
+  factory DistroLabels.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory DistroLabels.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'DistroLabels',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..pPS(2, _omitFieldNames ? '' : 'labels')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DistroLabels clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DistroLabels copyWith(void Function(DistroLabels) updates) =>
+      super.copyWith((message) => updates(message as DistroLabels))
+          as DistroLabels;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DistroLabels create() => DistroLabels._();
+  @$core.override
+  DistroLabels createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static DistroLabels getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<DistroLabels>(create);
+  static DistroLabels? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $pb.PbList<$core.String> get labels => $_getList(1);
+}
+
+class DeadLetter extends $pb.GeneratedMessage {
+  factory DeadLetter({
     $core.String? wslName,
+    $core.String? task,
+    $core.int? attempts,
+    $core.String? error,
+    $fixnum.Int64? time,
     $core.String? id,
-    $core.String? versionId,
-    $core.String? prettyName,
-    $core.bool? proAttached,
-    $core.String? hostname,
+    $core.String? type,
+  }) {
+    final result = create();
+    if (wslName != null) result.wslName = wslName;
+    if (task != null) result.task = task;
+    if (attempts != null) result.attempts = attempts;
+    if (error != null) result.error = error;
+    if (time != null) result.time = time;
+    if (id != null) result.id = id;
+    if (type != null) result.type = type;
+    return result;
+  }
+
+  DeadLetter._();
+
+  factory DeadLetter.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory DeadLetter.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'DeadLetter',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..aOS(2, _omitFieldNames ? '' : 'task')
+    ..a<$core.int>(3, _omitFieldNames ? '' : 'attempts', $pb.PbFieldType.O3)
+    ..aOS(4, _omitFieldNames ? '' : 'error')
+    ..aInt64(5, _omitFieldNames ? '' : 'time')
+    ..aOS(6, _omitFieldNames ? '' : 'id')
+    ..aOS(7, _omitFieldNames ? '' : 'type')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DeadLetter clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DeadLetter copyWith(void Function(DeadLetter) updates) =>
+      super.copyWith((message) => updates(message as DeadLetter)) as DeadLetter;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DeadLetter create() => DeadLetter._();
+  @$core.override
+  DeadLetter createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static DeadLetter getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<DeadLetter>(create);
+  static DeadLetter? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get task => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set task($core.String value) => $_setString(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasTask() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearTask() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.int get attempts => $_getIZ(2);
+  @$pb.TagNumber(3)
+  set attempts($core.int value) => $_setSignedInt32(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasAttempts() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearAttempts() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.String get error => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set error($core.String value) => $_setString(3, value);
+  @$pb.TagNumber(4)
+  $core.bool hasError() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearError() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $fixnum.Int64 get time => $_getI64(4);
+  @$pb.TagNumber(5)
+  set time($fixnum.Int64 value) => $_setInt64(4, value);
+  @$pb.TagNumber(5)
+  $core.bool hasTime() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearTime() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $core.String get id => $_getSZ(5);
+  @$pb.TagNumber(6)
+  set id($core.String value) => $_setString(5, value);
+  @$pb.TagNumber(6)
+  $core.bool hasId() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearId() => $_clearField(6);
+
+  @$pb.TagNumber(7)
+  $core.String get type => $_getSZ(6);
+  @$pb.TagNumber(7)
+  set type($core.String value) => $_setString(6, value);
+  @$pb.TagNumber(7)
+  $core.bool hasType() => $_has(6);
+  @$pb.TagNumber(7)
+  void clearType() => $_clearField(7);
+}
+
+class DeadLetters extends $pb.GeneratedMessage {
+  factory DeadLetters({
+    $core.Iterable<DeadLetter>? letters,
+  }) {
+    final result = create();
+    if (letters != null) result.letters.addAll(letters);
+    return result;
+  }
+
+  DeadLetters._();
+
+  factory DeadLetters.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory DeadLetters.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'DeadLetters',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pc<DeadLetter>(1, _omitFieldNames ? '' : 'letters', $pb.PbFieldType.PM,
+        subBuilder: DeadLetter.create)
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DeadLetters clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DeadLetters copyWith(void Function(DeadLetters) updates) =>
+      super.copyWith((message) => updates(message as DeadLetters))
+          as DeadLetters;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DeadLetters create() => DeadLetters._();
+  @$core.override
+  DeadLetters createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static DeadLetters getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<DeadLetters>(create);
+  static DeadLetters? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<DeadLetter> get letters => $_getList(0);
+}
+
+class QuarantinedTask extends $pb.GeneratedMessage {
+  factory QuarantinedTask({
+    $core.String? wslName,
+    $core.String? task,
+    $core.String? error,
+    $fixnum.Int64? time,
+  }) {
+    final result = create();
+    if (wslName != null) result.wslName = wslName;
+    if (task != null) result.task = task;
+    if (error != null) result.error = error;
+    if (time != null) result.time = time;
+    return result;
+  }
+
+  QuarantinedTask._();
+
+  factory QuarantinedTask.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory QuarantinedTask.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'QuarantinedTask',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..aOS(2, _omitFieldNames ? '' : 'task')
+    ..aOS(3, _omitFieldNames ? '' : 'error')
+    ..aInt64(4, _omitFieldNames ? '' : 'time')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  QuarantinedTask clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  QuarantinedTask copyWith(void Function(QuarantinedTask) updates) =>
+      super.copyWith((message) => updates(message as QuarantinedTask))
+          as QuarantinedTask;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static QuarantinedTask create() => QuarantinedTask._();
+  @$core.override
+  QuarantinedTask createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static QuarantinedTask getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<QuarantinedTask>(create);
+  static QuarantinedTask? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get task => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set task($core.String value) => $_setString(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasTask() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearTask() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get error => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set error($core.String value) => $_setString(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasError() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearError() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $fixnum.Int64 get time => $_getI64(3);
+  @$pb.TagNumber(4)
+  set time($fixnum.Int64 value) => $_setInt64(3, value);
+  @$pb.TagNumber(4)
+  $core.bool hasTime() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearTime() => $_clearField(4);
+}
+
+class QuarantinedTasks extends $pb.GeneratedMessage {
+  factory QuarantinedTasks({
+    $core.Iterable<QuarantinedTask>? tasks,
+  }) {
+    final result = create();
+    if (tasks != null) result.tasks.addAll(tasks);
+    return result;
+  }
+
+  QuarantinedTasks._();
+
+  factory QuarantinedTasks.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory QuarantinedTasks.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'QuarantinedTasks',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pc<QuarantinedTask>(1, _omitFieldNames ? '' : 'tasks', $pb.PbFieldType.PM,
+        subBuilder: QuarantinedTask.create)
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  QuarantinedTasks clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  QuarantinedTasks copyWith(void Function(QuarantinedTasks) updates) =>
+      super.copyWith((message) => updates(message as QuarantinedTasks))
+          as QuarantinedTasks;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static QuarantinedTasks create() => QuarantinedTasks._();
+  @$core.override
+  QuarantinedTasks createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static QuarantinedTasks getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<QuarantinedTasks>(create);
+  static QuarantinedTasks? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<QuarantinedTask> get tasks => $_getList(0);
+}
+
+class TaskHistoryRequest extends $pb.GeneratedMessage {
+  factory TaskHistoryRequest({
+    $core.String? wslName,
+  }) {
+    final result = create();
+    if (wslName != null) result.wslName = wslName;
+    return result;
+  }
+
+  TaskHistoryRequest._();
+
+  factory TaskHistoryRequest.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory TaskHistoryRequest.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'TaskHistoryRequest',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  TaskHistoryRequest clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  TaskHistoryRequest copyWith(void Function(TaskHistoryRequest) updates) =>
+      super.copyWith((message) => updates(message as TaskHistoryRequest))
+          as TaskHistoryRequest;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static TaskHistoryRequest create() => TaskHistoryRequest._();
+  @$core.override
+  TaskHistoryRequest createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static TaskHistoryRequest getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<TaskHistoryRequest>(create);
+  static TaskHistoryRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+}
+
+class TaskHistoryEntry extends $pb.GeneratedMessage {
+  factory TaskHistoryEntry({
+    $core.String? wslName,
+    $core.String? type,
+    $core.String? summary,
+    $fixnum.Int64? submitted,
+    $fixnum.Int64? started,
+    $fixnum.Int64? ended,
+    $core.int? attempts,
+    $core.String? result,
+    $core.String? error,
+    $core.String? details,
+    $core.String? id,
+    $core.bool? rebootRequired,
+  }) {
+    final result$ = create();
+    if (wslName != null) result$.wslName = wslName;
+    if (type != null) result$.type = type;
+    if (summary != null) result$.summary = summary;
+    if (submitted != null) result$.submitted = submitted;
+    if (started != null) result$.started = started;
+    if (ended != null) result$.ended = ended;
+    if (attempts != null) result$.attempts = attempts;
+    if (result != null) result$.result = result;
+    if (error != null) result$.error = error;
+    if (details != null) result$.details = details;
+    if (id != null) result$.id = id;
+    if (rebootRequired != null) result$.rebootRequired = rebootRequired;
+    return result$;
+  }
+
+  TaskHistoryEntry._();
+
+  factory TaskHistoryEntry.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory TaskHistoryEntry.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'TaskHistoryEntry',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..aOS(2, _omitFieldNames ? '' : 'type')
+    ..aOS(3, _omitFieldNames ? '' : 'summary')
+    ..aInt64(4, _omitFieldNames ? '' : 'submitted')
+    ..aInt64(5, _omitFieldNames ? '' : 'started')
+    ..aInt64(6, _omitFieldNames ? '' : 'ended')
+    ..a<$core.int>(7, _omitFieldNames ? '' : 'attempts', $pb.PbFieldType.O3)
+    ..aOS(8, _omitFieldNames ? '' : 'result')
+    ..aOS(9, _omitFieldNames ? '' : 'error')
+    ..aOS(10, _omitFieldNames ? '' : 'details')
+    ..aOS(11, _omitFieldNames ? '' : 'id')
+    ..aOB(12, _omitFieldNames ? '' : 'rebootRequired')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  TaskHistoryEntry clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  TaskHistoryEntry copyWith(void Function(TaskHistoryEntry) updates) =>
+      super.copyWith((message) => updates(message as TaskHistoryEntry))
+          as TaskHistoryEntry;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static TaskHistoryEntry create() => TaskHistoryEntry._();
+  @$core.override
+  TaskHistoryEntry createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static TaskHistoryEntry getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<TaskHistoryEntry>(create);
+  static TaskHistoryEntry? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get type => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set type($core.String value) => $_setString(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasType() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearType() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get summary => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set summary($core.String value) => $_setString(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasSummary() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearSummary() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $fixnum.Int64 get submitted => $_getI64(3);
+  @$pb.TagNumber(4)
+  set submitted($fixnum.Int64 value) => $_setInt64(3, value);
+  @$pb.TagNumber(4)
+  $core.bool hasSubmitted() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearSubmitted() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $fixnum.Int64 get started => $_getI64(4);
+  @$pb.TagNumber(5)
+  set started($fixnum.Int64 value) => $_setInt64(4, value);
+  @$pb.TagNumber(5)
+  $core.bool hasStarted() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearStarted() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $fixnum.Int64 get ended => $_getI64(5);
+  @$pb.TagNumber(6)
+  set ended($fixnum.Int64 value) => $_setInt64(5, value);
+  @$pb.TagNumber(6)
+  $core.bool hasEnded() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearEnded() => $_clearField(6);
+
+  @$pb.TagNumber(7)
+  $core.int get attempts => $_getIZ(6);
+  @$pb.TagNumber(7)
+  set attempts($core.int value) => $_setSignedInt32(6, value);
+  @$pb.TagNumber(7)
+  $core.bool hasAttempts() => $_has(6);
+  @$pb.TagNumber(7)
+  void clearAttempts() => $_clearField(7);
+
+  @$pb.TagNumber(8)
+  $core.String get result => $_getSZ(7);
+  @$pb.TagNumber(8)
+  set result($core.String value) => $_setString(7, value);
+  @$pb.TagNumber(8)
+  $core.bool hasResult() => $_has(7);
+  @$pb.TagNumber(8)
+  void clearResult() => $_clearField(8);
+
+  @$pb.TagNumber(9)
+  $core.String get error => $_getSZ(8);
+  @$pb.TagNumber(9)
+  set error($core.String value) => $_setString(8, value);
+  @$pb.TagNumber(9)
+  $core.bool hasError() => $_has(8);
+  @$pb.TagNumber(9)
+  void clearError() => $_clearField(9);
+
+  @$pb.TagNumber(10)
+  $core.String get details => $_getSZ(9);
+  @$pb.TagNumber(10)
+  set details($core.String value) => $_setString(9, value);
+  @$pb.TagNumber(10)
+  $core.bool hasDetails() => $_has(9);
+  @$pb.TagNumber(10)
+  void clearDetails() => $_clearField(10);
+
+  @$pb.TagNumber(11)
+  $core.String get id => $_getSZ(10);
+  @$pb.TagNumber(11)
+  set id($core.String value) => $_setString(10, value);
+  @$pb.TagNumber(11)
+  $core.bool hasId() => $_has(10);
+  @$pb.TagNumber(11)
+  void clearId() => $_clearField(11);
+
+  @$pb.TagNumber(12)
+  $core.bool get rebootRequired => $_getBF(11);
+  @$pb.TagNumber(12)
+  set rebootRequired($core.bool value) => $_setBool(11, value);
+  @$pb.TagNumber(12)
+  $core.bool hasRebootRequired() => $_has(11);
+  @$pb.TagNumber(12)
+  void clearRebootRequired() => $_clearField(12);
+}
+
+class TaskHistory extends $pb.GeneratedMessage {
+  factory TaskHistory({
+    $core.Iterable<TaskHistoryEntry>? entries,
+  }) {
+    final result = create();
+    if (entries != null) result.entries.addAll(entries);
+    return result;
+  }
+
+  TaskHistory._();
+
+  factory TaskHistory.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory TaskHistory.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'TaskHistory',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pc<TaskHistoryEntry>(
+        1, _omitFieldNames ? '' : 'entries', $pb.PbFieldType.PM,
+        subBuilder: TaskHistoryEntry.create)
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  TaskHistory clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  TaskHistory copyWith(void Function(TaskHistory) updates) =>
+      super.copyWith((message) => updates(message as TaskHistory))
+          as TaskHistory;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static TaskHistory create() => TaskHistory._();
+  @$core.override
+  TaskHistory createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static TaskHistory getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<TaskHistory>(create);
+  static TaskHistory? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<TaskHistoryEntry> get entries => $_getList(0);
+}
+
+class QueuedTask extends $pb.GeneratedMessage {
+  factory QueuedTask({
+    $core.String? wslName,
+    $core.String? id,
+    $core.String? type,
+    $core.String? summary,
+    $fixnum.Int64? submitted,
+    $core.bool? running,
+    $core.bool? deferred,
+  }) {
+    final result = create();
+    if (wslName != null) result.wslName = wslName;
+    if (id != null) result.id = id;
+    if (type != null) result.type = type;
+    if (summary != null) result.summary = summary;
+    if (submitted != null) result.submitted = submitted;
+    if (running != null) result.running = running;
+    if (deferred != null) result.deferred = deferred;
+    return result;
+  }
+
+  QueuedTask._();
+
+  factory QueuedTask.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory QueuedTask.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'QueuedTask',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..aOS(2, _omitFieldNames ? '' : 'id')
+    ..aOS(3, _omitFieldNames ? '' : 'type')
+    ..aOS(4, _omitFieldNames ? '' : 'summary')
+    ..aInt64(5, _omitFieldNames ? '' : 'submitted')
+    ..aOB(6, _omitFieldNames ? '' : 'running')
+    ..aOB(7, _omitFieldNames ? '' : 'deferred')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  QueuedTask clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  QueuedTask copyWith(void Function(QueuedTask) updates) =>
+      super.copyWith((message) => updates(message as QueuedTask))
+          as QueuedTask;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static QueuedTask create() => QueuedTask._();
+  @$core.override
+  QueuedTask createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static QueuedTask getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<QueuedTask>(create);
+  static QueuedTask? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get id => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set id($core.String value) => $_setString(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasId() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearId() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get type => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set type($core.String value) => $_setString(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasType() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearType() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.String get summary => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set summary($core.String value) => $_setString(3, value);
+  @$pb.TagNumber(4)
+  $core.bool hasSummary() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearSummary() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $fixnum.Int64 get submitted => $_getI64(4);
+  @$pb.TagNumber(5)
+  set submitted($fixnum.Int64 value) => $_setInt64(4, value);
+  @$pb.TagNumber(5)
+  $core.bool hasSubmitted() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearSubmitted() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $core.bool get running => $_getBF(5);
+  @$pb.TagNumber(6)
+  set running($core.bool value) => $_setBool(5, value);
+  @$pb.TagNumber(6)
+  $core.bool hasRunning() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearRunning() => $_clearField(6);
+
+  @$pb.TagNumber(7)
+  $core.bool get deferred => $_getBF(6);
+  @$pb.TagNumber(7)
+  set deferred($core.bool value) => $_setBool(6, value);
+  @$pb.TagNumber(7)
+  $core.bool hasDeferred() => $_has(6);
+  @$pb.TagNumber(7)
+  void clearDeferred() => $_clearField(7);
+}
+
+class QueuedTasks extends $pb.GeneratedMessage {
+  factory QueuedTasks({
+    $core.Iterable<QueuedTask>? tasks,
+  }) {
+    final result = create();
+    if (tasks != null) result.tasks.addAll(tasks);
+    return result;
+  }
+
+  QueuedTasks._();
+
+  factory QueuedTasks.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory QueuedTasks.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'QueuedTasks',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pc<QueuedTask>(1, _omitFieldNames ? '' : 'tasks', $pb.PbFieldType.PM,
+        subBuilder: QueuedTask.create)
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  QueuedTasks clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  QueuedTasks copyWith(void Function(QueuedTasks) updates) =>
+      super.copyWith((message) => updates(message as QueuedTasks))
+          as QueuedTasks;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static QueuedTasks create() => QueuedTasks._();
+  @$core.override
+  QueuedTasks createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static QueuedTasks getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<QueuedTasks>(create);
+  static QueuedTasks? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<QueuedTask> get tasks => $_getList(0);
+}
+
+class CancelTaskRequest extends $pb.GeneratedMessage {
+  factory CancelTaskRequest({
+    $core.String? wslName,
+    $core.String? id,
+  }) {
+    final result = create();
+    if (wslName != null) result.wslName = wslName;
+    if (id != null) result.id = id;
+    return result;
+  }
+
+  CancelTaskRequest._();
+
+  factory CancelTaskRequest.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory CancelTaskRequest.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'CancelTaskRequest',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..aOS(2, _omitFieldNames ? '' : 'id')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  CancelTaskRequest clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  CancelTaskRequest copyWith(void Function(CancelTaskRequest) updates) =>
+      super.copyWith((message) => updates(message as CancelTaskRequest))
+          as CancelTaskRequest;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static CancelTaskRequest create() => CancelTaskRequest._();
+  @$core.override
+  CancelTaskRequest createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static CancelTaskRequest getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<CancelTaskRequest>(create);
+  static CancelTaskRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get id => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set id($core.String value) => $_setString(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasId() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearId() => $_clearField(2);
+}
+
+class SecurityUpdateRequest extends $pb.GeneratedMessage {
+  factory SecurityUpdateRequest({
+    $core.Iterable<$core.String>? wslNames,
+    $core.Iterable<$core.String>? labels,
+  }) {
+    final result = create();
+    if (wslNames != null) result.wslNames.addAll(wslNames);
+    if (labels != null) result.labels.addAll(labels);
+    return result;
+  }
+
+  SecurityUpdateRequest._();
+
+  factory SecurityUpdateRequest.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory SecurityUpdateRequest.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'SecurityUpdateRequest',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pPS(1, _omitFieldNames ? '' : 'wslNames')
+    ..pPS(2, _omitFieldNames ? '' : 'labels')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  SecurityUpdateRequest clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  SecurityUpdateRequest copyWith(
+          void Function(SecurityUpdateRequest) updates) =>
+      super.copyWith((message) => updates(message as SecurityUpdateRequest))
+          as SecurityUpdateRequest;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static SecurityUpdateRequest create() => SecurityUpdateRequest._();
+  @$core.override
+  SecurityUpdateRequest createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static SecurityUpdateRequest getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<SecurityUpdateRequest>(create);
+  static SecurityUpdateRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<$core.String> get wslNames => $_getList(0);
+
+  @$pb.TagNumber(2)
+  $pb.PbList<$core.String> get labels => $_getList(1);
+}
+
+class RunCommandRequest extends $pb.GeneratedMessage {
+  factory RunCommandRequest({
+    $core.Iterable<$core.String>? wslNames,
+    $core.Iterable<$core.String>? argv,
+    $core.Iterable<$core.String>? env,
+    $core.String? workingDir,
+    $fixnum.Int64? timeoutSeconds,
+    $core.String? user,
+    $core.Iterable<$core.String>? labels,
+  }) {
+    final result = create();
+    if (wslNames != null) result.wslNames.addAll(wslNames);
+    if (argv != null) result.argv.addAll(argv);
+    if (env != null) result.env.addAll(env);
+    if (workingDir != null) result.workingDir = workingDir;
+    if (timeoutSeconds != null) result.timeoutSeconds = timeoutSeconds;
+    if (user != null) result.user = user;
+    if (labels != null) result.labels.addAll(labels);
+    return result;
+  }
+
+  RunCommandRequest._();
+
+  factory RunCommandRequest.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory RunCommandRequest.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'RunCommandRequest',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pPS(1, _omitFieldNames ? '' : 'wslNames')
+    ..pPS(2, _omitFieldNames ? '' : 'argv')
+    ..pPS(3, _omitFieldNames ? '' : 'env')
+    ..aOS(4, _omitFieldNames ? '' : 'workingDir')
+    ..aInt64(5, _omitFieldNames ? '' : 'timeoutSeconds')
+    ..aOS(6, _omitFieldNames ? '' : 'user')
+    ..pPS(7, _omitFieldNames ? '' : 'labels')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  RunCommandRequest clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  RunCommandRequest copyWith(void Function(RunCommandRequest) updates) =>
+      super.copyWith((message) => updates(message as RunCommandRequest))
+          as RunCommandRequest;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static RunCommandRequest create() => RunCommandRequest._();
+  @$core.override
+  RunCommandRequest createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static RunCommandRequest getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<RunCommandRequest>(create);
+  static RunCommandRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<$core.String> get wslNames => $_getList(0);
+
+  @$pb.TagNumber(2)
+  $pb.PbList<$core.String> get argv => $_getList(1);
+
+  @$pb.TagNumber(3)
+  $pb.PbList<$core.String> get env => $_getList(2);
+
+  @$pb.TagNumber(4)
+  $core.String get workingDir => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set workingDir($core.String value) => $_setString(3, value);
+  @$pb.TagNumber(4)
+  $core.bool hasWorkingDir() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearWorkingDir() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $fixnum.Int64 get timeoutSeconds => $_getI64(4);
+  @$pb.TagNumber(5)
+  set timeoutSeconds($fixnum.Int64 value) => $_setInt64(4, value);
+  @$pb.TagNumber(5)
+  $core.bool hasTimeoutSeconds() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearTimeoutSeconds() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $core.String get user => $_getSZ(5);
+  @$pb.TagNumber(6)
+  set user($core.String value) => $_setString(5, value);
+  @$pb.TagNumber(6)
+  $core.bool hasUser() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearUser() => $_clearField(6);
+
+  @$pb.TagNumber(7)
+  $pb.PbList<$core.String> get labels => $_getList(6);
+}
+
+class InventoryRequest extends $pb.GeneratedMessage {
+  factory InventoryRequest({
+    $core.String? wslName,
+    $core.String? format,
+    $core.bool? diff,
+  }) {
+    final result = create();
+    if (wslName != null) result.wslName = wslName;
+    if (format != null) result.format = format;
+    if (diff != null) result.diff = diff;
+    return result;
+  }
+
+  InventoryRequest._();
+
+  factory InventoryRequest.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory InventoryRequest.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'InventoryRequest',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..aOS(2, _omitFieldNames ? '' : 'format')
+    ..aOB(3, _omitFieldNames ? '' : 'diff')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  InventoryRequest clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  InventoryRequest copyWith(void Function(InventoryRequest) updates) =>
+      super.copyWith((message) => updates(message as InventoryRequest))
+          as InventoryRequest;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static InventoryRequest create() => InventoryRequest._();
+  @$core.override
+  InventoryRequest createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static InventoryRequest getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<InventoryRequest>(create);
+  static InventoryRequest? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get format => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set format($core.String value) => $_setString(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasFormat() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearFormat() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.bool get diff => $_getBF(2);
+  @$pb.TagNumber(3)
+  set diff($core.bool value) => $_setBool(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasDiff() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearDiff() => $_clearField(3);
+}
+
+class InventoryExport extends $pb.GeneratedMessage {
+  factory InventoryExport({
+    $core.List<$core.int>? data,
+  }) {
+    final result = create();
+    if (data != null) result.data = data;
+    return result;
+  }
+
+  InventoryExport._();
+
+  factory InventoryExport.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory InventoryExport.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'InventoryExport',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..a<$core.List<$core.int>>(
+        1,
+        _omitFieldNames ? '' : 'data',
+        $pb.PbFieldType.OY)
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  InventoryExport clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  InventoryExport copyWith(void Function(InventoryExport) updates) =>
+      super.copyWith((message) => updates(message as InventoryExport))
+          as InventoryExport;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static InventoryExport create() => InventoryExport._();
+  @$core.override
+  InventoryExport createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static InventoryExport getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<InventoryExport>(create);
+  static InventoryExport? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.List<$core.int> get data => $_getN(0);
+  @$pb.TagNumber(1)
+  set data($core.List<$core.int> value) => $_setBytes(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasData() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearData() => $_clearField(1);
+}
+
+class StartMetrics extends $pb.GeneratedMessage {
+  factory StartMetrics({
+    $fixnum.Int64? parallelism,
+    $fixnum.Int64? running,
+    $fixnum.Int64? queuedInteractive,
+    $fixnum.Int64? queuedBackground,
+    $fixnum.Int64? maxQueued,
+    $fixnum.Int64? admitted,
+    $fixnum.Int64? timedOut,
+  }) {
+    final result = create();
+    if (parallelism != null) result.parallelism = parallelism;
+    if (running != null) result.running = running;
+    if (queuedInteractive != null) result.queuedInteractive = queuedInteractive;
+    if (queuedBackground != null) result.queuedBackground = queuedBackground;
+    if (maxQueued != null) result.maxQueued = maxQueued;
+    if (admitted != null) result.admitted = admitted;
+    if (timedOut != null) result.timedOut = timedOut;
+    return result;
+  }
+
+  StartMetrics._();
+
+  factory StartMetrics.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory StartMetrics.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'StartMetrics',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aInt64(1, _omitFieldNames ? '' : 'parallelism')
+    ..aInt64(2, _omitFieldNames ? '' : 'running')
+    ..aInt64(3, _omitFieldNames ? '' : 'queuedInteractive')
+    ..aInt64(4, _omitFieldNames ? '' : 'queuedBackground')
+    ..aInt64(5, _omitFieldNames ? '' : 'maxQueued')
+    ..aInt64(6, _omitFieldNames ? '' : 'admitted')
+    ..aInt64(7, _omitFieldNames ? '' : 'timedOut')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  StartMetrics clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  StartMetrics copyWith(void Function(StartMetrics) updates) =>
+      super.copyWith((message) => updates(message as StartMetrics))
+          as StartMetrics;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static StartMetrics create() => StartMetrics._();
+  @$core.override
+  StartMetrics createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static StartMetrics getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<StartMetrics>(create);
+  static StartMetrics? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $fixnum.Int64 get parallelism => $_getI64(0);
+  @$pb.TagNumber(1)
+  set parallelism($fixnum.Int64 value) => $_setInt64(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasParallelism() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearParallelism() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $fixnum.Int64 get running => $_getI64(1);
+  @$pb.TagNumber(2)
+  set running($fixnum.Int64 value) => $_setInt64(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasRunning() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearRunning() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $fixnum.Int64 get queuedInteractive => $_getI64(2);
+  @$pb.TagNumber(3)
+  set queuedInteractive($fixnum.Int64 value) => $_setInt64(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasQueuedInteractive() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearQueuedInteractive() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $fixnum.Int64 get queuedBackground => $_getI64(3);
+  @$pb.TagNumber(4)
+  set queuedBackground($fixnum.Int64 value) => $_setInt64(3, value);
+  @$pb.TagNumber(4)
+  $core.bool hasQueuedBackground() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearQueuedBackground() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $fixnum.Int64 get maxQueued => $_getI64(4);
+  @$pb.TagNumber(5)
+  set maxQueued($fixnum.Int64 value) => $_setInt64(4, value);
+  @$pb.TagNumber(5)
+  $core.bool hasMaxQueued() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearMaxQueued() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $fixnum.Int64 get admitted => $_getI64(5);
+  @$pb.TagNumber(6)
+  set admitted($fixnum.Int64 value) => $_setInt64(5, value);
+  @$pb.TagNumber(6)
+  $core.bool hasAdmitted() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearAdmitted() => $_clearField(6);
+
+  @$pb.TagNumber(7)
+  $fixnum.Int64 get timedOut => $_getI64(6);
+  @$pb.TagNumber(7)
+  set timedOut($fixnum.Int64 value) => $_setInt64(6, value);
+  @$pb.TagNumber(7)
+  $core.bool hasTimedOut() => $_has(6);
+  @$pb.TagNumber(7)
+  void clearTimedOut() => $_clearField(7);
+}
+
+class DistroInfo extends $pb.GeneratedMessage {
+  factory DistroInfo({
+    $core.String? wslName,
+    $core.String? id,
+    $core.String? versionId,
+    $core.String? prettyName,
+    $core.bool? proAttached,
+    $core.String? hostname,
+    $core.String? serviceVersion,
+    $core.Iterable<$core.String>? labels,
+    $core.Iterable<$core.String>? capabilities,
+  }) {
+    final result = create();
+    if (wslName != null) result.wslName = wslName;
+    if (id != null) result.id = id;
+    if (versionId != null) result.versionId = versionId;
+    if (prettyName != null) result.prettyName = prettyName;
+    if (proAttached != null) result.proAttached = proAttached;
+    if (hostname != null) result.hostname = hostname;
+    if (serviceVersion != null) result.serviceVersion = serviceVersion;
+    if (labels != null) result.labels.addAll(labels);
+    if (capabilities != null) result.capabilities.addAll(capabilities);
+    return result;
+  }
+
+  DistroInfo._();
+
+  factory DistroInfo.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory DistroInfo.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'DistroInfo',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'wslName')
+    ..aOS(2, _omitFieldNames ? '' : 'id')
+    ..aOS(3, _omitFieldNames ? '' : 'versionId')
+    ..aOS(4, _omitFieldNames ? '' : 'prettyName')
+    ..aOB(5, _omitFieldNames ? '' : 'proAttached')
+    ..aOS(6, _omitFieldNames ? '' : 'hostname')
+    ..aOS(7, _omitFieldNames ? '' : 'serviceVersion')
+    ..pPS(8, _omitFieldNames ? '' : 'labels')
+    ..pPS(9, _omitFieldNames ? '' : 'capabilities')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DistroInfo clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  DistroInfo copyWith(void Function(DistroInfo) updates) =>
+      super.copyWith((message) => updates(message as DistroInfo)) as DistroInfo;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static DistroInfo create() => DistroInfo._();
+  @$core.override
+  DistroInfo createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static DistroInfo getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<DistroInfo>(create);
+  static DistroInfo? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get wslName => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set wslName($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasWslName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearWslName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get id => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set id($core.String value) => $_setString(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasId() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearId() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get versionId => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set versionId($core.String value) => $_setString(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasVersionId() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearVersionId() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.String get prettyName => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set prettyName($core.String value) => $_setString(3, value);
+  @$pb.TagNumber(4)
+  $core.bool hasPrettyName() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearPrettyName() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.bool get proAttached => $_getBF(4);
+  @$pb.TagNumber(5)
+  set proAttached($core.bool value) => $_setBool(4, value);
+  @$pb.TagNumber(5)
+  $core.bool hasProAttached() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearProAttached() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $core.String get hostname => $_getSZ(5);
+  @$pb.TagNumber(6)
+  set hostname($core.String value) => $_setString(5, value);
+  @$pb.TagNumber(6)
+  $core.bool hasHostname() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearHostname() => $_clearField(6);
+
+  @$pb.TagNumber(7)
+  $core.String get serviceVersion => $_getSZ(6);
+  @$pb.TagNumber(7)
+  set serviceVersion($core.String value) => $_setString(6, value);
+  @$pb.TagNumber(7)
+  $core.bool hasServiceVersion() => $_has(6);
+  @$pb.TagNumber(7)
+  void clearServiceVersion() => $_clearField(7);
+
+  @$pb.TagNumber(8)
+  $pb.PbList<$core.String> get labels => $_getList(7);
+
+  @$pb.TagNumber(9)
+  $pb.PbList<$core.String> get capabilities => $_getList(8);
+}
+
+class ProAttachCmd extends $pb.GeneratedMessage {
+  factory ProAttachCmd({
+    $core.String? token,
+  }) {
+    final result = create();
+    if (token != null) result.token = token;
+    return result;
+  }
+
+  ProAttachCmd._();
+
+  factory ProAttachCmd.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory ProAttachCmd.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'ProAttachCmd',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'token')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  ProAttachCmd clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  ProAttachCmd copyWith(void Function(ProAttachCmd) updates) =>
+      super.copyWith((message) => updates(message as ProAttachCmd))
+          as ProAttachCmd;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ProAttachCmd create() => ProAttachCmd._();
+  @$core.override
+  ProAttachCmd createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static ProAttachCmd getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<ProAttachCmd>(create);
+  static ProAttachCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get token => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set token($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasToken() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearToken() => $_clearField(1);
+}
+
+class LandscapeConfigCmd extends $pb.GeneratedMessage {
+  factory LandscapeConfigCmd({
+    $core.String? config,
+  }) {
+    final result = create();
+    if (config != null) result.config = config;
+    return result;
+  }
+
+  LandscapeConfigCmd._();
+
+  factory LandscapeConfigCmd.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory LandscapeConfigCmd.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'LandscapeConfigCmd',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'config')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  LandscapeConfigCmd clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  LandscapeConfigCmd copyWith(void Function(LandscapeConfigCmd) updates) =>
+      super.copyWith((message) => updates(message as LandscapeConfigCmd))
+          as LandscapeConfigCmd;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static LandscapeConfigCmd create() => LandscapeConfigCmd._();
+  @$core.override
+  LandscapeConfigCmd createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static LandscapeConfigCmd getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<LandscapeConfigCmd>(create);
+  static LandscapeConfigCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get config => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set config($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasConfig() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearConfig() => $_clearField(1);
+}
+
+class ProxyConfigCmd extends $pb.GeneratedMessage {
+  factory ProxyConfigCmd({
+    $core.String? httpProxy,
+    $core.String? httpsProxy,
+    $core.String? noProxy,
+  }) {
+    final result = create();
+    if (httpProxy != null) result.httpProxy = httpProxy;
+    if (httpsProxy != null) result.httpsProxy = httpsProxy;
+    if (noProxy != null) result.noProxy = noProxy;
+    return result;
+  }
+
+  ProxyConfigCmd._();
+
+  factory ProxyConfigCmd.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory ProxyConfigCmd.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'ProxyConfigCmd',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'httpProxy')
+    ..aOS(2, _omitFieldNames ? '' : 'httpsProxy')
+    ..aOS(3, _omitFieldNames ? '' : 'noProxy')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  ProxyConfigCmd clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  ProxyConfigCmd copyWith(void Function(ProxyConfigCmd) updates) =>
+      super.copyWith((message) => updates(message as ProxyConfigCmd))
+          as ProxyConfigCmd;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ProxyConfigCmd create() => ProxyConfigCmd._();
+  @$core.override
+  ProxyConfigCmd createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static ProxyConfigCmd getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<ProxyConfigCmd>(create);
+  static ProxyConfigCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get httpProxy => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set httpProxy($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasHttpProxy() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearHttpProxy() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get httpsProxy => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set httpsProxy($core.String value) => $_setString(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasHttpsProxy() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearHttpsProxy() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get noProxy => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set noProxy($core.String value) => $_setString(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasNoProxy() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearNoProxy() => $_clearField(3);
+}
+
+class RunCommandCmd extends $pb.GeneratedMessage {
+  factory RunCommandCmd({
+    $core.Iterable<$core.String>? argv,
+    $core.Iterable<$core.String>? env,
+    $core.String? workingDir,
+    $fixnum.Int64? timeoutSeconds,
+    $core.String? user,
+    $core.List<$core.int>? stdin,
+  }) {
+    final result = create();
+    if (argv != null) result.argv.addAll(argv);
+    if (env != null) result.env.addAll(env);
+    if (workingDir != null) result.workingDir = workingDir;
+    if (timeoutSeconds != null) result.timeoutSeconds = timeoutSeconds;
+    if (user != null) result.user = user;
+    if (stdin != null) result.stdin = stdin;
+    return result;
+  }
+
+  RunCommandCmd._();
+
+  factory RunCommandCmd.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory RunCommandCmd.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'RunCommandCmd',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pPS(1, _omitFieldNames ? '' : 'argv')
+    ..pPS(2, _omitFieldNames ? '' : 'env')
+    ..aOS(3, _omitFieldNames ? '' : 'workingDir')
+    ..aInt64(4, _omitFieldNames ? '' : 'timeoutSeconds')
+    ..aOS(5, _omitFieldNames ? '' : 'user')
+    ..a<$core.List<$core.int>>(
+        6,
+        _omitFieldNames ? '' : 'stdin',
+        $pb.PbFieldType.OY)
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  RunCommandCmd clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  RunCommandCmd copyWith(void Function(RunCommandCmd) updates) =>
+      super.copyWith((message) => updates(message as RunCommandCmd))
+          as RunCommandCmd;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static RunCommandCmd create() => RunCommandCmd._();
+  @$core.override
+  RunCommandCmd createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static RunCommandCmd getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<RunCommandCmd>(create);
+  static RunCommandCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<$core.String> get argv => $_getList(0);
+
+  @$pb.TagNumber(2)
+  $pb.PbList<$core.String> get env => $_getList(1);
+
+  @$pb.TagNumber(3)
+  $core.String get workingDir => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set workingDir($core.String value) => $_setString(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasWorkingDir() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearWorkingDir() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $fixnum.Int64 get timeoutSeconds => $_getI64(3);
+  @$pb.TagNumber(4)
+  set timeoutSeconds($fixnum.Int64 value) => $_setInt64(3, value);
+  @$pb.TagNumber(4)
+  $core.bool hasTimeoutSeconds() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearTimeoutSeconds() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.String get user => $_getSZ(4);
+  @$pb.TagNumber(5)
+  set user($core.String value) => $_setString(4, value);
+  @$pb.TagNumber(5)
+  $core.bool hasUser() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearUser() => $_clearField(5);
+
+  @$pb.TagNumber(6)
+  $core.List<$core.int> get stdin => $_getN(5);
+  @$pb.TagNumber(6)
+  set stdin($core.List<$core.int> value) => $_setBytes(5, value);
+  @$pb.TagNumber(6)
+  $core.bool hasStdin() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearStdin() => $_clearField(6);
+}
+
+class CommandOutput extends $pb.GeneratedMessage {
+  factory CommandOutput({
+    $core.int? exitCode,
+    $core.List<$core.int>? stdout,
+    $core.List<$core.int>? stderr,
+    $core.bool? truncated,
+  }) {
+    final result = create();
+    if (exitCode != null) result.exitCode = exitCode;
+    if (stdout != null) result.stdout = stdout;
+    if (stderr != null) result.stderr = stderr;
+    if (truncated != null) result.truncated = truncated;
+    return result;
+  }
+
+  CommandOutput._();
+
+  factory CommandOutput.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory CommandOutput.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'CommandOutput',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..a<$core.int>(1, _omitFieldNames ? '' : 'exitCode', $pb.PbFieldType.O3)
+    ..a<$core.List<$core.int>>(
+        2,
+        _omitFieldNames ? '' : 'stdout',
+        $pb.PbFieldType.OY)
+    ..a<$core.List<$core.int>>(
+        3,
+        _omitFieldNames ? '' : 'stderr',
+        $pb.PbFieldType.OY)
+    ..aOB(4, _omitFieldNames ? '' : 'truncated')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  CommandOutput clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  CommandOutput copyWith(void Function(CommandOutput) updates) =>
+      super.copyWith((message) => updates(message as CommandOutput))
+          as CommandOutput;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static CommandOutput create() => CommandOutput._();
+  @$core.override
+  CommandOutput createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static CommandOutput getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<CommandOutput>(create);
+  static CommandOutput? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.int get exitCode => $_getIZ(0);
+  @$pb.TagNumber(1)
+  set exitCode($core.int value) => $_setSignedInt32(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasExitCode() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearExitCode() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.List<$core.int> get stdout => $_getN(1);
+  @$pb.TagNumber(2)
+  set stdout($core.List<$core.int> value) => $_setBytes(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasStdout() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearStdout() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.List<$core.int> get stderr => $_getN(2);
+  @$pb.TagNumber(3)
+  set stderr($core.List<$core.int> value) => $_setBytes(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasStderr() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearStderr() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.bool get truncated => $_getBF(3);
+  @$pb.TagNumber(4)
+  set truncated($core.bool value) => $_setBool(3, value);
+  @$pb.TagNumber(4)
+  $core.bool hasTruncated() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearTruncated() => $_clearField(4);
+}
+
+class ProServicesCmd extends $pb.GeneratedMessage {
+  factory ProServicesCmd({
+    $core.Iterable<$core.String>? enable,
+    $core.Iterable<$core.String>? disable,
+  }) {
+    final result = create();
+    if (enable != null) result.enable.addAll(enable);
+    if (disable != null) result.disable.addAll(disable);
+    return result;
+  }
+
+  ProServicesCmd._();
+
+  factory ProServicesCmd.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory ProServicesCmd.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'ProServicesCmd',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pPS(1, _omitFieldNames ? '' : 'enable')
+    ..pPS(2, _omitFieldNames ? '' : 'disable')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  ProServicesCmd clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  ProServicesCmd copyWith(void Function(ProServicesCmd) updates) =>
+      super.copyWith((message) => updates(message as ProServicesCmd))
+          as ProServicesCmd;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ProServicesCmd create() => ProServicesCmd._();
+  @$core.override
+  ProServicesCmd createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static ProServicesCmd getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<ProServicesCmd>(create);
+  static ProServicesCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<$core.String> get enable => $_getList(0);
+
+  @$pb.TagNumber(2)
+  $pb.PbList<$core.String> get disable => $_getList(1);
+}
+
+class ProServicesResult extends $pb.GeneratedMessage {
+  factory ProServicesResult({
+    $core.Iterable<ProServiceResult>? services,
+  }) {
+    final result = create();
+    if (services != null) result.services.addAll(services);
+    return result;
+  }
+
+  ProServicesResult._();
+
+  factory ProServicesResult.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory ProServicesResult.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'ProServicesResult',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pc<ProServiceResult>(
+        1, _omitFieldNames ? '' : 'services', $pb.PbFieldType.PM,
+        subBuilder: ProServiceResult.create)
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  ProServicesResult clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  ProServicesResult copyWith(void Function(ProServicesResult) updates) =>
+      super.copyWith((message) => updates(message as ProServicesResult))
+          as ProServicesResult;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ProServicesResult create() => ProServicesResult._();
+  @$core.override
+  ProServicesResult createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static ProServicesResult getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<ProServicesResult>(create);
+  static ProServicesResult? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<ProServiceResult> get services => $_getList(0);
+}
+
+class ProServiceResult extends $pb.GeneratedMessage {
+  factory ProServiceResult({
+    $core.String? name,
+    $core.bool? enable,
+    $core.String? error,
+  }) {
+    final result = create();
+    if (name != null) result.name = name;
+    if (enable != null) result.enable = enable;
+    if (error != null) result.error = error;
+    return result;
+  }
+
+  ProServiceResult._();
+
+  factory ProServiceResult.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory ProServiceResult.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'ProServiceResult',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'name')
+    ..aOB(2, _omitFieldNames ? '' : 'enable')
+    ..aOS(3, _omitFieldNames ? '' : 'error')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  ProServiceResult clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  ProServiceResult copyWith(void Function(ProServiceResult) updates) =>
+      super.copyWith((message) => updates(message as ProServiceResult))
+          as ProServiceResult;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static ProServiceResult create() => ProServiceResult._();
+  @$core.override
+  ProServiceResult createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static ProServiceResult getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<ProServiceResult>(create);
+  static ProServiceResult? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get name => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set name($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.bool get enable => $_getBF(1);
+  @$pb.TagNumber(2)
+  set enable($core.bool value) => $_setBool(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasEnable() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearEnable() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get error => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set error($core.String value) => $_setString(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasError() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearError() => $_clearField(3);
+}
+
+class SecurityUpdateCmd extends $pb.GeneratedMessage {
+  factory SecurityUpdateCmd() => create();
+
+  SecurityUpdateCmd._();
+
+  factory SecurityUpdateCmd.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory SecurityUpdateCmd.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'SecurityUpdateCmd',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  SecurityUpdateCmd clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  SecurityUpdateCmd copyWith(void Function(SecurityUpdateCmd) updates) =>
+      super.copyWith((message) => updates(message as SecurityUpdateCmd))
+          as SecurityUpdateCmd;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static SecurityUpdateCmd create() => SecurityUpdateCmd._();
+  @$core.override
+  SecurityUpdateCmd createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static SecurityUpdateCmd getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<SecurityUpdateCmd>(create);
+  static SecurityUpdateCmd? _defaultInstance;
+}
+
+class SecurityUpdateResult extends $pb.GeneratedMessage {
+  factory SecurityUpdateResult({
+    $core.Iterable<UpgradedPackage>? packages,
+    $core.bool? rebootRequired,
+  }) {
+    final result = create();
+    if (packages != null) result.packages.addAll(packages);
+    if (rebootRequired != null) result.rebootRequired = rebootRequired;
+    return result;
+  }
+
+  SecurityUpdateResult._();
+
+  factory SecurityUpdateResult.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory SecurityUpdateResult.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'SecurityUpdateResult',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..pc<UpgradedPackage>(
+        1, _omitFieldNames ? '' : 'packages', $pb.PbFieldType.PM,
+        subBuilder: UpgradedPackage.create)
+    ..aOB(2, _omitFieldNames ? '' : 'rebootRequired')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  SecurityUpdateResult clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  SecurityUpdateResult copyWith(void Function(SecurityUpdateResult) updates) =>
+      super.copyWith((message) => updates(message as SecurityUpdateResult))
+          as SecurityUpdateResult;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static SecurityUpdateResult create() => SecurityUpdateResult._();
+  @$core.override
+  SecurityUpdateResult createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static SecurityUpdateResult getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<SecurityUpdateResult>(create);
+  static SecurityUpdateResult? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $pb.PbList<UpgradedPackage> get packages => $_getList(0);
+
+  @$pb.TagNumber(2)
+  $core.bool get rebootRequired => $_getBF(1);
+  @$pb.TagNumber(2)
+  set rebootRequired($core.bool value) => $_setBool(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasRebootRequired() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearRebootRequired() => $_clearField(2);
+}
+
+class UpgradedPackage extends $pb.GeneratedMessage {
+  factory UpgradedPackage({
+    $core.String? name,
+    $core.String? oldVersion,
+    $core.String? newVersion,
   }) {
     final result = create();
-    if (wslName != null) result.wslName = wslName;
-    if (id != null) result.id = id;
-    if (versionId != null) result.versionId = versionId;
-    if (prettyName != null) result.prettyName = prettyName;
-    if (proAttached != null) result.proAttached = proAttached;
-    if (hostname != null) result.hostname = hostname;
+    if (name != null) result.name = name;
+    if (oldVersion != null) result.oldVersion = oldVersion;
+    if (newVersion != null) result.newVersion = newVersion;
     return result;
   }
 
-  DistroInfo._();
+  UpgradedPackage._();
 
-  factory DistroInfo.fromBuffer($core.List<$core.int> data,
+  factory UpgradedPackage.fromBuffer($core.List<$core.int> data,
           [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
       create()..mergeFromBuffer(data, registry);
-  factory DistroInfo.fromJson($core.String json,
+  factory UpgradedPackage.fromJson($core.String json,
           [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
       create()..mergeFromJson(json, registry);
 
   static final $pb.BuilderInfo _i = $pb.BuilderInfo(
-      _omitMessageNames ? '' : 'DistroInfo',
+      _omitMessageNames ? '' : 'UpgradedPackage',
       package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
       createEmptyInstance: create)
-    ..aOS(1, _omitFieldNames ? '' : 'wslName')
-    ..aOS(2, _omitFieldNames ? '' : 'id')
-    ..aOS(3, _omitFieldNames ? '' : 'versionId')
-    ..aOS(4, _omitFieldNames ? '' : 'prettyName')
-    ..aOB(5, _omitFieldNames ? '' : 'proAttached')
-    ..aOS(6, _omitFieldNames ? '' : 'hostname')
+    ..aOS(1, _omitFieldNames ? '' : 'name')
+    ..aOS(2, _omitFieldNames ? '' : 'oldVersion')
+    ..aOS(3, _omitFieldNames ? '' : 'newVersion')
     ..hasRequiredFields = false;
 
   @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
-  DistroInfo clone() => deepCopy();
+  UpgradedPackage clone() => deepCopy();
   @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
-  DistroInfo copyWith(void Function(DistroInfo) updates) =>
-      super.copyWith((message) => updates(message as DistroInfo)) as DistroInfo;
+  UpgradedPackage copyWith(void Function(UpgradedPackage) updates) =>
+      super.copyWith((message) => updates(message as UpgradedPackage))
+          as UpgradedPackage;
 
   @$core.override
   $pb.BuilderInfo get info_ => _i;
 
   @$core.pragma('dart2js:noInline')
-  static DistroInfo create() => DistroInfo._();
+  static UpgradedPackage create() => UpgradedPackage._();
   @$core.override
-  DistroInfo createEmptyInstance() => create();
+  UpgradedPackage createEmptyInstance() => create();
   @$core.pragma('dart2js:noInline')
-  static DistroInfo getDefault() => _defaultInstance ??=
-      $pb.GeneratedMessage.$_defaultFor<DistroInfo>(create);
-  static DistroInfo? _defaultInstance;
+  static UpgradedPackage getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<UpgradedPackage>(create);
+  static UpgradedPackage? _defaultInstance;
 
   @$pb.TagNumber(1)
-  $core.String get wslName => $_getSZ(0);
+  $core.String get name => $_getSZ(0);
   @$pb.TagNumber(1)
-  set wslName($core.String value) => $_setString(0, value);
+  set name($core.String value) => $_setString(0, value);
   @$pb.TagNumber(1)
-  $core.bool hasWslName() => $_has(0);
+  $core.bool hasName() => $_has(0);
   @$pb.TagNumber(1)
-  void clearWslName() => $_clearField(1);
+  void clearName() => $_clearField(1);
 
   @$pb.TagNumber(2)
-  $core.String get id => $_getSZ(1);
+  $core.String get oldVersion => $_getSZ(1);
   @$pb.TagNumber(2)
-  set id($core.String value) => $_setString(1, value);
+  set oldVersion($core.String value) => $_setString(1, value);
   @$pb.TagNumber(2)
-  $core.bool hasId() => $_has(1);
+  $core.bool hasOldVersion() => $_has(1);
   @$pb.TagNumber(2)
-  void clearId() => $_clearField(2);
+  void clearOldVersion() => $_clearField(2);
 
   @$pb.TagNumber(3)
-  $core.String get versionId => $_getSZ(2);
+  $core.String get newVersion => $_getSZ(2);
   @$pb.TagNumber(3)
-  set versionId($core.String value) => $_setString(2, value);
+  set newVersion($core.String value) => $_setString(2, value);
   @$pb.TagNumber(3)
-  $core.bool hasVersionId() => $_has(2);
+  $core.bool hasNewVersion() => $_has(2);
   @$pb.TagNumber(3)
-  void clearVersionId() => $_clearField(3);
+  void clearNewVersion() => $_clearField(3);
+}
 
-  @$pb.TagNumber(4)
-  $core.String get prettyName => $_getSZ(3);
-  @$pb.TagNumber(4)
-  set prettyName($core.String value) => $_setString(3, value);
-  @$pb.TagNumber(4)
-  $core.bool hasPrettyName() => $_has(3);
-  @$pb.TagNumber(4)
-  void clearPrettyName() => $_clearField(4);
+class PackageInventoryCmd extends $pb.GeneratedMessage {
+  factory PackageInventoryCmd() => create();
 
-  @$pb.TagNumber(5)
-  $core.bool get proAttached => $_getBF(4);
-  @$pb.TagNumber(5)
-  set proAttached($core.bool value) => $_setBool(4, value);
-  @$pb.TagNumber(5)
-  $core.bool hasProAttached() => $_has(4);
-  @$pb.TagNumber(5)
-  void clearProAttached() => $_clearField(5);
+  PackageInventoryCmd._();
 
-  @$pb.TagNumber(6)
-  $core.String get hostname => $_getSZ(5);
-  @$pb.TagNumber(6)
-  set hostname($core.String value) => $_setString(5, value);
-  @$pb.TagNumber(6)
-  $core.bool hasHostname() => $_has(5);
-  @$pb.TagNumber(6)
-  void clearHostname() => $_clearField(6);
+  factory PackageInventoryCmd.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory PackageInventoryCmd.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'PackageInventoryCmd',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  PackageInventoryCmd clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  PackageInventoryCmd copyWith(void Function(PackageInventoryCmd) updates) =>
+      super.copyWith((message) => updates(message as PackageInventoryCmd))
+          as PackageInventoryCmd;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static PackageInventoryCmd create() => PackageInventoryCmd._();
+  @$core.override
+  PackageInventoryCmd createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static PackageInventoryCmd getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<PackageInventoryCmd>(create);
+  static PackageInventoryCmd? _defaultInstance;
 }
 
-class ProAttachCmd extends $pb.GeneratedMessage {
-  factory ProAttachCmd({
-    $core.String? token,
+class PackageInventory extends $pb.GeneratedMessage {
+  factory PackageInventory({
+    $core.List<$core.int>? packages,
   }) {
     final result = create();
-    if (token != null) result.token = token;
+    if (packages != null) result.packages = packages;
     return result;
   }
 
-  ProAttachCmd._();
+  PackageInventory._();
 
-  factory ProAttachCmd.fromBuffer($core.List<$core.int> data,
+  factory PackageInventory.fromBuffer($core.List<$core.int> data,
           [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
       create()..mergeFromBuffer(data, registry);
-  factory ProAttachCmd.fromJson($core.String json,
+  factory PackageInventory.fromJson($core.String json,
           [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
       create()..mergeFromJson(json, registry);
 
   static final $pb.BuilderInfo _i = $pb.BuilderInfo(
-      _omitMessageNames ? '' : 'ProAttachCmd',
+      _omitMessageNames ? '' : 'PackageInventory',
       package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
       createEmptyInstance: create)
-    ..aOS(1, _omitFieldNames ? '' : 'token')
+    ..a<$core.List<$core.int>>(
+        1,
+        _omitFieldNames ? '' : 'packages',
+        $pb.PbFieldType.OY)
     ..hasRequiredFields = false;
 
   @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
-  ProAttachCmd clone() => deepCopy();
+  PackageInventory clone() => deepCopy();
   @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
-  ProAttachCmd copyWith(void Function(ProAttachCmd) updates) =>
-      super.copyWith((message) => updates(message as ProAttachCmd))
-          as ProAttachCmd;
+  PackageInventory copyWith(void Function(PackageInventory) updates) =>
+      super.copyWith((message) => updates(message as PackageInventory))
+          as PackageInventory;
 
   @$core.override
   $pb.BuilderInfo get info_ => _i;
 
   @$core.pragma('dart2js:noInline')
-  static ProAttachCmd create() => ProAttachCmd._();
+  static PackageInventory create() => PackageInventory._();
   @$core.override
-  ProAttachCmd createEmptyInstance() => create();
+  PackageInventory createEmptyInstance() => create();
   @$core.pragma('dart2js:noInline')
-  static ProAttachCmd getDefault() => _defaultInstance ??=
-      $pb.GeneratedMessage.$_defaultFor<ProAttachCmd>(create);
-  static ProAttachCmd? _defaultInstance;
+  static PackageInventory getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<PackageInventory>(create);
+  static PackageInventory? _defaultInstance;
 
   @$pb.TagNumber(1)
-  $core.String get token => $_getSZ(0);
+  $core.List<$core.int> get packages => $_getN(0);
   @$pb.TagNumber(1)
-  set token($core.String value) => $_setString(0, value);
+  set packages($core.List<$core.int> value) => $_setBytes(0, value);
   @$pb.TagNumber(1)
-  $core.bool hasToken() => $_has(0);
+  $core.bool hasPackages() => $_has(0);
   @$pb.TagNumber(1)
-  void clearToken() => $_clearField(1);
+  void clearPackages() => $_clearField(1);
 }
 
-class LandscapeConfigCmd extends $pb.GeneratedMessage {
-  factory LandscapeConfigCmd({
-    $core.String? config,
+class PackageList extends $pb.GeneratedMessage {
+  factory PackageList({
+    $core.Iterable<InstalledPackage>? packages,
   }) {
     final result = create();
-    if (config != null) result.config = config;
+    if (packages != null) result.packages.addAll(packages);
     return result;
   }
 
-  LandscapeConfigCmd._();
+  PackageList._();
 
-  factory LandscapeConfigCmd.fromBuffer($core.List<$core.int> data,
+  factory PackageList.fromBuffer($core.List<$core.int> data,
           [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
       create()..mergeFromBuffer(data, registry);
-  factory LandscapeConfigCmd.fromJson($core.String json,
+  factory PackageList.fromJson($core.String json,
           [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
       create()..mergeFromJson(json, registry);
 
   static final $pb.BuilderInfo _i = $pb.BuilderInfo(
-      _omitMessageNames ? '' : 'LandscapeConfigCmd',
+      _omitMessageNames ? '' : 'PackageList',
       package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
       createEmptyInstance: create)
-    ..aOS(1, _omitFieldNames ? '' : 'config')
+    ..pc<InstalledPackage>(
+        1, _omitFieldNames ? '' : 'packages', $pb.PbFieldType.PM,
+        subBuilder: InstalledPackage.create)
     ..hasRequiredFields = false;
 
   @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
-  LandscapeConfigCmd clone() => deepCopy();
+  PackageList clone() => deepCopy();
   @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
-  LandscapeConfigCmd copyWith(void Function(LandscapeConfigCmd) updates) =>
-      super.copyWith((message) => updates(message as LandscapeConfigCmd))
-          as LandscapeConfigCmd;
+  PackageList copyWith(void Function(PackageList) updates) =>
+      super.copyWith((message) => updates(message as PackageList))
+          as PackageList;
 
   @$core.override
   $pb.BuilderInfo get info_ => _i;
 
   @$core.pragma('dart2js:noInline')
-  static LandscapeConfigCmd create() => LandscapeConfigCmd._();
+  static PackageList create() => PackageList._();
   @$core.override
-  LandscapeConfigCmd createEmptyInstance() => create();
+  PackageList createEmptyInstance() => create();
   @$core.pragma('dart2js:noInline')
-  static LandscapeConfigCmd getDefault() => _defaultInstance ??=
-      $pb.GeneratedMessage.$_defaultFor<LandscapeConfigCmd>(create);
-  static LandscapeConfigCmd? _defaultInstance;
+  static PackageList getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<PackageList>(create);
+  static PackageList? _defaultInstance;
 
   @$pb.TagNumber(1)
-  $core.String get config => $_getSZ(0);
+  $pb.PbList<InstalledPackage> get packages => $_getList(0);
+}
+
+class InstalledPackage extends $pb.GeneratedMessage {
+  factory InstalledPackage({
+    $core.String? name,
+    $core.String? version,
+    $core.String? architecture,
+    $core.String? origin,
+    $core.bool? esm,
+  }) {
+    final result = create();
+    if (name != null) result.name = name;
+    if (version != null) result.version = version;
+    if (architecture != null) result.architecture = architecture;
+    if (origin != null) result.origin = origin;
+    if (esm != null) result.esm = esm;
+    return result;
+  }
+
+  InstalledPackage._();
+
+  factory InstalledPackage.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory InstalledPackage.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'InstalledPackage',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'name')
+    ..aOS(2, _omitFieldNames ? '' : 'version')
+    ..aOS(3, _omitFieldNames ? '' : 'architecture')
+    ..aOS(4, _omitFieldNames ? '' : 'origin')
+    ..aOB(5, _omitFieldNames ? '' : 'esm')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  InstalledPackage clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  InstalledPackage copyWith(void Function(InstalledPackage) updates) =>
+      super.copyWith((message) => updates(message as InstalledPackage))
+          as InstalledPackage;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static InstalledPackage create() => InstalledPackage._();
+  @$core.override
+  InstalledPackage createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static InstalledPackage getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<InstalledPackage>(create);
+  static InstalledPackage? _defaultInstance;
+
   @$pb.TagNumber(1)
-  set config($core.String value) => $_setString(0, value);
+  $core.String get name => $_getSZ(0);
   @$pb.TagNumber(1)
-  $core.bool hasConfig() => $_has(0);
+  set name($core.String value) => $_setString(0, value);
   @$pb.TagNumber(1)
-  void clearConfig() => $_clearField(1);
+  $core.bool hasName() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearName() => $_clearField(1);
+
+  @$pb.TagNumber(2)
+  $core.String get version => $_getSZ(1);
+  @$pb.TagNumber(2)
+  set version($core.String value) => $_setString(1, value);
+  @$pb.TagNumber(2)
+  $core.bool hasVersion() => $_has(1);
+  @$pb.TagNumber(2)
+  void clearVersion() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  $core.String get architecture => $_getSZ(2);
+  @$pb.TagNumber(3)
+  set architecture($core.String value) => $_setString(2, value);
+  @$pb.TagNumber(3)
+  $core.bool hasArchitecture() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearArchitecture() => $_clearField(3);
+
+  @$pb.TagNumber(4)
+  $core.String get origin => $_getSZ(3);
+  @$pb.TagNumber(4)
+  set origin($core.String value) => $_setString(3, value);
+  @$pb.TagNumber(4)
+  $core.bool hasOrigin() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearOrigin() => $_clearField(4);
+
+  @$pb.TagNumber(5)
+  $core.bool get esm => $_getBF(4);
+  @$pb.TagNumber(5)
+  set esm($core.bool value) => $_setBool(4, value);
+  @$pb.TagNumber(5)
+  $core.bool hasEsm() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearEsm() => $_clearField(5);
+}
+
+class CancelCmd extends $pb.GeneratedMessage {
+  factory CancelCmd({
+    $core.String? command,
+  }) {
+    final result = create();
+    if (command != null) result.command = command;
+    return result;
+  }
+
+  CancelCmd._();
+
+  factory CancelCmd.fromBuffer($core.List<$core.int> data,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromBuffer(data, registry);
+  factory CancelCmd.fromJson($core.String json,
+          [$pb.ExtensionRegistry registry = $pb.ExtensionRegistry.EMPTY]) =>
+      create()..mergeFromJson(json, registry);
+
+  static final $pb.BuilderInfo _i = $pb.BuilderInfo(
+      _omitMessageNames ? '' : 'CancelCmd',
+      package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
+      createEmptyInstance: create)
+    ..aOS(1, _omitFieldNames ? '' : 'command')
+    ..hasRequiredFields = false;
+
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  CancelCmd clone() => deepCopy();
+  @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
+  CancelCmd copyWith(void Function(CancelCmd) updates) =>
+      super.copyWith((message) => updates(message as CancelCmd))
+          as CancelCmd;
+
+  @$core.override
+  $pb.BuilderInfo get info_ => _i;
+
+  @$core.pragma('dart2js:noInline')
+  static CancelCmd create() => CancelCmd._();
+  @$core.override
+  CancelCmd createEmptyInstance() => create();
+  @$core.pragma('dart2js:noInline')
+  static CancelCmd getDefault() => _defaultInstance ??=
+      $pb.GeneratedMessage.$_defaultFor<CancelCmd>(create);
+  static CancelCmd? _defaultInstance;
+
+  @$pb.TagNumber(1)
+  $core.String get command => $_getSZ(0);
+  @$pb.TagNumber(1)
+  set command($core.String value) => $_setString(0, value);
+  @$pb.TagNumber(1)
+  $core.bool hasCommand() => $_has(0);
+  @$pb.TagNumber(1)
+  void clearCommand() => $_clearField(1);
 }
 
-enum MSG_Data { wslName, result, notSet }
+enum MSG_Data {
+  wslName,
+  result,
+  commandOutput,
+  proServices,
+  securityUpdate,
+  packageInventory,
+  notSet
+}
 
 class MSG extends $pb.GeneratedMessage {
   factory MSG({
     $core.String? wslName,
     $core.String? result,
+    CommandOutput? commandOutput,
+    ProServicesResult? proServices,
+    SecurityUpdateResult? securityUpdate,
+    PackageInventory? packageInventory,
   }) {
     final result$ = create();
     if (wslName != null) result$.wslName = wslName;
     if (result != null) result$.result = result;
+    if (commandOutput != null) result$.commandOutput = commandOutput;
+    if (proServices != null) result$.proServices = proServices;
+    if (securityUpdate != null) result$.securityUpdate = securityUpdate;
+    if (packageInventory != null) result$.packageInventory = packageInventory;
     return result$;
   }
 
@@ -725,15 +3156,27 @@ class MSG extends $pb.GeneratedMessage {
   static const $core.Map<$core.int, MSG_Data> _MSG_DataByTag = {
     1: MSG_Data.wslName,
     2: MSG_Data.result,
+    3: MSG_Data.commandOutput,
+    4: MSG_Data.proServices,
+    5: MSG_Data.securityUpdate,
+    6: MSG_Data.packageInventory,
     0: MSG_Data.notSet
   };
   static final $pb.BuilderInfo _i = $pb.BuilderInfo(
       _omitMessageNames ? '' : 'MSG',
       package: const $pb.PackageName(_omitMessageNames ? '' : 'agentapi'),
       createEmptyInstance: create)
-    ..oo(0, [1, 2])
+    ..oo(0, [1, 2, 3, 4, 5, 6])
     ..aOS(1, _omitFieldNames ? '' : 'wslName')
     ..aOS(2, _omitFieldNames ? '' : 'result')
+    ..aOM<CommandOutput>(3, _omitFieldNames ? '' : 'commandOutput',
+        subBuilder: CommandOutput.create)
+    ..aOM<ProServicesResult>(4, _omitFieldNames ? '' : 'proServices',
+        subBuilder: ProServicesResult.create)
+    ..aOM<SecurityUpdateResult>(5, _omitFieldNames ? '' : 'securityUpdate',
+        subBuilder: SecurityUpdateResult.create)
+    ..aOM<PackageInventory>(6, _omitFieldNames ? '' : 'packageInventory',
+        subBuilder: PackageInventory.create)
     ..hasRequiredFields = false;
 
   @$core.Deprecated('See https://github.com/google/protobuf.dart/issues/998.')
@@ -756,9 +3199,17 @@ class MSG extends $pb.GeneratedMessage {
 
   @$pb.TagNumber(1)
   @$pb.TagNumber(2)
+  @$pb.TagNumber(3)
+  @$pb.TagNumber(4)
+  @$pb.TagNumber(5)
+  @$pb.TagNumber(6)
   MSG_Data whichData() => _MSG_DataByTag[$_whichOneof(0)]!;
   @$pb.TagNumber(1)
   @$pb.TagNumber(2)
+  @$pb.TagNumber(3)
+  @$pb.TagNumber(4)
+  @$pb.TagNumber(5)
+  @$pb.TagNumber(6)
   void clearData() => $_clearField($_whichOneof(0));
 
   @$pb.TagNumber(1)
@@ -778,6 +3229,50 @@ class MSG extends $pb.GeneratedMessage {
   $core.bool hasResult() => $_has(1);
   @$pb.TagNumber(2)
   void clearResult() => $_clearField(2);
+
+  @$pb.TagNumber(3)
+  CommandOutput get commandOutput => $_getN(2);
+  @$pb.TagNumber(3)
+  set commandOutput(CommandOutput value) => $_setField(3, value);
+  @$pb.TagNumber(3)
+  $core.bool hasCommandOutput() => $_has(2);
+  @$pb.TagNumber(3)
+  void clearCommandOutput() => $_clearField(3);
+  @$pb.TagNumber(3)
+  CommandOutput ensureCommandOutput() => $_ensure(2);
+
+  @$pb.TagNumber(4)
+  ProServicesResult get proServices => $_getN(3);
+  @$pb.TagNumber(4)
+  set proServices(ProServicesResult value) => $_setField(4, value);
+  @$pb.TagNumber(4)
+  $core.bool hasProServices() => $_has(3);
+  @$pb.TagNumber(4)
+  void clearProServices() => $_clearField(4);
+  @$pb.TagNumber(4)
+  ProServicesResult ensureProServices() => $_ensure(3);
+
+  @$pb.TagNumber(5)
+  SecurityUpdateResult get securityUpdate => $_getN(4);
+  @$pb.TagNumber(5)
+  set securityUpdate(SecurityUpdateResult value) => $_setField(5, value);
+  @$pb.TagNumber(5)
+  $core.bool hasSecurityUpdate() => $_has(4);
+  @$pb.TagNumber(5)
+  void clearSecurityUpdate() => $_clearField(5);
+  @$pb.TagNumber(5)
+  SecurityUpdateResult ensureSecurityUpdate() => $_ensure(4);
+
+  @$pb.TagNumber(6)
+  PackageInventory get packageInventory => $_getN(5);
+  @$pb.TagNumber(6)
+  set packageInventory(PackageInventory value) => $_setField(6, value);
+  @$pb.TagNumber(6)
+  $core.bool hasPackageInventory() => $_has(5);
+  @$pb.TagNumber(6)
+  void clearPackageInventory() => $_clearField(6);
+  @$pb.TagNumber(6)
+  PackageInventory ensurePackageInventory() => $_ensure(5);
 }
 
 const $core.bool _omitFieldNames =
@@ -67,6 +67,84 @@ class UIClient extends $grpc.Client {
     return $createUnaryCall(_$notifyPurchase, request, options: options);
   }
 
+  $grpc.ResponseFuture<$0.DistroContracts> getDistroContracts(
+    $0.Empty request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$getDistroContracts, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Empty> setDistroLabels(
+    $0.DistroLabels request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$setDistroLabels, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.DeadLetters> getDeadLetters(
+    $0.Empty request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$getDeadLetters, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.TaskHistory> getTaskHistory(
+    $0.TaskHistoryRequest request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$getTaskHistory, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Empty> applySecurityUpdates(
+    $0.SecurityUpdateRequest request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$applySecurityUpdates, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.InventoryExport> exportPackageInventory(
+    $0.InventoryRequest request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$exportPackageInventory, request,
+        options: options);
+  }
+
+  $grpc.ResponseFuture<$0.QueuedTasks> getQueuedTasks(
+    $0.TaskHistoryRequest request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$getQueuedTasks, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Empty> cancelTask(
+    $0.CancelTaskRequest request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$cancelTask, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.QuarantinedTasks> getQuarantinedTasks(
+    $0.Empty request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$getQuarantinedTasks, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.Empty> runCommand(
+    $0.RunCommandRequest request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$runCommand, request, options: options);
+  }
+
+  $grpc.ResponseFuture<$0.StartMetrics> getStartMetrics(
+    $0.Empty request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createUnaryCall(_$getStartMetrics, request, options: options);
+  }
+
   // method descriptors
 
   static final _$applyProToken =
@@ -93,6 +171,60 @@ class UIClient extends $grpc.Client {
           '/agentapi.UI/NotifyPurchase',
           ($0.Empty value) => value.writeToBuffer(),
           $0.SubscriptionInfo.fromBuffer);
+  static final _$getDistroContracts =
+      $grpc.ClientMethod<$0.Empty, $0.DistroContracts>(
+          '/agentapi.UI/GetDistroContracts',
+          ($0.Empty value) => value.writeToBuffer(),
+          $0.DistroContracts.fromBuffer);
+  static final _$setDistroLabels =
+      $grpc.ClientMethod<$0.DistroLabels, $0.Empty>(
+          '/agentapi.UI/SetDistroLabels',
+          ($0.DistroLabels value) => value.writeToBuffer(),
+          $0.Empty.fromBuffer);
+  static final _$getDeadLetters = $grpc.ClientMethod<$0.Empty, $0.DeadLetters>(
+      '/agentapi.UI/GetDeadLetters',
+      ($0.Empty value) => value.writeToBuffer(),
+      $0.DeadLetters.fromBuffer);
+  static final _$getTaskHistory =
+      $grpc.ClientMethod<$0.TaskHistoryRequest, $0.TaskHistory>(
+          '/agentapi.UI/GetTaskHistory',
+          ($0.TaskHistoryRequest value) => value.writeToBuffer(),
+          $0.TaskHistory.fromBuffer);
+  static final _$applySecurityUpdates =
+      $grpc.ClientMethod<$0.SecurityUpdateRequest, $0.Empty>(
+          '/agentapi.UI/ApplySecurityUpdates',
+          ($0.SecurityUpdateRequest value) => value.writeToBuffer(),
+          $0.Empty.fromBuffer);
+  static final _$exportPackageInventory =
+      $grpc.ClientMethod<$0.InventoryRequest, $0.InventoryExport>(
+          '/agentapi.UI/ExportPackageInventory',
+          ($0.InventoryRequest value) => value.writeToBuffer(),
+          $0.InventoryExport.fromBuffer);
+  static final _$getQueuedTasks =
+      $grpc.ClientMethod<$0.TaskHistoryRequest, $0.QueuedTasks>(
+          '/agentapi.UI/GetQueuedTasks',
+          ($0.TaskHistoryRequest value) => value.writeToBuffer(),
+          $0.QueuedTasks.fromBuffer);
+  static final _$cancelTask =
+      $grpc.ClientMethod<$0.CancelTaskRequest, $0.Empty>(
+          '/agentapi.UI/CancelTask',
+          ($0.CancelTaskRequest value) => value.writeToBuffer(),
+          $0.Empty.fromBuffer);
+  static final _$getQuarantinedTasks =
+      $grpc.ClientMethod<$0.Empty, $0.QuarantinedTasks>(
+          '/agentapi.UI/GetQuarantinedTasks',
+          ($0.Empty value) => value.writeToBuffer(),
+          $0.QuarantinedTasks.fromBuffer);
+  static final _$runCommand =
+      $grpc.ClientMethod<$0.RunCommandRequest, $0.Empty>(
+          '/agentapi.UI/RunCommand',
+          ($0.RunCommandRequest value) => value.writeToBuffer(),
+          $0.Empty.fromBuffer);
+  static final _$getStartMetrics =
+      $grpc.ClientMethod<$0.Empty, $0.StartMetrics>(
+          '/agentapi.UI/GetStartMetrics',
+          ($0.Empty value) => value.writeToBuffer(),
+          $0.StartMetrics.fromBuffer);
 }
 
 @$pb.GrpcServiceName('agentapi.UI')
@@ -135,6 +267,86 @@ abstract class UIServiceBase extends $grpc.Service {
         false,
         ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
         ($0.SubscriptionInfo value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.DistroContracts>(
+        'GetDistroContracts',
+        getDistroContracts_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.DistroContracts value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.DistroLabels, $0.Empty>(
+        'SetDistroLabels',
+        setDistroLabels_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.DistroLabels.fromBuffer(value),
+        ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.DeadLetters>(
+        'GetDeadLetters',
+        getDeadLetters_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.DeadLetters value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.TaskHistoryRequest, $0.TaskHistory>(
+        'GetTaskHistory',
+        getTaskHistory_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) =>
+            $0.TaskHistoryRequest.fromBuffer(value),
+        ($0.TaskHistory value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.SecurityUpdateRequest, $0.Empty>(
+        'ApplySecurityUpdates',
+        applySecurityUpdates_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) =>
+            $0.SecurityUpdateRequest.fromBuffer(value),
+        ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.InventoryRequest, $0.InventoryExport>(
+        'ExportPackageInventory',
+        exportPackageInventory_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.InventoryRequest.fromBuffer(value),
+        ($0.InventoryExport value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.TaskHistoryRequest, $0.QueuedTasks>(
+        'GetQueuedTasks',
+        getQueuedTasks_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) =>
+            $0.TaskHistoryRequest.fromBuffer(value),
+        ($0.QueuedTasks value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.CancelTaskRequest, $0.Empty>(
+        'CancelTask',
+        cancelTask_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.CancelTaskRequest.fromBuffer(value),
+        ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.QuarantinedTasks>(
+        'GetQuarantinedTasks',
+        getQuarantinedTasks_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.QuarantinedTasks value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.RunCommandRequest, $0.Empty>(
+        'RunCommand',
+        runCommand_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.RunCommandRequest.fromBuffer(value),
+        ($0.Empty value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.Empty, $0.StartMetrics>(
+        'GetStartMetrics',
+        getStartMetrics_Pre,
+        false,
+        false,
+        ($core.List<$core.int> value) => $0.Empty.fromBuffer(value),
+        ($0.StartMetrics value) => value.writeToBuffer()));
   }
 
   $async.Future<$0.SubscriptionInfo> applyProToken_Pre(
@@ -176,6 +388,100 @@ abstract class UIServiceBase extends $grpc.Service {
 
   $async.Future<$0.SubscriptionInfo> notifyPurchase(
       $grpc.ServiceCall call, $0.Empty request);
+
+  $async.Future<$0.DistroContracts> getDistroContracts_Pre(
+      $grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getDistroContracts($call, await $request);
+  }
+
+  $async.Future<$0.DistroContracts> getDistroContracts(
+      $grpc.ServiceCall call, $0.Empty request);
+
+  $async.Future<$0.Empty> setDistroLabels_Pre(
+      $grpc.ServiceCall $call, $async.Future<$0.DistroLabels> $request) async {
+    return setDistroLabels($call, await $request);
+  }
+
+  $async.Future<$0.Empty> setDistroLabels(
+      $grpc.ServiceCall call, $0.DistroLabels request);
+
+  $async.Future<$0.DeadLetters> getDeadLetters_Pre(
+      $grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getDeadLetters($call, await $request);
+  }
+
+  $async.Future<$0.DeadLetters> getDeadLetters(
+      $grpc.ServiceCall call, $0.Empty request);
+
+  $async.Future<$0.TaskHistory> getTaskHistory_Pre(
+      $grpc.ServiceCall $call,
+      $async.Future<$0.TaskHistoryRequest> $request) async {
+    return getTaskHistory($call, await $request);
+  }
+
+  $async.Future<$0.TaskHistory> getTaskHistory(
+      $grpc.ServiceCall call, $0.TaskHistoryRequest request);
+
+  $async.Future<$0.Empty> applySecurityUpdates_Pre(
+      $grpc.ServiceCall $call,
+      $async.Future<$0.SecurityUpdateRequest> $request) async {
+    return applySecurityUpdates($call, await $request);
+  }
+
+  $async.Future<$0.Empty> applySecurityUpdates(
+      $grpc.ServiceCall call, $0.SecurityUpdateRequest request);
+
+  $async.Future<$0.InventoryExport> exportPackageInventory_Pre(
+      $grpc.ServiceCall $call,
+      $async.Future<$0.InventoryRequest> $request) async {
+    return exportPackageInventory($call, await $request);
+  }
+
+  $async.Future<$0.InventoryExport> exportPackageInventory(
+      $grpc.ServiceCall call, $0.InventoryRequest request);
+
+  $async.Future<$0.QueuedTasks> getQueuedTasks_Pre(
+      $grpc.ServiceCall $call,
+      $async.Future<$0.TaskHistoryRequest> $request) async {
+    return getQueuedTasks($call, await $request);
+  }
+
+  $async.Future<$0.QueuedTasks> getQueuedTasks(
+      $grpc.ServiceCall call, $0.TaskHistoryRequest request);
+
+  $async.Future<$0.Empty> cancelTask_Pre(
+      $grpc.ServiceCall $call,
+      $async.Future<$0.CancelTaskRequest> $request) async {
+    return cancelTask($call, await $request);
+  }
+
+  $async.Future<$0.Empty> cancelTask(
+      $grpc.ServiceCall call, $0.CancelTaskRequest request);
+
+  $async.Future<$0.QuarantinedTasks> getQuarantinedTasks_Pre(
+      $grpc.ServiceCall $call, $async.Future<$0.Empty> $request) async {
+    return getQuarantinedTasks($call, await $request);
+  }
+
+  $async.Future<$0.QuarantinedTasks> getQuarantinedTasks(
+      $grpc.ServiceCall call, $0.Empty request);
+
+  $async.Future<$0.Empty> runCommand_Pre(
+      $grpc.ServiceCall $call,
+      $async.Future<$0.RunCommandRequest> $request) async {
+    return runCommand($call, await $request);
+  }
+
+  $async.Future<$0.Empty> runCommand(
+      $grpc.ServiceCall call, $0.RunCommandRequest request);
+
+  $async.Future<$0.StartMetrics> getStartMetrics_Pre(
+      $grpc.ServiceCall $call,  $async.Future<$0.Empty> $request) async {
+    return getStartMetrics($call, await $request);
+  }
+
+  $async.Future<$0.StartMetrics> getStartMetrics(
+      $grpc.ServiceCall call, $0.Empty request);
 }
 
 @$pb.GrpcServiceName('agentapi.WSLInstance')
@@ -214,6 +520,53 @@ class WSLInstanceClient extends $grpc.Client {
         options: options);
   }
 
+  $grpc.ResponseStream<$0.ProxyConfigCmd> proxyConfigCommands(
+    $async.Stream<$0.MSG> request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createStreamingCall(_$proxyConfigCommands, request,
+        options: options);
+  }
+
+  $grpc.ResponseStream<$0.RunCommandCmd> runCommandCommands(
+    $async.Stream<$0.MSG> request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createStreamingCall(_$runCommandCommands, request,
+        options: options);
+  }
+
+  $grpc.ResponseStream<$0.ProServicesCmd> proServicesCommands(
+    $async.Stream<$0.MSG> request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createStreamingCall(_$proServicesCommands, request,
+        options: options);
+  }
+
+  $grpc.ResponseStream<$0.SecurityUpdateCmd> securityUpdateCommands(
+    $async.Stream<$0.MSG> request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createStreamingCall(_$securityUpdateCommands, request,
+        options: options);
+  }
+
+  $grpc.ResponseStream<$0.PackageInventoryCmd> packageInventoryCommands(
+    $async.Stream<$0.MSG> request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createStreamingCall(_$packageInventoryCommands, request,
+        options: options);
+  }
+
+  $grpc.ResponseStream<$0.CancelCmd> cancelCommands(
+    $async.Stream<$0.MSG> request, {
+    $grpc.CallOptions? options,
+  }) {
+    return $createStreamingCall(_$cancelCommands, request, options: options);
+  }
+
   // method descriptors
 
   static final _$connected = $grpc.ClientMethod<$0.DistroInfo, $0.Empty>(
@@ -230,6 +583,35 @@ class WSLInstanceClient extends $grpc.Client {
           '/agentapi.WSLInstance/LandscapeConfigCommands',
           ($0.MSG value) => value.writeToBuffer(),
           $0.LandscapeConfigCmd.fromBuffer);
+  static final _$proxyConfigCommands =
+      $grpc.ClientMethod<$0.MSG, $0.ProxyConfigCmd>(
+          '/agentapi.WSLInstance/ProxyConfigCommands',
+          ($0.MSG value) => value.writeToBuffer(),
+          $0.ProxyConfigCmd.fromBuffer);
+  static final _$runCommandCommands =
+      $grpc.ClientMethod<$0.MSG, $0.RunCommandCmd>(
+          '/agentapi.WSLInstance/RunCommandCommands',
+          ($0.MSG value) => value.writeToBuffer(),
+          $0.RunCommandCmd.fromBuffer);
+  static final _$proServicesCommands =
+      $grpc.ClientMethod<$0.MSG, $0.ProServicesCmd>(
+          '/agentapi.WSLInstance/ProServicesCommands',
+          ($0.MSG value) => value.writeToBuffer(),
+          $0.ProServicesCmd.fromBuffer);
+  static final _$securityUpdateCommands =
+      $grpc.ClientMethod<$0.MSG, $0.SecurityUpdateCmd>(
+          '/agentapi.WSLInstance/SecurityUpdateCommands',
+          ($0.MSG value) => value.writeToBuffer(),
+          $0.SecurityUpdateCmd.fromBuffer);
+  static final _$packageInventoryCommands =
+      $grpc.ClientMethod<$0.MSG, $0.PackageInventoryCmd>(
+          '/agentapi.WSLInstance/PackageInventoryCommands',
+          ($0.MSG value) => value.writeToBuffer(),
+          $0.PackageInventoryCmd.fromBuffer);
+  static final _$cancelCommands = $grpc.ClientMethod<$0.MSG, $0.CancelCmd>(
+      '/agentapi.WSLInstance/CancelCommands',
+      ($0.MSG value) => value.writeToBuffer(),
+      $0.CancelCmd.fromBuffer);
 }
 
 @$pb.GrpcServiceName('agentapi.WSLInstance')
@@ -258,6 +640,48 @@ abstract class WSLInstanceServiceBase extends $grpc.Service {
         true,
         ($core.List<$core.int> value) => $0.MSG.fromBuffer(value),
         ($0.LandscapeConfigCmd value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.MSG, $0.ProxyConfigCmd>(
+        'ProxyConfigCommands',
+        proxyConfigCommands,
+        true,
+        true,
+        ($core.List<$core.int> value) => $0.MSG.fromBuffer(value),
+        ($0.ProxyConfigCmd value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.MSG, $0.RunCommandCmd>(
+        'RunCommandCommands',
+        runCommandCommands,
+        true,
+        true,
+        ($core.List<$core.int> value) => $0.MSG.fromBuffer(value),
+        ($0.RunCommandCmd value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.MSG, $0.ProServicesCmd>(
+        'ProServicesCommands',
+        proServicesCommands,
+        true,
+        true,
+        ($core.List<$core.int> value) => $0.MSG.fromBuffer(value),
+        ($0.ProServicesCmd value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.MSG, $0.SecurityUpdateCmd>(
+        'SecurityUpdateCommands',
+        securityUpdateCommands,
+        true,
+        true,
+        ($core.List<$core.int> value) => $0.MSG.fromBuffer(value),
+        ($0.SecurityUpdateCmd value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.MSG, $0.PackageInventoryCmd>(
+        'PackageInventoryCommands',
+        packageInventoryCommands,
+        true,
+        true,
+        ($core.List<$core.int> value) => $0.MSG.fromBuffer(value),
+        ($0.PackageInventoryCmd value) => value.writeToBuffer()));
+    $addMethod($grpc.ServiceMethod<$0.MSG, $0.CancelCmd>(
+        'CancelCommands',
+        cancelCommands,
+        true,
+        true,
+        ($core.List<$core.int> value) => $0.MSG.fromBuffer(value),
+        ($0.CancelCmd value) => value.writeToBuffer()));
   }
 
   $async.Future<$0.Empty> connected(
@@ -268,4 +692,22 @@ abstract class WSLInstanceServiceBase extends $grpc.Service {
 
   $async.Stream<$0.LandscapeConfigCmd> landscapeConfigCommands(
       $grpc.ServiceCall call, $async.Stream<$0.MSG> request);
+
+  $async.Stream<$0.ProxyConfigCmd> proxyConfigCommands(
+      $grpc.ServiceCall call, $async.Stream<$0.MSG> request);
+
+  $async.Stream<$0.RunCommandCmd> runCommandCommands(
+      $grpc.ServiceCall call, $async.Stream<$0.MSG> request);
+
+  $async.Stream<$0.ProServicesCmd> proServicesCommands(
+      $grpc.ServiceCall call, $async.Stream<$0.MSG> request);
+
+  $async.Stream<$0.SecurityUpdateCmd> securityUpdateCommands(
+      $grpc.ServiceCall call, $async.Stream<$0.MSG> request);
+
+  $async.Stream<$0.PackageInventoryCmd> packageInventoryCommands(
+      $grpc.ServiceCall call, $async.Stream<$0.MSG> request);
+
+  $async.Stream<$0.CancelCmd> cancelCommands(
+      $grpc.ServiceCall call, $async.Stream<$0.MSG> request);
 }
@@ -176,6 +176,332 @@ final $typed_data.Uint8List configSourcesDescriptor = $convert.base64Decode(
     'NjcmlwdGlvbkluZm9SD3Byb1N1YnNjcmlwdGlvbhJDCg9sYW5kc2NhcGVTb3VyY2UYAiABKAsy'
     'GS5hZ2VudGFwaS5MYW5kc2NhcGVTb3VyY2VSD2xhbmRzY2FwZVNvdXJjZQ==');
 
+@$core.Deprecated('Use distroContractDescriptor instead')
+const DistroContract$json = {
+  '1': 'DistroContract',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'token', '3': 2, '4': 1, '5': 9, '10': 'token'},
+    {'1': 'pattern', '3': 3, '4': 1, '5': 9, '10': 'pattern'},
+  ],
+};
+
+/// Descriptor for `DistroContract`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List distroContractDescriptor = $convert.base64Decode(
+    'Cg5EaXN0cm9Db250cmFjdBIZCgh3c2xfbmFtZRgBIAEoCVIHd3NsTmFtZRIUCgV0b2tlbhgCIA'
+    'EoCVIFdG9rZW4SGAoHcGF0dGVybhgDIAEoCVIHcGF0dGVybg==');
+
+@$core.Deprecated('Use distroContractsDescriptor instead')
+const DistroContracts$json = {
+  '1': 'DistroContracts',
+  '2': [
+    {
+      '1': 'distros',
+      '3': 1,
+      '4': 3,
+      '5': 11,
+      '6': '.agentapi.DistroContract',
+      '10': 'distros'
+    },
+  ],
+};
+
+/// Descriptor for `DistroContracts`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List distroContractsDescriptor = $convert.base64Decode(
+    'Cg9EaXN0cm9Db250cmFjdHMSMgoHZGlzdHJvcxgBIAMoCzIYLmFnZW50YXBpLkRpc3Ryb0Nvbn'
+    'RyYWN0UgdkaXN0cm9z');
+
+@$core.Deprecated('Use distroLabelsDescriptor instead')
+const DistroLabels$json = {
+  '1': 'DistroLabels',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'labels', '3': 2, '4': 3, '5': 9, '10': 'labels'},
+  ],
+};
+
+/// Descriptor for `DistroLabels`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List distroLabelsDescriptor = $convert.base64Decode(
+    'CgxEaXN0cm9MYWJlbHMSGQoId3NsX25hbWUYASABKAlSB3dzbE5hbWUSFgoGbGFiZWxzGAIgAy'
+    'gJUgZsYWJlbHM=');
+
+@$core.Deprecated('Use deadLetterDescriptor instead')
+const DeadLetter$json = {
+  '1': 'DeadLetter',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'task', '3': 2, '4': 1, '5': 9, '10': 'task'},
+    {'1': 'attempts', '3': 3, '4': 1, '5': 5, '10': 'attempts'},
+    {'1': 'error', '3': 4, '4': 1, '5': 9, '10': 'error'},
+    {'1': 'time', '3': 5, '4': 1, '5': 3, '10': 'time'},
+    {'1': 'id', '3': 6, '4': 1, '5': 9, '10': 'id'},
+    {'1': 'type', '3': 7, '4': 1, '5': 9, '10': 'type'},
+  ],
+};
+
+/// Descriptor for `DeadLetter`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List deadLetterDescriptor = $convert.base64Decode(
+    'CgpEZWFkTGV0dGVyEhkKCHdzbF9uYW1lGAEgASgJUgd3c2xOYW1lEhIKBHRhc2sYAiABKAlSBH'
+    'Rhc2sSGgoIYXR0ZW1wdHMYAyABKAVSCGF0dGVtcHRzEhQKBWVycm9yGAQgASgJUgVlcnJvchIS'
+    'CgR0aW1lGAUgASgDUgR0aW1lEg4KAmlkGAYgASgJUgJpZBISCgR0eXBlGAcgASgJUgR0eXBl');
+
+@$core.Deprecated('Use deadLettersDescriptor instead')
+const DeadLetters$json = {
+  '1': 'DeadLetters',
+  '2': [
+    {
+      '1': 'letters',
+      '3': 1,
+      '4': 3,
+      '5': 11,
+      '6': '.agentapi.DeadLetter',
+      '10': 'letters'
+    },
+  ],
+};
+
+/// Descriptor for `DeadLetters`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List deadLettersDescriptor = $convert.base64Decode(
+    'CgtEZWFkTGV0dGVycxIuCgdsZXR0ZXJzGAEgAygLMhQuYWdlbnRhcGkuRGVhZExldHRlclIHbG'
+    'V0dGVycw==');
+
+@$core.Deprecated('Use quarantinedTaskDescriptor instead')
+const QuarantinedTask$json = {
+  '1': 'QuarantinedTask',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'task', '3': 2, '4': 1, '5': 9, '10': 'task'},
+    {'1': 'error', '3': 3, '4': 1, '5': 9, '10': 'error'},
+    {'1': 'time', '3': 4, '4': 1, '5': 3, '10': 'time'},
+  ],
+};
+
+/// Descriptor for `QuarantinedTask`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List quarantinedTaskDescriptor = $convert.base64Decode(
+    'Cg9RdWFyYW50aW5lZFRhc2sSGQoId3NsX25hbWUYASABKAlSB3dzbE5hbWUSEgoEdGFzaxgCIA'
+    'EoCVIEdGFzaxIUCgVlcnJvchgDIAEoCVIFZXJyb3ISEgoEdGltZRgEIAEoA1IEdGltZQ==');
+
+@$core.Deprecated('Use quarantinedTasksDescriptor instead')
+const QuarantinedTasks$json = {
+  '1': 'QuarantinedTasks',
+  '2': [
+    {
+      '1': 'tasks',
+      '3': 1,
+      '4': 3,
+      '5': 11,
+      '6': '.agentapi.QuarantinedTask',
+      '10': 'tasks'
+    },
+  ],
+};
+
+/// Descriptor for `QuarantinedTasks`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List quarantinedTasksDescriptor = $convert.base64Decode(
+    'ChBRdWFyYW50aW5lZFRhc2tzEi8KBXRhc2tzGAEgAygLMhkuYWdlbnRhcGkuUXVhcmFudGluZW'
+    'RUYXNrUgV0YXNrcw==');
+
+@$core.Deprecated('Use taskHistoryRequestDescriptor instead')
+const TaskHistoryRequest$json = {
+  '1': 'TaskHistoryRequest',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+  ],
+};
+
+/// Descriptor for `TaskHistoryRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List taskHistoryRequestDescriptor =
+    $convert.base64Decode(
+        'ChJUYXNrSGlzdG9yeVJlcXVlc3QSGQoId3NsX25hbWUYASABKAlSB3dzbE5hbWU=');
+
+@$core.Deprecated('Use taskHistoryEntryDescriptor instead')
+const TaskHistoryEntry$json = {
+  '1': 'TaskHistoryEntry',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'type', '3': 2, '4': 1, '5': 9, '10': 'type'},
+    {'1': 'summary', '3': 3, '4': 1, '5': 9, '10': 'summary'},
+    {'1': 'submitted', '3': 4, '4': 1, '5': 3, '10': 'submitted'},
+    {'1': 'started', '3': 5, '4': 1, '5': 3, '10': 'started'},
+    {'1': 'ended', '3': 6, '4': 1, '5': 3, '10': 'ended'},
+    {'1': 'attempts', '3': 7, '4': 1, '5': 5, '10': 'attempts'},
+    {'1': 'result', '3': 8, '4': 1, '5': 9, '10': 'result'},
+    {'1': 'error', '3': 9, '4': 1, '5': 9, '10': 'error'},
+    {'1': 'details', '3': 10, '4': 1, '5': 9, '10': 'details'},
+    {'1': 'id', '3': 11, '4': 1, '5': 9, '10': 'id'},
+    {'1': 'reboot_required', '3': 12, '4': 1, '5': 8, '10': 'rebootRequired'},
+  ],
+};
+
+/// Descriptor for `TaskHistoryEntry`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List taskHistoryEntryDescriptor = $convert.base64Decode(
+    'ChBUYXNrSGlzdG9yeUVudHJ5EhkKCHdzbF9uYW1lGAEgASgJUgd3c2xOYW1lEhIKBHR5cGUYAi'
+    'ABKAlSBHR5cGUSGAoHc3VtbWFyeRgDIAEoCVIHc3VtbWFyeRIcCglzdWJtaXR0ZWQYBCABKANS'
+    'CXN1Ym1pdHRlZBIYCgdzdGFydGVkGAUgASgDUgdzdGFydGVkEhQKBWVuZGVkGAYgASgDUgVlbm'
+    'RlZBIaCghhdHRlbXB0cxgHIAEoBVIIYXR0ZW1wdHMSFgoGcmVzdWx0GAggASgJUgZyZXN1bHQS'
+    'FAoFZXJyb3IYCSABKAlSBWVycm9yEhgKB2RldGFpbHMYCiABKAlSB2RldGFpbHMSDgoCaWQYCy'
+    'ABKAlSAmlkEicKD3JlYm9vdF9yZXF1aXJlZBgMIAEoCFIOcmVib290UmVxdWlyZWQ=');
+
+@$core.Deprecated('Use taskHistoryDescriptor instead')
+const TaskHistory$json = {
+  '1': 'TaskHistory',
+  '2': [
+    {
+      '1': 'entries',
+      '3': 1,
+      '4': 3,
+      '5': 11,
+      '6': '.agentapi.TaskHistoryEntry',
+      '10': 'entries'
+    },
+  ],
+};
+
+/// Descriptor for `TaskHistory`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List taskHistoryDescriptor = $convert.base64Decode(
+    'CgtUYXNrSGlzdG9yeRI0CgdlbnRyaWVzGAEgAygLMhouYWdlbnRhcGkuVGFza0hpc3RvcnlFbn'
+    'RyeVIHZW50cmllcw==');
+
+@$core.Deprecated('Use queuedTaskDescriptor instead')
+const QueuedTask$json = {
+  '1': 'QueuedTask',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'id', '3': 2, '4': 1, '5': 9, '10': 'id'},
+    {'1': 'type', '3': 3, '4': 1, '5': 9, '10': 'type'},
+    {'1': 'summary', '3': 4, '4': 1, '5': 9, '10': 'summary'},
+    {'1': 'submitted', '3': 5, '4': 1, '5': 3, '10': 'submitted'},
+    {'1': 'running', '3': 6, '4': 1, '5': 8, '10': 'running'},
+    {'1': 'deferred', '3': 7, '4': 1, '5': 8, '10': 'deferred'},
+  ],
+};
+
+/// Descriptor for `QueuedTask`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List queuedTaskDescriptor = $convert.base64Decode(
+    'CgpRdWV1ZWRUYXNrEhkKCHdzbF9uYW1lGAEgASgJUgd3c2xOYW1lEg4KAmlkGAIgASgJUgJpZB'
+    'ISCgR0eXBlGAMgASgJUgR0eXBlEhgKB3N1bW1hcnkYBCABKAlSB3N1bW1hcnkSHAoJc3VibWl0'
+    'dGVkGAUgASgDUglzdWJtaXR0ZWQSGAoHcnVubmluZxgGIAEoCFIHcnVubmluZxIaCghkZWZlcn'
+    'JlZBgHIAEoCFIIZGVmZXJyZWQ=');
+
+@$core.Deprecated('Use queuedTasksDescriptor instead')
+const QueuedTasks$json = {
+  '1': 'QueuedTasks',
+  '2': [
+    {
+      '1': 'tasks',
+      '3': 1,
+      '4': 3,
+      '5': 11,
+      '6': '.agentapi.QueuedTask',
+      '10': 'tasks'
+    },
+  ],
+};
+
+/// Descriptor for `QueuedTasks`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List queuedTasksDescriptor = $convert.base64Decode(
+    'CgtRdWV1ZWRUYXNrcxIqCgV0YXNrcxgBIAMoCzIULmFnZW50YXBpLlF1ZXVlZFRhc2tSBXRhc2'
+    'tz');
+
+@$core.Deprecated('Use cancelTaskRequestDescriptor instead')
+const CancelTaskRequest$json = {
+  '1': 'CancelTaskRequest',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'id', '3': 2, '4': 1, '5': 9, '10': 'id'},
+  ],
+};
+
+/// Descriptor for `CancelTaskRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List cancelTaskRequestDescriptor = $convert.base64Decode(
+    'ChFDYW5jZWxUYXNrUmVxdWVzdBIZCgh3c2xfbmFtZRgBIAEoCVIHd3NsTmFtZRIOCgJpZBgCIA'
+    'EoCVICaWQ=');
+
+@$core.Deprecated('Use securityUpdateRequestDescriptor instead')
+const SecurityUpdateRequest$json = {
+  '1': 'SecurityUpdateRequest',
+  '2': [
+    {'1': 'wsl_names', '3': 1, '4': 3, '5': 9, '10': 'wslNames'},
+    {'1': 'labels', '3': 2, '4': 3, '5': 9, '10': 'labels'},
+  ],
+};
+
+/// Descriptor for `SecurityUpdateRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List securityUpdateRequestDescriptor =
+    $convert.base64Decode(
+        'ChVTZWN1cml0eVVwZGF0ZVJlcXVlc3QSGwoJd3NsX25hbWVzGAEgAygJUgh3c2xOYW1lcxIWCg'
+        'ZsYWJlbHMYAiADKAlSBmxhYmVscw==');
+
+@$core.Deprecated('Use runCommandRequestDescriptor instead')
+const RunCommandRequest$json = {
+  '1': 'RunCommandRequest',
+  '2': [
+    {'1': 'wsl_names', '3': 1, '4': 3, '5': 9, '10': 'wslNames'},
+    {'1': 'argv', '3': 2, '4': 3, '5': 9, '10': 'argv'},
+    {'1': 'env', '3': 3, '4': 3, '5': 9, '10': 'env'},
+    {'1': 'working_dir', '3': 4, '4': 1, '5': 9, '10': 'workingDir'},
+    {'1': 'timeout_seconds', '3': 5, '4': 1, '5': 3, '10': 'timeoutSeconds'},
+    {'1': 'user', '3': 6, '4': 1, '5': 9, '10': 'user'},
+    {'1': 'labels', '3': 7, '4': 3, '5': 9, '10': 'labels'},
+  ],
+};
+
+/// Descriptor for `RunCommandRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List runCommandRequestDescriptor = $convert.base64Decode(
+    'ChFSdW5Db21tYW5kUmVxdWVzdBIbCgl3c2xfbmFtZXMYASADKAlSCHdzbE5hbWVzEhIKBGFyZ3'
+    'YYAiADKAlSBGFyZ3YSEAoDZW52GAMgAygJUgNlbnYSHwoLd29ya2luZ19kaXIYBCABKAlSCndv'
+    'cmtpbmdEaXISJwoPdGltZW91dF9zZWNvbmRzGAUgASgDUg50aW1lb3V0U2Vjb25kcxISCgR1c2'
+    'VyGAYgASgJUgR1c2VyEhYKBmxhYmVscxgHIAMoCVIGbGFiZWxz');
+
+@$core.Deprecated('Use inventoryRequestDescriptor instead')
+const InventoryRequest$json = {
+  '1': 'InventoryRequest',
+  '2': [
+    {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '10': 'wslName'},
+    {'1': 'format', '3': 2, '4': 1, '5': 9, '10': 'format'},
+    {'1': 'diff', '3': 3, '4': 1, '5': 8, '10': 'diff'},
+  ],
+};
+
+/// Descriptor for `InventoryRequest`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List inventoryRequestDescriptor = $convert.base64Decode(
+    'ChBJbnZlbnRvcnlSZXF1ZXN0EhkKCHdzbF9uYW1lGAEgASgJUgd3c2xOYW1lEhYKBmZvcm1hdB'
+    'gCIAEoCVIGZm9ybWF0EhIKBGRpZmYYAyABKAhSBGRpZmY=');
+
+@$core.Deprecated('Use inventoryExportDescriptor instead')
+const InventoryExport$json = {
+  '1': 'InventoryExport',
+  '2': [
+    {'1': 'data', '3': 1, '4': 1, '5': 12, '10': 'data'},
+  ],
+};
+
+/// Descriptor for `InventoryExport`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List inventoryExportDescriptor = $convert
+    .base64Decode('Cg9JbnZlbnRvcnlFeHBvcnQSEgoEZGF0YRgBIAEoDFIEZGF0YQ==');
+
+@$core.Deprecated('Use startMetricsDescriptor instead')
+const StartMetrics$json = {
+  '1': 'StartMetrics',
+  '2': [
+    {'1': 'parallelism', '3': 1, '4': 1, '5': 3, '10': 'parallelism'},
+    {'1': 'running', '3': 2, '4': 1, '5': 3, '10': 'running'},
+    {'1': 'queued_interactive', '3': 3, '4': 1, '5': 3, '10': 'queuedInteractive'},
+    {'1': 'queued_background', '3': 4, '4': 1, '5': 3, '10': 'queuedBackground'},
+    {'1': 'max_queued', '3': 5, '4': 1, '5': 3, '10': 'maxQueued'},
+    {'1': 'admitted', '3': 6, '4': 1, '5': 3, '10': 'admitted'},
+    {'1': 'timed_out', '3': 7, '4': 1, '5': 3, '10': 'timedOut'},
+  ],
+};
+
+/// Descriptor for `StartMetrics`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List startMetricsDescriptor = $convert.base64Decode(
+    'CgxTdGFydE1ldHJpY3MSIAoLcGFyYWxsZWxpc20YASABKANSC3BhcmFsbGVsaXNtEhgKB3J1bm'
+    '5pbmcYAiABKANSB3J1bm5pbmcSLQoScXVldWVkX2ludGVyYWN0aXZlGAMgASgDUhFxdWV1ZWRJ'
+    'bnRlcmFjdGl2ZRIrChFxdWV1ZWRfYmFja2dyb3VuZBgEIAEoA1IQcXVldWVkQmFja2dyb3VuZB'
+    'IdCgptYXhfcXVldWVkGAUgASgDUgltYXhRdWV1ZWQSGgoIYWRtaXR0ZWQYBiABKANSCGFkbWl0'
+    'dGVkEhsKCXRpbWVkX291dBgHIAEoA1IIdGltZWRPdXQ=');
+
 @$core.Deprecated('Use distroInfoDescriptor instead')
 const DistroInfo$json = {
   '1': 'DistroInfo',
@@ -186,6 +512,9 @@ const DistroInfo$json = {
     {'1': 'pretty_name', '3': 4, '4': 1, '5': 9, '10': 'prettyName'},
     {'1': 'pro_attached', '3': 5, '4': 1, '5': 8, '10': 'proAttached'},
     {'1': 'hostname', '3': 6, '4': 1, '5': 9, '10': 'hostname'},
+    {'1': 'service_version', '3': 7, '4': 1, '5': 9, '10': 'serviceVersion'},
+    {'1': 'labels', '3': 8, '4': 3, '5': 9, '10': 'labels'},
+    {'1': 'capabilities', '3': 9, '4': 3, '5': 9, '10': 'capabilities'},
   ],
 };
 
@@ -194,7 +523,9 @@ final $typed_data.Uint8List distroInfoDescriptor = $convert.base64Decode(
     'CgpEaXN0cm9JbmZvEhkKCHdzbF9uYW1lGAEgASgJUgd3c2xOYW1lEg4KAmlkGAIgASgJUgJpZB'
     'IdCgp2ZXJzaW9uX2lkGAMgASgJUgl2ZXJzaW9uSWQSHwoLcHJldHR5X25hbWUYBCABKAlSCnBy'
     'ZXR0eU5hbWUSIQoMcHJvX2F0dGFjaGVkGAUgASgIUgtwcm9BdHRhY2hlZBIaCghob3N0bmFtZR'
-    'gGIAEoCVIIaG9zdG5hbWU=');
+    'gGIAEoCVIIaG9zdG5hbWUSJwoPc2VydmljZV92ZXJzaW9uGAcgASgJUg5zZXJ2aWNlVmVyc2lv'
+    'bhIWCgZsYWJlbHMYCCADKAlSBmxhYmVscxIiCgxjYXBhYmlsaXRpZXMYCSADKAlSDGNhcGFiaW'
+    'xpdGllcw==');
 
 @$core.Deprecated('Use proAttachCmdDescriptor instead')
 const ProAttachCmd$json = {
@@ -221,12 +552,268 @@ final $typed_data.Uint8List landscapeConfigCmdDescriptor =
     $convert.base64Decode(
         'ChJMYW5kc2NhcGVDb25maWdDbWQSFgoGY29uZmlnGAEgASgJUgZjb25maWc=');
 
+@$core.Deprecated('Use proxyConfigCmdDescriptor instead')
+const ProxyConfigCmd$json = {
+  '1': 'ProxyConfigCmd',
+  '2': [
+    {'1': 'http_proxy', '3': 1, '4': 1, '5': 9, '10': 'httpProxy'},
+    {'1': 'https_proxy', '3': 2, '4': 1, '5': 9, '10': 'httpsProxy'},
+    {'1': 'no_proxy', '3': 3, '4': 1, '5': 9, '10': 'noProxy'},
+  ],
+};
+
+/// Descriptor for `ProxyConfigCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List proxyConfigCmdDescriptor = $convert.base64Decode(
+    'Cg5Qcm94eUNvbmZpZ0NtZBIdCgpodHRwX3Byb3h5GAEgASgJUglodHRwUHJveHkSHwoLaHR0cH'
+    'NfcHJveHkYAiABKAlSCmh0dHBzUHJveHkSGQoIbm9fcHJveHkYAyABKAlSB25vUHJveHk=');
+
+@$core.Deprecated('Use runCommandCmdDescriptor instead')
+const RunCommandCmd$json = {
+  '1': 'RunCommandCmd',
+  '2': [
+    {'1': 'argv', '3': 1, '4': 3, '5': 9, '10': 'argv'},
+    {'1': 'env', '3': 2, '4': 3, '5': 9, '10': 'env'},
+    {'1': 'working_dir', '3': 3, '4': 1, '5': 9, '10': 'workingDir'},
+    {'1': 'timeout_seconds', '3': 4, '4': 1, '5': 3, '10': 'timeoutSeconds'},
+    {'1': 'user', '3': 5, '4': 1, '5': 9, '10': 'user'},
+    {'1': 'stdin', '3': 6, '4': 1, '5': 12, '10': 'stdin'},
+  ],
+};
+
+/// Descriptor for `RunCommandCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List runCommandCmdDescriptor = $convert.base64Decode(
+    'Cg1SdW5Db21tYW5kQ21kEhIKBGFyZ3YYASADKAlSBGFyZ3YSEAoDZW52GAIgAygJUgNlbnYSHw'
+    'oLd29ya2luZ19kaXIYAyABKAlSCndvcmtpbmdEaXISJwoPdGltZW91dF9zZWNvbmRzGAQgASgD'
+    'Ug50aW1lb3V0U2Vjb25kcxISCgR1c2VyGAUgASgJUgR1c2VyEhQKBXN0ZGluGAYgASgMUgVzdG'
+    'Rpbg==');
+
+@$core.Deprecated('Use commandOutputDescriptor instead')
+const CommandOutput$json = {
+  '1': 'CommandOutput',
+  '2': [
+    {'1': 'exit_code', '3': 1, '4': 1, '5': 5, '10': 'exitCode'},
+    {'1': 'stdout', '3': 2, '4': 1, '5': 12, '10': 'stdout'},
+    {'1': 'stderr', '3': 3, '4': 1, '5': 12, '10': 'stderr'},
+    {'1': 'truncated', '3': 4, '4': 1, '5': 8, '10': 'truncated'},
+  ],
+};
+
+/// Descriptor for `CommandOutput`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List commandOutputDescriptor = $convert.base64Decode(
+    'Cg1Db21tYW5kT3V0cHV0EhsKCWV4aXRfY29kZRgBIAEoBVIIZXhpdENvZGUSFgoGc3Rkb3V0GA'
+    'IgASgMUgZzdGRvdXQSFgoGc3RkZXJyGAMgASgMUgZzdGRlcnISHAoJdHJ1bmNhdGVkGAQgASgI'
+    'Ugl0cnVuY2F0ZWQ=');
+
+@$core.Deprecated('Use proServicesCmdDescriptor instead')
+const ProServicesCmd$json = {
+  '1': 'ProServicesCmd',
+  '2': [
+    {'1': 'enable', '3': 1, '4': 3, '5': 9, '10': 'enable'},
+    {'1': 'disable', '3': 2, '4': 3, '5': 9, '10': 'disable'},
+  ],
+};
+
+/// Descriptor for `ProServicesCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List proServicesCmdDescriptor = $convert.base64Decode(
+    'Cg5Qcm9TZXJ2aWNlc0NtZBIWCgZlbmFibGUYASADKAlSBmVuYWJsZRIYCgdkaXNhYmxlGAIgAy'
+    'gJUgdkaXNhYmxl');
+
+@$core.Deprecated('Use proServicesResultDescriptor instead')
+const ProServicesResult$json = {
+  '1': 'ProServicesResult',
+  '2': [
+    {
+      '1': 'services',
+      '3': 1,
+      '4': 3,
+      '5': 11,
+      '6': '.agentapi.ProServiceResult',
+      '10': 'services'
+    },
+  ],
+};
+
+/// Descriptor for `ProServicesResult`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List proServicesResultDescriptor = $convert.base64Decode(
+    'ChFQcm9TZXJ2aWNlc1Jlc3VsdBI2CghzZXJ2aWNlcxgBIAMoCzIaLmFnZW50YXBpLlByb1Nlcn'
+    'ZpY2VSZXN1bHRSCHNlcnZpY2Vz');
+
+@$core.Deprecated('Use proServiceResultDescriptor instead')
+const ProServiceResult$json = {
+  '1': 'ProServiceResult',
+  '2': [
+    {'1': 'name', '3': 1, '4': 1, '5': 9, '10': 'name'},
+    {'1': 'enable', '3': 2, '4': 1, '5': 8, '10': 'enable'},
+    {'1': 'error', '3': 3, '4': 1, '5': 9, '10': 'error'},
+  ],
+};
+
+/// Descriptor for `ProServiceResult`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List proServiceResultDescriptor = $convert.base64Decode(
+    'ChBQcm9TZXJ2aWNlUmVzdWx0EhIKBG5hbWUYASABKAlSBG5hbWUSFgoGZW5hYmxlGAIgASgIUg'
+    'ZlbmFibGUSFAoFZXJyb3IYAyABKAlSBWVycm9y');
+
+@$core.Deprecated('Use securityUpdateCmdDescriptor instead')
+const SecurityUpdateCmd$json = {
+  '1': 'SecurityUpdateCmd',
+};
+
+/// Descriptor for `SecurityUpdateCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List securityUpdateCmdDescriptor =
+    $convert.base64Decode('ChFTZWN1cml0eVVwZGF0ZUNtZA==');
+
+@$core.Deprecated('Use securityUpdateResultDescriptor instead')
+const SecurityUpdateResult$json = {
+  '1': 'SecurityUpdateResult',
+  '2': [
+    {
+      '1': 'packages',
+      '3': 1,
+      '4': 3,
+      '5': 11,
+      '6': '.agentapi.UpgradedPackage',
+      '10': 'packages'
+    },
+    {'1': 'reboot_required', '3': 2, '4': 1, '5': 8, '10': 'rebootRequired'},
+  ],
+};
+
+/// Descriptor for `SecurityUpdateResult`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List securityUpdateResultDescriptor =
+    $convert.base64Decode(
+        'ChRTZWN1cml0eVVwZGF0ZVJlc3VsdBI1CghwYWNrYWdlcxgBIAMoCzIZLmFnZW50YXBpLlVwZ3'
+        'JhZGVkUGFja2FnZVIIcGFja2FnZXMSJwoPcmVib290X3JlcXVpcmVkGAIgASgIUg5yZWJvb3RS'
+        'ZXF1aXJlZA==');
+
+@$core.Deprecated('Use upgradedPackageDescriptor instead')
+const UpgradedPackage$json = {
+  '1': 'UpgradedPackage',
+  '2': [
+    {'1': 'name', '3': 1, '4': 1, '5': 9, '10': 'name'},
+    {'1': 'old_version', '3': 2, '4': 1, '5': 9, '10': 'oldVersion'},
+    {'1': 'new_version', '3': 3, '4': 1, '5': 9, '10': 'newVersion'},
+  ],
+};
+
+/// Descriptor for `UpgradedPackage`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List upgradedPackageDescriptor = $convert.base64Decode(
+    'Cg9VcGdyYWRlZFBhY2thZ2USEgoEbmFtZRgBIAEoCVIEbmFtZRIfCgtvbGRfdmVyc2lvbhgCIA'
+    'EoCVIKb2xkVmVyc2lvbhIfCgtuZXdfdmVyc2lvbhgDIAEoCVIKbmV3VmVyc2lvbg==');
+
+@$core.Deprecated('Use packageInventoryCmdDescriptor instead')
+const PackageInventoryCmd$json = {
+  '1': 'PackageInventoryCmd',
+};
+
+/// Descriptor for `PackageInventoryCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List packageInventoryCmdDescriptor =
+    $convert.base64Decode('ChNQYWNrYWdlSW52ZW50b3J5Q21k');
+
+@$core.Deprecated('Use packageInventoryDescriptor instead')
+const PackageInventory$json = {
+  '1': 'PackageInventory',
+  '2': [
+    {'1': 'packages', '3': 1, '4': 1, '5': 12, '10': 'packages'},
+  ],
+};
+
+/// Descriptor for `PackageInventory`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List packageInventoryDescriptor =
+    $convert.base64Decode(
+        'ChBQYWNrYWdlSW52ZW50b3J5EhoKCHBhY2thZ2VzGAEgASgMUghwYWNrYWdlcw==');
+
+@$core.Deprecated('Use packageListDescriptor instead')
+const PackageList$json = {
+  '1': 'PackageList',
+  '2': [
+    {
+      '1': 'packages',
+      '3': 1,
+      '4': 3,
+      '5': 11,
+      '6': '.agentapi.InstalledPackage',
+      '10': 'packages'
+    },
+  ],
+};
+
+/// Descriptor for `PackageList`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List packageListDescriptor = $convert.base64Decode(
+    'CgtQYWNrYWdlTGlzdBI2CghwYWNrYWdlcxgBIAMoCzIaLmFnZW50YXBpLkluc3RhbGxlZFBhY2'
+    'thZ2VSCHBhY2thZ2Vz');
+
+@$core.Deprecated('Use installedPackageDescriptor instead')
+const InstalledPackage$json = {
+  '1': 'InstalledPackage',
+  '2': [
+    {'1': 'name', '3': 1, '4': 1, '5': 9, '10': 'name'},
+    {'1': 'version', '3': 2, '4': 1, '5': 9, '10': 'version'},
+    {'1': 'architecture', '3': 3, '4': 1, '5': 9, '10': 'architecture'},
+    {'1': 'origin', '3': 4, '4': 1, '5': 9, '10': 'origin'},
+    {'1': 'esm', '3': 5, '4': 1, '5': 8, '10': 'esm'},
+  ],
+};
+
+/// Descriptor for `InstalledPackage`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List installedPackageDescriptor = $convert.base64Decode(
+    'ChBJbnN0YWxsZWRQYWNrYWdlEhIKBG5hbWUYASABKAlSBG5hbWUSGAoHdmVyc2lvbhgCIAEoCV'
+    'IHdmVyc2lvbhIiCgxhcmNoaXRlY3R1cmUYAyABKAlSDGFyY2hpdGVjdHVyZRIWCgZvcmlnaW4Y'
+    'BCABKAlSBm9yaWdpbhIQCgNlc20YBSABKAhSA2VzbQ==');
+
+@$core.Deprecated('Use cancelCmdDescriptor instead')
+const CancelCmd$json = {
+  '1': 'CancelCmd',
+  '2': [
+    {'1': 'command', '3': 1, '4': 1, '5': 9, '10': 'command'},
+  ],
+};
+
+/// Descriptor for `CancelCmd`. Decode as a `google.protobuf.DescriptorProto`.
+final $typed_data.Uint8List cancelCmdDescriptor =
+    $convert.base64Decode('CglDYW5jZWxDbWQSGAoHY29tbWFuZBgBIAEoCVIHY29tbWFuZA==');
+
 @$core.Deprecated('Use mSGDescriptor instead')
 const MSG$json = {
   '1': 'MSG',
   '2': [
     {'1': 'wsl_name', '3': 1, '4': 1, '5': 9, '9': 0, '10': 'wslName'},
     {'1': 'result', '3': 2, '4': 1, '5': 9, '9': 0, '10': 'result'},
+    {
+      '1': 'command_output',
+      '3': 3,
+      '4': 1,
+      '5': 11,
+      '6': '.agentapi.CommandOutput',
+      '9': 0,
+      '10': 'commandOutput'
+    },
+    {
+      '1': 'pro_services',
+      '3': 4,
+      '4': 1,
+      '5': 11,
+      '6': '.agentapi.ProServicesResult',
+      '9': 0,
+      '10': 'proServices'
+    },
+    {
+      '1': 'security_update',
+      '3': 5,
+      '4': 1,
+      '5': 11,
+      '6': '.agentapi.SecurityUpdateResult',
+      '9': 0,
+      '10': 'securityUpdate'
+    },
+    {
+      '1': 'package_inventory',
+      '3': 6,
+      '4': 1,
+      '5': 11,
+      '6': '.agentapi.PackageInventory',
+      '9': 0,
+      '10': 'packageInventory'
+    },
   ],
   '8': [
     {'1': 'data'},
@@ -236,4 +823,9 @@ const MSG$json = {
 /// Descriptor for `MSG`. Decode as a `google.protobuf.DescriptorProto`.
 final $typed_data.Uint8List mSGDescriptor = $convert.base64Decode(
     'CgNNU0cSGwoId3NsX25hbWUYASABKAlIAFIHd3NsTmFtZRIYCgZyZXN1bHQYAiABKAlIAFIGcm'
-    'VzdWx0QgYKBGRhdGE=');
+    'VzdWx0EkAKDmNvbW1hbmRfb3V0cHV0GAMgASgLMhcuYWdlbnRhcGkuQ29tbWFuZE91dHB1dEgA'
+    'Ug1jb21tYW5kT3V0cHV0EkAKDHByb19zZXJ2aWNlcxgEIAEoCzIbLmFnZW50YXBpLlByb1Nlcn'
+    'ZpY2VzUmVzdWx0SABSC3Byb1NlcnZpY2VzEkkKD3NlY3VyaXR5X3VwZGF0ZRgFIAEoCzIeLmFn'
+    'ZW50YXBpLlNlY3VyaXR5VXBkYXRlUmVzdWx0SABSDnNlY3VyaXR5VXBkYXRlEkkKEXBhY2thZ2'
+    'VfaW52ZW50b3J5GAYgASgLMhouYWdlbnRhcGkuUGFja2FnZUludmVudG9yeUgAUhBwYWNrYWdl'
+    'SW52ZW50b3J5QgYKBGRhdGE=');
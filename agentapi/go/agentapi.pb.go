@@ -417,33 +417,1766 @@ func (x *ConfigSources) GetLandscapeSource() *LandscapeSource {
 	return nil
 }
 
+type DistroContract struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	Token         string                 `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`     // The obfuscated Ubuntu Pro token that applies to the instance.
+	Pattern       string                 `protobuf:"bytes,3,opt,name=pattern,proto3" json:"pattern,omitempty"` // The token map pattern that selected the token. Empty if the default subscription applies.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DistroContract) Reset() {
+	*x = DistroContract{}
+	mi := &file_agentapi_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistroContract) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistroContract) ProtoMessage() {}
+
+func (x *DistroContract) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistroContract.ProtoReflect.Descriptor instead.
+func (*DistroContract) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DistroContract) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *DistroContract) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *DistroContract) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+type DistroContracts struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Distros       []*DistroContract      `protobuf:"bytes,1,rep,name=distros,proto3" json:"distros,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DistroContracts) Reset() {
+	*x = DistroContracts{}
+	mi := &file_agentapi_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistroContracts) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistroContracts) ProtoMessage() {}
+
+func (x *DistroContracts) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistroContracts.ProtoReflect.Descriptor instead.
+func (*DistroContracts) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DistroContracts) GetDistros() []*DistroContract {
+	if x != nil {
+		return x.Distros
+	}
+	return nil
+}
+
+// DistroLabels contains the labels assigned by the user to a distro. An empty list removes them all.
+type DistroLabels struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	Labels        []string               `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DistroLabels) Reset() {
+	*x = DistroLabels{}
+	mi := &file_agentapi_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistroLabels) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistroLabels) ProtoMessage() {}
+
+func (x *DistroLabels) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistroLabels.ProtoReflect.Descriptor instead.
+func (*DistroLabels) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DistroLabels) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *DistroLabels) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// DeadLetter is a task that the agent gave up on after exhausting its retry policy.
+type DeadLetter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	Task          string                 `protobuf:"bytes,2,opt,name=task,proto3" json:"task,omitempty"`          // The description of the task.
+	Attempts      int32                  `protobuf:"varint,3,opt,name=attempts,proto3" json:"attempts,omitempty"` // The number of times the task was attempted.
+	Error         string                 `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`        // The error of the last attempt.
+	Time          int64                  `protobuf:"varint,5,opt,name=time,proto3" json:"time,omitempty"`         // When the task was given up on, in seconds since the Unix epoch.
+	Id            string                 `protobuf:"bytes,6,opt,name=id,proto3" json:"id,omitempty"`              // The identifier of the task. Empty for tasks that had none.
+	Type          string                 `protobuf:"bytes,7,opt,name=type,proto3" json:"type,omitempty"`          // The stable identifier of the type of the task, such as "pro-attachment".
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetter) Reset() {
+	*x = DeadLetter{}
+	mi := &file_agentapi_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetter) ProtoMessage() {}
+
+func (x *DeadLetter) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetter.ProtoReflect.Descriptor instead.
+func (*DeadLetter) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeadLetter) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetTask() string {
+	if x != nil {
+		return x.Task
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *DeadLetter) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeadLetter) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type DeadLetters struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Letters       []*DeadLetter          `protobuf:"bytes,1,rep,name=letters,proto3" json:"letters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeadLetters) Reset() {
+	*x = DeadLetters{}
+	mi := &file_agentapi_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeadLetters) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeadLetters) ProtoMessage() {}
+
+func (x *DeadLetters) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeadLetters.ProtoReflect.Descriptor instead.
+func (*DeadLetters) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DeadLetters) GetLetters() []*DeadLetter {
+	if x != nil {
+		return x.Letters
+	}
+	return nil
+}
+
+// QuarantinedTask is a stored task that the agent could not read back, such as one of a type it does not know about.
+type QuarantinedTask struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	Task          string                 `protobuf:"bytes,2,opt,name=task,proto3" json:"task,omitempty"`   // The task as it was stored.
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"` // The reason why it could not be read.
+	Time          int64                  `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`  // When the task was quarantined, in seconds since the Unix epoch.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuarantinedTask) Reset() {
+	*x = QuarantinedTask{}
+	mi := &file_agentapi_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuarantinedTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuarantinedTask) ProtoMessage() {}
+
+func (x *QuarantinedTask) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuarantinedTask.ProtoReflect.Descriptor instead.
+func (*QuarantinedTask) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *QuarantinedTask) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *QuarantinedTask) GetTask() string {
+	if x != nil {
+		return x.Task
+	}
+	return ""
+}
+
+func (x *QuarantinedTask) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *QuarantinedTask) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+type QuarantinedTasks struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tasks         []*QuarantinedTask     `protobuf:"bytes,1,rep,name=tasks,proto3" json:"tasks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuarantinedTasks) Reset() {
+	*x = QuarantinedTasks{}
+	mi := &file_agentapi_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuarantinedTasks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuarantinedTasks) ProtoMessage() {}
+
+func (x *QuarantinedTasks) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuarantinedTasks.ProtoReflect.Descriptor instead.
+func (*QuarantinedTasks) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *QuarantinedTasks) GetTasks() []*QuarantinedTask {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+// TaskHistoryRequest selects the distro whose task history is returned. An empty name selects all distros.
+type TaskHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskHistoryRequest) Reset() {
+	*x = TaskHistoryRequest{}
+	mi := &file_agentapi_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskHistoryRequest) ProtoMessage() {}
+
+func (x *TaskHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskHistoryRequest.ProtoReflect.Descriptor instead.
+func (*TaskHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TaskHistoryRequest) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+// TaskHistoryEntry is the outcome of an attempt at running a task.
+type TaskHistoryEntry struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	WslName        string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	Type           string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`                                             // The stable identifier of the type of the task, such as "security-update".
+	Summary        string                 `protobuf:"bytes,3,opt,name=summary,proto3" json:"summary,omitempty"`                                       // The description of the task, with any secret obfuscated.
+	Submitted      int64                  `protobuf:"varint,4,opt,name=submitted,proto3" json:"submitted,omitempty"`                                  // When the task was submitted, in seconds since the Unix epoch. Zero if unknown.
+	Started        int64                  `protobuf:"varint,5,opt,name=started,proto3" json:"started,omitempty"`                                      // When the attempt started, in seconds since the Unix epoch.
+	Ended          int64                  `protobuf:"varint,6,opt,name=ended,proto3" json:"ended,omitempty"`                                          // When the attempt ended, in seconds since the Unix epoch.
+	Attempts       int32                  `protobuf:"varint,7,opt,name=attempts,proto3" json:"attempts,omitempty"`                                    // The number of times the task was attempted so far.
+	Result         string                 `protobuf:"bytes,8,opt,name=result,proto3" json:"result,omitempty"`                                         // One of "succeeded", "retrying", "failed", "expired", "dropped" or "cancelled".
+	Error          string                 `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`                                           // The error of the attempt, if any.
+	Details        string                 `protobuf:"bytes,10,opt,name=details,proto3" json:"details,omitempty"`                                      // What the task reported about its outcome, if anything.
+	Id             string                 `protobuf:"bytes,11,opt,name=id,proto3" json:"id,omitempty"`                                                // The identifier of the task. Empty for tasks that had none.
+	RebootRequired bool                   `protobuf:"varint,12,opt,name=reboot_required,json=rebootRequired,proto3" json:"reboot_required,omitempty"` // Whether the distro must be restarted for the attempt to take effect.
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TaskHistoryEntry) Reset() {
+	*x = TaskHistoryEntry{}
+	mi := &file_agentapi_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskHistoryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskHistoryEntry) ProtoMessage() {}
+
+func (x *TaskHistoryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskHistoryEntry.ProtoReflect.Descriptor instead.
+func (*TaskHistoryEntry) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *TaskHistoryEntry) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *TaskHistoryEntry) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *TaskHistoryEntry) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *TaskHistoryEntry) GetSubmitted() int64 {
+	if x != nil {
+		return x.Submitted
+	}
+	return 0
+}
+
+func (x *TaskHistoryEntry) GetStarted() int64 {
+	if x != nil {
+		return x.Started
+	}
+	return 0
+}
+
+func (x *TaskHistoryEntry) GetEnded() int64 {
+	if x != nil {
+		return x.Ended
+	}
+	return 0
+}
+
+func (x *TaskHistoryEntry) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *TaskHistoryEntry) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *TaskHistoryEntry) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *TaskHistoryEntry) GetDetails() string {
+	if x != nil {
+		return x.Details
+	}
+	return ""
+}
+
+func (x *TaskHistoryEntry) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TaskHistoryEntry) GetRebootRequired() bool {
+	if x != nil {
+		return x.RebootRequired
+	}
+	return false
+}
+
+// TaskHistory contains the most recent task outcomes, oldest first for each distro.
+type TaskHistory struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*TaskHistoryEntry    `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskHistory) Reset() {
+	*x = TaskHistory{}
+	mi := &file_agentapi_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskHistory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskHistory) ProtoMessage() {}
+
+func (x *TaskHistory) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskHistory.ProtoReflect.Descriptor instead.
+func (*TaskHistory) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *TaskHistory) GetEntries() []*TaskHistoryEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+// QueuedTask is a task waiting to run, or running, in a distro.
+type QueuedTask struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`                // The identifier to cancel the task with.
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`            // The stable identifier of the type of the task, such as "pro-attachment".
+	Summary       string                 `protobuf:"bytes,4,opt,name=summary,proto3" json:"summary,omitempty"`      // The description of the task, with any secret obfuscated.
+	Submitted     int64                  `protobuf:"varint,5,opt,name=submitted,proto3" json:"submitted,omitempty"` // When the task was submitted, in seconds since the Unix epoch. Zero if unknown.
+	Running       bool                   `protobuf:"varint,6,opt,name=running,proto3" json:"running,omitempty"`     // Whether the task is running right now.
+	Deferred      bool                   `protobuf:"varint,7,opt,name=deferred,proto3" json:"deferred,omitempty"`   // Whether the task waits for the distro to be started by someone else.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueuedTask) Reset() {
+	*x = QueuedTask{}
+	mi := &file_agentapi_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueuedTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueuedTask) ProtoMessage() {}
+
+func (x *QueuedTask) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueuedTask.ProtoReflect.Descriptor instead.
+func (*QueuedTask) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *QueuedTask) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *QueuedTask) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *QueuedTask) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *QueuedTask) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *QueuedTask) GetSubmitted() int64 {
+	if x != nil {
+		return x.Submitted
+	}
+	return 0
+}
+
+func (x *QueuedTask) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *QueuedTask) GetDeferred() bool {
+	if x != nil {
+		return x.Deferred
+	}
+	return false
+}
+
+// QueuedTasks contains the tasks of the distros that did not complete yet, running ones first.
+type QueuedTasks struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tasks         []*QueuedTask          `protobuf:"bytes,1,rep,name=tasks,proto3" json:"tasks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueuedTasks) Reset() {
+	*x = QueuedTasks{}
+	mi := &file_agentapi_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueuedTasks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueuedTasks) ProtoMessage() {}
+
+func (x *QueuedTasks) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueuedTasks.ProtoReflect.Descriptor instead.
+func (*QueuedTasks) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *QueuedTasks) GetTasks() []*QueuedTask {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+// CancelTaskRequest selects the task to cancel. A queued task is removed from the queue, and a running one
+// is interrupted along with the commands it is running in the distro.
+type CancelTaskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelTaskRequest) Reset() {
+	*x = CancelTaskRequest{}
+	mi := &file_agentapi_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelTaskRequest) ProtoMessage() {}
+
+func (x *CancelTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelTaskRequest.ProtoReflect.Descriptor instead.
+func (*CancelTaskRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CancelTaskRequest) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *CancelTaskRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+// SecurityUpdateRequest selects the distros where security updates are applied, either by name or by label.
+// Selecting neither selects all distros.
+type SecurityUpdateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslNames      []string               `protobuf:"bytes,1,rep,name=wsl_names,json=wslNames,proto3" json:"wsl_names,omitempty"`
+	Labels        []string               `protobuf:"bytes,2,rep,name=labels,proto3" json:"labels,omitempty"` // Selects the distros that carry all of these labels.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SecurityUpdateRequest) Reset() {
+	*x = SecurityUpdateRequest{}
+	mi := &file_agentapi_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SecurityUpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SecurityUpdateRequest) ProtoMessage() {}
+
+func (x *SecurityUpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SecurityUpdateRequest.ProtoReflect.Descriptor instead.
+func (*SecurityUpdateRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SecurityUpdateRequest) GetWslNames() []string {
+	if x != nil {
+		return x.WslNames
+	}
+	return nil
+}
+
+func (x *SecurityUpdateRequest) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// RunCommandRequest selects the distros where a command runs, either by name or by label, and the command to run.
+// Selecting neither selects all distros.
+type RunCommandRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	WslNames       []string               `protobuf:"bytes,1,rep,name=wsl_names,json=wslNames,proto3" json:"wsl_names,omitempty"`
+	Argv           []string               `protobuf:"bytes,2,rep,name=argv,proto3" json:"argv,omitempty"`                                            // The executable followed by its arguments.
+	Env            []string               `protobuf:"bytes,3,rep,name=env,proto3" json:"env,omitempty"`                                              // KEY=VALUE pairs added to the environment of the command.
+	WorkingDir     string                 `protobuf:"bytes,4,opt,name=working_dir,json=workingDir,proto3" json:"working_dir,omitempty"`              // Empty means the default one.
+	TimeoutSeconds int64                  `protobuf:"varint,5,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"` // Zero means no timeout.
+	User           string                 `protobuf:"bytes,6,opt,name=user,proto3" json:"user,omitempty"`                                            // The Linux user running the command. Empty means root.
+	Labels         []string               `protobuf:"bytes,7,rep,name=labels,proto3" json:"labels,omitempty"`                                        // Selects the distros that carry all of these labels.
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RunCommandRequest) Reset() {
+	*x = RunCommandRequest{}
+	mi := &file_agentapi_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunCommandRequest) ProtoMessage() {}
+
+func (x *RunCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunCommandRequest.ProtoReflect.Descriptor instead.
+func (*RunCommandRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RunCommandRequest) GetWslNames() []string {
+	if x != nil {
+		return x.WslNames
+	}
+	return nil
+}
+
+func (x *RunCommandRequest) GetArgv() []string {
+	if x != nil {
+		return x.Argv
+	}
+	return nil
+}
+
+func (x *RunCommandRequest) GetEnv() []string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *RunCommandRequest) GetWorkingDir() string {
+	if x != nil {
+		return x.WorkingDir
+	}
+	return ""
+}
+
+func (x *RunCommandRequest) GetTimeoutSeconds() int64 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+func (x *RunCommandRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *RunCommandRequest) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+// InventoryRequest selects the package inventories to export, and how.
+type InventoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"` // The distro whose inventory is exported. Empty for all distros.
+	Format        string                 `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`                  // Either "json" or "csv". Empty for "json".
+	Diff          bool                   `protobuf:"varint,3,opt,name=diff,proto3" json:"diff,omitempty"`                     // Export the changes since the previous inventory instead of the installed packages.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InventoryRequest) Reset() {
+	*x = InventoryRequest{}
+	mi := &file_agentapi_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InventoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InventoryRequest) ProtoMessage() {}
+
+func (x *InventoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InventoryRequest.ProtoReflect.Descriptor instead.
+func (*InventoryRequest) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *InventoryRequest) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *InventoryRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *InventoryRequest) GetDiff() bool {
+	if x != nil {
+		return x.Diff
+	}
+	return false
+}
+
+type InventoryExport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"` // The inventories in the requested format.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InventoryExport) Reset() {
+	*x = InventoryExport{}
+	mi := &file_agentapi_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InventoryExport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InventoryExport) ProtoMessage() {}
+
+func (x *InventoryExport) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InventoryExport.ProtoReflect.Descriptor instead.
+func (*InventoryExport) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *InventoryExport) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+// StartMetrics is a snapshot of the admission of distro starts, which are limited to a few at a time.
+type StartMetrics struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Parallelism       int64                  `protobuf:"varint,1,opt,name=parallelism,proto3" json:"parallelism,omitempty"`                                      // The number of distros allowed to start at the same time.
+	Running           int64                  `protobuf:"varint,2,opt,name=running,proto3" json:"running,omitempty"`                                              // The number of distros starting right now.
+	QueuedInteractive int64                  `protobuf:"varint,3,opt,name=queued_interactive,json=queuedInteractive,proto3" json:"queued_interactive,omitempty"` // The number of starts requested by a user or by Landscape waiting to be admitted.
+	QueuedBackground  int64                  `protobuf:"varint,4,opt,name=queued_background,json=queuedBackground,proto3" json:"queued_background,omitempty"`    // The number of starts needed to run tasks waiting to be admitted.
+	MaxQueued         int64                  `protobuf:"varint,5,opt,name=max_queued,json=maxQueued,proto3" json:"max_queued,omitempty"`                         // The highest number of starts that were waiting at the same time.
+	Admitted          int64                  `protobuf:"varint,6,opt,name=admitted,proto3" json:"admitted,omitempty"`                                            // The number of starts admitted since the agent started.
+	TimedOut          int64                  `protobuf:"varint,7,opt,name=timed_out,json=timedOut,proto3" json:"timed_out,omitempty"`                            // The number of starts that timed out or were cancelled before being admitted.
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *StartMetrics) Reset() {
+	*x = StartMetrics{}
+	mi := &file_agentapi_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartMetrics) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartMetrics) ProtoMessage() {}
+
+func (x *StartMetrics) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartMetrics.ProtoReflect.Descriptor instead.
+func (*StartMetrics) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *StartMetrics) GetParallelism() int64 {
+	if x != nil {
+		return x.Parallelism
+	}
+	return 0
+}
+
+func (x *StartMetrics) GetRunning() int64 {
+	if x != nil {
+		return x.Running
+	}
+	return 0
+}
+
+func (x *StartMetrics) GetQueuedInteractive() int64 {
+	if x != nil {
+		return x.QueuedInteractive
+	}
+	return 0
+}
+
+func (x *StartMetrics) GetQueuedBackground() int64 {
+	if x != nil {
+		return x.QueuedBackground
+	}
+	return 0
+}
+
+func (x *StartMetrics) GetMaxQueued() int64 {
+	if x != nil {
+		return x.MaxQueued
+	}
+	return 0
+}
+
+func (x *StartMetrics) GetAdmitted() int64 {
+	if x != nil {
+		return x.Admitted
+	}
+	return 0
+}
+
+func (x *StartMetrics) GetTimedOut() int64 {
+	if x != nil {
+		return x.TimedOut
+	}
+	return 0
+}
+
 type DistroInfo struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	WslName        string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
+	Id             string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	VersionId      string                 `protobuf:"bytes,3,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
+	PrettyName     string                 `protobuf:"bytes,4,opt,name=pretty_name,json=prettyName,proto3" json:"pretty_name,omitempty"`
+	ProAttached    bool                   `protobuf:"varint,5,opt,name=pro_attached,json=proAttached,proto3" json:"pro_attached,omitempty"`
+	Hostname       string                 `protobuf:"bytes,6,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	ServiceVersion string                 `protobuf:"bytes,7,opt,name=service_version,json=serviceVersion,proto3" json:"service_version,omitempty"`
+	Labels         []string               `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty"`             // The labels declared from inside the distro.
+	Capabilities   []string               `protobuf:"bytes,9,rep,name=capabilities,proto3" json:"capabilities,omitempty"` // The full method names of the optional command streams that the service opens.
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DistroInfo) Reset() {
+	*x = DistroInfo{}
+	mi := &file_agentapi_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DistroInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DistroInfo) ProtoMessage() {}
+
+func (x *DistroInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DistroInfo.ProtoReflect.Descriptor instead.
+func (*DistroInfo) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *DistroInfo) GetWslName() string {
+	if x != nil {
+		return x.WslName
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetVersionId() string {
+	if x != nil {
+		return x.VersionId
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetPrettyName() string {
+	if x != nil {
+		return x.PrettyName
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetProAttached() bool {
+	if x != nil {
+		return x.ProAttached
+	}
+	return false
+}
+
+func (x *DistroInfo) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetServiceVersion() string {
+	if x != nil {
+		return x.ServiceVersion
+	}
+	return ""
+}
+
+func (x *DistroInfo) GetLabels() []string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *DistroInfo) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+type ProAttachCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProAttachCmd) Reset() {
+	*x = ProAttachCmd{}
+	mi := &file_agentapi_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProAttachCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProAttachCmd) ProtoMessage() {}
+
+func (x *ProAttachCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProAttachCmd.ProtoReflect.Descriptor instead.
+func (*ProAttachCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ProAttachCmd) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type LandscapeConfigCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Config        string                 `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LandscapeConfigCmd) Reset() {
+	*x = LandscapeConfigCmd{}
+	mi := &file_agentapi_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LandscapeConfigCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LandscapeConfigCmd) ProtoMessage() {}
+
+func (x *LandscapeConfigCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LandscapeConfigCmd.ProtoReflect.Descriptor instead.
+func (*LandscapeConfigCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *LandscapeConfigCmd) GetConfig() string {
+	if x != nil {
+		return x.Config
+	}
+	return ""
+}
+
+// ProxyConfigCmd contains the proxy settings for the distro. Empty values unset the proxy.
+type ProxyConfigCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HttpProxy     string                 `protobuf:"bytes,1,opt,name=http_proxy,json=httpProxy,proto3" json:"http_proxy,omitempty"`
+	HttpsProxy    string                 `protobuf:"bytes,2,opt,name=https_proxy,json=httpsProxy,proto3" json:"https_proxy,omitempty"`
+	NoProxy       string                 `protobuf:"bytes,3,opt,name=no_proxy,json=noProxy,proto3" json:"no_proxy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProxyConfigCmd) Reset() {
+	*x = ProxyConfigCmd{}
+	mi := &file_agentapi_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProxyConfigCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProxyConfigCmd) ProtoMessage() {}
+
+func (x *ProxyConfigCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProxyConfigCmd.ProtoReflect.Descriptor instead.
+func (*ProxyConfigCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ProxyConfigCmd) GetHttpProxy() string {
+	if x != nil {
+		return x.HttpProxy
+	}
+	return ""
+}
+
+func (x *ProxyConfigCmd) GetHttpsProxy() string {
+	if x != nil {
+		return x.HttpsProxy
+	}
+	return ""
+}
+
+func (x *ProxyConfigCmd) GetNoProxy() string {
+	if x != nil {
+		return x.NoProxy
+	}
+	return ""
+}
+
+// RunCommandCmd asks the distro to run a command.
+type RunCommandCmd struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Argv           []string               `protobuf:"bytes,1,rep,name=argv,proto3" json:"argv,omitempty"`                                            // The command and its arguments. The command is looked up in the PATH.
+	Env            []string               `protobuf:"bytes,2,rep,name=env,proto3" json:"env,omitempty"`                                              // Environment variables in KEY=VALUE form, added to the ones of the service.
+	WorkingDir     string                 `protobuf:"bytes,3,opt,name=working_dir,json=workingDir,proto3" json:"working_dir,omitempty"`              // The directory to run the command in. Empty for the one of the service.
+	TimeoutSeconds int64                  `protobuf:"varint,4,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"` // The time after which the command is killed. Zero for no timeout.
+	User           string                 `protobuf:"bytes,5,opt,name=user,proto3" json:"user,omitempty"`                                            // The user to run the command as. Empty for root.
+	Stdin          []byte                 `protobuf:"bytes,6,opt,name=stdin,proto3" json:"stdin,omitempty"`                                          // The standard input of the command.
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RunCommandCmd) Reset() {
+	*x = RunCommandCmd{}
+	mi := &file_agentapi_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunCommandCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunCommandCmd) ProtoMessage() {}
+
+func (x *RunCommandCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunCommandCmd.ProtoReflect.Descriptor instead.
+func (*RunCommandCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *RunCommandCmd) GetArgv() []string {
+	if x != nil {
+		return x.Argv
+	}
+	return nil
+}
+
+func (x *RunCommandCmd) GetEnv() []string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *RunCommandCmd) GetWorkingDir() string {
+	if x != nil {
+		return x.WorkingDir
+	}
+	return ""
+}
+
+func (x *RunCommandCmd) GetTimeoutSeconds() int64 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+func (x *RunCommandCmd) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *RunCommandCmd) GetStdin() []byte {
+	if x != nil {
+		return x.Stdin
+	}
+	return nil
+}
+
+// CommandOutput is the outcome of a command that ran in the distro.
+type CommandOutput struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExitCode      int32                  `protobuf:"varint,1,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	Stdout        []byte                 `protobuf:"bytes,2,opt,name=stdout,proto3" json:"stdout,omitempty"`        // The beginning of the standard output of the command.
+	Stderr        []byte                 `protobuf:"bytes,3,opt,name=stderr,proto3" json:"stderr,omitempty"`        // The beginning of the standard error of the command.
+	Truncated     bool                   `protobuf:"varint,4,opt,name=truncated,proto3" json:"truncated,omitempty"` // Whether any of the outputs was too long to be returned in full.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CommandOutput) Reset() {
+	*x = CommandOutput{}
+	mi := &file_agentapi_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CommandOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CommandOutput) ProtoMessage() {}
+
+func (x *CommandOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CommandOutput.ProtoReflect.Descriptor instead.
+func (*CommandOutput) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *CommandOutput) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *CommandOutput) GetStdout() []byte {
+	if x != nil {
+		return x.Stdout
+	}
+	return nil
+}
+
+func (x *CommandOutput) GetStderr() []byte {
+	if x != nil {
+		return x.Stderr
+	}
+	return nil
+}
+
+func (x *CommandOutput) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+// ProServicesCmd asks the distro to enable and disable Ubuntu Pro services. Services not listed are left as they are.
+type ProServicesCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Enable        []string               `protobuf:"bytes,1,rep,name=enable,proto3" json:"enable,omitempty"`
+	Disable       []string               `protobuf:"bytes,2,rep,name=disable,proto3" json:"disable,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProServicesCmd) Reset() {
+	*x = ProServicesCmd{}
+	mi := &file_agentapi_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProServicesCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProServicesCmd) ProtoMessage() {}
+
+func (x *ProServicesCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProServicesCmd.ProtoReflect.Descriptor instead.
+func (*ProServicesCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ProServicesCmd) GetEnable() []string {
+	if x != nil {
+		return x.Enable
+	}
+	return nil
+}
+
+func (x *ProServicesCmd) GetDisable() []string {
+	if x != nil {
+		return x.Disable
+	}
+	return nil
+}
+
+// ProServicesResult is the outcome of a ProServicesCmd, service by service.
+type ProServicesResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Services      []*ProServiceResult    `protobuf:"bytes,1,rep,name=services,proto3" json:"services,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProServicesResult) Reset() {
+	*x = ProServicesResult{}
+	mi := &file_agentapi_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProServicesResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProServicesResult) ProtoMessage() {}
+
+func (x *ProServicesResult) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProServicesResult.ProtoReflect.Descriptor instead.
+func (*ProServicesResult) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ProServicesResult) GetServices() []*ProServiceResult {
+	if x != nil {
+		return x.Services
+	}
+	return nil
+}
+
+type ProServiceResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Enable        bool                   `protobuf:"varint,2,opt,name=enable,proto3" json:"enable,omitempty"` // Whether the service was to be enabled or disabled.
+	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`    // Empty if the service was enabled or disabled successfully.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProServiceResult) Reset() {
+	*x = ProServiceResult{}
+	mi := &file_agentapi_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProServiceResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProServiceResult) ProtoMessage() {}
+
+func (x *ProServiceResult) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProServiceResult.ProtoReflect.Descriptor instead.
+func (*ProServiceResult) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ProServiceResult) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ProServiceResult) GetEnable() bool {
+	if x != nil {
+		return x.Enable
+	}
+	return false
+}
+
+func (x *ProServiceResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// SecurityUpdateCmd asks the distro to refresh its package lists and apply the pending security
+// upgrades, including the ones from Expanded Security Maintenance when the distro is attached.
+type SecurityUpdateCmd struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	WslName       string                 `protobuf:"bytes,1,opt,name=wsl_name,json=wslName,proto3" json:"wsl_name,omitempty"`
-	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
-	VersionId     string                 `protobuf:"bytes,3,opt,name=version_id,json=versionId,proto3" json:"version_id,omitempty"`
-	PrettyName    string                 `protobuf:"bytes,4,opt,name=pretty_name,json=prettyName,proto3" json:"pretty_name,omitempty"`
-	ProAttached   bool                   `protobuf:"varint,5,opt,name=pro_attached,json=proAttached,proto3" json:"pro_attached,omitempty"`
-	Hostname      string                 `protobuf:"bytes,6,opt,name=hostname,proto3" json:"hostname,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DistroInfo) Reset() {
-	*x = DistroInfo{}
-	mi := &file_agentapi_proto_msgTypes[6]
+func (x *SecurityUpdateCmd) Reset() {
+	*x = SecurityUpdateCmd{}
+	mi := &file_agentapi_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DistroInfo) String() string {
+func (x *SecurityUpdateCmd) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DistroInfo) ProtoMessage() {}
+func (*SecurityUpdateCmd) ProtoMessage() {}
 
-func (x *DistroInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_agentapi_proto_msgTypes[6]
+func (x *SecurityUpdateCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -454,75 +2187,228 @@ func (x *DistroInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DistroInfo.ProtoReflect.Descriptor instead.
-func (*DistroInfo) Descriptor() ([]byte, []int) {
-	return file_agentapi_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use SecurityUpdateCmd.ProtoReflect.Descriptor instead.
+func (*SecurityUpdateCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{33}
 }
 
-func (x *DistroInfo) GetWslName() string {
+// SecurityUpdateResult is the outcome of a SecurityUpdateCmd.
+type SecurityUpdateResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Packages       []*UpgradedPackage     `protobuf:"bytes,1,rep,name=packages,proto3" json:"packages,omitempty"`
+	RebootRequired bool                   `protobuf:"varint,2,opt,name=reboot_required,json=rebootRequired,proto3" json:"reboot_required,omitempty"` // Whether the distro must be restarted (terminated) for the upgrades to take effect.
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SecurityUpdateResult) Reset() {
+	*x = SecurityUpdateResult{}
+	mi := &file_agentapi_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SecurityUpdateResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SecurityUpdateResult) ProtoMessage() {}
+
+func (x *SecurityUpdateResult) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[34]
 	if x != nil {
-		return x.WslName
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *DistroInfo) GetId() string {
+// Deprecated: Use SecurityUpdateResult.ProtoReflect.Descriptor instead.
+func (*SecurityUpdateResult) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *SecurityUpdateResult) GetPackages() []*UpgradedPackage {
 	if x != nil {
-		return x.Id
+		return x.Packages
+	}
+	return nil
+}
+
+func (x *SecurityUpdateResult) GetRebootRequired() bool {
+	if x != nil {
+		return x.RebootRequired
+	}
+	return false
+}
+
+type UpgradedPackage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	OldVersion    string                 `protobuf:"bytes,2,opt,name=old_version,json=oldVersion,proto3" json:"old_version,omitempty"`
+	NewVersion    string                 `protobuf:"bytes,3,opt,name=new_version,json=newVersion,proto3" json:"new_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpgradedPackage) Reset() {
+	*x = UpgradedPackage{}
+	mi := &file_agentapi_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpgradedPackage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpgradedPackage) ProtoMessage() {}
+
+func (x *UpgradedPackage) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpgradedPackage.ProtoReflect.Descriptor instead.
+func (*UpgradedPackage) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *UpgradedPackage) GetName() string {
+	if x != nil {
+		return x.Name
 	}
 	return ""
 }
 
-func (x *DistroInfo) GetVersionId() string {
+func (x *UpgradedPackage) GetOldVersion() string {
 	if x != nil {
-		return x.VersionId
+		return x.OldVersion
 	}
 	return ""
 }
 
-func (x *DistroInfo) GetPrettyName() string {
+func (x *UpgradedPackage) GetNewVersion() string {
 	if x != nil {
-		return x.PrettyName
+		return x.NewVersion
 	}
 	return ""
 }
 
-func (x *DistroInfo) GetProAttached() bool {
+// PackageInventoryCmd asks the distro for the list of its installed packages.
+type PackageInventoryCmd struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PackageInventoryCmd) Reset() {
+	*x = PackageInventoryCmd{}
+	mi := &file_agentapi_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PackageInventoryCmd) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PackageInventoryCmd) ProtoMessage() {}
+
+func (x *PackageInventoryCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[36]
 	if x != nil {
-		return x.ProAttached
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return false
+	return mi.MessageOf(x)
 }
 
-func (x *DistroInfo) GetHostname() string {
+// Deprecated: Use PackageInventoryCmd.ProtoReflect.Descriptor instead.
+func (*PackageInventoryCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{36}
+}
+
+// PackageInventory is the outcome of a PackageInventoryCmd.
+type PackageInventory struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Packages      []byte                 `protobuf:"bytes,1,opt,name=packages,proto3" json:"packages,omitempty"` // A gzip-compressed, serialized PackageList.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PackageInventory) Reset() {
+	*x = PackageInventory{}
+	mi := &file_agentapi_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PackageInventory) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PackageInventory) ProtoMessage() {}
+
+func (x *PackageInventory) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[37]
 	if x != nil {
-		return x.Hostname
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-type ProAttachCmd struct {
+// Deprecated: Use PackageInventory.ProtoReflect.Descriptor instead.
+func (*PackageInventory) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *PackageInventory) GetPackages() []byte {
+	if x != nil {
+		return x.Packages
+	}
+	return nil
+}
+
+type PackageList struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	Packages      []*InstalledPackage    `protobuf:"bytes,1,rep,name=packages,proto3" json:"packages,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ProAttachCmd) Reset() {
-	*x = ProAttachCmd{}
-	mi := &file_agentapi_proto_msgTypes[7]
+func (x *PackageList) Reset() {
+	*x = PackageList{}
+	mi := &file_agentapi_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ProAttachCmd) String() string {
+func (x *PackageList) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ProAttachCmd) ProtoMessage() {}
+func (*PackageList) ProtoMessage() {}
 
-func (x *ProAttachCmd) ProtoReflect() protoreflect.Message {
-	mi := &file_agentapi_proto_msgTypes[7]
+func (x *PackageList) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -533,40 +2419,118 @@ func (x *ProAttachCmd) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ProAttachCmd.ProtoReflect.Descriptor instead.
-func (*ProAttachCmd) Descriptor() ([]byte, []int) {
-	return file_agentapi_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use PackageList.ProtoReflect.Descriptor instead.
+func (*PackageList) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{38}
 }
 
-func (x *ProAttachCmd) GetToken() string {
+func (x *PackageList) GetPackages() []*InstalledPackage {
 	if x != nil {
-		return x.Token
+		return x.Packages
+	}
+	return nil
+}
+
+type InstalledPackage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	Architecture  string                 `protobuf:"bytes,3,opt,name=architecture,proto3" json:"architecture,omitempty"`
+	Origin        string                 `protobuf:"bytes,4,opt,name=origin,proto3" json:"origin,omitempty"` // The archive the installed version comes from, such as "noble-updates". Empty if unknown.
+	Esm           bool                   `protobuf:"varint,5,opt,name=esm,proto3" json:"esm,omitempty"`      // Whether the installed version comes from Expanded Security Maintenance.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InstalledPackage) Reset() {
+	*x = InstalledPackage{}
+	mi := &file_agentapi_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InstalledPackage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InstalledPackage) ProtoMessage() {}
+
+func (x *InstalledPackage) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InstalledPackage.ProtoReflect.Descriptor instead.
+func (*InstalledPackage) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *InstalledPackage) GetName() string {
+	if x != nil {
+		return x.Name
 	}
 	return ""
 }
 
-type LandscapeConfigCmd struct {
+func (x *InstalledPackage) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *InstalledPackage) GetArchitecture() string {
+	if x != nil {
+		return x.Architecture
+	}
+	return ""
+}
+
+func (x *InstalledPackage) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *InstalledPackage) GetEsm() bool {
+	if x != nil {
+		return x.Esm
+	}
+	return false
+}
+
+// CancelCmd asks the distro to cancel a command it is running on behalf of the agent. The cancelled
+// command replies with a result reporting the cancellation.
+type CancelCmd struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Config        string                 `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"` // Name of the command message to cancel, such as "RunCommandCmd". Empty cancels them all.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LandscapeConfigCmd) Reset() {
-	*x = LandscapeConfigCmd{}
-	mi := &file_agentapi_proto_msgTypes[8]
+func (x *CancelCmd) Reset() {
+	*x = CancelCmd{}
+	mi := &file_agentapi_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LandscapeConfigCmd) String() string {
+func (x *CancelCmd) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LandscapeConfigCmd) ProtoMessage() {}
+func (*CancelCmd) ProtoMessage() {}
 
-func (x *LandscapeConfigCmd) ProtoReflect() protoreflect.Message {
-	mi := &file_agentapi_proto_msgTypes[8]
+func (x *CancelCmd) ProtoReflect() protoreflect.Message {
+	mi := &file_agentapi_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -577,14 +2541,14 @@ func (x *LandscapeConfigCmd) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LandscapeConfigCmd.ProtoReflect.Descriptor instead.
-func (*LandscapeConfigCmd) Descriptor() ([]byte, []int) {
-	return file_agentapi_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use CancelCmd.ProtoReflect.Descriptor instead.
+func (*CancelCmd) Descriptor() ([]byte, []int) {
+	return file_agentapi_proto_rawDescGZIP(), []int{40}
 }
 
-func (x *LandscapeConfigCmd) GetConfig() string {
+func (x *CancelCmd) GetCommand() string {
 	if x != nil {
-		return x.Config
+		return x.Command
 	}
 	return ""
 }
@@ -595,6 +2559,10 @@ type MSG struct {
 	//
 	//	*MSG_WslName
 	//	*MSG_Result
+	//	*MSG_CommandOutput
+	//	*MSG_ProServices
+	//	*MSG_SecurityUpdate
+	//	*MSG_PackageInventory
 	Data          isMSG_Data `protobuf_oneof:"data"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -602,7 +2570,7 @@ type MSG struct {
 
 func (x *MSG) Reset() {
 	*x = MSG{}
-	mi := &file_agentapi_proto_msgTypes[9]
+	mi := &file_agentapi_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -614,7 +2582,7 @@ func (x *MSG) String() string {
 func (*MSG) ProtoMessage() {}
 
 func (x *MSG) ProtoReflect() protoreflect.Message {
-	mi := &file_agentapi_proto_msgTypes[9]
+	mi := &file_agentapi_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -627,7 +2595,7 @@ func (x *MSG) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MSG.ProtoReflect.Descriptor instead.
 func (*MSG) Descriptor() ([]byte, []int) {
-	return file_agentapi_proto_rawDescGZIP(), []int{9}
+	return file_agentapi_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *MSG) GetData() isMSG_Data {
@@ -655,6 +2623,42 @@ func (x *MSG) GetResult() string {
 	return ""
 }
 
+func (x *MSG) GetCommandOutput() *CommandOutput {
+	if x != nil {
+		if x, ok := x.Data.(*MSG_CommandOutput); ok {
+			return x.CommandOutput
+		}
+	}
+	return nil
+}
+
+func (x *MSG) GetProServices() *ProServicesResult {
+	if x != nil {
+		if x, ok := x.Data.(*MSG_ProServices); ok {
+			return x.ProServices
+		}
+	}
+	return nil
+}
+
+func (x *MSG) GetSecurityUpdate() *SecurityUpdateResult {
+	if x != nil {
+		if x, ok := x.Data.(*MSG_SecurityUpdate); ok {
+			return x.SecurityUpdate
+		}
+	}
+	return nil
+}
+
+func (x *MSG) GetPackageInventory() *PackageInventory {
+	if x != nil {
+		if x, ok := x.Data.(*MSG_PackageInventory); ok {
+			return x.PackageInventory
+		}
+	}
+	return nil
+}
+
 type isMSG_Data interface {
 	isMSG_Data()
 }
@@ -667,10 +2671,34 @@ type MSG_Result struct {
 	Result string `protobuf:"bytes,2,opt,name=result,proto3,oneof"` // Used in response to a command
 }
 
+type MSG_CommandOutput struct {
+	CommandOutput *CommandOutput `protobuf:"bytes,3,opt,name=command_output,json=commandOutput,proto3,oneof"` // Used in response to a RunCommandCmd that could run.
+}
+
+type MSG_ProServices struct {
+	ProServices *ProServicesResult `protobuf:"bytes,4,opt,name=pro_services,json=proServices,proto3,oneof"` // Used in response to a ProServicesCmd that could run.
+}
+
+type MSG_SecurityUpdate struct {
+	SecurityUpdate *SecurityUpdateResult `protobuf:"bytes,5,opt,name=security_update,json=securityUpdate,proto3,oneof"` // Used in response to a SecurityUpdateCmd that could run.
+}
+
+type MSG_PackageInventory struct {
+	PackageInventory *PackageInventory `protobuf:"bytes,6,opt,name=package_inventory,json=packageInventory,proto3,oneof"` // Used in response to a PackageInventoryCmd that could run.
+}
+
 func (*MSG_WslName) isMSG_Data() {}
 
 func (*MSG_Result) isMSG_Data() {}
 
+func (*MSG_CommandOutput) isMSG_Data() {}
+
+func (*MSG_ProServices) isMSG_Data() {}
+
+func (*MSG_SecurityUpdate) isMSG_Data() {}
+
+func (*MSG_PackageInventory) isMSG_Data() {}
+
 var File_agentapi_proto protoreflect.FileDescriptor
 
 const file_agentapi_proto_rawDesc = "" +
@@ -695,7 +2723,93 @@ const file_agentapi_proto_rawDesc = "" +
 	"\x13landscapeSourceType\"\x9a\x01\n" +
 	"\rConfigSources\x12D\n" +
 	"\x0fproSubscription\x18\x01 \x01(\v2\x1a.agentapi.SubscriptionInfoR\x0fproSubscription\x12C\n" +
-	"\x0flandscapeSource\x18\x02 \x01(\v2\x19.agentapi.LandscapeSourceR\x0flandscapeSource\"\xb6\x01\n" +
+	"\x0flandscapeSource\x18\x02 \x01(\v2\x19.agentapi.LandscapeSourceR\x0flandscapeSource\"[\n" +
+	"\x0eDistroContract\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x18\n" +
+	"\apattern\x18\x03 \x01(\tR\apattern\"E\n" +
+	"\x0fDistroContracts\x122\n" +
+	"\adistros\x18\x01 \x03(\v2\x18.agentapi.DistroContractR\adistros\"A\n" +
+	"\fDistroLabels\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x16\n" +
+	"\x06labels\x18\x02 \x03(\tR\x06labels\"\xa5\x01\n" +
+	"\n" +
+	"DeadLetter\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x12\n" +
+	"\x04task\x18\x02 \x01(\tR\x04task\x12\x1a\n" +
+	"\battempts\x18\x03 \x01(\x05R\battempts\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x12\n" +
+	"\x04time\x18\x05 \x01(\x03R\x04time\x12\x0e\n" +
+	"\x02id\x18\x06 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\a \x01(\tR\x04type\"=\n" +
+	"\vDeadLetters\x12.\n" +
+	"\aletters\x18\x01 \x03(\v2\x14.agentapi.DeadLetterR\aletters\"j\n" +
+	"\x0fQuarantinedTask\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x12\n" +
+	"\x04task\x18\x02 \x01(\tR\x04task\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x12\n" +
+	"\x04time\x18\x04 \x01(\x03R\x04time\"C\n" +
+	"\x10QuarantinedTasks\x12/\n" +
+	"\x05tasks\x18\x01 \x03(\v2\x19.agentapi.QuarantinedTaskR\x05tasks\"/\n" +
+	"\x12TaskHistoryRequest\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\"\xc6\x02\n" +
+	"\x10TaskHistoryEntry\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\asummary\x18\x03 \x01(\tR\asummary\x12\x1c\n" +
+	"\tsubmitted\x18\x04 \x01(\x03R\tsubmitted\x12\x18\n" +
+	"\astarted\x18\x05 \x01(\x03R\astarted\x12\x14\n" +
+	"\x05ended\x18\x06 \x01(\x03R\x05ended\x12\x1a\n" +
+	"\battempts\x18\a \x01(\x05R\battempts\x12\x16\n" +
+	"\x06result\x18\b \x01(\tR\x06result\x12\x14\n" +
+	"\x05error\x18\t \x01(\tR\x05error\x12\x18\n" +
+	"\adetails\x18\n" +
+	" \x01(\tR\adetails\x12\x0e\n" +
+	"\x02id\x18\v \x01(\tR\x02id\x12'\n" +
+	"\x0freboot_required\x18\f \x01(\bR\x0erebootRequired\"C\n" +
+	"\vTaskHistory\x124\n" +
+	"\aentries\x18\x01 \x03(\v2\x1a.agentapi.TaskHistoryEntryR\aentries\"\xb9\x01\n" +
+	"\n" +
+	"QueuedTask\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x18\n" +
+	"\asummary\x18\x04 \x01(\tR\asummary\x12\x1c\n" +
+	"\tsubmitted\x18\x05 \x01(\x03R\tsubmitted\x12\x18\n" +
+	"\arunning\x18\x06 \x01(\bR\arunning\x12\x1a\n" +
+	"\bdeferred\x18\a \x01(\bR\bdeferred\"9\n" +
+	"\vQueuedTasks\x12*\n" +
+	"\x05tasks\x18\x01 \x03(\v2\x14.agentapi.QueuedTaskR\x05tasks\">\n" +
+	"\x11CancelTaskRequest\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\"L\n" +
+	"\x15SecurityUpdateRequest\x12\x1b\n" +
+	"\twsl_names\x18\x01 \x03(\tR\bwslNames\x12\x16\n" +
+	"\x06labels\x18\x02 \x03(\tR\x06labels\"\xcc\x01\n" +
+	"\x11RunCommandRequest\x12\x1b\n" +
+	"\twsl_names\x18\x01 \x03(\tR\bwslNames\x12\x12\n" +
+	"\x04argv\x18\x02 \x03(\tR\x04argv\x12\x10\n" +
+	"\x03env\x18\x03 \x03(\tR\x03env\x12\x1f\n" +
+	"\vworking_dir\x18\x04 \x01(\tR\n" +
+	"workingDir\x12'\n" +
+	"\x0ftimeout_seconds\x18\x05 \x01(\x03R\x0etimeoutSeconds\x12\x12\n" +
+	"\x04user\x18\x06 \x01(\tR\x04user\x12\x16\n" +
+	"\x06labels\x18\a \x03(\tR\x06labels\"Y\n" +
+	"\x10InventoryRequest\x12\x19\n" +
+	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x16\n" +
+	"\x06format\x18\x02 \x01(\tR\x06format\x12\x12\n" +
+	"\x04diff\x18\x03 \x01(\bR\x04diff\"%\n" +
+	"\x0fInventoryExport\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"\xfe\x01\n" +
+	"\fStartMetrics\x12 \n" +
+	"\vparallelism\x18\x01 \x01(\x03R\vparallelism\x12\x18\n" +
+	"\arunning\x18\x02 \x01(\x03R\arunning\x12-\n" +
+	"\x12queued_interactive\x18\x03 \x01(\x03R\x11queuedInteractive\x12+\n" +
+	"\x11queued_background\x18\x04 \x01(\x03R\x10queuedBackground\x12\x1d\n" +
+	"\n" +
+	"max_queued\x18\x05 \x01(\x03R\tmaxQueued\x12\x1a\n" +
+	"\badmitted\x18\x06 \x01(\x03R\badmitted\x12\x1b\n" +
+	"\ttimed_out\x18\a \x01(\x03R\btimedOut\"\x9b\x02\n" +
 	"\n" +
 	"DistroInfo\x12\x19\n" +
 	"\bwsl_name\x18\x01 \x01(\tR\awslName\x12\x0e\n" +
@@ -705,25 +2819,102 @@ const file_agentapi_proto_rawDesc = "" +
 	"\vpretty_name\x18\x04 \x01(\tR\n" +
 	"prettyName\x12!\n" +
 	"\fpro_attached\x18\x05 \x01(\bR\vproAttached\x12\x1a\n" +
-	"\bhostname\x18\x06 \x01(\tR\bhostname\"$\n" +
+	"\bhostname\x18\x06 \x01(\tR\bhostname\x12'\n" +
+	"\x0fservice_version\x18\a \x01(\tR\x0eserviceVersion\x12\x16\n" +
+	"\x06labels\x18\b \x03(\tR\x06labels\x12\"\n" +
+	"\fcapabilities\x18\t \x03(\tR\fcapabilities\"$\n" +
 	"\fProAttachCmd\x12\x14\n" +
 	"\x05token\x18\x01 \x01(\tR\x05token\",\n" +
 	"\x12LandscapeConfigCmd\x12\x16\n" +
-	"\x06config\x18\x01 \x01(\tR\x06config\"D\n" +
+	"\x06config\x18\x01 \x01(\tR\x06config\"k\n" +
+	"\x0eProxyConfigCmd\x12\x1d\n" +
+	"\n" +
+	"http_proxy\x18\x01 \x01(\tR\thttpProxy\x12\x1f\n" +
+	"\vhttps_proxy\x18\x02 \x01(\tR\n" +
+	"httpsProxy\x12\x19\n" +
+	"\bno_proxy\x18\x03 \x01(\tR\anoProxy\"\xa9\x01\n" +
+	"\rRunCommandCmd\x12\x12\n" +
+	"\x04argv\x18\x01 \x03(\tR\x04argv\x12\x10\n" +
+	"\x03env\x18\x02 \x03(\tR\x03env\x12\x1f\n" +
+	"\vworking_dir\x18\x03 \x01(\tR\n" +
+	"workingDir\x12'\n" +
+	"\x0ftimeout_seconds\x18\x04 \x01(\x03R\x0etimeoutSeconds\x12\x12\n" +
+	"\x04user\x18\x05 \x01(\tR\x04user\x12\x14\n" +
+	"\x05stdin\x18\x06 \x01(\fR\x05stdin\"z\n" +
+	"\rCommandOutput\x12\x1b\n" +
+	"\texit_code\x18\x01 \x01(\x05R\bexitCode\x12\x16\n" +
+	"\x06stdout\x18\x02 \x01(\fR\x06stdout\x12\x16\n" +
+	"\x06stderr\x18\x03 \x01(\fR\x06stderr\x12\x1c\n" +
+	"\ttruncated\x18\x04 \x01(\bR\ttruncated\"B\n" +
+	"\x0eProServicesCmd\x12\x16\n" +
+	"\x06enable\x18\x01 \x03(\tR\x06enable\x12\x18\n" +
+	"\adisable\x18\x02 \x03(\tR\adisable\"K\n" +
+	"\x11ProServicesResult\x126\n" +
+	"\bservices\x18\x01 \x03(\v2\x1a.agentapi.ProServiceResultR\bservices\"T\n" +
+	"\x10ProServiceResult\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x16\n" +
+	"\x06enable\x18\x02 \x01(\bR\x06enable\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"\x13\n" +
+	"\x11SecurityUpdateCmd\"v\n" +
+	"\x14SecurityUpdateResult\x125\n" +
+	"\bpackages\x18\x01 \x03(\v2\x19.agentapi.UpgradedPackageR\bpackages\x12'\n" +
+	"\x0freboot_required\x18\x02 \x01(\bR\x0erebootRequired\"g\n" +
+	"\x0fUpgradedPackage\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vold_version\x18\x02 \x01(\tR\n" +
+	"oldVersion\x12\x1f\n" +
+	"\vnew_version\x18\x03 \x01(\tR\n" +
+	"newVersion\"\x15\n" +
+	"\x13PackageInventoryCmd\".\n" +
+	"\x10PackageInventory\x12\x1a\n" +
+	"\bpackages\x18\x01 \x01(\fR\bpackages\"E\n" +
+	"\vPackageList\x126\n" +
+	"\bpackages\x18\x01 \x03(\v2\x1a.agentapi.InstalledPackageR\bpackages\"\x8e\x01\n" +
+	"\x10InstalledPackage\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\x12\"\n" +
+	"\farchitecture\x18\x03 \x01(\tR\farchitecture\x12\x16\n" +
+	"\x06origin\x18\x04 \x01(\tR\x06origin\x12\x10\n" +
+	"\x03esm\x18\x05 \x01(\bR\x03esm\"%\n" +
+	"\tCancelCmd\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\"\xde\x02\n" +
 	"\x03MSG\x12\x1b\n" +
 	"\bwsl_name\x18\x01 \x01(\tH\x00R\awslName\x12\x18\n" +
-	"\x06result\x18\x02 \x01(\tH\x00R\x06resultB\x06\n" +
-	"\x04data2\xc9\x02\n" +
+	"\x06result\x18\x02 \x01(\tH\x00R\x06result\x12@\n" +
+	"\x0ecommand_output\x18\x03 \x01(\v2\x17.agentapi.CommandOutputH\x00R\rcommandOutput\x12@\n" +
+	"\fpro_services\x18\x04 \x01(\v2\x1b.agentapi.ProServicesResultH\x00R\vproServices\x12I\n" +
+	"\x0fsecurity_update\x18\x05 \x01(\v2\x1e.agentapi.SecurityUpdateResultH\x00R\x0esecurityUpdate\x12I\n" +
+	"\x11package_inventory\x18\x06 \x01(\v2\x1a.agentapi.PackageInventoryH\x00R\x10packageInventoryB\x06\n" +
+	"\x04data2\xb8\b\n" +
 	"\x02UI\x12F\n" +
 	"\rApplyProToken\x12\x17.agentapi.ProAttachInfo\x1a\x1a.agentapi.SubscriptionInfo\"\x00\x12N\n" +
 	"\x14ApplyLandscapeConfig\x12\x19.agentapi.LandscapeConfig\x1a\x19.agentapi.LandscapeSource\"\x00\x12*\n" +
 	"\x04Ping\x12\x0f.agentapi.Empty\x1a\x0f.agentapi.Empty\"\x00\x12>\n" +
 	"\x10GetConfigSources\x12\x0f.agentapi.Empty\x1a\x17.agentapi.ConfigSources\"\x00\x12?\n" +
-	"\x0eNotifyPurchase\x12\x0f.agentapi.Empty\x1a\x1a.agentapi.SubscriptionInfo\"\x002\xd9\x01\n" +
+	"\x0eNotifyPurchase\x12\x0f.agentapi.Empty\x1a\x1a.agentapi.SubscriptionInfo\"\x00\x12B\n" +
+	"\x12GetDistroContracts\x12\x0f.agentapi.Empty\x1a\x19.agentapi.DistroContracts\"\x00\x12<\n" +
+	"\x0fSetDistroLabels\x12\x16.agentapi.DistroLabels\x1a\x0f.agentapi.Empty\"\x00\x12:\n" +
+	"\x0eGetDeadLetters\x12\x0f.agentapi.Empty\x1a\x15.agentapi.DeadLetters\"\x00\x12G\n" +
+	"\x0eGetTaskHistory\x12\x1c.agentapi.TaskHistoryRequest\x1a\x15.agentapi.TaskHistory\"\x00\x12J\n" +
+	"\x14ApplySecurityUpdates\x12\x1f.agentapi.SecurityUpdateRequest\x1a\x0f.agentapi.Empty\"\x00\x12Q\n" +
+	"\x16ExportPackageInventory\x12\x1a.agentapi.InventoryRequest\x1a\x19.agentapi.InventoryExport\"\x00\x12G\n" +
+	"\x0eGetQueuedTasks\x12\x1c.agentapi.TaskHistoryRequest\x1a\x15.agentapi.QueuedTasks\"\x00\x12<\n" +
+	"\n" +
+	"CancelTask\x12\x1b.agentapi.CancelTaskRequest\x1a\x0f.agentapi.Empty\"\x00\x12D\n" +
+	"\x13GetQuarantinedTasks\x12\x0f.agentapi.Empty\x1a\x1a.agentapi.QuarantinedTasks\"\x00\x12<\n" +
+	"\n" +
+	"RunCommand\x12\x1b.agentapi.RunCommandRequest\x1a\x0f.agentapi.Empty\"\x00\x12<\n" +
+	"\x0fGetStartMetrics\x12\x0f.agentapi.Empty\x1a\x16.agentapi.StartMetrics\"\x002\x81\x05\n" +
 	"\vWSLInstance\x126\n" +
 	"\tConnected\x12\x14.agentapi.DistroInfo\x1a\x0f.agentapi.Empty\"\x00(\x01\x12D\n" +
 	"\x15ProAttachmentCommands\x12\r.agentapi.MSG\x1a\x16.agentapi.ProAttachCmd\"\x00(\x010\x01\x12L\n" +
-	"\x17LandscapeConfigCommands\x12\r.agentapi.MSG\x1a\x1c.agentapi.LandscapeConfigCmd\"\x00(\x010\x01B2Z0github.com/canonical/ubuntu-pro-for-wsl/agentapib\x06proto3"
+	"\x17LandscapeConfigCommands\x12\r.agentapi.MSG\x1a\x1c.agentapi.LandscapeConfigCmd\"\x00(\x010\x01\x12D\n" +
+	"\x13ProxyConfigCommands\x12\r.agentapi.MSG\x1a\x18.agentapi.ProxyConfigCmd\"\x00(\x010\x01\x12B\n" +
+	"\x12RunCommandCommands\x12\r.agentapi.MSG\x1a\x17.agentapi.RunCommandCmd\"\x00(\x010\x01\x12D\n" +
+	"\x13ProServicesCommands\x12\r.agentapi.MSG\x1a\x18.agentapi.ProServicesCmd\"\x00(\x010\x01\x12J\n" +
+	"\x16SecurityUpdateCommands\x12\r.agentapi.MSG\x1a\x1b.agentapi.SecurityUpdateCmd\"\x00(\x010\x01\x12N\n" +
+	"\x18PackageInventoryCommands\x12\r.agentapi.MSG\x1a\x1d.agentapi.PackageInventoryCmd\"\x00(\x010\x01\x12:\n" +
+	"\x0eCancelCommands\x12\r.agentapi.MSG\x1a\x13.agentapi.CancelCmd\"\x00(\x010\x01B2Z0github.com/canonical/ubuntu-pro-for-wsl/agentapib\x06proto3"
 
 var (
 	file_agentapi_proto_rawDescOnce sync.Once
@@ -737,18 +2928,50 @@ func file_agentapi_proto_rawDescGZIP() []byte {
 	return file_agentapi_proto_rawDescData
 }
 
-var file_agentapi_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_agentapi_proto_msgTypes = make([]protoimpl.MessageInfo, 42)
 var file_agentapi_proto_goTypes = []any{
-	(*Empty)(nil),              // 0: agentapi.Empty
-	(*ProAttachInfo)(nil),      // 1: agentapi.ProAttachInfo
-	(*LandscapeConfig)(nil),    // 2: agentapi.LandscapeConfig
-	(*SubscriptionInfo)(nil),   // 3: agentapi.SubscriptionInfo
-	(*LandscapeSource)(nil),    // 4: agentapi.LandscapeSource
-	(*ConfigSources)(nil),      // 5: agentapi.ConfigSources
-	(*DistroInfo)(nil),         // 6: agentapi.DistroInfo
-	(*ProAttachCmd)(nil),       // 7: agentapi.ProAttachCmd
-	(*LandscapeConfigCmd)(nil), // 8: agentapi.LandscapeConfigCmd
-	(*MSG)(nil),                // 9: agentapi.MSG
+	(*Empty)(nil),                 // 0: agentapi.Empty
+	(*ProAttachInfo)(nil),         // 1: agentapi.ProAttachInfo
+	(*LandscapeConfig)(nil),       // 2: agentapi.LandscapeConfig
+	(*SubscriptionInfo)(nil),      // 3: agentapi.SubscriptionInfo
+	(*LandscapeSource)(nil),       // 4: agentapi.LandscapeSource
+	(*ConfigSources)(nil),         // 5: agentapi.ConfigSources
+	(*DistroContract)(nil),        // 6: agentapi.DistroContract
+	(*DistroContracts)(nil),       // 7: agentapi.DistroContracts
+	(*DistroLabels)(nil),          // 8: agentapi.DistroLabels
+	(*DeadLetter)(nil),            // 9: agentapi.DeadLetter
+	(*DeadLetters)(nil),           // 10: agentapi.DeadLetters
+	(*QuarantinedTask)(nil),       // 11: agentapi.QuarantinedTask
+	(*QuarantinedTasks)(nil),      // 12: agentapi.QuarantinedTasks
+	(*TaskHistoryRequest)(nil),    // 13: agentapi.TaskHistoryRequest
+	(*TaskHistoryEntry)(nil),      // 14: agentapi.TaskHistoryEntry
+	(*TaskHistory)(nil),           // 15: agentapi.TaskHistory
+	(*QueuedTask)(nil),            // 16: agentapi.QueuedTask
+	(*QueuedTasks)(nil),           // 17: agentapi.QueuedTasks
+	(*CancelTaskRequest)(nil),     // 18: agentapi.CancelTaskRequest
+	(*SecurityUpdateRequest)(nil), // 19: agentapi.SecurityUpdateRequest
+	(*RunCommandRequest)(nil),     // 20: agentapi.RunCommandRequest
+	(*InventoryRequest)(nil),      // 21: agentapi.InventoryRequest
+	(*InventoryExport)(nil),       // 22: agentapi.InventoryExport
+	(*StartMetrics)(nil),          // 23: agentapi.StartMetrics
+	(*DistroInfo)(nil),            // 24: agentapi.DistroInfo
+	(*ProAttachCmd)(nil),          // 25: agentapi.ProAttachCmd
+	(*LandscapeConfigCmd)(nil),    // 26: agentapi.LandscapeConfigCmd
+	(*ProxyConfigCmd)(nil),        // 27: agentapi.ProxyConfigCmd
+	(*RunCommandCmd)(nil),         // 28: agentapi.RunCommandCmd
+	(*CommandOutput)(nil),         // 29: agentapi.CommandOutput
+	(*ProServicesCmd)(nil),        // 30: agentapi.ProServicesCmd
+	(*ProServicesResult)(nil),     // 31: agentapi.ProServicesResult
+	(*ProServiceResult)(nil),      // 32: agentapi.ProServiceResult
+	(*SecurityUpdateCmd)(nil),     // 33: agentapi.SecurityUpdateCmd
+	(*SecurityUpdateResult)(nil),  // 34: agentapi.SecurityUpdateResult
+	(*UpgradedPackage)(nil),       // 35: agentapi.UpgradedPackage
+	(*PackageInventoryCmd)(nil),   // 36: agentapi.PackageInventoryCmd
+	(*PackageInventory)(nil),      // 37: agentapi.PackageInventory
+	(*PackageList)(nil),           // 38: agentapi.PackageList
+	(*InstalledPackage)(nil),      // 39: agentapi.InstalledPackage
+	(*CancelCmd)(nil),             // 40: agentapi.CancelCmd
+	(*MSG)(nil),                   // 41: agentapi.MSG
 }
 var file_agentapi_proto_depIdxs = []int32{
 	0,  // 0: agentapi.SubscriptionInfo.none:type_name -> agentapi.Empty
@@ -760,27 +2983,73 @@ var file_agentapi_proto_depIdxs = []int32{
 	0,  // 6: agentapi.LandscapeSource.organization:type_name -> agentapi.Empty
 	3,  // 7: agentapi.ConfigSources.proSubscription:type_name -> agentapi.SubscriptionInfo
 	4,  // 8: agentapi.ConfigSources.landscapeSource:type_name -> agentapi.LandscapeSource
-	1,  // 9: agentapi.UI.ApplyProToken:input_type -> agentapi.ProAttachInfo
-	2,  // 10: agentapi.UI.ApplyLandscapeConfig:input_type -> agentapi.LandscapeConfig
-	0,  // 11: agentapi.UI.Ping:input_type -> agentapi.Empty
-	0,  // 12: agentapi.UI.GetConfigSources:input_type -> agentapi.Empty
-	0,  // 13: agentapi.UI.NotifyPurchase:input_type -> agentapi.Empty
-	6,  // 14: agentapi.WSLInstance.Connected:input_type -> agentapi.DistroInfo
-	9,  // 15: agentapi.WSLInstance.ProAttachmentCommands:input_type -> agentapi.MSG
-	9,  // 16: agentapi.WSLInstance.LandscapeConfigCommands:input_type -> agentapi.MSG
-	3,  // 17: agentapi.UI.ApplyProToken:output_type -> agentapi.SubscriptionInfo
-	4,  // 18: agentapi.UI.ApplyLandscapeConfig:output_type -> agentapi.LandscapeSource
-	0,  // 19: agentapi.UI.Ping:output_type -> agentapi.Empty
-	5,  // 20: agentapi.UI.GetConfigSources:output_type -> agentapi.ConfigSources
-	3,  // 21: agentapi.UI.NotifyPurchase:output_type -> agentapi.SubscriptionInfo
-	0,  // 22: agentapi.WSLInstance.Connected:output_type -> agentapi.Empty
-	7,  // 23: agentapi.WSLInstance.ProAttachmentCommands:output_type -> agentapi.ProAttachCmd
-	8,  // 24: agentapi.WSLInstance.LandscapeConfigCommands:output_type -> agentapi.LandscapeConfigCmd
-	17, // [17:25] is the sub-list for method output_type
-	9,  // [9:17] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	6,  // 9: agentapi.DistroContracts.distros:type_name -> agentapi.DistroContract
+	9,  // 10: agentapi.DeadLetters.letters:type_name -> agentapi.DeadLetter
+	11, // 11: agentapi.QuarantinedTasks.tasks:type_name -> agentapi.QuarantinedTask
+	14, // 12: agentapi.TaskHistory.entries:type_name -> agentapi.TaskHistoryEntry
+	16, // 13: agentapi.QueuedTasks.tasks:type_name -> agentapi.QueuedTask
+	32, // 14: agentapi.ProServicesResult.services:type_name -> agentapi.ProServiceResult
+	35, // 15: agentapi.SecurityUpdateResult.packages:type_name -> agentapi.UpgradedPackage
+	39, // 16: agentapi.PackageList.packages:type_name -> agentapi.InstalledPackage
+	29, // 17: agentapi.MSG.command_output:type_name -> agentapi.CommandOutput
+	31, // 18: agentapi.MSG.pro_services:type_name -> agentapi.ProServicesResult
+	34, // 19: agentapi.MSG.security_update:type_name -> agentapi.SecurityUpdateResult
+	37, // 20: agentapi.MSG.package_inventory:type_name -> agentapi.PackageInventory
+	1,  // 21: agentapi.UI.ApplyProToken:input_type -> agentapi.ProAttachInfo
+	2,  // 22: agentapi.UI.ApplyLandscapeConfig:input_type -> agentapi.LandscapeConfig
+	0,  // 23: agentapi.UI.Ping:input_type -> agentapi.Empty
+	0,  // 24: agentapi.UI.GetConfigSources:input_type -> agentapi.Empty
+	0,  // 25: agentapi.UI.NotifyPurchase:input_type -> agentapi.Empty
+	0,  // 26: agentapi.UI.GetDistroContracts:input_type -> agentapi.Empty
+	8,  // 27: agentapi.UI.SetDistroLabels:input_type -> agentapi.DistroLabels
+	0,  // 28: agentapi.UI.GetDeadLetters:input_type -> agentapi.Empty
+	13, // 29: agentapi.UI.GetTaskHistory:input_type -> agentapi.TaskHistoryRequest
+	19, // 30: agentapi.UI.ApplySecurityUpdates:input_type -> agentapi.SecurityUpdateRequest
+	21, // 31: agentapi.UI.ExportPackageInventory:input_type -> agentapi.InventoryRequest
+	13, // 32: agentapi.UI.GetQueuedTasks:input_type -> agentapi.TaskHistoryRequest
+	18, // 33: agentapi.UI.CancelTask:input_type -> agentapi.CancelTaskRequest
+	0,  // 34: agentapi.UI.GetQuarantinedTasks:input_type -> agentapi.Empty
+	20, // 35: agentapi.UI.RunCommand:input_type -> agentapi.RunCommandRequest
+	0,  // 36: agentapi.UI.GetStartMetrics:input_type -> agentapi.Empty
+	24, // 37: agentapi.WSLInstance.Connected:input_type -> agentapi.DistroInfo
+	41, // 38: agentapi.WSLInstance.ProAttachmentCommands:input_type -> agentapi.MSG
+	41, // 39: agentapi.WSLInstance.LandscapeConfigCommands:input_type -> agentapi.MSG
+	41, // 40: agentapi.WSLInstance.ProxyConfigCommands:input_type -> agentapi.MSG
+	41, // 41: agentapi.WSLInstance.RunCommandCommands:input_type -> agentapi.MSG
+	41, // 42: agentapi.WSLInstance.ProServicesCommands:input_type -> agentapi.MSG
+	41, // 43: agentapi.WSLInstance.SecurityUpdateCommands:input_type -> agentapi.MSG
+	41, // 44: agentapi.WSLInstance.PackageInventoryCommands:input_type -> agentapi.MSG
+	41, // 45: agentapi.WSLInstance.CancelCommands:input_type -> agentapi.MSG
+	3,  // 46: agentapi.UI.ApplyProToken:output_type -> agentapi.SubscriptionInfo
+	4,  // 47: agentapi.UI.ApplyLandscapeConfig:output_type -> agentapi.LandscapeSource
+	0,  // 48: agentapi.UI.Ping:output_type -> agentapi.Empty
+	5,  // 49: agentapi.UI.GetConfigSources:output_type -> agentapi.ConfigSources
+	3,  // 50: agentapi.UI.NotifyPurchase:output_type -> agentapi.SubscriptionInfo
+	7,  // 51: agentapi.UI.GetDistroContracts:output_type -> agentapi.DistroContracts
+	0,  // 52: agentapi.UI.SetDistroLabels:output_type -> agentapi.Empty
+	10, // 53: agentapi.UI.GetDeadLetters:output_type -> agentapi.DeadLetters
+	15, // 54: agentapi.UI.GetTaskHistory:output_type -> agentapi.TaskHistory
+	0,  // 55: agentapi.UI.ApplySecurityUpdates:output_type -> agentapi.Empty
+	22, // 56: agentapi.UI.ExportPackageInventory:output_type -> agentapi.InventoryExport
+	17, // 57: agentapi.UI.GetQueuedTasks:output_type -> agentapi.QueuedTasks
+	0,  // 58: agentapi.UI.CancelTask:output_type -> agentapi.Empty
+	12, // 59: agentapi.UI.GetQuarantinedTasks:output_type -> agentapi.QuarantinedTasks
+	0,  // 60: agentapi.UI.RunCommand:output_type -> agentapi.Empty
+	23, // 61: agentapi.UI.GetStartMetrics:output_type -> agentapi.StartMetrics
+	0,  // 62: agentapi.WSLInstance.Connected:output_type -> agentapi.Empty
+	25, // 63: agentapi.WSLInstance.ProAttachmentCommands:output_type -> agentapi.ProAttachCmd
+	26, // 64: agentapi.WSLInstance.LandscapeConfigCommands:output_type -> agentapi.LandscapeConfigCmd
+	27, // 65: agentapi.WSLInstance.ProxyConfigCommands:output_type -> agentapi.ProxyConfigCmd
+	28, // 66: agentapi.WSLInstance.RunCommandCommands:output_type -> agentapi.RunCommandCmd
+	30, // 67: agentapi.WSLInstance.ProServicesCommands:output_type -> agentapi.ProServicesCmd
+	33, // 68: agentapi.WSLInstance.SecurityUpdateCommands:output_type -> agentapi.SecurityUpdateCmd
+	36, // 69: agentapi.WSLInstance.PackageInventoryCommands:output_type -> agentapi.PackageInventoryCmd
+	40, // 70: agentapi.WSLInstance.CancelCommands:output_type -> agentapi.CancelCmd
+	46, // [46:71] is the sub-list for method output_type
+	21, // [21:46] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_agentapi_proto_init() }
@@ -799,9 +3068,13 @@ func file_agentapi_proto_init() {
 		(*LandscapeSource_User)(nil),
 		(*LandscapeSource_Organization)(nil),
 	}
-	file_agentapi_proto_msgTypes[9].OneofWrappers = []any{
+	file_agentapi_proto_msgTypes[41].OneofWrappers = []any{
 		(*MSG_WslName)(nil),
 		(*MSG_Result)(nil),
+		(*MSG_CommandOutput)(nil),
+		(*MSG_ProServices)(nil),
+		(*MSG_SecurityUpdate)(nil),
+		(*MSG_PackageInventory)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -809,7 +3082,7 @@ func file_agentapi_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_agentapi_proto_rawDesc), len(file_agentapi_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   10,
+			NumMessages:   42,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
@@ -19,11 +19,22 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	UI_ApplyProToken_FullMethodName        = "/agentapi.UI/ApplyProToken"
-	UI_ApplyLandscapeConfig_FullMethodName = "/agentapi.UI/ApplyLandscapeConfig"
-	UI_Ping_FullMethodName                 = "/agentapi.UI/Ping"
-	UI_GetConfigSources_FullMethodName     = "/agentapi.UI/GetConfigSources"
-	UI_NotifyPurchase_FullMethodName       = "/agentapi.UI/NotifyPurchase"
+	UI_ApplyProToken_FullMethodName          = "/agentapi.UI/ApplyProToken"
+	UI_ApplyLandscapeConfig_FullMethodName   = "/agentapi.UI/ApplyLandscapeConfig"
+	UI_Ping_FullMethodName                   = "/agentapi.UI/Ping"
+	UI_GetConfigSources_FullMethodName       = "/agentapi.UI/GetConfigSources"
+	UI_NotifyPurchase_FullMethodName         = "/agentapi.UI/NotifyPurchase"
+	UI_GetDistroContracts_FullMethodName     = "/agentapi.UI/GetDistroContracts"
+	UI_SetDistroLabels_FullMethodName        = "/agentapi.UI/SetDistroLabels"
+	UI_GetDeadLetters_FullMethodName         = "/agentapi.UI/GetDeadLetters"
+	UI_GetTaskHistory_FullMethodName         = "/agentapi.UI/GetTaskHistory"
+	UI_ApplySecurityUpdates_FullMethodName   = "/agentapi.UI/ApplySecurityUpdates"
+	UI_ExportPackageInventory_FullMethodName = "/agentapi.UI/ExportPackageInventory"
+	UI_GetQueuedTasks_FullMethodName         = "/agentapi.UI/GetQueuedTasks"
+	UI_CancelTask_FullMethodName             = "/agentapi.UI/CancelTask"
+	UI_GetQuarantinedTasks_FullMethodName    = "/agentapi.UI/GetQuarantinedTasks"
+	UI_RunCommand_FullMethodName             = "/agentapi.UI/RunCommand"
+	UI_GetStartMetrics_FullMethodName        = "/agentapi.UI/GetStartMetrics"
 )
 
 // UIClient is the client API for UI service.
@@ -35,6 +46,17 @@ type UIClient interface {
 	Ping(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
 	GetConfigSources(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*ConfigSources, error)
 	NotifyPurchase(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*SubscriptionInfo, error)
+	GetDistroContracts(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*DistroContracts, error)
+	SetDistroLabels(ctx context.Context, in *DistroLabels, opts ...grpc.CallOption) (*Empty, error)
+	GetDeadLetters(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*DeadLetters, error)
+	GetTaskHistory(ctx context.Context, in *TaskHistoryRequest, opts ...grpc.CallOption) (*TaskHistory, error)
+	ApplySecurityUpdates(ctx context.Context, in *SecurityUpdateRequest, opts ...grpc.CallOption) (*Empty, error)
+	ExportPackageInventory(ctx context.Context, in *InventoryRequest, opts ...grpc.CallOption) (*InventoryExport, error)
+	GetQueuedTasks(ctx context.Context, in *TaskHistoryRequest, opts ...grpc.CallOption) (*QueuedTasks, error)
+	CancelTask(ctx context.Context, in *CancelTaskRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetQuarantinedTasks(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*QuarantinedTasks, error)
+	RunCommand(ctx context.Context, in *RunCommandRequest, opts ...grpc.CallOption) (*Empty, error)
+	GetStartMetrics(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*StartMetrics, error)
 }
 
 type uIClient struct {
@@ -95,6 +117,116 @@ func (c *uIClient) NotifyPurchase(ctx context.Context, in *Empty, opts ...grpc.C
 	return out, nil
 }
 
+func (c *uIClient) GetDistroContracts(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*DistroContracts, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DistroContracts)
+	err := c.cc.Invoke(ctx, UI_GetDistroContracts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) SetDistroLabels(ctx context.Context, in *DistroLabels, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UI_SetDistroLabels_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetDeadLetters(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*DeadLetters, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeadLetters)
+	err := c.cc.Invoke(ctx, UI_GetDeadLetters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetTaskHistory(ctx context.Context, in *TaskHistoryRequest, opts ...grpc.CallOption) (*TaskHistory, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TaskHistory)
+	err := c.cc.Invoke(ctx, UI_GetTaskHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) ApplySecurityUpdates(ctx context.Context, in *SecurityUpdateRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UI_ApplySecurityUpdates_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) ExportPackageInventory(ctx context.Context, in *InventoryRequest, opts ...grpc.CallOption) (*InventoryExport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InventoryExport)
+	err := c.cc.Invoke(ctx, UI_ExportPackageInventory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetQueuedTasks(ctx context.Context, in *TaskHistoryRequest, opts ...grpc.CallOption) (*QueuedTasks, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueuedTasks)
+	err := c.cc.Invoke(ctx, UI_GetQueuedTasks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) CancelTask(ctx context.Context, in *CancelTaskRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UI_CancelTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetQuarantinedTasks(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*QuarantinedTasks, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QuarantinedTasks)
+	err := c.cc.Invoke(ctx, UI_GetQuarantinedTasks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) RunCommand(ctx context.Context, in *RunCommandRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, UI_RunCommand_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *uIClient) GetStartMetrics(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*StartMetrics, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartMetrics)
+	err := c.cc.Invoke(ctx, UI_GetStartMetrics_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UIServer is the server API for UI service.
 // All implementations must embed UnimplementedUIServer
 // for forward compatibility.
@@ -104,6 +236,17 @@ type UIServer interface {
 	Ping(context.Context, *Empty) (*Empty, error)
 	GetConfigSources(context.Context, *Empty) (*ConfigSources, error)
 	NotifyPurchase(context.Context, *Empty) (*SubscriptionInfo, error)
+	GetDistroContracts(context.Context, *Empty) (*DistroContracts, error)
+	SetDistroLabels(context.Context, *DistroLabels) (*Empty, error)
+	GetDeadLetters(context.Context, *Empty) (*DeadLetters, error)
+	GetTaskHistory(context.Context, *TaskHistoryRequest) (*TaskHistory, error)
+	ApplySecurityUpdates(context.Context, *SecurityUpdateRequest) (*Empty, error)
+	ExportPackageInventory(context.Context, *InventoryRequest) (*InventoryExport, error)
+	GetQueuedTasks(context.Context, *TaskHistoryRequest) (*QueuedTasks, error)
+	CancelTask(context.Context, *CancelTaskRequest) (*Empty, error)
+	GetQuarantinedTasks(context.Context, *Empty) (*QuarantinedTasks, error)
+	RunCommand(context.Context, *RunCommandRequest) (*Empty, error)
+	GetStartMetrics(context.Context, *Empty) (*StartMetrics, error)
 	mustEmbedUnimplementedUIServer()
 }
 
@@ -129,6 +272,39 @@ func (UnimplementedUIServer) GetConfigSources(context.Context, *Empty) (*ConfigS
 func (UnimplementedUIServer) NotifyPurchase(context.Context, *Empty) (*SubscriptionInfo, error) {
 	return nil, status.Error(codes.Unimplemented, "method NotifyPurchase not implemented")
 }
+func (UnimplementedUIServer) GetDistroContracts(context.Context, *Empty) (*DistroContracts, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDistroContracts not implemented")
+}
+func (UnimplementedUIServer) SetDistroLabels(context.Context, *DistroLabels) (*Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetDistroLabels not implemented")
+}
+func (UnimplementedUIServer) GetDeadLetters(context.Context, *Empty) (*DeadLetters, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDeadLetters not implemented")
+}
+func (UnimplementedUIServer) GetTaskHistory(context.Context, *TaskHistoryRequest) (*TaskHistory, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTaskHistory not implemented")
+}
+func (UnimplementedUIServer) ApplySecurityUpdates(context.Context, *SecurityUpdateRequest) (*Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method ApplySecurityUpdates not implemented")
+}
+func (UnimplementedUIServer) ExportPackageInventory(context.Context, *InventoryRequest) (*InventoryExport, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExportPackageInventory not implemented")
+}
+func (UnimplementedUIServer) GetQueuedTasks(context.Context, *TaskHistoryRequest) (*QueuedTasks, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetQueuedTasks not implemented")
+}
+func (UnimplementedUIServer) CancelTask(context.Context, *CancelTaskRequest) (*Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method CancelTask not implemented")
+}
+func (UnimplementedUIServer) GetQuarantinedTasks(context.Context, *Empty) (*QuarantinedTasks, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetQuarantinedTasks not implemented")
+}
+func (UnimplementedUIServer) RunCommand(context.Context, *RunCommandRequest) (*Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method RunCommand not implemented")
+}
+func (UnimplementedUIServer) GetStartMetrics(context.Context, *Empty) (*StartMetrics, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStartMetrics not implemented")
+}
 func (UnimplementedUIServer) mustEmbedUnimplementedUIServer() {}
 func (UnimplementedUIServer) testEmbeddedByValue()            {}
 
@@ -240,6 +416,204 @@ func _UI_NotifyPurchase_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UI_GetDistroContracts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetDistroContracts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetDistroContracts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetDistroContracts(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_SetDistroLabels_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DistroLabels)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).SetDistroLabels(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_SetDistroLabels_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).SetDistroLabels(ctx, req.(*DistroLabels))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_GetDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetDeadLetters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetDeadLetters(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_GetTaskHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetTaskHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetTaskHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetTaskHistory(ctx, req.(*TaskHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_ApplySecurityUpdates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecurityUpdateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).ApplySecurityUpdates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_ApplySecurityUpdates_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).ApplySecurityUpdates(ctx, req.(*SecurityUpdateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_ExportPackageInventory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InventoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).ExportPackageInventory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_ExportPackageInventory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).ExportPackageInventory(ctx, req.(*InventoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_GetQueuedTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetQueuedTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetQueuedTasks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetQueuedTasks(ctx, req.(*TaskHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_CancelTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).CancelTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_CancelTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).CancelTask(ctx, req.(*CancelTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_GetQuarantinedTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetQuarantinedTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetQuarantinedTasks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetQuarantinedTasks(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_RunCommand_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunCommandRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).RunCommand(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_RunCommand_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).RunCommand(ctx, req.(*RunCommandRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UI_GetStartMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UIServer).GetStartMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UI_GetStartMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UIServer).GetStartMetrics(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UI_ServiceDesc is the grpc.ServiceDesc for UI service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -267,15 +641,65 @@ var UI_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "NotifyPurchase",
 			Handler:    _UI_NotifyPurchase_Handler,
 		},
+		{
+			MethodName: "GetDistroContracts",
+			Handler:    _UI_GetDistroContracts_Handler,
+		},
+		{
+			MethodName: "SetDistroLabels",
+			Handler:    _UI_SetDistroLabels_Handler,
+		},
+		{
+			MethodName: "GetDeadLetters",
+			Handler:    _UI_GetDeadLetters_Handler,
+		},
+		{
+			MethodName: "GetTaskHistory",
+			Handler:    _UI_GetTaskHistory_Handler,
+		},
+		{
+			MethodName: "ApplySecurityUpdates",
+			Handler:    _UI_ApplySecurityUpdates_Handler,
+		},
+		{
+			MethodName: "ExportPackageInventory",
+			Handler:    _UI_ExportPackageInventory_Handler,
+		},
+		{
+			MethodName: "GetQueuedTasks",
+			Handler:    _UI_GetQueuedTasks_Handler,
+		},
+		{
+			MethodName: "CancelTask",
+			Handler:    _UI_CancelTask_Handler,
+		},
+		{
+			MethodName: "GetQuarantinedTasks",
+			Handler:    _UI_GetQuarantinedTasks_Handler,
+		},
+		{
+			MethodName: "RunCommand",
+			Handler:    _UI_RunCommand_Handler,
+		},
+		{
+			MethodName: "GetStartMetrics",
+			Handler:    _UI_GetStartMetrics_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "agentapi.proto",
 }
 
 const (
-	WSLInstance_Connected_FullMethodName               = "/agentapi.WSLInstance/Connected"
-	WSLInstance_ProAttachmentCommands_FullMethodName   = "/agentapi.WSLInstance/ProAttachmentCommands"
-	WSLInstance_LandscapeConfigCommands_FullMethodName = "/agentapi.WSLInstance/LandscapeConfigCommands"
+	WSLInstance_Connected_FullMethodName                = "/agentapi.WSLInstance/Connected"
+	WSLInstance_ProAttachmentCommands_FullMethodName    = "/agentapi.WSLInstance/ProAttachmentCommands"
+	WSLInstance_LandscapeConfigCommands_FullMethodName  = "/agentapi.WSLInstance/LandscapeConfigCommands"
+	WSLInstance_ProxyConfigCommands_FullMethodName      = "/agentapi.WSLInstance/ProxyConfigCommands"
+	WSLInstance_RunCommandCommands_FullMethodName       = "/agentapi.WSLInstance/RunCommandCommands"
+	WSLInstance_ProServicesCommands_FullMethodName      = "/agentapi.WSLInstance/ProServicesCommands"
+	WSLInstance_SecurityUpdateCommands_FullMethodName   = "/agentapi.WSLInstance/SecurityUpdateCommands"
+	WSLInstance_PackageInventoryCommands_FullMethodName = "/agentapi.WSLInstance/PackageInventoryCommands"
+	WSLInstance_CancelCommands_FullMethodName           = "/agentapi.WSLInstance/CancelCommands"
 )
 
 // WSLInstanceClient is the client API for WSLInstance service.
@@ -286,6 +710,12 @@ type WSLInstanceClient interface {
 	// Reverse unary calls
 	ProAttachmentCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, ProAttachCmd], error)
 	LandscapeConfigCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, LandscapeConfigCmd], error)
+	ProxyConfigCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, ProxyConfigCmd], error)
+	RunCommandCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, RunCommandCmd], error)
+	ProServicesCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, ProServicesCmd], error)
+	SecurityUpdateCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, SecurityUpdateCmd], error)
+	PackageInventoryCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, PackageInventoryCmd], error)
+	CancelCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, CancelCmd], error)
 }
 
 type wSLInstanceClient struct {
@@ -335,6 +765,84 @@ func (c *wSLInstanceClient) LandscapeConfigCommands(ctx context.Context, opts ..
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type WSLInstance_LandscapeConfigCommandsClient = grpc.BidiStreamingClient[MSG, LandscapeConfigCmd]
 
+func (c *wSLInstanceClient) ProxyConfigCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, ProxyConfigCmd], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[3], WSLInstance_ProxyConfigCommands_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MSG, ProxyConfigCmd]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_ProxyConfigCommandsClient = grpc.BidiStreamingClient[MSG, ProxyConfigCmd]
+
+func (c *wSLInstanceClient) RunCommandCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, RunCommandCmd], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[4], WSLInstance_RunCommandCommands_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MSG, RunCommandCmd]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_RunCommandCommandsClient = grpc.BidiStreamingClient[MSG, RunCommandCmd]
+
+func (c *wSLInstanceClient) ProServicesCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, ProServicesCmd], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[5], WSLInstance_ProServicesCommands_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MSG, ProServicesCmd]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_ProServicesCommandsClient = grpc.BidiStreamingClient[MSG, ProServicesCmd]
+
+func (c *wSLInstanceClient) SecurityUpdateCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, SecurityUpdateCmd], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[6], WSLInstance_SecurityUpdateCommands_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MSG, SecurityUpdateCmd]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_SecurityUpdateCommandsClient = grpc.BidiStreamingClient[MSG, SecurityUpdateCmd]
+
+func (c *wSLInstanceClient) PackageInventoryCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, PackageInventoryCmd], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[7], WSLInstance_PackageInventoryCommands_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MSG, PackageInventoryCmd]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_PackageInventoryCommandsClient = grpc.BidiStreamingClient[MSG, PackageInventoryCmd]
+
+func (c *wSLInstanceClient) CancelCommands(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MSG, CancelCmd], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &WSLInstance_ServiceDesc.Streams[8], WSLInstance_CancelCommands_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MSG, CancelCmd]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_CancelCommandsClient = grpc.BidiStreamingClient[MSG, CancelCmd]
+
 // WSLInstanceServer is the server API for WSLInstance service.
 // All implementations must embed UnimplementedWSLInstanceServer
 // for forward compatibility.
@@ -343,6 +851,12 @@ type WSLInstanceServer interface {
 	// Reverse unary calls
 	ProAttachmentCommands(grpc.BidiStreamingServer[MSG, ProAttachCmd]) error
 	LandscapeConfigCommands(grpc.BidiStreamingServer[MSG, LandscapeConfigCmd]) error
+	ProxyConfigCommands(grpc.BidiStreamingServer[MSG, ProxyConfigCmd]) error
+	RunCommandCommands(grpc.BidiStreamingServer[MSG, RunCommandCmd]) error
+	ProServicesCommands(grpc.BidiStreamingServer[MSG, ProServicesCmd]) error
+	SecurityUpdateCommands(grpc.BidiStreamingServer[MSG, SecurityUpdateCmd]) error
+	PackageInventoryCommands(grpc.BidiStreamingServer[MSG, PackageInventoryCmd]) error
+	CancelCommands(grpc.BidiStreamingServer[MSG, CancelCmd]) error
 	mustEmbedUnimplementedWSLInstanceServer()
 }
 
@@ -362,6 +876,24 @@ func (UnimplementedWSLInstanceServer) ProAttachmentCommands(grpc.BidiStreamingSe
 func (UnimplementedWSLInstanceServer) LandscapeConfigCommands(grpc.BidiStreamingServer[MSG, LandscapeConfigCmd]) error {
 	return status.Error(codes.Unimplemented, "method LandscapeConfigCommands not implemented")
 }
+func (UnimplementedWSLInstanceServer) ProxyConfigCommands(grpc.BidiStreamingServer[MSG, ProxyConfigCmd]) error {
+	return status.Error(codes.Unimplemented, "method ProxyConfigCommands not implemented")
+}
+func (UnimplementedWSLInstanceServer) RunCommandCommands(grpc.BidiStreamingServer[MSG, RunCommandCmd]) error {
+	return status.Error(codes.Unimplemented, "method RunCommandCommands not implemented")
+}
+func (UnimplementedWSLInstanceServer) ProServicesCommands(grpc.BidiStreamingServer[MSG, ProServicesCmd]) error {
+	return status.Error(codes.Unimplemented, "method ProServicesCommands not implemented")
+}
+func (UnimplementedWSLInstanceServer) SecurityUpdateCommands(grpc.BidiStreamingServer[MSG, SecurityUpdateCmd]) error {
+	return status.Error(codes.Unimplemented, "method SecurityUpdateCommands not implemented")
+}
+func (UnimplementedWSLInstanceServer) PackageInventoryCommands(grpc.BidiStreamingServer[MSG, PackageInventoryCmd]) error {
+	return status.Error(codes.Unimplemented, "method PackageInventoryCommands not implemented")
+}
+func (UnimplementedWSLInstanceServer) CancelCommands(grpc.BidiStreamingServer[MSG, CancelCmd]) error {
+	return status.Error(codes.Unimplemented, "method CancelCommands not implemented")
+}
 func (UnimplementedWSLInstanceServer) mustEmbedUnimplementedWSLInstanceServer() {}
 func (UnimplementedWSLInstanceServer) testEmbeddedByValue()                     {}
 
@@ -404,6 +936,48 @@ func _WSLInstance_LandscapeConfigCommands_Handler(srv interface{}, stream grpc.S
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type WSLInstance_LandscapeConfigCommandsServer = grpc.BidiStreamingServer[MSG, LandscapeConfigCmd]
 
+func _WSLInstance_ProxyConfigCommands_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WSLInstanceServer).ProxyConfigCommands(&grpc.GenericServerStream[MSG, ProxyConfigCmd]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_ProxyConfigCommandsServer = grpc.BidiStreamingServer[MSG, ProxyConfigCmd]
+
+func _WSLInstance_RunCommandCommands_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WSLInstanceServer).RunCommandCommands(&grpc.GenericServerStream[MSG, RunCommandCmd]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_RunCommandCommandsServer = grpc.BidiStreamingServer[MSG, RunCommandCmd]
+
+func _WSLInstance_ProServicesCommands_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WSLInstanceServer).ProServicesCommands(&grpc.GenericServerStream[MSG, ProServicesCmd]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_ProServicesCommandsServer = grpc.BidiStreamingServer[MSG, ProServicesCmd]
+
+func _WSLInstance_SecurityUpdateCommands_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WSLInstanceServer).SecurityUpdateCommands(&grpc.GenericServerStream[MSG, SecurityUpdateCmd]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_SecurityUpdateCommandsServer = grpc.BidiStreamingServer[MSG, SecurityUpdateCmd]
+
+func _WSLInstance_PackageInventoryCommands_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WSLInstanceServer).PackageInventoryCommands(&grpc.GenericServerStream[MSG, PackageInventoryCmd]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_PackageInventoryCommandsServer = grpc.BidiStreamingServer[MSG, PackageInventoryCmd]
+
+func _WSLInstance_CancelCommands_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WSLInstanceServer).CancelCommands(&grpc.GenericServerStream[MSG, CancelCmd]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type WSLInstance_CancelCommandsServer = grpc.BidiStreamingServer[MSG, CancelCmd]
+
 // WSLInstance_ServiceDesc is the grpc.ServiceDesc for WSLInstance service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -429,6 +1003,42 @@ var WSLInstance_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "ProxyConfigCommands",
+			Handler:       _WSLInstance_ProxyConfigCommands_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "RunCommandCommands",
+			Handler:       _WSLInstance_RunCommandCommands_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ProServicesCommands",
+			Handler:       _WSLInstance_ProServicesCommands_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "SecurityUpdateCommands",
+			Handler:       _WSLInstance_SecurityUpdateCommands_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "PackageInventoryCommands",
+			Handler:       _WSLInstance_PackageInventoryCommands_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "CancelCommands",
+			Handler:       _WSLInstance_CancelCommands_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "agentapi.proto",
 }
@@ -71,6 +71,10 @@ class AgentApiClient {
   /// Returns current information about the config sources, if any, to determine which parts of the UI are enabled.
   Future<ConfigSources> configSources() => _client.getConfigSources(Empty());
 
+  /// Returns which Ubuntu Pro contract each instance uses.
+  Future<DistroContracts> distroContracts() =>
+      _client.getDistroContracts(Empty());
+
   /// Notifies the background agent of a succesfull purchase transaction on MS Store.
   /// It's expected that an updated SubscriptionInfo will be returned.
   Future<SubscriptionInfo> notifyPurchase() => _client.notifyPurchase(Empty());
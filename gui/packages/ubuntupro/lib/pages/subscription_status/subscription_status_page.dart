@@ -21,11 +21,15 @@ class SubscriptionStatusPage extends StatelessWidget {
   Widget build(BuildContext context) {
     final model = context.watch<SubscriptionStatusModel>();
     final lang = AppLocalizations.of(context);
+    final contracts = DistroContractsList(
+      load: getService<AgentApiClient>().distroContracts,
+    );
 
     return AnimatedSwitcher(
       duration: const Duration(milliseconds: 700),
       child: switch (model) {
         StoreSubscriptionStatusModel() => SubscriptionStatus(
+            contracts: contracts,
             actionButtons: [
               if (model.canConfigureLandscape) _landscapeButton(context),
             ],
@@ -37,6 +41,7 @@ class SubscriptionStatusPage extends StatelessWidget {
             ],
           ),
         UserSubscriptionStatusModel() => SubscriptionStatus(
+            contracts: contracts,
             actionButtons: [
               if (model.canConfigureLandscape) _landscapeButton(context),
               ElevatedButton(
@@ -67,6 +72,7 @@ class SubscriptionStatusPage extends StatelessWidget {
             ],
           ),
         OrgSubscriptionStatusModel() => SubscriptionStatus(
+            contracts: contracts,
             actionButtons: model.canConfigureLandscape
                 ? [_landscapeButton(context)]
                 : null,
@@ -1,17 +1,29 @@
+import 'package:agentapi/agentapi.dart';
 import 'package:flutter/material.dart';
+import 'package:ubuntu_logger/ubuntu_logger.dart';
 import 'package:yaru/yaru.dart';
 
 import '/l10n/app_localizations.dart';
 import '/pages/widgets/page_widgets.dart';
 
+final _log = Logger('subscription_status');
+
 /// A page content widget built on top of the Dark styled landing page showing the current user active subscription
 /// feedback and an optional action button in a column layout.
 class SubscriptionStatus extends StatelessWidget {
-  const SubscriptionStatus({super.key, this.actionButtons, this.footerLinks});
+  const SubscriptionStatus({
+    super.key,
+    this.actionButtons,
+    this.footerLinks,
+    this.contracts,
+  });
 
   /// The optional action button matching the capabilities of the current subscription type.
   final List<Widget>? actionButtons;
 
+  /// The optional list of the contracts each instance uses.
+  final Widget? contracts;
+
   final List<Widget>? footerLinks;
 
   @override
@@ -32,6 +44,7 @@ class SubscriptionStatus extends StatelessWidget {
           subtitle: Text(lang.ubuntuProEnabledInfo),
           yaruInfoType: YaruInfoType.success,
         ),
+        if (contracts != null) contracts!,
         if (actionButtons != null)
           Center(
             child: Padding(
@@ -46,3 +59,67 @@ class SubscriptionStatus extends StatelessWidget {
     );
   }
 }
+
+/// Lists the Ubuntu Pro contract each instance uses, along with the token map entry that selected it.
+/// Nothing is shown until the contracts are loaded, nor if there are none.
+class DistroContractsList extends StatefulWidget {
+  const DistroContractsList({super.key, required this.load});
+
+  /// Fetches the contracts from the agent.
+  final Future<DistroContracts> Function() load;
+
+  @override
+  State<DistroContractsList> createState() => _DistroContractsListState();
+}
+
+class _DistroContractsListState extends State<DistroContractsList> {
+  late final Future<DistroContracts> _contracts;
+
+  @override
+  void initState() {
+    super.initState();
+    _contracts = widget.load().catchError((err) {
+      _log.warning('Could not get the contracts of the instances: $err');
+      return DistroContracts();
+    });
+  }
+
+  @override
+  Widget build(BuildContext context) {
+    final lang = AppLocalizations.of(context);
+
+    return FutureBuilder<DistroContracts>(
+      future: _contracts,
+      builder: (context, snapshot) {
+        final distros = snapshot.data?.distros ?? [];
+        if (distros.isEmpty) {
+          return const SizedBox.shrink();
+        }
+
+        return Padding(
+          padding: const EdgeInsets.only(top: 32.0),
+          child: Column(
+            crossAxisAlignment: CrossAxisAlignment.start,
+            children: [
+              Text(
+                lang.distroContracts,
+                style: Theme.of(context).textTheme.titleMedium,
+              ),
+              const SizedBox(height: 8.0),
+              for (final distro in distros)
+                ListTile(
+                  title: Text(distro.wslName),
+                  subtitle: Text(
+                    distro.pattern.isEmpty
+                        ? lang.distroContractsDefault
+                        : lang.distroContractsPattern(distro.pattern),
+                  ),
+                  trailing: Text(distro.token),
+                ),
+            ],
+          ),
+        );
+      },
+    );
+  }
+}
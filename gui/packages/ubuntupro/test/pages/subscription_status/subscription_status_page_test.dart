@@ -210,6 +210,35 @@ void main() {
       });
     });
   });
+  testWidgets('contracts', (tester) async {
+    final client = getService<AgentApiClient>() as FakeAgentApiClient;
+    client.contracts = [
+      DistroContract(wslName: 'Ubuntu', token: 'C1******'),
+      DistroContract(
+        wslName: 'Ubuntu-24.04',
+        token: 'C2******',
+        pattern: 'Ubuntu-2*',
+      ),
+    ];
+    addTearDown(() => client.contracts = []);
+
+    final app = buildApp(
+      SubscriptionInfo()..ensureUser(),
+      LandscapeSource(),
+      client,
+    );
+
+    await tester.pumpWidget(app);
+    await tester.pumpAndSettle();
+
+    final context = tester.element(find.byType(SubscriptionStatusPage));
+    final lang = AppLocalizations.of(context);
+
+    expect(find.text(lang.distroContracts), findsOneWidget);
+    expect(find.text('Ubuntu'), findsOneWidget);
+    expect(find.text('Ubuntu-24.04'), findsOneWidget);
+  });
+
   testWidgets('creates a model', (tester) async {
     final app = buildMultiProviderWizardApp(
       routes: {'/': const WizardRoute(builder: SubscriptionStatusPage.create)},
@@ -351,6 +380,13 @@ Widget buildWizardApp(Map<String, WizardRoute> routes) {
 }
 
 class FakeAgentApiClient extends Fake implements AgentApiClient {
+  List<DistroContract> contracts = [];
+
+  @override
+  Future<DistroContracts> distroContracts() async {
+    return DistroContracts(distros: contracts);
+  }
+
   @override
   Future<LandscapeSource> applyLandscapeConfig(String config) async {
     return LandscapeSource()..ensureUser();
@@ -1,5 +1,7 @@
+import 'package:agentapi/agentapi.dart';
 import 'package:flutter/material.dart';
 import 'package:flutter_test/flutter_test.dart';
+import 'package:ubuntupro/l10n/app_localizations.dart';
 import 'package:ubuntupro/pages/subscription_status/subscription_status_widgets.dart';
 import 'package:yaru_test/yaru_test.dart';
 
@@ -58,4 +60,74 @@ void main() {
       expect(clicked, isTrue);
     });
   });
+
+  group('distro contracts', () {
+    testWidgets('lists the contract of each instance', (tester) async {
+      await tester.pumpWidget(
+        buildSingleRouteMultiProviderApp(
+          child: Scaffold(
+            body: DistroContractsList(
+              load: () async => DistroContracts(
+                distros: [
+                  DistroContract(wslName: 'Ubuntu', token: 'C1******'),
+                  DistroContract(
+                    wslName: 'Ubuntu-24.04',
+                    token: 'C2******',
+                    pattern: 'Ubuntu-2*',
+                  ),
+                ],
+              ),
+            ),
+          ),
+        ),
+      );
+      await tester.pumpAndSettle();
+
+      final context = tester.element(find.byType(DistroContractsList));
+      final lang = AppLocalizations.of(context);
+
+      expect(find.text(lang.distroContracts), findsOneWidget);
+      expect(find.text('Ubuntu'), findsOneWidget);
+      expect(find.text('C1******'), findsOneWidget);
+      expect(find.text(lang.distroContractsDefault), findsOneWidget);
+      expect(find.text('Ubuntu-24.04'), findsOneWidget);
+      expect(find.text('C2******'), findsOneWidget);
+      expect(
+        find.text(lang.distroContractsPattern('Ubuntu-2*')),
+        findsOneWidget,
+      );
+    });
+
+    testWidgets('shows nothing without contracts', (tester) async {
+      await tester.pumpWidget(
+        buildSingleRouteMultiProviderApp(
+          child: Scaffold(
+            body: DistroContractsList(load: () async => DistroContracts()),
+          ),
+        ),
+      );
+      await tester.pumpAndSettle();
+
+      final context = tester.element(find.byType(DistroContractsList));
+      final lang = AppLocalizations.of(context);
+
+      expect(find.text(lang.distroContracts), findsNothing);
+      expect(find.byType(ListTile), findsNothing);
+    });
+
+    testWidgets('shows nothing when the agent fails', (tester) async {
+      await tester.pumpWidget(
+        buildSingleRouteMultiProviderApp(
+          child: Scaffold(
+            body: DistroContractsList(
+              load: () => Future.error(Exception('agent error')),
+            ),
+          ),
+        ),
+      );
+      await tester.pumpAndSettle();
+
+      expect(find.byType(ListTile), findsNothing);
+    });
+  });
 }
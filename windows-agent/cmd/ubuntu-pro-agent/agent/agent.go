@@ -15,6 +15,7 @@ import (
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/daemon"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices"
@@ -45,7 +46,11 @@ type App struct {
 }
 
 type daemonConfig struct {
-	Verbosity int
+	Verbosity           int
+	MaxConcurrentStarts int
+
+	// Policy contains the settings that apply when the registry does not provide them.
+	Policy config.FileData
 }
 
 type options struct {
@@ -118,10 +123,15 @@ func New(o ...option) *App {
 
 	installVerbosityFlag(&a.rootCmd, a.viper)
 	installConfigFlag(&a.rootCmd)
+	installMaxConcurrentStartsFlag(&a.rootCmd, a.viper)
 
 	// subcommands
 	a.installVersion()
 	a.installClean()
+	a.installLabels(o)
+	a.installSecurityUpdate(o)
+	a.installInventory(o)
+	a.installHistory(o)
 
 	return &a
 }
@@ -148,6 +158,8 @@ func (a *App) serve(ctx context.Context, opt options) error {
 		publicDir,
 		privateDir,
 		proservices.WithRegistry(opt.registry),
+		proservices.WithMaxConcurrentStarts(a.config.MaxConcurrentStarts),
+		proservices.WithConfigFileData(a.config.Policy),
 	)
 	if err != nil {
 		close(a.ready)
@@ -15,6 +15,7 @@ import (
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/cmd/ubuntu-pro-agent/agent"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/daemon/daemontestutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/registrywatcher/registry"
 	"github.com/stretchr/testify/require"
 )
@@ -179,6 +180,62 @@ func TestConfigArg(t *testing.T) {
 	require.Equal(t, 1, a.Config().Verbosity)
 }
 
+func TestConfigMaxConcurrentStarts(t *testing.T) {
+	testCases := map[string]struct {
+		config string
+
+		want int
+	}{
+		"Success with the default value":     {want: startscheduler.DefaultParallelism},
+		"Success with the configured value":  {config: "maxconcurrentstarts: 3", want: 3},
+		"Success with the value in any case": {config: "MaxConcurrentStarts: 2", want: 2},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			getStdout := captureStdout(t)
+
+			configPath := filepath.Join(t.TempDir(), "ubuntu-pro-agent.yaml")
+			require.NoError(t, os.WriteFile(configPath, []byte(tc.config), 0600), "Setup: couldn't write config file")
+
+			a := agent.New()
+			a.SetArgs("version", "--config", configPath)
+
+			err := a.Run()
+			out := getStdout()
+			require.NoError(t, err, "Run should not return an error, stdout: %v", out)
+			require.Equal(t, tc.want, a.Config().MaxConcurrentStarts, "Unexpected number of concurrent starts")
+		})
+	}
+}
+
+func TestConfigPolicy(t *testing.T) {
+	getStdout := captureStdout(t)
+
+	config := `policy:
+  ubuntuprotokenmap: |
+    label:ci=TOKEN
+  ubuntuproservices: esm-apps
+  httpproxy: http://proxy:3128
+  scheduledtasks: "*=weekly pro-refresh"
+`
+	configPath := filepath.Join(t.TempDir(), "ubuntu-pro-agent.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(config), 0600), "Setup: couldn't write config file")
+
+	a := agent.New()
+	a.SetArgs("version", "--config", configPath)
+
+	err := a.Run()
+	out := getStdout()
+	require.NoError(t, err, "Run should not return an error, stdout: %v", out)
+
+	got := a.Config().Policy
+	require.Equal(t, "label:ci=TOKEN\n", got.UbuntuProTokenMap, "Unexpected Ubuntu Pro token map")
+	require.Equal(t, "esm-apps", got.UbuntuProServices, "Unexpected Ubuntu Pro services")
+	require.Equal(t, "http://proxy:3128", got.HTTPProxy, "Unexpected HTTP proxy")
+	require.Equal(t, "*=weekly pro-refresh", got.ScheduledTasks, "Unexpected scheduled tasks")
+}
+
 func TestConfigAutoDetect(t *testing.T) {
 	getStdout := captureStdout(t)
 	filename := "ubuntu-pro-agent.yaml"
@@ -462,6 +519,168 @@ func TestLogs(t *testing.T) {
 	}
 }
 
+func TestLabels(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		args    []string
+		noAgent bool
+
+		wantErr string
+	}{
+		"Error when the distro is not managed by the agent": {args: []string{"NotManaged", "ci"}, wantErr: "not found"},
+		"Error when the agent is not running":               {args: []string{"Ubuntu", "ci"}, noAgent: true, wantErr: "could not find the running agent"},
+		"Error when no distro is specified":                 {noAgent: true, wantErr: "requires at least 1 arg"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			publicDir := t.TempDir()
+
+			if !tc.noAgent {
+				startAgent(t, publicDir)
+			}
+
+			cli := agent.NewForTesting(t, publicDir, "")
+			cli.SetArgs(append([]string{"labels"}, tc.args...)...)
+
+			err := cli.Run()
+			require.Error(t, err, "Run should return an error")
+			require.ErrorContains(t, err, tc.wantErr, "Unexpected error message")
+		})
+	}
+}
+
+func TestSecurityUpdate(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		args    []string
+		noAgent bool
+
+		wantErr string
+	}{
+		"Success updating all distros": {},
+
+		"Error when the distro is not managed by the agent": {args: []string{"NotManaged"}, wantErr: "not found"},
+		"Error when the agent is not running":               {noAgent: true, wantErr: "could not find the running agent"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			publicDir := t.TempDir()
+
+			if !tc.noAgent {
+				startAgent(t, publicDir)
+			}
+
+			cli := agent.NewForTesting(t, publicDir, "")
+			cli.SetArgs(append([]string{"security-update"}, tc.args...)...)
+
+			err := cli.Run()
+			if tc.wantErr == "" {
+				require.NoError(t, err, "Run should return no error")
+				return
+			}
+			require.Error(t, err, "Run should return an error")
+			require.ErrorContains(t, err, tc.wantErr, "Unexpected error message")
+		})
+	}
+}
+
+func TestInventory(t *testing.T) {
+	// Not parallel because we capture stdout
+
+	testCases := map[string]struct {
+		args    []string
+		noAgent bool
+
+		wantOut string
+		wantErr string
+	}{
+		"Success exporting JSON":       {wantOut: "[]"},
+		"Success exporting CSV":        {args: []string{"--format", "csv"}, wantOut: "distro,name,version,architecture,origin,esm"},
+		"Success exporting a CSV diff": {args: []string{"--format=csv", "--diff"}, wantOut: "distro,change,name,architecture,old_version,new_version"},
+
+		"Error when the format is unknown":         {args: []string{"--format", "xml"}, wantErr: "unknown format"},
+		"Error when the distro has no inventory":   {args: []string{"NotManaged"}, wantErr: "no package inventory"},
+		"Error when more than one distro is given": {args: []string{"Ubuntu", "Ubuntu-24.04"}, noAgent: true, wantErr: "accepts at most 1 arg"},
+		"Error when the agent is not running":      {noAgent: true, wantErr: "could not find the running agent"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			publicDir := t.TempDir()
+
+			if !tc.noAgent {
+				startAgent(t, publicDir)
+			}
+
+			getStdout := captureStdout(t)
+
+			cli := agent.NewForTesting(t, publicDir, "")
+			cli.SetArgs(append([]string{"inventory"}, tc.args...)...)
+
+			err := cli.Run()
+			out := getStdout()
+			if tc.wantErr != "" {
+				require.Error(t, err, "Run should return an error")
+				require.ErrorContains(t, err, tc.wantErr, "Unexpected error message")
+				return
+			}
+			require.NoError(t, err, "Run should return no error")
+			require.Contains(t, out, tc.wantOut, "Unexpected exported inventory")
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	// Not parallel because we capture stdout
+
+	testCases := map[string]struct {
+		args    []string
+		noAgent bool
+
+		wantOut string
+		wantErr string
+	}{
+		"Success exporting the history of all distros": {wantOut: "[]"},
+		"Success exporting the history of a distro":    {args: []string{"Ubuntu"}, wantOut: "[]"},
+
+		"Error when more than one distro is given": {args: []string{"Ubuntu", "Ubuntu-24.04"}, noAgent: true, wantErr: "accepts at most 1 arg"},
+		"Error when the agent is not running":      {noAgent: true, wantErr: "could not find the running agent"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			publicDir := t.TempDir()
+
+			if !tc.noAgent {
+				startAgent(t, publicDir)
+			}
+
+			getStdout := captureStdout(t)
+
+			cli := agent.NewForTesting(t, publicDir, "")
+			cli.SetArgs(append([]string{"history"}, tc.args...)...)
+
+			err := cli.Run()
+			out := getStdout()
+			if tc.wantErr != "" {
+				require.Error(t, err, "Run should return an error")
+				require.ErrorContains(t, err, tc.wantErr, "Unexpected error message")
+				return
+			}
+			require.NoError(t, err, "Run should return no error")
+			require.Contains(t, out, tc.wantOut, "Unexpected exported history")
+		})
+	}
+}
+
 func TestClean(t *testing.T) {
 	// Not parallel because we modify the environment
 
@@ -624,6 +843,31 @@ func startDaemon(t *testing.T, args ...string) (app *agent.App, done func()) {
 	}
 }
 
+// startAgent starts the agent in the background and waits until it publishes its address in publicDir,
+// so that the commands talking to it can find it. The agent is stopped when the test ends.
+func startAgent(t *testing.T, publicDir string) {
+	t.Helper()
+
+	a := agent.NewForTesting(t, publicDir, "")
+	a.SetArgs()
+
+	ch := make(chan error)
+	go func() {
+		ch <- a.Run()
+		close(ch)
+	}()
+	t.Cleanup(func() {
+		a.Quit()
+		require.NoError(t, <-ch, "Run should exit without any errors")
+	})
+
+	a.WaitReady()
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(publicDir, common.ListeningPortFileName))
+		return err == nil
+	}, 10*time.Second, 100*time.Millisecond, "Setup: the agent never published its address")
+}
+
 // captureStdout capture current process stdout and returns a function to get the captured buffer.
 func captureStdout(t *testing.T) func() string {
 	t.Helper()
@@ -10,6 +10,7 @@ import (
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -69,6 +70,15 @@ func installConfigFlag(cmd *cobra.Command) *string {
 	return cmd.PersistentFlags().StringP("config", "c", "", i18n.G("configuration file path"))
 }
 
+// installMaxConcurrentStartsFlag adds the --max-concurrent-starts option and returns the reference to it.
+func installMaxConcurrentStartsFlag(cmd *cobra.Command, viper *viper.Viper) *int {
+	r := cmd.Flags().Int("max-concurrent-starts", startscheduler.DefaultParallelism, i18n.G("maximum number of distros to start at the same time"))
+	if err := viper.BindPFlag("maxconcurrentstarts", cmd.Flags().Lookup("max-concurrent-starts")); err != nil {
+		log.Warning(context.Background(), err)
+	}
+	return r
+}
+
 // SetVerboseMode change ErrorFormat and logs between very, middly and non verbose.
 func setVerboseMode(level int) {
 	var reportCaller bool
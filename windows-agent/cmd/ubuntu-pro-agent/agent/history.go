@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/decorate"
+	"go.yaml.in/yaml/v3"
+)
+
+func (a *App) installHistory(o []option) {
+	cmd := &cobra.Command{
+		Use:   "history [DISTRO]",
+		Short: i18n.G("Exports the task history of the distros"),
+		Long:  i18n.G("Asks the running agent for the outcome of the most recent tasks in the given distro, or in all of them if none is given, and writes it as YAML. Secrets such as tokens are left out, so the output can be attached to a bug report."),
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			publicDir, err := a.publicDir(opt)
+			if err != nil {
+				return err
+			}
+
+			var distroName string
+			if len(args) > 0 {
+				distroName = args[0]
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			return exportHistory(ctx, publicDir, distroName)
+		},
+	}
+	a.rootCmd.AddCommand(cmd)
+}
+
+// historyEntry is the outcome of an attempt at running a task, as exported.
+type historyEntry struct {
+	Distro         string    `yaml:"distro"`
+	ID             string    `yaml:"id,omitempty"`
+	Type           string    `yaml:"type"`
+	Summary        string    `yaml:"summary"`
+	Submitted      time.Time `yaml:"submitted,omitempty"`
+	Started        time.Time `yaml:"started"`
+	Ended          time.Time `yaml:"ended"`
+	Attempts       int32     `yaml:"attempts"`
+	Result         string    `yaml:"result"`
+	Error          string    `yaml:"error,omitempty"`
+	Details        string    `yaml:"details,omitempty"`
+	RebootRequired bool      `yaml:"reboot_required,omitempty"`
+}
+
+// exportHistory asks the running agent for the task history of the distro, or of all of them if the
+// name is empty, and writes it to stdout as YAML.
+func exportHistory(ctx context.Context, publicDir string, distroName string) (err error) {
+	defer decorate.OnError(&err, "could not export the task history")
+
+	conn, err := dialAgent(publicDir)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	history, err := agentapi.NewUIClient(conn).GetTaskHistory(ctx, &agentapi.TaskHistoryRequest{WslName: distroName})
+	if err != nil {
+		return err
+	}
+
+	entries := make([]historyEntry, 0, len(history.GetEntries()))
+	for _, e := range history.GetEntries() {
+		h := historyEntry{
+			Distro:         e.GetWslName(),
+			ID:             e.GetId(),
+			Type:           e.GetType(),
+			Summary:        e.GetSummary(),
+			Started:        time.Unix(e.GetStarted(), 0).UTC(),
+			Ended:          time.Unix(e.GetEnded(), 0).UTC(),
+			Attempts:       e.GetAttempts(),
+			Result:         e.GetResult(),
+			Error:          e.GetError(),
+			Details:        e.GetDetails(),
+			RebootRequired: e.GetRebootRequired(),
+		}
+		if e.GetSubmitted() != 0 {
+			h.Submitted = time.Unix(e.GetSubmitted(), 0).UTC()
+		}
+		entries = append(entries, h)
+	}
+
+	return yaml.NewEncoder(os.Stdout).Encode(entries)
+}
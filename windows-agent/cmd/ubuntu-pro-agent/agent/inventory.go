@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/decorate"
+)
+
+func (a *App) installInventory(o []option) {
+	var format string
+	var diff bool
+
+	cmd := &cobra.Command{
+		Use:   "inventory [DISTRO]",
+		Short: i18n.G("Exports the package inventory of the distros"),
+		Long:  i18n.G("Asks the running agent for the packages installed in the given distro, or in all of them if none is given, as collected when they last connected. With --diff, the changes since the previous inventory are exported instead."),
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			publicDir, err := a.publicDir(opt)
+			if err != nil {
+				return err
+			}
+
+			var distroName string
+			if len(args) > 0 {
+				distroName = args[0]
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			return exportInventory(ctx, publicDir, &agentapi.InventoryRequest{WslName: distroName, Format: format, Diff: diff})
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "json", i18n.G("output format: json or csv"))
+	cmd.Flags().BoolVar(&diff, "diff", false, i18n.G("export the changes since the previous inventory"))
+
+	a.rootCmd.AddCommand(cmd)
+}
+
+// exportInventory asks the running agent for the package inventories and writes them to stdout.
+func exportInventory(ctx context.Context, publicDir string, req *agentapi.InventoryRequest) (err error) {
+	defer decorate.OnError(&err, "could not export the package inventory")
+
+	conn, err := dialAgent(publicDir)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	inv, err := agentapi.NewUIClient(conn).ExportPackageInventory(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(inv.GetData())
+	return err
+}
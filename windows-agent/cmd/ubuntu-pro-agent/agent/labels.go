@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/certs"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/decorate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func (a *App) installLabels(o []option) {
+	cmd := &cobra.Command{
+		Use:   "labels DISTRO [LABEL...]",
+		Short: i18n.G("Sets the labels of a distro"),
+		Long:  i18n.G("Replaces the labels assigned to a distro managed by the running agent. Passing no labels removes them all."),
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			publicDir, err := a.publicDir(opt)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			return setDistroLabels(ctx, publicDir, args[0], args[1:])
+		},
+	}
+	a.rootCmd.AddCommand(cmd)
+}
+
+// setDistroLabels asks the running agent to replace the labels of the distro.
+func setDistroLabels(ctx context.Context, publicDir, distroName string, labels []string) (err error) {
+	defer decorate.OnError(&err, "could not set labels of distro %q", distroName)
+
+	conn, err := dialAgent(publicDir)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = agentapi.NewUIClient(conn).SetDistroLabels(ctx, &agentapi.DistroLabels{
+		WslName: distroName,
+		Labels:  labels,
+	})
+	return err
+}
+
+// dialAgent creates a client connection to the running agent, using the address and
+// certificates it published in its public directory.
+func dialAgent(publicDir string) (*grpc.ClientConn, error) {
+	addrPath := filepath.Join(publicDir, common.ListeningPortFileName)
+	addr, err := os.ReadFile(addrPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not find the running agent: %v", err)
+	}
+
+	tlsConfig, err := clientTLSConfig(filepath.Join(publicDir, common.CertificatesDir))
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(strings.TrimSpace(string(addr)), grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	if err != nil {
+		return nil, fmt.Errorf("could not create a gRPC client: %v", err)
+	}
+
+	return conn, nil
+}
+
+// clientTLSConfig loads the clients' certificates published by the agent.
+func clientTLSConfig(certsPath string) (conf *tls.Config, err error) {
+	defer decorate.OnError(&err, "could not load TLS config")
+
+	cert, err := tls.LoadX509KeyPair(filepath.Join(certsPath, common.ClientsCertFilePrefix+common.CertificateSuffix), filepath.Join(certsPath, common.ClientsCertFilePrefix+common.KeySuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	ca := x509.NewCertPool()
+	caFilePath := filepath.Join(certsPath, common.RootCACertFileName)
+	caBytes, err := os.ReadFile(caFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if ok := ca.AppendCertsFromPEM(caBytes); !ok {
+		return nil, fmt.Errorf("failed to parse %q", caFilePath)
+	}
+
+	return &tls.Config{
+		ServerName:   common.GRPCServerNameOverride,
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      ca,
+		MinVersion:   certs.MinTLSVersion,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/i18n"
+	"github.com/spf13/cobra"
+	"github.com/ubuntu/decorate"
+)
+
+func (a *App) installSecurityUpdate(o []option) {
+	cmd := &cobra.Command{
+		Use:   "security-update [DISTRO...]",
+		Short: i18n.G("Applies the pending security updates"),
+		Long:  i18n.G("Asks the running agent to apply the pending security updates in the given distros, or in all of them if none is given. The updates are applied in the background: their outcome is recorded in the task history."),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var opt options
+			for _, f := range o {
+				f(&opt)
+			}
+
+			publicDir, err := a.publicDir(opt)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			return applySecurityUpdates(ctx, publicDir, args)
+		},
+	}
+	a.rootCmd.AddCommand(cmd)
+}
+
+// applySecurityUpdates asks the running agent to apply the pending security updates in the distros.
+func applySecurityUpdates(ctx context.Context, publicDir string, distroNames []string) (err error) {
+	defer decorate.OnError(&err, "could not apply security updates")
+
+	conn, err := dialAgent(publicDir)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = agentapi.NewUIClient(conn).ApplySecurityUpdates(ctx, &agentapi.SecurityUpdateRequest{
+		WslNames: distroNames,
+	})
+	return err
+}
@@ -18,10 +18,12 @@ import (
 	"gopkg.in/ini.v1"
 )
 
-// Config is a configuration provider for ProToken and the Landscape config.
+// Config is a configuration provider for ProToken, the Landscape config and the proxy settings.
 type Config interface {
 	Subscription() (string, config.Source, error)
+	ContractRules() ([]config.ContractRule, error)
 	LandscapeClientConfig() (string, config.Source, error)
+	Proxy() (config.Proxy, error)
 }
 
 // CloudInit contains necessary data to drop cloud-init user data files for WSL's data source to pick them up.
@@ -158,10 +160,38 @@ func (c CloudInit) RemoveDistroData(distroName string) (err error) {
 	return nil
 }
 
+// RenameDistroData moves the cloud-init user data and metadata of a distro to its new name.
+//
+// Files that did not exist are ignored.
+func (c CloudInit) RenameDistroData(oldName, newName string) (err error) {
+	defer decorate.OnError(&err, "could not rename distro-specific cloud-init files")
+
+	for _, ext := range []string{".user-data", ".meta-data"} {
+		oldPath := filepath.Join(c.dataDir, oldName+ext)
+		newPath := filepath.Join(c.dataDir, newName+ext)
+
+		err := os.Rename(oldPath, newPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func marshalConfig(conf Config) ([]byte, error) {
 	contents := make(map[string]interface{})
 
-	if err := ubuntuProModule(conf, contents); err != nil {
+	proxy, err := conf.Proxy()
+	if err != nil {
+		return nil, err
+	}
+
+	aptModule(proxy, contents)
+
+	if err := ubuntuProModule(conf, proxy, contents); err != nil {
 		return nil, err
 	}
 
@@ -192,7 +222,21 @@ func marshalConfig(conf Config) ([]byte, error) {
 	return w.Bytes(), nil
 }
 
-func ubuntuProModule(c Config, out map[string]interface{}) error {
+// aptModule makes apt go through the proxy.
+func aptModule(proxy config.Proxy, out map[string]interface{}) {
+	if proxy.HTTP == "" && proxy.HTTPS == "" {
+		return
+	}
+
+	type aptModule struct {
+		HTTPProxy  string `yaml:"http_proxy,omitempty"`
+		HTTPSProxy string `yaml:"https_proxy,omitempty"`
+	}
+
+	out["apt"] = aptModule{HTTPProxy: proxy.HTTP, HTTPSProxy: proxy.HTTPS}
+}
+
+func ubuntuProModule(c Config, proxy config.Proxy, out map[string]interface{}) error {
 	token, src, err := c.Subscription()
 	if err != nil {
 		return err
@@ -201,11 +245,33 @@ func ubuntuProModule(c Config, out map[string]interface{}) error {
 		return nil
 	}
 
+	// The agent.yaml file is shared by all distros, so we cannot tell those with their own
+	// contract apart. We leave the attachment to the agent instead.
+	rules, err := c.ContractRules()
+	if err != nil {
+		return err
+	}
+	if len(rules) != 0 {
+		return nil
+	}
+
+	// These are passed on to `pro config set`, so that attaching goes through the proxy.
+	type proConfig struct {
+		HTTPProxy  string `yaml:"http_proxy,omitempty"`
+		HTTPSProxy string `yaml:"https_proxy,omitempty"`
+	}
+
 	type uaModule struct {
-		Token string `yaml:"token"`
+		Token  string     `yaml:"token"`
+		Config *proConfig `yaml:"config,omitempty"`
+	}
+
+	m := uaModule{Token: token}
+	if proxy.HTTP != "" || proxy.HTTPS != "" {
+		m.Config = &proConfig{HTTPProxy: proxy.HTTP, HTTPSProxy: proxy.HTTPS}
 	}
 
-	out["ubuntu_pro"] = uaModule{Token: token}
+	out["ubuntu_pro"] = m
 	return nil
 }
 
@@ -92,10 +92,13 @@ hostagent_uid = landscapeUID1234
 		skipProToken      bool
 		skipLandscapeConf bool
 		skipHostAgentUID  bool
+		withContractRules bool
+		withProxy         bool
 
 		// Break marshalling
 		breakSubscription bool
 		breakLandscape    bool
+		breakProxy        bool
 
 		// Landscape parsing
 		landscapeNoClientSection bool
@@ -109,9 +112,12 @@ hostagent_uid = landscapeUID1234
 
 		wantAgentYamlAsDir bool
 	}{
-		"Success":                            {},
-		"Without hostagent UID":              {skipHostAgentUID: true},
-		"Without pro token":                  {skipProToken: true},
+		"Success":               {},
+		"Without hostagent UID": {skipHostAgentUID: true},
+		"Without pro token":     {skipProToken: true},
+		"Without pro token when distros have their own contracts": {withContractRules: true},
+		"With proxy":                         {withProxy: true},
+		"With proxy and without pro token":   {withProxy: true, skipProToken: true, skipLandscapeConf: true},
 		"Without Landscape":                  {skipLandscapeConf: true},
 		"Without Landscape [client] section": {landscapeNoClientSection: true},
 		"With empty contents":                {skipProToken: true, skipLandscapeConf: true},
@@ -119,6 +125,7 @@ hostagent_uid = landscapeUID1234
 		"Error to remove existing agent.yaml":   {skipProToken: true, skipLandscapeConf: true, breakRemovingFile: true, wantAgentYamlAsDir: true},
 		"Error obtaining pro token":             {breakSubscription: true},
 		"Error obtaining Landscape config":      {breakLandscape: true},
+		"Error obtaining proxy settings":        {breakProxy: true},
 		"Error with erroneous Landscape config": {badLandscape: true},
 
 		"Error when the datadir cannot be created":   {breakDir: true},
@@ -149,6 +156,7 @@ hostagent_uid = landscapeUID1234
 			// Test overriding the file: New() created the agent.yaml file
 			conf.subcriptionErr = tc.breakSubscription
 			conf.landscapeErr = tc.breakLandscape
+			conf.proxyErr = tc.breakProxy
 
 			conf.proToken = "NEW_PRO_TOKEN"
 			if tc.skipProToken {
@@ -165,6 +173,12 @@ hostagent_uid = landscapeUID1234
 			if tc.skipLandscapeConf {
 				conf.landscapeConf = ""
 			}
+			if tc.withContractRules {
+				conf.contractRules = []config.ContractRule{{Pattern: "Customer*", Token: "CUSTOMER_PRO_TOKEN"}}
+			}
+			if tc.withProxy {
+				conf.proxy = config.Proxy{HTTP: "http://proxy.example.com:3128", HTTPS: "http://proxy.example.com:3129", NoProxy: "localhost"}
+			}
 			if tc.skipHostAgentUID {
 				conf.landscapeConf = strings.Replace(conf.landscapeConf, "hostagent_uid = landscapeUID1234", "", 1)
 			}
@@ -394,12 +408,94 @@ func TestRemoveDistroData(t *testing.T) {
 	}
 }
 
+func TestRenameDistroData(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		noMetadata      bool
+		filesDoNotExist bool
+		dirDoesNotExist bool
+		newNameIsDir    bool
+
+		wantErr bool
+	}{
+		"Success":                                  {},
+		"Success when there is no metadata":        {noMetadata: true},
+		"Success when the files did not exist":     {filesDoNotExist: true},
+		"Success when the directory did not exist": {dirDoesNotExist: true},
+
+		"Error when the files cannot be renamed": {newNameIsDir: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			oldName := "CoolDistro"
+			newName := "CoolerDistro"
+
+			publicDir := t.TempDir()
+			dir := filepath.Join(publicDir, ".cloud-init")
+
+			ci, err := cloudinit.New(ctx, &mockConfig{}, publicDir)
+			require.NoError(t, err, "Setup: cloud-init New should return no errors")
+
+			if !tc.dirDoesNotExist {
+				require.NoError(t, os.MkdirAll(dir, 0700), "Setup: could not set up directory")
+			}
+			if !tc.dirDoesNotExist && !tc.filesDoNotExist {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, oldName+".user-data"), []byte("user data"), 0600), "Setup: could not write user data")
+				if !tc.noMetadata {
+					require.NoError(t, os.WriteFile(filepath.Join(dir, oldName+".meta-data"), []byte("metadata"), 0600), "Setup: could not write metadata")
+				}
+			}
+			if tc.newNameIsDir {
+				// The user data cannot replace a non-empty directory.
+				require.NoError(t, os.MkdirAll(filepath.Join(dir, newName+".user-data", "child"), 0700), "Setup: could not set up directory")
+			}
+
+			err = ci.RenameDistroData(oldName, newName)
+			if tc.wantErr {
+				require.Error(t, err, "RenameDistroData should return an error")
+				require.FileExists(t, filepath.Join(dir, oldName+".user-data"), "RenameDistroData should not have removed the old user data")
+				return
+			}
+			require.NoError(t, err, "RenameDistroData should return no errors")
+
+			require.NoFileExists(t, filepath.Join(dir, oldName+".user-data"), "RenameDistroData should have moved the user data")
+			require.NoFileExists(t, filepath.Join(dir, oldName+".meta-data"), "RenameDistroData should have moved the metadata")
+
+			if tc.dirDoesNotExist || tc.filesDoNotExist {
+				require.NoFileExists(t, filepath.Join(dir, newName+".user-data"), "RenameDistroData should not have created any user data")
+				return
+			}
+
+			got, err := os.ReadFile(filepath.Join(dir, newName+".user-data"))
+			require.NoError(t, err, "The user data should have been moved to the new name")
+			require.Equal(t, "user data", string(got), "The user data should not have changed")
+
+			if tc.noMetadata {
+				require.NoFileExists(t, filepath.Join(dir, newName+".meta-data"), "RenameDistroData should not have created any metadata")
+				return
+			}
+			got, err = os.ReadFile(filepath.Join(dir, newName+".meta-data"))
+			require.NoError(t, err, "The metadata should have been moved to the new name")
+			require.Equal(t, "metadata", string(got), "The metadata should not have changed")
+		})
+	}
+}
+
 type mockConfig struct {
 	proToken       string
 	subcriptionErr bool
+	contractRules  []config.ContractRule
 
 	landscapeConf string
 	landscapeErr  bool
+
+	proxy    config.Proxy
+	proxyErr bool
 }
 
 func (c mockConfig) Subscription() (string, config.Source, error) {
@@ -414,6 +510,10 @@ func (c mockConfig) Subscription() (string, config.Source, error) {
 	return c.proToken, config.SourceUser, nil
 }
 
+func (c mockConfig) ContractRules() ([]config.ContractRule, error) {
+	return c.contractRules, nil
+}
+
 func (c mockConfig) LandscapeClientConfig() (string, config.Source, error) {
 	if c.landscapeErr {
 		return "", config.SourceNone, errors.New("could not get landscape configuration: mock error")
@@ -425,3 +525,11 @@ func (c mockConfig) LandscapeClientConfig() (string, config.Source, error) {
 
 	return c.landscapeConf, config.SourceUser, nil
 }
+
+func (c mockConfig) Proxy() (config.Proxy, error) {
+	if c.proxyErr {
+		return config.Proxy{}, errors.New("could not get proxy settings: mock error")
+	}
+
+	return c.proxy, nil
+}
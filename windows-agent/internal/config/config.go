@@ -1,5 +1,5 @@
 // Package config manages configuration parameters. It manages the configuration for
-// the Windows Agent so that only a single config file needs to exist.
+// the Windows Agent so that it is kept in a single place of the storage.
 package config
 
 import (
@@ -9,25 +9,57 @@ import (
 	"errors"
 	"fmt"
 	"net"
-	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/rollout"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/wakepolicy"
 	"github.com/ubuntu/decorate"
 	"gopkg.in/ini.v1"
 )
 
 // Config manages configuration parameters. It is a wrapper around a dictionary
-// that reads and updates the config file.
+// that reads and updates the config in the storage.
 type Config struct {
 	// data
 	configState
 
-	// disk backing
-	storagePath string
+	// adoptUnmanaged is the registry policy on unmanaged distros. As it is not stored, it lives outside configState.
+	adoptUnmanaged bool
+
+	// wakePolicy is the registry policy on waking distros up for background work. It is not stored either.
+	wakePolicy wakepolicy.Policy
+
+	// rolloutPolicy is the registry policy on rolling configuration changes out to the distros. Ditto.
+	rolloutPolicy rollout.Policy
+
+	// schedules are the tasks that the registry policy submits periodically to the distros. Ditto.
+	schedules []Schedule
+
+	// schedulesErr is why the last table of schedules received is invalid, in which case schedules is the last valid one.
+	schedulesErr error
+
+	// fileData are the settings of the configuration file of the agent, which apply where the registry has none.
+	fileData FileData
+
+	// history keeps the settings before their last change, so that failed rollouts can be rolled back.
+	history configHistory
+
+	// subscriptionRollout and landscapeRollout roll the changes of the Ubuntu Pro tokens and of the Landscape
+	// configuration out to the distros. The distros they have not reached yet keep the previous settings.
+	subscriptionRollout *rollout.Controller
+	landscapeRollout    *rollout.Controller
+
+	// registryReceived is true once the registry data has been received, so that its previous values are known.
+	registryReceived bool
+
+	// storage backing
+	storageDir string
+	ctx        context.Context
 
 	// Sync
 	mu *sync.Mutex
@@ -35,6 +67,8 @@ type Config struct {
 	// observers are notified after any configuration changes.
 	notifyLandscape LandscapeNotifier
 	notifyUbuntuPro UbuntuProNotifier
+	notifyProxy     ProxyNotifier
+	notifyServices  ProServicesNotifier
 }
 
 // UbuntuProNotifier is a function that is called when the Ubuntu Pro subscription changes.
@@ -43,23 +77,38 @@ type UbuntuProNotifier func(ctx context.Context, token string)
 // LandscapeNotifier is a function that is called when the Landscape configuration changes.
 type LandscapeNotifier func(ctx context.Context, config, uid string)
 
+// ProxyNotifier is a function that is called when the proxy settings change.
+type ProxyNotifier func(ctx context.Context, proxy Proxy)
+
+// ProServicesNotifier is a function that is called when the Ubuntu Pro services policy changes.
+type ProServicesNotifier func(ctx context.Context)
+
 // configState contains the actual configuration data.
 //
 // Its methods must be public for proper YAML (un)marshalling.
 type configState struct {
 	Subscription subscription
 	Landscape    landscapeConf
+	Contracts    contracts
+	Proxy        proxyConf
+	ProServices  proServicesConf
 }
 
 // New creates and initializes a new Config object.
 func New(ctx context.Context, cachePath string) (m *Config) {
 	m = &Config{
-		storagePath: filepath.Join(cachePath, "config"),
-		mu:          &sync.Mutex{},
+		storageDir: cachePath,
+		ctx:        ctx,
+		mu:         &sync.Mutex{},
+
+		subscriptionRollout: rollout.New(ctx),
+		landscapeRollout:    rollout.New(ctx),
 
 		// No-ops to avoid nil checks
 		notifyUbuntuPro: func(ctx context.Context, token string) {},
 		notifyLandscape: func(ctx context.Context, config, uid string) {},
+		notifyProxy:     func(ctx context.Context, proxy Proxy) {},
+		notifyServices:  func(ctx context.Context) {},
 	}
 
 	return m
@@ -81,6 +130,22 @@ func (c *Config) SetUbuntuProNotifier(notify UbuntuProNotifier) {
 	c.notifyUbuntuPro = notify
 }
 
+// SetProxyNotifier sets the function to be called when the proxy settings change.
+func (c *Config) SetProxyNotifier(notify ProxyNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifyProxy = notify
+}
+
+// SetProServicesNotifier sets the function to be called when the Ubuntu Pro services policy changes.
+func (c *Config) SetProServicesNotifier(notify ProServicesNotifier) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notifyServices = notify
+}
+
 // Subscription returns the ProToken and the method it was acquired with (if any).
 func (c *Config) Subscription() (token string, source Source, err error) {
 	s, err := c.get()
@@ -92,6 +157,152 @@ func (c *Config) Subscription() (token string, source Source, err error) {
 	return token, source, nil
 }
 
+// ContractFor returns the Ubuntu Pro token that applies to the given distro, which carries
+// the specified labels. Distros matching the token mapping table use the mapped token,
+// and the rest use the default subscription. Distros that the rollout of the last change
+// has not reached yet keep the token that applied before it.
+func (c *Config) ContractFor(distroName string, labels ...string) (Contract, error) {
+	s, err := c.get()
+	if err != nil {
+		return Contract{}, fmt.Errorf("config: could not get Ubuntu Pro contract for %q: %v", distroName, err)
+	}
+
+	if !c.subscriptionRollout.Reached(distroName) {
+		if prev, err := c.PreviousContractFor(distroName, labels...); err == nil {
+			return prev, nil
+		}
+	}
+
+	return s.subscriptionState().contractFor(distroName, labels), nil
+}
+
+// PreviousContractFor is like ContractFor, but it returns the Ubuntu Pro token that applied to the distro
+// before the last change of the subscription or of the token mapping table.
+func (c *Config) PreviousContractFor(distroName string, labels ...string) (Contract, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.load(); err != nil {
+		return Contract{}, fmt.Errorf("config: could not get previous Ubuntu Pro contract for %q: %v", distroName, err)
+	}
+
+	prev, err := c.history.Subscription.get()
+	if err != nil {
+		return Contract{}, fmt.Errorf("config: could not get previous Ubuntu Pro contract for %q: %v", distroName, err)
+	}
+
+	return prev.contractFor(distroName, labels), nil
+}
+
+// RollBackSubscription puts the subscription and the token mapping table in effect before the last change
+// back in effect, until the next change. It is used when the rollout of a change fails.
+func (c *Config) RollBackSubscription() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.load(); err != nil {
+		return fmt.Errorf("config: could not roll back Ubuntu Pro subscription: %v", err)
+	}
+	if err := c.history.Subscription.rollBack(); err != nil {
+		return fmt.Errorf("config: could not roll back Ubuntu Pro subscription: %v", err)
+	}
+	if err := c.dump(); err != nil {
+		return fmt.Errorf("config: could not roll back Ubuntu Pro subscription: %v", err)
+	}
+	return nil
+}
+
+// SubscriptionRollout returns the controller of the rollouts of the Ubuntu Pro tokens to the distros.
+func (c *Config) SubscriptionRollout() *rollout.Controller {
+	return c.subscriptionRollout
+}
+
+// subscriptionState returns the settings that decide the Ubuntu Pro token of each distro.
+func (s configState) subscriptionState() subscriptionState {
+	return subscriptionState{
+		User:         s.Subscription.User,
+		Store:        s.Subscription.Store,
+		Organization: s.Subscription.Organization,
+		Rules:        s.Contracts.Rules,
+	}
+}
+
+// contractFor returns the Ubuntu Pro token that applies to the given distro.
+func (s subscriptionState) contractFor(distroName string, labels []string) Contract {
+	if r, ok := (contracts{Rules: s.Rules}).match(distroName, labels); ok {
+		return Contract{Token: r.Token, Source: SourceRegistry, Pattern: r.Pattern}
+	}
+
+	token, source := subscription{User: s.User, Store: s.Store, Organization: s.Organization}.resolve()
+	return Contract{Token: token, Source: source}
+}
+
+// ContractRules returns the token mapping table for distros that must not use the default subscription.
+func (c *Config) ContractRules() ([]ContractRule, error) {
+	s, err := c.get()
+	if err != nil {
+		return nil, fmt.Errorf("config: could not get Ubuntu Pro token map: %v", err)
+	}
+
+	return s.Contracts.Rules, nil
+}
+
+// ProServicesFor returns the Ubuntu Pro services that must be enabled and disabled in the given
+// distro, which carries the specified labels. Distros matching the services mapping table use the
+// mapped services, and the rest use the default ones.
+func (c *Config) ProServicesFor(distroName string, labels ...string) (ProServices, error) {
+	s, err := c.get()
+	if err != nil {
+		return ProServices{}, fmt.Errorf("config: could not get Ubuntu Pro services for %q: %v", distroName, err)
+	}
+
+	return s.ProServices.match(distroName, labels), nil
+}
+
+// Proxy returns the proxy settings to use for outbound connections, both from the agent and from the distros.
+func (c *Config) Proxy() (Proxy, error) {
+	s, err := c.get()
+	if err != nil {
+		return Proxy{}, fmt.Errorf("config: could not get proxy settings: %v", err)
+	}
+
+	return s.Proxy.Settings, nil
+}
+
+// AdoptUnmanaged returns true if the agent must attempt to make unmanaged Ubuntu distros managed,
+// by installing and enabling wsl-pro-service in them.
+func (c *Config) AdoptUnmanaged() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.adoptUnmanaged
+}
+
+// WakePolicy returns the policy that waking distros up for background work must abide by.
+func (c *Config) WakePolicy() wakepolicy.Policy {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.wakePolicy
+}
+
+// RolloutPolicy returns the policy that rollouts of configuration changes to the distros must abide by.
+func (c *Config) RolloutPolicy() rollout.Policy {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rolloutPolicy
+}
+
+// Schedules returns the tasks that must be submitted periodically to the distros. If the last table
+// received is invalid, the last valid one is returned along with the parsing error.
+func (c *Config) Schedules() ([]Schedule, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.schedules), c.schedulesErr
+}
+
 // LandscapeClientConfig returns the complete Landscape client configuration and
 // the method it was acquired with (if any).
 func (c *Config) LandscapeClientConfig() (string, Source, error) {
@@ -104,6 +315,68 @@ func (c *Config) LandscapeClientConfig() (string, Source, error) {
 	return conf, src, nil
 }
 
+// LandscapeClientConfigFor is like LandscapeClientConfig, but distros that the rollout of the last change
+// has not reached yet keep the configuration in effect before it.
+func (c *Config) LandscapeClientConfigFor(distroName string) (string, Source, error) {
+	conf, src, err := c.LandscapeClientConfig()
+	if err != nil || c.landscapeRollout.Reached(distroName) {
+		return conf, src, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, err := c.history.Landscape.get()
+	if err != nil {
+		// The configuration before the change is unknown: the current one is the best we have.
+		return conf, src, nil
+	}
+
+	conf, src = prev.resolve()
+	return conf, src, nil
+}
+
+// LandscapeRollout returns the controller of the rollouts of the Landscape configuration to the distros.
+func (c *Config) LandscapeRollout() *rollout.Controller {
+	return c.landscapeRollout
+}
+
+// PreviousLandscapeClientConfig returns the complete Landscape client configuration in effect before the last change.
+func (c *Config) PreviousLandscapeClientConfig() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.load(); err != nil {
+		return "", fmt.Errorf("config: could not get previous Landscape configuration: %v", err)
+	}
+
+	prev, err := c.history.Landscape.get()
+	if err != nil {
+		return "", fmt.Errorf("config: could not get previous Landscape configuration: %v", err)
+	}
+
+	conf, _ := prev.resolve()
+	return conf, nil
+}
+
+// RollBackLandscapeConfig puts the Landscape client configuration in effect before the last change back in
+// effect, until the next change. It is used when the rollout of a change fails.
+func (c *Config) RollBackLandscapeConfig() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.load(); err != nil {
+		return fmt.Errorf("config: could not roll back Landscape configuration: %v", err)
+	}
+	if err := c.history.Landscape.rollBack(); err != nil {
+		return fmt.Errorf("config: could not roll back Landscape configuration: %v", err)
+	}
+	if err := c.dump(); err != nil {
+		return fmt.Errorf("config: could not roll back Landscape configuration: %v", err)
+	}
+	return nil
+}
+
 // SetUserSubscription overwrites the value of the user-provided Ubuntu Pro token.
 func (c *Config) SetUserSubscription(ctx context.Context, proToken string) (err error) {
 	defer decorate.OnError(&err, "config: could not set user-provided Ubuntu Pro subscription")
@@ -126,6 +399,9 @@ func (c *Config) SetUserSubscription(ctx context.Context, proToken string) (err
 		return ErrUserConfigIsNotNew
 	}
 
+	if err := c.rememberSubscription(s); err != nil {
+		log.Warningf(ctx, "%v", err)
+	}
 	c.notifyUbuntuPro(ctx, proToken)
 	return nil
 }
@@ -152,6 +428,9 @@ func (c *Config) SetStoreSubscription(ctx context.Context, proToken string) (err
 	}
 
 	if isNew {
+		if err := c.rememberSubscription(s); err != nil {
+			log.Warningf(ctx, "%v", err)
+		}
 		c.notifyUbuntuPro(ctx, proToken)
 	}
 
@@ -169,6 +448,11 @@ func (c *Config) SetUserLandscapeConfig(ctx context.Context, landscapeConfig str
 		return fmt.Errorf("config: could not complete Landscape configuration: %v", err)
 	}
 
+	prev, err := c.get()
+	if err != nil {
+		return fmt.Errorf("config: could not get existing Landscape configuration: %v", err)
+	}
+
 	isNew, err := c.set(&c.Landscape.UserConfig, landscapeConfig)
 	if err != nil {
 		return errors.New("config: could not set Landscape configuration")
@@ -178,6 +462,10 @@ func (c *Config) SetUserLandscapeConfig(ctx context.Context, landscapeConfig str
 		return ErrUserConfigIsNotNew
 	}
 
+	if err := c.rememberLandscape(prev); err != nil {
+		log.Warningf(ctx, "%v", err)
+	}
+
 	c.notifyLandscape(ctx, landscapeConfig, c.Landscape.UID)
 
 	return nil
@@ -219,6 +507,7 @@ func (c *Config) setAgentUIDAndUpdateClientConf(ctx context.Context, uid string)
 		return "", nil
 	}
 
+	prev := c.Landscape
 	updated, err := completeLandscapeConfig(landscapeConf, uid)
 	if err != nil {
 		return "", fmt.Errorf("config: could not update client conf with agent UID changes: %v", err)
@@ -235,6 +524,7 @@ func (c *Config) setAgentUIDAndUpdateClientConf(ctx context.Context, uid string)
 
 	oldUID := c.Landscape.UID
 	c.Landscape.UID = uid
+	c.history.Landscape.remember(landscapeState{UserConfig: prev.UserConfig, OrgConfig: prev.OrgConfig})
 	if e := c.dump(); e != nil {
 		// rollback if we can't dump the config
 		log.Warning(ctx, "Failed to dump config after changing agent UID, rolling back")
@@ -247,6 +537,7 @@ func (c *Config) setAgentUIDAndUpdateClientConf(ctx context.Context, uid string)
 		}
 		return "", fmt.Errorf("config: could not set Landscape agent UID: %v", e)
 	}
+
 	return updated, err
 }
 
@@ -258,7 +549,38 @@ func (c *Config) get() (s configState, err error) {
 		return s, err
 	}
 
-	return c.configState, nil
+	s = c.configState
+	c.history.apply(&s)
+	return s, nil
+}
+
+// rememberSubscription keeps the subscription settings before a change in the history.
+func (c *Config) rememberSubscription(prev configState) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.history.Subscription.remember(prev.subscriptionState())
+	if err := c.dump(); err != nil {
+		return fmt.Errorf("config: could not store the Ubuntu Pro subscription before the change: %v", err)
+	}
+	return nil
+}
+
+// rememberLandscape keeps the Landscape configuration before a change in the history.
+func (c *Config) rememberLandscape(prev configState) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.history.Landscape.remember(prev.landscapeState())
+	if err := c.dump(); err != nil {
+		return fmt.Errorf("config: could not store the Landscape configuration before the change: %v", err)
+	}
+	return nil
+}
+
+// landscapeState returns the settings that decide the Landscape configuration of the distros.
+func (s configState) landscapeState() landscapeState {
+	return landscapeState{UserConfig: s.Landscape.UserConfig, OrgConfig: s.Landscape.OrgConfig}
 }
 
 // set is a generic method to safely modify the config.
@@ -305,9 +627,82 @@ func (c *Config) LandscapeAgentUID() (string, error) {
 // RegistryData contains the data that the Ubuntu Pro registry key can provide.
 type RegistryData struct {
 	UbuntuProToken, LandscapeConfig string
+
+	// UbuntuProTokenMap is the token mapping table for distros that must
+	// not use the default subscription. See ContractFor.
+	UbuntuProTokenMap string
+
+	// UbuntuProServices and UbuntuProServicesMap are the Ubuntu Pro services that must be
+	// enabled and disabled in the distros. See ProServicesFor.
+	UbuntuProServices, UbuntuProServicesMap string
+
+	// HTTPProxy, HTTPSProxy and NoProxy are the proxy settings. See Proxy.
+	HTTPProxy, HTTPSProxy, NoProxy string
+
+	// AdoptUnmanaged is the policy on unmanaged distros. See AdoptUnmanaged.
+	AdoptUnmanaged bool
+
+	// MaintenanceWindows, MaxAwakeDistros and NeverWakeDistros are the policy on waking distros up
+	// for background work. See WakePolicy.
+	MaintenanceWindows string
+	MaxAwakeDistros    int
+	NeverWakeDistros   bool
+
+	// RolloutCanaries, RolloutBatchSize, RolloutSuccessThreshold and RolloutMaxFailures are the policy on
+	// rolling changes of the subscription and of the Landscape configuration out to the distros. See RolloutPolicy.
+	RolloutCanaries, RolloutBatchSize           int
+	RolloutSuccessThreshold, RolloutMaxFailures int
+
+	// ScheduledTasks is the table of tasks submitted periodically to the distros. See Schedules.
+	ScheduledTasks string
+}
+
+// FileData contains the settings that the configuration file of the agent can provide. Each of them is
+// a fallback for the homonymous RegistryData field, and only applies when the registry does not set it.
+type FileData struct {
+	UbuntuProTokenMap                       string
+	UbuntuProServices, UbuntuProServicesMap string
+	HTTPProxy, HTTPSProxy, NoProxy          string
+	ScheduledTasks                          string
 }
 
-// UpdateRegistryData takes in data from the registry and applies it as necessary.
+// SetFileData sets the settings provided by the configuration file of the agent. They are applied
+// along with the registry data, on its next update.
+func (c *Config) SetFileData(data FileData) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fileData = data
+}
+
+// withFileData returns the registry data, completed with the file settings that the registry does not set.
+// The proxy settings are taken as a whole from either of them, so that they are never mixed up.
+func (data RegistryData) withFileData(file FileData) RegistryData {
+	if data.UbuntuProTokenMap == "" {
+		data.UbuntuProTokenMap = file.UbuntuProTokenMap
+	}
+
+	if data.UbuntuProServices == "" {
+		data.UbuntuProServices = file.UbuntuProServices
+	}
+
+	if data.UbuntuProServicesMap == "" {
+		data.UbuntuProServicesMap = file.UbuntuProServicesMap
+	}
+
+	if data.HTTPProxy == "" && data.HTTPSProxy == "" && data.NoProxy == "" {
+		data.HTTPProxy, data.HTTPSProxy, data.NoProxy = file.HTTPProxy, file.HTTPSProxy, file.NoProxy
+	}
+
+	if data.ScheduledTasks == "" {
+		data.ScheduledTasks = file.ScheduledTasks
+	}
+
+	return data
+}
+
+// UpdateRegistryData takes in data from the registry and applies it as necessary, along with the
+// settings of the configuration file that the registry does not override.
 func (c *Config) UpdateRegistryData(ctx context.Context, data RegistryData, db *database.DistroDB) (err error) {
 	defer decorate.OnError(&err, "config: could not update registry-provided data")
 
@@ -325,11 +720,85 @@ func (c *Config) UpdateRegistryData(ctx context.Context, data RegistryData, db *
 	if err = c.load(); err != nil {
 		return err
 	}
+	prev := c.configState
+
+	data = data.withFileData(c.fileData)
+
+	// Proxy settings
+	// They go first so that distros are told about the proxy before attempting to reach any server.
+	proxy := Proxy{HTTP: data.HTTPProxy, HTTPS: data.HTTPSProxy, NoProxy: data.NoProxy}
+	if err := proxy.validate(); err != nil {
+		log.Errorf(ctx, "Config: removing proxy settings from registry: %v", err)
+		proxy = Proxy{}
+	}
+	// We don't duplicate org data inside the config file.
+	c.configState.Proxy.Settings = proxy
+	if hasChanged(proxy.String(), &c.configState.Proxy.Checksum) {
+		log.Debug(ctx, "Config: new proxy settings received from the registry")
+
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyProxy(ctx, proxy)
+		})
+	}
+
+	// Adoption of unmanaged distros
+	if c.adoptUnmanaged != data.AdoptUnmanaged {
+		log.Debugf(ctx, "Config: adoption of unmanaged distros set to %t from the registry", data.AdoptUnmanaged)
+	}
+	c.adoptUnmanaged = data.AdoptUnmanaged
+
+	// Wake policy
+	wake := wakepolicy.Policy{MaxAwake: max(data.MaxAwakeDistros, 0), NeverWake: data.NeverWakeDistros}
+	windows, err := wakepolicy.ParseWindows(data.MaintenanceWindows)
+	if err != nil {
+		// Ignoring the windows would allow waking distros up at any time, which is what they were meant to prevent.
+		log.Errorf(ctx, "Config: never waking distros up, as the maintenance windows from the registry are invalid: %v", err)
+		wake.NeverWake = true
+	}
+	wake.Windows = windows
+	if !c.wakePolicy.Equal(wake) {
+		log.Debugf(ctx, "Config: new wake policy received from the registry: windows %q, at most %d distros awake, never wake: %t",
+			windows, wake.MaxAwake, wake.NeverWake)
+
+		afterUnlock = append(afterUnlock, func() {
+			if db != nil {
+				db.SetWakePolicy(wake)
+			}
+		})
+	}
+	c.wakePolicy = wake
+
+	// Rollout policy
+	rollouts := rollout.Policy{
+		Canaries:         max(data.RolloutCanaries, 0),
+		BatchSize:        max(data.RolloutBatchSize, 0),
+		SuccessThreshold: min(max(data.RolloutSuccessThreshold, 0), 100),
+		MaxFailures:      max(data.RolloutMaxFailures, 0),
+	}
+	if c.rolloutPolicy != rollouts {
+		log.Debugf(ctx, "Config: new rollout policy received from the registry: %d canaries, batches of %d, %d%% success threshold, at most %d failures",
+			rollouts.Canaries, rollouts.BatchSize, rollouts.SuccessThreshold, rollouts.MaxFailures)
+	}
+	c.rolloutPolicy = rollouts
+
+	// Scheduled tasks
+	// An invalid table keeps the previous one in effect, rather than cancelling every schedule.
+	schedules, err := parseSchedules(data.ScheduledTasks)
+	c.schedulesErr = err
+	if err != nil {
+		log.Errorf(ctx, "Config: keeping the previous scheduled tasks, as the ones from the registry are invalid: %v", err)
+	} else {
+		if !slices.Equal(c.schedules, schedules) {
+			log.Debugf(ctx, "Config: %d scheduled tasks received from the registry", len(schedules))
+		}
+		c.schedules = schedules
+	}
 
 	// Ubuntu Pro subscription
 	// We store it in the config now because we don't duplicate org data inside the config file.
 	c.configState.Subscription.Organization = data.UbuntuProToken
-	if hasChanged(data.UbuntuProToken, &c.configState.Subscription.Checksum) {
+	tokenChanged := hasChanged(data.UbuntuProToken, &c.configState.Subscription.Checksum)
+	if tokenChanged {
 		log.Debug(ctx, "Config: new Ubuntu Pro subscription received from the registry")
 
 		// We must resolve the subscription in case a lower priority token becomes active
@@ -339,6 +808,58 @@ func (c *Config) UpdateRegistryData(ctx context.Context, data RegistryData, db *
 		})
 	}
 
+	// Token mapping table
+	// An invalid table keeps the previous one in effect, rather than attaching every mapped distro to the default contract.
+	var mapChanged bool
+	rules, err := parseContractRules(data.UbuntuProTokenMap)
+	if err != nil {
+		log.Errorf(ctx, "Config: keeping the previous Ubuntu Pro token map, as the one from the registry is invalid: %v", err)
+	} else {
+		// Ditto for not duplicating org data.
+		c.Contracts.Rules = rules
+		mapChanged = hasChanged(data.UbuntuProTokenMap, &c.Contracts.Checksum)
+	}
+	if mapChanged && !tokenChanged {
+		log.Debug(ctx, "Config: new Ubuntu Pro token map received from the registry")
+
+		// The default subscription is unchanged, but the tokens of the mapped distros may have.
+		resolv, _ := c.configState.Subscription.resolve()
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyUbuntuPro(ctx, resolv)
+		})
+	}
+
+	// The previous registry data is only known if it was received since the agent started.
+	if (tokenChanged || mapChanged) && c.registryReceived {
+		c.history.Subscription.remember(prev.subscriptionState())
+	}
+
+	// Ubuntu Pro services
+	services, err := parseProServices(data.UbuntuProServices)
+	if err != nil {
+		log.Errorf(ctx, "Config: removing Ubuntu Pro services from registry: %v", err)
+		data.UbuntuProServices = ""
+	}
+	servicesRules, err := parseProServicesRules(data.UbuntuProServicesMap)
+	if err != nil {
+		log.Errorf(ctx, "Config: removing Ubuntu Pro services map from registry: %v", err)
+		data.UbuntuProServicesMap = ""
+	}
+	// Ditto for not duplicating org data.
+	c.ProServices.Default = services
+	c.ProServices.Rules = servicesRules
+	var servicesPolicy string
+	if data.UbuntuProServices != "" || data.UbuntuProServicesMap != "" {
+		servicesPolicy = data.UbuntuProServices + "\n" + data.UbuntuProServicesMap
+	}
+	if hasChanged(servicesPolicy, &c.ProServices.Checksum) {
+		log.Debug(ctx, "Config: new Ubuntu Pro services received from the registry")
+
+		afterUnlock = append(afterUnlock, func() {
+			c.notifyServices(ctx)
+		})
+	}
+
 	// Landscape configuration
 	conf, err := completeLandscapeConfig(data.LandscapeConfig, c.Landscape.UID)
 	if err != nil {
@@ -349,6 +870,10 @@ func (c *Config) UpdateRegistryData(ctx context.Context, data RegistryData, db *
 	if hasChanged(conf, &c.Landscape.Checksum) {
 		log.Debug(ctx, "Config: new Landscape configuration received from the registry")
 
+		if c.registryReceived {
+			c.history.Landscape.remember(prev.landscapeState())
+		}
+
 		// We must resolve the landscape config in case a lower priority config becomes active
 		resolv, _ := c.Landscape.resolve()
 		uid := c.Landscape.UID
@@ -361,6 +886,7 @@ func (c *Config) UpdateRegistryData(ctx context.Context, data RegistryData, db *
 		return err
 	}
 
+	c.registryReceived = true
 	return nil
 }
 
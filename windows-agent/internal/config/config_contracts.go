@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ContractRule maps the distros whose name matches Pattern to an Ubuntu Pro token.
+type ContractRule struct {
+	// Pattern is a shell-like pattern (see path.Match) matched case-insensitively against the distro name.
+	// Patterns starting with "label:" are matched against the distro's labels instead.
+	Pattern string
+	Token   string
+}
+
+// Contract is the Ubuntu Pro token that applies to a particular distro.
+type Contract struct {
+	Token  string
+	Source Source
+
+	// Pattern is the pattern of the ContractRule that selected the token.
+	// It is empty when the default subscription applies.
+	Pattern string
+}
+
+// contracts contains the token mapping table for distros that must not use the default subscription.
+type contracts struct {
+	Rules    []ContractRule `yaml:"-"`
+	Checksum string
+}
+
+// labelPrefix marks the patterns that select distros by label rather than by name.
+const labelPrefix = "label:"
+
+// match returns the first rule that matches the distro name or one of its labels.
+func (c contracts) match(distroName string, labels []string) (ContractRule, bool) {
+	name := strings.ToLower(distroName)
+	for _, r := range c.Rules {
+		if r.matches(name, labels) {
+			return r, true
+		}
+	}
+
+	return ContractRule{}, false
+}
+
+// matches returns true if the rule selects the distro with the given lowercase name and labels.
+func (r ContractRule) matches(name string, labels []string) bool {
+	return matchDistro(r.Pattern, name, labels)
+}
+
+// matchDistro returns true if the pattern selects the distro with the given lowercase name and labels.
+func matchDistro(pattern, name string, labels []string) bool {
+	pattern = strings.ToLower(pattern)
+
+	// Patterns are validated when parsed, so errors are not possible.
+	labelPattern, byLabel := strings.CutPrefix(pattern, labelPrefix)
+	if !byLabel {
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}
+
+	labelPattern = strings.TrimSpace(labelPattern)
+	for _, l := range labels {
+		if ok, _ := path.Match(labelPattern, strings.ToLower(l)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseContractRules parses a token mapping table.
+//
+// Each non-empty line is a "pattern=token" pair. Lines starting with '#' are ignored.
+// Patterns like "label:ci" select the distros carrying a matching label.
+func parseContractRules(table string) ([]ContractRule, error) {
+	var rules []ContractRule
+
+	sc := bufio.NewScanner(strings.NewReader(table))
+	for i := 1; sc.Scan(); i++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern, token, found := strings.Cut(line, "=")
+		pattern, token = strings.TrimSpace(pattern), strings.TrimSpace(token)
+		if !found || pattern == "" || token == "" {
+			return nil, fmt.Errorf("line %d: expected 'pattern=token'", i)
+		}
+
+		if err := validatePattern(pattern); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i, err)
+		}
+
+		rules = append(rules, ContractRule{Pattern: pattern, Token: token})
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// validatePattern checks that a pattern selecting distros by name or by label is well formed.
+func validatePattern(pattern string) error {
+	p, byLabel := strings.CutPrefix(strings.ToLower(pattern), labelPrefix)
+	if byLabel && strings.TrimSpace(p) == "" {
+		return fmt.Errorf("empty label in pattern %q", pattern)
+	}
+
+	if _, err := path.Match(p, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+
+	return nil
+}
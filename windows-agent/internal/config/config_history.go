@@ -0,0 +1,87 @@
+package config
+
+import "errors"
+
+// errNoHistory is returned when the settings before the last change are not known, because the change happened
+// before the agent started.
+var errNoHistory = errors.New("the settings before the last change are not known")
+
+// configHistory keeps the settings that were distributed to the distros before the last change, so that a
+// rollout of the new ones can be rolled back. Unlike the config state, it is stored along with the registry
+// data it contains, so that a rollout interrupted by a restart can still be rolled back.
+type configHistory struct {
+	Subscription previous[subscriptionState]
+	Landscape    previous[landscapeState]
+}
+
+// subscriptionState contains the settings that decide the Ubuntu Pro token of each distro.
+type subscriptionState struct {
+	User         string `yaml:",omitempty"`
+	Store        string `yaml:",omitempty"`
+	Organization string `yaml:",omitempty"`
+
+	Rules []ContractRule `yaml:",omitempty"`
+}
+
+// landscapeState contains the settings that decide the Landscape configuration of the distros.
+type landscapeState struct {
+	UserConfig string `yaml:",omitempty"`
+	OrgConfig  string `yaml:",omitempty"`
+}
+
+// resolve returns the Landscape configuration in effect and its source.
+func (l landscapeState) resolve() (string, Source) {
+	return landscapeConf{UserConfig: l.UserConfig, OrgConfig: l.OrgConfig}.resolve()
+}
+
+// previous is the value of a setting before its last change.
+type previous[T any] struct {
+	Value T    `yaml:",omitempty"`
+	Known bool `yaml:",omitempty"`
+
+	// RolledBack is true while the previous value is in effect, because the rollout of the current one failed.
+	RolledBack bool `yaml:",omitempty"`
+}
+
+// remember keeps the value a setting had before a change. If the value in effect was a rolled back one,
+// it is kept instead, as the current value never reached the distros.
+func (p *previous[T]) remember(value T) {
+	if !p.RolledBack {
+		p.Value = value
+	}
+	p.Known = true
+	p.RolledBack = false
+}
+
+// get returns the previous value, or errNoHistory if it is not known.
+func (p previous[T]) get() (T, error) {
+	if !p.Known {
+		return p.Value, errNoHistory
+	}
+	return p.Value, nil
+}
+
+// rollBack puts the previous value in effect until the next change.
+func (p *previous[T]) rollBack() error {
+	if !p.Known {
+		return errNoHistory
+	}
+	p.RolledBack = true
+	return nil
+}
+
+// apply replaces the settings that are rolled back in the state with their previous values.
+func (h configHistory) apply(s *configState) {
+	if h.Subscription.RolledBack {
+		prev := h.Subscription.Value
+		s.Subscription.User = prev.User
+		s.Subscription.Store = prev.Store
+		s.Subscription.Organization = prev.Organization
+		s.Contracts.Rules = prev.Rules
+	}
+
+	if h.Landscape.RolledBack {
+		s.Landscape.UserConfig = h.Landscape.Value.UserConfig
+		s.Landscape.OrgConfig = h.Landscape.Value.OrgConfig
+	}
+}
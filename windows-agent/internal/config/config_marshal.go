@@ -1,53 +1,116 @@
 package config
 
 import (
-	"errors"
 	"fmt"
-	"io/fs"
-	"os"
+	"path/filepath"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
 	"github.com/ubuntu/decorate"
 	"go.yaml.in/yaml/v3"
 )
 
+const (
+	// configBucket is the storage bucket containing the config.
+	configBucket = "config"
+
+	// stateKey is the key of the config state in configBucket.
+	stateKey = "state"
+
+	// historyKey is the key of the settings before their last change in configBucket.
+	historyKey = "history"
+
+	// legacyFileName is the name of the file that contained the config before it moved into the storage.
+	legacyFileName = "config"
+)
+
 func (c *Config) load() (err error) {
-	defer decorate.OnError(&err, "could not load config from disk")
+	defer decorate.OnError(&err, "could not load config from storage")
 
-	var s configState
+	store, err := storage.Open(c.ctx, c.storageDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// Import the config file written by older versions of the agent.
+	err = storage.ImportFile(c.ctx, store, filepath.Join(c.storageDir, legacyFileName), func(tx storage.Tx, contents []byte) error {
+		var s configState
+		if err := yaml.Unmarshal(contents, &s); err != nil {
+			return fmt.Errorf("could not umarshal config file: %v", err)
+		}
+		return tx.Put(configBucket, stateKey, contents)
+	})
+	if err != nil {
+		return err
+	}
 
-	out, err := os.ReadFile(c.storagePath)
-	if errors.Is(err, fs.ErrNotExist) {
-		out = []byte{}
-	} else if err != nil {
-		return fmt.Errorf("could not read config file: %v", err)
+	var out, history []byte
+	err = store.View(func(tx storage.Tx) error {
+		out = tx.Get(configBucket, stateKey)
+		history = tx.Get(configBucket, historyKey)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not read config: %v", err)
 	}
 
+	var s configState
 	if err := yaml.Unmarshal(out, &s); err != nil {
-		return fmt.Errorf("could not umarshal config file: %v", err)
+		return fmt.Errorf("could not umarshal config: %v", err)
+	}
+
+	var h configHistory
+	if err := yaml.Unmarshal(history, &h); err != nil {
+		return fmt.Errorf("could not umarshal config history: %v", err)
 	}
 
 	// Registry data must not be overridden
 	tokenOrg := c.configState.Subscription.Organization
 	landscapeOrg := c.Landscape.OrgConfig
+	contractRules := c.Contracts.Rules
+	proxy := c.configState.Proxy.Settings
+	services := c.ProServices
 
 	c.configState = s
+	c.history = h
 
 	c.configState.Subscription.Organization = tokenOrg
 	c.Landscape.OrgConfig = landscapeOrg
+	c.Contracts.Rules = contractRules
+	c.configState.Proxy.Settings = proxy
+	c.ProServices.Default = services.Default
+	c.ProServices.Rules = services.Rules
 
 	return nil
 }
 
 func (c *Config) dump() (err error) {
-	defer decorate.OnError(&err, "could not store config to disk")
+	defer decorate.OnError(&err, "could not store config to storage")
 
 	out, err := yaml.Marshal(c.configState)
 	if err != nil {
 		return fmt.Errorf("could not marshal config: %v", err)
 	}
 
-	if err := os.WriteFile(c.storagePath, out, 0600); err != nil {
-		return fmt.Errorf("could not write config file: %v", err)
+	history, err := yaml.Marshal(c.history)
+	if err != nil {
+		return fmt.Errorf("could not marshal config history: %v", err)
+	}
+
+	store, err := storage.Open(c.ctx, c.storageDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	err = store.Update(func(tx storage.Tx) error {
+		if err := tx.Put(configBucket, stateKey, out); err != nil {
+			return err
+		}
+		return tx.Put(configBucket, historyKey, history)
+	})
+	if err != nil {
+		return fmt.Errorf("could not write config: %v", err)
 	}
 
 	return nil
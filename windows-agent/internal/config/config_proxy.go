@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Proxy contains the proxy settings for outbound connections. They follow the
+// conventions of the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+type Proxy struct {
+	HTTP    string
+	HTTPS   string
+	NoProxy string
+}
+
+// IsZero returns true if no proxy setting is set.
+func (p Proxy) IsZero() bool {
+	return p == Proxy{}
+}
+
+// String serializes the settings, so that they can be checksummed. Empty settings
+// serialize to an empty string.
+func (p Proxy) String() string {
+	if p.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("http=%s\nhttps=%s\nno_proxy=%s", p.HTTP, p.HTTPS, p.NoProxy)
+}
+
+// validate checks that the proxies are absolute URLs.
+func (p Proxy) validate() error {
+	for field, value := range map[string]string{"HTTP": p.HTTP, "HTTPS": p.HTTPS} {
+		if value == "" {
+			continue
+		}
+
+		u, err := url.Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s proxy: %v", field, err)
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid %s proxy %q: expected a URL such as http://proxy.example.com:3128", field, value)
+		}
+	}
+
+	if strings.ContainsAny(p.NoProxy, " \t\n") {
+		return fmt.Errorf("invalid no_proxy list %q: expected a comma-separated list without spaces", p.NoProxy)
+	}
+
+	return nil
+}
+
+// proxyConf contains the proxy settings provided by the organization.
+type proxyConf struct {
+	Settings Proxy `yaml:"-"`
+	Checksum string
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/wakepolicy"
+)
+
+// Kinds of scheduled tasks.
+const (
+	// ScheduleProRefresh refreshes the Ubuntu Pro contract information of the distro.
+	ScheduleProRefresh = "pro-refresh"
+
+	// ScheduleSecurityUpdate applies the pending security updates.
+	ScheduleSecurityUpdate = "security-update"
+
+	// ScheduleLandscapeConfig sends the Landscape client configuration again.
+	ScheduleLandscapeConfig = "landscape-config"
+
+	// SchedulePackageInventory collects the list of installed packages.
+	SchedulePackageInventory = "package-inventory"
+)
+
+// scheduleKinds are the kinds of tasks that can be scheduled.
+var scheduleKinds = []string{ScheduleProRefresh, ScheduleSecurityUpdate, ScheduleLandscapeConfig, SchedulePackageInventory}
+
+// schedulePeriods are the names accepted in place of a duration.
+var schedulePeriods = map[string]time.Duration{
+	"hourly": time.Hour,
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// Schedule is a task submitted periodically to the distros whose name matches Pattern.
+// Patterns follow the same syntax as the ones of ContractRule.
+type Schedule struct {
+	Pattern string
+
+	// Task is the kind of task, such as ScheduleSecurityUpdate.
+	Task string
+
+	// Every is the time between runs.
+	Every time.Duration
+
+	// At is the time of the day of the runs, as an offset from midnight in local time. It is only
+	// set for periods of whole days, when Aligned is true.
+	At      time.Duration
+	Aligned bool
+}
+
+// String returns the schedule in the format accepted in the schedules table. It identifies the schedule.
+func (s Schedule) String() string {
+	period := s.Every.String()
+	for name, d := range schedulePeriods {
+		if d == s.Every {
+			period = name
+		}
+	}
+
+	if s.Aligned {
+		period += "@" + wakepolicy.FormatTimeOfDay(s.At)
+	}
+
+	return fmt.Sprintf("%s=%s %s", s.Pattern, period, s.Task)
+}
+
+// Matches returns true if the schedule applies to the distro with the given name and labels.
+func (s Schedule) Matches(distroName string, labels []string) bool {
+	return matchDistro(s.Pattern, strings.ToLower(distroName), labels)
+}
+
+// parseSchedules parses a table of scheduled tasks.
+//
+// Each non-empty line is a "pattern=period task" pair, such as "label:ci=daily@02:00 security-update".
+// The period is hourly, daily, weekly or a duration like 6h, optionally followed by the time of the day
+// of the runs for periods of whole days. Lines starting with '#' are ignored.
+func parseSchedules(table string) ([]Schedule, error) {
+	var schedules []Schedule
+
+	sc := bufio.NewScanner(strings.NewReader(table))
+	for i := 1; sc.Scan(); i++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern, spec, found := strings.Cut(line, "=")
+		pattern = strings.TrimSpace(pattern)
+		fields := strings.Fields(spec)
+		if !found || pattern == "" || len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected 'pattern=period task'", i)
+		}
+
+		if err := validatePattern(pattern); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i, err)
+		}
+
+		s, err := parseSchedule(fields[0], strings.ToLower(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i, err)
+		}
+		s.Pattern = pattern
+
+		schedules = append(schedules, s)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// parseSchedule parses the period and the kind of task of a schedule.
+func parseSchedule(period, kind string) (s Schedule, err error) {
+	if !slices.Contains(scheduleKinds, kind) {
+		return s, fmt.Errorf("unknown task %q: expected one of %s", kind, strings.Join(scheduleKinds, ", "))
+	}
+	s.Task = kind
+
+	period, at, aligned := strings.Cut(strings.ToLower(period), "@")
+
+	var ok bool
+	if s.Every, ok = schedulePeriods[period]; !ok {
+		if s.Every, err = time.ParseDuration(period); err != nil {
+			return s, fmt.Errorf("invalid period %q: expected hourly, daily, weekly or a duration like 6h", period)
+		}
+	}
+	if s.Every < time.Hour {
+		return s, fmt.Errorf("invalid period %q: tasks cannot run more often than hourly", period)
+	}
+
+	if !aligned {
+		return s, nil
+	}
+
+	if s.Every%(24*time.Hour) != 0 {
+		return s, fmt.Errorf("invalid period %q: only periods of whole days can run at a time of the day", period)
+	}
+	if s.At, err = wakepolicy.ParseTimeOfDay(at); err != nil {
+		return s, err
+	}
+	s.Aligned = true
+
+	return s, nil
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ProServices are the Ubuntu Pro services that must be enabled and disabled in a distro.
+// Services in neither list are left as they are.
+type ProServices struct {
+	Enable  []string
+	Disable []string
+}
+
+// IsZero returns true if no service is to be enabled nor disabled.
+func (s ProServices) IsZero() bool {
+	return len(s.Enable) == 0 && len(s.Disable) == 0
+}
+
+// ProServicesRule maps the distros whose name matches Pattern to the Ubuntu Pro services that must
+// be enabled and disabled in them. Patterns follow the same syntax as the ones of ContractRule.
+type ProServicesRule struct {
+	Pattern  string
+	Services ProServices
+}
+
+// proServicesConf contains the Ubuntu Pro services provided by the organization.
+type proServicesConf struct {
+	// Default applies to the distros that match none of the rules.
+	Default  ProServices       `yaml:"-"`
+	Rules    []ProServicesRule `yaml:"-"`
+	Checksum string
+}
+
+// match returns the services of the first rule that matches the distro name or one of its labels,
+// or the default ones if none does.
+func (c proServicesConf) match(distroName string, labels []string) ProServices {
+	name := strings.ToLower(distroName)
+	for _, r := range c.Rules {
+		if matchDistro(r.Pattern, name, labels) {
+			return r.Services
+		}
+	}
+
+	return c.Default
+}
+
+// serviceName is the syntax of the names of the Ubuntu Pro services, such as esm-apps or cc-eal.
+var serviceName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// parseProServices parses a comma-separated list of services. Services prefixed with '-' must be
+// disabled, the rest must be enabled.
+func parseProServices(list string) (s ProServices, err error) {
+	for item := range strings.SplitSeq(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+
+		name, disable := strings.CutPrefix(item, "-")
+		if !serviceName.MatchString(name) {
+			return ProServices{}, fmt.Errorf("invalid service name %q", name)
+		}
+
+		if slices.Contains(s.Enable, name) || slices.Contains(s.Disable, name) {
+			return ProServices{}, fmt.Errorf("service %q is listed more than once", name)
+		}
+
+		if disable {
+			s.Disable = append(s.Disable, name)
+		} else {
+			s.Enable = append(s.Enable, name)
+		}
+	}
+
+	return s, nil
+}
+
+// parseProServicesRules parses a services mapping table.
+//
+// Each non-empty line is a "pattern=services" pair, where services is a list as parsed by
+// parseProServices. Lines starting with '#' are ignored.
+func parseProServicesRules(table string) ([]ProServicesRule, error) {
+	var rules []ProServicesRule
+
+	sc := bufio.NewScanner(strings.NewReader(table))
+	for i := 1; sc.Scan(); i++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern, list, found := strings.Cut(line, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !found || pattern == "" {
+			return nil, fmt.Errorf("line %d: expected 'pattern=services'", i)
+		}
+
+		if err := validatePattern(pattern); err != nil {
+			return nil, fmt.Errorf("line %d: %v", i, err)
+		}
+
+		services, err := parseProServices(list)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", i, err)
+		}
+
+		rules = append(rules, ProServicesRule{Pattern: pattern, Services: services})
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
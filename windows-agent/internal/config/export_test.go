@@ -0,0 +1,35 @@
+package config
+
+import (
+	"context"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+)
+
+// StoredConfig returns the config kept in the storage in the directory.
+func StoredConfig(ctx context.Context, storageDir string) (out []byte, err error) {
+	s, err := storage.Open(ctx, storageDir)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	err = s.View(func(tx storage.Tx) error {
+		out = tx.Get(configBucket, stateKey)
+		return nil
+	})
+	return out, err
+}
+
+// StoreConfig overwrites the config kept in the storage in the directory.
+func StoreConfig(ctx context.Context, storageDir string, contents []byte) error {
+	s, err := storage.Open(ctx, storageDir)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return s.Update(func(tx storage.Tx) error {
+		return tx.Put(configBucket, stateKey, contents)
+	})
+}
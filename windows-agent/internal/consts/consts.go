@@ -7,6 +7,11 @@ const (
 	// DefaultLogLevel is the default logging level selected without any option.
 	DefaultLogLevel = log.WarnLevel
 
-	// DatabaseFileName corresponds to the base name of the file containing the database.
+	// DatabaseFileName corresponds to the base name of the file that contained the database
+	// before it was moved into the storage.
 	DatabaseFileName = "distros.db"
+
+	// StorageFileName corresponds to the base name of the file containing the storage, where
+	// the agent keeps its state.
+	StorageFileName = "agent.db"
 )
@@ -5,11 +5,7 @@ package database
 import (
 	"context"
 	"errors"
-	"fmt"
-	"io/fs"
-	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -17,8 +13,13 @@ import (
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/wakepolicy"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
 	"github.com/ubuntu/decorate"
-	"go.yaml.in/yaml/v3"
+	wsl "github.com/ubuntu/gowsl"
 )
 
 const (
@@ -26,29 +27,45 @@ const (
 )
 
 // DistroDB is a thread-safe single-table database of WSL distribution instances. This
-// database is held in memory and backed in disk. Any write on the database will be instanly
-// followed up by a write-to-disk.
+// database is held in memory and backed in the storage. Any write on the database will be
+// instanly followed up by a write to the storage.
 type DistroDB struct {
 	distros map[string]*distro.Distro
+	indexes indexes
 	mu      sync.RWMutex
 
 	scheduleTrigger chan struct{}
+	dumpTrigger     chan struct{}
 
 	storageDir string
+	store      storage.Store
+
+	// newDistroTasks returns the tasks to submit to distros when they are added to the database.
+	newDistroTasks   func(*distro.Distro) ([]task.Task, error)
+	newDistroTasksMu sync.RWMutex
 
 	ctx       context.Context
 	cancelCtx func()
 	once      sync.Once
 
 	// Multiple distros starting at the same time can cause WSL (and the whole machine) to freeze up.
-	// This mutex is used to block multiple distros from starting at the same time.
-	distroStartMu sync.Mutex
+	// This scheduler limits how many distros start at the same time, and in which order.
+	startScheduler *startscheduler.Scheduler
+	wakePolicy     *wakepolicy.Governor
 
 	onCleanup []func(string)
+
+	// unmanaged caches the information about the Ubuntu distros not managed by the agent.
+	unmanaged *unmanagedCache
+
+	// onRename is called when a distro in the database is found registered under a new name.
+	onRename   func(oldName, newName string)
+	onRenameMu sync.RWMutex
 }
 
-// New creates a database and populates it with data in the file located
-// at "storagePath". Changes to the database will be written on this file.
+// New creates a database and populates it with data in the storage located
+// in "storageDir". Changes to the database will be written on the storage.
+// A database file written by an older version of the agent is imported into it.
 //
 // You must call Close to deallocate resources.
 //
@@ -56,8 +73,9 @@ type DistroDB struct {
 // undefined behaviour.
 //
 // Every certain amount of times, the database wil purge all distros that
-// are no longer registered or that have been marked as unreachable. This
-// cleanup can be triggered on demmand with TriggerCleanup.
+// are no longer registered or that have been marked as unreachable. Distros
+// that were renamed are moved to their new name instead. This cleanup can be
+// triggered on demmand with TriggerCleanup.
 func New(ctx context.Context, storageDir string, onCleanup ...func(string)) (db *DistroDB, err error) {
 	defer decorate.OnError(&err, "could not initialize database")
 
@@ -73,15 +91,30 @@ func New(ctx context.Context, storageDir string, onCleanup ...func(string)) (db
 	db = &DistroDB{
 		storageDir:      storageDir,
 		scheduleTrigger: make(chan struct{}),
+		dumpTrigger:     make(chan struct{}, 1),
 		ctx:             ctx,
 		cancelCtx:       cancel,
 		onCleanup:       onCleanup,
+		unmanaged:       newUnmanagedCache(),
+		startScheduler:  startscheduler.New(),
+		wakePolicy:      wakepolicy.New(),
+	}
+
+	db.store, err = storage.Open(ctx, storageDir)
+	if err != nil {
+		cancel()
+		return nil, err
 	}
 
 	if err := db.load(ctx); err != nil {
+		db.cleanupAllDistros(ctx)
+		db.store.Close()
+		cancel()
 		return nil, err
 	}
 
+	go db.watchUnmanagedDistros()
+
 	go func() {
 		for {
 			select {
@@ -89,6 +122,11 @@ func New(ctx context.Context, storageDir string, onCleanup ...func(string)) (db
 				return
 			case <-time.After(timeBetweenGC):
 			case <-db.scheduleTrigger:
+			case <-db.dumpTrigger:
+				if err := db.dumpIfRunning(); err != nil {
+					log.Errorf(ctx, "Database: failed to store updated records: %v", err)
+				}
+				continue
 			}
 
 			if err := db.cleanup(ctx); err != nil {
@@ -147,16 +185,30 @@ func (db *DistroDB) GetDistroAndUpdateProperties(ctx context.Context, name strin
 	normalizedName := strings.ToLower(name)
 	d, found := db.distros[normalizedName]
 
+	// Name not in database, known GUID: the distro was renamed, so it is moved to its new name.
+	if !found {
+		if oldKey, old, ok := db.renamedFrom(ctx, name); ok {
+			d, err := db.rename(ctx, oldKey, old, name)
+			if err != nil {
+				return nil, err
+			}
+			if d.SetProperties(props) {
+				db.indexes.add(normalizedName, d)
+				err = db.dump()
+			}
+			return d, err
+		}
+	}
+
 	// Name not in database: create a new distro and returns it
 	if !found {
 		log.Debugf(ctx, "Database: cache miss, creating %q and adding it to the database", name)
 
-		d, err := distro.New(db.ctx, name, props, db.storageDir, &db.distroStartMu)
+		d, err := distro.New(db.ctx, name, props, db.storageDir, db.startScheduler, db.distroOptions()...)
 		if err != nil {
 			return nil, err
 		}
-		db.distros[normalizedName] = d
-		err = db.dump()
+		err = db.add(ctx, normalizedName, d)
 		return d, err
 	}
 
@@ -168,13 +220,13 @@ func (db *DistroDB) GetDistroAndUpdateProperties(ctx context.Context, name strin
 
 		go d.Cleanup(ctx)
 		delete(db.distros, normalizedName)
+		db.indexes.remove(normalizedName)
 
-		d, err := distro.New(db.ctx, name, props, db.storageDir, &db.distroStartMu)
+		d, err := distro.New(db.ctx, name, props, db.storageDir, db.startScheduler, db.distroOptions()...)
 		if err != nil {
 			return nil, err
 		}
-		db.distros[normalizedName] = d
-		err = db.dump()
+		err = db.add(ctx, normalizedName, d)
 		return d, err
 	}
 
@@ -183,13 +235,160 @@ func (db *DistroDB) GetDistroAndUpdateProperties(ctx context.Context, name strin
 	// Name in database, correct GUID: refresh with latest properties of a valid distro
 	var err error
 	if d.SetProperties(props) {
+		db.indexes.add(normalizedName, d)
 		err = db.dump()
 	}
 
 	return d, err
 }
 
-// Dump stores the current database state to disk, overriding old dumps.
+// SetNewDistroTasks sets the function that returns the tasks to submit to distros when they are
+// added to the database. These tasks are deferred, and stored atomically with the new distro.
+func (db *DistroDB) SetNewDistroTasks(f func(*distro.Distro) ([]task.Task, error)) {
+	db.newDistroTasksMu.Lock()
+	defer db.newDistroTasksMu.Unlock()
+
+	db.newDistroTasks = f
+}
+
+// SetOnRename sets the function called when a distro in the database is found registered
+// under a new name, after it has been moved to it.
+func (db *DistroDB) SetOnRename(f func(oldName, newName string)) {
+	db.onRenameMu.Lock()
+	defer db.onRenameMu.Unlock()
+
+	db.onRename = f
+}
+
+// SetMaxConcurrentStarts sets the number of distros allowed to start at the same time.
+// Values lower than 1 are ignored.
+func (db *DistroDB) SetMaxConcurrentStarts(n int) {
+	db.startScheduler.SetParallelism(n)
+}
+
+// SetWakePolicy changes the policy that waking distros up for background work must abide by.
+// Distros already awake are not affected.
+func (db *DistroDB) SetWakePolicy(p wakepolicy.Policy) {
+	db.wakePolicy.SetPolicy(p)
+}
+
+// WakePolicy returns the policy that waking distros up for background work must abide by.
+func (db *DistroDB) WakePolicy() wakepolicy.Policy {
+	return db.wakePolicy.Policy()
+}
+
+// DeadLetters returns the tasks that were given up on after exhausting their retry policy, indexed by distro name.
+func (db *DistroDB) DeadLetters() (map[string][]worker.DeadLetter, error) {
+	return worker.StoredDeadLetters(db.store)
+}
+
+// QuarantinedTasks returns the stored tasks that could not be read back when loading the queues of the distros,
+// indexed by distro name.
+func (db *DistroDB) QuarantinedTasks() (map[string][]worker.QuarantinedTask, error) {
+	return worker.StoredQuarantine(db.store)
+}
+
+// TaskHistory returns the most recent outcomes of the tasks of every distro, oldest first, indexed by distro name.
+func (db *DistroDB) TaskHistory() (map[string][]worker.HistoryEntry, error) {
+	return worker.StoredHistory(db.store)
+}
+
+// PackageInventories returns the latest package inventories collected from every distro, indexed by distro name.
+func (db *DistroDB) PackageInventories() (map[string]worker.Inventories, error) {
+	return worker.StoredInventories(db.store)
+}
+
+// StartMetrics returns a snapshot of the admission of distro starts.
+func (db *DistroDB) StartMetrics() startscheduler.Metrics {
+	return db.startScheduler.Metrics()
+}
+
+// add adds a new distro to the database and stores it, along with its initial tasks.
+// It must be called with the database lock held.
+func (db *DistroDB) add(ctx context.Context, normalizedName string, d *distro.Distro) error {
+	db.distros[normalizedName] = d
+	db.indexes.add(normalizedName, d)
+
+	db.newDistroTasksMu.RLock()
+	newDistroTasks := db.newDistroTasks
+	db.newDistroTasksMu.RUnlock()
+
+	if newDistroTasks == nil {
+		return db.dump()
+	}
+
+	tasks, err := newDistroTasks(d)
+	if err != nil {
+		log.Warningf(ctx, "Database: could not get the initial tasks of distro %q: %v", d.Name(), err)
+	}
+	if len(tasks) == 0 {
+		return db.dump()
+	}
+
+	return d.SubmitDeferredTasksAtomically(func(saveTasks func(storage.Tx) error) error {
+		return db.store.Update(func(tx storage.Tx) error {
+			if err := db.write(tx); err != nil {
+				return err
+			}
+			return saveTasks(tx)
+		})
+	}, tasks...)
+}
+
+// distroOptions are the options for the distros created by the database.
+func (db *DistroDB) distroOptions() []distro.Option {
+	return []distro.Option{distro.WithOnRecordsChange(db.requestDump), distro.WithStore(db.store), distro.WithWakePolicy(db.wakePolicy)}
+}
+
+// UpdateProperties sets the properties of a distro in the database, and stores them
+// if they changed. Properties of distros in the database must be changed via this
+// method (or GetDistroAndUpdateProperties) so that queries remain accurate.
+func (db *DistroDB) UpdateProperties(d *distro.Distro, props distro.Properties) error {
+	if db.stopped() {
+		panic("UpdateProperties: database already stopped")
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if !d.SetProperties(props) {
+		return nil
+	}
+
+	if normalizedName := strings.ToLower(d.Name()); db.distros[normalizedName] == d {
+		db.indexes.add(normalizedName, d)
+	}
+
+	return db.dump()
+}
+
+// SetLabels replaces the labels of the distro with the specified name, and stores them
+// if they changed.
+func (db *DistroDB) SetLabels(name string, labels []string) (err error) {
+	defer decorate.OnError(&err, "could not set labels of distro %q", name)
+
+	if db.stopped() {
+		panic("SetLabels: database already stopped")
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	normalizedName := strings.ToLower(name)
+	d, ok := db.distros[normalizedName]
+	if !ok {
+		return errors.New("distro not in database")
+	}
+
+	if !d.SetLabels(labels) {
+		return nil
+	}
+
+	db.indexes.add(normalizedName, d)
+	return db.dump()
+}
+
+// Dump stores the current database state to the storage, overriding old dumps.
 // Next time we start the agent, the database will be loaded from this dump.
 func (db *DistroDB) Dump() error {
 	if db.stopped() {
@@ -202,6 +401,29 @@ func (db *DistroDB) Dump() error {
 	return db.dump()
 }
 
+// requestDump schedules a dump of the database without blocking. It is used when
+// information changes outside of the database's control, such as the distros' records.
+func (db *DistroDB) requestDump() {
+	select {
+	case db.dumpTrigger <- struct{}{}:
+	default:
+		// A dump is already pending.
+	}
+}
+
+// dumpIfRunning stores the database to the storage, unless it has been closed: Close takes
+// care of the last dump.
+func (db *DistroDB) dumpIfRunning() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.stopped() {
+		return nil
+	}
+
+	return db.dump()
+}
+
 // TriggerCleanup forces the database cleanup loop to skip its current delay and
 // call autoCleanup immediately. It is blocking until the cleanup starts.
 func (db *DistroDB) TriggerCleanup() {
@@ -213,6 +435,7 @@ func (db *DistroDB) TriggerCleanup() {
 }
 
 // cleanup removes any distro that no longer exists or has been reset from the database.
+// Distros that were renamed are moved to their new name instead.
 func (db *DistroDB) cleanup(ctx context.Context) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
@@ -223,6 +446,13 @@ func (db *DistroDB) cleanup(ctx context.Context) error {
 			continue
 		}
 
+		if newName, ok := db.renamedTo(ctx, d); ok {
+			if _, err := db.rename(ctx, name, d, newName); err != nil {
+				log.Warningf(ctx, "Database: %v", err)
+			}
+			continue
+		}
+
 		log.Infof(ctx, "Database: distro %q became invalid, cleaning up.", d.Name())
 		for _, f := range db.onCleanup {
 			if f != nil {
@@ -231,6 +461,7 @@ func (db *DistroDB) cleanup(ctx context.Context) error {
 		}
 		go d.Cleanup(ctx)
 		delete(db.distros, name)
+		db.indexes.remove(name)
 		needsDBDump = true
 	}
 	if needsDBDump {
@@ -239,80 +470,169 @@ func (db *DistroDB) cleanup(ctx context.Context) error {
 	return nil
 }
 
-// load reads the database from disk.
-func (db *DistroDB) load(ctx context.Context) (err error) {
-	defer decorate.OnError(&err, "failed to load database from disk")
-
-	// Read raw database from disk
-	out, err := os.ReadFile(filepath.Join(db.storageDir, consts.DatabaseFileName))
-	if errors.Is(err, fs.ErrNotExist) {
-		db.distros = make(map[string]*distro.Distro)
-		return nil
-	}
+// renamedTo returns the name under which the GUID of an invalid distro is registered, if
+// it is registered under a name not in use in the database. GoWSL reports the names of
+// registered distros in lower case, so that is the case the new name will be in.
+//
+// It must be called with the database lock held.
+func (db *DistroDB) renamedTo(ctx context.Context, d *distro.Distro) (newName string, found bool) {
+	registered, err := wsl.RegisteredDistros(ctx)
 	if err != nil {
-		return err
+		log.Warningf(ctx, "Database: could not look for the new name of distro %q: %v", d.Name(), err)
+		return "", false
 	}
 
-	// Parse database into intermediate objects
-	distros := make([]serializableDistro, 0)
-	err = yaml.Unmarshal(out, &distros)
+	for i := range registered {
+		r := &registered[i]
+		GUID, err := r.GUID()
+		if err != nil || GUID.String() != d.GUID() {
+			continue
+		}
+
+		if _, ok := db.distros[strings.ToLower(r.Name())]; ok {
+			// Either the distro was not renamed, or the new name is already taken.
+			return "", false
+		}
+		return r.Name(), true
+	}
+
+	return "", false
+}
+
+// renamedFrom returns the invalid distro in the database whose GUID is registered under
+// the specified name, along with its key in the database.
+//
+// It must be called with the database lock held.
+func (db *DistroDB) renamedFrom(ctx context.Context, name string) (oldKey string, d *distro.Distro, found bool) {
+	registered := wsl.NewDistro(ctx, name)
+	GUID, err := registered.GUID()
 	if err != nil {
-		return fmt.Errorf("could not unmarshal: %v", err)
+		return "", nil, false
 	}
 
-	// Initializing distros into database
-	db.distros = make(map[string]*distro.Distro, len(distros))
-	for _, inert := range distros {
-		d, err := inert.newDistro(ctx, db.storageDir, &db.distroStartMu)
-		if err != nil {
-			log.Warningf(ctx, "Database: read invalid distro from database: %#+v", inert)
+	for key, d := range db.distros {
+		if d.GUID() != GUID.String() {
 			continue
 		}
-		db.distros[strings.ToLower(d.Name())] = d
+		if d.IsValid() {
+			return "", nil, false
+		}
+		return key, d, true
 	}
 
-	return nil
+	return "", nil, false
 }
 
-// dump writes the database contents into the file inside db.storageDir.
-// The dump is deterministic, with distros always sorted alphabetically.
-func (db *DistroDB) dump() (err error) {
-	defer decorate.OnError(&err, "failed to dump database to disk")
+// rename moves a distro to its new name, keeping its GUID, properties, records, labels and
+// stored tasks. The distro object is replaced, as names are immutable.
+//
+// It must be called with the database lock held.
+func (db *DistroDB) rename(ctx context.Context, oldKey string, old *distro.Distro, newName string) (d *distro.Distro, err error) {
+	oldName := old.Name()
+	defer decorate.OnError(&err, "could not move distro %q to its new name %q", oldName, newName)
 
-	// Sort distros case-independently.
-	normalizedNames := make([]string, 0, len(db.distros))
-	for n := range db.distros {
-		normalizedNames = append(normalizedNames, n)
-	}
-	sort.Strings(normalizedNames)
+	log.Infof(ctx, "Database: distro %q was renamed to %q, moving it.", oldName, newName)
 
-	// Create intermediate easy-to-marshall objects
-	distros := make([]serializableDistro, 0, len(db.distros))
-	for _, n := range normalizedNames {
-		distros = append(distros, newSerializableDistro(db.distros[n]))
+	inert := newSerializableDistro(old)
+	inert.Name = newName
+
+	// The worker must be stopped before moving its tasks, otherwise it could store them under the old name again.
+	old.Cleanup(ctx)
+	delete(db.distros, oldKey)
+	db.indexes.remove(oldKey)
+
+	if err := worker.RenameStoredTasks(db.store, oldName, newName); err != nil {
+		log.Warningf(ctx, "Database: %v", err)
 	}
 
-	// Generate dump
-	out, err := yaml.Marshal(distros)
+	d, err = inert.newDistro(db.ctx, db.storageDir, db.startScheduler, db.distroOptions()...)
 	if err != nil {
-		return fmt.Errorf("could not marshal: %v", err)
+		return nil, errors.Join(err, db.dump())
 	}
 
-	// Write dump
-	storagePath := filepath.Join(db.storageDir, consts.DatabaseFileName)
-	err = os.WriteFile(storagePath+".new", out, 0600)
+	newKey := strings.ToLower(newName)
+	db.distros[newKey] = d
+	db.indexes.add(newKey, d)
+
+	db.onRenameMu.RLock()
+	onRename := db.onRename
+	db.onRenameMu.RUnlock()
+
+	if onRename != nil {
+		onRename(oldName, newName)
+	}
+
+	return d, db.dump()
+}
+
+// load reads the database from the storage.
+func (db *DistroDB) load(ctx context.Context) (err error) {
+	defer decorate.OnError(&err, "failed to load database from storage")
+
+	db.distros = make(map[string]*distro.Distro)
+	db.indexes = newIndexes()
+
+	// Import the database file written by older versions of the agent.
+	var migrated bool
+	legacyPath := filepath.Join(db.storageDir, consts.DatabaseFileName)
+	err = storage.ImportFile(ctx, db.store, legacyPath, func(tx storage.Tx, contents []byte) error {
+		distros, m, err := unmarshalDatabase(contents)
+		if err != nil {
+			return err
+		}
+		migrated = m
+		return writeDatabase(tx, distros)
+	})
 	if err != nil {
 		return err
 	}
 
-	err = os.Rename(storagePath+".new", storagePath)
+	var distros []serializableDistro
+	err = db.store.View(func(tx storage.Tx) error {
+		distros, err = readDatabase(tx)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
+	// Initializing distros into database
+	for _, inert := range distros {
+		d, err := inert.newDistro(ctx, db.storageDir, db.startScheduler, db.distroOptions()...)
+		if err != nil {
+			log.Warningf(ctx, "Database: read invalid distro from database: %#+v", inert)
+			continue
+		}
+		normalizedName := strings.ToLower(d.Name())
+		db.distros[normalizedName] = d
+		db.indexes.add(normalizedName, d)
+	}
+
+	if migrated {
+		log.Infof(ctx, "Database: migrating database to schema version %d", schemaVersion)
+		return db.dump()
+	}
+
 	return nil
 }
 
+// dump writes the database contents into the storage.
+func (db *DistroDB) dump() (err error) {
+	defer decorate.OnError(&err, "failed to dump database to storage")
+
+	return db.store.Update(db.write)
+}
+
+// write writes the database contents in the transaction.
+func (db *DistroDB) write(tx storage.Tx) error {
+	distros := make([]serializableDistro, 0, len(db.distros))
+	for _, d := range db.distros {
+		distros = append(distros, newSerializableDistro(d))
+	}
+
+	return writeDatabase(tx, distros)
+}
+
 func (db *DistroDB) stopped() bool {
 	select {
 	case <-db.ctx.Done():
@@ -323,7 +643,7 @@ func (db *DistroDB) stopped() bool {
 }
 
 // Close frees up resources allocated to database maintenance and
-// ensures the database contents are written to file.
+// ensures the database contents are written to the storage.
 func (db *DistroDB) Close(ctx context.Context) {
 	db.once.Do(func() {
 		db.cancelCtx()
@@ -338,6 +658,10 @@ func (db *DistroDB) Close(ctx context.Context) {
 
 		close(db.scheduleTrigger)
 		db.cleanupAllDistros(ctx)
+
+		if err := db.store.Close(); err != nil {
+			log.Warningf(ctx, "Database: error while closing: %v", err)
+		}
 	})
 }
 
@@ -360,4 +684,5 @@ func (db *DistroDB) cleanupAllDistros(ctx context.Context) {
 
 	// Leave behind an empty map to avoid operating on stopped distros
 	db.distros = map[string]*distro.Distro{}
+	db.indexes = newIndexes()
 }
@@ -4,10 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"text/template"
@@ -18,6 +18,11 @@ import (
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage/storagetestutils"
 	"github.com/stretchr/testify/require"
 	wsl "github.com/ubuntu/gowsl"
 	wslmock "github.com/ubuntu/gowsl/mock"
@@ -25,13 +30,21 @@ import (
 	"golang.org/x/exp/slices"
 )
 
+func init() {
+	task.Register[testTask]()
+}
+
 type dbDirState int
 
 const (
 	emptyDbDir dbDirState = iota
 	goodDbFile
+	storedDb
+	legacyDbFile
+	futureDbFile
 	badDbFile
 	badDbFileContents
+	badStorage
 )
 
 //nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
@@ -50,11 +63,15 @@ func TestNew(t *testing.T) {
 		wantDistros []string
 		wantErr     bool
 	}{
-		"Success on no pre-exisiting database file": {dirState: emptyDbDir, wantDistros: []string{}},
-		"Success at loading distro from database":   {dirState: goodDbFile, wantDistros: []string{distro}},
+		"Success on no pre-exisiting database file":      {dirState: emptyDbDir, wantDistros: []string{}},
+		"Success at loading distro from database":        {dirState: goodDbFile, wantDistros: []string{distro}},
+		"Success at loading distro from storage":         {dirState: storedDb, wantDistros: []string{distro}},
+		"Success migrating a database without a version": {dirState: legacyDbFile, wantDistros: []string{distro}},
 
 		"Error with syntax error in database file":             {dirState: badDbFileContents, wantErr: true},
+		"Error with a database from a newer version":           {dirState: futureDbFile, wantErr: true},
 		"Error due to database file exists but cannot be read": {dirState: badDbFile, wantErr: true},
+		"Error when the storage cannot be opened":              {dirState: badStorage, wantErr: true},
 	}
 
 	for name, tc := range testCases {
@@ -71,6 +88,24 @@ func TestNew(t *testing.T) {
 				require.NoError(t, err, "Setup: could not write wrong database file")
 			case goodDbFile:
 				databaseFromTemplate(t, dbDir, distroID{distro, guid})
+			case storedDb:
+				databaseFromTemplate(t, dbDir, distroID{distro, guid})
+				db, err := database.New(ctx, dbDir)
+				require.NoError(t, err, "Setup: could not import the database file into the storage")
+				db.Close(ctx)
+			case badStorage:
+				err := os.MkdirAll(filepath.Join(dbDir, consts.StorageFileName), 0600)
+				require.NoError(t, err, "Setup: could not create folder where the storage is supposed to go")
+			case legacyDbFile:
+				// Before versioning, the database was a bare list of distros.
+				legacy := fmt.Sprintf("- name: %s\n  guid: '%s'\n  properties:\n    distroid: Ubuntu\n", distro, guid)
+				err := os.WriteFile(filepath.Join(dbDir, consts.DatabaseFileName), []byte(legacy), 0600)
+				require.NoError(t, err, "Setup: could not write legacy database file")
+			case futureDbFile:
+				out, err := yaml.Marshal(database.SerializableDatabase{Version: database.SchemaVersion + 1})
+				require.NoError(t, err, "Setup: could not marshal future database")
+				err = os.WriteFile(filepath.Join(dbDir, consts.DatabaseFileName), out, 0600)
+				require.NoError(t, err, "Setup: could not write future database file")
 			}
 
 			db, err := database.New(ctx, dbDir)
@@ -86,6 +121,19 @@ func TestNew(t *testing.T) {
 
 			distros := db.DistroNames()
 			require.ElementsMatch(t, tc.wantDistros, distros, "database should contain all the registered distros read from file")
+			require.NoFileExists(t, filepath.Join(dbDir, consts.DatabaseFileName), "Database file should have been removed after its import")
+
+			if tc.dirState != legacyDbFile {
+				return
+			}
+
+			// The database must have been migrated to the current schema version.
+			dump := readDump(t, dbDir)
+
+			sd := newStructuredDump(t, dump)
+			require.Len(t, sd.data, 1, "The migrated database should contain the distro")
+			require.Equal(t, guid, sd.data[0].GUID, "The migrated database should keep the GUID of the distro")
+			require.False(t, sd.data[0].Records.FirstSeen.IsZero(), "The migrated database should have filled in the records")
 		})
 	}
 }
@@ -148,13 +196,15 @@ func TestDatabaseGetUnmanaged(t *testing.T) {
 		ctx = database.WithUNCRootPath(wsl.WithMock(ctx, wslmock.New()), uncRoot)
 	}
 
-	// Registers some Ubuntu instances
+	// Registers some running Ubuntu instances
 	var distros []string
 	for range 3 {
 		distros = append(distros, func() string {
 			d, _ := wsltestutils.RegisterDistro(t, ctx, false)
 			d = strings.ToLower(d)
 			testutils.WriteOsRelease(t, uncRoot, d, "ubuntu-os-release")
+			writeHostname(t, uncRoot, d, d+"-host")
+			keepDistroRunning(t, ctx, d)
 			return d
 		}())
 	}
@@ -163,6 +213,14 @@ func TestDatabaseGetUnmanaged(t *testing.T) {
 	nonUbuntu, _ := wsltestutils.RegisterDistro(t, ctx, false)
 	nonUbuntu = strings.ToLower(nonUbuntu)
 	testutils.WriteOsRelease(t, uncRoot, nonUbuntu, "other-os-release")
+	writeHostname(t, uncRoot, nonUbuntu, "other-host")
+	keepDistroRunning(t, ctx, nonUbuntu)
+
+	// Register one Ubuntu instance that is stopped, hence it cannot be inspected.
+	stopped, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	stopped = strings.ToLower(stopped)
+	testutils.WriteOsRelease(t, uncRoot, stopped, "ubuntu-os-release")
+	writeHostname(t, uncRoot, stopped, "stopped-host")
 
 	testCases := map[string]struct {
 		dbDistros []string
@@ -193,15 +251,145 @@ func TestDatabaseGetUnmanaged(t *testing.T) {
 			var gotUnmanaged []string
 			for _, d := range db.GetUnmanagedDistros() {
 				gotUnmanaged = append(gotUnmanaged, d.Name)
+				require.Equal(t, d.Name+"-host", d.Hostname, "GetUnmanagedDistros should read the hostname of the distro")
+				require.Equal(t, wsl.Running, d.State, "GetUnmanagedDistros should report the state of the distro")
 			}
 
 			require.ElementsMatch(t, tc.want, gotUnmanaged, "GetUnmanagedDistros returned unexpected set of distros")
 			require.NotElementsMatch(t, tc.dbDistros, gotUnmanaged, "GetUnmanagedDistros should not return a distro in the database")
 			require.NotContains(t, gotUnmanaged, nonUbuntu, "GetUnmanagedDistros should not return a non-Ubuntu distro")
+			require.NotContains(t, gotUnmanaged, stopped, "GetUnmanagedDistros should not return a distro that was never seen running")
+			require.Equal(t, "Stopped", wsltestutils.DistroState(t, ctx, stopped), "GetUnmanagedDistros should not wake up stopped distros")
+		})
+	}
+}
+
+func TestUnmanagedDistrosCache(t *testing.T) {
+	if !wsl.MockAvailable() {
+		t.Skip("This test can only run with the mock")
+	}
+	t.Parallel()
+
+	testCases := map[string]struct {
+		notUbuntu        bool
+		stopDistro       bool
+		renameDistro     bool
+		unregisterDistro bool
+		registerDistro   bool
+
+		wantChanged bool
+		wantState   wsl.State
+		wantNone    bool
+	}{
+		"Success keeping a running distro":                        {wantState: wsl.Running},
+		"Success keeping a distro stopped after being inspected":  {stopDistro: true, wantState: wsl.Stopped},
+		"Success following a renamed distro by its GUID":          {renameDistro: true, wantChanged: true, wantState: wsl.Running},
+		"Success dropping an unregistered distro":                 {unregisterDistro: true, wantChanged: true, wantNone: true},
+		"Success detecting a newly registered distro":             {registerDistro: true, wantChanged: true, wantState: wsl.Running},
+		"Success not listing a stopped distro that is not Ubuntu": {notUbuntu: true, stopDistro: true, wantNone: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := wslmock.New()
+			uncRoot := t.TempDir()
+			ctx := database.WithUNCRootPath(wsl.WithMock(context.Background(), m), uncRoot)
+
+			// GoWSL reports the names of registered distros in lower case.
+			distroName, guid := wsltestutils.RegisterDistro(t, ctx, false)
+			osRelease, wantCached := "ubuntu-os-release", 1
+			if tc.notUbuntu {
+				osRelease, wantCached = "other-os-release", 0
+			}
+			testutils.WriteOsRelease(t, uncRoot, strings.ToLower(distroName), osRelease)
+			writeHostname(t, uncRoot, strings.ToLower(distroName), "cool-host")
+			stop := keepDistroRunning(t, ctx, distroName)
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: database creation should not fail")
+			defer db.Close(ctx)
+
+			got := db.GetUnmanagedDistros()
+			require.Len(t, got, wantCached, "Setup: only the running Ubuntu distro should have been cached")
+			require.False(t, db.UnmanagedRegistrationsChanged(), "No registration changes should be detected before any change")
+
+			wantName := distroName
+			switch {
+			case tc.stopDistro:
+				stop()
+				d := wsl.NewDistro(ctx, distroName)
+				require.NoError(t, d.Terminate(), "Setup: could not stop the distro")
+			case tc.renameDistro:
+				wantName = wsltestutils.RandomDistroName(t)
+				renameMockDistro(t, m, guid, wantName)
+				testutils.WriteOsRelease(t, uncRoot, strings.ToLower(wantName), "ubuntu-os-release")
+				writeHostname(t, uncRoot, strings.ToLower(wantName), "cool-host")
+			case tc.unregisterDistro:
+				stop()
+				wsltestutils.UnregisterDistro(t, ctx, distroName)
+			case tc.registerDistro:
+				wantName = wsltestutils.RandomDistroName(t)
+				wsltestutils.RegisterDistroNamed(t, ctx, wantName)
+				testutils.WriteOsRelease(t, uncRoot, strings.ToLower(wantName), "ubuntu-os-release")
+				writeHostname(t, uncRoot, strings.ToLower(wantName), "cool-host")
+				keepDistroRunning(t, ctx, wantName)
+			}
+
+			require.Equal(t, tc.wantChanged, db.UnmanagedRegistrationsChanged(), "Mismatch in whether registration changes were detected")
+
+			db.RefreshUnmanagedDistros()
+			got = db.GetUnmanagedDistros()
+
+			if tc.wantNone {
+				require.Empty(t, got, "No unmanaged distro should have been cached")
+				return
+			}
+
+			i := slices.IndexFunc(got, func(info database.BasicDistroInfo) bool { return strings.EqualFold(info.Name, wantName) })
+			require.NotEqual(t, -1, i, "Distro %q should have been cached", wantName)
+			require.Equal(t, "cool-host", got[i].Hostname, "Hostname should have been cached")
+			require.Equal(t, tc.wantState, got[i].State, "Mismatch in the cached state")
+
+			if tc.stopDistro {
+				require.Equal(t, "Stopped", wsltestutils.DistroState(t, ctx, distroName), "Refreshing the cache should not wake up stopped distros")
+			}
 		})
 	}
 }
 
+// writeHostname writes the /etc/hostname file of the distro under the UNC root.
+func writeHostname(t *testing.T, uncRoot, distroName, hostname string) {
+	t.Helper()
+
+	dir := filepath.Join(uncRoot, distroName, "etc")
+	require.NoError(t, os.MkdirAll(dir, 0750), "Setup: could not create the etc directory of the distro")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hostname"), []byte(hostname+"\n"), 0600), "Setup: could not write the hostname file")
+}
+
+// keepDistroRunning starts a long-lived process in the mocked distro so that it is running until
+// the returned function is called or the test ends.
+func keepDistroRunning(t *testing.T, ctx context.Context, distroName string) (stop func()) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(ctx)
+	d := wsl.NewDistro(ctx, distroName)
+	cmd := d.Command(ctx, "sleep infinity")
+	require.NoError(t, cmd.Start(), "Setup: could not start a process in the distro")
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			cancel()
+			_ = cmd.Wait()
+		})
+	}
+	t.Cleanup(stop)
+
+	return stop
+}
+
 //nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
 func TestDatabaseGet(t *testing.T) {
 	ctx := context.Background()
@@ -259,6 +447,191 @@ func TestDatabaseGet(t *testing.T) {
 	}
 }
 
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	// The database template contains, in this order:
+	// - ubuntu 20.04, attached, labels prod and eu
+	// - Ubuntu 22.04, not attached, label dev, connected
+	// - ubuntu 24.04, attached, label prod
+	// - debian 12, not attached, no labels
+	// - ubuntu 22.10, attached, label eu, unregistered after loading the database
+	var ids []distroID
+	for range 5 {
+		name, guid := wsltestutils.RegisterDistro(t, ctx, false)
+		ids = append(ids, distroID{name, guid})
+	}
+
+	databaseDir := t.TempDir()
+	databaseFromTemplate(t, databaseDir, ids...)
+
+	db, err := database.New(ctx, databaseDir)
+	require.NoError(t, err, "Setup: New() should return no error")
+
+	// Must use Cleanup. If we use defer, it'll run before the subtests are launched.
+	t.Cleanup(func() { db.Close(ctx) })
+
+	d, ok := db.Get(ids[1].Name)
+	require.True(t, ok, "Setup: distro should be in the database")
+	require.NoError(t, d.SetConnection(&mockConnection{}), "Setup: could not set connection")
+
+	wsltestutils.UnregisterDistro(t, ctx, ids[4].Name)
+
+	yes, no := true, false
+
+	testCases := map[string]struct {
+		query database.Query
+
+		want []int
+	}{
+		"Empty query selects all distros": {want: []int{0, 1, 2, 3, 4}},
+
+		"Filter by distro ID case-insensitively":   {query: database.Query{DistroID: "UBUNTU"}, want: []int{0, 1, 2, 4}},
+		"Filter by minimum version":                {query: database.Query{MinVersionID: "22.04"}, want: []int{1, 2, 4}},
+		"Filter by maximum version":                {query: database.Query{MaxVersionID: "22.04"}, want: []int{0, 1, 3}},
+		"Filter by version range":                  {query: database.Query{MinVersionID: "22.04", MaxVersionID: "22.10"}, want: []int{1, 4}},
+		"Filter by attached":                       {query: database.Query{ProAttached: &yes}, want: []int{0, 2, 4}},
+		"Filter by not attached":                   {query: database.Query{ProAttached: &no}, want: []int{1, 3}},
+		"Filter by connected":                      {query: database.Query{Connected: &yes}, want: []int{1}},
+		"Filter by not connected":                  {query: database.Query{Connected: &no}, want: []int{0, 2, 3, 4}},
+		"Filter by valid":                          {query: database.Query{Valid: &yes}, want: []int{0, 1, 2, 3}},
+		"Filter by not valid":                      {query: database.Query{Valid: &no}, want: []int{4}},
+		"Filter by label":                          {query: database.Query{Labels: []string{"prod"}}, want: []int{0, 2}},
+		"Filter by several labels":                 {query: database.Query{Labels: []string{"prod", "EU"}}, want: []int{0}},
+		"Filter by several fields":                 {query: database.Query{DistroID: "ubuntu", ProAttached: &yes, Valid: &yes, Labels: []string{"eu"}}, want: []int{0}},
+		"No distro matches an unknown distro ID":   {query: database.Query{DistroID: "fedora"}},
+		"No distro matches an unknown label":       {query: database.Query{Labels: []string{"prod", "staging"}}},
+		"No distro matches an empty version range": {query: database.Query{MinVersionID: "23.04", MaxVersionID: "23.10"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			want := make([]string, 0, len(tc.want))
+			for _, i := range tc.want {
+				want = append(want, ids[i].Name)
+			}
+			slices.SortFunc(want, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+
+			got := make([]string, 0, len(want))
+			for _, d := range db.Query(tc.query) {
+				got = append(got, d.Name())
+			}
+
+			require.Equal(t, want, got, "Query returned an unexpected set of distros, or in an unexpected order")
+		})
+	}
+}
+
+func TestQueryIndexesAreUpdated(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	db, err := database.New(ctx, t.TempDir())
+	require.NoError(t, err, "Setup: New() should return no error")
+	defer db.Close(ctx)
+
+	query := func(q database.Query) bool {
+		t.Helper()
+		got := db.Query(q)
+		require.LessOrEqual(t, len(got), 1, "Query should return at most one distro")
+		return len(got) == 1
+	}
+	yes := true
+
+	require.False(t, query(database.Query{}), "Query should not return distros from an empty database")
+
+	d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{DistroID: "ubuntu"})
+	require.NoError(t, err, "GetDistroAndUpdateProperties should return no error")
+	require.True(t, query(database.Query{DistroID: "ubuntu"}), "Query should find a distro added to the database")
+
+	_, err = db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{DistroID: "debian"})
+	require.NoError(t, err, "GetDistroAndUpdateProperties should return no error")
+	require.False(t, query(database.Query{DistroID: "ubuntu"}), "Query should not find a distro by its former properties")
+	require.True(t, query(database.Query{DistroID: "debian"}), "Query should find a distro by its updated properties")
+
+	err = db.UpdateProperties(d, distro.Properties{DistroID: "debian", ProAttached: true})
+	require.NoError(t, err, "UpdateProperties should return no error")
+	require.True(t, query(database.Query{ProAttached: &yes}), "Query should find a distro by the properties set with UpdateProperties")
+
+	err = db.SetLabels(distroName, []string{"prod"})
+	require.NoError(t, err, "SetLabels should return no error")
+	require.True(t, query(database.Query{Labels: []string{"prod"}}), "Query should find a distro by its labels")
+
+	err = db.SetLabels(distroName, []string{"dev"})
+	require.NoError(t, err, "SetLabels should return no error")
+	require.False(t, query(database.Query{Labels: []string{"prod"}}), "Query should not find a distro by its former labels")
+
+	err = db.UpdateProperties(d, distro.Properties{DistroID: "debian", ProAttached: true, Labels: []string{"ci"}})
+	require.NoError(t, err, "UpdateProperties should return no error")
+	require.True(t, query(database.Query{Labels: []string{"ci", "dev"}}), "Query should find a distro by both its assigned and declared labels")
+
+	err = db.SetLabels(wsltestutils.RandomDistroName(t), []string{"dev"})
+	require.Error(t, err, "SetLabels should return an error for a distro not in the database")
+
+	wsltestutils.UnregisterDistro(t, ctx, distroName)
+	db.TriggerCleanup()
+	require.Eventually(t, func() bool {
+		return !query(database.Query{Labels: []string{"dev"}})
+	}, 5*time.Second, 100*time.Millisecond, "Query should not find a distro removed from the database")
+
+	// Testing use after close
+	db.Close(ctx)
+	require.Panics(t, func() { db.Query(database.Query{}) }, "Database Query should panic when used after Close.")
+}
+
+func TestSubmitTasksTo(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	selected, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	declared, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	other, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	dbDir := t.TempDir()
+	db, err := database.New(ctx, dbDir)
+	require.NoError(t, err, "Setup: New() should return no error")
+	defer db.Close(ctx)
+
+	_, err = db.GetDistroAndUpdateProperties(ctx, selected, distro.Properties{})
+	require.NoError(t, err, "Setup: GetDistroAndUpdateProperties should return no error")
+	_, err = db.GetDistroAndUpdateProperties(ctx, declared, distro.Properties{Labels: []string{"CI"}})
+	require.NoError(t, err, "Setup: GetDistroAndUpdateProperties should return no error")
+	_, err = db.GetDistroAndUpdateProperties(ctx, other, distro.Properties{})
+	require.NoError(t, err, "Setup: GetDistroAndUpdateProperties should return no error")
+
+	require.NoError(t, db.SetLabels(selected, []string{"ci"}), "Setup: SetLabels should return no error")
+	require.NoError(t, db.SetLabels(other, []string{"dev"}), "Setup: SetLabels should return no error")
+
+	n, err := db.SubmitTasksTo(database.Query{Labels: []string{"ci"}}, testTask{ID: "selected"})
+	require.NoError(t, err, "SubmitTasksTo should return no error")
+	require.Equal(t, 2, n, "SubmitTasksTo should select the distros with the label, either assigned or declared")
+
+	s, err := storage.Open(ctx, dbDir)
+	require.NoError(t, err, "Could not open the storage")
+	defer s.Close()
+
+	tasks, err := worker.StoredTasks(s)
+	require.NoError(t, err, "Could not read the stored tasks")
+	require.Contains(t, string(tasks[selected]), "selected", "The task should have been submitted to the distro with the assigned label")
+	require.Contains(t, string(tasks[declared]), "selected", "The task should have been submitted to the distro with the declared label")
+	require.NotContains(t, string(tasks[other]), "selected", "The task should not have been submitted to the distro without the label")
+}
+
 func TestDatabaseGetAfterClose(t *testing.T) {
 	ctx := context.Background()
 	if wsl.MockAvailable() {
@@ -301,7 +674,7 @@ func TestDatabaseDump(t *testing.T) {
 		"Success with an empty DB":              {dirState: goodDbFile, emptyDB: true},
 		"Success writing on an empty directory": {dirState: emptyDbDir},
 
-		"Error when it cannot write the dump to file": {dirState: badDbFile, wantErr: true},
+		"Error when it cannot write the dump to the storage": {dirState: badStorage, wantErr: true},
 	}
 
 	for name, tc := range testCases {
@@ -313,32 +686,31 @@ func TestDatabaseDump(t *testing.T) {
 				databaseFromTemplate(t, dbDir, distroID{distro1, guid1}, distroID{distro2, guid2})
 			}
 
+			ctx, breaker := storagetestutils.WithBreakableBackend(ctx)
+
 			db, err := database.New(ctx, dbDir)
 			require.NoError(t, err, "Setup: empty database should be created without issue")
 			defer db.Close(ctx)
 
-			dbFile := filepath.Join(dbDir, consts.DatabaseFileName)
 			switch tc.dirState {
-			case badDbFile:
-				testutils.ReplaceFileWithDir(t, dbFile, "Setup: could not create directory to interfere with database dump")
+			case badStorage:
+				breaker.Break()
 			case goodDbFile:
 				// generateDatabaseFile already generated it
 			case emptyDbDir:
-				err := os.RemoveAll(dbFile)
-				require.NoError(t, err, "Setup: could not remove pre-existing database dump")
+				require.NoError(t, database.WipeDump(ctx, dbDir), "Setup: could not remove pre-existing database dump")
 			default:
 				require.FailNow(t, "Setup: test case not implemented")
 			}
 
 			err = db.Dump()
 			if tc.wantErr {
-				require.Error(t, err, "Dump() should return an error when the database file (or its directory) is not valid")
+				require.Error(t, err, "Dump() should return an error when the storage cannot be written")
 				return
 			}
-			require.NoError(t, err, "Dump() should return no error when the database file and its directory are both valid")
+			require.NoError(t, err, "Dump() should return no error when the storage can be written")
 
-			dump, err := os.ReadFile(filepath.Join(dbDir, consts.DatabaseFileName))
-			require.NoError(t, err, "The database dump should be readable after calling Dump()")
+			dump := readDump(t, dbDir)
 
 			t.Logf("Generated dump:\n%s", dump)
 
@@ -460,6 +832,8 @@ func TestGetDistroAndUpdateProperties(t *testing.T) {
 				distroID{distroInDB, guids[distroInDB]},
 				distroID{reRegisteredDistro, guids[reRegisteredDistro]})
 
+			ctx, breaker := storagetestutils.WithBreakableBackend(ctx)
+
 			db, err := database.New(ctx, dbDir)
 			require.NoError(t, err, "Setup: New() should return no error")
 			defer db.Close(ctx)
@@ -468,12 +842,10 @@ func TestGetDistroAndUpdateProperties(t *testing.T) {
 				guids[reRegisteredDistro] = wsltestutils.ReregisterDistro(t, ctx, reRegisteredDistro, false)
 			}
 
-			dbFile := filepath.Join(dbDir, consts.DatabaseFileName)
 			if tc.breakDBbDump {
-				testutils.ReplaceFileWithDir(t, dbFile, "Setup: could not create directory to interfere with database dump")
+				breaker.Break()
 			}
-			initialDumpModTime := fileModTime(t, dbFile)
-			time.Sleep(100 * time.Millisecond) // Prevents modtime precision issues
+			initialDump := readDump(t, dbDir)
 
 			d, err := db.GetDistroAndUpdateProperties(ctx, tc.distroName, tc.props)
 			if tc.wantErr {
@@ -504,12 +876,12 @@ func TestGetDistroAndUpdateProperties(t *testing.T) {
 				require.Equal(t, props[distroInDB], d.Properties(), "GetDistroAndUpdateProperties should not modify other distros' properties")
 			}
 
-			lastDumpModTime := fileModTime(t, dbFile)
+			lastDump := readDump(t, dbDir)
 			if tc.wantDbDumpRefreshed {
-				require.True(t, lastDumpModTime.After(initialDumpModTime), "GetDistroAndUpdateProperties should modify the database dump file after writing on the database")
+				require.NotEqual(t, string(initialDump), string(lastDump), "GetDistroAndUpdateProperties should modify the stored database after writing on the database")
 				return
 			}
-			require.Equal(t, initialDumpModTime, lastDumpModTime, "GetDistroAndUpdateProperties should not modify database dump file")
+			require.Equal(t, string(initialDump), string(lastDump), "GetDistroAndUpdateProperties should not modify the stored database")
 
 			// Testing use after close
 			db.Close(ctx)
@@ -518,6 +890,85 @@ func TestGetDistroAndUpdateProperties(t *testing.T) {
 	}
 }
 
+func TestNewDistroTasks(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	testCases := map[string]struct {
+		noHook       bool
+		noTasks      bool
+		hookErr      bool
+		breakStorage bool
+
+		wantStored bool
+		wantTasks  bool
+		wantErr    bool
+	}{
+		"Success storing a new distro along with its tasks":              {wantStored: true, wantTasks: true},
+		"Success storing a new distro without a new distro function":     {noHook: true, wantStored: true},
+		"Success storing a new distro without tasks":                     {noTasks: true, wantStored: true},
+		"Success storing a new distro when its tasks cannot be computed": {hookErr: true, wantStored: true},
+
+		"Error when the storage cannot be written": {breakStorage: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if wsl.MockAvailable() {
+				t.Parallel()
+			}
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			dbDir := t.TempDir()
+
+			ctx, breaker := storagetestutils.WithBreakableBackend(ctx)
+
+			db, err := database.New(ctx, dbDir)
+			require.NoError(t, err, "Setup: New() should return no error")
+			defer db.Close(ctx)
+
+			if !tc.noHook {
+				db.SetNewDistroTasks(func(d *distro.Distro) ([]task.Task, error) {
+					require.Equal(t, distroName, d.Name(), "The new distro function should receive the new distro")
+					if tc.hookErr {
+						return nil, errors.New("mock error")
+					}
+					if tc.noTasks {
+						return nil, nil
+					}
+					return []task.Task{testTask{ID: "initial"}}, nil
+				})
+			}
+
+			if tc.breakStorage {
+				breaker.Break()
+			}
+
+			_, err = db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{DistroID: "ubuntu"})
+			if tc.wantErr {
+				require.Error(t, err, "GetDistroAndUpdateProperties should return an error")
+			} else {
+				require.NoError(t, err, "GetDistroAndUpdateProperties should return no error")
+			}
+
+			sd := newStructuredDump(t, readDump(t, dbDir))
+			stored := slices.ContainsFunc(sd.data, func(s database.SerializableDistro) bool { return s.Name == distroName })
+			require.Equal(t, tc.wantStored, stored, "Mismatch in whether the new distro was stored")
+
+			s, err := storage.Open(ctx, dbDir)
+			require.NoError(t, err, "Could not open the storage")
+			defer s.Close()
+
+			tasks, err := worker.StoredTasks(s)
+			require.NoError(t, err, "Could not read the stored tasks")
+			require.Equal(t, tc.wantTasks, tasks[distroName] != nil, "Mismatch in whether the initial tasks were stored")
+		})
+	}
+}
+
 func TestDatabaseCleanup(t *testing.T) {
 	ctx := context.Background()
 	if wsl.MockAvailable() {
@@ -549,7 +1000,6 @@ func TestDatabaseCleanup(t *testing.T) {
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
 			dbDir := t.TempDir()
-			dbFile := filepath.Join(dbDir, consts.DatabaseFileName)
 
 			distros := []distroID{
 				{distro1, guid1},
@@ -572,6 +1022,8 @@ func TestDatabaseCleanup(t *testing.T) {
 				}
 			}
 
+			ctx, breaker := storagetestutils.WithBreakableBackend(ctx)
+
 			db, err := database.New(ctx, dbDir, cleanupFunc)
 			require.NoError(t, err, "Setup: New() should have returned no error")
 			defer db.Close(ctx)
@@ -587,27 +1039,23 @@ func TestDatabaseCleanup(t *testing.T) {
 			}
 
 			if tc.breakDbDump {
-				err := os.RemoveAll(dbFile)
-				require.NoError(t, err, "Setup: when attempting to interfere with a Dump(): could not remove database file")
-				err = os.MkdirAll(dbFile, 0600)
-				require.NoError(t, err, "Setup: when attempting to interfere with a Dump(): could not create directory in database file's location")
+				breaker.Break()
 			}
 
-			initialModTime := fileModTime(t, dbFile)
-			time.Sleep(100 * time.Millisecond) // Prevents modtime precision issues
+			initialDump := readDump(t, dbDir)
 
 			fileUpdated := func() bool {
-				return initialModTime != fileModTime(t, dbFile)
+				return string(initialDump) != string(readDump(t, dbDir))
 			}
 
 			db.TriggerCleanup()
 
 			const delay = 500 * time.Millisecond
 			if tc.wantDumpRefreshed {
-				require.Eventually(t, fileUpdated, delay, 10*time.Millisecond, "Database file should be created after a cleanup when a distro has been unregistered")
+				require.Eventually(t, fileUpdated, delay, 10*time.Millisecond, "Stored database should be updated after a cleanup when a distro has been unregistered")
 			} else {
 				time.Sleep(delay)
-				require.False(t, fileUpdated(), "Database file should not be refreshed by a cleanup when no distro has been cleaned up")
+				require.False(t, fileUpdated(), "Stored database should not be refreshed by a cleanup when no distro has been cleaned up")
 			}
 
 			require.Equal(t, tc.wantCleanup, cleanupCalled.Load(), "Cleanup callback state mismatch")
@@ -621,18 +1069,141 @@ func TestDatabaseCleanup(t *testing.T) {
 	}
 }
 
-// fileModTime returns the ModTime of the provided path. If the path
-// does not exist, the time is reported as Unix 0.
-func fileModTime(t *testing.T, path string) time.Time {
-	t.Helper()
+func TestDatabaseMaxConcurrentStarts(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	db, err := database.New(ctx, t.TempDir())
+	require.NoError(t, err, "Setup: database creation should not fail")
+	defer db.Close(ctx)
 
-	info, err := os.Stat(path)
-	if errors.Is(err, fs.ErrNotExist) {
-		return time.Unix(0, 0)
+	require.Equal(t, startscheduler.DefaultParallelism, db.StartMetrics().Parallelism, "Database should allow the default number of concurrent starts")
+
+	db.SetMaxConcurrentStarts(3)
+	require.Equal(t, 3, db.StartMetrics().Parallelism, "Database should allow the configured number of concurrent starts")
+
+	db.SetMaxConcurrentStarts(0)
+	require.Equal(t, 3, db.StartMetrics().Parallelism, "Database should ignore invalid numbers of concurrent starts")
+}
+
+func TestDatabaseRename(t *testing.T) {
+	if !wsl.MockAvailable() {
+		t.Skip("This test can only run with the mock")
+	}
+	t.Parallel()
+
+	testCases := map[string]struct {
+		onConnect bool
+		noHook    bool
+	}{
+		"Success moving a renamed distro during the cleanup":             {},
+		"Success moving a renamed distro when it connects with its name": {onConnect: true},
+		"Success moving a renamed distro without a rename hook":          {noHook: true},
 	}
 
-	require.NoError(t, err, "Could not Stat file %q", path)
-	return info.ModTime()
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			m := wslmock.New()
+			ctx := wsl.WithMock(context.Background(), m)
+
+			oldName, guid := wsltestutils.RegisterDistro(t, ctx, false)
+			newName := wsltestutils.RandomDistroName(t)
+			dbDir := t.TempDir()
+
+			db, err := database.New(ctx, dbDir)
+			require.NoError(t, err, "Setup: New() should return no error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, oldName, distro.Properties{DistroID: "ubuntu", Hostname: "host"})
+			require.NoError(t, err, "Setup: could not add the distro to the database")
+			require.NoError(t, db.SetLabels(oldName, []string{"ci"}), "Setup: could not set labels")
+			require.NoError(t, d.SubmitDeferredTasks(testTask{ID: "pending"}), "Setup: could not submit a task")
+
+			var renamed atomic.Value
+			if !tc.noHook {
+				db.SetOnRename(func(oldName, newName string) {
+					renamed.Store([2]string{oldName, newName})
+				})
+			}
+
+			// The mock registry is renamed without locking, so the background refresh of unmanaged distros must be over.
+			db.GetUnmanagedDistros()
+			renameMockDistro(t, m, guid, newName)
+
+			if tc.onConnect {
+				d, err = db.GetDistroAndUpdateProperties(ctx, newName, distro.Properties{DistroID: "ubuntu", Hostname: "newhost"})
+				require.NoError(t, err, "GetDistroAndUpdateProperties should return no error")
+				require.Equal(t, newName, d.Name(), "The distro should have been moved to its new name")
+				require.Equal(t, "newhost", d.Properties().Hostname, "The properties of the renamed distro should have been updated")
+			} else {
+				db.TriggerCleanup()
+				require.Eventually(t, func() bool {
+					_, ok := db.Get(newName)
+					return ok
+				}, 5*time.Second, 10*time.Millisecond, "The distro should have been moved to its new name")
+			}
+
+			_, ok := db.Get(oldName)
+			require.False(t, ok, "The distro should no longer be found with its old name")
+
+			d, ok = db.Get(newName)
+			require.True(t, ok, "The distro should be found with its new name")
+			require.True(t, strings.EqualFold(newName, d.Name()), "The name of the distro should have been updated")
+			require.Equal(t, guid, d.GUID(), "The GUID of the renamed distro should not change")
+			require.True(t, d.IsValid(), "The renamed distro should be valid")
+			require.ElementsMatch(t, []string{"ci"}, d.Labels(), "The labels of the renamed distro should be kept")
+			require.Len(t, db.Query(database.Query{Labels: []string{"ci"}}), 1, "Queries should find the renamed distro")
+
+			if tc.noHook {
+				require.Nil(t, renamed.Load(), "No rename hook should have been called")
+			} else {
+				require.Equal(t, [2]string{oldName, d.Name()}, renamed.Load(), "The rename hook should have been called with the old and new names")
+			}
+
+			db.Close(ctx)
+
+			sd := newStructuredDump(t, readDump(t, dbDir))
+			require.Len(t, sd.data, 1, "Only the renamed distro should have been stored")
+			require.Equal(t, d.Name(), sd.data[0].Name, "The distro should have been stored with its new name")
+
+			s, err := storage.Open(ctx, dbDir)
+			require.NoError(t, err, "Could not open the storage")
+			defer s.Close()
+
+			tasks, err := worker.StoredTasks(s)
+			require.NoError(t, err, "Could not read the stored tasks")
+			require.NotContains(t, tasks, oldName, "No tasks should remain stored under the old name")
+			require.Contains(t, tasks, d.Name(), "The tasks should have been moved to the new name")
+		})
+	}
+}
+
+// renameMockDistro changes the name under which the distro with the specified GUID is registered in the mock.
+// The registry key is modified without write lock, so there must be no concurrent readers.
+func renameMockDistro(t *testing.T, m *wslmock.Backend, guid, newName string) {
+	t.Helper()
+
+	k, err := m.OpenLxssRegistry(fmt.Sprintf("{%s}", guid))
+	require.NoError(t, err, "Setup: could not open the registry key of the distro")
+	defer k.Close()
+
+	key, ok := k.(*wslmock.RegistryKey)
+	require.True(t, ok, "Setup: unexpected registry key type %T", k)
+	key.Data["DistributionName"] = newName
+}
+
+// readDump returns the database stored in the directory, in the layout of the database file.
+func readDump(t *testing.T, dbDir string) []byte {
+	t.Helper()
+
+	dump, err := database.ReadDump(context.Background(), dbDir)
+	require.NoError(t, err, "Could not read the stored database")
+	return dump
 }
 
 // distroID is a convenience struct to package a distro's identifying data.
@@ -671,12 +1242,13 @@ type structuredDump struct {
 func newStructuredDump(t *testing.T, rawDump []byte) structuredDump {
 	t.Helper()
 
-	var data []database.SerializableDistro
+	var db database.SerializableDatabase
 
-	err := yaml.Unmarshal(rawDump, &data)
+	err := yaml.Unmarshal(rawDump, &db)
 	require.NoError(t, err, "In an attempt to parse a database dump: Unmarshal failed for dump:\n%s", rawDump)
+	require.Equal(t, database.SchemaVersion, db.Version, "Database dump should have the current schema version")
 
-	return structuredDump{data: data}
+	return structuredDump{data: db.Distros}
 }
 
 // anonymise takes a structured dump and removes all dynamically-generated information,
@@ -687,5 +1259,38 @@ func (sd *structuredDump) anonymise(t *testing.T) {
 	for i := range sd.data {
 		sd.data[i].Name = fmt.Sprintf("%%DISTRONAME%d%%", i)
 		sd.data[i].GUID = fmt.Sprintf("%%GUID%d%%", i)
+
+		// Distros that were not in the database get stamped with the current time.
+		require.False(t, sd.data[i].Records.FirstSeen.IsZero(), "Records in the dump should have a FirstSeen timestamp")
+		if time.Since(sd.data[i].Records.FirstSeen) < time.Hour {
+			sd.data[i].Records.FirstSeen = time.Time{}
+		}
 	}
 }
+
+// testTask is a task that does nothing.
+type testTask struct {
+	ID string
+}
+
+func (testTask) Execute(context.Context, task.Connection) error { return nil }
+
+// mockConnection is a connection to a WSL-Pro-Service that does nothing.
+type mockConnection struct{}
+
+func (*mockConnection) SendProAttachment(context.Context, string) error               { return nil }
+func (*mockConnection) SendLandscapeConfig(context.Context, string) error             { return nil }
+func (*mockConnection) SendProxyConfig(context.Context, string, string, string) error { return nil }
+func (*mockConnection) SendRunCommand(context.Context, task.Command) (task.CommandOutput, error) {
+	return task.CommandOutput{}, nil
+}
+func (*mockConnection) SendProServices(context.Context, []string, []string) ([]task.ServiceResult, error) {
+	return nil, nil
+}
+func (*mockConnection) SendSecurityUpdate(context.Context) (task.SecurityUpdateReport, error) {
+	return task.SecurityUpdateReport{}, nil
+}
+func (*mockConnection) SendPackageInventory(context.Context) ([]task.Package, error) {
+	return nil, nil
+}
+func (*mockConnection) Close() {}
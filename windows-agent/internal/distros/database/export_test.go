@@ -2,16 +2,24 @@ package database
 
 import (
 	"context"
-	"sync"
+	"errors"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"go.yaml.in/yaml/v3"
 )
 
 type SerializableDistro = serializableDistro
 
+type SerializableDatabase = serializableDatabase
+
+// SchemaVersion is the current version of the database file layout.
+const SchemaVersion = schemaVersion
+
 // NewDistro is a wrapper around newDistro so as to make it accessible to tests.
-func (in SerializableDistro) NewDistro(ctx context.Context, storageDir string, startupMu *sync.Mutex) (*distro.Distro, error) {
-	return in.newDistro(ctx, storageDir, startupMu)
+func (in SerializableDistro) NewDistro(ctx context.Context, storageDir string, scheduler *startscheduler.Scheduler) (*distro.Distro, error) {
+	return in.newDistro(ctx, storageDir, scheduler)
 }
 
 // NewSerializableDistro is a wrapper around newSerializableDistro so as to make it accessible to tests.
@@ -29,3 +37,46 @@ func (db *DistroDB) DistroNames() (out []string) {
 	}
 	return out
 }
+
+// ReadDump returns the database stored in the directory, in the layout of the database file.
+func ReadDump(ctx context.Context, storageDir string) ([]byte, error) {
+	s, err := storage.Open(ctx, storageDir)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	var distros []serializableDistro
+	err = s.View(func(tx storage.Tx) error {
+		distros, err = readDatabase(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(serializableDatabase{Version: schemaVersion, Distros: distros})
+}
+
+// WipeDump removes the database stored in the directory.
+func WipeDump(ctx context.Context, storageDir string) error {
+	s, err := storage.Open(ctx, storageDir)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	return s.Update(func(tx storage.Tx) error {
+		return errors.Join(tx.DeleteBucket(distrosBucket), tx.DeleteBucket(databaseBucket))
+	})
+}
+
+// RefreshUnmanagedDistros refreshes the cache of unmanaged distros without waiting for the background refresh.
+func (db *DistroDB) RefreshUnmanagedDistros() {
+	db.refreshUnmanagedDistros()
+}
+
+// UnmanagedRegistrationsChanged returns whether distros were registered, unregistered or renamed since the last refresh.
+func (db *DistroDB) UnmanagedRegistrationsChanged() bool {
+	return db.unmanagedRegistrationsChanged()
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"strings"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+)
+
+// nameSet is a set of normalized distro names.
+type nameSet map[string]struct{}
+
+// indexes are the secondary indexes of the database, mapping the stored fields of the
+// distros to their normalized names. They must be kept up to date, under the database
+// lock, every time a distro is added, removed, or has its properties or labels changed.
+type indexes struct {
+	distroID    map[string]nameSet
+	proAttached map[bool]nameSet
+	label       map[string]nameSet
+
+	// indexed contains the keys each distro was indexed under, so they can be removed.
+	indexed map[string]indexKeys
+}
+
+// indexKeys are the values a distro is indexed by.
+type indexKeys struct {
+	distroID    string
+	proAttached bool
+	labels      []string
+}
+
+func newIndexes() indexes {
+	return indexes{
+		distroID:    make(map[string]nameSet),
+		proAttached: make(map[bool]nameSet),
+		label:       make(map[string]nameSet),
+		indexed:     make(map[string]indexKeys),
+	}
+}
+
+// add indexes the distro under its normalized name, replacing any previous entries.
+func (ix indexes) add(normalizedName string, d *distro.Distro) {
+	ix.remove(normalizedName)
+
+	props := d.Properties()
+	keys := indexKeys{
+		distroID:    strings.ToLower(props.DistroID),
+		proAttached: props.ProAttached,
+		labels:      d.Labels(),
+	}
+
+	insert(ix.distroID, keys.distroID, normalizedName)
+	insert(ix.proAttached, keys.proAttached, normalizedName)
+	for _, l := range keys.labels {
+		insert(ix.label, l, normalizedName)
+	}
+
+	ix.indexed[normalizedName] = keys
+}
+
+// remove removes the distro from all indexes.
+func (ix indexes) remove(normalizedName string) {
+	keys, ok := ix.indexed[normalizedName]
+	if !ok {
+		return
+	}
+
+	erase(ix.distroID, keys.distroID, normalizedName)
+	erase(ix.proAttached, keys.proAttached, normalizedName)
+	for _, l := range keys.labels {
+		erase(ix.label, l, normalizedName)
+	}
+
+	delete(ix.indexed, normalizedName)
+}
+
+// lookup returns the normalized names of the distros that match the indexed fields of
+// the query. If the query filters by none of them, all names are returned.
+func (ix indexes) lookup(q Query) nameSet {
+	var sets []nameSet
+	if q.DistroID != "" {
+		sets = append(sets, ix.distroID[strings.ToLower(q.DistroID)])
+	}
+	if q.ProAttached != nil {
+		sets = append(sets, ix.proAttached[*q.ProAttached])
+	}
+	for _, l := range distro.NormalizeLabels(q.Labels) {
+		sets = append(sets, ix.label[l])
+	}
+
+	if len(sets) == 0 {
+		all := make(nameSet, len(ix.indexed))
+		for n := range ix.indexed {
+			all[n] = struct{}{}
+		}
+		return all
+	}
+
+	// Intersect starting from the smallest set.
+	smallest := 0
+	for i := range sets {
+		if len(sets[i]) < len(sets[smallest]) {
+			smallest = i
+		}
+	}
+
+	out := make(nameSet, len(sets[smallest]))
+outer:
+	for n := range sets[smallest] {
+		for _, s := range sets {
+			if _, ok := s[n]; !ok {
+				continue outer
+			}
+		}
+		out[n] = struct{}{}
+	}
+
+	return out
+}
+
+func insert[K comparable](index map[K]nameSet, key K, normalizedName string) {
+	s, ok := index[key]
+	if !ok {
+		s = make(nameSet)
+		index[key] = s
+	}
+	s[normalizedName] = struct{}{}
+}
+
+func erase[K comparable](index map[K]nameSet, key K, normalizedName string) {
+	s, ok := index[key]
+	if !ok {
+		return
+	}
+
+	delete(s, normalizedName)
+	if len(s) == 0 {
+		delete(index, key)
+	}
+}
@@ -0,0 +1,141 @@
+package database
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+// Query selects distros from the database. Zero-valued fields do not filter, so the
+// zero Query selects all distros. Distros must match every field that is set.
+type Query struct {
+	// DistroID selects distros with this ID (as in /etc/os-release), case-insensitively.
+	DistroID string
+
+	// MinVersionID and MaxVersionID select distros whose version ID (as in /etc/os-release)
+	// lies within this inclusive range. Distros with an unknown version never match a range.
+	MinVersionID string
+	MaxVersionID string
+
+	// ProAttached selects distros by their Ubuntu Pro attachment status.
+	ProAttached *bool
+
+	// Connected selects distros by whether their WSL-Pro-Service is connected to the agent.
+	Connected *bool
+
+	// Valid selects distros by whether they are still registered with the same GUID.
+	Valid *bool
+
+	// Labels selects distros that carry all of these labels.
+	Labels []string
+}
+
+// Query returns the distros that match the query, sorted case-independently by name.
+// Fields stored in the database are looked up via indexes, so that only the distros
+// that match them are inspected to evaluate the rest of the query.
+func (db *DistroDB) Query(q Query) []*distro.Distro {
+	if db.stopped() {
+		panic("Query: database already stopped")
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	candidates := db.indexes.lookup(q)
+
+	normalizedNames := make([]string, 0, len(candidates))
+	for n := range candidates {
+		normalizedNames = append(normalizedNames, n)
+	}
+	sort.Strings(normalizedNames)
+
+	var out []*distro.Distro
+	for _, n := range normalizedNames {
+		d, ok := db.distros[n]
+		if !ok || !q.matchesUnindexed(d) {
+			continue
+		}
+		out = append(out, d)
+	}
+
+	return out
+}
+
+// SubmitTasksTo submits the tasks to every distro that matches the query, and returns
+// how many distros were selected.
+func (db *DistroDB) SubmitTasksTo(q Query, tasks ...task.Task) (n int, err error) {
+	distros := db.Query(q)
+	for _, d := range distros {
+		if e := d.SubmitTasks(tasks...); e != nil {
+			err = errors.Join(err, fmt.Errorf("distro %q: %v", d.Name(), e))
+		}
+	}
+
+	if err != nil {
+		return len(distros), fmt.Errorf("could not submit tasks to all selected distros: %v", err)
+	}
+
+	return len(distros), nil
+}
+
+// matchesUnindexed evaluates the fields of the query that are not covered by the indexes.
+func (q Query) matchesUnindexed(d *distro.Distro) bool {
+	if q.MinVersionID != "" || q.MaxVersionID != "" {
+		v := d.Properties().VersionID
+		if v == "" {
+			return false
+		}
+		if q.MinVersionID != "" && compareVersions(v, q.MinVersionID) < 0 {
+			return false
+		}
+		if q.MaxVersionID != "" && compareVersions(v, q.MaxVersionID) > 0 {
+			return false
+		}
+	}
+
+	if q.Valid != nil && d.IsValid() != *q.Valid {
+		return false
+	}
+
+	if q.Connected != nil {
+		// Invalid distros are never connected.
+		conn, err := d.Connection()
+		if connected := err == nil && conn != nil; connected != *q.Connected {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareVersions compares two version IDs such as "22.04" component by component,
+// numerically when both components are numbers and lexically otherwise.
+// It returns -1, 0 or +1 like strings.Compare.
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+
+		var c int
+		if errX == nil && errY == nil {
+			c = cmp.Compare(x, y)
+		} else {
+			c = strings.Compare(as[i], bs[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(as), len(bs))
+}
@@ -0,0 +1,115 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"go.yaml.in/yaml/v3"
+)
+
+// schemaVersion is the version of the layout of the stored distros. It must be
+// increased, and a migration added, whenever the layout changes in a way that
+// older versions cannot read.
+//
+// Versions:
+//   - 0: a bare list of distros, with only their name, GUID and properties.
+//   - 1: the list of distros is versioned, and distros carry their records.
+const schemaVersion = 1
+
+const (
+	// distrosBucket is the storage bucket containing the distros, indexed by normalized name.
+	distrosBucket = "distros"
+
+	// databaseBucket is the storage bucket containing information about the database itself.
+	databaseBucket = "database"
+
+	// versionKey is the key of the schema version in databaseBucket.
+	versionKey = "version"
+)
+
+// writeDatabase replaces the distros in the storage.
+func writeDatabase(tx storage.Tx, distros []serializableDistro) error {
+	if err := tx.DeleteBucket(distrosBucket); err != nil {
+		return err
+	}
+
+	for _, d := range distros {
+		out, err := yaml.Marshal(d)
+		if err != nil {
+			return fmt.Errorf("could not marshal distro %q: %v", d.Name, err)
+		}
+		if err := tx.Put(distrosBucket, strings.ToLower(d.Name), out); err != nil {
+			return err
+		}
+	}
+
+	return tx.Put(databaseBucket, versionKey, []byte(strconv.Itoa(schemaVersion)))
+}
+
+// readDatabase reads the distros in the storage, sorted case-independently by name.
+func readDatabase(tx storage.Tx) (distros []serializableDistro, err error) {
+	if out := tx.Get(databaseBucket, versionKey); out != nil {
+		version, err := strconv.Atoi(string(out))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse database schema version: %v", err)
+		}
+		if version > schemaVersion {
+			return nil, fmt.Errorf("database schema version %d is newer than the supported version %d", version, schemaVersion)
+		}
+	}
+
+	err = tx.ForEach(distrosBucket, func(normalizedName string, out []byte) error {
+		var d serializableDistro
+		if err := yaml.Unmarshal(out, &d); err != nil {
+			return fmt.Errorf("could not unmarshal distro %q: %v", normalizedName, err)
+		}
+		distros = append(distros, d)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return distros, nil
+}
+
+// serializableDatabase is the layout of the database file written by older versions of the agent.
+type serializableDatabase struct {
+	Version int
+	Distros []serializableDistro
+}
+
+// unmarshalDatabase parses the contents of the database file, migrating older layouts
+// into the current one. It returns true if the distros must be rewritten to be up to date.
+func unmarshalDatabase(out []byte) (distros []serializableDistro, migrated bool, err error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(out, &root); err != nil {
+		return nil, false, err
+	}
+
+	// Empty file.
+	if len(root.Content) == 0 {
+		return nil, false, nil
+	}
+
+	// Version 0: the records are left empty for the distros to fill them in.
+	if root.Content[0].Kind == yaml.SequenceNode {
+		if err := root.Decode(&distros); err != nil {
+			return nil, false, err
+		}
+		return distros, true, nil
+	}
+
+	var db serializableDatabase
+	if err := root.Decode(&db); err != nil {
+		return nil, false, err
+	}
+
+	if db.Version > schemaVersion {
+		return nil, false, fmt.Errorf("database schema version %d is newer than the supported version %d", db.Version, schemaVersion)
+	}
+
+	return db.Distros, db.Version < schemaVersion, nil
+}
@@ -2,9 +2,9 @@ package database
 
 import (
 	"context"
-	"sync"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
 	"github.com/google/uuid"
 )
 
@@ -17,16 +17,20 @@ type serializableDistro struct {
 	Name string
 	GUID string
 	distro.Properties
+	Records distro.Records
+	Labels  []string `yaml:",omitempty"`
 }
 
-// newDistro calls distro.New with the name, GUID and properties specified
+// newDistro calls distro.New with the name, GUID, properties, records and labels specified
 // in its inert counterpart.
-func (in serializableDistro) newDistro(ctx context.Context, storageDir string, startupMu *sync.Mutex) (*distro.Distro, error) {
+func (in serializableDistro) newDistro(ctx context.Context, storageDir string, scheduler *startscheduler.Scheduler, args ...distro.Option) (*distro.Distro, error) {
 	GUID, err := uuid.Parse(in.GUID)
 	if err != nil {
 		return nil, err
 	}
-	return distro.New(ctx, in.Name, in.Properties, storageDir, startupMu, distro.WithGUID(GUID))
+
+	args = append([]distro.Option{distro.WithGUID(GUID), distro.WithRecords(in.Records), distro.WithLabels(in.Labels)}, args...)
+	return distro.New(ctx, in.Name, in.Properties, storageDir, scheduler, args...)
 }
 
 // newSerializableDistro takes the information in distro.Distro relevant to the database
@@ -36,5 +40,7 @@ func newSerializableDistro(d *distro.Distro) serializableDistro {
 		Name:       d.Name(),
 		GUID:       d.GUID(),
 		Properties: d.Properties(),
+		Records:    d.Records(),
+		Labels:     d.AssignedLabels(),
 	}
 }
@@ -2,12 +2,14 @@ package database_test
 
 import (
 	"context"
-	"sync"
+
 	"testing"
+	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
 	"github.com/stretchr/testify/require"
 	wsl "github.com/ubuntu/gowsl"
 	wslmock "github.com/ubuntu/gowsl/mock"
@@ -29,6 +31,26 @@ func TestSerializableDistroMarshallUnmarshall(t *testing.T) {
 				Hostname:    "Machine98",
 			},
 		},
+		"With records and labels": {
+			Name: "Ubuntu",
+			GUID: "{12345678-1234-1234-1234-123456789abc}",
+			Properties: distro.Properties{
+				DistroID:    "Ubuntu",
+				VersionID:   "98.04",
+				PrettyName:  "Ubuntu 98.04.0 LTS",
+				ProAttached: true,
+				Hostname:    "Machine98",
+			},
+			Records: distro.Records{
+				FirstSeen:              time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+				LastConnected:          time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC),
+				LastProAttachment:      distro.TaskResult{Time: time.Date(2024, 3, 2, 12, 1, 0, 0, time.UTC)},
+				LastLandscapeConfigure: distro.TaskResult{Time: time.Date(2024, 3, 2, 12, 2, 0, 0, time.UTC), Error: "could not reach Landscape"},
+				ServiceVersion:         "1.2.3",
+				WSLVersion:             2,
+			},
+			Labels: []string{"dev", "eu"},
+		},
 		"Escaped characters": {
 			Name: "Ubuntu",
 			GUID: "{12345678-1234-1234-1234-123456789abc}",
@@ -109,9 +131,8 @@ func TestSerializableDistroNewDistro(t *testing.T) {
 			}
 
 			// This distro is never started, so no need for any global mutex
-			var mu sync.Mutex
 
-			d, err := s.NewDistro(ctx, t.TempDir(), &mu)
+			d, err := s.NewDistro(ctx, t.TempDir(), startscheduler.New())
 			if err == nil {
 				defer d.Cleanup(context.Background())
 			}
@@ -140,16 +161,17 @@ func TestNewSerializableDistro(t *testing.T) {
 		PrettyName:  "Ubuntu -5.04 (Invented Idea)",
 		ProAttached: true,
 		Hostname:    "NegativeMachine",
+		Labels:      []string{"ci"},
 	}
 
 	// This distro is never started, so no need for any global mutex
-	var mu sync.Mutex
 
-	d, err := distro.New(ctx, registeredDistro, props, t.TempDir(), &mu)
+	d, err := distro.New(ctx, registeredDistro, props, t.TempDir(), startscheduler.New(), distro.WithLabels([]string{"prod"}))
 	require.NoError(t, err, "Setup: distro New() should return no error")
 
 	s := database.NewSerializableDistro(d)
 	require.Equal(t, registeredDistro, s.Name)
 	require.Equal(t, registeredGUID, s.GUID)
 	require.Equal(t, props, s.Properties)
+	require.Equal(t, []string{"prod"}, s.Labels, "Only the labels assigned by the user should be stored apart from the properties")
 }
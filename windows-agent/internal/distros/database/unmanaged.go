@@ -4,14 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
-	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
-	"github.com/google/uuid"
 	"github.com/ubuntu/gowsl"
 	"gopkg.in/ini.v1"
 )
@@ -26,71 +27,246 @@ type BasicDistroInfo struct {
 	State     gowsl.State // Stopped, Running, Uninstalling etc
 }
 
+// unmanagedRefreshInterval is how often the unmanaged distros are refreshed when no registration
+// changes are notified, so that their states are kept up to date.
+const unmanagedRefreshInterval = 5 * time.Minute
+
+// unmanagedCache holds the information about the Ubuntu distros not managed by the agent,
+// indexed by GUID. It is refreshed in the background, so that reading it is cheap.
+type unmanagedCache struct {
+	distros map[string]BasicDistroInfo
+	mu      sync.RWMutex
+
+	// registered is the snapshot of registered distros (name to GUID) of the last refresh.
+	registered map[string]string
+
+	// notUbuntu is the set of GUIDs of the registered distros found not to be Ubuntu, which are not inspected again.
+	notUbuntu map[string]struct{}
+
+	// ready is closed after the first refresh.
+	ready chan struct{}
+
+	// changed receives the notifications of registration changes. See RegistrationsChanged.
+	changed chan struct{}
+}
+
+func newUnmanagedCache() *unmanagedCache {
+	return &unmanagedCache{
+		distros: make(map[string]BasicDistroInfo),
+		ready:   make(chan struct{}),
+		changed: make(chan struct{}, 1),
+	}
+}
+
 // GetUnmanagedDistros returns a list of Ubuntu instances that are currently registered in WSL but not managed by this agent.
-// This is an expensive operation that involves a light wake up of all instances not previously discovered.
-// This information is a snapshot at the time of the call, we don't store it.
+// The list is served from a cache refreshed in the background, periodically and when distros are registered,
+// unregistered or renamed. It blocks until the cache has been populated for the first time.
+//
+// Distros are only inspected while running, so stopped distros are never woken up. Hence, a stopped distro
+// is only listed if it was seen running since the agent started: until then, it is not known to be Ubuntu.
 func (db *DistroDB) GetUnmanagedDistros() (distros []BasicDistroInfo) {
-	managed := db.GetAll()
+	select {
+	case <-db.unmanaged.ready:
+	case <-db.ctx.Done():
+	}
+
+	managed := db.managedGUIDs()
+
+	db.unmanaged.mu.RLock()
+	defer db.unmanaged.mu.RUnlock()
+
+	for guid, info := range db.unmanaged.distros {
+		if _, ok := managed[guid]; ok {
+			continue
+		}
+		distros = append(distros, info)
+	}
+
+	slices.SortFunc(distros, func(a, b BasicDistroInfo) int { return strings.Compare(a.Name, b.Name) })
+	return distros
+}
+
+// managedGUIDs returns the set of GUIDs of the distros in the database.
+func (db *DistroDB) managedGUIDs() map[string]struct{} {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	guids := make(map[string]struct{}, len(db.distros))
+	for _, d := range db.distros {
+		// d.GUID() is cheap because it reads from a value already cached.
+		guids[d.GUID()] = struct{}{}
+	}
+	return guids
+}
+
+// RegistrationsChanged notifies the database that distros may have been registered, unregistered or renamed,
+// so that the unmanaged distros are refreshed if they were. It does not block.
+func (db *DistroDB) RegistrationsChanged() {
+	select {
+	case db.unmanaged.changed <- struct{}{}:
+	default:
+		// A refresh is already pending.
+	}
+}
+
+// watchUnmanagedDistros keeps the cache of unmanaged distros up to date until the database is closed.
+func (db *DistroDB) watchUnmanagedDistros() {
+	db.refreshUnmanagedDistros()
+	close(db.unmanaged.ready)
+
+	refresh := time.NewTimer(unmanagedRefreshInterval)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-db.ctx.Done():
+			return
+		case <-db.unmanaged.changed:
+			if !db.unmanagedRegistrationsChanged() {
+				continue
+			}
+		case <-refresh.C:
+		}
+
+		db.refreshUnmanagedDistros()
+		refresh.Reset(unmanagedRefreshInterval)
+	}
+}
+
+// unmanagedRegistrationsChanged returns true if any distro was registered, unregistered or renamed
+// since the last refresh. This only reads the registry, which is cheap, so notifications about
+// unrelated changes are filtered out before inspecting any distro.
+func (db *DistroDB) unmanagedRegistrationsChanged() bool {
+	registered, err := registeredGUIDs(db.ctx)
+	if err != nil {
+		log.Warningf(db.ctx, "Database: %v", err)
+		return false
+	}
+
+	db.unmanaged.mu.RLock()
+	defer db.unmanaged.mu.RUnlock()
 
-	registered, err := gowsl.RegisteredDistros(db.ctx)
+	return !maps.Equal(registered, db.unmanaged.registered)
+}
+
+// refreshUnmanagedDistros updates the cache of unmanaged distros. Running distros are inspected,
+// while stopped ones keep the information collected the last time they were seen running.
+func (db *DistroDB) refreshUnmanagedDistros() {
+	registered, err := registeredGUIDs(db.ctx)
 	if err != nil {
-		log.Errorf(db.ctx, "failed to get registered distros: %v", err)
-		return distros
+		log.Errorf(db.ctx, "Database: failed to get registered distros: %v", err)
+		return
 	}
 
+	managed := db.managedGUIDs()
 	uncRoot := selectUNCRoot(db.ctx)
-	for _, d := range registered {
-		// Acquiring the distro GUID from GoWSL is quite expensive the way it's implemented now, so we do it once and then pass it along.
-		guid, err := d.GUID()
-		if err != nil {
-			log.Warningf(db.ctx, "failed to get GUID for distro %q: %v", d.Name(), err)
+
+	db.unmanaged.mu.RLock()
+	previous := maps.Clone(db.unmanaged.distros)
+	previousNotUbuntu := maps.Clone(db.unmanaged.notUbuntu)
+	db.unmanaged.mu.RUnlock()
+
+	distros := make(map[string]BasicDistroInfo)
+	notUbuntu := make(map[string]struct{})
+	for name, guid := range registered {
+		if _, ok := managed[guid]; ok {
+			// This is a managed distro, skip it.
 			continue
 		}
-		if slices.ContainsFunc(managed, func(m *distro.Distro) bool {
-			// m.GUID() is cheap because it reads from a value already cached.
-			return guid.String() == m.GUID()
-		}) {
-			// This is a managed distro, skip it.
+
+		if _, ok := previousNotUbuntu[guid]; ok {
+			notUbuntu[guid] = struct{}{}
 			continue
 		}
 
-		info, err := basicDistroInfo(db.ctx, d, guid, uncRoot)
+		info, err := basicDistroInfo(gowsl.NewDistro(db.ctx, name), guid, previous[guid], uncRoot)
+		if errors.Is(err, errNotUbuntu) {
+			notUbuntu[guid] = struct{}{}
+		}
 		if err != nil {
-			log.Warningf(db.ctx, "failed to get basic info for distro %q: %v", d.Name(), err)
+			log.Debugf(db.ctx, "Database: skipping unmanaged distro: %v", err)
 			continue
 		}
-		distros = append(distros, info)
+		distros[guid] = info
 	}
 
-	return distros
+	db.unmanaged.mu.Lock()
+	defer db.unmanaged.mu.Unlock()
+
+	db.unmanaged.distros = distros
+	db.unmanaged.registered = registered
+	db.unmanaged.notUbuntu = notUbuntu
 }
 
+// registeredGUIDs returns the GUIDs of the registered distros, indexed by name.
+func registeredGUIDs(ctx context.Context) (map[string]string, error) {
+	registered, err := gowsl.RegisteredDistros(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	guids := make(map[string]string, len(registered))
+	for _, d := range registered {
+		// Acquiring the distro GUID from GoWSL is quite expensive the way it's implemented now, so we do it once and then pass it along.
+		guid, err := d.GUID()
+		if err != nil {
+			log.Warningf(ctx, "failed to get GUID for distro %q: %v", d.Name(), err)
+			continue
+		}
+		guids[d.Name()] = guid.String()
+	}
+
+	return guids, nil
+}
+
+var (
+	// errNotUbuntu is returned by basicDistroInfo for the distros that turn out not to be Ubuntu once inspected.
+	errNotUbuntu = errors.New("not an Ubuntu distro")
+
+	// errNotInspected is returned by basicDistroInfo for the stopped distros that were never seen running.
+	errNotInspected = errors.New("never seen running")
+)
+
 // basicDistroInfo collects the minimal information about a distro instance for display purposes.
-func basicDistroInfo(ctx context.Context, d gowsl.Distro, guid uuid.UUID, uncRoot string) (info BasicDistroInfo, err error) {
-	// State has to be read earlier, because reading files and running commands alters it (into 'Running' ofc).
+// Stopped distros are not inspected, as that would wake them up: the previously collected
+// information is returned instead, with the state and name updated, or errNotInspected if there is none.
+func basicDistroInfo(d gowsl.Distro, guid string, previous BasicDistroInfo, uncRoot string) (info BasicDistroInfo, err error) {
+	// State has to be read earlier, because reading files alters it (into 'Running' ofc).
 	state, err := d.State()
 	if err != nil {
 		return BasicDistroInfo{}, fmt.Errorf("failed to get state of distro %q: %v", d.Name(), err)
 	}
 
-	osInfo, err := readOsRelease(filepath.Join(uncRoot, d.Name()))
+	if state != gowsl.Running {
+		if previous.GUID == "" {
+			return BasicDistroInfo{}, fmt.Errorf("skipping stopped distro instance %q: %w", d.Name(), errNotInspected)
+		}
+		previous.Name = d.Name()
+		previous.GUID = guid
+		previous.State = state
+		return previous, nil
+	}
+
+	root := filepath.Join(uncRoot, d.Name())
+
+	osInfo, err := readOsRelease(root)
 	if err != nil {
 		return BasicDistroInfo{}, fmt.Errorf("failed to get distro basic info for %q: %v", d.Name(), err)
 	}
 
 	if !strings.EqualFold(osInfo.Id, "ubuntu") {
 		// Our business only concerns with Ubuntu instances.
-		return BasicDistroInfo{}, fmt.Errorf("skipping non-Ubuntu distro instance %q", d.Name())
+		return BasicDistroInfo{}, fmt.Errorf("skipping distro instance %q: %w", d.Name(), errNotUbuntu)
 	}
 
-	hostname, err := d.Command(ctx, "hostname").Output()
+	hostname, err := os.ReadFile(filepath.Join(root, "etc", "hostname"))
 	if err != nil {
 		return BasicDistroInfo{}, fmt.Errorf("failed to get hostname for %s: %v", d.Name(), err)
 	}
 
 	return BasicDistroInfo{
 		Name:      d.Name(),
-		GUID:      guid.String(),
+		GUID:      guid,
 		DistroID:  osInfo.Id,
 		VersionID: osInfo.VersionId,
 		Hostname:  strings.TrimSpace(string(hostname)),
@@ -8,10 +8,14 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/wakepolicy"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
 	"github.com/google/uuid"
 	"github.com/ubuntu/decorate"
 	wsl "github.com/ubuntu/gowsl"
@@ -28,11 +32,24 @@ type Distro struct {
 	properties   Properties
 	propertiesMu sync.RWMutex
 
+	// Records contains non-volatile information that is stored in the database
+	records         Records
+	recordsMu       sync.RWMutex
+	onRecordsChange func()
+
+	// Labels are free-form tags assigned by the user that allow selecting distros. They are
+	// stored in the database. Labels declared from inside the distro are part of its properties.
+	labels   []string
+	labelsMu sync.RWMutex
+
 	// invalidated is an internal value if distro can't be contacted through GRPC
 	invalidated atomic.Bool
 
 	worker       workerInterface
 	stateManager *stateManager
+
+	// ownStore is the store the distro opened for its worker, if none was supplied.
+	ownStore storage.Store
 }
 
 // workerInterface is an interface that is implements the task processing worker. It is intended
@@ -43,7 +60,12 @@ type workerInterface interface {
 	SetConnection(worker.Connection)
 	SubmitTasks(...task.Task) error
 	SubmitDeferredTasks(...task.Task) error
+	SubmitDeferredTasksAtomically(func(func(storage.Tx) error) error, ...task.Task) error
+	SubmitTaskGroup(...task.Task) error
+	SubmitDeferredTaskGroup(...task.Task) error
 	EnqueueDeferredTasks()
+	QueuedTasks() []worker.QueuedTask
+	CancelTask(context.Context, string) error
 	Stop(context.Context)
 }
 
@@ -58,6 +80,11 @@ type options struct {
 	guid                  uuid.UUID
 	taskProcessingContext context.Context
 	newWorkerFunc         func(context.Context, *Distro, string) (workerInterface, error)
+	records               Records
+	onRecordsChange       func()
+	labels                []string
+	store                 storage.Store
+	wakePolicy            *wakepolicy.Governor
 }
 
 // Option is an optional argument for distro.New.
@@ -71,6 +98,22 @@ func WithGUID(guid uuid.UUID) Option {
 	}
 }
 
+// WithStore is an optional parameter for distro.New that sets the store where the distro's
+// tasks are kept. Otherwise, the distro opens the store in its storage directory.
+func WithStore(s storage.Store) Option {
+	return func(o *options) {
+		o.store = s
+	}
+}
+
+// WithWakePolicy is an optional parameter for distro.New that subjects waking the distro up for
+// background work to the policy enforced by the governor. Otherwise, the distro can always be woken up.
+func WithWakePolicy(g *wakepolicy.Governor) Option {
+	return func(o *options) {
+		o.wakePolicy = g
+	}
+}
+
 // New creates a new Distro object after searching for a distro with the given name.
 //
 //   - If identity.Name is not registered, a DistroDoesNotExist error is returned.
@@ -80,16 +123,29 @@ func WithGUID(guid uuid.UUID) Option {
 //
 //   - To avoid the latter check, you can pass a default-constructed identity.GUID. In that
 //     case, the distro will be created with its currently registered GUID.
-func New(ctx context.Context, name string, props Properties, storageDir string, startupMu *sync.Mutex, args ...Option) (distro *Distro, err error) {
+func New(ctx context.Context, name string, props Properties, storageDir string, scheduler *startscheduler.Scheduler, args ...Option) (distro *Distro, err error) {
 	defer decorate.OnError(&err, "could not initialize distro %q", name)
 
 	var nilGUID uuid.UUID
 	opts := options{
 		guid:                  nilGUID,
 		taskProcessingContext: context.Background(),
-		newWorkerFunc: func(ctx context.Context, d *Distro, dir string) (workerInterface, error) {
-			return worker.New(ctx, d, dir)
-		},
+	}
+	opts.newWorkerFunc = func(ctx context.Context, d *Distro, dir string) (workerInterface, error) {
+		store := opts.store
+		if store == nil {
+			s, err := storage.Open(d.ctx, dir)
+			if err != nil {
+				return nil, err
+			}
+			store, d.ownStore = s, s
+		}
+
+		w, err := worker.New(ctx, d, dir, store)
+		if err != nil && d.ownStore != nil {
+			_ = d.ownStore.Close()
+		}
+		return w, err
 	}
 
 	for _, f := range args {
@@ -118,19 +174,31 @@ func New(ctx context.Context, name string, props Properties, storageDir string,
 		}
 	}
 
-	if startupMu == nil {
-		return nil, errors.New("startup mutex must not be nil")
+	if scheduler == nil {
+		return nil, errors.New("start scheduler must not be nil")
+	}
+
+	if opts.records.FirstSeen.IsZero() {
+		opts.records.FirstSeen = time.Now()
 	}
 
 	distro = &Distro{
-		identity:   id,
-		properties: props,
+		identity:        id,
+		properties:      props.normalized(),
+		records:         opts.records,
+		onRecordsChange: opts.onRecordsChange,
+		labels:          NormalizeLabels(opts.labels),
 		stateManager: &stateManager{
 			distroIdentity: id,
-			startupMu:      startupMu,
+			scheduler:      scheduler,
+			wakePolicy:     opts.wakePolicy,
 		},
 	}
 
+	if v := distro.wslVersion(ctx); v != 0 {
+		distro.records.WSLVersion = v
+	}
+
 	distro.worker, err = opts.newWorkerFunc(opts.taskProcessingContext, distro, storageDir)
 	if err != nil {
 		return nil, err
@@ -167,7 +235,8 @@ func (d *Distro) SetProperties(p Properties) bool {
 	d.propertiesMu.Lock()
 	defer d.propertiesMu.Unlock()
 
-	if d.properties == p {
+	p = p.normalized()
+	if d.properties.Equal(p) {
 		return false
 	}
 	d.properties = p
@@ -225,12 +294,51 @@ func (d *Distro) SubmitDeferredTasks(tasks ...task.Task) (err error) {
 	return d.worker.SubmitDeferredTasks(tasks...)
 }
 
+// SubmitTaskGroup enqueues tasks that run in order and fail together on our current worker list.
+// See Worker.SubmitTaskGroup for details.
+func (d *Distro) SubmitTaskGroup(tasks ...task.Task) (err error) {
+	if !d.IsValid() {
+		return &NotValidError{}
+	}
+	return d.worker.SubmitTaskGroup(tasks...)
+}
+
+// SubmitDeferredTaskGroup enqueues deferred tasks that run in order and fail together on our current
+// worker list. See Worker.SubmitDeferredTaskGroup for details.
+func (d *Distro) SubmitDeferredTaskGroup(tasks ...task.Task) (err error) {
+	if !d.IsValid() {
+		return &NotValidError{}
+	}
+	return d.worker.SubmitDeferredTaskGroup(tasks...)
+}
+
+// SubmitDeferredTasksAtomically enqueues one or more deferred tasks, storing them in the
+// transaction run by commit. See Worker.SubmitDeferredTasksAtomically for details.
+func (d *Distro) SubmitDeferredTasksAtomically(commit func(saveTasks func(storage.Tx) error) error, tasks ...task.Task) (err error) {
+	if !d.IsValid() {
+		return &NotValidError{}
+	}
+	return d.worker.SubmitDeferredTasksAtomically(commit, tasks...)
+}
+
 // EnqueueDeferredTasks takes all deferred tasks and promotes them
 // to regular tasks.
 func (d *Distro) EnqueueDeferredTasks() {
 	d.worker.EnqueueDeferredTasks()
 }
 
+// QueuedTasks returns the tasks of the distro that did not complete yet.
+// See Worker.QueuedTasks for details.
+func (d *Distro) QueuedTasks() []worker.QueuedTask {
+	return d.worker.QueuedTasks()
+}
+
+// CancelTask cancels the task of the distro with that ID, whether it is queued or running.
+// See Worker.CancelTask for details.
+func (d *Distro) CancelTask(ctx context.Context, id string) error {
+	return d.worker.CancelTask(ctx, id)
+}
+
 // Cleanup releases all resources associated with the distro.
 func (d *Distro) Cleanup(ctx context.Context) {
 	if d == nil {
@@ -238,6 +346,12 @@ func (d *Distro) Cleanup(ctx context.Context) {
 	}
 	d.worker.Stop(ctx)
 	d.stateManager.reset()
+
+	if d.ownStore != nil {
+		if err := d.ownStore.Close(); err != nil {
+			log.Warningf(ctx, "distro %q: could not close storage: %v", d.Name(), err)
+		}
+	}
 }
 
 // Invalidate sets the invalid flag to true. The state of this flag can be read with IsValid.
@@ -272,15 +386,31 @@ func (d *Distro) State() (s wsl.State, err error) {
 
 // LockAwake ensures that the distro will stay awake until ReleaseAwake is called.
 // ReleaseAwake must be called the same amount of times for the distro to be
-// allowed to stop.
+// allowed to stop. If the distro needs to be started, it waits behind the
+// starts of higher priority. See LockAwakeWithPriority.
+//
+// Waking the distro up is subject to the wake policy: if it does not allow it, an
+// error wrapping wakepolicy.ErrNotAllowed is returned. See WithWakePolicy.
 //
 // The distro is guaranteed to be running by the time this function returns,
 // otherwise an error is returned.
 func (d *Distro) LockAwake() error {
+	return d.LockAwakeWithPriority(startscheduler.Background)
+}
+
+// LockAwakeWithPriority is like LockAwake, but the distro start is admitted with
+// the given priority. Only starts with background priority are subject to the wake policy.
+func (d *Distro) LockAwakeWithPriority(p startscheduler.Priority) error {
 	if !d.IsValid() {
 		return &NotValidError{}
 	}
-	return d.stateManager.lock(d.ctx)
+	return d.stateManager.lock(d.ctx, p)
+}
+
+// WaitWakeAllowed blocks until the wake policy allows waking the distro up for background
+// work, or the context is done. See LockAwake.
+func (d *Distro) WaitWakeAllowed(ctx context.Context) error {
+	return d.stateManager.waitWakeAllowed(ctx)
 }
 
 // ReleaseAwake undoes the last call to LockAwake. If this was the last call, the
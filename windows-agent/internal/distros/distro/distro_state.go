@@ -9,6 +9,8 @@ import (
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro/touchdistro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/wakepolicy"
 	wsl "github.com/ubuntu/gowsl"
 )
 
@@ -25,9 +27,16 @@ type stateManager struct {
 	// or decreasing the count entails more operations than simply adding one to this number.
 	mu sync.Mutex
 
-	// startupMu protects against multiple distros starting at the same time. This could cause WSL
+	// scheduler limits the number of distros starting at the same time. Too many could cause WSL
 	// (and the whole machine) to freeze up.
-	startupMu *sync.Mutex
+	scheduler *startscheduler.Scheduler
+
+	// wakePolicy decides whether the distro may be woken up for background work. If nil, it always may.
+	wakePolicy *wakepolicy.Governor
+
+	// releasePermit gives back the permission to keep the distro awake for background work. It is nil
+	// unless the distro was stopped when it was locked awake with background priority.
+	releasePermit func()
 }
 
 // state returns the state of the WSL distro, as implemeted by GoWSL.
@@ -40,12 +49,15 @@ func (m *stateManager) state() (s wsl.State, err error) {
 	return wslDistro.State()
 }
 
-// lock increases the internal counter. If it was zero, the distro is awaken and locked awake.
-// The context should be used to pass the GoWSL backend, and cancelling it does not override
-// the need to call unlock.
+// lock increases the internal counter. If it was zero, the distro is awaken with the given priority
+// and locked awake. The context should be used to pass the GoWSL backend, and cancelling it does
+// not override the need to call unlock.
+//
+// Waking the distro up with background priority is subject to the wake policy: if it does not allow
+// it, an error wrapping wakepolicy.ErrNotAllowed is returned.
 //
 //nolint:nolintlint  // Golangci-lint gives false positives only without --build-tags=gowslmock
-func (m *stateManager) lock(ctx context.Context) error {
+func (m *stateManager) lock(ctx context.Context, p startscheduler.Priority) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -64,10 +76,18 @@ func (m *stateManager) lock(ctx context.Context) error {
 		m.cancel()
 	}
 
+	acquired, err := m.acquirePermit(p)
+	if err != nil {
+		return err
+	}
+
 	//nolint:staticcheck // False positive. 'cancel' is used in both paths.
 	ctx, cancel := context.WithCancel(ctx)
-	if err := m.keepAwake(ctx); err != nil {
+	if err := m.keepAwake(ctx, p); err != nil {
 		cancel()
+		if acquired {
+			m.givePermitBack()
+		}
 		return err
 	}
 
@@ -93,6 +113,7 @@ func (m *stateManager) release() error {
 
 	m.cancel()
 	m.cancel = nil
+	m.givePermitBack()
 
 	return nil
 }
@@ -110,40 +131,141 @@ func (m *stateManager) reset() {
 	m.refcount = 0
 	m.cancel()
 	m.cancel = nil
+	m.givePermitBack()
+}
+
+// acquirePermit obtains the permission to keep the distro awake for background work from the wake
+// policy, if it is needed and not held already. It returns true if it was acquired by this call.
+// It must be called with the mutex held.
+func (m *stateManager) acquirePermit(p startscheduler.Priority) (bool, error) {
+	if p != startscheduler.Background || m.wakePolicy == nil || m.releasePermit != nil {
+		return false, nil
+	}
+
+	// A distro that is already running was not woken up by us, so it needs no permission.
+	if s, err := m.state(); err == nil && s == wsl.Running {
+		return false, nil
+	}
+
+	release, err := m.wakePolicy.Acquire()
+	if err != nil {
+		return false, fmt.Errorf("could not wake distro up: %w", err)
+	}
+
+	m.releasePermit = release
+	return true, nil
+}
+
+// givePermitBack releases the permission to keep the distro awake for background work, if it was
+// needed. It must be called with the mutex held.
+func (m *stateManager) givePermitBack() {
+	if m.releasePermit == nil {
+		return
+	}
+
+	m.releasePermit()
+	m.releasePermit = nil
 }
 
-// keepAwake ensures the distro is started by poking the distro every once in a while.
+// waitWakeAllowed blocks until the wake policy allows waking the distro up, or the context is done.
+func (m *stateManager) waitWakeAllowed(ctx context.Context) error {
+	if m.wakePolicy == nil {
+		return nil
+	}
+	return m.wakePolicy.WaitAllowed(ctx)
+}
+
+const (
+	// minRestartDelay and maxRestartDelay bound the time to wait before waking up a distro that stopped
+	// while locked awake. The delay doubles every time waking it up fails.
+	minRestartDelay = time.Second
+	maxRestartDelay = time.Minute
+)
+
+// keepAwake ensures the distro is started, then keeps it awake with a long-lived process.
 // Cancelling the context will remove this keep awake lock, but does not necessarily mean
 // that the distribution will be shutdown right away.
 //
 // The distro will be running by the time keepAwake returns.
-func (m *stateManager) keepAwake(ctx context.Context) (err error) {
-	m.startupMu.Lock()
-	defer m.startupMu.Unlock()
-
+func (m *stateManager) keepAwake(ctx context.Context, p startscheduler.Priority) (err error) {
 	// Wake up distro
-	if err := touchdistro.Touch(ctx, m.distroIdentity.Name); err != nil {
+	metrics := m.scheduler.Metrics()
+	log.Debugf(ctx, "Distro %q: waking up with %s priority (%d distros starting, %d queued)",
+		m.distroIdentity.Name, p, metrics.Running, metrics.Queued[startscheduler.Background]+metrics.Queued[startscheduler.Interactive])
+
+	wait, err := m.wake(ctx, p)
+	if err != nil {
 		return fmt.Errorf("could not wake distro up: %v", err)
 	}
 
 	// Keep distro awake
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-time.After(5 * time.Second):
-				if err := touchdistro.Touch(ctx, m.distroIdentity.Name); err != nil {
-					// The distro instance could have been manually
-					// unregistered.
-					if touchdistro.IsWslDistroNotFound(err) {
-						return
-					}
-					log.Errorf(ctx, "Distro %q: %v", m.distroIdentity.Name, err)
-				}
-			}
-		}
-	}()
+	go m.superviseKeepAlive(ctx, p, wait)
 
 	return nil
 }
+
+// wake starts the distro once the scheduler admits it, then starts the keep-alive process.
+// The returned function waits for the keep-alive process to exit.
+func (m *stateManager) wake(ctx context.Context, p startscheduler.Priority) (wait func() error, err error) {
+	err = m.scheduler.Run(ctx, p, func(ctx context.Context) error {
+		return touchdistro.Touch(ctx, m.distroIdentity.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return touchdistro.KeepAlive(ctx, m.distroIdentity.Name)
+}
+
+// superviseKeepAlive waits for the keep-alive process until the context is done. The process exits
+// as soon as the distro stops, in which case the distro is woken up again and the process restarted.
+func (m *stateManager) superviseKeepAlive(ctx context.Context, p startscheduler.Priority, wait func() error) {
+	name := m.distroIdentity.Name
+
+	for {
+		err := wait()
+		if ctx.Err() != nil {
+			return
+		}
+
+		// The distro instance could have been manually unregistered.
+		if touchdistro.IsWslDistroNotFound(err) {
+			log.Warningf(ctx, "Distro %q: no longer keeping the distro awake: %v", name, err)
+			return
+		}
+
+		log.Warningf(ctx, "Distro %q: distro stopped while locked awake: %v", name, err)
+
+		if wait = m.wakeAgain(ctx, p); wait == nil {
+			return
+		}
+	}
+}
+
+// wakeAgain wakes the distro up after it stopped while locked awake, retrying with an increasing delay.
+// It returns nil if the context is done or the distro no longer exists.
+func (m *stateManager) wakeAgain(ctx context.Context, p startscheduler.Priority) (wait func() error) {
+	name := m.distroIdentity.Name
+
+	for delay := minRestartDelay; ; delay = min(2*delay, maxRestartDelay) {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+
+		wait, err := m.wake(ctx, p)
+		if err == nil {
+			return wait
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if touchdistro.IsWslDistroNotFound(err) {
+			log.Warningf(ctx, "Distro %q: no longer keeping the distro awake: %v", name, err)
+			return nil
+		}
+
+		log.Errorf(ctx, "Distro %q: could not wake distro up, retrying in %s: %v", name, min(2*delay, maxRestartDelay), err)
+	}
+}
@@ -5,14 +5,19 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
-	"sync"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/wakepolicy"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
@@ -26,23 +31,23 @@ func TestMain(m *testing.M) {
 	m.Run()
 }
 
-// globalStartupMu protects against multiple distros starting at the same time.
-var globalStartupMu sync.Mutex
+// globalStartScheduler protects against multiple distros starting at the same time.
+var globalStartScheduler = startscheduler.New()
 
-// startupMutex exists so that all distro tests share the same startup mutex.
-// This mutex prevents multiple distros from starting at the same time, which
+// startScheduler exists so that all distro tests share the same start scheduler.
+// This scheduler prevents multiple distros from starting at the same time, which
 // could freeze the machine.
 //
 // When a mock WSL is used, this concern does not exist so we provide a new
-// mutex for every test so they can run in parallel without interference.
-func startupMutex() *sync.Mutex {
+// scheduler for every test so they can run in parallel without interference.
+func startScheduler() *startscheduler.Scheduler {
 	if wsl.MockAvailable() {
-		// No real distros: use a different mutex every test
-		return &sync.Mutex{}
+		// No real distros: use a different scheduler every test
+		return startscheduler.New()
 	}
 
-	// Real distros: use a the same mutex for all tests
-	return &globalStartupMu
+	// Real distros: use a the same scheduler for all tests
+	return globalStartScheduler
 }
 
 func TestNew(t *testing.T) {
@@ -68,7 +73,7 @@ func TestNew(t *testing.T) {
 		distro                 string
 		withGUID               string
 		preventWorkDirCreation bool
-		nilMutex               bool
+		nilScheduler           bool
 
 		wantErr     bool
 		wantErrType error
@@ -82,7 +87,7 @@ func TestNew(t *testing.T) {
 		"Error when the distro is not registered":                       {distro: nonRegisteredDistro, wantErr: true, wantErrType: &distro.NotValidError{}},
 		"Error when the distro is not registered, but the GUID is":      {distro: nonRegisteredDistro, withGUID: registeredGUID, wantErr: true, wantErrType: &distro.NotValidError{}},
 		"Error when neither the distro nor the GUID are registered":     {distro: nonRegisteredDistro, withGUID: fakeGUID, wantErr: true, wantErrType: &distro.NotValidError{}},
-		"Error when the startup mutex is nil":                           {distro: registeredDistro, nilMutex: true, wantErr: true},
+		"Error when the start scheduler is nil":                         {distro: registeredDistro, nilScheduler: true, wantErr: true},
 		"Error when the distro working directory cannot be created":     {distro: nonRegisteredDistro, preventWorkDirCreation: true, wantErr: true, wantErrType: &distro.NotValidError{}},
 	}
 
@@ -105,12 +110,12 @@ func TestNew(t *testing.T) {
 				require.NoError(t, err, "Setup: could not write file to interfere with distro's MkDir")
 			}
 
-			mu := startupMutex()
-			if tc.nilMutex {
-				mu = nil
+			scheduler := startScheduler()
+			if tc.nilScheduler {
+				scheduler = nil
 			}
 
-			d, err = distro.New(ctx, tc.distro, props, t.TempDir(), mu, args...)
+			d, err = distro.New(ctx, tc.distro, props, t.TempDir(), scheduler, args...)
 			defer d.Cleanup(context.Background())
 
 			if tc.wantErr {
@@ -141,7 +146,7 @@ func TestString(t *testing.T) {
 	GUID, err := uuid.Parse(guid)
 	require.NoError(t, err, "Setup: could not parse guid %s: %v", GUID, err)
 
-	d, err := distro.New(ctx, name, distro.Properties{}, t.TempDir(), startupMutex(), distro.WithGUID(GUID))
+	d, err := distro.New(ctx, name, distro.Properties{}, t.TempDir(), startScheduler(), distro.WithGUID(GUID))
 	defer d.Cleanup(context.Background())
 
 	require.NoError(t, err, "Setup: unexpected error in distro.New")
@@ -180,7 +185,7 @@ func TestIsValid(t *testing.T) {
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
 			// Create an always valid distro
-			d, err := distro.New(ctx, distro1, distro.Properties{}, t.TempDir(), startupMutex())
+			d, err := distro.New(ctx, distro1, distro.Properties{}, t.TempDir(), startScheduler())
 			defer d.Cleanup(context.Background())
 
 			require.NoError(t, err, "Setup: distro New() should return no errors")
@@ -208,6 +213,7 @@ func TestSetProperties(t *testing.T) {
 		VersionID:   "100.04",
 		PrettyName:  "Ubuntu 100.04.0 LTS",
 		ProAttached: true,
+		Labels:      []string{"ci", "dev"},
 	}
 
 	props2 := distro.Properties{
@@ -218,12 +224,15 @@ func TestSetProperties(t *testing.T) {
 	}
 
 	testCases := map[string]struct {
-		sameProps bool
+		sameProps   bool
+		otherLabels []string
 
 		want bool
 	}{
 		"Return true when setting a new set of properties":     {want: true},
+		"Return true when setting different labels":            {sameProps: true, otherLabels: []string{"ci"}, want: true},
 		"Return false when setting the same set of properties": {sameProps: true, want: false},
+		"Return false when setting equivalent labels":          {sameProps: true, otherLabels: []string{" Dev", "ci", "CI"}, want: false},
 	}
 
 	for name, tc := range testCases {
@@ -235,13 +244,16 @@ func TestSetProperties(t *testing.T) {
 			}
 
 			dname, _ := wsltestutils.RegisterDistro(t, ctx, false)
-			d, err := distro.New(ctx, dname, props1, t.TempDir(), startupMutex())
+			d, err := distro.New(ctx, dname, props1, t.TempDir(), startScheduler())
 			require.NoError(t, err, "Setup: distro New should return no errors")
 
 			p := props2
 			if tc.sameProps {
 				p = props1
 			}
+			if tc.otherLabels != nil {
+				p.Labels = tc.otherLabels
+			}
 
 			got := d.SetProperties(p)
 			require.Equal(t, tc.want, got, "Unexpected return value from SetProperties")
@@ -249,6 +261,161 @@ func TestSetProperties(t *testing.T) {
 	}
 }
 
+func TestLabels(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	testCases := map[string]struct {
+		initial  []string
+		declared []string
+		set      []string
+
+		wantChanged  bool
+		wantLabels   []string
+		wantAssigned []string
+	}{
+		"Return true when setting new labels":       {initial: []string{"dev"}, set: []string{"prod"}, wantChanged: true, wantLabels: []string{"prod"}},
+		"Return true when removing all labels":      {initial: []string{"dev"}, wantChanged: true, wantLabels: []string{}},
+		"Return false when setting the same labels": {initial: []string{"dev", "eu"}, set: []string{"eu", "dev"}, wantLabels: []string{"dev", "eu"}},
+
+		"Labels are normalized": {set: []string{" Prod ", "prod", "", "EU"}, wantChanged: true, wantLabels: []string{"eu", "prod"}},
+
+		"Declared labels are merged with the assigned ones": {initial: []string{"dev"}, declared: []string{"CI", "dev"}, set: []string{"dev"}, wantLabels: []string{"ci", "dev"}, wantAssigned: []string{"dev"}},
+		"Declared labels remain when removing all labels":   {initial: []string{"dev"}, declared: []string{"ci"}, wantChanged: true, wantLabels: []string{"ci"}, wantAssigned: []string{}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			dname, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			props := distro.Properties{Labels: tc.declared}
+			d, err := distro.New(ctx, dname, props, t.TempDir(), startScheduler(), distro.WithLabels(tc.initial))
+			require.NoError(t, err, "Setup: distro New should return no errors")
+
+			if tc.wantAssigned == nil {
+				tc.wantAssigned = tc.wantLabels
+			}
+
+			got := d.SetLabels(tc.set)
+			require.Equal(t, tc.wantChanged, got, "Unexpected return value from SetLabels")
+			require.Equal(t, tc.wantLabels, d.Labels(), "Unexpected labels after SetLabels")
+			require.Equal(t, tc.wantAssigned, d.AssignedLabels(), "Unexpected assigned labels after SetLabels")
+
+			for _, l := range tc.wantLabels {
+				require.True(t, d.HasLabels(strings.ToUpper(l)), "HasLabels should match label %q regardless of case", l)
+			}
+			require.False(t, d.HasLabels(append(tc.wantLabels, "missing")...), "HasLabels should not match a label the distro does not carry")
+		})
+	}
+}
+
+func TestRecords(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	firstSeen := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		knownDistro bool
+		task        task.Task
+		taskErr     error
+
+		wantFirstSeen bool
+		wantProResult bool
+		wantLpeResult bool
+	}{
+		"Success recording a new distro":             {},
+		"Success keeping the records of a known one": {knownDistro: true, wantFirstSeen: true},
+
+		"Success recording a Pro attachment":          {task: tasks.ProAttachment{Token: "123"}, wantProResult: true},
+		"Success recording a failed Pro attachment":   {task: tasks.ProAttachment{Token: "123"}, taskErr: errors.New("mock error"), wantProResult: true},
+		"Success recording a Landscape configuration": {task: tasks.LandscapeConfigure{Config: "[client]"}, wantLpeResult: true},
+		"Success ignoring untracked tasks":            {task: tasks.ProxyConfig{}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			var changes atomic.Int32
+			args := []distro.Option{distro.WithOnRecordsChange(func() { changes.Add(1) })}
+			if tc.knownDistro {
+				args = append(args, distro.WithRecords(distro.Records{FirstSeen: firstSeen, ServiceVersion: "1.0"}))
+			}
+
+			dname, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			d, err := distro.New(ctx, dname, distro.Properties{}, t.TempDir(), startScheduler(), args...)
+			require.NoError(t, err, "Setup: distro New should return no errors")
+			defer d.Cleanup(ctx)
+
+			r := d.Records()
+			if tc.wantFirstSeen {
+				require.Equal(t, firstSeen, r.FirstSeen, "FirstSeen should be preserved for known distros")
+			} else {
+				require.WithinDuration(t, time.Now(), r.FirstSeen, time.Minute, "FirstSeen should be set for new distros")
+			}
+			require.Equal(t, uint8(2), r.WSLVersion, "WSLVersion should be read from the registry")
+			require.Zero(t, changes.Load(), "Creating a distro should not notify any change")
+
+			d.RecordConnection(ctx, "2.0")
+			r = d.Records()
+			require.WithinDuration(t, time.Now(), r.LastConnected, time.Minute, "LastConnected should be set when the distro connects")
+			require.Equal(t, "2.0", r.ServiceVersion, "ServiceVersion should be the one reported when connecting")
+			require.Equal(t, int32(1), changes.Load(), "Recording a connection should notify a change")
+
+			if tc.task == nil {
+				return
+			}
+
+			d.RecordTaskResult(tc.task, tc.taskErr)
+			r = d.Records()
+
+			for _, c := range []struct {
+				name   string
+				result distro.TaskResult
+				want   bool
+			}{
+				{"LastProAttachment", r.LastProAttachment, tc.wantProResult},
+				{"LastLandscapeConfigure", r.LastLandscapeConfigure, tc.wantLpeResult},
+			} {
+				if !c.want {
+					require.Zero(t, c.result, "%s should not have been recorded", c.name)
+					continue
+				}
+				require.WithinDuration(t, time.Now(), c.result.Time, time.Minute, "%s should have been recorded", c.name)
+				if tc.taskErr != nil {
+					require.Equal(t, tc.taskErr.Error(), c.result.Error, "%s should have recorded the error", c.name)
+				} else {
+					require.Empty(t, c.result.Error, "%s should have been recorded as successful", c.name)
+				}
+			}
+
+			last, tracked := r.LastResult(tc.task)
+			require.Equal(t, tc.wantProResult || tc.wantLpeResult, tracked, "LastResult should only find tracked tasks")
+			if tracked {
+				require.False(t, last.Time.IsZero(), "LastResult should return the recorded result")
+			}
+
+			wantChanges := int32(1)
+			if tc.wantProResult || tc.wantLpeResult {
+				wantChanges++
+			}
+			require.Equal(t, wantChanges, changes.Load(), "Recording a tracked task should notify a change")
+		})
+	}
+}
+
 func TestLockReleaseAwake(t *testing.T) {
 	if wsl.MockAvailable() {
 		t.Parallel()
@@ -270,6 +437,9 @@ func TestLockReleaseAwake(t *testing.T) {
 		errorOnSecondLock        bool
 		errorStateOnSecondLock   bool
 
+		// Supervision
+		terminateWhileLocked bool
+
 		// Alternatives to Release
 		cleanupDistro bool
 
@@ -283,6 +453,7 @@ func TestLockReleaseAwake(t *testing.T) {
 		"Registered distro is kept awake until ReleaseAwake":                              {},
 		"Registered distro is kept awake until ReleaseAwake (two locks and two releases)": {doubleLock: true},
 		"Registered distro is awaken by second LockAwake":                                 {doubleLock: true, stopDistroInbetweenLocks: true},
+		"Registered distro is woken up again when terminated while locked":                {terminateWhileLocked: true},
 
 		"Registered distro is kept awake until distro cleanup": {cleanupDistro: true},
 
@@ -318,7 +489,7 @@ func TestLockReleaseAwake(t *testing.T) {
 				distroName, _ = wsltestutils.RegisterDistro(t, ctx, true)
 			}
 
-			d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), startupMutex())
+			d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), startScheduler())
 			defer d.Cleanup(context.Background())
 
 			require.NoError(t, err, "Setup: distro New should return no error")
@@ -347,12 +518,17 @@ func TestLockReleaseAwake(t *testing.T) {
 				return wsltestutils.DistroState(t, ctx, distroName) == "Running"
 			}, 10*time.Second, time.Second, "distro should have started after calling LockAwake")
 
+			if tc.terminateWhileLocked {
+				wsltestutils.TerminateDistro(t, ctx, distroName)
+				require.Eventually(t, func() bool {
+					return wsltestutils.DistroState(t, ctx, distroName) == "Running"
+				}, 10*time.Second, 100*time.Millisecond, "distro should have been woken up again after being terminated")
+			}
+
 			// Second lock
 			if tc.doubleLock {
-				if tc.stopDistroInbetweenLocks {
-					wsltestutils.TerminateDistro(t, ctx, distroName)
-				}
-
+				// The mock is broken before stopping the distro, because the distro is woken up
+				// again in the background as soon as it stops.
 				if tc.errorOnSecondLock {
 					mock.WslLaunchInteractiveError = true
 				}
@@ -361,6 +537,10 @@ func TestLockReleaseAwake(t *testing.T) {
 					mock.StateError = true
 				}
 
+				if tc.stopDistroInbetweenLocks {
+					wsltestutils.TerminateDistro(t, ctx, distroName)
+				}
+
 				err = d.LockAwake()
 				if tc.wantSecondLockErr {
 					require.Errorf(t, err, "Second LockAwake should have returned an error")
@@ -435,22 +615,32 @@ func TestNoSimultaneousStartups(t *testing.T) {
 	}
 
 	ctx := wsl.WithMock(context.Background(), wslmock.New())
-	var startupMu sync.Mutex
+	scheduler := startscheduler.New()
 
 	distroName, _ := wsltestutils.RegisterDistro(t, ctx, true)
-	d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), &startupMu)
+	d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), scheduler)
 	defer d.Cleanup(context.Background())
 	require.NoError(t, err, "Setup: distro New should return no error")
 
 	wsltestutils.TerminateDistro(t, ctx, distroName)
 
-	// Lock the startup mutex to pretend some other distro is starting up
+	// Occupy the only start slot to pretend some other distro is starting up
 	const lockAwakeMaxTime = 20 * time.Second
 	ch := make(chan error)
 
 	func() {
-		startupMu.Lock()
-		defer startupMu.Unlock()
+		otherStarting := make(chan struct{})
+		otherStarted := make(chan struct{})
+		defer close(otherStarted)
+		go func() {
+			//nolint:errcheck // The other start is a pretense
+			scheduler.Run(ctx, startscheduler.Interactive, func(context.Context) error {
+				close(otherStarting)
+				<-otherStarted
+				return nil
+			})
+		}()
+		<-otherStarting
 
 		go func() {
 			// We send the error to be asserted in the main goroutine because
@@ -461,21 +651,112 @@ func TestNoSimultaneousStartups(t *testing.T) {
 
 		time.Sleep(lockAwakeMaxTime)
 		state := wsltestutils.DistroState(t, ctx, distroName)
-		require.Equal(t, "Stopped", state, "Distro should not start while the mutex is locked")
+		require.Equal(t, "Stopped", state, "Distro should not start while another distro is starting")
+		require.Equal(t, 1, scheduler.Metrics().Queued[startscheduler.Background], "Distro start should be queued")
 	}()
 
-	// The startup mutex has been released to pretend some other distro finished starting up
+	// The start slot has been released to pretend some other distro finished starting up
 
 	select {
 	case <-time.After(lockAwakeMaxTime):
-		require.Fail(t, "LockAwake should have returned after releasing the startup mutex")
+		require.Fail(t, "LockAwake should have returned after releasing the start slot")
 	case err := <-ch:
 		require.NoError(t, err, "LockAwake should return no error")
 		break
 	}
 
 	state := wsltestutils.DistroState(t, ctx, distroName)
-	require.Equal(t, "Running", state, "Distro should start after the mutex is released")
+	require.Equal(t, "Running", state, "Distro should start after the start slot is released")
+}
+
+func TestLockAwakeWakePolicy(t *testing.T) {
+	t.Parallel()
+
+	if !wsl.MockAvailable() {
+		t.Skip("Skipped without mocks to avoid waking real distros up")
+	}
+
+	testCases := map[string]struct {
+		policy        wakepolicy.Policy
+		distroRunning bool
+		interactive   bool
+		otherAwake    bool
+
+		wantErr bool
+	}{
+		"Success waking the distro up when the policy allows it":                 {},
+		"Success waking the distro up below the maximum number of awake distros": {policy: wakepolicy.Policy{MaxAwake: 1}},
+		"Success locking a running distro when the policy forbids waking it up":  {policy: wakepolicy.Policy{NeverWake: true}, distroRunning: true},
+		"Success waking the distro up interactively when the policy forbids it":  {policy: wakepolicy.Policy{NeverWake: true}, interactive: true},
+
+		"Error when the policy forbids waking the distro up":            {policy: wakepolicy.Policy{NeverWake: true}, wantErr: true},
+		"Error when the maximum number of distros are awake":            {policy: wakepolicy.Policy{MaxAwake: 1}, otherAwake: true, wantErr: true},
+		"Error when the distro is woken up outside maintenance windows": {policy: wakepolicy.Policy{Windows: []wakepolicy.Window{outsideWindow()}}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := wsl.WithMock(context.Background(), wslmock.New())
+
+			g := wakepolicy.New()
+			g.SetPolicy(tc.policy)
+
+			if tc.otherAwake {
+				_, err := g.Acquire()
+				require.NoError(t, err, "Setup: could not pretend another distro is awake")
+			}
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), startScheduler(), distro.WithWakePolicy(g))
+			require.NoError(t, err, "Setup: distro New should return no error")
+			defer d.Cleanup(context.Background())
+
+			if tc.distroRunning {
+				require.NoError(t, d.LockAwakeWithPriority(startscheduler.Interactive), "Setup: could not start the distro")
+				defer d.ReleaseAwake() //nolint:errcheck // Irrelevant to the test
+			} else {
+				wsltestutils.TerminateDistro(t, ctx, distroName)
+			}
+
+			p := startscheduler.Background
+			if tc.interactive {
+				p = startscheduler.Interactive
+			}
+
+			err = d.LockAwakeWithPriority(p)
+			if tc.wantErr {
+				require.ErrorIs(t, err, wakepolicy.ErrNotAllowed, "LockAwake should return an error")
+				require.Equal(t, "Stopped", wsltestutils.DistroState(t, ctx, distroName), "Distro should not have been woken up")
+				return
+			}
+			require.NoError(t, err, "LockAwake should return no error")
+			require.Equal(t, "Running", wsltestutils.DistroState(t, ctx, distroName), "Distro should be running")
+
+			if tc.policy.MaxAwake == 0 {
+				return
+			}
+
+			_, err = g.Acquire()
+			require.ErrorIs(t, err, wakepolicy.ErrNotAllowed, "The distro should count as awake while it is locked")
+
+			require.NoError(t, d.ReleaseAwake(), "ReleaseAwake should return no error")
+
+			release, err := g.Acquire()
+			require.NoError(t, err, "The distro should no longer count as awake after it is released")
+			release()
+		})
+	}
+}
+
+// outsideWindow returns a maintenance window that does not contain the current time.
+func outsideWindow() wakepolicy.Window {
+	y, m, d := time.Now().Date()
+	now := time.Since(time.Date(y, m, d, 0, 0, 0, 0, time.Local))
+
+	start := (now + 2*time.Hour).Truncate(time.Minute) % (24 * time.Hour)
+	return wakepolicy.Window{Start: start, End: (start + time.Hour) % (24 * time.Hour)}
 }
 
 func TestState(t *testing.T) {
@@ -510,7 +791,7 @@ func TestState(t *testing.T) {
 			}
 
 			distroName, _ := wsltestutils.RegisterDistro(t, ctx, true)
-			d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), startupMutex())
+			d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), startScheduler())
 			require.NoError(t, err, "Setup: distro New should return no errors")
 
 			gowslDistro := wsl.NewDistro(ctx, distroName)
@@ -573,7 +854,7 @@ func TestWorkerConstruction(t *testing.T) {
 				distroName,
 				distro.Properties{},
 				workDir,
-				startupMutex(),
+				startScheduler(),
 				distro.WithTaskProcessingContext(ctx),
 				withMockWorker)
 			defer d.Cleanup(context.Background())
@@ -603,7 +884,7 @@ func TestInvalidateIdempotent(t *testing.T) {
 
 	inj, w := mockWorkerInjector(false)
 
-	d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), &globalStartupMu, inj)
+	d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), globalStartScheduler, inj)
 	defer d.Cleanup(context.Background())
 	require.NoError(t, err, "Setup: distro New should return no error")
 
@@ -667,7 +948,7 @@ func TestWorkerWrappers(t *testing.T) {
 
 			inj, w := mockWorkerInjector(false)
 
-			d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), startupMutex(), inj)
+			d, err := distro.New(ctx, distroName, distro.Properties{}, t.TempDir(), startScheduler(), inj)
 			defer d.Cleanup(context.Background())
 			require.NoError(t, err, "Setup: distro New should return no error")
 
@@ -754,7 +1035,7 @@ func TestUninstall(t *testing.T) {
 
 			name, _ := wsltestutils.RegisterDistro(t, ctx, false)
 
-			d, err := distro.New(ctx, name, distro.Properties{}, t.TempDir(), startupMutex())
+			d, err := distro.New(ctx, name, distro.Properties{}, t.TempDir(), startScheduler())
 			require.NoError(t, err, "Setup: distro New should return no errors")
 
 			if tc.unregisterDistro {
@@ -826,23 +1107,64 @@ func (w *mockWorker) SubmitDeferredTasks(...task.Task) error {
 	return nil
 }
 
+func (w *mockWorker) SubmitDeferredTasksAtomically(func(func(storage.Tx) error) error, ...task.Task) error {
+	return nil
+}
+
+func (w *mockWorker) SubmitTaskGroup(...task.Task) error {
+	w.submitTasksCalled = true
+	return nil
+}
+
+func (w *mockWorker) SubmitDeferredTaskGroup(...task.Task) error {
+	return nil
+}
+
 func (w *mockWorker) EnqueueDeferredTasks() {
 	panic("Not implemented")
 }
 
+func (w *mockWorker) QueuedTasks() []worker.QueuedTask {
+	return nil
+}
+
+func (w *mockWorker) CancelTask(context.Context, string) error {
+	return nil
+}
+
 func (w *mockWorker) Stop(context.Context) {
 	w.stopCalled = true
 }
 
 type mockConnection struct{}
 
-func (c *mockConnection) SendProAttachment(proToken string) error {
+func (c *mockConnection) SendProAttachment(ctx context.Context, proToken string) error {
 	return nil
 }
 
-func (c *mockConnection) SendLandscapeConfig(lpeConfig string) error {
+func (c *mockConnection) SendLandscapeConfig(ctx context.Context, lpeConfig string) error {
 	return nil
 }
 
+func (c *mockConnection) SendProxyConfig(ctx context.Context, httpProxy, httpsProxy, noProxy string) error {
+	return nil
+}
+
+func (c *mockConnection) SendRunCommand(ctx context.Context, cmd task.Command) (task.CommandOutput, error) {
+	return task.CommandOutput{}, nil
+}
+
+func (c *mockConnection) SendProServices(ctx context.Context, enable, disable []string) ([]task.ServiceResult, error) {
+	return nil, nil
+}
+
+func (c *mockConnection) SendSecurityUpdate(context.Context) (task.SecurityUpdateReport, error) {
+	return task.SecurityUpdateReport{}, nil
+}
+
+func (c *mockConnection) SendPackageInventory(context.Context) ([]task.Package, error) {
+	return nil, nil
+}
+
 func (c *mockConnection) Close() {
 }
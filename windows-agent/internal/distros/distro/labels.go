@@ -0,0 +1,91 @@
+package distro
+
+import (
+	"slices"
+	"strings"
+)
+
+// WithLabels is an optional parameter for distro.New that sets the labels of a
+// distro that is already known, such as one loaded from the database.
+func WithLabels(labels []string) Option {
+	return func(o *options) {
+		o.labels = labels
+	}
+}
+
+// Labels returns the distro's effective labels: the ones assigned by the user together
+// with the ones declared from inside the distro. They are sorted and free of duplicates.
+func (d *Distro) Labels() []string {
+	d.labelsMu.RLock()
+	defer d.labelsMu.RUnlock()
+
+	return d.effectiveLabels()
+}
+
+// AssignedLabels is a getter for the labels assigned by the user to the distro. They are
+// sorted and free of duplicates.
+func (d *Distro) AssignedLabels() []string {
+	d.labelsMu.RLock()
+	defer d.labelsMu.RUnlock()
+
+	return slices.Clone(d.labels)
+}
+
+// HasLabels returns true if the distro carries all the specified labels, either assigned
+// by the user or declared from inside the distro.
+func (d *Distro) HasLabels(labels ...string) bool {
+	d.labelsMu.RLock()
+	defer d.labelsMu.RUnlock()
+
+	effective := d.effectiveLabels()
+	for _, l := range labels {
+		if _, found := slices.BinarySearch(effective, normalizeLabel(l)); !found {
+			return false
+		}
+	}
+	return true
+}
+
+// effectiveLabels merges the assigned and the declared labels. The caller must hold the
+// labels lock.
+func (d *Distro) effectiveLabels() []string {
+	declared := d.Properties().Labels
+	if len(declared) == 0 {
+		return slices.Clone(d.labels)
+	}
+
+	return NormalizeLabels(append(slices.Clone(d.labels), declared...))
+}
+
+// SetLabels replaces the labels assigned by the user to the distro, and returns true if
+// they are different from the original ones.
+func (d *Distro) SetLabels(labels []string) bool {
+	labels = NormalizeLabels(labels)
+
+	d.labelsMu.Lock()
+	defer d.labelsMu.Unlock()
+
+	if slices.Equal(d.labels, labels) {
+		return false
+	}
+	d.labels = labels
+	return true
+}
+
+// NormalizeLabels returns the canonical form of a set of labels: lowercase, without
+// surrounding whitespace, sorted and without empty entries nor duplicates.
+func NormalizeLabels(labels []string) []string {
+	out := make([]string, 0, len(labels))
+	for _, l := range labels {
+		if l = normalizeLabel(l); l != "" {
+			out = append(out, l)
+		}
+	}
+
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 
 	"github.com/google/uuid"
 	wsl "github.com/ubuntu/gowsl"
@@ -30,6 +31,28 @@ type Properties struct {
 
 	// Ubuntu Pro
 	ProAttached bool
+
+	// Labels are the labels declared from inside the distro. They complement the ones
+	// assigned by the user (see Distro.Labels).
+	Labels []string `yaml:",omitempty"`
+}
+
+// normalized returns a copy of the properties with their labels in canonical form.
+func (p Properties) normalized() Properties {
+	if p.Labels = NormalizeLabels(p.Labels); len(p.Labels) == 0 {
+		p.Labels = nil
+	}
+	return p
+}
+
+// Equal returns true if both sets of properties are the same.
+func (p Properties) Equal(other Properties) bool {
+	return p.DistroID == other.DistroID &&
+		p.VersionID == other.VersionID &&
+		p.PrettyName == other.PrettyName &&
+		p.Hostname == other.Hostname &&
+		p.ProAttached == other.ProAttached &&
+		slices.Equal(p.Labels, other.Labels)
 }
 
 // isValid checks that the properties against the registry.
@@ -0,0 +1,138 @@
+package distro
+
+import (
+	"context"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
+	wsl "github.com/ubuntu/gowsl"
+)
+
+// Records contains persistent information gathered by the agent over the lifetime of the distro.
+type Records struct {
+	// FirstSeen is the first time the agent knew about the distro.
+	FirstSeen time.Time
+	// LastConnected is the last time the WSL-Pro-Service connected to the agent.
+	LastConnected time.Time
+
+	// Results of the last tasks that configured the distro.
+	LastProAttachment      TaskResult
+	LastLandscapeConfigure TaskResult
+
+	// ServiceVersion is the version of the WSL-Pro-Service, as reported the last time it connected.
+	ServiceVersion string
+	// WSLVersion is the version of WSL the distro runs on (1 or 2), or 0 if unknown.
+	WSLVersion uint8
+}
+
+// TaskResult is the outcome of a task.
+type TaskResult struct {
+	Time time.Time
+	// Error is the reason the task failed, or empty if it succeeded.
+	Error string
+}
+
+// WithRecords is an optional parameter for distro.New that sets the records of a
+// distro that is already known, such as one loaded from the database.
+func WithRecords(r Records) Option {
+	return func(o *options) {
+		o.records = r
+	}
+}
+
+// WithOnRecordsChange is an optional parameter for distro.New to be notified when the
+// records change, so that they can be persisted.
+func WithOnRecordsChange(f func()) Option {
+	return func(o *options) {
+		o.onRecordsChange = f
+	}
+}
+
+// Records is a getter for the distro's Records.
+func (d *Distro) Records() Records {
+	d.recordsMu.RLock()
+	defer d.recordsMu.RUnlock()
+
+	return d.records
+}
+
+// RecordConnection records that the WSL-Pro-Service of the distro connected to the agent,
+// reporting the specified version.
+func (d *Distro) RecordConnection(ctx context.Context, serviceVersion string) {
+	wslVersion := d.wslVersion(ctx)
+
+	d.updateRecords(func(r *Records) {
+		r.LastConnected = time.Now()
+		r.ServiceVersion = serviceVersion
+		if wslVersion != 0 {
+			r.WSLVersion = wslVersion
+		}
+	})
+}
+
+// RecordTaskResult records the outcome of the tasks that are tracked in the distro's Records.
+// Other tasks are ignored.
+func (d *Distro) RecordTaskResult(t task.Task, taskErr error) {
+	result := TaskResult{Time: time.Now()}
+	if taskErr != nil {
+		result.Error = taskErr.Error()
+	}
+
+	typeID := task.TypeID(t)
+	if _, ok := new(Records).resultOf(typeID); !ok {
+		return
+	}
+
+	d.updateRecords(func(r *Records) {
+		field, _ := r.resultOf(typeID)
+		*field = result
+	})
+}
+
+// LastResult returns the outcome of the last task of the same type as t, if it is tracked in the Records.
+func (r Records) LastResult(t task.Task) (TaskResult, bool) {
+	result, ok := r.resultOf(task.TypeID(t))
+	if !ok {
+		return TaskResult{}, false
+	}
+	return *result, true
+}
+
+// resultOf returns the field of the Records tracking the outcome of the tasks with the specified type ID.
+func (r *Records) resultOf(typeID string) (*TaskResult, bool) {
+	switch typeID {
+	case task.TypeID(tasks.ProAttachment{}):
+		return &r.LastProAttachment, true
+	case task.TypeID(tasks.LandscapeConfigure{}):
+		return &r.LastLandscapeConfigure, true
+	}
+
+	return nil, false
+}
+
+// updateRecords modifies the records and notifies the change.
+func (d *Distro) updateRecords(update func(*Records)) {
+	func() {
+		d.recordsMu.Lock()
+		defer d.recordsMu.Unlock()
+
+		update(&d.records)
+	}()
+
+	if d.onRecordsChange != nil {
+		d.onRecordsChange()
+	}
+}
+
+// wslVersion returns the version of WSL the distro runs on, or 0 if it cannot be determined.
+func (d *Distro) wslVersion(ctx context.Context) uint8 {
+	conf, err := wsl.NewDistro(d.ctx, d.Name()).GetConfiguration()
+	if err != nil {
+		log.Warningf(ctx, "Distro %q: could not determine WSL version: %v", d.Name(), err)
+		return 0
+	}
+
+	return conf.Version
+}
@@ -2,7 +2,8 @@
 
 // Package touchdistro exists to provide multiple, mockable implementations
 // for the actions of touching a distro, i.e. sending a short-lived command so
-// as to wake it up, and waiting for distro initialisation with cloud-init.
+// as to wake it up, keeping it awake with a long-lived process, and waiting for
+// distro initialisation with cloud-init.
 package touchdistro
 
 import (
@@ -36,6 +37,34 @@ func Touch(ctx context.Context, distroName string) error {
 	return nil
 }
 
+// KeepAlive starts a "sleep infinity" command in the distro. The returned wait function blocks until
+// the context is done, in which case it returns the context's error, or until the command dies, for
+// instance because the distro was terminated. It returns wslDistroNotFoundError when the distroName
+// contains the unregister magic word, to ease testing.
+func KeepAlive(ctx context.Context, distroName string) (wait func() error, err error) {
+	if strings.HasSuffix(distroName, "unregistered-late") {
+		return nil, &wslDistroNotFoundError{errors.New(distroName)}
+	}
+	d := wsl.NewDistro(ctx, distroName)
+
+	cmd := d.Command(ctx, "sleep infinity")
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start keep-alive process: %v", err)
+	}
+
+	return func() error {
+		err := cmd.Wait()
+		if ctx.Err() != nil {
+			// The mock only starts the distro's inactivity timer when it is touched: we do it so that the
+			// distro stops eventually, as it would once the last process in it exits.
+			_ = Touch(context.WithoutCancel(ctx), distroName)
+			return ctx.Err()
+		}
+
+		return fmt.Errorf("keep-alive process exited: %v", err)
+	}, nil
+}
+
 // WaitForCloudInit sends a "exit 0" command to a distro because tests are not really interested in details of a cloud-init run.
 func WaitForCloudInit(ctx context.Context, distroName string) error {
 	d := wsl.NewDistro(ctx, distroName)
@@ -2,15 +2,18 @@
 
 // Package touchdistro exists to provide multiple, mockable implementations
 // for the actions of touching a distro, i.e. sending a short-lived command so
-// as to wake it up, and waiting for distro initialisation with cloud-init.
+// as to wake it up, keeping it awake with a long-lived process, and waiting for
+// distro initialisation with cloud-init.
 package touchdistro
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os/exec"
 	"strings"
 	"syscall"
+	"time"
 )
 
 // Touch sends a "exit 0" command to a distro in order to wake it up.
@@ -27,6 +30,43 @@ func Touch(ctx context.Context, distroName string) error {
 	return nil
 }
 
+// KeepAlive starts a long-lived process in the distro, which keeps it awake for as long as the process
+// lives. The returned wait function blocks until the context is done, in which case it returns the
+// context's error, or until the process dies, for instance because the distro was terminated.
+func KeepAlive(ctx context.Context, distroName string) (wait func() error, err error) {
+	// The process does nothing but wait for its input to be closed, which also happens if wsl.exe dies.
+	cmd := wslCmd(ctx, distroName, "/bin/sh", "-c", "exec cat >/dev/null")
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("could not create keep-alive process: %v", err)
+	}
+
+	// On cancellation, we let the process exit on its own before killing it.
+	cmd.Cancel = stdin.Close
+	cmd.WaitDelay = 5 * time.Second
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start keep-alive process: %v", err)
+	}
+
+	return func() error {
+		err := cmd.Wait()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if strings.Contains(out.String(), "Wsl/Service/WSL_E_DISTRO_NOT_FOUND") {
+			return &wslDistroNotFoundError{fmt.Errorf("keep-alive process exited: %v", err)}
+		}
+		return fmt.Errorf("keep-alive process exited: %v. Output: %s", err, out.String())
+	}, nil
+}
+
 // WaitForCloudInit blocks the caller until cloud-init has finished initialising the distro.
 func WaitForCloudInit(ctx context.Context, distroName string) error {
 	// Wait for cloud-init to finish if systemd and its service is enabled.
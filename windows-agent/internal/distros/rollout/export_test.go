@@ -0,0 +1,11 @@
+package rollout
+
+import "time"
+
+// SetPollInterval overrides the interval between checks of the results of a stage.
+func (c *Controller) SetPollInterval(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.poll = d
+}
@@ -0,0 +1,331 @@
+// Package rollout delivers configuration changes to the distros in stages, so that a bad change is
+// detected on a few of them and rolled back before it reaches the whole fleet.
+package rollout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+// DefaultStageTimeout is how long the distros in a stage have to report their results when the policy does not say.
+const DefaultStageTimeout = 10 * time.Minute
+
+// Policy is the set of rules that rollouts of configuration changes abide by.
+// The zero value submits changes to all distros at once, without waiting for their results.
+type Policy struct {
+	// Canaries is the number of distros that receive the change before any other.
+	Canaries int
+
+	// BatchSize is the number of distros that receive the change in each stage after the canaries.
+	// Zero means all the remaining distros at once.
+	BatchSize int
+
+	// SuccessThreshold is the percentage of distros in a stage that must report success for the rollout
+	// to go on with the next stage. Otherwise, it is halted and rolled back. Distros that do not report
+	// in time, such as stopped ones, count against it.
+	SuccessThreshold int
+
+	// MaxFailures is the number of distros that may fail to apply the change before the rollout is
+	// halted and rolled back.
+	MaxFailures int
+
+	// StageTimeout is how long the distros in a stage have to report their results. Zero means DefaultStageTimeout.
+	StageTimeout time.Duration
+}
+
+// Staged returns true if the policy rolls changes out in stages rather than to all distros at once.
+func (p Policy) Staged() bool {
+	return p.Canaries > 0 || p.BatchSize > 0
+}
+
+// stages splits the distros into the canaries and the batches that follow them.
+func (p Policy) stages(distros []Distro) (stages [][]Distro) {
+	if n := min(p.Canaries, len(distros)); n > 0 {
+		stages = append(stages, distros[:n])
+		distros = distros[n:]
+	}
+
+	size := p.BatchSize
+	if size <= 0 {
+		size = len(distros)
+	}
+
+	for len(distros) > 0 {
+		n := min(size, len(distros))
+		stages = append(stages, distros[:n])
+		distros = distros[n:]
+	}
+
+	return stages
+}
+
+// Distro is a distro that changes are rolled out to. It is implemented by *distro.Distro.
+type Distro interface {
+	Name() string
+	Labels() []string
+	SubmitTasks(...task.Task) error
+	Records() distro.Records
+}
+
+// Change is a configuration change to roll out.
+type Change struct {
+	// Name describes the change in the logs.
+	Name string
+
+	// Apply and Revert return the tasks that apply the change to a distro and undo it.
+	// The outcome of the tasks returned by Apply must be tracked in the distro's Records.
+	Apply, Revert func(Distro) (task.Task, error)
+
+	// RollBack is called when the rollout is rolled back, before reverting the change in the distros
+	// that received it. It is optional.
+	RollBack func() error
+}
+
+// Controller runs the rollouts of one kind of change. Starting a rollout cancels the one in
+// progress, as the new change supersedes it.
+//
+// While a rollout is in progress, the distros it has not reached yet keep the value before the change:
+// see Reached.
+type Controller struct {
+	ctx context.Context
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// reached contains the names of the distros that the rollout in progress submitted the change to.
+	// It is nil when no rollout is in progress, as the change is then in effect for every distro.
+	reached   map[string]bool
+	reachedMu sync.RWMutex
+
+	// poll is the interval between checks of the results of a stage.
+	poll time.Duration
+}
+
+// New creates a controller for rollouts. Rollouts in progress are cancelled when the context is.
+func New(ctx context.Context) *Controller {
+	done := make(chan struct{})
+	close(done)
+
+	return &Controller{
+		ctx:    ctx,
+		cancel: func() {},
+		done:   done,
+		poll:   time.Second,
+	}
+}
+
+// Start cancels the rollout in progress, if any, and rolls the change out to the distros according to the policy.
+// Unless the policy is staged, the change is submitted to all distros before returning. Otherwise, the stages
+// run in the background.
+func (c *Controller) Start(ctx context.Context, p Policy, distros []Distro, ch Change) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cancel()
+	<-c.done
+
+	if !p.Staged() {
+		c.setReached(nil)
+		log.Debugf(ctx, "Rollout: submitting %s to %d distros", ch.Name, len(distros))
+		if _, err := submit(distros, ch.Apply); err != nil {
+			log.Warningf(ctx, "Rollout: could not submit %s to all distros: %v", ch.Name, err)
+		}
+		return
+	}
+
+	// No distro has the change until its stage begins.
+	c.setReached(map[string]bool{})
+
+	// Rollouts outlive the request that started them, so they hang from the controller's context instead.
+	rctx, cancel := context.WithCancel(c.ctx)
+	done := make(chan struct{})
+	c.cancel = cancel
+	c.done = done
+
+	go func() {
+		defer close(done)
+		defer cancel()
+		c.run(rctx, p, slices.Clone(distros), ch)
+	}()
+}
+
+// Reached returns true if the change is in effect for the distro: either no rollout is in progress, or the
+// rollout in progress already submitted the change to it. Otherwise, the distro must keep the value before
+// the change, including when it connects or is registered in the meantime.
+func (c *Controller) Reached(distroName string) bool {
+	c.reachedMu.RLock()
+	defer c.reachedMu.RUnlock()
+
+	return c.reached == nil || c.reached[distroName]
+}
+
+// setReached replaces the set of distros that the change is in effect for. Nil means all of them.
+func (c *Controller) setReached(reached map[string]bool) {
+	c.reachedMu.Lock()
+	defer c.reachedMu.Unlock()
+
+	c.reached = reached
+}
+
+// reach marks the change as in effect for the distros.
+func (c *Controller) reach(distros []Distro) {
+	c.reachedMu.Lock()
+	defer c.reachedMu.Unlock()
+
+	for _, d := range distros {
+		c.reached[d.Name()] = true
+	}
+}
+
+// Wait blocks until the rollout in progress, if any, finishes.
+func (c *Controller) Wait() {
+	c.mu.Lock()
+	done := c.done
+	c.mu.Unlock()
+
+	<-done
+}
+
+// run rolls the change out stage by stage, halting if a stage does not succeed.
+func (c *Controller) run(ctx context.Context, p Policy, distros []Distro, ch Change) {
+	slices.SortFunc(distros, func(a, b Distro) int { return strings.Compare(a.Name(), b.Name()) })
+
+	timeout := p.StageTimeout
+	if timeout <= 0 {
+		timeout = DefaultStageTimeout
+	}
+
+	stages := p.stages(distros)
+	log.Infof(ctx, "Rollout: rolling %s out to %d distros in %d stages", ch.Name, len(distros), len(stages))
+
+	var updated []Distro
+	var failures int
+	for i, stage := range stages {
+		since := time.Now()
+		c.reach(stage)
+		submitted, err := submit(stage, ch.Apply)
+		if err != nil {
+			log.Warningf(ctx, "Rollout: could not submit %s to all distros in stage %d: %v", ch.Name, i+1, err)
+		}
+		for _, s := range submitted {
+			updated = append(updated, s.distro)
+		}
+
+		succeeded, failed := c.await(ctx, submitted, since, timeout)
+		if ctx.Err() != nil {
+			log.Infof(ctx, "Rollout: %s was cancelled during stage %d", ch.Name, i+1)
+			return
+		}
+
+		failures += failed
+		log.Debugf(ctx, "Rollout: stage %d of %s: %d of %d distros succeeded, %d failed", i+1, ch.Name, succeeded, len(stage), failed)
+
+		if failures > p.MaxFailures {
+			log.Errorf(ctx, "Rollout: halting %s after %d distros failed to apply it, rolling back %d distros", ch.Name, failures, len(updated))
+			c.rollBack(ctx, updated, ch)
+			return
+		}
+
+		if succeeded*100 < p.SuccessThreshold*len(stage) {
+			log.Errorf(ctx, "Rollout: halting %s at stage %d: only %d of %d distros succeeded, rolling back %d distros", ch.Name, i+1, succeeded, len(stage), len(updated))
+			c.rollBack(ctx, updated, ch)
+			return
+		}
+	}
+
+	c.setReached(nil)
+	log.Infof(ctx, "Rollout: %s reached all distros", ch.Name)
+}
+
+// submission is a task submitted to a distro.
+type submission struct {
+	distro Distro
+	task   task.Task
+}
+
+// submit submits the task returned by newTask to each distro, returning the successful submissions.
+func submit(distros []Distro, newTask func(Distro) (task.Task, error)) (submitted []submission, err error) {
+	for _, d := range distros {
+		t, e := newTask(d)
+		if e == nil {
+			e = d.SubmitTasks(t)
+		}
+		if e != nil {
+			err = errors.Join(err, fmt.Errorf("%s: %v", d.Name(), e))
+			continue
+		}
+
+		submitted = append(submitted, submission{distro: d, task: t})
+	}
+
+	return submitted, err
+}
+
+// await waits until all the submitted tasks have a result newer than since, or until the timeout expires.
+// It returns the number of tasks that succeeded and failed.
+func (c *Controller) await(ctx context.Context, submitted []submission, since time.Time, timeout time.Duration) (succeeded, failed int) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	ticker := time.NewTicker(c.poll)
+	defer ticker.Stop()
+
+	for {
+		succeeded, failed = 0, 0
+		for _, s := range submitted {
+			r, ok := s.distro.Records().LastResult(s.task)
+			if !ok || r.Time.Before(since) {
+				continue
+			}
+			if r.Error != "" {
+				failed++
+				continue
+			}
+			succeeded++
+		}
+
+		if succeeded+failed == len(submitted) {
+			return succeeded, failed
+		}
+
+		select {
+		case <-ctx.Done():
+			return succeeded, failed
+		case <-timer.C:
+			return succeeded, failed
+		case <-ticker.C:
+		}
+	}
+}
+
+// rollBack reverts the change in the distros that received it. Once the previous value is back in effect,
+// the rollout no longer holds any distro back. Otherwise, every distro is held back on it.
+func (c *Controller) rollBack(ctx context.Context, updated []Distro, ch Change) {
+	var err error
+	if ch.RollBack != nil {
+		err = ch.RollBack()
+	}
+
+	if ch.RollBack == nil || err != nil {
+		if err != nil {
+			log.Warningf(ctx, "Rollout: %v", err)
+		}
+		c.setReached(map[string]bool{})
+	} else {
+		c.setReached(nil)
+	}
+
+	if _, err := submit(updated, ch.Revert); err != nil {
+		log.Warningf(ctx, "Rollout: could not roll %s back in all distros: %v", ch.Name, err)
+	}
+}
@@ -0,0 +1,228 @@
+package rollout_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/rollout"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart(t *testing.T) {
+	t.Parallel()
+
+	const (
+		oldToken = "OLD_TOKEN"
+		newToken = "NEW_TOKEN"
+	)
+
+	testCases := map[string]struct {
+		policy        rollout.Policy
+		failing       []string
+		silent        []string
+		breakTask     bool
+		breakRollBack bool
+
+		wantTokens   map[string][]string
+		wantRollBack bool
+		wantHeldBack bool
+	}{
+		"Success submitting to all distros at once": {
+			failing:    []string{"a"},
+			wantTokens: map[string][]string{"a": {newToken}, "b": {newToken}, "c": {newToken}, "d": {newToken}, "e": {newToken}},
+		},
+		"Success rolling out in stages": {
+			policy:     rollout.Policy{Canaries: 1, BatchSize: 2, SuccessThreshold: 100},
+			wantTokens: map[string][]string{"a": {newToken}, "b": {newToken}, "c": {newToken}, "d": {newToken}, "e": {newToken}},
+		},
+		"Success tolerating failures up to the limit": {
+			policy:     rollout.Policy{Canaries: 1, BatchSize: 2, MaxFailures: 1},
+			failing:    []string{"c"},
+			wantTokens: map[string][]string{"a": {newToken}, "b": {newToken}, "c": {newToken}, "d": {newToken}, "e": {newToken}},
+		},
+		"Success rolling back when the canary fails": {
+			policy:       rollout.Policy{Canaries: 1, BatchSize: 2},
+			failing:      []string{"a"},
+			wantTokens:   map[string][]string{"a": {newToken, oldToken}},
+			wantRollBack: true,
+		},
+		"Success rolling back the previous stages when a batch fails": {
+			policy:       rollout.Policy{Canaries: 1, BatchSize: 2},
+			failing:      []string{"c"},
+			wantTokens:   map[string][]string{"a": {newToken, oldToken}, "b": {newToken, oldToken}, "c": {newToken, oldToken}},
+			wantRollBack: true,
+		},
+		"Success rolling back when a stage does not reach the success threshold": {
+			policy:       rollout.Policy{Canaries: 1, BatchSize: 2, SuccessThreshold: 100, StageTimeout: 500 * time.Millisecond},
+			silent:       []string{"b"},
+			wantTokens:   map[string][]string{"a": {newToken, oldToken}, "b": {newToken, oldToken}, "c": {newToken, oldToken}},
+			wantRollBack: true,
+		},
+		"Success holding every distro back when the previous value cannot be put back in effect": {
+			policy:        rollout.Policy{Canaries: 1, BatchSize: 2},
+			failing:       []string{"a"},
+			breakRollBack: true,
+			wantTokens:    map[string][]string{"a": {newToken, oldToken}},
+			wantRollBack:  true,
+			wantHeldBack:  true,
+		},
+		"Success skipping distros where the change cannot be submitted": {
+			policy:     rollout.Policy{Canaries: 1},
+			breakTask:  true,
+			wantTokens: map[string][]string{"b": {newToken}, "c": {newToken}, "d": {newToken}, "e": {newToken}},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// Out of order to ensure that stages are sorted by name.
+			var distros []rollout.Distro
+			fakes := map[string]*fakeDistro{}
+			for _, n := range []string{"d", "b", "e", "a", "c"} {
+				d := &fakeDistro{name: n, fail: slices.Contains(tc.failing, n), silent: slices.Contains(tc.silent, n)}
+				fakes[n] = d
+				distros = append(distros, d)
+			}
+
+			var rolledBack bool
+			change := rollout.Change{
+				Name: "test token",
+				Apply: func(d rollout.Distro) (task.Task, error) {
+					if tc.breakTask && d.Name() == "a" {
+						return nil, errors.New("mock error")
+					}
+					return tasks.ProAttachment{Token: newToken}, nil
+				},
+				Revert: func(d rollout.Distro) (task.Task, error) {
+					return tasks.ProAttachment{Token: oldToken}, nil
+				},
+				RollBack: func() error {
+					rolledBack = true
+					if tc.breakRollBack {
+						return errors.New("mock error")
+					}
+					return nil
+				},
+			}
+
+			c := rollout.New(ctx)
+			c.SetPollInterval(10 * time.Millisecond)
+
+			c.Start(ctx, tc.policy, distros, change)
+			c.Wait()
+
+			for n, d := range fakes {
+				require.Equal(t, tc.wantTokens[n], d.tokens(), "Unexpected tokens submitted to distro %q", n)
+			}
+			require.Equal(t, tc.wantRollBack, rolledBack, "RollBack should only be called when the rollout is rolled back")
+
+			for _, n := range []string{"a", "e", "unknown"} {
+				require.Equal(t, !tc.wantHeldBack, c.Reached(n), "Distro %q should only be held back on the previous value when it could not be put back in effect", n)
+			}
+		})
+	}
+}
+
+func TestStartSupersedesRolloutInProgress(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	canary := &fakeDistro{name: "a", silent: true}
+	other := &fakeDistro{name: "b"}
+	distros := []rollout.Distro{canary, other}
+
+	change := func(token string) rollout.Change {
+		return rollout.Change{
+			Name:   token,
+			Apply:  func(rollout.Distro) (task.Task, error) { return tasks.ProAttachment{Token: token}, nil },
+			Revert: func(rollout.Distro) (task.Task, error) { return tasks.ProAttachment{}, nil },
+		}
+	}
+
+	c := rollout.New(ctx)
+	c.SetPollInterval(10 * time.Millisecond)
+
+	// The canary never reports, so the first rollout would wait for it for the whole stage timeout.
+	c.Start(ctx, rollout.Policy{Canaries: 1, StageTimeout: time.Hour}, distros, change("first"))
+	require.Eventually(t, func() bool { return len(canary.tokens()) == 1 }, time.Second, 10*time.Millisecond,
+		"The canary should have received the first change")
+
+	require.True(t, c.Reached("a"), "The change should be in effect for the canary")
+	require.False(t, c.Reached("b"), "Distros in later stages should keep the previous value")
+	require.False(t, c.Reached("unknown"), "Distros that are not part of the rollout should keep the previous value")
+
+	c.Start(ctx, rollout.Policy{}, distros, change("second"))
+	c.Wait()
+
+	require.Equal(t, []string{"first", "second"}, canary.tokens(), "The canary should have received both changes")
+	require.Equal(t, []string{"second"}, other.tokens(), "The first rollout should not have reached the other distro")
+	require.True(t, c.Reached("b"), "Changes submitted to all distros at once should be in effect for all of them")
+}
+
+// fakeDistro records the Pro tokens submitted to it, and reports a result for each of them
+// unless it is silent.
+type fakeDistro struct {
+	name   string
+	fail   bool
+	silent bool
+
+	mu        sync.Mutex
+	records   distro.Records
+	submitted []string
+}
+
+func (d *fakeDistro) Name() string {
+	return d.name
+}
+
+func (d *fakeDistro) Labels() []string {
+	return nil
+}
+
+func (d *fakeDistro) SubmitTasks(ts ...task.Task) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, t := range ts {
+		d.submitted = append(d.submitted, t.(tasks.ProAttachment).Token)
+		if d.silent {
+			continue
+		}
+
+		result := distro.TaskResult{Time: time.Now()}
+		if d.fail {
+			result.Error = "mock error"
+		}
+		d.records.LastProAttachment = result
+	}
+
+	return nil
+}
+
+func (d *fakeDistro) Records() distro.Records {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.records
+}
+
+func (d *fakeDistro) tokens() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return slices.Clone(d.submitted)
+}
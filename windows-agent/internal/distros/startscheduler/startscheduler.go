@@ -0,0 +1,259 @@
+// Package startscheduler implements the admission control of distro starts. Multiple distros
+// starting at the same time can cause WSL (and the whole machine) to freeze up, so starts are
+// limited to a few at a time, and the most urgent ones go first.
+package startscheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Priority is the urgency of a start request. Requests with a higher priority are admitted first.
+type Priority int
+
+const (
+	// Background is the priority of starts that nobody is waiting for, such as those needed to run tasks.
+	Background Priority = iota
+
+	// Interactive is the priority of starts requested by a user or by Landscape.
+	Interactive
+
+	numPriorities
+)
+
+func (p Priority) String() string {
+	switch p {
+	case Background:
+		return "background"
+	case Interactive:
+		return "interactive"
+	default:
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+}
+
+const (
+	// DefaultParallelism is the number of distros allowed to start at the same time by default.
+	DefaultParallelism = 1
+
+	// DefaultTimeout is the time a start request is allowed to take when its context has no deadline,
+	// including the time spent queued.
+	DefaultTimeout = 5 * time.Minute
+)
+
+// Metrics is a snapshot of the state of the scheduler.
+type Metrics struct {
+	// Parallelism is the number of starts allowed at the same time.
+	Parallelism int
+
+	// Running is the number of starts in progress.
+	Running int
+
+	// Queued is the number of requests waiting to be admitted, per priority.
+	Queued map[Priority]int
+
+	// MaxQueued is the highest number of requests that were waiting at the same time.
+	MaxQueued int
+
+	// Admitted is the number of requests that were admitted.
+	Admitted uint64
+
+	// TimedOut is the number of requests that timed out or were cancelled before being admitted.
+	TimedOut uint64
+}
+
+// request is a start request waiting in the queue.
+type request struct {
+	// admitted is closed when the request is admitted.
+	admitted chan struct{}
+}
+
+// Scheduler admits distro starts, a limited number at a time and by priority.
+type Scheduler struct {
+	mu sync.Mutex
+
+	parallelism int
+
+	running int
+	queues  [numPriorities][]*request
+
+	maxQueued int
+	admitted  uint64
+	timedOut  uint64
+}
+
+type options struct {
+	parallelism int
+}
+
+// Option is an optional argument for New.
+type Option func(*options)
+
+// WithParallelism sets the number of distros allowed to start at the same time. Values lower than 1 are ignored.
+func WithParallelism(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.parallelism = n
+		}
+	}
+}
+
+// New creates a new start scheduler.
+func New(args ...Option) *Scheduler {
+	opts := options{
+		parallelism: DefaultParallelism,
+	}
+
+	for _, f := range args {
+		f(&opts)
+	}
+
+	return &Scheduler{
+		parallelism: opts.parallelism,
+	}
+}
+
+// Run waits until the request is admitted, then calls start with a context that expires with the request.
+// Requests with the same priority are admitted in order of arrival.
+//
+// The request expires with the deadline of the context, or after DefaultTimeout if it has none. It fails if
+// it is not admitted before it expires or the context is cancelled, in which case start is not called.
+func (s *Scheduler) Run(ctx context.Context, p Priority, start func(context.Context) error) error {
+	if p < 0 || p >= numPriorities {
+		return fmt.Errorf("invalid start priority %s", p)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+
+	if err := s.acquire(ctx, p); err != nil {
+		return err
+	}
+	defer s.release()
+
+	return start(ctx)
+}
+
+// SetParallelism changes the number of distros allowed to start at the same time. Values lower than 1 are ignored.
+// Lowering it does not interrupt the starts in progress.
+func (s *Scheduler) SetParallelism(n int) {
+	if n < 1 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.parallelism = n
+	s.dispatch()
+}
+
+// Metrics returns a snapshot of the state of the scheduler.
+func (s *Scheduler) Metrics() Metrics {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := Metrics{
+		Parallelism: s.parallelism,
+		Running:     s.running,
+		Queued:      make(map[Priority]int, numPriorities),
+		MaxQueued:   s.maxQueued,
+		Admitted:    s.admitted,
+		TimedOut:    s.timedOut,
+	}
+
+	for p, q := range s.queues {
+		m.Queued[Priority(p)] = len(q)
+	}
+
+	return m
+}
+
+// acquire blocks until the request is admitted or the context is done.
+func (s *Scheduler) acquire(ctx context.Context, p Priority) error {
+	s.mu.Lock()
+
+	if s.running < s.parallelism && s.queued() == 0 {
+		s.running++
+		s.admitted++
+		s.mu.Unlock()
+		return nil
+	}
+
+	r := &request{admitted: make(chan struct{})}
+	s.queues[p] = append(s.queues[p], r)
+	s.maxQueued = max(s.maxQueued, s.queued())
+	s.mu.Unlock()
+
+	select {
+	case <-r.admitted:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	select {
+	case <-r.admitted:
+		// Admitted at the same time as the context was done: the slot goes to the next request.
+		s.running--
+		s.admitted--
+		s.dispatch()
+	default:
+		s.remove(p, r)
+	}
+	s.timedOut++
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return errors.New("timed out waiting for other distros to start")
+	}
+	return ctx.Err()
+}
+
+// release frees the slot of an admitted request.
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running--
+	s.dispatch()
+}
+
+// dispatch admits queued requests, highest priority first, while there are free slots.
+// It must be called with the mutex held.
+func (s *Scheduler) dispatch() {
+	for p := numPriorities - 1; p >= 0; p-- {
+		for s.running < s.parallelism && len(s.queues[p]) > 0 {
+			r := s.queues[p][0]
+			s.queues[p] = s.queues[p][1:]
+			s.running++
+			s.admitted++
+			close(r.admitted)
+		}
+	}
+}
+
+// remove takes a request out of its queue. It must be called with the mutex held.
+func (s *Scheduler) remove(p Priority, r *request) {
+	for i := range s.queues[p] {
+		if s.queues[p][i] == r {
+			s.queues[p] = append(s.queues[p][:i], s.queues[p][i+1:]...)
+			return
+		}
+	}
+}
+
+// queued returns the number of requests waiting to be admitted. It must be called with the mutex held.
+func (s *Scheduler) queued() (n int) {
+	for _, q := range s.queues {
+		n += len(q)
+	}
+	return n
+}
@@ -0,0 +1,250 @@
+package startscheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelism(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		parallelism int
+
+		wantMaxRunning int32
+	}{
+		"Success with the default parallelism": {wantMaxRunning: startscheduler.DefaultParallelism},
+		"Success with a parallelism of 3":      {parallelism: 3, wantMaxRunning: 3},
+		"Success ignoring invalid parallelism": {parallelism: -1, wantMaxRunning: startscheduler.DefaultParallelism},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := startscheduler.New(startscheduler.WithParallelism(tc.parallelism))
+
+			var running, maxRunning atomic.Int32
+			var wg sync.WaitGroup
+			for range 10 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err := s.Run(context.Background(), startscheduler.Background, func(context.Context) error {
+						n := running.Add(1)
+						defer running.Add(-1)
+
+						for {
+							m := maxRunning.Load()
+							if n <= m || maxRunning.CompareAndSwap(m, n) {
+								break
+							}
+						}
+
+						time.Sleep(50 * time.Millisecond)
+						return nil
+					})
+					require.NoError(t, err, "Run should not return an error")
+				}()
+			}
+			wg.Wait()
+
+			require.Equal(t, tc.wantMaxRunning, maxRunning.Load(), "Unexpected number of starts running at the same time")
+
+			m := s.Metrics()
+			require.Equal(t, uint64(10), m.Admitted, "All requests should have been admitted")
+			require.Zero(t, m.Running, "No start should be running after all requests return")
+			require.Positive(t, m.MaxQueued, "Some requests should have been queued")
+		})
+	}
+}
+
+func TestPriority(t *testing.T) {
+	t.Parallel()
+
+	s := startscheduler.New()
+
+	// Occupy the only slot so that the following requests are queued.
+	blocking := make(chan struct{})
+	blocked := make(chan struct{})
+	go func() {
+		//nolint:errcheck // The result is irrelevant
+		s.Run(context.Background(), startscheduler.Background, func(context.Context) error {
+			close(blocked)
+			<-blocking
+			return nil
+		})
+	}()
+	<-blocked
+
+	var mu sync.Mutex
+	var order []string
+
+	var wg sync.WaitGroup
+	requests := []struct {
+		name string
+		p    startscheduler.Priority
+	}{
+		{"background 1", startscheduler.Background},
+		{"interactive 1", startscheduler.Interactive},
+		{"background 2", startscheduler.Background},
+		{"interactive 2", startscheduler.Interactive},
+	}
+	for i, r := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.Run(context.Background(), r.p, func(context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, r.name)
+				return nil
+			})
+			require.NoError(t, err, "Run should not return an error")
+		}()
+
+		// Wait for the request to be queued, so that the arrival order is deterministic.
+		require.Eventually(t, func() bool { return queued(s) == i+1 },
+			time.Second, time.Millisecond, "Setup: request %q should have been queued", r.name)
+	}
+
+	m := s.Metrics()
+	require.Equal(t, 2, m.Queued[startscheduler.Background], "Unexpected number of queued background requests")
+	require.Equal(t, 2, m.Queued[startscheduler.Interactive], "Unexpected number of queued interactive requests")
+	require.Equal(t, 1, m.Running, "Only the blocking request should be running")
+
+	close(blocking)
+	wg.Wait()
+
+	require.Equal(t, []string{"interactive 1", "interactive 2", "background 1", "background 2"}, order,
+		"Interactive requests should be admitted first, then in order of arrival")
+}
+
+// queued returns the number of requests waiting in the scheduler.
+func queued(s *startscheduler.Scheduler) (n int) {
+	for _, q := range s.Metrics().Queued {
+		n += q
+	}
+	return n
+}
+
+func TestRunErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		cancelCtx       bool
+		invalidPriority bool
+		startErr        bool
+
+		wantTimedOut uint64
+	}{
+		"Error when the request times out":    {wantTimedOut: 1},
+		"Error when the context is cancelled": {cancelCtx: true, wantTimedOut: 1},
+		"Error when the priority is invalid":  {invalidPriority: true},
+		"Error when the start function fails": {startErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := startscheduler.New()
+
+			if !tc.startErr && !tc.invalidPriority {
+				// Occupy the only slot so that the request is queued.
+				blocking := make(chan struct{})
+				defer close(blocking)
+				blocked := make(chan struct{})
+				go func() {
+					//nolint:errcheck // The result is irrelevant
+					s.Run(context.Background(), startscheduler.Background, func(context.Context) error {
+						close(blocked)
+						<-blocking
+						return nil
+					})
+				}()
+				<-blocked
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+			defer cancel()
+			if tc.cancelCtx {
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					cancel()
+				}()
+			}
+
+			p := startscheduler.Interactive
+			if tc.invalidPriority {
+				p = startscheduler.Priority(42)
+			}
+
+			var called bool
+			err := s.Run(ctx, p, func(context.Context) error {
+				called = true
+				if tc.startErr {
+					return errors.New("mock error")
+				}
+				return nil
+			})
+			require.Error(t, err, "Run should return an error")
+
+			if tc.cancelCtx {
+				require.ErrorIs(t, err, context.Canceled, "Run should return the error of the cancelled context")
+			}
+
+			require.Equal(t, tc.startErr, called, "The start function should only be called once the request is admitted")
+
+			m := s.Metrics()
+			require.Equal(t, tc.wantTimedOut, m.TimedOut, "Unexpected number of requests that timed out")
+			require.Zero(t, m.Queued[startscheduler.Interactive], "The failed request should not remain queued")
+		})
+	}
+}
+
+func TestSetParallelism(t *testing.T) {
+	t.Parallel()
+
+	s := startscheduler.New()
+
+	blocking := make(chan struct{})
+	defer close(blocking)
+
+	started := make(chan struct{}, 2)
+	for range 2 {
+		go func() {
+			//nolint:errcheck // The result is irrelevant
+			s.Run(context.Background(), startscheduler.Background, func(context.Context) error {
+				started <- struct{}{}
+				<-blocking
+				return nil
+			})
+		}()
+	}
+
+	<-started
+	require.Eventually(t, func() bool { return s.Metrics().Queued[startscheduler.Background] == 1 },
+		time.Second, time.Millisecond, "Setup: the second request should have been queued")
+
+	s.SetParallelism(0)
+	require.Equal(t, startscheduler.DefaultParallelism, s.Metrics().Parallelism, "Invalid parallelism should be ignored")
+
+	s.SetParallelism(2)
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		require.Fail(t, "Raising the parallelism should admit the queued request")
+	}
+
+	m := s.Metrics()
+	require.Equal(t, 2, m.Parallelism, "Parallelism should have been updated")
+	require.Equal(t, 2, m.Running, "Both requests should be running")
+}
@@ -0,0 +1,30 @@
+package task
+
+import "time"
+
+// Command is a command to run in a distro.
+type Command struct {
+	// Argv is the executable followed by its arguments.
+	Argv []string
+	// Env is a list of KEY=VALUE pairs added to the environment of the command.
+	Env []string
+	// WorkingDir is the directory the command runs in. Empty means the default one.
+	WorkingDir string
+	// Timeout is how long the command may run before being killed. Zero means no timeout.
+	Timeout time.Duration
+	// User is the Linux user that runs the command. Empty means root.
+	User string
+	// Stdin is fed to the standard input of the command.
+	Stdin []byte
+}
+
+// CommandOutput is the outcome of a command that ran in a distro.
+type CommandOutput struct {
+	// ExitCode is the exit code of the command.
+	ExitCode int
+	// Stdout and Stderr are the beginning of the outputs of the command.
+	Stdout []byte
+	Stderr []byte
+	// Truncated is true if any of the outputs was too long to be sent back in full.
+	Truncated bool
+}
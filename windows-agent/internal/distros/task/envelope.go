@@ -0,0 +1,98 @@
+package task
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrExpired is the outcome of the tasks discarded because they expired before running.
+var ErrExpired = errors.New("task expired before it could run")
+
+// ErrCancelled is the outcome of the tasks cancelled before they could complete.
+var ErrCancelled = errors.New("task cancelled")
+
+// ErrPrerequisiteFailed is the outcome of the tasks discarded because a task of their group failed for good.
+var ErrPrerequisiteFailed = errors.New("a prerequisite task failed")
+
+// Envelope is a task along with the bookkeeping stored with it.
+type Envelope struct {
+	Task Task
+
+	// Priority is the precedence of the task in the queue: higher priorities run first.
+	Priority int
+	// Expires is the time after which the task is discarded instead of run. Zero means never.
+	Expires time.Time
+
+	// Submitted is the time the task was submitted.
+	Submitted time.Time
+	// Started is the time the current attempt started. It is not stored.
+	Started time.Time
+	// Report is what the task reported about the outcome of the current attempt. It is not stored.
+	Report string
+	// RebootRequired is true if the distro must be restarted for the current attempt to take effect. Ditto.
+	RebootRequired bool
+
+	// Attempts is the number of times the task failed and had to be retried.
+	Attempts int
+	// LastError is the error of the last failed attempt.
+	LastError string
+
+	// ID identifies the task so that other tasks can wait for it, and so that it can be cancelled.
+	// Tasks stored by older versions of the agent may have none.
+	ID string
+	// After are the IDs of the tasks that must complete before this one can run. It is dropped if any of them fails for good.
+	After []string
+	// WaitsFor are the IDs of the tasks that must be done before this one can run, whether they succeed or not.
+	WaitsFor []string
+	// Group identifies the tasks submitted together that fail together. Empty if the task is not in a group.
+	Group string
+}
+
+// taskWithPriority are tasks that implement the Priority method to run before or after others.
+type taskWithPriority interface {
+	Task
+	Priority() int
+}
+
+// taskWithTTL are tasks that implement the TTL method to be discarded when they could not run in time.
+type taskWithTTL interface {
+	Task
+	TTL() time.Duration
+}
+
+// taskWithDependencies are tasks that implement the DependsOn method to wait for the equivalent
+// tasks that are queued when they are submitted. They still run if those fail.
+type taskWithDependencies interface {
+	Task
+	DependsOn() []Task
+}
+
+// DependenciesOf returns the tasks that a task waits for: the ones returned by its DependsOn method,
+// or none if it has no such method.
+func DependenciesOf(t Task) []Task {
+	if T, ok := t.(taskWithDependencies); ok {
+		return T.DependsOn()
+	}
+	return nil
+}
+
+// NewEnvelope wraps a task that is being submitted now. Its priority is the one returned by its Priority
+// method, or zero if it has none. Likewise, it expires after the time returned by its TTL method, or never.
+func NewEnvelope(t Task) Envelope {
+	e := Envelope{Task: t, Submitted: time.Now()}
+
+	if T, ok := t.(taskWithPriority); ok {
+		e.Priority = T.Priority()
+	}
+
+	if T, ok := t.(taskWithTTL); ok && T.TTL() > 0 {
+		e.Expires = time.Now().Add(T.TTL())
+	}
+
+	return e
+}
+
+// Expired returns true if the task expired at the specified time.
+func (e Envelope) Expired(now time.Time) bool {
+	return !e.Expires.IsZero() && now.After(e.Expires)
+}
@@ -1,15 +1,19 @@
 package task
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func BackupRegistry(t *testing.T) {
 	t.Helper()
 
-	backup := registry
-	registry = make(map[string]decodeFunc)
+	backup, backupTypes := registry, registeredTypes
+	registry = make(map[string]*registration)
+	registeredTypes = make(map[reflect.Type]*registration)
 
 	t.Cleanup(func() {
-		registry = backup
+		registry, registeredTypes = backup, backupTypes
 	})
 }
 
@@ -0,0 +1,45 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// PackageInventory is the list of packages installed in a distro at some point in time.
+type PackageInventory struct {
+	// Collected is when the inventory was taken.
+	Collected time.Time
+	// Packages are the installed packages.
+	Packages []Package
+}
+
+// Package is a package installed in a distro.
+type Package struct {
+	Name         string
+	Version      string
+	Architecture string
+	// Origin is the suite the installed version comes from, empty if it is not available in any archive.
+	Origin string `yaml:",omitempty"`
+	// ESM is true if the installed version comes from the Expanded Security Maintenance archives.
+	ESM bool `yaml:",omitempty"`
+}
+
+// inventoryRecorderKey is the context key of the callback that stores the package inventories collected by a task.
+type inventoryRecorderKey struct{}
+
+// WithInventoryRecorder returns a context that passes the package inventories recorded by the task
+// that runs with it to the callback.
+func WithInventoryRecorder(ctx context.Context, record func(PackageInventory) error) context.Context {
+	return context.WithValue(ctx, inventoryRecorderKey{}, record)
+}
+
+// RecordInventory stores the package inventory collected by the task running with the context.
+// Unlike reports, inventories are not optional: recording fails if nobody keeps track of them.
+func RecordInventory(ctx context.Context, inv PackageInventory) error {
+	record, ok := ctx.Value(inventoryRecorderKey{}).(func(PackageInventory) error)
+	if !ok {
+		return errors.New("no package inventory recorder in context")
+	}
+	return record(inv)
+}
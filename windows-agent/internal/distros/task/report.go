@@ -0,0 +1,42 @@
+package task
+
+import (
+	"context"
+	"fmt"
+)
+
+// reporterKey is the context key of the callback that collects the reports of a task.
+type reporterKey struct{}
+
+// WithReporter returns a context that passes the reports of the task that runs with it to the callback.
+func WithReporter(ctx context.Context, report func(string)) context.Context {
+	return context.WithValue(ctx, reporterKey{}, report)
+}
+
+// Report describes the outcome of the task running with the context, for whoever keeps track of it.
+// Only the last report of each attempt is kept. Reports must not contain secrets.
+func Report(ctx context.Context, format string, args ...any) {
+	report, ok := ctx.Value(reporterKey{}).(func(string))
+	if !ok {
+		return
+	}
+	report(fmt.Sprintf(format, args...))
+}
+
+// rebootKey is the context key of the callback that collects the reboot requests of a task.
+type rebootKey struct{}
+
+// WithRebootNotifier returns a context that passes the reboot requests of the task that runs with it to the callback.
+func WithRebootNotifier(ctx context.Context, notify func()) context.Context {
+	return context.WithValue(ctx, rebootKey{}, notify)
+}
+
+// RequireReboot tells whoever keeps track of the task running with the context that the distro must be
+// restarted for the outcome of the current attempt to take effect.
+func RequireReboot(ctx context.Context) {
+	notify, ok := ctx.Value(rebootKey{}).(func())
+	if !ok {
+		return
+	}
+	notify()
+}
@@ -0,0 +1,72 @@
+package task
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// maxRetryDelay caps the delay between attempts, no matter how many times the task failed.
+const maxRetryDelay = 6 * time.Hour
+
+// RetryPolicy describes how a task that fails with a NeedsRetryError is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times the task is attempted before giving up. Zero means no limit.
+	MaxAttempts int
+
+	// InitialDelay is the time to wait after the first failed attempt.
+	InitialDelay time.Duration
+
+	// Factor is the growth of the delay after each failed attempt.
+	Factor float64
+
+	// Jitter is the fraction of the delay that is randomized, between 0 and 1.
+	Jitter float64
+}
+
+// DefaultRetryPolicy is the policy of the tasks that do not specify one.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  10,
+	InitialDelay: time.Minute,
+	Factor:       2,
+	Jitter:       0.2,
+}
+
+// taskWithRetryPolicy are tasks that implement the RetryPolicy method to override the default policy.
+type taskWithRetryPolicy interface {
+	Task
+	RetryPolicy() RetryPolicy
+}
+
+// RetryPolicyOf returns the retry policy of a task: the one returned by its RetryPolicy method if
+// it has one, or DefaultRetryPolicy otherwise.
+func RetryPolicyOf(t Task) RetryPolicy {
+	if T, ok := t.(taskWithRetryPolicy); ok {
+		return T.RetryPolicy()
+	}
+	return DefaultRetryPolicy
+}
+
+// Exhausted returns true if a task that failed that many attempts must not be retried anymore.
+func (p RetryPolicy) Exhausted(attempts int) bool {
+	return p.MaxAttempts > 0 && attempts >= p.MaxAttempts
+}
+
+// Delay returns the time to wait before retrying a task that failed that many attempts.
+func (p RetryPolicy) Delay(attempts int) time.Duration {
+	delay := float64(p.InitialDelay)
+	for range attempts - 1 {
+		delay *= max(p.Factor, 1)
+		if delay >= float64(maxRetryDelay) {
+			break
+		}
+	}
+	delay = min(delay, float64(maxRetryDelay))
+
+	if p.Jitter > 0 {
+		// Spread the delay uniformly over [delay*(1-jitter), delay*(1+jitter)]
+		//#nosec G404 // Jitter does not need a cryptographically secure random number.
+		delay *= 1 + min(p.Jitter, 1)*(2*rand.Float64()-1)
+	}
+
+	return time.Duration(delay)
+}
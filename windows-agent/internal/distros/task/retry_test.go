@@ -0,0 +1,107 @@
+package task_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	t.Parallel()
+
+	policy := task.RetryPolicy{InitialDelay: time.Second, Factor: 3}
+
+	testCases := map[string]struct {
+		policy   task.RetryPolicy
+		attempts int
+
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		"Initial delay after the first attempt":   {policy: policy, attempts: 1, wantMin: time.Second, wantMax: time.Second},
+		"Delay grows after each attempt":          {policy: policy, attempts: 3, wantMin: 9 * time.Second, wantMax: 9 * time.Second},
+		"Delay does not shrink with a low factor": {policy: task.RetryPolicy{InitialDelay: time.Second, Factor: 0.5}, attempts: 3, wantMin: time.Second, wantMax: time.Second},
+		"Delay is capped":                         {policy: policy, attempts: 1000, wantMin: 6 * time.Hour, wantMax: 6 * time.Hour},
+
+		"Jitter spreads the delay": {policy: task.RetryPolicy{InitialDelay: 10 * time.Second, Factor: 2, Jitter: 0.5}, attempts: 2, wantMin: 10 * time.Second, wantMax: 30 * time.Second},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			for range 100 {
+				got := tc.policy.Delay(tc.attempts)
+				require.GreaterOrEqual(t, got, tc.wantMin, "Delay should not be shorter than expected")
+				require.LessOrEqual(t, got, tc.wantMax, "Delay should not be longer than expected")
+			}
+		})
+	}
+}
+
+func TestRetryPolicyOf(t *testing.T) {
+	t.Parallel()
+
+	custom := task.RetryPolicy{MaxAttempts: 2, InitialDelay: time.Second}
+
+	require.Equal(t, task.DefaultRetryPolicy, task.RetryPolicyOf(emptyTask{}), "Tasks with no policy should get the default one")
+	require.Equal(t, custom, task.RetryPolicyOf(taskWithPolicy{policy: custom}), "Tasks with a policy should get their own")
+
+	require.False(t, custom.Exhausted(1), "Policy should not be exhausted before reaching the maximum attempts")
+	require.True(t, custom.Exhausted(2), "Policy should be exhausted after reaching the maximum attempts")
+	require.False(t, task.RetryPolicy{}.Exhausted(1000), "Policy with no maximum attempts should never be exhausted")
+}
+
+func TestNewEnvelope(t *testing.T) {
+	t.Parallel()
+
+	e := task.NewEnvelope(emptyTask{})
+	require.WithinDuration(t, time.Now(), e.Submitted, time.Minute, "Tasks should be stamped with their submission time")
+	require.Zero(t, e.Priority, "Tasks with no priority should get the default one")
+	require.True(t, e.Expires.IsZero(), "Tasks with no TTL should never expire")
+	require.False(t, e.Expired(time.Now().Add(24*time.Hour)), "Tasks with no TTL should never expire")
+
+	e = task.NewEnvelope(taskWithPolicy{priority: 3, ttl: time.Hour})
+	require.Equal(t, 3, e.Priority, "Tasks with a priority should get their own")
+	require.False(t, e.Expired(time.Now()), "Tasks should not expire before their TTL")
+	require.True(t, e.Expired(time.Now().Add(2*time.Hour)), "Tasks should expire after their TTL")
+}
+
+func TestDependenciesOf(t *testing.T) {
+	t.Parallel()
+
+	deps := []task.Task{emptyTask{}}
+
+	require.Empty(t, task.DependenciesOf(emptyTask{}), "Tasks with no DependsOn method should have no dependencies")
+	require.Equal(t, deps, task.DependenciesOf(taskWithPolicy{dependsOn: deps}), "Tasks with a DependsOn method should get their own")
+}
+
+type taskWithPolicy struct {
+	policy    task.RetryPolicy
+	priority  int
+	ttl       time.Duration
+	dependsOn []task.Task
+}
+
+func (taskWithPolicy) Execute(context.Context, task.Connection) error {
+	return nil
+}
+
+func (t taskWithPolicy) RetryPolicy() task.RetryPolicy {
+	return t.policy
+}
+
+func (t taskWithPolicy) Priority() int {
+	return t.priority
+}
+
+func (t taskWithPolicy) TTL() time.Duration {
+	return t.ttl
+}
+
+func (t taskWithPolicy) DependsOn() []task.Task {
+	return t.dependsOn
+}
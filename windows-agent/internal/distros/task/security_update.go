@@ -0,0 +1,16 @@
+package task
+
+// SecurityUpdateReport is the outcome of applying the pending security updates in a distro.
+type SecurityUpdateReport struct {
+	// Packages are the packages that were upgraded.
+	Packages []UpgradedPackage
+	// RebootRequired is true if the distro must be terminated for the upgrades to take effect.
+	RebootRequired bool
+}
+
+// UpgradedPackage is a package that was upgraded in a distro.
+type UpgradedPackage struct {
+	Name       string
+	OldVersion string
+	NewVersion string
+}
@@ -0,0 +1,10 @@
+package task
+
+// ServiceResult is the outcome of enabling or disabling an Ubuntu Pro service in a distro.
+type ServiceResult struct {
+	Name string
+	// Enable is true if the service was to be enabled, and false if it was to be disabled.
+	Enable bool
+	// Error is empty if the service was enabled or disabled successfully.
+	Error string
+}
@@ -3,16 +3,26 @@ package task
 
 import (
 	"context"
+	"errors"
 	"fmt"
 )
 
 // Connection is a connection to the WSL-Pro-Service that allows for
-// sending commands.
+// sending commands. Cancelling the context of a command interrupts it in the distro.
 type Connection interface {
-	SendProAttachment(proToken string) error
-	SendLandscapeConfig(lpeConfig string) error
+	SendProAttachment(ctx context.Context, proToken string) error
+	SendLandscapeConfig(ctx context.Context, lpeConfig string) error
+	SendProxyConfig(ctx context.Context, httpProxy, httpsProxy, noProxy string) error
+	SendRunCommand(ctx context.Context, cmd Command) (CommandOutput, error)
+	SendProServices(ctx context.Context, enable, disable []string) ([]ServiceResult, error)
+	SendSecurityUpdate(ctx context.Context) (SecurityUpdateReport, error)
+	SendPackageInventory(ctx context.Context) ([]Package, error)
 }
 
+// ErrUnsupported is returned by the connections to distros whose WSL-Pro-Service cannot handle a command,
+// as it predates it. Retrying is pointless until the distro is updated.
+var ErrUnsupported = errors.New("unsupported by this distro")
+
 // Task represents a given task that is ging to be executed by a distro.
 type Task interface {
 	Execute(context.Context, Connection) error
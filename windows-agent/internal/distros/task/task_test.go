@@ -2,12 +2,15 @@ package task_test
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v3"
 )
 
 // We use the following number because is not representable as a float64:
@@ -27,8 +30,9 @@ func TestRegistry(t *testing.T) {
 
 	task.Register[testTask]()
 	task.Register[emptyTask]()
+	task.Register[versionedTask](task.WithTypeID("versioned"), task.WithAliases("former-versioned"))
 
-	want := []string{"task_test.testTask", "task_test.emptyTask"}
+	want := []string{"task_test.testTask", "task_test.emptyTask", "versioned", "former-versioned", "task_test.versionedTask"}
 	got = task.RegisteredTasks()
 
 	require.ElementsMatch(t, want, got, "registry should contain only the registered tasks")
@@ -48,9 +52,12 @@ func TestMarshal(t *testing.T) {
 		"Task with a very large integer": {input: testTask{Message: "Not representable as a float64", Number: bigInt}},
 		"Task with no contents":          {input: emptyTask{}},
 
+		"Task with a type ID and a schema version":            {input: versionedTask{Message: "Hello, world!"}},
 		"Unregistered task should still marshal successfully": {input: unregisteredTask{Score: 9001}},
 	}
 
+	registerVersionedTask()
+
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
@@ -81,13 +88,22 @@ func TestUnmarshal(t *testing.T) {
 		"Task with a very large integer": {want: testTask{Number: bigInt, Message: "Not representable as a float64"}},
 		"Empty task":                     {want: emptyTask{}},
 
+		"Task with a type ID":                      {want: versionedTask{Message: "Hello, world!"}},
+		"Task stored with its Go type name":        {want: versionedTask{Message: "Hello, world!"}},
+		"Task stored with an alias":                {want: versionedTask{Message: "Hello, world!"}},
+		"Task stored with an older schema version": {want: versionedTask{Message: "Hello, world!"}},
+
 		// Error cases
-		"Error on unregistered task":    {wantErr: true},
-		"Error on bad YAML syntax":      {wantErr: true},
-		"Error on missing task label":   {wantErr: true},
-		"Error on bad datatype in task": {wantErr: true},
+		"Error on unregistered task":                {wantErr: true},
+		"Error on bad YAML syntax":                  {wantErr: true},
+		"Error on missing task label":               {wantErr: true},
+		"Error on bad datatype in task":             {wantErr: true},
+		"Error on task with a newer schema version": {wantErr: true},
+		"Error on task that cannot be upgraded":     {wantErr: true},
 	}
 
+	registerVersionedTask()
+
 	for name, tc := range testCases {
 		t.Run(name, func(t *testing.T) {
 			t.Parallel()
@@ -141,6 +157,112 @@ func TestMarsallUnmarshall(t *testing.T) {
 	}
 }
 
+//nolint:tparallel // Cannot make test parallel because of BackupRegistry.
+func TestMarshalUnmarshalEnvelopes(t *testing.T) {
+	task.BackupRegistry(t)
+	task.Register[testTask]()
+
+	testCases := map[string]struct {
+		input task.Envelope
+	}{
+		"Task with no attempts":         {input: task.Envelope{Task: testTask{Message: "Hello, world!", Number: 42}}},
+		"Task with failed attempts":     {input: task.Envelope{Task: testTask{Number: 1}, Attempts: 3, LastError: "could not reach the server"}},
+		"Task with priority and expiry": {input: task.Envelope{Task: testTask{Number: 2}, Priority: 5, Expires: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}},
+		"Task with submission time":     {input: task.Envelope{Task: testTask{Number: 4}, Submitted: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}},
+		"Task with dependencies":        {input: task.Envelope{Task: testTask{Number: 3}, ID: "second", After: []string{"first"}, WaitsFor: []string{"zeroth"}, Group: "group"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			serial, err := task.MarshalEnvelopes([]task.Envelope{tc.input})
+			require.NoError(t, err, "input envelope should marshal with no errors")
+
+			got, undecodable, err := task.UnmarshalEnvelopes(serial)
+			require.NoError(t, err, "Registered task should not fail to unmarshal")
+			require.Empty(t, undecodable, "Registered task should not be undecodable")
+			require.Equal(t, []task.Envelope{tc.input}, got, "Marshaling, then unmarshaling an envelope should return the same object")
+
+			tasks, err := task.UnmarshalYAML(serial)
+			require.NoError(t, err, "Envelopes should be readable as plain tasks")
+			require.Equal(t, []task.Task{tc.input.Task}, tasks, "Envelopes read as plain tasks should return the task")
+		})
+	}
+}
+
+func TestUnmarshalEnvelopesWithUndecodableTasks(t *testing.T) {
+	task.BackupRegistry(t)
+	task.Register[testTask]()
+
+	in := `- type: task_test.testTask
+  task:
+    message: first
+- type: task_test.unregisteredTask
+  task:
+    score: 9001
+  attempts: 2
+- type: task_test.testTask
+  task:
+    number: "not a number"
+- type: task_test.testTask
+  task:
+    message: last
+`
+
+	envelopes, undecodable, err := task.UnmarshalEnvelopes([]byte(in))
+	require.NoError(t, err, "UnmarshalEnvelopes should not fail because of undecodable tasks")
+	require.Equal(t, []task.Envelope{{Task: testTask{Message: "first"}}, {Task: testTask{Message: "last"}}}, envelopes,
+		"The decodable tasks should have been returned")
+
+	require.Len(t, undecodable, 2, "The undecodable tasks should have been returned apart")
+	require.ErrorContains(t, undecodable[0].Err, "not registered", "Mismatch in the reason the first task could not be decoded")
+	require.Contains(t, string(undecodable[0].Raw), "score: 9001", "The undecodable task should be returned as stored")
+	require.Contains(t, string(undecodable[0].Raw), "attempts: 2", "The undecodable task should be returned with its bookkeeping")
+	require.Contains(t, string(undecodable[1].Raw), "not a number", "The undecodable task should be returned as stored")
+
+	_, err = task.UnmarshalYAML([]byte(in))
+	require.Error(t, err, "UnmarshalYAML should fail if any task cannot be decoded")
+}
+
+func TestRegisterConflict(t *testing.T) {
+	task.BackupRegistry(t)
+	task.Register[testTask](task.WithTypeID("conflict"))
+
+	require.NotPanics(t, func() { task.Register[testTask](task.WithTypeID("conflict")) }, "Registering a type twice should not panic")
+	require.Panics(t, func() { task.Register[emptyTask](task.WithTypeID("conflict")) }, "Registering another type with the same ID should panic")
+	require.Panics(t, func() { task.Register[emptyTask](task.WithAliases("task_test.testTask")) }, "Registering another type with the same alias should panic")
+}
+
+// registerVersionedTask registers versionedTask at schema version 2: the message used to be
+// stored as a "text" field in version 0, and an unused "legacy" field was dropped in version 1.
+func registerVersionedTask() {
+	task.Register[versionedTask](
+		task.WithTypeID("versioned"),
+		task.WithAliases("former-versioned"),
+		task.WithUpgrades(
+			func(node *yaml.Node) error {
+				for i := 0; i < len(node.Content); i += 2 {
+					if node.Content[i].Value == "text" {
+						node.Content[i].Value = "message"
+						return nil
+					}
+				}
+				return errors.New("no text field")
+			},
+			func(node *yaml.Node) error {
+				for i := 0; i < len(node.Content); i += 2 {
+					if node.Content[i].Value == "legacy" {
+						node.Content = append(node.Content[:i], node.Content[i+2:]...)
+						break
+					}
+				}
+				return nil
+			},
+		),
+	)
+}
+
 type testTask struct {
 	Message string
 	Number  uint64
@@ -154,6 +276,12 @@ type emptyTask struct {
 	DummyImplementer `yaml:"-"`
 }
 
+type versionedTask struct {
+	Message string
+
+	DummyImplementer `yaml:"-"`
+}
+
 type unregisteredTask struct {
 	Score int
 
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/ubuntu/decorate"
 	"go.yaml.in/yaml/v3"
@@ -11,57 +12,230 @@ import (
 
 type decodeFunc = func(*yaml.Node) (Task, error)
 
-var registry = map[string]decodeFunc{}
+// registration describes how the tasks of a registered type are stored.
+type registration struct {
+	// id identifies the task type in the storage.
+	id string
+	// aliases are other identifiers the task type was stored with.
+	aliases []string
+	// upgrades bring the stored contents of a task from each schema version to the next one.
+	upgrades []UpgradeFunc
+
+	goType reflect.Type
+	decode decodeFunc
+}
+
+// registry contains the registered task types, indexed by type ID and aliases.
+var registry = map[string]*registration{}
+
+// registeredTypes contains the registered task types, indexed by Go type.
+var registeredTypes = map[reflect.Type]*registration{}
+
+// UpgradeFunc upgrades the stored contents of a task from a schema version to the next one.
+type UpgradeFunc func(node *yaml.Node) error
+
+// RegisterOption is an optional setting of a registered task type.
+type RegisterOption func(*registration)
+
+// WithTypeID sets the identifier that tasks of the type are stored with, which must never change
+// afterwards. The name of the Go type is used otherwise, so renaming it would make stored tasks unreadable.
+func WithTypeID(id string) RegisterOption {
+	return func(r *registration) {
+		r.id = id
+	}
+}
+
+// WithAliases sets other identifiers that tasks of the type were stored with, such as former type IDs.
+// The name of the Go type is always an alias, so that tasks stored before they had a type ID can be read.
+func WithAliases(aliases ...string) RegisterOption {
+	return func(r *registration) {
+		r.aliases = append(r.aliases, aliases...)
+	}
+}
+
+// WithUpgrades sets the schema upgrades of the task type: upgrades[i] upgrades the contents of the tasks stored
+// with schema version i to version i+1. The current schema version is the number of upgrades.
+func WithUpgrades(upgrades ...UpgradeFunc) RegisterOption {
+	return func(r *registration) {
+		r.upgrades = upgrades
+	}
+}
 
 // Register registers a task type to the gobal registry. This is needed to deserialize
 // tasks. Call Register[YourTask] to the module's init in order to use it.
-func Register[T Task]() {
-	typename := reflect.TypeOf((*T)(nil)).Elem().String()
-	registry[typename] = func(node *yaml.Node) (Task, error) {
-		var t T
-		err := node.Decode(&t)
-		return t, err
+//
+// It panics if another task type is registered with the same type ID or alias.
+func Register[T Task](opts ...RegisterOption) {
+	goType := reflect.TypeOf((*T)(nil)).Elem()
+	r := &registration{
+		id:     goType.String(),
+		goType: goType,
+		decode: func(node *yaml.Node) (Task, error) {
+			var t T
+			err := node.Decode(&t)
+			return t, err
+		},
+	}
+
+	for _, f := range opts {
+		f(r)
 	}
+
+	for _, name := range append([]string{r.id, goType.String()}, r.aliases...) {
+		if other, ok := registry[name]; ok && other.goType != goType {
+			panic(fmt.Sprintf("task type %q is already registered by %s", name, other.goType))
+		}
+		registry[name] = r
+	}
+	registeredTypes[goType] = r
+}
+
+// typeOf returns the type ID and schema version that the task is stored with. Tasks of
+// unregistered types are stored with the name of their Go type.
+func typeOf(t Task) (id string, version int) {
+	goType := reflect.TypeOf(t)
+	if r, ok := registeredTypes[goType]; ok {
+		return r.id, len(r.upgrades)
+	}
+	return goType.String(), 0
+}
+
+// TypeID returns the stable identifier of the type of the task, which it is stored with. Unlike the name
+// of its Go type, it does not change when the type is renamed or moved.
+func TypeID(t Task) string {
+	id, _ := typeOf(t)
+	return id
+}
+
+// Undecodable is a stored task that could not be decoded, such as one of a type that is no longer registered.
+type Undecodable struct {
+	// Raw is the YAML of the task as stored, bookkeeping included.
+	Raw []byte
+	// Err is the reason why the task could not be decoded.
+	Err error
 }
 
 type yamlTaskHelper struct {
-	Task Task
-	Type string
+	Task      Task
+	Type      string
+	Version   int       `yaml:",omitempty"`
+	Priority  int       `yaml:",omitempty"`
+	Expires   time.Time `yaml:",omitempty"`
+	Submitted time.Time `yaml:",omitempty"`
+	Attempts  int       `yaml:",omitempty"`
+	LastError string    `yaml:",omitempty"`
+	ID        string    `yaml:",omitempty"`
+	After     []string  `yaml:",omitempty"`
+	WaitsFor  []string  `yaml:",omitempty"`
+	Group     string    `yaml:",omitempty"`
 }
 
 // MarshalYAML marshals a slice of tasks in YAML format.
 func MarshalYAML(tasks []Task) (out []byte, err error) {
+	envelopes := make([]Envelope, 0, len(tasks))
+	for _, t := range tasks {
+		envelopes = append(envelopes, Envelope{Task: t})
+	}
+	return MarshalEnvelopes(envelopes)
+}
+
+// UnmarshalYAML unmarshals a slice of tasks from a YAML document. It fails if any of them cannot be decoded.
+func UnmarshalYAML(in []byte) (tasks []Task, err error) {
+	envelopes, undecodable, err := UnmarshalEnvelopes(in)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range undecodable {
+		err = errors.Join(err, u.Err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range envelopes {
+		tasks = append(tasks, e.Task)
+	}
+	return tasks, nil
+}
+
+// MarshalEnvelopes marshals a slice of tasks along with their bookkeeping in YAML format.
+func MarshalEnvelopes(envelopes []Envelope) (out []byte, err error) {
 	var tmp []yamlTaskHelper
-	for i := range tasks {
-		t := tasks[i]
+	for _, e := range envelopes {
+		typeID, version := typeOf(e.Task)
 		tmp = append(tmp, yamlTaskHelper{
-			Type: reflect.TypeOf(t).String(),
-			Task: t,
+			Type:      typeID,
+			Version:   version,
+			Task:      e.Task,
+			Priority:  e.Priority,
+			Expires:   e.Expires,
+			Submitted: e.Submitted,
+			Attempts:  e.Attempts,
+			LastError: e.LastError,
+			ID:        e.ID,
+			After:     e.After,
+			WaitsFor:  e.WaitsFor,
+			Group:     e.Group,
 		})
 	}
 
 	return yaml.Marshal(tmp)
 }
 
-// UnmarshalYAML unmarshals a slice of tasks from a YAML document.
-func UnmarshalYAML(in []byte) (tasks []Task, err error) {
-	var tmp []yamlTaskHelper
-	if err := yaml.Unmarshal(in, &tmp); err != nil {
-		return nil, err
+// UnmarshalEnvelopes unmarshals a slice of tasks along with their bookkeeping from a YAML document.
+// Documents written by MarshalYAML are read with no bookkeeping. Tasks stored with an older schema
+// version are upgraded. The tasks that cannot be decoded are returned apart, so that the others
+// can still be read; an error is only returned if the document itself cannot be read.
+func UnmarshalEnvelopes(in []byte) (envelopes []Envelope, undecodable []Undecodable, err error) {
+	var nodes []yaml.Node
+	if err := yaml.Unmarshal(in, &nodes); err != nil {
+		return nil, nil, err
 	}
 
-	for i := range tmp {
-		tasks = append(tasks, tmp[i].Task)
+	for _, node := range nodes {
+		var h yamlTaskHelper
+		if err := node.Decode(&h); err != nil {
+			raw, e := yaml.Marshal(&node)
+			if e != nil {
+				return nil, nil, fmt.Errorf("could not marshal undecodable task: %v", e)
+			}
+			undecodable = append(undecodable, Undecodable{Raw: raw, Err: err})
+			continue
+		}
+
+		envelopes = append(envelopes, Envelope{
+			Task:      h.Task,
+			Priority:  h.Priority,
+			Expires:   h.Expires,
+			Submitted: h.Submitted,
+			Attempts:  h.Attempts,
+			LastError: h.LastError,
+			ID:        h.ID,
+			After:     h.After,
+			WaitsFor:  h.WaitsFor,
+			Group:     h.Group,
+		})
 	}
-	return tasks, nil
+	return envelopes, undecodable, nil
 }
 
 // UnmarshalYAML overrides the unmarshalling behaviour of yamlTaskHelper so that
 // the type of the underlying Task can be read before parsing its contents.
 func (t *yamlTaskHelper) UnmarshalYAML(node *yaml.Node) error {
 	var tmp struct {
-		Type string
-		Task rawTask
+		Type      string
+		Version   int
+		Task      rawTask
+		Priority  int
+		Expires   time.Time
+		Submitted time.Time
+		Attempts  int
+		LastError string
+		ID        string
+		After     []string
+		WaitsFor  []string
+		Group     string
 	}
 
 	err := node.Decode(&tmp)
@@ -70,7 +244,17 @@ func (t *yamlTaskHelper) UnmarshalYAML(node *yaml.Node) error {
 	}
 
 	t.Type = tmp.Type
-	if t.Task, err = tmp.Task.decode(t.Type); err != nil {
+	t.Version = tmp.Version
+	t.Priority = tmp.Priority
+	t.Expires = tmp.Expires
+	t.Submitted = tmp.Submitted
+	t.Attempts = tmp.Attempts
+	t.LastError = tmp.LastError
+	t.ID = tmp.ID
+	t.After = tmp.After
+	t.WaitsFor = tmp.WaitsFor
+	t.Group = tmp.Group
+	if t.Task, err = tmp.Task.decode(t.Type, t.Version); err != nil {
 		return err
 	}
 
@@ -91,20 +275,31 @@ func (rt *rawTask) UnmarshalYAML(node *yaml.Node) error {
 	return nil
 }
 
-// decode performs the actual unmarshalling. rawTask.UnmarshalYAML needs be called before.
-func (rt rawTask) decode(taskTypeName string) (task Task, err error) {
-	defer decorate.OnError(&err, "task type %q", taskTypeName)
+// decode performs the actual unmarshalling, after upgrading the contents stored with that schema version
+// to the current one. rawTask.UnmarshalYAML needs be called before.
+func (rt rawTask) decode(typeID string, version int) (task Task, err error) {
+	defer decorate.OnError(&err, "task type %q", typeID)
 
 	if rt.Node == nil {
 		return nil, errors.New("decoding error: nil node")
 	}
 
-	decode, ok := registry[taskTypeName]
+	r, ok := registry[typeID]
 	if !ok {
 		return nil, errors.New("not registered")
 	}
 
-	task, err = decode(rt.Node)
+	if version < 0 || version > len(r.upgrades) {
+		return nil, fmt.Errorf("unsupported schema version %d: the latest one is %d", version, len(r.upgrades))
+	}
+
+	for v := version; v < len(r.upgrades); v++ {
+		if err := r.upgrades[v](rt.Node); err != nil {
+			return nil, fmt.Errorf("could not upgrade from schema version %d: %v", v, err)
+		}
+	}
+
+	task, err = r.decode(rt.Node)
 	if err != nil {
 		return nil, fmt.Errorf("could not decode: %v", err)
 	}
@@ -0,0 +1,11 @@
+package wakepolicy
+
+import "time"
+
+// SetNow overrides the clock of the governor.
+func (g *Governor) SetNow(now func() time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.now = now
+}
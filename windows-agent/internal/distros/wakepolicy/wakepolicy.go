@@ -0,0 +1,250 @@
+// Package wakepolicy decides whether the agent may wake a stopped distro up to run background work,
+// such as tasks. Starts requested by a user or by Landscape are not subject to it.
+package wakepolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotAllowed is the error returned when the policy does not allow waking a distro up.
+var ErrNotAllowed = errors.New("waking the distro up is not allowed by the power policy")
+
+// Window is a daily time range during which distros may be woken up. It may wrap around midnight.
+type Window struct {
+	// Start and End are the offsets from midnight, in local time.
+	Start, End time.Duration
+}
+
+// Policy is the set of rules that distros must abide by to be woken up for background work.
+// The zero value allows waking any number of distros at any time.
+type Policy struct {
+	// Windows are the times of the day when distros may be woken up. Empty means any time.
+	Windows []Window
+
+	// MaxAwake is the maximum number of distros awake for background work at the same time. Zero means no limit.
+	MaxAwake int
+
+	// NeverWake forbids waking distros up: background work waits until the user starts them.
+	NeverWake bool
+}
+
+// ParseWindows parses a comma-separated list of time ranges, such as "22:00-06:00,12:00-13:00".
+func ParseWindows(s string) (windows []Window, err error) {
+	for r := range strings.SplitSeq(s, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+
+		start, end, ok := strings.Cut(r, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid maintenance window %q: expected a range like 22:00-06:00", r)
+		}
+
+		var w Window
+		if w.Start, err = ParseTimeOfDay(start); err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %v", r, err)
+		}
+		if w.End, err = ParseTimeOfDay(end); err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %q: %v", r, err)
+		}
+		if w.Start == w.End {
+			return nil, fmt.Errorf("invalid maintenance window %q: it must not be empty", r)
+		}
+
+		windows = append(windows, w)
+	}
+
+	return windows, nil
+}
+
+// ParseTimeOfDay parses a time of the day like 22:00, and returns its offset from midnight.
+func ParseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of the day %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// String returns the window in the format accepted by ParseWindows.
+func (w Window) String() string {
+	return fmt.Sprintf("%s-%s", FormatTimeOfDay(w.Start), FormatTimeOfDay(w.End))
+}
+
+// FormatTimeOfDay formats an offset from midnight as a time of the day like 22:00.
+func FormatTimeOfDay(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// contains returns true if the time of the day is within the window.
+func (w Window) contains(tod time.Duration) bool {
+	if w.Start < w.End {
+		return w.Start <= tod && tod < w.End
+	}
+	// Wraps around midnight
+	return tod >= w.Start || tod < w.End
+}
+
+// inWindow returns true if the time is within any of the windows, or if there are no windows.
+// Otherwise, it also returns the time until the next window opens.
+func (p Policy) inWindow(t time.Time) (bool, time.Duration) {
+	if len(p.Windows) == 0 {
+		return true, 0
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	tod := t.Sub(midnight)
+
+	next := 24 * time.Hour
+	for _, w := range p.Windows {
+		if w.contains(tod) {
+			return true, 0
+		}
+
+		wait := w.Start - tod
+		if wait < 0 {
+			wait += 24 * time.Hour
+		}
+		next = min(next, wait)
+	}
+
+	return false, next
+}
+
+// Equal returns true if both policies contain the same rules.
+func (p Policy) Equal(other Policy) bool {
+	return p.MaxAwake == other.MaxAwake && p.NeverWake == other.NeverWake && slices.Equal(p.Windows, other.Windows)
+}
+
+// Governor enforces a policy on the distros woken up for background work.
+// The zero value is not usable: use New.
+type Governor struct {
+	mu     sync.Mutex
+	policy Policy
+
+	// awake is the number of distros woken up for background work that have not been released yet.
+	awake int
+
+	// changed is closed and replaced whenever waking a distro up may have become allowed.
+	changed chan struct{}
+
+	now func() time.Time
+}
+
+// New creates a governor that allows waking distros up at any time until a policy is set.
+func New() *Governor {
+	return &Governor{
+		changed: make(chan struct{}),
+		now:     time.Now,
+	}
+}
+
+// SetPolicy replaces the policy. Distros already awake are not affected.
+func (g *Governor) SetPolicy(p Policy) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.policy = p
+	g.notify()
+}
+
+// Policy returns the policy in force.
+func (g *Governor) Policy() Policy {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.policy
+}
+
+// Acquire requests permission to wake a distro up for background work. It returns ErrNotAllowed if
+// the policy does not allow it now. Otherwise, release must be called once the distro no longer
+// needs to stay awake for that work.
+func (g *Governor) Acquire() (release func(), err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if ok, _ := g.allowed(); !ok {
+		return nil, ErrNotAllowed
+	}
+
+	g.awake++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+
+			g.awake--
+			g.notify()
+		})
+	}, nil
+}
+
+// WaitAllowed blocks until the policy allows waking a distro up, or the context is done.
+// Being allowed does not reserve anything: Acquire may still fail afterwards.
+func (g *Governor) WaitAllowed(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		ok, wait := g.allowed()
+		changed := g.changed
+		g.mu.Unlock()
+
+		if ok {
+			return nil
+		}
+
+		// A nil channel blocks forever: without a window to wait for, only a change can allow it.
+		var timeout <-chan time.Time
+		var timer *time.Timer
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			timeout = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-timeout:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// allowed returns true if the policy allows waking a distro up now. Otherwise, it also returns the
+// time until the next window opens, or zero if only a change of policy or a release can allow it.
+// It must be called with the mutex held.
+func (g *Governor) allowed() (bool, time.Duration) {
+	if g.policy.NeverWake {
+		return false, 0
+	}
+
+	if ok, wait := g.policy.inWindow(g.now()); !ok {
+		return false, wait
+	}
+
+	if g.policy.MaxAwake > 0 && g.awake >= g.policy.MaxAwake {
+		return false, 0
+	}
+
+	return true, 0
+}
+
+// notify wakes up the goroutines waiting in WaitAllowed. It must be called with the mutex held.
+func (g *Governor) notify() {
+	close(g.changed)
+	g.changed = make(chan struct{})
+}
@@ -0,0 +1,194 @@
+package wakepolicy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/wakepolicy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWindows(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		input string
+
+		want    []wakepolicy.Window
+		wantErr bool
+	}{
+		"Success with no windows":                 {input: ""},
+		"Success with a single window":            {input: "12:00-13:30", want: []wakepolicy.Window{{Start: 12 * time.Hour, End: 13*time.Hour + 30*time.Minute}}},
+		"Success with a window wrapping midnight": {input: "22:00-06:00", want: []wakepolicy.Window{{Start: 22 * time.Hour, End: 6 * time.Hour}}},
+		"Success with multiple windows and spaces": {input: " 22:00 - 06:00 , 12:00-13:00,", want: []wakepolicy.Window{
+			{Start: 22 * time.Hour, End: 6 * time.Hour},
+			{Start: 12 * time.Hour, End: 13 * time.Hour},
+		}},
+
+		"Error when the range has no separator": {input: "22:00", wantErr: true},
+		"Error when the start is not a time":    {input: "late-06:00", wantErr: true},
+		"Error when the end is out of range":    {input: "22:00-25:00", wantErr: true},
+		"Error when the window is empty":        {input: "12:00-12:00", wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := wakepolicy.ParseWindows(tc.input)
+			if tc.wantErr {
+				require.Error(t, err, "ParseWindows should return an error")
+				return
+			}
+			require.NoError(t, err, "ParseWindows should return no error")
+			require.Equal(t, tc.want, got, "Unexpected windows")
+
+			for i := range got {
+				again, err := wakepolicy.ParseWindows(got[i].String())
+				require.NoError(t, err, "The string representation of a window should be parseable")
+				require.Equal(t, got[i:i+1], again, "The string representation of a window should round-trip")
+			}
+		})
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	t.Parallel()
+
+	nights := wakepolicy.Window{Start: 22 * time.Hour, End: 6 * time.Hour}
+	lunch := wakepolicy.Window{Start: 12 * time.Hour, End: 13 * time.Hour}
+
+	testCases := map[string]struct {
+		policy    wakepolicy.Policy
+		timeOfDay time.Duration
+		awake     int
+
+		wantErr bool
+	}{
+		"Success with the default policy":                    {},
+		"Success inside a window":                            {policy: wakepolicy.Policy{Windows: []wakepolicy.Window{nights, lunch}}, timeOfDay: 12*time.Hour + 30*time.Minute},
+		"Success inside a window after midnight":             {policy: wakepolicy.Policy{Windows: []wakepolicy.Window{nights}}, timeOfDay: 2 * time.Hour},
+		"Success below the maximum number of awake distros":  {policy: wakepolicy.Policy{MaxAwake: 2}, awake: 1},
+		"Success when distros were released below the limit": {policy: wakepolicy.Policy{MaxAwake: 1}, awake: -1},
+		"Error outside of the windows":                       {policy: wakepolicy.Policy{Windows: []wakepolicy.Window{nights, lunch}}, timeOfDay: 9 * time.Hour, wantErr: true},
+		"Error at the end of a window":                       {policy: wakepolicy.Policy{Windows: []wakepolicy.Window{lunch}}, timeOfDay: 13 * time.Hour, wantErr: true},
+		"Error when the maximum number of distros are awake": {policy: wakepolicy.Policy{MaxAwake: 2}, awake: 2, wantErr: true},
+		"Error when distros must never be woken up":          {policy: wakepolicy.Policy{NeverWake: true}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			g := wakepolicy.New()
+			g.SetNow(func() time.Time { return today().Add(tc.timeOfDay) })
+			g.SetPolicy(tc.policy)
+
+			// A negative number means acquiring and releasing that many permits.
+			for range tc.awake {
+				_, err := g.Acquire()
+				require.NoError(t, err, "Setup: could not acquire a permit")
+			}
+			for range -tc.awake {
+				release, err := g.Acquire()
+				require.NoError(t, err, "Setup: could not acquire a permit")
+				release()
+				release() // Releasing twice must have no effect
+			}
+
+			release, err := g.Acquire()
+			if tc.wantErr {
+				require.ErrorIs(t, err, wakepolicy.ErrNotAllowed, "Acquire should return ErrNotAllowed")
+				return
+			}
+			require.NoError(t, err, "Acquire should return no error")
+			require.NotNil(t, release, "Acquire should return a release function")
+		})
+	}
+}
+
+func TestWaitAllowed(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		policy wakepolicy.Policy
+
+		setPolicy     bool
+		release       bool
+		windowOpens   bool
+		cancelContext bool
+
+		wantErr bool
+	}{
+		"Success right away when allowed":                 {},
+		"Success when the policy changes":                 {policy: wakepolicy.Policy{NeverWake: true}, setPolicy: true},
+		"Success when an awake distro is released":        {policy: wakepolicy.Policy{MaxAwake: 1}, release: true},
+		"Success when a window opens":                     {windowOpens: true},
+		"Error when the context is cancelled":             {policy: wakepolicy.Policy{NeverWake: true}, cancelContext: true, wantErr: true},
+		"Error when the context is cancelled in a window": {windowOpens: true, cancelContext: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			g := wakepolicy.New()
+
+			if tc.windowOpens {
+				// The window opens 100ms after the test starts, or in an hour if the context is cancelled.
+				tc.policy = wakepolicy.Policy{Windows: []wakepolicy.Window{{Start: time.Hour, End: 2 * time.Hour}}}
+				start, now := today().Add(time.Hour), time.Now()
+				g.SetNow(func() time.Time {
+					if tc.cancelContext {
+						return start.Add(-time.Hour)
+					}
+					return start.Add(time.Since(now) - 100*time.Millisecond)
+				})
+			}
+			g.SetPolicy(tc.policy)
+
+			var release func()
+			if tc.release {
+				var err error
+				release, err = g.Acquire()
+				require.NoError(t, err, "Setup: could not acquire a permit")
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			go func() {
+				time.Sleep(200 * time.Millisecond)
+				switch {
+				case tc.cancelContext:
+					cancel()
+				case tc.setPolicy:
+					g.SetPolicy(wakepolicy.Policy{})
+				case tc.release:
+					release()
+				}
+			}()
+
+			done := make(chan error)
+			go func() { done <- g.WaitAllowed(ctx) }()
+
+			select {
+			case err := <-done:
+				if tc.wantErr {
+					require.Error(t, err, "WaitAllowed should return an error")
+					return
+				}
+				require.NoError(t, err, "WaitAllowed should return no error")
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "WaitAllowed should have returned")
+			}
+		})
+	}
+}
+
+// today returns the midnight of the current day.
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
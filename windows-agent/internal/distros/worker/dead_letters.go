@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/ubuntu/decorate"
+	"go.yaml.in/yaml/v3"
+)
+
+// deadLettersBucket is the storage bucket containing the tasks that exhausted their retry policy,
+// indexed by distro name.
+const deadLettersBucket = "deadletters"
+
+// maxDeadLetters is the number of dead letters kept for each distro. Older ones are discarded.
+const maxDeadLetters = 20
+
+// DeadLetter is a task that was given up on after exhausting its retry policy.
+type DeadLetter struct {
+	// ID identifies the task. Empty for tasks that had none.
+	ID string `yaml:",omitempty"`
+	// Type is the stable identifier of the type of the task, which does not change if its Go type is renamed.
+	Type string `yaml:",omitempty"`
+	// Task is the description of the task.
+	Task string
+	// Attempts is the number of times the task was attempted.
+	Attempts int
+	// Error is the error of the last attempt.
+	Error string
+	// Time is when the task was given up on.
+	Time time.Time
+}
+
+// appendDeadLetter adds a dead letter to the ones of the distro stored in the transaction.
+func appendDeadLetter(tx storage.Tx, distroName string, dl DeadLetter) error {
+	letters, err := readDeadLetters(tx.Get(deadLettersBucket, distroName))
+	if err != nil {
+		return err
+	}
+
+	letters = append(letters, dl)
+	if len(letters) > maxDeadLetters {
+		letters = letters[len(letters)-maxDeadLetters:]
+	}
+
+	out, err := yaml.Marshal(letters)
+	if err != nil {
+		return fmt.Errorf("could not marshal dead letters: %v", err)
+	}
+
+	return tx.Put(deadLettersBucket, distroName, out)
+}
+
+// readDeadLetters parses the dead letters of a distro as stored.
+func readDeadLetters(out []byte) (letters []DeadLetter, err error) {
+	if out == nil {
+		return nil, nil
+	}
+
+	if err := yaml.Unmarshal(out, &letters); err != nil {
+		return nil, fmt.Errorf("could not unmarshal dead letters: %v", err)
+	}
+	return letters, nil
+}
+
+// StoredDeadLetters returns the dead letters kept in the storage, oldest first, indexed by distro name.
+func StoredDeadLetters(s storage.Store) (letters map[string][]DeadLetter, err error) {
+	defer decorate.OnError(&err, "could not read dead letters from storage")
+
+	letters = make(map[string][]DeadLetter)
+	err = s.View(func(tx storage.Tx) error {
+		return tx.ForEach(deadLettersBucket, func(distroName string, value []byte) error {
+			l, err := readDeadLetters(value)
+			if err != nil {
+				return fmt.Errorf("distro %q: %v", distroName, err)
+			}
+			letters[distroName] = l
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return letters, nil
+}
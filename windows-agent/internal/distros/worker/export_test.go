@@ -4,6 +4,9 @@ import (
 	"fmt"
 )
 
+// MaxHistory is the number of history entries kept for each distro.
+const MaxHistory = maxHistory
+
 // CheckQueuedTaskCount checks that the number of tasks in the queue matches expectations.
 func (w *Worker) CheckQueuedTaskCount(want int) error {
 	if got := w.manager.QueueLen(); got != want {
@@ -0,0 +1,167 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/ubuntu/decorate"
+	"go.yaml.in/yaml/v3"
+)
+
+// historyBucket is the storage bucket containing the task execution history, indexed by distro name.
+const historyBucket = "history"
+
+// maxHistory is the number of history entries kept for each distro. Older ones are discarded.
+const maxHistory = 50
+
+// Result is the outcome of an attempt at running a task.
+type Result string
+
+const (
+	// ResultSucceeded is the result of the tasks that completed successfully.
+	ResultSucceeded Result = "succeeded"
+	// ResultRetrying is the result of the failed attempts that will be retried.
+	ResultRetrying Result = "retrying"
+	// ResultFailed is the result of the tasks that failed for good.
+	ResultFailed Result = "failed"
+	// ResultExpired is the result of the tasks discarded because they expired before running.
+	ResultExpired Result = "expired"
+	// ResultDropped is the result of the tasks discarded because a task they depend on failed.
+	ResultDropped Result = "dropped"
+	// ResultCancelled is the result of the tasks cancelled before they could complete.
+	ResultCancelled Result = "cancelled"
+)
+
+// HistoryEntry is the outcome of an attempt at running a task.
+type HistoryEntry struct {
+	// ID identifies the task. Empty for tasks that had none.
+	ID string `yaml:",omitempty"`
+	// Type is the stable identifier of the type of the task, which does not change if its Go type is renamed.
+	Type string
+	// Summary is the description of the task, which does not contain secrets.
+	Summary string
+
+	// Submitted is when the task was submitted. Zero if unknown.
+	Submitted time.Time
+	// Started is when the attempt started.
+	Started time.Time
+	// Ended is when the attempt ended.
+	Ended time.Time
+
+	// Attempts is the number of times the task was attempted so far.
+	Attempts int
+	// Result is the outcome of the attempt.
+	Result Result
+	// Error is the error of the attempt, if any.
+	Error string `yaml:",omitempty"`
+	// Details is what the task reported about the outcome of the attempt, if anything.
+	Details string `yaml:",omitempty"`
+	// RebootRequired is true if the distro must be restarted for the attempt to take effect.
+	RebootRequired bool `yaml:",omitempty"`
+}
+
+// newHistoryEntry describes the outcome of the attempt at running the task. If the task ran, the
+// attempt must have been counted in the envelope already.
+func newHistoryEntry(e task.Envelope, result Result, err error) HistoryEntry {
+	h := HistoryEntry{
+		ID:        e.ID,
+		Type:      task.TypeID(e.Task),
+		Summary:   fmt.Sprint(e.Task),
+		Submitted: e.Submitted,
+		Started:   e.Started,
+		Ended:     time.Now(),
+		Attempts:  e.Attempts,
+		Result:    result,
+		Details:   e.Report,
+
+		RebootRequired: e.RebootRequired,
+	}
+
+	if h.Started.IsZero() {
+		// The task did not run
+		h.Started = h.Ended
+	}
+
+	if err != nil {
+		h.Error = err.Error()
+	}
+
+	return h
+}
+
+// resultOf returns the result of an attempt that ended with that error, and that will not be retried.
+func resultOf(err error) Result {
+	switch {
+	case err == nil:
+		return ResultSucceeded
+	case errors.Is(err, task.ErrExpired):
+		return ResultExpired
+	case errors.Is(err, task.ErrPrerequisiteFailed):
+		return ResultDropped
+	case errors.Is(err, task.ErrCancelled):
+		return ResultCancelled
+	default:
+		return ResultFailed
+	}
+}
+
+// appendHistory adds entries to the history of the distro stored in the transaction.
+func appendHistory(tx storage.Tx, distroName string, entries ...HistoryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	history, err := readHistory(tx.Get(historyBucket, distroName))
+	if err != nil {
+		return err
+	}
+
+	history = append(history, entries...)
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+
+	out, err := yaml.Marshal(history)
+	if err != nil {
+		return fmt.Errorf("could not marshal task history: %v", err)
+	}
+
+	return tx.Put(historyBucket, distroName, out)
+}
+
+// readHistory parses the history of a distro as stored.
+func readHistory(out []byte) (history []HistoryEntry, err error) {
+	if out == nil {
+		return nil, nil
+	}
+
+	if err := yaml.Unmarshal(out, &history); err != nil {
+		return nil, fmt.Errorf("could not unmarshal task history: %v", err)
+	}
+	return history, nil
+}
+
+// StoredHistory returns the task execution history kept in the storage, oldest first, indexed by distro name.
+func StoredHistory(s storage.Store) (history map[string][]HistoryEntry, err error) {
+	defer decorate.OnError(&err, "could not read task history from storage")
+
+	history = make(map[string][]HistoryEntry)
+	err = s.View(func(tx storage.Tx) error {
+		return tx.ForEach(historyBucket, func(distroName string, value []byte) error {
+			h, err := readHistory(value)
+			if err != nil {
+				return fmt.Errorf("distro %q: %v", distroName, err)
+			}
+			history[distroName] = h
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
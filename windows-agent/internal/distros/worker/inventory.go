@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"fmt"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/ubuntu/decorate"
+	"go.yaml.in/yaml/v3"
+)
+
+// inventoryBucket is the storage bucket containing the package inventories, indexed by distro name.
+const inventoryBucket = "inventory"
+
+// Inventories are the last two package inventories collected from a distro, so that
+// the changes between them can be reported.
+type Inventories struct {
+	// Current is the latest inventory.
+	Current task.PackageInventory
+	// Previous is the inventory collected before the current one. Nil if there is none.
+	Previous *task.PackageInventory `yaml:",omitempty"`
+}
+
+// recordInventory stores the inventory as the current one of the distro, and the former current one as the previous.
+func (tm *taskManager) recordInventory(inv task.PackageInventory) (err error) {
+	defer decorate.OnError(&err, "could not store the package inventory")
+
+	return tm.store.Update(func(tx storage.Tx) error {
+		stored, err := readInventories(tx.Get(inventoryBucket, tm.key))
+		if err != nil {
+			return err
+		}
+
+		next := Inventories{Current: inv}
+		if stored != nil {
+			next.Previous = &stored.Current
+		}
+
+		out, err := yaml.Marshal(next)
+		if err != nil {
+			return fmt.Errorf("could not marshal package inventory: %v", err)
+		}
+
+		return tx.Put(inventoryBucket, tm.key, out)
+	})
+}
+
+// readInventories parses the inventories of a distro as stored. Nil if there are none.
+func readInventories(out []byte) (*Inventories, error) {
+	if out == nil {
+		return nil, nil
+	}
+
+	var inv Inventories
+	if err := yaml.Unmarshal(out, &inv); err != nil {
+		return nil, fmt.Errorf("could not unmarshal package inventory: %v", err)
+	}
+	return &inv, nil
+}
+
+// StoredInventories returns the package inventories kept in the storage, indexed by distro name.
+func StoredInventories(s storage.Store) (inventories map[string]Inventories, err error) {
+	defer decorate.OnError(&err, "could not read package inventories from storage")
+
+	inventories = make(map[string]Inventories)
+	err = s.View(func(tx storage.Tx) error {
+		return tx.ForEach(inventoryBucket, func(distroName string, value []byte) error {
+			inv, err := readInventories(value)
+			if err != nil {
+				return fmt.Errorf("distro %q: %v", distroName, err)
+			}
+			inventories[distroName] = *inv
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return inventories, nil
+}
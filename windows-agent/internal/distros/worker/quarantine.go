@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/ubuntu/decorate"
+	"go.yaml.in/yaml/v3"
+)
+
+// quarantineBucket is the storage bucket containing the stored tasks that could not be decoded,
+// indexed by distro name.
+const quarantineBucket = "quarantine"
+
+// maxQuarantined is the number of quarantined tasks kept for each distro. Older ones are discarded.
+const maxQuarantined = 20
+
+// QuarantinedTask is a stored task that could not be decoded when loading the queue of a distro,
+// such as one of a type that this version of the agent does not know about.
+type QuarantinedTask struct {
+	// Task is the task as it was stored, bookkeeping included.
+	Task string
+	// Error is the reason why it could not be decoded.
+	Error string
+	// Time is when the task was quarantined.
+	Time time.Time
+}
+
+// appendQuarantine adds tasks to the ones of the distro quarantined in the transaction.
+func appendQuarantine(tx storage.Tx, distroName string, tasks ...QuarantinedTask) error {
+	quarantined, err := readQuarantine(tx.Get(quarantineBucket, distroName))
+	if err != nil {
+		return err
+	}
+
+	quarantined = append(quarantined, tasks...)
+	if len(quarantined) > maxQuarantined {
+		quarantined = quarantined[len(quarantined)-maxQuarantined:]
+	}
+
+	out, err := yaml.Marshal(quarantined)
+	if err != nil {
+		return fmt.Errorf("could not marshal quarantined tasks: %v", err)
+	}
+
+	return tx.Put(quarantineBucket, distroName, out)
+}
+
+// readQuarantine parses the quarantined tasks of a distro as stored.
+func readQuarantine(out []byte) (quarantined []QuarantinedTask, err error) {
+	if out == nil {
+		return nil, nil
+	}
+
+	if err := yaml.Unmarshal(out, &quarantined); err != nil {
+		return nil, fmt.Errorf("could not unmarshal quarantined tasks: %v", err)
+	}
+	return quarantined, nil
+}
+
+// StoredQuarantine returns the quarantined tasks kept in the storage, oldest first, indexed by distro name.
+func StoredQuarantine(s storage.Store) (quarantined map[string][]QuarantinedTask, err error) {
+	defer decorate.OnError(&err, "could not read quarantined tasks from storage")
+
+	quarantined = make(map[string][]QuarantinedTask)
+	err = s.View(func(tx storage.Tx) error {
+		return tx.ForEach(quarantineBucket, func(distroName string, value []byte) error {
+			q, err := readQuarantine(value)
+			if err != nil {
+				return fmt.Errorf("distro %q: %v", distroName, err)
+			}
+			quarantined[distroName] = q
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return quarantined, nil
+}
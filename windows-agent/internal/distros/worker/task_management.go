@@ -4,17 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/fs"
-	"os"
+	"slices"
 	"sync"
+	"time"
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/google/uuid"
 	"github.com/ubuntu/decorate"
 )
 
+// tasksBucket is the storage bucket containing the task queues, indexed by distro name.
+const tasksBucket = "tasks"
+
 // taskmanager is a helper struct for the worker that manages task submission
-// and completion management, as well as its disk storage.
+// and completion management, as well as its storage.
 //
 // The worker should only ever call public methods of this struct, and should
 // not read or write into any of its private fields.
@@ -23,23 +28,32 @@ import (
 // which is set to private because it is a freestanding function and we don't
 // want outside packages to be able to use it.
 type taskManager struct {
-	storagePath string
+	store storage.Store
+	key   string
 
 	tasks         *taskQueue
 	deferredTasks *taskQueue
 
+	// dropped is called with the tasks removed from the queues because a task they depend on failed for good.
+	dropped func(e task.Envelope, err error)
+
 	mu sync.RWMutex
 }
 
-// newTaskManager constructs and initializes a TaskManager.
-func newTaskManager(storagePath string) (*taskManager, error) {
+// newTaskManager constructs and initializes a TaskManager that stores the tasks of the
+// distro with the specified name. Tasks stored in legacyPath by older versions of the
+// agent are imported into the storage. The dropped callback is called, with no lock held,
+// for every task that will not run because a task it depends on failed for good.
+func newTaskManager(ctx context.Context, store storage.Store, distroName, legacyPath string, dropped func(task.Envelope, error)) (*taskManager, error) {
 	tm := taskManager{
-		storagePath:   storagePath,
+		store:         store,
+		key:           distroName,
 		tasks:         newTaskQueue(),
 		deferredTasks: newTaskQueue(),
+		dropped:       dropped,
 	}
 
-	if err := tm.load(); err != nil {
+	if err := tm.load(ctx, legacyPath); err != nil {
 		return &tm, err
 	}
 	return &tm, nil
@@ -73,68 +87,413 @@ func (tm *taskManager) Submit(deferred bool, tasks ...task.Task) error {
 	return tm.submitUnsafe(deferred, tasks...)
 }
 
+// SubmitGroup is like Submit, except that the tasks run in order and fail together: each task waits
+// for the previous one to complete, and the remaining ones are dropped if any of them fails for good.
+func (tm *taskManager) SubmitGroup(deferred bool, tasks ...task.Task) (err error) {
+	defer decorate.OnError(&err, "could not submit task group")
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	tm.queue(deferred, tm.envelopes(true, tasks...)...)
+	return tm.save()
+}
+
 // submitUnsafe is the thread-unsafe version of Submit.
 func (tm *taskManager) submitUnsafe(deferred bool, tasks ...task.Task) (err error) {
 	defer decorate.OnError(&err, "could not submit task")
 
+	tm.queue(deferred, tm.envelopes(false, tasks...)...)
+	return tm.save()
+}
+
+// queue adds the tasks to their queue, removing equivalent ones from both queues. It is not thread-safe
+// and does not store the queues.
+func (tm *taskManager) queue(deferred bool, envelopes ...task.Envelope) {
 	thisQueue := &tm.tasks
 	otherQueue := &tm.deferredTasks
 	if deferred {
 		thisQueue, otherQueue = otherQueue, thisQueue
 	}
 
-	for i := range tasks {
-		(*otherQueue).Remove(tasks[i])
-		(*thisQueue).Push(tasks[i])
+	for _, e := range envelopes {
+		(*otherQueue).Remove(e.Task)
+		(*thisQueue).Push(e)
 	}
+}
 
-	return tm.save()
+// envelopes wraps the tasks being submitted. Every task takes the place of the equivalent queued task it
+// overrides, if any, and waits for the queued tasks it depends on, running even if they fail. If group
+// is set, the tasks also run in order and fail together. It is not thread-safe.
+func (tm *taskManager) envelopes(group bool, tasks ...task.Task) []task.Envelope {
+	var groupID string
+	if group && len(tasks) > 1 {
+		groupID = uuid.NewString()
+	}
+
+	envelopes := make([]task.Envelope, 0, len(tasks))
+	for i, t := range tasks {
+		e := task.NewEnvelope(t)
+
+		if old, ok := tm.find(t); ok {
+			// The tasks waiting for the overridden task now wait for this one.
+			e.ID, e.After, e.WaitsFor, e.Group = old.ID, old.After, old.WaitsFor, old.Group
+		}
+
+		for _, dep := range task.DependenciesOf(t) {
+			if task.Is(dep, t) {
+				continue
+			}
+			if id := tm.idOf(dep, envelopes); id != "" && !slices.Contains(e.WaitsFor, id) {
+				e.WaitsFor = append(e.WaitsFor, id)
+			}
+		}
+
+		if e.ID == "" {
+			e.ID = uuid.NewString()
+		}
+
+		if groupID != "" {
+			e.Group = groupID
+			if i > 0 {
+				e.After = append(e.After, envelopes[i-1].ID)
+			}
+		}
+
+		envelopes = append(envelopes, e)
+	}
+
+	return envelopes
+}
+
+// Queued returns the tasks waiting in the regular queue and in the deferred one, in no particular order.
+func (tm *taskManager) Queued() (queued, deferred []task.Envelope) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	return tm.tasks.Data(), tm.deferredTasks.Data()
+}
+
+// Cancel removes the queued task with that ID from either queue, along with the tasks that depend on it,
+// and records the cancellation in the history. It returns the cancelled task, and false if no such task is queued.
+func (tm *taskManager) Cancel(id string) (cancelled task.Envelope, ok bool, err error) {
+	defer decorate.OnError(&err, "could not cancel task")
+
+	var dropped []task.Envelope
+	defer func() { tm.reportDropped(cancelled, dropped) }()
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	hasID := func(e task.Envelope) bool { return e.ID == id }
+	removed := append(tm.tasks.RemoveIf(hasID), tm.deferredTasks.RemoveIf(hasID)...)
+	if len(removed) == 0 {
+		return task.Envelope{}, false, nil
+	}
+	cancelled = removed[0]
+
+	dropped = tm.dropDependents(cancelled)
+	history := append([]HistoryEntry{newHistoryEntry(cancelled, ResultCancelled, task.ErrCancelled)}, droppedHistory(cancelled, dropped)...)
+
+	return cancelled, true, tm.save(history...)
+}
+
+// find returns the task equivalent to "t" in either queue. It is not thread-safe.
+func (tm *taskManager) find(t task.Task) (task.Envelope, bool) {
+	if e, ok := tm.tasks.Find(t); ok {
+		return e, true
+	}
+	return tm.deferredTasks.Find(t)
+}
+
+// idOf returns the ID of the task equivalent to "t", either among the ones being submitted or in the
+// queues, so that other tasks can wait for it. The task is given an ID if it has none. An empty string
+// is returned if no such task exists. It is not thread-safe.
+func (tm *taskManager) idOf(t task.Task, submitting []task.Envelope) string {
+	for i := range slices.Backward(submitting) {
+		if !task.Is(submitting[i].Task, t) {
+			continue
+		}
+		if submitting[i].ID == "" {
+			submitting[i].ID = uuid.NewString()
+		}
+		return submitting[i].ID
+	}
+
+	queued, ok := tm.find(t)
+	if !ok {
+		return ""
+	}
+	if queued.ID != "" {
+		return queued.ID
+	}
+
+	id := uuid.NewString()
+	setID := func(e *task.Envelope) {
+		if task.Is(e.Task, t) {
+			e.ID = id
+		}
+	}
+	tm.tasks.Update(setID)
+	tm.deferredTasks.Update(setID)
+
+	return id
+}
+
+// SubmitAtomically is like Submit, except that the tasks are stored by the transaction that commit
+// runs, which must call saveTasks within it. The tasks are only submitted if the commit succeeds.
+func (tm *taskManager) SubmitAtomically(deferred bool, commit func(saveTasks func(storage.Tx) error) error, tasks ...task.Task) (err error) {
+	defer decorate.OnError(&err, "could not submit task")
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	// Wrapping the tasks may give an ID to the queued tasks they depend on, so it comes first.
+	envelopes := tm.envelopes(false, tasks...)
+
+	// Compute the queues as they will be after the submission, without touching them yet.
+	queued, queuedDeferred := tm.tasks.Data(), tm.deferredTasks.Data()
+	thisQueue, otherQueue := &queued, &queuedDeferred
+	if deferred {
+		thisQueue, otherQueue = otherQueue, thisQueue
+	}
+
+	for _, e := range envelopes {
+		isEquivalent := func(q task.Envelope) bool { return task.Is(e.Task, q.Task) }
+		*otherQueue = removeIf(*otherQueue, isEquivalent)
+		*thisQueue = append(removeIf(*thisQueue, isEquivalent), e)
+	}
+
+	saveTasks := func(tx storage.Tx) error { return tm.write(tx, append(queued, queuedDeferred...)) }
+	if err := commit(saveTasks); err != nil {
+		return err
+	}
+
+	tm.queue(deferred, envelopes...)
+	return nil
 }
 
 // resubmit submits a task with lowest priority, meaning that it will be overridden
 // by any equivalent already in the queue.
-func (tm *taskManager) resubmit(t task.Task) (err error) {
+func (tm *taskManager) resubmit(e task.Envelope) (err error) {
 	defer decorate.OnError(&err, "could not re-submit task")
 
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
-	if tm.tasks.Contains(t) {
-		// No need to resubmit
-		return nil
+	if newer, ok := tm.tasks.Find(e.Task); ok {
+		// No need to resubmit, but the tasks waiting for this one must wait for the newer one.
+		if e.ID == "" || newer.ID == e.ID {
+			return nil
+		}
+		tm.redirect(e.ID, newer.Task)
+		return tm.save()
 	}
-	tm.deferredTasks.PushIfNew(t)
+	tm.deferredTasks.PushIfNew(e)
 
 	return tm.save()
 }
 
-// NextTask pulls the next task from the queue. If no task is queued, this function blocks until either a task is
-// submitted or the context is cancelled, whichever happens first.
+// promote moves the tasks that are still deferred to the regular queue.
+func (tm *taskManager) promote(tasks ...task.Task) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	// The queued task may have been overridden by an equivalent one: that is the one we promote.
+	// Tasks that are no longer deferred already ran or were enqueued since.
+	for _, queued := range tm.deferredTasks.Data() {
+		if !slices.ContainsFunc(tasks, func(t task.Task) bool { return task.Is(t, queued.Task) }) {
+			continue
+		}
+		tm.deferredTasks.Remove(queued.Task)
+		tm.tasks.Push(queued)
+	}
+}
+
+// NextTask pulls the next task from the queue, with the highest priority first. If no task is queued, this function
+// blocks until either a task is submitted or the context is cancelled, whichever happens first.
 // The second argument indicates whether a task was pulled or not.
-func (tm *taskManager) NextTask(ctx context.Context) (task.Task, bool) {
-	t := tm.tasks.Pull(ctx)
-	return t, t != nil
+func (tm *taskManager) NextTask(ctx context.Context) (task.Envelope, bool) {
+	return tm.tasks.Pull(ctx, tm.deferredTasks)
 }
 
 // TaskDone cleans up after a task is completed, and conditionally re-submits failed ones.
-func (tm *taskManager) TaskDone(ctx context.Context, t task.Task, taskResult error) (err error) {
-	defer decorate.OnError(&err, "task %s", t)
+// It returns the delay after which a re-submitted task is to be retried, or zero if it was not re-submitted.
+func (tm *taskManager) TaskDone(ctx context.Context, e task.Envelope, taskResult error) (retryIn time.Duration, err error) {
+	defer decorate.OnError(&err, "task %s", e.Task)
 
 	if errors.As(taskResult, &task.NeedsRetryError{}) {
-		log.Errorf(ctx, "%v", taskResult) // Error message already mentions resubmission
-		return tm.resubmit(t)
+		return tm.AttemptFailed(ctx, e, taskResult)
 	}
 
-	if err := tm.save(); err != nil {
-		return fmt.Errorf("cleanup: could not save task queue: %v", err)
+	if err := tm.Discard(e, taskResult); err != nil {
+		return 0, fmt.Errorf("cleanup: %v", err)
 	}
 
 	if taskResult == nil {
-		return nil
+		return 0, nil
 	}
 
 	log.Errorf(ctx, "failed and will not be retried: %v", taskResult)
-	return taskResult
+	return 0, taskResult
+}
+
+// Discard stores the queues after a task was pulled and will not run again, along with its outcome in
+// the history. If it did not complete successfully, the tasks that depend on it are dropped.
+func (tm *taskManager) Discard(e task.Envelope, result error) (err error) {
+	defer decorate.OnError(&err, "could not save task queue")
+
+	var dropped []task.Envelope
+	defer func() { tm.reportDropped(e, dropped) }()
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	r := resultOf(result)
+	if r == ResultSucceeded || r == ResultFailed || (r == ResultCancelled && !e.Started.IsZero()) {
+		// The task ran
+		e.Attempts++
+	}
+	history := []HistoryEntry{newHistoryEntry(e, r, result)}
+
+	if result != nil {
+		dropped = tm.dropDependents(e)
+		history = append(history, droppedHistory(e, dropped)...)
+	}
+
+	return tm.save(history...)
+}
+
+// dropDependents removes the queued tasks that can no longer run because a task failed for good: the
+// rest of its group, and the tasks that must run after any of the removed ones. The tasks that merely
+// wait for them are kept. It returns the removed tasks. It is not thread-safe and does not store the queues.
+func (tm *taskManager) dropDependents(failed task.Envelope) (dropped []task.Envelope) {
+	failedIDs := make(map[string]bool)
+	if failed.ID != "" {
+		failedIDs[failed.ID] = true
+	}
+
+	mustDrop := func(e task.Envelope) bool {
+		if failed.Group != "" && e.Group == failed.Group {
+			return true
+		}
+		return slices.ContainsFunc(e.After, func(id string) bool { return failedIDs[id] })
+	}
+
+	for {
+		removed := append(tm.tasks.RemoveIf(mustDrop), tm.deferredTasks.RemoveIf(mustDrop)...)
+		if len(removed) == 0 {
+			return dropped
+		}
+
+		for _, e := range removed {
+			if e.ID != "" {
+				failedIDs[e.ID] = true
+			}
+		}
+		dropped = append(dropped, removed...)
+	}
+}
+
+// reportDropped calls the dropped callback for every task dropped because the other one failed.
+// It must be called with no lock held.
+func (tm *taskManager) reportDropped(failed task.Envelope, dropped []task.Envelope) {
+	if tm.dropped == nil {
+		return
+	}
+
+	for _, e := range dropped {
+		tm.dropped(e, prerequisiteFailed(failed))
+	}
+}
+
+// droppedHistory returns the history entries of the tasks dropped because the other one failed.
+func droppedHistory(failed task.Envelope, dropped []task.Envelope) []HistoryEntry {
+	history := make([]HistoryEntry, 0, len(dropped))
+	for _, e := range dropped {
+		history = append(history, newHistoryEntry(e, ResultDropped, prerequisiteFailed(failed)))
+	}
+	return history
+}
+
+// prerequisiteFailed is the error of the tasks dropped because the failed one did not complete.
+func prerequisiteFailed(failed task.Envelope) error {
+	return fmt.Errorf("%w: %s", task.ErrPrerequisiteFailed, failed.Task)
+}
+
+// redirect makes the tasks waiting for the one with the old ID wait for the queued task "to" instead,
+// giving it an ID if it has none. It is not thread-safe and does not store the queues.
+func (tm *taskManager) redirect(oldID string, to task.Task) {
+	newID := tm.idOf(to, nil)
+
+	replace := func(e *task.Envelope) {
+		for _, ids := range [][]string{e.After, e.WaitsFor} {
+			for i := range ids {
+				if ids[i] == oldID {
+					ids[i] = newID
+				}
+			}
+		}
+	}
+	tm.tasks.Update(replace)
+	tm.deferredTasks.Update(replace)
+}
+
+// AttemptFailed counts a failed attempt at running the task, and re-submits it as deferred. If the task
+// exhausted its retry policy, it is moved to the dead-letter list instead.
+// It returns the delay after which the re-submitted task is to be retried, or zero if it was not re-submitted.
+func (tm *taskManager) AttemptFailed(ctx context.Context, e task.Envelope, cause error) (retryIn time.Duration, err error) {
+	defer decorate.OnError(&err, "could not re-submit task")
+
+	var dropped []task.Envelope
+	defer func() { tm.reportDropped(e, dropped) }()
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	e.Attempts++
+	e.LastError = cause.Error()
+
+	if newer, ok := tm.find(e.Task); ok {
+		// An equivalent task was submitted in the meantime: it supersedes this one, also for the tasks waiting for it.
+		if e.ID != "" && newer.ID != e.ID {
+			tm.redirect(e.ID, newer.Task)
+		}
+		return 0, tm.save(newHistoryEntry(e, ResultFailed, cause))
+	}
+
+	policy := task.RetryPolicyOf(e.Task)
+	if policy.Exhausted(e.Attempts) {
+		log.Errorf(ctx, "Task %s: giving up after %d attempts: %v", e.Task, e.Attempts, cause)
+
+		dl := DeadLetter{
+			ID:       e.ID,
+			Type:     task.TypeID(e.Task),
+			Task:     fmt.Sprint(e.Task),
+			Attempts: e.Attempts,
+			Error:    e.LastError,
+			Time:     time.Now(),
+		}
+
+		dropped = tm.dropDependents(e)
+		history := append([]HistoryEntry{newHistoryEntry(e, ResultFailed, cause)}, droppedHistory(e, dropped)...)
+
+		return 0, tm.store.Update(func(tx storage.Tx) error {
+			if err := tm.write(tx, append(tm.tasks.Data(), tm.deferredTasks.Data()...)); err != nil {
+				return err
+			}
+			if err := appendHistory(tx, tm.key, history...); err != nil {
+				return err
+			}
+			return appendDeadLetter(tx, tm.key, dl)
+		})
+	}
+
+	retryIn = policy.Delay(e.Attempts)
+	log.Errorf(ctx, "Task %s: attempt %d failed, retrying in %s: %v", e.Task, e.Attempts, retryIn.Round(time.Second), cause)
+
+	tm.deferredTasks.PushIfNew(e)
+	return retryIn, tm.save(newHistoryEntry(e, ResultRetrying, cause))
 }
 
 // EnqueueDeferredTasks takes all deferred tasks and promotes them
@@ -143,49 +502,134 @@ func (tm *taskManager) EnqueueDeferredTasks() {
 	tm.tasks.Absorb(tm.deferredTasks)
 }
 
-// save writes the current task queue (plus deferred tasks) to file.
-func (tm *taskManager) save() (err error) {
-	defer decorate.OnError(&err, "could not save queued tasks to disk")
+// save writes the current task queue (plus deferred tasks) to the storage, along with the entries to
+// add to the history. It is not thread-safe.
+func (tm *taskManager) save(history ...HistoryEntry) (err error) {
+	defer decorate.OnError(&err, "could not save queued tasks to storage")
 
 	tasks := append(tm.tasks.Data(), tm.deferredTasks.Data()...)
 
-	out, err := task.MarshalYAML(tasks)
+	return tm.store.Update(func(tx storage.Tx) error {
+		if err := tm.write(tx, tasks); err != nil {
+			return err
+		}
+		return appendHistory(tx, tm.key, history...)
+	})
+}
+
+// write stores the tasks in the transaction.
+func (tm *taskManager) write(tx storage.Tx, tasks []task.Envelope) error {
+	out, err := task.MarshalEnvelopes(tasks)
 	if err != nil {
 		return err
 	}
 
-	if err = os.WriteFile(tm.storagePath+".new", out, 0600); err != nil {
+	return tx.Put(tasksBucket, tm.key, out)
+}
+
+// load loads tasks from the storage, after importing them from the legacy file if it exists.
+// The stored tasks that cannot be decoded are moved into quarantine, so that the others are
+// not lost with them.
+func (tm *taskManager) load(ctx context.Context, legacyPath string) (err error) {
+	defer decorate.OnError(&err, "could not load tasks from storage")
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	err = storage.ImportFile(ctx, tm.store, legacyPath, func(tx storage.Tx, contents []byte) error {
+		return tx.Put(tasksBucket, tm.key, contents)
+	})
+	if err != nil {
 		return err
 	}
 
-	if err = os.Rename(tm.storagePath+".new", tm.storagePath); err != nil {
+	var out []byte
+	err = tm.store.View(func(tx storage.Tx) error {
+		out = tx.Get(tasksBucket, tm.key)
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	if out == nil {
+		return nil
+	}
 
-// Load loads tasks from file.
-func (tm *taskManager) load() (err error) {
-	defer decorate.OnError(&err, "could not load tasks from disk")
+	tasks, undecodable, err := task.UnmarshalEnvelopes(out)
+	if err != nil {
+		return err
+	}
 
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
+	if len(undecodable) > 0 {
+		quarantined := make([]QuarantinedTask, 0, len(undecodable))
+		for _, u := range undecodable {
+			log.Warningf(ctx, "Distro %q: quarantining stored task that could not be decoded: %v", tm.key, u.Err)
+			quarantined = append(quarantined, QuarantinedTask{Task: string(u.Raw), Error: u.Err.Error(), Time: time.Now()})
+		}
 
-	out, err := os.ReadFile(tm.storagePath)
-	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return nil
+		err = tm.store.Update(func(tx storage.Tx) error {
+			if err := appendQuarantine(tx, tm.key, quarantined...); err != nil {
+				return err
+			}
+			return tm.write(tx, tasks)
+		})
+		if err != nil {
+			return err
 		}
-		return err
 	}
 
-	var tasks []task.Task
-	if tasks, err = task.UnmarshalYAML(out); err != nil {
-		return err
+	// Tasks stored by older versions of the agent may have no ID, which is needed to cancel them.
+	for i := range tasks {
+		if tasks[i].ID == "" {
+			tasks[i].ID = uuid.NewString()
+		}
 	}
 
 	tm.tasks.Load(tasks)
 
 	return nil
 }
+
+// StoredTasks returns the task queues kept in the storage, serialized and indexed by distro name.
+func StoredTasks(s storage.Store) (tasks map[string][]byte, err error) {
+	defer decorate.OnError(&err, "could not read tasks from storage")
+
+	tasks = make(map[string][]byte)
+	err = s.View(func(tx storage.Tx) error {
+		return tx.ForEach(tasksBucket, func(distroName string, value []byte) error {
+			tasks[distroName] = value
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// RenameStoredTasks moves the task queue, dead letters, quarantined tasks, history and package inventories kept in the storage for a distro to its new name.
+// Nothing is done for those that are not stored under the old name.
+//
+// The worker of the old distro must be stopped beforehand, otherwise it could store its tasks again.
+func RenameStoredTasks(s storage.Store, oldName, newName string) (err error) {
+	defer decorate.OnError(&err, "could not move stored tasks from %q to %q", oldName, newName)
+
+	return s.Update(func(tx storage.Tx) error {
+		for _, bucket := range []string{tasksBucket, deadLettersBucket, quarantineBucket, historyBucket, inventoryBucket} {
+			out := tx.Get(bucket, oldName)
+			if out == nil {
+				continue
+			}
+
+			if err := tx.Put(bucket, newName, out); err != nil {
+				return err
+			}
+			if err := tx.Delete(bucket, oldName); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -2,14 +2,15 @@ package worker
 
 import (
 	"context"
+	"slices"
 	"sync"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 )
 
-// taskQueue is a queue that allows pushing and pulling tasks from a FIFO queue,
+// taskQueue is a queue that allows pushing and pulling tasks from a priority queue,
 // with the particularity that duplicated elements will be removed in favour of
-// the latest one.
+// the latest one. Tasks with the same priority are pulled in FIFO order.
 //
 // Pulling from an empty queue will wait until it is no longer empty.
 //
@@ -18,7 +19,7 @@ import (
 type taskQueue struct {
 	mu   sync.RWMutex
 	wait chan struct{}
-	data []task.Task
+	data []task.Envelope
 }
 
 // newWaitChannel creates a channel to notify waiters of new tasks.
@@ -32,12 +33,12 @@ func newTaskQueue() *taskQueue {
 	return &taskQueue{
 		mu:   sync.RWMutex{},
 		wait: newWaitChannel(),
-		data: make([]task.Task, 0),
+		data: make([]task.Envelope, 0),
 	}
 }
 
 // Load replaces the existing data with the one in "newData".
-func (q *taskQueue) Load(newData []task.Task) {
+func (q *taskQueue) Load(newData []task.Envelope) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
@@ -59,7 +60,7 @@ func (q *taskQueue) Absorb(other *taskQueue) {
 
 	close(other.wait)
 	other.wait = newWaitChannel()
-	other.data = make([]task.Task, 0)
+	other.data = make([]task.Envelope, 0)
 
 	close(q.wait)
 	q.wait = newWaitChannel()
@@ -75,66 +76,87 @@ func (q *taskQueue) Len() int {
 }
 
 // Data returns a copy of all the queued tasks.
-func (q *taskQueue) Data() []task.Task {
+func (q *taskQueue) Data() []task.Envelope {
 	q.mu.RLock()
 	defer q.mu.RUnlock()
 
-	return append([]task.Task{}, q.data...)
+	return append([]task.Envelope{}, q.data...)
 }
 
 // Push adds a task to the queue. Any existing equivalent tasks are removed.
-func (q *taskQueue) Push(t task.Task) {
+func (q *taskQueue) Push(e task.Envelope) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
 	// Remove copies of this task
-	q.data = removeIf(q.data, func(queued task.Task) bool { return task.Is(t, queued) })
+	q.data = removeIf(q.data, func(queued task.Envelope) bool { return task.Is(e.Task, queued.Task) })
 
 	// Append task
-	q.data = append(q.data, t)
+	q.data = append(q.data, e)
 
-	// Notify waiters if there are any
-	select {
-	case q.wait <- struct{}{}:
-	default:
-	}
+	q.notify()
 }
 
 // Push adds a task to the queue unless an equivalent task is queued already.
 // Useful for re-submitting failed tasks.
-func (q *taskQueue) PushIfNew(t task.Task) {
+func (q *taskQueue) PushIfNew(e task.Envelope) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
 	// Check if this task exists already
 	for _, queued := range q.data {
-		if task.Is(queued, t) {
+		if task.Is(queued.Task, e.Task) {
 			return
 		}
 	}
 
 	// Append task
-	q.data = append(q.data, t)
+	q.data = append(q.data, e)
 
-	// Notify waiters if there are any
-	select {
-	case q.wait <- struct{}{}:
-	default:
-	}
+	q.notify()
 }
 
-// Contains returns true if a task equivalent to "t" is queued.
-func (q *taskQueue) Contains(t task.Task) bool {
+// Find returns the queued task equivalent to "t", if any.
+func (q *taskQueue) Find(t task.Task) (task.Envelope, bool) {
 	q.mu.RLock()
 	defer q.mu.RUnlock()
 
 	for _, queued := range q.data {
-		if task.Is(queued, t) {
-			return true
+		if task.Is(queued.Task, t) {
+			return queued, true
 		}
 	}
 
-	return false
+	return task.Envelope{}, false
+}
+
+// Update calls f on every queued task so it can modify them in place.
+func (q *taskQueue) Update(f func(*task.Envelope)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i := range q.data {
+		f(&q.data[i])
+	}
+
+	// Tasks may no longer be waiting for others.
+	q.notify()
+}
+
+// RemoveIf erases all tasks that satisfy the predicate, and returns them.
+func (q *taskQueue) RemoveIf(predicate func(task.Envelope) bool) []task.Envelope {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var removed []task.Envelope
+	for _, queued := range q.data {
+		if predicate(queued) {
+			removed = append(removed, queued)
+		}
+	}
+	q.data = removeIf(q.data, predicate)
+
+	return removed
 }
 
 // Remove erases all tasks that are equivalent to "t".
@@ -142,25 +164,28 @@ func (q *taskQueue) Remove(t task.Task) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	q.data = removeIf(q.data, func(queued task.Task) bool { return task.Is(t, queued) })
+	q.data = removeIf(q.data, func(queued task.Envelope) bool { return task.Is(t, queued.Task) })
 }
 
-// Pull pops the first task in the queue. If the queue is empty, this function
-// blocks until a task is Pushed, Loaded or Absorved.
+// Pull pops the task with the highest priority in the queue among the ones that are not waiting for
+// others. A task waits as long as any of the tasks it runs after is queued, either in this queue or in
+// the "others" queue (which may be nil). If there is no such task, this function blocks until a task is
+// Pushed, Loaded or Absorved. The second return value is false if the context was cancelled before any
+// task could be pulled.
 //
 // Concurrent pulls are safe but the order in which they are served in is
 // indeterminate.
-func (q *taskQueue) Pull(ctx context.Context) task.Task {
+func (q *taskQueue) Pull(ctx context.Context, others *taskQueue) (task.Envelope, bool) {
 	// Avoid races if the context is cancelled already
 	select {
 	case <-ctx.Done():
-		return nil
+		return task.Envelope{}, false
 	default:
 	}
 
 	for {
-		if task, ok := q.tryPopFront(); ok {
-			return task
+		if e, ok := q.tryPopFront(others); ok {
+			return e, true
 		}
 
 		q.mu.RLock()
@@ -170,15 +195,15 @@ func (q *taskQueue) Pull(ctx context.Context) task.Task {
 
 		select {
 		case <-ctx.Done():
-			return nil
+			return task.Envelope{}, false
 		case <-wait:
 			// ↑
 			// | Race here: another goroutine could "steal" the
 			// | only entry in the queue. Or an empty Load could
 			// | leave an empty "data" behind.
 			// ↓
-			if task, ok := q.tryPopFront(); ok {
-				return task
+			if e, ok := q.tryPopFront(others); ok {
+				return e, true
 			}
 			// Solution to race: just try again
 		}
@@ -186,22 +211,72 @@ func (q *taskQueue) Pull(ctx context.Context) task.Task {
 }
 
 // tryPopFront is a helper function not to be used outside. Equivalent to Pull but without
-// waiting. It returns false if the queue is empty.
-func (q *taskQueue) tryPopFront() (task.Task, bool) {
+// waiting. It returns false if no task can be pulled.
+func (q *taskQueue) tryPopFront(others *taskQueue) (task.Envelope, bool) {
+	// Read the other queue before locking this one, so as not to deadlock with Absorb.
+	var pending map[string]bool
+	if others != nil {
+		pending = others.ids()
+	}
+
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	if len(q.data) == 0 {
-		return nil, false
+	for _, queued := range q.data {
+		if queued.ID != "" {
+			if pending == nil {
+				pending = make(map[string]bool)
+			}
+			pending[queued.ID] = true
+		}
 	}
 
-	r := q.data[0]
-	q.data = q.data[1:]
+	// The first of the tasks with the highest priority that is not waiting for others
+	front := -1
+	for i := range q.data {
+		isPending := func(id string) bool { return pending[id] }
+		if slices.ContainsFunc(q.data[i].After, isPending) || slices.ContainsFunc(q.data[i].WaitsFor, isPending) {
+			continue
+		}
+		if front == -1 || q.data[i].Priority > q.data[front].Priority {
+			front = i
+		}
+	}
+
+	if front == -1 {
+		return task.Envelope{}, false
+	}
+
+	r := q.data[front]
+	q.data = slices.Delete(q.data, front, front+1)
 	return r, true
 }
 
+// notify wakes up a waiter, if there is any, so that it looks for a task to pull.
+// The mutex must be held.
+func (q *taskQueue) notify() {
+	select {
+	case q.wait <- struct{}{}:
+	default:
+	}
+}
+
+// ids returns the set of IDs of the queued tasks.
+func (q *taskQueue) ids() map[string]bool {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	ids := make(map[string]bool)
+	for _, queued := range q.data {
+		if queued.ID != "" {
+			ids[queued.ID] = true
+		}
+	}
+	return ids
+}
+
 // removeIf removes all elements that satisfy the predicate from the array.
-func removeIf(array []task.Task, predicate func(task.Task) bool) []task.Task {
+func removeIf(array []task.Envelope, predicate func(task.Envelope) bool) []task.Envelope {
 	// Accepts or rejects every entry of the slice, pushing accepted
 	// entries to the end of the accepted region.
 	//
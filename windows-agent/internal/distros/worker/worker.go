@@ -6,11 +6,14 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"sync"
 	"time"
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/wakepolicy"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
 	"github.com/ubuntu/decorate"
 )
 
@@ -19,16 +22,24 @@ type distro interface {
 
 	LockAwake() error
 	ReleaseAwake() error
+	WaitWakeAllowed(context.Context) error
 
 	IsValid() bool
 	Invalidate(context.Context)
+
+	RecordTaskResult(task.Task, error)
 }
 
 // Connection encapsulates the logic behind sending and receiving messages
 // with the WSL-Pro-Service.
 type Connection interface {
-	SendProAttachment(proToken string) error
-	SendLandscapeConfig(lpeConfig string) error
+	SendProAttachment(ctx context.Context, proToken string) error
+	SendLandscapeConfig(ctx context.Context, lpeConfig string) error
+	SendProxyConfig(ctx context.Context, httpProxy, httpsProxy, noProxy string) error
+	SendRunCommand(ctx context.Context, cmd task.Command) (task.CommandOutput, error)
+	SendProServices(ctx context.Context, enable, disable []string) ([]task.ServiceResult, error)
+	SendSecurityUpdate(ctx context.Context) (task.SecurityUpdateReport, error)
+	SendPackageInventory(ctx context.Context) ([]task.Package, error)
 	Close()
 }
 
@@ -40,24 +51,60 @@ type Worker struct {
 	cancel     context.CancelFunc
 	processing chan struct{}
 
-	conn   Connection
-	connMu sync.RWMutex
+	// notAllowed are the tasks deferred because the wake policy did not allow waking the distro up.
+	// They are enqueued again once it does, unless the distro started and ran them in the meantime.
+	notAllowed   []task.Task
+	notAllowedMu sync.Mutex
+	waiting      sync.WaitGroup
+
+	// running is the task being processed, and cancelRunning interrupts it.
+	running       task.Envelope
+	cancelRunning context.CancelCauseFunc
+	runningMu     sync.Mutex
+
+	// conn is the connection to the WSL-Pro-Service, and connGen counts how many times it was set, so that
+	// tasks can tell whether it was replaced while they ran. connChanged is closed, then replaced, whenever
+	// the connection changes, to wake up the tasks waiting for one.
+	conn        Connection
+	connGen     uint64
+	connChanged chan struct{}
+	connMu      sync.RWMutex
 }
 
+const (
+	// connectionTimeout is how long each attempt waits for the distro to connect.
+	connectionTimeout = 30 * time.Second
+
+	// connectionAttempts is how many times a task wakes the distro up and waits for it to connect before
+	// it is considered unreachable.
+	connectionAttempts = 5
+
+	// maxReconnects is how many times a task runs again right away when the connection it ran on was replaced.
+	maxReconnects = 3
+)
+
 // New creates a new worker and starts it. Call Stop when you're done to avoid leaking the task execution goroutine.
-func New(ctx context.Context, d distro, storageDir string) (w *Worker, err error) {
+//
+// Tasks are kept in the store. Tasks that older versions of the agent stored in storageDir are imported into it.
+func New(ctx context.Context, d distro, storageDir string, store storage.Store) (w *Worker, err error) {
 	defer decorate.OnError(&err, "distro %q: could not create worker", d.Name())
 
-	storagePath := filepath.Join(storageDir, d.Name()+".tasks")
+	legacyPath := filepath.Join(storageDir, d.Name()+".tasks")
+
+	dropped := func(e task.Envelope, err error) {
+		log.Warningf(ctx, "Distro %q: task %q: dropped: %v", d.Name(), e.Task, err)
+		d.RecordTaskResult(e.Task, err)
+	}
 
-	tm, err := newTaskManager(storagePath)
+	tm, err := newTaskManager(ctx, store, d.Name(), legacyPath, dropped)
 	if err != nil {
 		return nil, err
 	}
 
 	w = &Worker{
-		distro:  d,
-		manager: tm,
+		distro:      d,
+		manager:     tm,
+		connChanged: make(chan struct{}),
 	}
 
 	w.start(ctx)
@@ -83,7 +130,8 @@ func (w *Worker) Connection() Connection {
 	return w.conn
 }
 
-// SetConnection removes the connection associated with the distro.
+// SetConnection replaces the connection associated with the distro, closing the previous one.
+// Setting it to nil removes it. The tasks waiting for a connection are notified.
 func (w *Worker) SetConnection(conn Connection) {
 	w.connMu.Lock()
 	defer w.connMu.Unlock()
@@ -93,6 +141,19 @@ func (w *Worker) SetConnection(conn Connection) {
 	}
 
 	w.conn = conn
+	w.connGen++
+
+	close(w.connChanged)
+	w.connChanged = make(chan struct{})
+}
+
+// connection returns the current connection and its generation, along with a channel that is closed
+// when the connection changes.
+func (w *Worker) connection() (Connection, uint64, <-chan struct{}) {
+	w.connMu.RLock()
+	defer w.connMu.RUnlock()
+
+	return w.conn, w.connGen, w.connChanged
 }
 
 // start starts the main task processing goroutine.
@@ -110,6 +171,7 @@ func (w *Worker) Stop(ctx context.Context) {
 	log.Debugf(ctx, "Distro %q: stopping task processing", w.distro.Name())
 	w.cancel()
 	<-w.processing
+	w.waiting.Wait()
 	w.SetConnection(nil)
 }
 
@@ -146,39 +208,279 @@ func (w *Worker) SubmitDeferredTasks(tasks ...task.Task) (err error) {
 	return w.manager.Submit(true, tasks...)
 }
 
+// SubmitTaskGroup enqueues tasks that run in the order they are given and fail together: each task
+// waits for the previous one to complete successfully, and if any of them fails for good, the remaining
+// ones are dropped. The tasks will wake up the distro.
+//
+// It will return an error if the distro has been cleaned up.
+func (w *Worker) SubmitTaskGroup(tasks ...task.Task) (err error) {
+	defer decorate.OnError(&err, "distro %q: tasks %q: could not submit", w.distro.Name(), tasks)
+
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	log.Infof(context.TODO(), "Distro %q: Submitting task group %q to queue", w.distro.Name(), tasks)
+	return w.manager.SubmitGroup(false, tasks...)
+}
+
+// SubmitDeferredTaskGroup is like SubmitTaskGroup, except that the tasks won't wake up the distro,
+// instead wait until it is awake.
+func (w *Worker) SubmitDeferredTaskGroup(tasks ...task.Task) (err error) {
+	defer decorate.OnError(&err, "distro %q: tasks %q: could not submit", w.distro.Name(), tasks)
+
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	log.Infof(context.TODO(), "Distro %q: Submitting task group %q to queue", w.distro.Name(), tasks)
+	return w.manager.SubmitGroup(true, tasks...)
+}
+
+// SubmitDeferredTasksAtomically is like SubmitDeferredTasks, except that the tasks are stored by the
+// transaction that commit runs, which must call saveTasks within it. This allows storing the tasks
+// atomically along with other changes. The tasks are only submitted if commit succeeds.
+func (w *Worker) SubmitDeferredTasksAtomically(commit func(saveTasks func(storage.Tx) error) error, tasks ...task.Task) (err error) {
+	defer decorate.OnError(&err, "distro %q: tasks %q: could not submit", w.distro.Name(), tasks)
+
+	log.Infof(context.TODO(), "Distro %q: Submitting tasks %q to queue", w.distro.Name(), tasks)
+
+	return w.manager.SubmitAtomically(true, commit, tasks...)
+}
+
 // EnqueueDeferredTasks takes all deferred tasks and promotes them
 // to regular tasks.
 func (w *Worker) EnqueueDeferredTasks() {
 	w.manager.EnqueueDeferredTasks()
 }
 
+// QueuedTask is a task that did not complete yet.
+type QueuedTask struct {
+	task.Envelope
+
+	// Running is true for the task being processed.
+	Running bool
+	// Deferred is true for the tasks that wait for the distro to be started by someone else.
+	Deferred bool
+}
+
+// QueuedTasks returns the tasks that did not complete yet: the running one first, if any, then the
+// queued ones by decreasing priority, then the deferred ones.
+func (w *Worker) QueuedTasks() []QueuedTask {
+	var tasks []QueuedTask
+
+	w.runningMu.Lock()
+	if w.cancelRunning != nil {
+		tasks = append(tasks, QueuedTask{Envelope: w.running, Running: true})
+	}
+	w.runningMu.Unlock()
+
+	queued, deferred := w.manager.Queued()
+	byPriority := func(a, b task.Envelope) int { return b.Priority - a.Priority }
+	slices.SortStableFunc(queued, byPriority)
+	slices.SortStableFunc(deferred, byPriority)
+
+	for _, e := range queued {
+		tasks = append(tasks, QueuedTask{Envelope: e})
+	}
+	for _, e := range deferred {
+		tasks = append(tasks, QueuedTask{Envelope: e, Deferred: true})
+	}
+
+	return tasks
+}
+
+// CancelTask cancels the task with that ID. A queued task is removed from the queue along with the
+// tasks that depend on it. A running task is interrupted along with the commands it runs in the distro,
+// and the tasks that depend on it are dropped once it stops.
+func (w *Worker) CancelTask(ctx context.Context, id string) (err error) {
+	defer decorate.OnError(&err, "distro %q: could not cancel task %q", w.distro.Name(), id)
+
+	if id == "" {
+		return errors.New("no task ID")
+	}
+
+	w.runningMu.Lock()
+	if w.cancelRunning != nil && w.running.ID == id {
+		log.Infof(ctx, "Distro %q: task %q: cancelling while running", w.distro.Name(), w.running.Task)
+		w.cancelRunning(task.ErrCancelled)
+		w.runningMu.Unlock()
+		return nil
+	}
+	w.runningMu.Unlock()
+
+	e, ok, err := w.manager.Cancel(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("no such task is queued or running")
+	}
+
+	log.Infof(ctx, "Distro %q: task %q: cancelled before running", w.distro.Name(), e.Task)
+	w.distro.RecordTaskResult(e.Task, task.ErrCancelled)
+
+	return nil
+}
+
+// setRunning sets the task being processed, and the function to cancel it. A nil cancel function
+// means that no task is running.
+func (w *Worker) setRunning(e task.Envelope, cancel context.CancelCauseFunc) {
+	w.runningMu.Lock()
+	defer w.runningMu.Unlock()
+
+	w.running = e
+	w.cancelRunning = cancel
+}
+
 // processTasks is the main loop for the distro, processing any existing tasks while starting and releasing
 // locks to distro,.
 func (w *Worker) processTasks(ctx context.Context) {
 	defer close(w.processing)
 
 	for {
-		t, ok := w.manager.NextTask(ctx)
+		e, ok := w.manager.NextTask(ctx)
 		if !ok {
 			return
 		}
+		t := e.Task
 
-		resultErr := w.processSingleTask(ctx, t)
+		if e.Expired(time.Now()) {
+			log.Warningf(ctx, "Distro %q: task %q: discarded because it expired at %s", w.distro.Name(), t, e.Expires.Format(time.DateTime))
+			w.distro.RecordTaskResult(t, task.ErrExpired)
+			if err := w.manager.Discard(e, task.ErrExpired); err != nil {
+				log.Errorf(ctx, "Distro %q: %v", w.distro.Name(), err)
+			}
+			continue
+		}
+
+		e.Started = time.Now()
+		e.Report = ""
+		e.RebootRequired = false
+
+		taskCtx, cancelTask := context.WithCancelCause(ctx)
+		w.setRunning(e, cancelTask)
+
+		taskCtx = task.WithReporter(taskCtx, func(r string) { e.Report = r })
+		taskCtx = task.WithRebootNotifier(taskCtx, func() { e.RebootRequired = true })
+		taskCtx = task.WithInventoryRecorder(taskCtx, w.manager.recordInventory)
+		resultErr := w.processSingleTask(taskCtx, t)
+
+		w.setRunning(task.Envelope{}, nil)
+		cancelled := errors.Is(context.Cause(taskCtx), task.ErrCancelled)
+		cancelTask(nil)
+
+		if resultErr != nil && cancelled {
+			log.Infof(ctx, "Distro %q: task %q: cancelled: %v", w.distro.Name(), t, resultErr)
+			w.distro.RecordTaskResult(t, task.ErrCancelled)
+			if err := w.manager.Discard(e, task.ErrCancelled); err != nil {
+				log.Errorf(ctx, "Distro %q: %v", w.distro.Name(), err)
+			}
+			continue
+		}
+
+		if errors.Is(resultErr, wakepolicy.ErrNotAllowed) {
+			log.Infof(ctx, "Distro %q: task %q: deferred until the distro can be woken up: %v", w.distro.Name(), t, resultErr)
+			w.deferUntilWakeAllowed(ctx, e)
+			continue
+		}
+
+		if errors.Is(resultErr, errInvalidDistro) {
+			// The distro was not contacted, so the attempt does not count. The task remains stored for the worker
+			// of the distro once it is valid again.
+			log.Infof(ctx, "Distro %q: task %q: kept for when the distro is valid again", w.distro.Name(), t)
+			if err := w.manager.resubmit(e); err != nil {
+				log.Errorf(ctx, "Distro %q: task %q: %v", w.distro.Name(), t, err)
+			}
+			continue
+		}
 
 		var target unreachableDistroError
 		if errors.As(resultErr, &target) {
 			log.Errorf(ctx, "Distro %q: task %q: distro not reachable: %v", w.distro.Name(), t, target.sourceErr)
+			// There is no point in retrying after a delay, as the distro is invalidated: the task is retried by the
+			// worker of the distro once it is valid again.
+			if _, err := w.manager.AttemptFailed(ctx, e, resultErr); err != nil {
+				log.Errorf(ctx, "Distro %q: task %q: %v", w.distro.Name(), t, err)
+			}
 			w.distro.Invalidate(ctx)
 			continue
 		}
 
-		err := w.manager.TaskDone(ctx, t, resultErr)
+		w.distro.RecordTaskResult(t, resultErr)
+
+		retryIn, err := w.manager.TaskDone(ctx, e, resultErr)
 		if err != nil {
 			log.Errorf(ctx, "Distro %q: %v", w.distro.Name(), err)
 		}
+		if retryIn > 0 {
+			w.retryAfter(ctx, t, retryIn)
+		}
+	}
+}
+
+// retryAfter enqueues the deferred task again once the delay is over, unless it ran in the meantime.
+func (w *Worker) retryAfter(ctx context.Context, t task.Task, delay time.Duration) {
+	w.waiting.Add(1)
+	go func() {
+		defer w.waiting.Done()
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			// Stopping: the task remains deferred.
+			return
+		case <-timer.C:
+		}
+
+		log.Debugf(ctx, "Distro %q: task %q: retrying", w.distro.Name(), t)
+		w.manager.promote(t)
+	}()
+}
+
+// deferUntilWakeAllowed submits the task as deferred, so that it runs whenever the distro starts.
+// It is also enqueued again as soon as the wake policy allows waking the distro up.
+func (w *Worker) deferUntilWakeAllowed(ctx context.Context, e task.Envelope) {
+	if err := w.manager.resubmit(e); err != nil {
+		log.Errorf(ctx, "Distro %q: %v", w.distro.Name(), err)
+		return
+	}
+
+	w.notAllowedMu.Lock()
+	defer w.notAllowedMu.Unlock()
+
+	w.notAllowed = append(w.notAllowed, e.Task)
+	if len(w.notAllowed) > 1 {
+		// Already waiting
+		return
 	}
+
+	w.waiting.Add(1)
+	go func() {
+		defer w.waiting.Done()
+
+		err := w.distro.WaitWakeAllowed(ctx)
+
+		w.notAllowedMu.Lock()
+		tasks := w.notAllowed
+		w.notAllowed = nil
+		w.notAllowedMu.Unlock()
+
+		if err != nil {
+			// Stopping: the tasks remain deferred.
+			return
+		}
+
+		log.Debugf(ctx, "Distro %q: the wake policy allows waking the distro up again", w.distro.Name())
+		w.manager.promote(tasks...)
+	}()
 }
 
+// errInvalidDistro is the error of the tasks that were not run because the distro is marked as invalid.
+var errInvalidDistro = errors.New("distro marked as invalid")
+
 type unreachableDistroError struct {
 	sourceErr error
 }
@@ -200,13 +502,12 @@ func (w *Worker) processSingleTask(ctx context.Context, t task.Task) error {
 	log.Debugf(ctx, "Distro %q: starting task %q", w.distro.Name(), t)
 
 	if !w.distro.IsValid() {
-		return newUnreachableDistroErr(errors.New("distro marked as invalid"))
+		return errInvalidDistro
 	}
 
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-
-	if err := w.distro.LockAwake(); err != nil {
+	if err := w.distro.LockAwake(); errors.Is(err, wakepolicy.ErrNotAllowed) {
+		return err
+	} else if err != nil {
 		return newUnreachableDistroErr(err)
 	}
 	//nolint:errcheck // Nothing we can do about it
@@ -214,12 +515,24 @@ func (w *Worker) processSingleTask(ctx context.Context, t task.Task) error {
 
 	log.Debugf(ctx, "Distro %q: distro is running.", w.distro.Name())
 
-	client, err := w.waitForActiveConnection(ctx)
-	if err != nil {
-		return fmt.Errorf("task %v: could not start task: %w", t, err)
-	}
+	for reconnects := 0; ; reconnects++ {
+		client, gen, err := w.waitForActiveConnection(ctx)
+		if err != nil {
+			return fmt.Errorf("task %v: could not start task: %w", t, err)
+		}
+
+		err = t.Execute(ctx, client)
+		if err == nil {
+			break
+		}
+
+		// A task that failed because its connection was replaced, such as when the distro reconnects,
+		// runs again on the new one. Only tasks that are safe to retry do so.
+		if reconnects < maxReconnects && ctx.Err() == nil && errors.As(err, &task.NeedsRetryError{}) && w.connectionReplaced(gen) {
+			log.Infof(ctx, "Distro %q: task %q: connection was replaced while running, retrying on the new one: %v", w.distro.Name(), t, err)
+			continue
+		}
 
-	if err := t.Execute(ctx, client); err != nil {
 		return fmt.Errorf("distro %q: task %q failed: %w", w.distro.Name(), t, err)
 	}
 
@@ -227,60 +540,61 @@ func (w *Worker) processSingleTask(ctx context.Context, t task.Task) error {
 	return nil
 }
 
-func (w *Worker) waitForActiveConnection(ctx context.Context) (conn Connection, err error) {
+// waitForActiveConnection waits for the distro to connect, and returns the connection and its generation.
+// It makes up to connectionAttempts attempts, waking the distro up again on each one in case it stopped, and
+// returns an unreachableDistroError if no connection is set by then.
+func (w *Worker) waitForActiveConnection(ctx context.Context) (conn Connection, gen uint64, err error) {
 	log.Debugf(ctx, "Distro %q: ensuring active connection.", w.distro.Name())
 
-	for range 5 {
-		conn, err = func() (Connection, error) {
+	for range connectionAttempts {
+		conn, gen, err = func() (Connection, uint64, error) {
 			// Potentially restart distro if it was stopped for some reason
-			if err := w.distro.LockAwake(); err != nil {
-				return nil, newUnreachableDistroErr(err)
+			if err := w.distro.LockAwake(); errors.Is(err, wakepolicy.ErrNotAllowed) {
+				return nil, 0, err
+			} else if err != nil {
+				return nil, 0, newUnreachableDistroErr(err)
 			}
 			//nolint:errcheck // Nothing we can do about it
 			defer w.distro.ReleaseAwake()
 
-			// Connect to GRPC client
-			client, err := w.waitForClient(ctx)
-			if err != nil {
-				return nil, err
-			}
-
-			log.Debugf(ctx, "Distro %q: connection is active.", w.distro.Name())
-			return client, nil
+			return w.waitForClient(ctx)
 		}()
 
-		if err == nil {
+		if err == nil || ctx.Err() != nil || errors.Is(err, wakepolicy.ErrNotAllowed) {
 			break
 		}
 	}
 
-	return conn, err
+	return conn, gen, err
 }
 
-// waitForClient waits for a valid GRPC client to connect to. It will retry for a while before
-// erroring out.
-func (w *Worker) waitForClient(ctx context.Context) (Connection, error) {
-	timedOutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+// waitForClient waits for the distro to connect, and returns as soon as a connection is set, or an
+// unreachableDistroError if none is set within connectionTimeout.
+func (w *Worker) waitForClient(ctx context.Context) (Connection, uint64, error) {
+	timer := time.NewTimer(connectionTimeout)
+	defer timer.Stop()
 
-	tickRate := 0 * time.Second
 	for {
+		conn, gen, changed := w.connection()
+		if conn != nil {
+			log.Debugf(ctx, "Distro %q: connection is active.", w.distro.Name())
+			return conn, gen, nil
+		}
+
 		select {
 		case <-ctx.Done():
 			// Context cancelled means agent teardown.
-			return nil, fmt.Errorf("stopped waiting for client: %v", timedOutCtx.Err())
-		case <-timedOutCtx.Done():
+			return nil, 0, fmt.Errorf("stopped waiting for client: %v", ctx.Err())
+		case <-timer.C:
 			// Timeout means the distro is not reachable.
-			return nil, newUnreachableDistroErr(errors.New("timed out waiting for client"))
-		case <-time.After(tickRate):
-			conn := w.Connection()
-
-			if conn == nil {
-				tickRate = time.Second
-				continue
-			}
-
-			return conn, nil
+			return nil, 0, newUnreachableDistroErr(errors.New("timed out waiting for client"))
+		case <-changed:
 		}
 	}
 }
+
+// connectionReplaced returns true if the connection changed since the given generation.
+func (w *Worker) connectionReplaced(gen uint64) bool {
+	_, current, _ := w.connection()
+	return current != gen
+}
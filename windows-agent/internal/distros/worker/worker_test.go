@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -17,7 +18,11 @@ import (
 	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/wakepolicy"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage/storagetestutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
@@ -25,6 +30,9 @@ import (
 
 func init() {
 	task.Register[emptyTask]()
+	task.Register[failingTask]()
+	task.Register[orderedTask]()
+	task.Register[inventoryTask]()
 }
 
 func TestMain(m *testing.M) {
@@ -50,20 +58,23 @@ func TestNew(t *testing.T) {
 
 	testCases := map[string]struct {
 		taskFile    taskFileState
-		fillUpQueue bool
+		tasksStored bool
 
-		wantErr    bool
-		wantNTasks int
+		wantErr         bool
+		wantNTasks      int
+		wantQuarantined int
 	}{
 		"Success with no task file":                        {},
+		"Success with tasks in the storage":                {tasksStored: true, wantNTasks: 1},
+		"Success ignoring a task file imported already":    {tasksStored: true, taskFile: fileHasTwoTasks, wantNTasks: 1},
 		"Success with empty task file":                     {taskFile: fileIsEmpty},
 		"Success with task file containing a single task":  {taskFile: fileHasOneTask, wantNTasks: 1},
 		"Success with task file containing multiple tasks": {taskFile: fileHasTwoTasks, wantNTasks: 2},
+		"Success quarantining a non-registered task type":  {taskFile: fileHasNonRegisteredTask, wantNTasks: 1, wantQuarantined: 1},
 
 		// Error
-		"Error when task file reads non-registered task type": {taskFile: fileHasNonRegisteredTask, wantErr: true},
-		"Error when task file has bad syntax":                 {taskFile: fileHasBadSyntax, wantErr: true},
-		"Error when task file is unreadable":                  {taskFile: fileIsDir, wantErr: true},
+		"Error when task file has bad syntax": {taskFile: fileHasBadSyntax, wantErr: true},
+		"Error when task file is unreadable":  {taskFile: fileIsDir, wantErr: true},
 	}
 
 	for name, tc := range testCases {
@@ -76,7 +87,22 @@ func TestNew(t *testing.T) {
 			distro := &testDistro{name: wsltestutils.RandomDistroName(t)}
 
 			distroDir := t.TempDir()
+			store := openStore(t, distroDir)
+
 			taskFile := filepath.Join(distroDir, distro.Name()+".tasks")
+			if tc.tasksStored {
+				err := os.WriteFile(taskFile, taskfileFromTemplate[emptyTask](t), 0600)
+				require.NoError(t, err, "Setup: could not write task file")
+
+				// A cancelled context prevents the worker from popping the task.
+				setupCtx, setupCancel := context.WithCancel(ctx)
+				setupCancel()
+
+				w, err := worker.New(setupCtx, distro, distroDir, store)
+				require.NoError(t, err, "Setup: could not import the task file")
+				w.Stop(setupCtx)
+			}
+
 			switch tc.taskFile {
 			case fileNotExist:
 			case fileIsEmpty:
@@ -92,7 +118,8 @@ func TestNew(t *testing.T) {
 				err := os.WriteFile(taskFile, out, 0600)
 				require.NoError(t, err, "Setup: could not write task file")
 			case fileHasNonRegisteredTask:
-				out := taskfileFromTemplate[*testTask](t)
+				out := taskfileFromTemplate[emptyTask](t)
+				out = append(out, taskfileFromTemplate[*testTask](t)...)
 				err := os.WriteFile(taskFile, out, 0600)
 				require.NoError(t, err, "Setup: could not write task file")
 			case fileHasBadSyntax:
@@ -107,7 +134,7 @@ func TestNew(t *testing.T) {
 			// and we can accurately assert on the task queue length.
 			cancel()
 
-			w, err := worker.New(ctx, distro, distroDir)
+			w, err := worker.New(ctx, distro, distroDir, store)
 			if err == nil {
 				defer w.Stop(ctx)
 			}
@@ -118,6 +145,19 @@ func TestNew(t *testing.T) {
 			}
 			require.NoError(t, err, "worker.New should not return an error")
 			require.NoError(t, w.CheckQueuedTaskCount(tc.wantNTasks), "Wrong number of queued tasks.")
+			require.NoFileExists(t, taskFile, "Task file should have been removed after its import")
+
+			quarantined, err := worker.StoredQuarantine(store)
+			require.NoError(t, err, "StoredQuarantine should return no error")
+			require.Len(t, quarantined[distro.Name()], tc.wantQuarantined, "Wrong number of quarantined tasks")
+			for _, q := range quarantined[distro.Name()] {
+				require.Contains(t, q.Task, "worker_test.testTask", "Quarantined tasks should be kept as stored")
+				require.Contains(t, q.Error, "not registered", "Quarantined tasks should record why they could not be decoded")
+			}
+
+			stored, err := worker.StoredTasks(store)
+			require.NoError(t, err, "StoredTasks should return no error")
+			require.NotContains(t, string(stored[distro.Name()]), "worker_test.testTask", "Quarantined tasks should have been removed from the queue")
 		})
 	}
 }
@@ -162,7 +202,8 @@ func TestTaskProcessing(t *testing.T) {
 				name: wsltestutils.RandomDistroName(t),
 			}
 
-			w, err := worker.New(ctx, d, t.TempDir())
+			dir := t.TempDir()
+			w, err := worker.New(ctx, d, dir, openStore(t, dir))
 			require.NoError(t, err, "Setup: worker New() should return no error")
 			defer w.Stop(ctx)
 
@@ -222,6 +263,13 @@ func TestTaskProcessing(t *testing.T) {
 			if !tc.wantExecuteCalled {
 				time.Sleep(2 * clientTickPeriod)
 				require.Equal(t, int32(0), ttask.ExecuteCalls.Load(), "Task executed unexpectedly")
+
+				if tc.unregisterAfterConstructor {
+					require.NoError(t, w.CheckTotalTaskCount(1), "The task should remain stored for when the distro is valid again")
+					queued := w.QueuedTasks()
+					require.Len(t, queued, 1, "The task should remain queued for when the distro is valid again")
+					require.Zero(t, queued[0].Attempts, "Tasks not run because the distro is invalid should not count as attempted")
+				}
 				return
 			}
 
@@ -247,6 +295,14 @@ func TestTaskProcessing(t *testing.T) {
 			time.Sleep(time.Second)
 			require.Equal(t, int32(1), ttask.ExecuteCalls.Load(), "Task should not execute more than once")
 
+			results := d.recordedResults()
+			require.Len(t, results, 1, "The result of the task should have been recorded once")
+			if tc.taskReturns == taskReturnsNil {
+				require.NoError(t, results[0], "The task should have been recorded as successful")
+			} else {
+				require.Error(t, results[0], "The task should have been recorded as failed")
+			}
+
 			switch tc.taskReturns {
 			case taskReturnsNil, taskReturnsErr:
 				require.NoError(t, w.CheckQueuedTaskCount(0), "No tasks should remain in the queue")
@@ -266,20 +322,146 @@ func TestSubmitTaskFailsCannotWrite(t *testing.T) {
 
 	distro := &testDistro{name: wsltestutils.RandomDistroName(t)}
 	distroDir := t.TempDir()
-	taskFile := filepath.Join(distroDir, distro.Name()+".tasks")
+	breaker := &storagetestutils.Breaker{}
 
-	w, err := worker.New(ctx, distro, distroDir)
+	w, err := worker.New(ctx, distro, distroDir, breaker.Wrap(openStore(t, distroDir)))
 	require.NoError(t, err, "Setup: unexpected error creating the worker")
 	defer w.Stop(ctx)
 
-	err = os.RemoveAll(taskFile)
-	require.NoError(t, err, "Could not remove distro task backup file")
-
-	err = os.MkdirAll(taskFile, 0600)
-	require.NoError(t, err, "Could not make dir at distro task file's location")
+	breaker.Break()
 
 	err = w.SubmitTasks(&emptyTask{})
-	require.Error(t, err, "Submitting a task when the task file is not writable should cause an error")
+	require.Error(t, err, "Submitting a task when the storage is not writable should cause an error")
+}
+
+func TestSubmitDeferredTasksAtomically(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		commitErr bool
+
+		wantErr bool
+	}{
+		"Success storing the tasks along with the commit": {},
+
+		"Error when the commit fails": {commitErr: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+			storageDir := t.TempDir()
+			store := openStore(t, storageDir)
+
+			w, err := worker.New(ctx, d, storageDir, store)
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			submitErr := w.SubmitDeferredTasksAtomically(func(saveTasks func(storage.Tx) error) error {
+				return store.Update(func(tx storage.Tx) error {
+					if err := tx.Put("test", "key", []byte("value")); err != nil {
+						return err
+					}
+					if err := saveTasks(tx); err != nil {
+						return err
+					}
+					if tc.commitErr {
+						return errors.New("mock error")
+					}
+					return nil
+				})
+			}, emptyTask{ID: uuid.NewString()})
+
+			var gotValue []byte
+			require.NoError(t, store.View(func(tx storage.Tx) error {
+				gotValue = tx.Get("test", "key")
+				return nil
+			}), "View should return no error")
+
+			stored, err := worker.StoredTasks(store)
+			require.NoError(t, err, "StoredTasks should return no error")
+
+			if tc.wantErr {
+				require.Error(t, submitErr, "SubmitDeferredTasksAtomically should return an error")
+				require.Nil(t, gotValue, "Changes of a failed commit should have been rolled back")
+				require.NotContains(t, stored, d.Name(), "Tasks of a failed commit should not have been stored")
+				require.NoError(t, w.CheckTotalTaskCount(0), "Tasks of a failed commit should not have been submitted")
+				return
+			}
+			require.NoError(t, submitErr, "SubmitDeferredTasksAtomically should return no error")
+			require.Equal(t, "value", string(gotValue), "Changes of the commit should have been stored")
+			require.Contains(t, stored, d.Name(), "Tasks should have been stored along with the commit")
+			require.NoError(t, w.CheckQueuedTaskCount(0), "Tasks should have been deferred")
+			require.NoError(t, w.CheckTotalTaskCount(1), "Tasks should have been submitted")
+		})
+	}
+}
+
+func TestRenameStoredTasks(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		noTasks      bool
+		breakStorage bool
+
+		wantErr bool
+	}{
+		"Success moving the tasks to the new name":      {},
+		"Success when there are no tasks to be renamed": {noTasks: true},
+
+		"Error when the storage cannot be written": {breakStorage: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			oldName := wsltestutils.RandomDistroName(t)
+			newName := wsltestutils.RandomDistroName(t)
+			storageDir := t.TempDir()
+			breaker := &storagetestutils.Breaker{}
+			store := breaker.Wrap(openStore(t, storageDir))
+
+			if !tc.noTasks {
+				w, err := worker.New(ctx, &testDistro{name: oldName}, storageDir, store)
+				require.NoError(t, err, "Setup: unexpected error creating the worker")
+				require.NoError(t, w.SubmitDeferredTasks(emptyTask{ID: "renamed"}), "Setup: could not submit a task")
+				w.Stop(ctx)
+			}
+
+			if tc.breakStorage {
+				breaker.Break()
+			}
+
+			err := worker.RenameStoredTasks(store, oldName, newName)
+			if tc.wantErr {
+				require.Error(t, err, "RenameStoredTasks should return an error")
+				return
+			}
+			require.NoError(t, err, "RenameStoredTasks should return no error")
+
+			stored, err := worker.StoredTasks(store)
+			require.NoError(t, err, "StoredTasks should return no error")
+			require.NotContains(t, stored, oldName, "Tasks should no longer be stored under the old name")
+			if tc.noTasks {
+				require.NotContains(t, stored, newName, "No tasks should have been stored under the new name")
+				return
+			}
+			require.Contains(t, stored, newName, "Tasks should be stored under the new name")
+
+			w, err := worker.New(ctx, &testDistro{name: newName}, storageDir, store)
+			require.NoError(t, err, "New should return no error")
+			defer w.Stop(ctx)
+			require.NoError(t, w.CheckTotalTaskCount(1), "The worker of the renamed distro should have loaded its tasks")
+		})
+	}
 }
 
 func TestSetConnection(t *testing.T) {
@@ -291,7 +473,8 @@ func TestSetConnection(t *testing.T) {
 		name: wsltestutils.RandomDistroName(t),
 	}
 
-	w, err := worker.New(ctx, d, t.TempDir())
+	dir := t.TempDir()
+	w, err := worker.New(ctx, d, dir, openStore(t, dir))
 	require.NoError(t, err, "Setup: unexpected error creating the worker")
 	defer w.Stop(ctx)
 
@@ -311,7 +494,7 @@ func TestSetConnection(t *testing.T) {
 	for i := range conn1calls {
 		c := w.Connection()
 		require.NotNil(t, c, "client should be non-nil after setting a connection")
-		err = c.SendProAttachment("123")
+		err = c.SendProAttachment(ctx, "123")
 		require.NoError(t, err, "SendProAttachment attempt #%d should have been done successfully", i)
 		require.EqualValues(t, i+1, conn1.proAttachmentCount.Load(), "second server should be pinged after c.Ping (iteration #%d)", i)
 	}
@@ -325,7 +508,7 @@ func TestSetConnection(t *testing.T) {
 	// Ping on renewed connection (new wsl instance service) and ensure only the second service receives the pings
 	c := w.Connection()
 	require.NotNil(t, c, "client should be non-nil after setting a connection")
-	err = c.SendProAttachment("123")
+	err = c.SendProAttachment(ctx, "123")
 	require.NoError(t, err, "SendProAttachment should have been done successfully")
 	require.EqualValues(t, 1, conn2.proAttachmentCount.Load(), "second connection's ProAttach should have been called")
 
@@ -349,7 +532,8 @@ func TestSetConnectionOnClosedConnection(t *testing.T) {
 		name: wsltestutils.RandomDistroName(t),
 	}
 
-	w, err := worker.New(ctx, d, t.TempDir())
+	dir := t.TempDir()
+	w, err := worker.New(ctx, d, dir, openStore(t, dir))
 	require.NoError(t, err, "Setup: unexpected error creating the worker")
 	defer w.Stop(ctx)
 
@@ -362,11 +546,103 @@ func TestSetConnectionOnClosedConnection(t *testing.T) {
 	w.SetConnection(conn2)
 
 	// New connection is functional.
-	err = w.Connection().SendLandscapeConfig("123")
+	err = w.Connection().SendLandscapeConfig(ctx, "123")
 	require.NoError(t, err, "SendLandscapeConfig should have been done successfully")
 	require.EqualValues(t, 1, conn2.LandscapeConfigCount.Load(), "second service have been used once")
 }
 
+func TestTaskStartsAsSoonAsConnected(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+
+	dir := t.TempDir()
+	w, err := worker.New(ctx, d, dir, openStore(t, dir))
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	ttask := &testTask{}
+	require.NoError(t, w.SubmitTasks(ttask), "SubmitTasks should return no error")
+
+	require.Eventually(t, func() bool { return d.state() == "Running" }, 5*time.Second, 10*time.Millisecond,
+		"Setup: the distro should have been woken up to run the task")
+
+	// Give the worker time to start waiting for the connection.
+	time.Sleep(100 * time.Millisecond)
+	require.Zero(t, ttask.ExecuteCalls.Load(), "Task should not run without a connection")
+
+	w.SetConnection(&mockConnection{})
+	require.Eventually(t, func() bool { return ttask.ExecuteCalls.Load() == 1 }, 200*time.Millisecond, time.Millisecond,
+		"Task should start right after the connection is set, without waiting for a polling interval")
+}
+
+func TestTaskRunsAgainWhenConnectionIsReplaced(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		notRetryable   bool
+		keepConnection bool
+
+		wantExecuteCalls int32
+		wantErr          bool
+	}{
+		"Success running the task again on the new connection": {wantExecuteCalls: 2},
+
+		"Error when the task cannot be retried":            {notRetryable: true, wantExecuteCalls: 1, wantErr: true},
+		"Error when the task fails on the same connection": {keepConnection: true, wantExecuteCalls: 1, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+
+			dir := t.TempDir()
+			w, err := worker.New(ctx, d, dir, openStore(t, dir))
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			conn1 := &mockConnection{}
+			w.SetConnection(conn1)
+
+			ttask := newReconnectingTask(tc.notRetryable)
+			require.NoError(t, w.SubmitTasks(ttask), "SubmitTasks should return no error")
+
+			select {
+			case <-ttask.started:
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "Setup: the task should have started")
+			}
+
+			if tc.keepConnection {
+				// Closing the connection without replacing it, such as when the stream breaks.
+				conn1.Close()
+			} else {
+				w.SetConnection(&mockConnection{})
+			}
+			close(ttask.fail)
+
+			require.Eventually(t, func() bool { return len(d.recordedResults()) == 1 }, 5*time.Second, 10*time.Millisecond,
+				"The result of the task should have been recorded")
+			require.Equal(t, tc.wantExecuteCalls, ttask.executeCalls.Load(), "Unexpected number of times the task ran")
+
+			if tc.wantErr {
+				require.Error(t, d.recordedResults()[0], "The task should have been recorded as failed")
+				return
+			}
+			require.NoError(t, d.recordedResults()[0], "The task should have been recorded as successful")
+			require.NoError(t, w.CheckTotalTaskCount(0), "The task should not remain queued for a retry")
+		})
+	}
+}
+
 func TestTaskDeferral(t *testing.T) {
 	t.Parallel()
 
@@ -379,8 +655,8 @@ func TestTaskDeferral(t *testing.T) {
 	}{
 		"Success reloading two tasks": {},
 
-		"Error if the task file cannot be read":    {breakReload: true, wantReloadErr: true},
-		"Error if the task file cannot be written": {breakSubmit: true, wantSubmitErr: true},
+		"Error if the task file cannot be read":  {breakReload: true, wantReloadErr: true},
+		"Error if the storage cannot be written": {breakSubmit: true, wantSubmitErr: true},
 	}
 
 	for name, tc := range testCases {
@@ -394,10 +670,10 @@ func TestTaskDeferral(t *testing.T) {
 				name: wsltestutils.RandomDistroName(t),
 			}
 
-			storage := t.TempDir()
-			taskFile := filepath.Join(storage, d.Name()+".tasks")
+			storageDir := t.TempDir()
+			breaker := &storagetestutils.Breaker{}
 
-			w, err := worker.New(ctx, d, storage)
+			w, err := worker.New(ctx, d, storageDir, breaker.Wrap(openStore(t, storageDir)))
 			require.NoError(t, err, "Setup: unexpected error creating the worker")
 			defer w.Stop(ctx)
 
@@ -418,14 +694,13 @@ func TestTaskDeferral(t *testing.T) {
 			require.NoError(t, err, "SubmitTasks should have succeeded for a second queued task")
 
 			if tc.breakSubmit {
-				// We wait until the blocking task is popped to avoid a filesystem race:
-				// write:           TaskManager.NextTask
-				// delete+write:    testutils.ReplaceFileWithDir
+				// We wait until the blocking task is popped so that breaking the storage
+				// only interferes with SubmitDeferredTasks.
 				require.Eventually(t, func() bool {
 					return w.CheckTotalTaskCount(1) == nil
 				}, 5*time.Second, 500*time.Millisecond, "Setup: Blocking task was never popped from queue")
 
-				testutils.ReplaceFileWithDir(t, taskFile, "Setup: could not replace task file with dir to interfere with SubmitDeferredTasks")
+				breaker.Break()
 			}
 
 			err = w.SubmitDeferredTasks(deferredTask)
@@ -491,9 +766,9 @@ func TestTaskDeduplication(t *testing.T) {
 				name: wsltestutils.RandomDistroName(t),
 			}
 
-			storage := t.TempDir()
+			storageDir := t.TempDir()
 
-			w, err := worker.New(ctx, d, storage)
+			w, err := worker.New(ctx, d, storageDir, openStore(t, storageDir))
 			require.NoError(t, err, "Setup: unexpected error creating the worker")
 			defer w.Stop(ctx)
 
@@ -559,9 +834,9 @@ func TestFailedTaskIsDeferred(t *testing.T) {
 		name: wsltestutils.RandomDistroName(t),
 	}
 
-	storage := t.TempDir()
+	storageDir := t.TempDir()
 
-	w, err := worker.New(ctx, d, storage)
+	w, err := worker.New(ctx, d, storageDir, openStore(t, storageDir))
 	require.NoError(t, err, "Setup: unexpected error creating the worker")
 	defer w.Stop(ctx)
 
@@ -583,6 +858,533 @@ func TestFailedTaskIsDeferred(t *testing.T) {
 	require.NoError(t, w.CheckQueuedTaskCount(0), "Task should not have been submitted into the queue, but rather deferred")
 }
 
+func TestTaskDeferredWhenWakeNotAllowed(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		distroStarts bool
+	}{
+		"Task runs once the policy allows waking the distro up": {},
+		"Task runs once when the distro starts in the meantime": {distroStarts: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{
+				name:        wsltestutils.RandomDistroName(t),
+				wakeAllowed: make(chan struct{}),
+			}
+			d.wakeNotAllowed.Store(true)
+
+			storageDir := t.TempDir()
+
+			w, err := worker.New(ctx, d, storageDir, openStore(t, storageDir))
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			w.SetConnection(&mockConnection{})
+
+			tk := testTask{ID: t.Name()}
+			err = w.SubmitTasks(&tk)
+			require.NoError(t, err, "SubmitTasks should return no error")
+
+			require.Eventually(t, func() bool {
+				return w.CheckQueuedTaskCount(0) == nil && w.CheckTotalTaskCount(1) == nil
+			}, 5*time.Second, 100*time.Millisecond, "Task should have been popped from the queue and deferred")
+			require.Zero(t, tk.ExecuteCalls.Load(), "Task should not have been executed")
+			require.True(t, d.IsValid(), "Distro should not have been invalidated")
+			require.Empty(t, d.recordedResults(), "No task result should have been recorded")
+
+			d.wakeNotAllowed.Store(false)
+
+			if tc.distroStarts {
+				w.EnqueueDeferredTasks()
+				require.Eventually(t, func() bool {
+					return tk.ExecuteCalls.Load() == 1
+				}, 5*time.Second, 100*time.Millisecond, "Task should have run when the distro started")
+				require.Eventually(t, func() bool {
+					return w.CheckTotalTaskCount(0) == nil
+				}, 5*time.Second, 100*time.Millisecond, "Task should have been completed")
+			}
+
+			close(d.wakeAllowed)
+
+			require.Eventually(t, func() bool {
+				return w.CheckTotalTaskCount(0) == nil && tk.ExecuteCalls.Load() > 0
+			}, 5*time.Second, 100*time.Millisecond, "Task should have run once the policy allowed it")
+
+			time.Sleep(500 * time.Millisecond)
+			require.Equal(t, int32(1), tk.ExecuteCalls.Load(), "Task should have run exactly once")
+		})
+	}
+}
+
+func TestTaskRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		maxAttempts int
+		restart     bool
+	}{
+		"Task is retried until it exhausts its retry policy": {maxAttempts: 3},
+		"Failed attempts are kept across restarts":           {maxAttempts: 2, restart: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+			storageDir := t.TempDir()
+			store := openStore(t, storageDir)
+
+			w, err := worker.New(ctx, d, storageDir, store)
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer func() { w.Stop(ctx) }()
+			w.SetConnection(&mockConnection{})
+
+			tk := failingTask{ID: uuid.NewString(), MaxAttempts: tc.maxAttempts, InitialDelay: 100 * time.Millisecond}
+			if tc.restart {
+				// Only a restart can retry the task
+				tk.InitialDelay = time.Hour
+			}
+
+			err = w.SubmitTasks(tk)
+			require.NoError(t, err, "SubmitTasks should return no error")
+
+			if tc.restart {
+				require.Eventually(t, func() bool {
+					return failingTaskCalls.get(tk.ID) == 1 && w.CheckTotalTaskCount(1) == nil
+				}, 5*time.Second, 100*time.Millisecond, "Task should have failed once and been deferred")
+
+				w.Stop(ctx)
+				w, err = worker.New(ctx, d, storageDir, store)
+				require.NoError(t, err, "New should return no error")
+				w.SetConnection(&mockConnection{})
+			}
+
+			require.Eventually(t, func() bool {
+				return failingTaskCalls.get(tk.ID) == tc.maxAttempts && w.CheckTotalTaskCount(0) == nil
+			}, 10*time.Second, 100*time.Millisecond, "Task should have been attempted %d times and given up on", tc.maxAttempts)
+
+			time.Sleep(500 * time.Millisecond)
+			require.Equal(t, tc.maxAttempts, failingTaskCalls.get(tk.ID), "Task should not have been attempted after exhausting its policy")
+
+			letters, err := worker.StoredDeadLetters(store)
+			require.NoError(t, err, "StoredDeadLetters should return no error")
+			require.Len(t, letters[d.Name()], 1, "The task should have been moved to the dead-letter list")
+
+			dl := letters[d.Name()][0]
+			require.Equal(t, tc.maxAttempts, dl.Attempts, "The dead letter should record the number of attempts")
+			require.Contains(t, dl.Error, "failingTask error", "The dead letter should record the last error")
+			require.Equal(t, tk.String(), dl.Task, "The dead letter should describe the task")
+			require.NotEmpty(t, dl.ID, "The dead letter should record the ID of the task")
+			require.Equal(t, task.TypeID(tk), dl.Type, "The dead letter should record the type of the task")
+		})
+	}
+}
+
+func TestTaskPriorityAndExpiry(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+	storageDir := t.TempDir()
+
+	w, err := worker.New(ctx, d, storageDir, openStore(t, storageDir))
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	group := uuid.NewString()
+	err = w.SubmitDeferredTasks(
+		orderedTask{Group: group, ID: "low", Prio: -1},
+		orderedTask{Group: group, ID: "default-1"},
+		orderedTask{Group: group, ID: "high", Prio: 1},
+		orderedTask{Group: group, ID: "expired", Prio: 2, Lifetime: time.Nanosecond},
+		orderedTask{Group: group, ID: "default-2"},
+	)
+	require.NoError(t, err, "SubmitDeferredTasks should return no error")
+
+	// Make sure the task expires
+	time.Sleep(10 * time.Millisecond)
+
+	w.SetConnection(&mockConnection{})
+	w.EnqueueDeferredTasks()
+
+	require.Eventually(t, func() bool {
+		return w.CheckTotalTaskCount(0) == nil && len(executionOrder.get(group)) == 4
+	}, 5*time.Second, 100*time.Millisecond, "All tasks should have been pulled from the queue")
+
+	require.Equal(t, []string{"high", "default-1", "default-2", "low"}, executionOrder.get(group),
+		"Tasks should have run by priority, then in order of submission, and the expired one should not have run")
+
+	results := d.recordedResults()
+	require.Len(t, results, 5, "The outcome of all tasks should have been recorded")
+	require.True(t, slices.ContainsFunc(results, func(err error) bool { return errors.Is(err, task.ErrExpired) }),
+		"The expired task should have been recorded as such")
+}
+
+func TestTaskDependencies(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		tasks       []orderedTask
+		asGroup     bool
+		submitApart bool
+
+		wantOrder   []string
+		wantDropped int
+	}{
+		"Group runs in order regardless of priority": {
+			tasks:     []orderedTask{{ID: "a", Prio: -1}, {ID: "b", Prio: 5}, {ID: "c"}},
+			asGroup:   true,
+			wantOrder: []string{"a", "b", "c"},
+		},
+		"Dependent task waits for its prerequisite": {
+			tasks:     []orderedTask{{ID: "prerequisite", Prio: -1}, {ID: "dependent", Prio: 5, After: "prerequisite"}},
+			wantOrder: []string{"prerequisite", "dependent"},
+		},
+		"Dependent task waits for its prerequisite submitted earlier": {
+			tasks:       []orderedTask{{ID: "prerequisite", Prio: -1}, {ID: "dependent", Prio: 5, After: "prerequisite"}},
+			submitApart: true,
+			wantOrder:   []string{"prerequisite", "dependent"},
+		},
+		"Dependent task does not wait for a prerequisite that is not queued": {
+			tasks:     []orderedTask{{ID: "dependent", After: "prerequisite"}},
+			wantOrder: []string{"dependent"},
+		},
+
+		"Error when a task of the group fails the rest of the group": {
+			tasks:       []orderedTask{{ID: "a"}, {ID: "b", Fail: true}, {ID: "c"}, {ID: "d"}},
+			asGroup:     true,
+			wantOrder:   []string{"a", "b"},
+			wantDropped: 2,
+		},
+		"Dependent task still runs when its prerequisite fails": {
+			tasks:     []orderedTask{{ID: "prerequisite", Fail: true}, {ID: "dependent", After: "prerequisite"}, {ID: "transitive", After: "dependent"}, {ID: "unrelated", Prio: -1}},
+			wantOrder: []string{"prerequisite", "dependent", "transitive", "unrelated"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+			storageDir := t.TempDir()
+
+			w, err := worker.New(ctx, d, storageDir, openStore(t, storageDir))
+			require.NoError(t, err, "Setup: unexpected error creating the worker")
+			defer w.Stop(ctx)
+
+			group := uuid.NewString()
+			var tasks []task.Task
+			for _, tk := range tc.tasks {
+				tk.Group = group
+				tasks = append(tasks, tk)
+			}
+
+			switch {
+			case tc.asGroup:
+				err = w.SubmitDeferredTaskGroup(tasks...)
+			case tc.submitApart:
+				for _, tk := range tasks {
+					err = errors.Join(err, w.SubmitDeferredTasks(tk))
+				}
+			default:
+				err = w.SubmitDeferredTasks(tasks...)
+			}
+			require.NoError(t, err, "Submitting the tasks should return no error")
+
+			w.SetConnection(&mockConnection{})
+			w.EnqueueDeferredTasks()
+
+			require.Eventually(t, func() bool {
+				return w.CheckTotalTaskCount(0) == nil && len(d.recordedResults()) == len(tc.tasks)
+			}, 5*time.Second, 100*time.Millisecond, "All tasks should have been pulled from the queue")
+
+			require.Equal(t, tc.wantOrder, executionOrder.get(group), "Tasks should have run after the ones they depend on")
+
+			var dropped int
+			for _, err := range d.recordedResults() {
+				if errors.Is(err, task.ErrPrerequisiteFailed) {
+					dropped++
+				}
+			}
+			require.Equal(t, tc.wantDropped, dropped, "Tasks depending on a failed one should have been dropped as such")
+		})
+	}
+}
+
+func TestTaskRunsWhenProxyConfigFails(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+	storageDir := t.TempDir()
+
+	w, err := worker.New(ctx, d, storageDir, openStore(t, storageDir))
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	err = w.SubmitDeferredTasks(tasks.ProxyConfig{HTTPSProxy: "http://proxy.example.com:3128"}, tasks.ProAttachment{Token: "token"})
+	require.NoError(t, err, "Submitting the tasks should return no error")
+
+	// The distro cannot apply proxy settings, so ProxyConfig fails for good.
+	conn := &mockConnection{proxyConfigErr: task.ErrUnsupported}
+	w.SetConnection(conn)
+	w.EnqueueDeferredTasks()
+
+	require.Eventually(t, func() bool {
+		return w.CheckTotalTaskCount(0) == nil && len(d.recordedResults()) == 2
+	}, 5*time.Second, 100*time.Millisecond, "All tasks should have been pulled from the queue")
+
+	require.Equal(t, int32(1), conn.proAttachmentCount.Load(), "ProAttachment should run even though the ProxyConfig it waits for failed")
+	for _, err := range d.recordedResults() {
+		require.NotErrorIs(t, err, task.ErrPrerequisiteFailed, "No task should have been dropped")
+	}
+}
+
+func TestTaskHistory(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+	storageDir := t.TempDir()
+	store := openStore(t, storageDir)
+
+	w, err := worker.New(ctx, d, storageDir, store)
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+
+	group := uuid.NewString()
+	err = w.SubmitDeferredTaskGroup(orderedTask{Group: group, ID: "succeeds", Reboot: true}, orderedTask{Group: group, ID: "fails", Fail: true}, orderedTask{Group: group, ID: "dropped"})
+	require.NoError(t, err, "SubmitDeferredTaskGroup should return no error")
+
+	err = w.SubmitDeferredTasks(
+		orderedTask{Group: group, ID: "expired", Lifetime: time.Nanosecond},
+		failingTask{ID: uuid.NewString(), MaxAttempts: 2, InitialDelay: 100 * time.Millisecond},
+	)
+	require.NoError(t, err, "SubmitDeferredTasks should return no error")
+
+	// Make sure the task expires
+	time.Sleep(10 * time.Millisecond)
+
+	w.SetConnection(&mockConnection{})
+	w.EnqueueDeferredTasks()
+
+	// Every attempt of every task
+	const wantEntries = 6
+
+	var history []worker.HistoryEntry
+	require.Eventually(t, func() bool {
+		h, err := worker.StoredHistory(store)
+		require.NoError(t, err, "StoredHistory should return no error")
+		history = h[d.Name()]
+		return w.CheckTotalTaskCount(0) == nil && len(history) == wantEntries
+	}, 5*time.Second, 100*time.Millisecond, "The outcome of every attempt should have been recorded")
+
+	results := make(map[worker.Result]int)
+	for _, h := range history {
+		results[h.Result]++
+
+		require.NotEmpty(t, h.Type, "History entries should record the task type")
+		require.NotEmpty(t, h.Summary, "History entries should record the task summary")
+		require.False(t, h.Submitted.IsZero(), "History entries should record the submission time")
+		require.False(t, h.Ended.Before(h.Started), "History entries should not end before they start")
+		if h.Result == worker.ResultSucceeded || h.Result == worker.ResultFailed || h.Result == worker.ResultRetrying {
+			require.Positive(t, h.Attempts, "History entries of tasks that ran should count their attempt")
+			require.False(t, h.Started.Before(h.Submitted), "History entries should not start before they are submitted")
+		}
+		if h.Result == worker.ResultSucceeded {
+			require.Equal(t, "ran succeeds", h.Details, "History entries should record the report of the task")
+		}
+		require.Equal(t, h.Result == worker.ResultSucceeded, h.RebootRequired, "History entries should record whether the task required a reboot")
+	}
+
+	require.Equal(t, map[worker.Result]int{
+		worker.ResultSucceeded: 1,
+		worker.ResultFailed:    2,
+		worker.ResultDropped:   1,
+		worker.ResultExpired:   1,
+		worker.ResultRetrying:  1,
+	}, results, "Mismatch in the recorded results")
+
+	last := history[len(history)-1]
+	require.Equal(t, worker.ResultFailed, last.Result, "The failing task should have been recorded last, once it exhausted its policy")
+	require.Equal(t, 2, last.Attempts, "The failing task should record all of its attempts")
+	require.Contains(t, last.Error, "failingTask error", "The failing task should record its error")
+}
+
+func TestTaskHistoryIsBounded(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+	storageDir := t.TempDir()
+	store := openStore(t, storageDir)
+
+	w, err := worker.New(ctx, d, storageDir, store)
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+	w.SetConnection(&mockConnection{})
+
+	var tasks []task.Task
+	for range worker.MaxHistory + 10 {
+		tasks = append(tasks, emptyTask{ID: uuid.NewString()})
+	}
+	last := tasks[len(tasks)-1].(emptyTask)
+
+	err = w.SubmitTasks(tasks...)
+	require.NoError(t, err, "SubmitTasks should return no error")
+
+	requireEventuallyTaskCompletes(t, last, "All tasks should have completed")
+	require.Eventually(t, func() bool {
+		return w.CheckTotalTaskCount(0) == nil
+	}, 5*time.Second, 100*time.Millisecond, "All tasks should have been pulled from the queue")
+
+	history, err := worker.StoredHistory(store)
+	require.NoError(t, err, "StoredHistory should return no error")
+	require.Len(t, history[d.Name()], worker.MaxHistory, "Only the most recent history entries should have been kept")
+}
+
+func TestCancelTask(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+	storageDir := t.TempDir()
+	store := openStore(t, storageDir)
+
+	w, err := worker.New(ctx, d, storageDir, store)
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+	w.SetConnection(&mockConnection{})
+
+	blocking := newBlockingTask(ctx)
+	defer blocking.complete()
+
+	err = w.SubmitTasks(blocking)
+	require.NoError(t, err, "SubmitTasks should return no error")
+	require.Eventually(t, blocking.executing.Load, 5*time.Second, 100*time.Millisecond, "The blocking task should have started running")
+
+	queued := emptyTask{ID: uuid.NewString()}
+	err = w.SubmitTasks(queued)
+	require.NoError(t, err, "SubmitTasks should return no error")
+
+	tasks := w.QueuedTasks()
+	require.Len(t, tasks, 2, "QueuedTasks should return the running and the queued tasks")
+	require.True(t, tasks[0].Running, "QueuedTasks should return the running task first")
+	require.False(t, tasks[1].Running, "QueuedTasks should not mark the queued task as running")
+	for _, q := range tasks {
+		require.NotEmpty(t, q.ID, "QueuedTasks should return tasks with an ID")
+	}
+
+	err = w.CancelTask(ctx, "")
+	require.Error(t, err, "CancelTask should return an error when no ID is given")
+
+	err = w.CancelTask(ctx, uuid.NewString())
+	require.Error(t, err, "CancelTask should return an error when the task is not queued nor running")
+
+	// Cancelling the queued task removes it from the queue
+	err = w.CancelTask(ctx, tasks[1].ID)
+	require.NoError(t, err, "CancelTask should return no error for a queued task")
+	require.Len(t, w.QueuedTasks(), 1, "The cancelled task should have been removed from the queue")
+
+	// Cancelling the running task interrupts it
+	err = w.CancelTask(ctx, tasks[0].ID)
+	require.NoError(t, err, "CancelTask should return no error for a running task")
+	require.Eventually(t, func() bool {
+		return !blocking.executing.Load() && len(w.QueuedTasks()) == 0
+	}, 5*time.Second, 100*time.Millisecond, "The running task should have been interrupted")
+
+	require.False(t, completedEmptyTasks.Has(queued.ID), "The cancelled queued task should not have run")
+
+	var history []worker.HistoryEntry
+	require.Eventually(t, func() bool {
+		h, err := worker.StoredHistory(store)
+		require.NoError(t, err, "StoredHistory should return no error")
+		history = h[d.Name()]
+		return len(history) == 2
+	}, 5*time.Second, 100*time.Millisecond, "The cancellations should have been recorded")
+
+	for _, h := range history {
+		require.Equal(t, worker.ResultCancelled, h.Result, "History entries should record the cancellation")
+		require.NotEmpty(t, h.ID, "History entries should record the task ID")
+	}
+
+	results := d.recordedResults()
+	require.Len(t, results, 2, "The outcome of both tasks should have been recorded")
+	for _, err := range results {
+		require.ErrorIs(t, err, task.ErrCancelled, "Cancelled tasks should have been recorded as such")
+	}
+}
+
+func TestPackageInventories(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &testDistro{name: wsltestutils.RandomDistroName(t)}
+	storageDir := t.TempDir()
+	store := openStore(t, storageDir)
+
+	w, err := worker.New(ctx, d, storageDir, store)
+	require.NoError(t, err, "Setup: unexpected error creating the worker")
+	defer w.Stop(ctx)
+	w.SetConnection(&mockConnection{})
+
+	inventories, err := worker.StoredInventories(store)
+	require.NoError(t, err, "StoredInventories should return no error")
+	require.NotContains(t, inventories, d.Name(), "No inventory should be stored before one is collected")
+
+	for _, version := range []string{"1.0", "2.0", "3.0"} {
+		require.NoError(t, w.SubmitTasks(inventoryTask{Version: version}), "SubmitTasks should return no error")
+		require.Eventually(t, func() bool {
+			inventories, err = worker.StoredInventories(store)
+			require.NoError(t, err, "StoredInventories should return no error")
+			inv, ok := inventories[d.Name()]
+			return ok && inv.Current.Packages[0].Version == version
+		}, 5*time.Second, 100*time.Millisecond, "The inventory collected by the task should have been stored")
+	}
+
+	inv := inventories[d.Name()]
+	require.False(t, inv.Current.Collected.IsZero(), "The collection time should have been stored")
+	require.NotNil(t, inv.Previous, "The previous inventory should have been kept")
+	require.Equal(t, "2.0", inv.Previous.Packages[0].Version, "Only the inventory preceding the current one should have been kept")
+
+	w.Stop(ctx)
+	newName := wsltestutils.RandomDistroName(t)
+	require.NoError(t, worker.RenameStoredTasks(store, d.Name(), newName), "RenameStoredTasks should return no error")
+
+	inventories, err = worker.StoredInventories(store)
+	require.NoError(t, err, "StoredInventories should return no error")
+	require.NotContains(t, inventories, d.Name(), "Inventories should no longer be stored under the old name")
+	require.Equal(t, inv, inventories[newName], "Inventories should have been moved to the new name")
+}
+
 func requireEventuallyTaskCompletes(t *testing.T, task emptyTask, msg string, args ...any) {
 	t.Helper()
 
@@ -609,6 +1411,133 @@ func (t emptyTask) String() string {
 	return "Empty test task"
 }
 
+// inventoryTask is a task that records an inventory with a single package of the given version.
+type inventoryTask struct {
+	Version string
+}
+
+func (t inventoryTask) Execute(ctx context.Context, _ task.Connection) error {
+	return task.RecordInventory(ctx, task.PackageInventory{
+		Collected: time.Now(),
+		Packages:  []task.Package{{Name: "mypackage", Version: t.Version, Architecture: "amd64"}},
+	})
+}
+
+func (t inventoryTask) String() string {
+	return "Inventory test task"
+}
+
+// failingTaskCalls counts the executions of each failing task, by ID. We need a global
+// variable for the same reasons as with completedEmptyTasks.
+var failingTaskCalls = &callCounter{calls: make(map[string]int)}
+
+type callCounter struct {
+	calls map[string]int
+	mu    sync.Mutex
+}
+
+func (c *callCounter) inc(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[id]++
+}
+
+func (c *callCounter) get(id string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[id]
+}
+
+// failingTask is a task that always fails and asks to be retried.
+type failingTask struct {
+	ID           string
+	MaxAttempts  int
+	InitialDelay time.Duration
+}
+
+func (t failingTask) Execute(context.Context, task.Connection) error {
+	failingTaskCalls.inc(t.ID)
+	return task.NeedsRetryError{SourceErr: errors.New("failingTask error")}
+}
+
+func (t failingTask) RetryPolicy() task.RetryPolicy {
+	return task.RetryPolicy{MaxAttempts: t.MaxAttempts, InitialDelay: t.InitialDelay, Factor: 2}
+}
+
+func (t failingTask) String() string {
+	return fmt.Sprintf("Failing test task %s", t.ID)
+}
+
+// executionOrder records the IDs of the ordered tasks as they run, by group. We need a global
+// variable for the same reasons as with completedEmptyTasks.
+var executionOrder = &orderRecorder{order: make(map[string][]string)}
+
+type orderRecorder struct {
+	order map[string][]string
+	mu    sync.Mutex
+}
+
+func (r *orderRecorder) record(group, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.order[group] = append(r.order[group], id)
+}
+
+func (r *orderRecorder) get(group string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.order[group])
+}
+
+// orderedTask is a task that records when it runs, with optional priority, lifetime and
+// dependency on the ordered task of the same group with the ID in After.
+type orderedTask struct {
+	Group    string
+	ID       string
+	Prio     int
+	Lifetime time.Duration
+	After    string
+	Fail     bool
+	Reboot   bool
+}
+
+func (t orderedTask) Execute(ctx context.Context, _ task.Connection) error {
+	executionOrder.record(t.Group, t.ID)
+	task.Report(ctx, "ran %s", t.ID)
+	if t.Reboot {
+		task.RequireReboot(ctx)
+	}
+	if t.Fail {
+		return errors.New("orderedTask error")
+	}
+	return nil
+}
+
+func (t orderedTask) DependsOn() []task.Task {
+	if t.After == "" {
+		return nil
+	}
+	return []task.Task{orderedTask{Group: t.Group, ID: t.After}}
+}
+
+// Is makes ordered tasks equivalent when they share their group and ID.
+func (t orderedTask) Is(other task.Task) bool {
+	o, ok := other.(orderedTask)
+	return ok && o.Group == t.Group && o.ID == t.ID
+}
+
+func (t orderedTask) Priority() int {
+	return t.Prio
+}
+
+func (t orderedTask) TTL() time.Duration {
+	return t.Lifetime
+}
+
+func (t orderedTask) String() string {
+	return fmt.Sprintf("Ordered test task %s", t.ID)
+}
+
 type testTask struct {
 	// ExecuteCalls counts the number of times Execute is called
 	ExecuteCalls atomic.Int32
@@ -661,6 +1590,56 @@ func (t *testTask) Is(other task.Task) bool {
 	return t.ID == o.ID
 }
 
+// reconnectingTask runs until fail is closed, then fails if the connection it ran on was closed.
+// Subsequent runs succeed right away.
+type reconnectingTask struct {
+	notRetryable bool
+
+	started      chan struct{}
+	fail         chan struct{}
+	executeCalls atomic.Int32
+}
+
+func newReconnectingTask(notRetryable bool) *reconnectingTask {
+	return &reconnectingTask{
+		notRetryable: notRetryable,
+		started:      make(chan struct{}),
+		fail:         make(chan struct{}),
+	}
+}
+
+// MarshalYAML is necessary to avoid races between Execute and Save.
+func (t *reconnectingTask) MarshalYAML() (interface{}, error) {
+	return struct{ NotRetryable bool }{NotRetryable: t.notRetryable}, nil
+}
+
+func (t *reconnectingTask) Execute(ctx context.Context, conn task.Connection) error {
+	if t.executeCalls.Add(1) > 1 {
+		return nil
+	}
+	close(t.started)
+
+	select {
+	case <-t.fail:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if !conn.(*mockConnection).closed.Load() {
+		return nil
+	}
+
+	err := errors.New("connection closed")
+	if t.notRetryable {
+		return err
+	}
+	return task.NeedsRetryError{SourceErr: err}
+}
+
+func (t *reconnectingTask) String() string {
+	return "Reconnecting task"
+}
+
 // blockingTask is a task that blocks execution until complete() is called.
 type blockingTask struct {
 	ctx       context.Context
@@ -706,9 +1685,17 @@ type testDistro struct {
 	// TODO: Is this used?
 	LockAwakeError error // LockAwake will throw this error (unless it is nil)
 
+	// wakeNotAllowed makes LockAwake fail as if the wake policy did not allow waking the distro up.
+	wakeNotAllowed atomic.Bool
+	// wakeAllowed makes WaitWakeAllowed block until it is closed (unless it is nil).
+	wakeAllowed chan struct{}
+
 	// Do not use directly
 	runningRefCount int
 	runningMu       sync.RWMutex
+
+	results   []error
+	resultsMu sync.Mutex
 }
 
 // state returns the state of the distro as specified by wsl.exe. Possible states:
@@ -740,6 +1727,10 @@ func (d *testDistro) LockAwake() error {
 		return err
 	}
 
+	if d.wakeNotAllowed.Load() {
+		return fmt.Errorf("LockAwake: testDistro %q: %w", d.name, wakepolicy.ErrNotAllowed)
+	}
+
 	if !d.IsValid() {
 		return fmt.Errorf("LockAwake: testDistro %q is not valid", d.name)
 	}
@@ -764,6 +1755,19 @@ func (d *testDistro) ReleaseAwake() error {
 	return nil
 }
 
+func (d *testDistro) WaitWakeAllowed(ctx context.Context) error {
+	if d.wakeAllowed == nil {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-d.wakeAllowed:
+		return nil
+	}
+}
+
 func (d *testDistro) IsValid() bool {
 	return !d.invalid.Load()
 }
@@ -772,6 +1776,31 @@ func (d *testDistro) Invalidate(ctx context.Context) {
 	d.invalid.Store(true)
 }
 
+func (d *testDistro) RecordTaskResult(t task.Task, err error) {
+	d.resultsMu.Lock()
+	defer d.resultsMu.Unlock()
+
+	d.results = append(d.results, err)
+}
+
+func (d *testDistro) recordedResults() []error {
+	d.resultsMu.Lock()
+	defer d.resultsMu.Unlock()
+
+	return d.results
+}
+
+// openStore opens the storage in the directory, and closes it when the test ends.
+func openStore(t *testing.T, dir string) storage.Store {
+	t.Helper()
+
+	s, err := storage.Open(context.Background(), dir)
+	require.NoError(t, err, "Setup: could not open storage")
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
 func taskfileFromTemplate[T task.Task](t *testing.T) []byte {
 	t.Helper()
 
@@ -793,18 +1822,41 @@ type mockConnection struct {
 	proAttachmentCount   atomic.Int32
 	LandscapeConfigCount atomic.Int32
 	closed               atomic.Bool
+
+	// proxyConfigErr is returned by SendProxyConfig.
+	proxyConfigErr error
 }
 
-func (conn *mockConnection) SendProAttachment(proToken string) error {
+func (conn *mockConnection) SendProAttachment(ctx context.Context, proToken string) error {
 	conn.proAttachmentCount.Add(1)
 	return nil
 }
 
-func (conn *mockConnection) SendLandscapeConfig(lpeConfig string) error {
+func (conn *mockConnection) SendLandscapeConfig(ctx context.Context, lpeConfig string) error {
 	conn.LandscapeConfigCount.Add(1)
 	return nil
 }
 
+func (conn *mockConnection) SendProxyConfig(ctx context.Context, httpProxy, httpsProxy, noProxy string) error {
+	return conn.proxyConfigErr
+}
+
+func (conn *mockConnection) SendRunCommand(ctx context.Context, cmd task.Command) (task.CommandOutput, error) {
+	return task.CommandOutput{}, nil
+}
+
+func (conn *mockConnection) SendProServices(ctx context.Context, enable, disable []string) ([]task.ServiceResult, error) {
+	return nil, nil
+}
+
+func (conn *mockConnection) SendSecurityUpdate(context.Context) (task.SecurityUpdateReport, error) {
+	return task.SecurityUpdateReport{}, nil
+}
+
+func (conn *mockConnection) SendPackageInventory(context.Context) ([]task.Package, error) {
+	return nil, nil
+}
+
 func (conn *mockConnection) Close() {
 	conn.closed.Store(true)
 }
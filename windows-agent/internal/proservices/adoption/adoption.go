@@ -0,0 +1,279 @@
+// Package adoption makes unmanaged Ubuntu distros managed without user action. When the
+// organization opts in, it periodically looks for running unmanaged distros and installs and
+// enables wsl-pro-service in them, so that they connect to the agent.
+package adoption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/ubuntu/decorate"
+	wsl "github.com/ubuntu/gowsl"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// adoptionInterval is how often the unmanaged distros are looked for.
+	adoptionInterval = 5 * time.Minute
+
+	// retryDelay is the time to wait before attempting to adopt a distro again.
+	retryDelay = time.Hour
+
+	// maxAttempts is the number of attempts after which a distro that is still unmanaged is no longer adopted.
+	maxAttempts = 3
+
+	// adoptionBucket is the storage bucket where the outcome of the adoption attempts is kept, indexed by GUID.
+	adoptionBucket = "adoption"
+)
+
+// Outcome is the result of the last attempt to adopt a distro.
+type Outcome string
+
+const (
+	// Adopted means that wsl-pro-service was installed and enabled in the distro.
+	Adopted Outcome = "adopted"
+
+	// Failed means that wsl-pro-service could not be installed or enabled in the distro.
+	Failed Outcome = "failed"
+)
+
+// Record is the outcome of the attempts to adopt a distro. It is removed once the distro is no longer unmanaged.
+type Record struct {
+	Name        string    `yaml:"name"`
+	Attempts    int       `yaml:"attempts"` // Attempts so far, including those that succeeded but the distro never connected
+	LastAttempt time.Time `yaml:"last_attempt"`
+	Outcome     Outcome   `yaml:"outcome"`
+	Error       string    `yaml:"error,omitempty"`
+}
+
+// Config is the configuration the adoption service depends on.
+type Config interface {
+	AdoptUnmanaged() bool
+}
+
+// Service adopts unmanaged distros in the background.
+type Service struct {
+	ctx    context.Context
+	cancel func()
+
+	conf       Config
+	db         *database.DistroDB
+	storageDir string
+
+	// running is closed when the background loop returns. It is nil if the service was never started.
+	running chan struct{}
+}
+
+// New creates a new adoption service. Call Start to begin adopting distros.
+func New(ctx context.Context, conf Config, db *database.DistroDB, storageDir string) *Service {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &Service{
+		ctx:        ctx,
+		cancel:     cancel,
+		conf:       conf,
+		db:         db,
+		storageDir: storageDir,
+	}
+}
+
+// Start begins looking for distros to adopt in the background.
+func (s *Service) Start() {
+	s.running = make(chan struct{})
+	go s.run()
+}
+
+// Stop stops looking for distros to adopt, and waits for the ongoing attempts to finish.
+func (s *Service) Stop() {
+	s.cancel()
+	if s.running != nil {
+		<-s.running
+	}
+}
+
+func (s *Service) run() {
+	defer close(s.running)
+
+	ticker := time.NewTicker(adoptionInterval)
+	defer ticker.Stop()
+
+	for {
+		s.adoptAll()
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// adoptAll attempts to adopt every running unmanaged distro that is due, if the policy allows it.
+func (s *Service) adoptAll() {
+	if !s.conf.AdoptUnmanaged() {
+		return
+	}
+
+	if err := s.pruneRecords(); err != nil {
+		log.Warningf(s.ctx, "Adoption: %v", err)
+	}
+
+	for _, d := range s.db.GetUnmanagedDistros() {
+		if s.ctx.Err() != nil {
+			return
+		}
+
+		// We never wake up a distro just to adopt it.
+		if d.State != wsl.Running {
+			continue
+		}
+
+		if err := s.adopt(d); err != nil {
+			log.Warningf(s.ctx, "Adoption: %v", err)
+		}
+	}
+}
+
+// adopt attempts to adopt the distro if it is due, and records the outcome.
+func (s *Service) adopt(d database.BasicDistroInfo) (err error) {
+	defer decorate.OnError(&err, "could not adopt distro %q", d.Name)
+
+	r, err := s.record(d.GUID)
+	if err != nil {
+		return err
+	}
+
+	// A distro that was adopted recently may not have connected yet: we give it time before trying again.
+	// One that never connects after being adopted counts as a failure, lest we retry forever.
+	now := time.Now()
+	if r.Attempts >= maxAttempts || now.Sub(r.LastAttempt) < retryDelay {
+		return nil
+	}
+
+	log.Infof(s.ctx, "Adoption: installing and enabling wsl-pro-service in unmanaged distro %q", d.Name)
+	out, cmdErr := adoptCommand(s.ctx, d.Name)
+
+	r.Name = d.Name
+	r.LastAttempt = now
+	r.Attempts++
+	if cmdErr != nil {
+		r.Outcome = Failed
+		r.Error = fmt.Sprintf("%v. Output: %s", cmdErr, strings.TrimSpace(string(out)))
+	} else {
+		r.Outcome = Adopted
+		r.Error = ""
+	}
+
+	if err := s.setRecord(d.GUID, r); err != nil {
+		return errors.Join(cmdErr, err)
+	}
+
+	if cmdErr != nil {
+		return fmt.Errorf("attempt %d of %d failed: %s", r.Attempts, maxAttempts, r.Error)
+	}
+
+	log.Infof(s.ctx, "Adoption: wsl-pro-service is enabled in distro %q", d.Name)
+	return nil
+}
+
+// record returns the outcome of the previous attempts to adopt the distro, if any.
+func (s *Service) record(guid string) (r Record, err error) {
+	store, err := storage.Open(s.ctx, s.storageDir)
+	if err != nil {
+		return r, err
+	}
+	defer store.Close()
+
+	var out []byte
+	err = store.View(func(tx storage.Tx) error {
+		out = tx.Get(adoptionBucket, guid)
+		return nil
+	})
+	if err != nil || out == nil {
+		return r, err
+	}
+
+	if err := yaml.Unmarshal(out, &r); err != nil {
+		return r, fmt.Errorf("could not parse adoption record: %v", err)
+	}
+
+	return r, nil
+}
+
+// setRecord stores the outcome of the attempts to adopt the distro.
+func (s *Service) setRecord(guid string, r Record) error {
+	out, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal adoption record: %v", err)
+	}
+
+	store, err := storage.Open(s.ctx, s.storageDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	return store.Update(func(tx storage.Tx) error {
+		return tx.Put(adoptionBucket, guid, out)
+	})
+}
+
+// pruneRecords removes the records of the distros that were unregistered or became managed. The records of
+// the unmanaged distros that are not listed, such as the stopped ones not seen running since the agent
+// started, are kept so that their attempts still count.
+func (s *Service) pruneRecords() (err error) {
+	defer decorate.OnError(&err, "could not prune adoption records")
+
+	registered, err := wsl.RegisteredDistros(s.ctx)
+	if err != nil {
+		return err
+	}
+
+	managed := make(map[string]struct{})
+	for _, d := range s.db.GetAll() {
+		managed[d.GUID()] = struct{}{}
+	}
+
+	keep := make(map[string]struct{}, len(registered))
+	for _, d := range registered {
+		guid, err := d.GUID()
+		if err != nil {
+			return fmt.Errorf("could not get the GUID of distro %q: %v", d.Name(), err)
+		}
+		if _, ok := managed[guid.String()]; !ok {
+			keep[guid.String()] = struct{}{}
+		}
+	}
+
+	store, err := storage.Open(s.ctx, s.storageDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	return store.Update(func(tx storage.Tx) error {
+		var prune []string
+		err := tx.ForEach(adoptionBucket, func(guid string, _ []byte) error {
+			if _, ok := keep[guid]; !ok {
+				prune = append(prune, guid)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, guid := range prune {
+			if err := tx.Delete(adoptionBucket, guid); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
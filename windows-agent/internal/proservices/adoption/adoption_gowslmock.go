@@ -0,0 +1,19 @@
+//go:build gowslmock
+
+package adoption
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// adoptCommand mocks installing and enabling wsl-pro-service in the distro.
+// It intentionally fails for distros whose name contains "adoption_error".
+func adoptCommand(ctx context.Context, distroName string) ([]byte, error) {
+	if strings.Contains(distroName, "adoption_error") {
+		return []byte("E: Unable to locate package wsl-pro-service"), errors.New("exit status 100")
+	}
+
+	return []byte("Created symlink /etc/systemd/system/multi-user.target.wants/wsl-pro.service"), nil
+}
@@ -0,0 +1,9 @@
+//go:build !gowslmock
+
+package adoption
+
+import "context"
+
+func adoptCommand(ctx context.Context, distroName string) ([]byte, error) {
+	panic("adoptCommand: this function can only be run on Windows")
+}
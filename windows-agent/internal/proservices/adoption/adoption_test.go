@@ -0,0 +1,172 @@
+package adoption_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/adoption"
+	"github.com/stretchr/testify/require"
+	wsl "github.com/ubuntu/gowsl"
+	wslmock "github.com/ubuntu/gowsl/mock"
+)
+
+func TestMain(m *testing.M) {
+	if !wsl.MockAvailable() {
+		// Adopting a distro installs packages in it: we only run these tests with the mock.
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestAdopt(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		policyDisabled bool
+		stoppedDistro  bool
+		commandError   bool
+		managedDistro  bool
+
+		unregisterDistro bool
+
+		previous *adoption.Record
+
+		wantNoRecord bool
+		wantOutcome  adoption.Outcome
+		wantAttempts int
+	}{
+		"Success adopting a running distro":                             {wantOutcome: adoption.Adopted, wantAttempts: 1},
+		"Success retrying a distro that failed a long time ago":         {previous: &adoption.Record{Outcome: adoption.Failed, Error: "mock error", Attempts: 1, LastAttempt: time.Now().Add(-2 * time.Hour)}, wantOutcome: adoption.Adopted, wantAttempts: 2},
+		"Success retrying a distro that never connected after adoption": {previous: &adoption.Record{Outcome: adoption.Adopted, Attempts: 1, LastAttempt: time.Now().Add(-2 * time.Hour)}, wantOutcome: adoption.Adopted, wantAttempts: 2},
+		"Success removing the record of a distro that is now managed":   {managedDistro: true, previous: &adoption.Record{Outcome: adoption.Adopted, Attempts: 1, LastAttempt: time.Now()}, wantNoRecord: true},
+		"Success keeping the record of a stopped distro":                {stoppedDistro: true, previous: &adoption.Record{Outcome: adoption.Failed, Error: "mock error", Attempts: 1, LastAttempt: time.Now()}, wantOutcome: adoption.Failed, wantAttempts: 1},
+		"Success removing the record of a distro that was unregistered": {unregisterDistro: true, stoppedDistro: true, previous: &adoption.Record{Outcome: adoption.Failed, Error: "mock error", Attempts: 1, LastAttempt: time.Now()}, wantNoRecord: true},
+
+		"No attempt when the policy is disabled":          {policyDisabled: true, wantNoRecord: true},
+		"No attempt when the distro is stopped":           {stoppedDistro: true, wantNoRecord: true},
+		"No attempt when the distro is managed":           {managedDistro: true, wantNoRecord: true},
+		"No attempt when the distro failed recently":      {previous: &adoption.Record{Outcome: adoption.Failed, Error: "mock error", Attempts: 1, LastAttempt: time.Now()}, wantOutcome: adoption.Failed, wantAttempts: 1},
+		"No attempt when the distro was adopted recently": {previous: &adoption.Record{Outcome: adoption.Adopted, Attempts: 1, LastAttempt: time.Now()}, wantOutcome: adoption.Adopted, wantAttempts: 1},
+		"No attempt after too many failures":              {previous: &adoption.Record{Outcome: adoption.Failed, Error: "mock error", Attempts: adoption.MaxAttempts, LastAttempt: time.Now().Add(-2 * time.Hour)}, wantOutcome: adoption.Failed, wantAttempts: adoption.MaxAttempts},
+		"No attempt after too many adoptions":             {previous: &adoption.Record{Outcome: adoption.Adopted, Attempts: adoption.MaxAttempts, LastAttempt: time.Now().Add(-2 * time.Hour)}, wantOutcome: adoption.Adopted, wantAttempts: adoption.MaxAttempts},
+
+		"Error recorded when the command fails":            {commandError: true, wantOutcome: adoption.Failed, wantAttempts: 1},
+		"Error recorded when the command fails once again": {commandError: true, previous: &adoption.Record{Outcome: adoption.Failed, Error: "mock error", Attempts: 1, LastAttempt: time.Now().Add(-2 * time.Hour)}, wantOutcome: adoption.Failed, wantAttempts: 2},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			uncRoot := t.TempDir()
+			ctx := database.WithUNCRootPath(wsl.WithMock(context.Background(), wslmock.New()), uncRoot)
+
+			distroName := wsltestutils.RandomDistroName(t)
+			if tc.commandError {
+				distroName += "_adoption_error"
+			}
+			guid := wsltestutils.RegisterDistroNamed(t, ctx, distroName)
+			registeredName := distroName
+
+			// Unmanaged distros are listed with the name WSL reports, which is lowercase.
+			distroName = strings.ToLower(distroName)
+			testutils.WriteOsRelease(t, uncRoot, distroName, "ubuntu-os-release")
+			writeHostname(t, uncRoot, distroName)
+			if !tc.stoppedDistro {
+				keepDistroRunning(t, ctx, distroName)
+			}
+
+			storageDir := t.TempDir()
+			db, err := database.New(ctx, storageDir)
+			require.NoError(t, err, "Setup: could not create empty database")
+			defer db.Close(ctx)
+
+			if tc.managedDistro {
+				_, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+				require.NoError(t, err, "Setup: could not add the distro to the database")
+			}
+
+			s := adoption.New(ctx, mockConfig{adopt: !tc.policyDisabled}, db, storageDir)
+			defer s.Stop()
+
+			if tc.previous != nil {
+				err := s.SetRecord(guid, *tc.previous)
+				require.NoError(t, err, "Setup: could not store the previous adoption record")
+			}
+
+			if tc.unregisterDistro {
+				wsltestutils.UnregisterDistro(t, ctx, registeredName)
+				db.RegistrationsChanged()
+				require.Eventually(t, func() bool { return len(db.GetUnmanagedDistros()) == 0 },
+					5*time.Second, 100*time.Millisecond, "Setup: the unregistered distro should no longer be listed")
+			}
+
+			s.AdoptAll()
+
+			got, err := s.Record(guid)
+			require.NoError(t, err, "Record should not return an error")
+
+			if tc.wantNoRecord {
+				require.Zero(t, got, "No adoption should have been attempted")
+				return
+			}
+
+			require.Equal(t, tc.wantOutcome, got.Outcome, "Unexpected adoption outcome")
+			require.Equal(t, tc.wantAttempts, got.Attempts, "Unexpected number of attempts")
+
+			if tc.wantOutcome == adoption.Failed {
+				require.NotEmpty(t, got.Error, "A failed adoption should record its error")
+			} else {
+				require.Empty(t, got.Error, "A successful adoption should not record an error")
+			}
+
+			if tc.previous != nil && tc.previous.LastAttempt.Equal(got.LastAttempt) {
+				// The distro was not due: nothing else changed.
+				return
+			}
+			require.Equal(t, distroName, got.Name, "The adoption record should contain the distro name")
+			require.WithinDuration(t, time.Now(), got.LastAttempt, time.Minute, "The adoption record should contain the time of the attempt")
+		})
+	}
+}
+
+// keepDistroRunning starts a long-lived process in the mocked distro so that it is running until the test ends.
+func keepDistroRunning(t *testing.T, ctx context.Context, distroName string) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(ctx)
+	d := wsl.NewDistro(ctx, distroName)
+	cmd := d.Command(ctx, "sleep infinity")
+	require.NoError(t, cmd.Start(), "Setup: could not start a process in the distro")
+
+	t.Cleanup(func() {
+		cancel()
+		_ = cmd.Wait()
+	})
+}
+
+type mockConfig struct {
+	adopt bool
+}
+
+func (c mockConfig) AdoptUnmanaged() bool {
+	return c.adopt
+}
+
+// writeHostname writes the hostname file the agent reads when inspecting unmanaged distros.
+func writeHostname(t *testing.T, uncRoot, distroName string) {
+	t.Helper()
+
+	dir := filepath.Join(uncRoot, distroName, "etc")
+	require.NoError(t, os.MkdirAll(dir, 0750), "Setup: could not create the etc directory of the distro")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hostname"), []byte(distroName+"\n"), 0600), "Setup: could not write the hostname file")
+}
@@ -0,0 +1,37 @@
+//go:build !gowslmock
+
+package adoption
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// https://learn.microsoft.com/en-us/windows/win32/procthread/process-creation-flags
+//
+// CREATE_NO_WINDOW:
+// The process is a console application that is being run without
+// a console window. Therefore, the console handle for the
+// application is not set.
+const createNoWindow = 0x08000000
+
+// adoptScript installs wsl-pro-service if it is missing, then enables it.
+const adoptScript = `if ! dpkg-query -W -f='${Status}' wsl-pro-service 2>/dev/null | grep -q 'install ok installed'; then
+	export DEBIAN_FRONTEND=noninteractive
+	apt-get update
+	apt-get install -y wsl-pro-service
+fi
+systemctl enable --now wsl-pro.service`
+
+// adoptCommand installs and enables wsl-pro-service in the distro.
+// gowsl cannot run commands as root, hence we use wsl.exe directly.
+func adoptCommand(ctx context.Context, distroName string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "wsl.exe", "-u", "root", "-d", distroName, "--", "/bin/sh", "-ec", adoptScript)
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: createNoWindow,
+	}
+
+	return cmd.CombinedOutput()
+}
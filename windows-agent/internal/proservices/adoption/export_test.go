@@ -0,0 +1,19 @@
+package adoption
+
+// AdoptAll is a wrapper around adoptAll so as to make it accessible to tests.
+func (s *Service) AdoptAll() {
+	s.adoptAll()
+}
+
+// Record returns the outcome of the previous attempts to adopt the distro.
+func (s *Service) Record(guid string) (Record, error) {
+	return s.record(guid)
+}
+
+// SetRecord overrides the outcome of the previous attempts to adopt the distro.
+func (s *Service) SetRecord(guid string, r Record) error {
+	return s.setRecord(guid, r)
+}
+
+// MaxAttempts is the number of attempts after which a distro that is still unmanaged is no longer adopted.
+const MaxAttempts = maxAttempts
@@ -166,16 +166,20 @@ func TestNewInstanceHook(t *testing.T) {
 		breakConfig bool
 		proToken    string
 		lcape       string
+		services    string
 		props       distro.Properties
 
 		wantErr   bool
 		taskCount int
 	}{
-		"Success":                       {proToken: "token", lcape: "[client]", taskCount: 2},
-		"Success with a pro token only": {proToken: "token", taskCount: 1},
-		"Success with no tasks":         {taskCount: 0},
-		"No tasks when the instance is already pro attached": {proToken: "token", props: distro.Properties{ProAttached: true}, wantErr: false, taskCount: 0},
-		"Error when the config cannot be loaded":             {breakConfig: true, wantErr: true},
+		"Success":                                                 {proToken: "token", lcape: "[client]", taskCount: 2},
+		"Success with a pro token only":                           {proToken: "token", taskCount: 1},
+		"Success with no tasks":                                   {taskCount: 0},
+		"Success with Ubuntu Pro services":                        {proToken: "token", services: "esm-apps,-usg", taskCount: 2},
+		"No tasks when the instance is already pro attached":      {proToken: "token", props: distro.Properties{ProAttached: true}, wantErr: false, taskCount: 0},
+		"Only services when the instance is already pro attached": {proToken: "token", services: "esm-apps", props: distro.Properties{ProAttached: true}, taskCount: 1},
+		"No services without a pro token":                         {services: "esm-apps", taskCount: 0},
+		"Error when the config cannot be loaded":                  {breakConfig: true, wantErr: true},
 	}
 	for name, tc := range testcases {
 		t.Run(name, func(t *testing.T) {
@@ -204,7 +208,11 @@ func TestNewInstanceHook(t *testing.T) {
 			}
 			ctx := t.Context()
 			conf := config.New(ctx, privateDir)
-			tsks, err := newInstanceTasks(conf, tc.props)
+			if tc.services != "" {
+				err := conf.UpdateRegistryData(ctx, config.RegistryData{UbuntuProServices: tc.services}, nil)
+				require.NoError(t, err, "Setup: could not set the Ubuntu Pro services")
+			}
+			tsks, err := newInstanceTasks(conf, "Ubuntu", nil, tc.props)
 			if tc.wantErr {
 				require.Error(t, err, "NewInstanceTasks should have failed")
 				return
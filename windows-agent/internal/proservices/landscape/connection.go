@@ -11,6 +11,7 @@ import (
 	landscapeapi "github.com/canonical/landscape-hostagent-api"
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proxy"
 	"github.com/ubuntu/decorate"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/connectivity"
@@ -58,7 +59,16 @@ func newConnection(ctx context.Context, d serviceData) (conn *connection, err er
 		return nil, err
 	}
 
-	grpcConn, err := grpc.NewClient(conn.hostConf.hostagentURL, grpc.WithTransportCredentials(creds))
+	target := conn.hostConf.hostagentURL
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if !conn.hostConf.proxy.IsZero() {
+		// The dialer must receive the host name rather than the resolved address, so that the
+		// proxy (and no_proxy) can be applied to it.
+		target = "passthrough:///" + target
+		opts = append(opts, grpc.WithContextDialer(proxy.Dialer(conn.hostConf.proxy)))
+	}
+
+	grpcConn, err := grpc.NewClient(target, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -3,7 +3,13 @@ package landscape
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
+	"github.com/ubuntu/decorate"
 )
 
 // Controller is a light-weight structure used to send certain instructions to
@@ -42,6 +48,51 @@ func (c Controller) Reconnect(ctx context.Context) (succcess bool) {
 	return c.forceReconnect(ctx)
 }
 
+// ApplySecurityUpdates queues a task applying the pending security updates in each of the distros.
+// Nothing is queued if any of the distros is not in the database.
+func (c Controller) ApplySecurityUpdates(ctx context.Context, distroIDs ...string) (err error) {
+	distros := make([]*distro.Distro, 0, len(distroIDs))
+	for _, id := range distroIDs {
+		d, ok := c.database().Get(id)
+		if !ok {
+			return fmt.Errorf("distro %q not in database", id)
+		}
+		distros = append(distros, d)
+	}
+
+	for _, d := range distros {
+		if e := d.SubmitTasks(tasks.SecurityUpdate{}); e != nil {
+			err = errors.Join(err, fmt.Errorf("could not submit the security updates to distro %q: %v", d.Name(), e))
+		}
+	}
+
+	return err
+}
+
+// DeadLetters returns the tasks that were given up on in the distros reported to Landscape, indexed by distro name.
+func (c Controller) DeadLetters() (letters map[string][]worker.DeadLetter, err error) {
+	defer decorate.OnError(&err, "could not get the dead letters of the distros reported to Landscape")
+
+	conf, err := newLandscapeHostConf(c.config())
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := c.database().DeadLetters()
+	if err != nil {
+		return nil, err
+	}
+
+	letters = make(map[string][]worker.DeadLetter)
+	for _, d := range reportedDistros(c, conf) {
+		if l, ok := all[d.Name()]; ok {
+			letters[d.Name()] = l
+		}
+	}
+
+	return letters, nil
+}
+
 // tryReconnect sends a "please, connect" signal to the Landscape client and blocks until
 // this connection is established, or until the context is canceled. Returns true if the
 // connection was successfully established.
@@ -19,7 +19,9 @@ import (
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	d "github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro/touchdistro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/landscape/distroinstall"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proxy"
 	"github.com/ubuntu/decorate"
 	"github.com/ubuntu/gowsl"
 	"google.golang.org/grpc"
@@ -167,7 +169,9 @@ func (e executor) start(ctx context.Context, cmd *landscapeapi.Command_Start) (e
 	}
 
 	e.sendProgressStatusMsg(ctx, landscapeapi.CommandState_InProgress)
-	return d.LockAwake()
+
+	// Someone is waiting for this distro: it goes before the distros started to run tasks.
+	return d.LockAwakeWithPriority(startscheduler.Interactive)
 }
 
 func (e executor) stop(ctx context.Context, cmd *landscapeapi.Command_Stop) (err error) {
@@ -226,8 +230,13 @@ func (e executor) install(ctx context.Context, cmd *landscapeapi.Command_Install
 			return fmt.Errorf("target distro ID %s is reserved and should not be used for custom instances", id)
 		}
 
+		proxySettings, err := e.config().Proxy()
+		if err != nil {
+			return fmt.Errorf("could not get proxy settings: %v", err)
+		}
+
 		e.sendProgressStatusMsg(ctx, landscapeapi.CommandState_InProgress)
-		if err = installFromURL(ctx, e.homeDir(), e.downloadDir(), distro, u); err != nil {
+		if err = installFromURL(ctx, proxy.HTTPClient(proxySettings, 0), e.homeDir(), e.downloadDir(), distro, u); err != nil {
 			return err
 		}
 	} else {
@@ -350,7 +359,7 @@ func createDefaultUser(ctx context.Context, distro gowsl.Distro) (err error) {
 	return distro.DefaultUID(uid)
 }
 
-func installFromURL(ctx context.Context, homeDir string, downloadDir string, distro gowsl.Distro, rootfsURL *url.URL) (err error) {
+func installFromURL(ctx context.Context, client *http.Client, homeDir string, downloadDir string, distro gowsl.Distro, rootfsURL *url.URL) (err error) {
 	defer decorate.OnError(&err, "can't install from URL: %q", rootfsURL)
 
 	tmpDir := filepath.Join(downloadDir, distro.Name())
@@ -362,7 +371,7 @@ func installFromURL(ctx context.Context, homeDir string, downloadDir string, dis
 
 	tarball := filepath.Join(tmpDir, distro.Name()+".tar.gz")
 
-	err = download(ctx, rootfsURL, tarball)
+	err = download(ctx, client, rootfsURL, tarball)
 	if err != nil {
 		return err
 	}
@@ -390,15 +399,15 @@ func installFromURL(ctx context.Context, homeDir string, downloadDir string, dis
 
 // download downloads the rootfs from the given URL and writes it to the given destination while verifying its checksum.
 // The checksum is read from the SHA256SUMS file found alongside the rootfs URL, as done in cloud-images.ubuntu.com.
-func download(ctx context.Context, u *url.URL, destination string) (err error) {
+func download(ctx context.Context, client *http.Client, u *url.URL, destination string) (err error) {
 	defer decorate.OnError(&err, "could not download %q", u)
 
-	checksum, err := wantRootfsChecksum(ctx, u)
+	checksum, err := wantRootfsChecksum(ctx, client, u)
 	if err != nil {
 		return err
 	}
 
-	resp, err := http.Get(u.String())
+	resp, err := client.Get(u.String())
 	if err != nil {
 		return err
 	}
@@ -448,7 +457,7 @@ func download(ctx context.Context, u *url.URL, destination string) (err error) {
 // 2646292d657f4c9ef5dfce804a5a1e66d8c1324c74147b8bc9b1bf154d7feaf8 *jammy-server-cloudimg-arm64-root.tar.xz
 //
 // ...
-func wantRootfsChecksum(ctx context.Context, u *url.URL) (string, error) {
+func wantRootfsChecksum(ctx context.Context, client *http.Client, u *url.URL) (string, error) {
 	imageName := filepath.Base(u.Path)
 	shasRelativeURL, err := url.Parse("SHA256SUMS")
 	if err != nil {
@@ -456,7 +465,7 @@ func wantRootfsChecksum(ctx context.Context, u *url.URL) (string, error) {
 	}
 	checksumsURL := u.ResolveReference(shasRelativeURL)
 
-	resp, err := http.Get(checksumsURL.String())
+	resp, err := client.Get(checksumsURL.String())
 	// Errors here are protocol errors, not 404s. "A non-2xx response doesn't cause an error".
 	if err != nil {
 		return "", fmt.Errorf("could not download checksums file %q: %v", checksumsURL, err)
@@ -16,10 +16,10 @@ import (
 	landscapeapi "github.com/canonical/landscape-hostagent-api"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/mocks/landscape/landscapemockservice"
-	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/landscape"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage/storagetestutils"
 	"github.com/stretchr/testify/require"
 	wsl "github.com/ubuntu/gowsl"
 	wslmock "github.com/ubuntu/gowsl/mock"
@@ -310,8 +310,7 @@ func TestInstall(t *testing.T) {
 					}
 
 					if tc.corruptDb {
-						require.NoError(t, os.RemoveAll(filepath.Join(home, consts.DatabaseFileName)), "Setup: removing the database file should not fail")
-						require.NoError(t, os.MkdirAll(filepath.Join(home, consts.DatabaseFileName), 0750), "Setup: breaking the database file should not fail")
+						testBed.storageBreaker.Break()
 					}
 
 					var cloudInit string
@@ -762,6 +761,9 @@ type commandTestBed struct {
 	clientService *landscape.Service
 
 	wslMock *wslmock.Backend
+
+	// storageBreaker makes writes to the storage of the agent components fail.
+	storageBreaker *storagetestutils.Breaker
 }
 
 // distroSettings tells testReceiveCommand what the test distro should be like.
@@ -804,6 +806,8 @@ func testReceiveCommand(t *testing.T, distrosettings distroSettings, homedir str
 	tb.wslMock = wslmock.New()
 	ctx = wsl.WithMock(ctx, tb.wslMock)
 
+	ctx, tb.storageBreaker = storagetestutils.WithBreakableBackend(ctx)
+
 	tb.ctx = ctx
 
 	// Set up Landscape server
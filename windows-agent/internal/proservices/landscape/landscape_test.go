@@ -25,13 +25,17 @@ import (
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/rollout"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/landscape"
-	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	wsl "github.com/ubuntu/gowsl"
 	wslmock "github.com/ubuntu/gowsl/mock"
+	"go.yaml.in/yaml/v3"
 	"golang.org/x/exp/maps"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -118,6 +122,7 @@ func TestConnect(t *testing.T) {
 		clientUsesTLS              bool
 		serverUsesTLS              bool
 		breakLandscapeClientConfig bool
+		breakProxy                 bool
 
 		breakUIDFile bool
 		uid          string
@@ -146,6 +151,7 @@ func TestConnect(t *testing.T) {
 		"Error when the server returns invalid argument":       {serverErrorCode: codes.InvalidArgument, wantErr: true},
 		"Error when the first-contact SendUpdatedInfo fails":   {tokenErr: true, wantErr: true},
 		"Error when the config cannot be accessed":             {breakLandscapeClientConfig: true, wantErr: true},
+		"Error when the proxy settings cannot be accessed":     {breakProxy: true, wantErr: true},
 		"Error when the config cannot be parsed":               {wantErr: true},
 		"Error when the SSL certificate cannot be read":        {clientUsesTLS: true, serverUsesTLS: true, wantErr: true},
 		"Error when the SSL certificate is not valid":          {clientUsesTLS: true, serverUsesTLS: true, wantErr: true},
@@ -192,6 +198,7 @@ func TestConnect(t *testing.T) {
 
 			// We trigger an error when deciding to use a certificate or not
 			conf.landscapeConfigErr = tc.breakLandscapeClientConfig
+			conf.proxyErr = tc.breakProxy
 
 			conf.landscapeAgentUID = tc.uid
 
@@ -303,9 +310,9 @@ func TestSendUpdatedInfo(t *testing.T) {
 	}
 
 	testCases := map[string]struct {
-		tokenErr     bool
-		stateErr     bool
-		wslLaunchErr bool
+		tokenErr            bool
+		stateErr            bool
+		noUnmanagedHostname bool
 
 		breakWSLRegistry   bool // Needs dontRegisterDistro to be true
 		dontRegisterDistro bool
@@ -317,6 +324,9 @@ func TestSendUpdatedInfo(t *testing.T) {
 		withUnmanagedInstance bool
 		wantUnmanagedInstance bool
 
+		landscapeLabels string
+		distroLabels    []string
+
 		wantErr           bool
 		wantDistroSkipped bool
 	}{
@@ -327,7 +337,10 @@ func TestSendUpdatedInfo(t *testing.T) {
 
 		"Success with an unmanaged distro":                       {withUnmanagedInstance: true, wantUnmanagedInstance: true},
 		"Skip unmanaged distro when state cannot be obtained":    {withUnmanagedInstance: true, stateErr: true, wantDistroSkipped: true},
-		"Skip unmanaged distro when hostname cannot be obtained": {withUnmanagedInstance: true, wslLaunchErr: true},
+		"Skip unmanaged distro when hostname cannot be obtained": {withUnmanagedInstance: true, noUnmanagedHostname: true},
+
+		"Success with a distro carrying the Landscape labels": {landscapeLabels: "CI, sandbox", distroLabels: []string{"ci", "sandbox", "eu"}},
+		"Skip distros not carrying all the Landscape labels":  {landscapeLabels: "ci, sandbox", distroLabels: []string{"ci"}, wantDistroSkipped: true},
 
 		"Error when the token cannot be retreived":                           {tokenErr: true, wantErr: true},
 		"Error when attempting to SendUpdatedInfo after having disconnected": {disconnectBeforeSend: true, wantErr: true},
@@ -340,18 +353,22 @@ func TestSendUpdatedInfo(t *testing.T) {
 				t.Parallel()
 				mock := wslmock.New()
 				mock.StateError = tc.stateErr
-				mock.WslLaunchError = tc.wslLaunchErr
 				mock.OpenLxssKeyError = tc.breakWSLRegistry
 				ctx = wsl.WithMock(ctx, mock)
-			} else if tc.stateErr || tc.wslLaunchErr || tc.breakWSLRegistry {
+			} else if tc.stateErr || tc.breakWSLRegistry {
 				t.Skip("This test is skipped because it necessitates the GoWSL mock")
 			}
 
 			lis, server, mockService := setUpLandscapeMock(t, ctx, "localhost:", "", nil)
 
+			lconf := defaultLandscapeConfig
+			if tc.landscapeLabels != "" {
+				lconf = strings.Replace(lconf, "[host]\n", fmt.Sprintf("[host]\nlabels = %s\n", tc.landscapeLabels), 1)
+			}
+
 			conf := &mockConfig{
 				proToken:              "TOKEN",
-				landscapeClientConfig: executeLandscapeConfigTemplate(t, defaultLandscapeConfig, "", lis.Addr().String()),
+				landscapeClientConfig: executeLandscapeConfigTemplate(t, lconf, "", lis.Addr().String()),
 			}
 
 			//nolint:errcheck // We don't care about these errors
@@ -365,6 +382,21 @@ func TestSendUpdatedInfo(t *testing.T) {
 				uncRoot := t.TempDir()
 				ctx = database.WithUNCRootPath(ctx, uncRoot)
 				testutils.WriteOsRelease(t, uncRoot, unmanaged, "ubuntu-os-release")
+				if !tc.noUnmanagedHostname {
+					etc := filepath.Join(uncRoot, unmanaged, "etc")
+					require.NoError(t, os.MkdirAll(etc, 0750), "Setup: could not create the etc directory of the unmanaged distro")
+					require.NoError(t, os.WriteFile(filepath.Join(etc, "hostname"), []byte("unmanaged\n"), 0600), "Setup: could not write the hostname of the unmanaged distro")
+				}
+
+				// Unmanaged distros are only inspected while running.
+				ctx, cancel := context.WithCancel(ctx)
+				d := wsl.NewDistro(ctx, unmanaged)
+				cmd := d.Command(ctx, "sleep infinity")
+				require.NoError(t, cmd.Start(), "Setup: could not keep the unmanaged distro running")
+				defer func() {
+					cancel()
+					_ = cmd.Wait()
+				}()
 			}
 
 			db, err := database.New(ctx, t.TempDir())
@@ -382,6 +414,7 @@ func TestSendUpdatedInfo(t *testing.T) {
 					PrettyName:  "😎 Cool guy 🎸",
 					Hostname:    "CoolMachine",
 					ProAttached: true,
+					Labels:      tc.distroLabels,
 				}
 
 				d, err = db.GetDistroAndUpdateProperties(ctx, distroName, props)
@@ -977,24 +1010,22 @@ func TestNotifyConfigUpdate(t *testing.T) {
 			service.NotifyConfigUpdate(ctx, tc.conf, tc.uid)
 
 			// There is no direct way to observe the result of that function other than relying on the implementation details of the task database.
-			tasksFiles, err := filepath.Glob(filepath.Join(storageDir, "*.tasks"))
-			require.NoError(t, err, "NotifyConfigUpdate: could not list the tasks files storage dir: %s", storageDir)
+			storedTasks := readStoredTasks(t, ctx, storageDir)
 
 			if tc.wantNoTasks {
-				require.Empty(t, tasksFiles, "NotifyConfigUpdate: should not have created a tasks file when the database is empty")
+				require.Empty(t, storedTasks, "NotifyConfigUpdate: should not have stored any tasks when the database is empty")
 				return
 			}
 
-			b, err := os.ReadFile(tasksFiles[0])
-			require.NoError(t, err, "NotifyConfigUpdate: should have caused creation of a tasks file")
-			task := strings.TrimSpace(strings.ReplaceAll(string(b), " ", ""))
+			require.Len(t, storedTasks, 1, "NotifyConfigUpdate: should have stored the tasks of the distro")
+			task := strings.TrimSpace(strings.ReplaceAll(storedTasks[0], " ", ""))
 			require.NotEmpty(t, task, "NotifyConfigUpdate: tasks file should not be empty")
 			if tc.uid == "" && tc.conf != "" {
 				require.NotContains(t, task, tc.want, "NotifyConfigUpdate: tasks file should not contain the Landscape client config submitted")
 			} else {
 				require.Contains(t, task, tc.want, "NotifyConfigUpdate: tasks file should contain the Landscape client config submitted")
 			}
-			require.Contains(t, task, tasks.LandscapeConfigure{}.String(), "NotifyConfigUpdate: tasks file should contain a LandscapeConfigure task")
+			require.Contains(t, task, "landscape-configure", "NotifyConfigUpdate: tasks file should contain a LandscapeConfigure task")
 		})
 	}
 }
@@ -1051,16 +1082,13 @@ func TestNotifyConfigUpdateWithAgentYaml(t *testing.T) {
 			_ = c.SetLandscapeAgentUID(ctx, "landscapeUID")
 
 			// There is no direct way to observe the result of that function other than relying on the implementation details of the task database.
-			tasksFiles, err := filepath.Glob(filepath.Join(storageDir, "*.tasks"))
-			require.NoError(t, err, "NotifyConfigUpdate: could not list the tasks files storage dir: %s", storageDir)
-			require.NotEmpty(t, tasksFiles, "NotifyConfigUpdate: should have created a tasks file")
+			storedTasks := readStoredTasks(t, ctx, storageDir)
+			require.Len(t, storedTasks, 1, "NotifyConfigUpdate: should have stored the tasks of the distro")
 
-			b, err := os.ReadFile(tasksFiles[0])
-			require.NoError(t, err, "NotifyConfigUpdate: should have caused creation of a tasks file")
-			task := string(b)
+			task := storedTasks[0]
 			require.NotEmpty(t, task, "NotifyConfigUpdate: tasks file should not be empty")
 
-			require.Contains(t, task, tasks.LandscapeConfigure{}.String(), "NotifyConfigUpdate: tasks file should contain a LandscapeConfigure task")
+			require.Contains(t, task, "landscape-configure", "NotifyConfigUpdate: tasks file should contain a LandscapeConfigure task")
 
 			basepath := testutils.TestFixturePath(t)
 
@@ -1074,6 +1102,182 @@ func TestNotifyConfigUpdateWithAgentYaml(t *testing.T) {
 		})
 	}
 }
+
+func TestApplySecurityUpdates(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	testcases := map[string]struct {
+		unknownDistro bool
+
+		wantErr bool
+	}{
+		"Success": {},
+
+		"Error when a distro is not in the database": {unknownDistro: true, wantErr: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			storageDir := t.TempDir()
+			db, err := database.New(ctx, storageDir)
+			require.NoError(t, err, "Setup: database New should not return an error")
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, true)
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: distro %s GetDistroAndUpdateProperties should return no errors", distroName)
+			// Let's stop the distro right away so it's worker don't race with us for the tasks file.
+			d.Cleanup(ctx)
+
+			ids := []string{distroName}
+			if tc.unknownDistro {
+				ids = append(ids, wsltestutils.RandomDistroName(t))
+			}
+
+			service, err := landscape.New(ctx, config.New(ctx, storageDir), db, &mockCloudInit{}, nil, landscape.WithHomeDir(t.TempDir()))
+			require.NoError(t, err, "Setup: New should not return an error")
+
+			err = service.Controller().ApplySecurityUpdates(ctx, ids...)
+			if tc.wantErr {
+				require.Error(t, err, "ApplySecurityUpdates should return an error")
+				require.Empty(t, readStoredTasks(t, ctx, storageDir), "ApplySecurityUpdates should not queue any task if a distro is missing")
+				return
+			}
+			require.NoError(t, err, "ApplySecurityUpdates should return no error")
+
+			storedTasks := readStoredTasks(t, ctx, storageDir)
+			require.Len(t, storedTasks, 1, "ApplySecurityUpdates: should have stored the tasks of the distro")
+			require.Contains(t, storedTasks[0], "type: security-update", "ApplySecurityUpdates: tasks file should contain a SecurityUpdate task")
+		})
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	testcases := map[string]struct {
+		landscapeLabels string
+		noConfig        bool
+		breakStorage    bool
+
+		wantLetters bool
+		wantErr     bool
+	}{
+		"Success with the dead letters of a reported distro":                   {wantLetters: true},
+		"Success with the dead letters of a distro carrying the labels":        {landscapeLabels: "ci", wantLetters: true},
+		"Success omitting the dead letters of distros not carrying the labels": {landscapeLabels: "sandbox"},
+
+		"Error when there is no Landscape configuration": {noConfig: true, wantErr: true},
+		"Error when the dead letters cannot be read":     {breakStorage: true, wantErr: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, true)
+
+			letters := []worker.DeadLetter{{ID: "task-1", Type: "security-update", Task: "SecurityUpdate", Attempts: 3, Error: "timeout"}}
+			stored, err := yaml.Marshal(letters)
+			require.NoError(t, err, "Setup: could not marshal the dead letters")
+			if tc.breakStorage {
+				stored = []byte("[this is not valid YAML")
+			}
+
+			storageDir := t.TempDir()
+			s, err := storage.Open(ctx, storageDir)
+			require.NoError(t, err, "Setup: could not open the storage")
+			err = s.Update(func(tx storage.Tx) error { return tx.Put("deadletters", distroName, stored) })
+			require.NoError(t, err, "Setup: could not store the dead letters")
+			require.NoError(t, s.Close(), "Setup: could not close the storage")
+
+			db, err := database.New(ctx, storageDir)
+			require.NoError(t, err, "Setup: database New should not return an error")
+			defer db.Close(ctx)
+
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{Labels: []string{"ci"}})
+			require.NoError(t, err, "Setup: distro %s GetDistroAndUpdateProperties should return no errors", distroName)
+			d.Cleanup(ctx)
+
+			lconf := defaultLandscapeConfig
+			if tc.landscapeLabels != "" {
+				lconf = strings.Replace(lconf, "[host]\n", fmt.Sprintf("[host]\nlabels = %s\n", tc.landscapeLabels), 1)
+			}
+
+			conf := &mockConfig{proToken: "TOKEN"}
+			if !tc.noConfig {
+				conf.landscapeClientConfig = executeLandscapeConfigTemplate(t, lconf, "", "localhost:0")
+			}
+
+			service, err := landscape.New(ctx, conf, db, &mockCloudInit{}, nil, landscape.WithHomeDir(t.TempDir()))
+			require.NoError(t, err, "Setup: New should not return an error")
+
+			got, err := service.Controller().DeadLetters()
+			if tc.wantErr {
+				require.Error(t, err, "DeadLetters should return an error")
+				return
+			}
+			require.NoError(t, err, "DeadLetters should return no error")
+
+			if !tc.wantLetters {
+				require.Empty(t, got, "DeadLetters should not return the dead letters of distros not reported to Landscape")
+				return
+			}
+			require.Equal(t, map[string][]worker.DeadLetter{distroName: letters}, got, "DeadLetters should return the dead letters of the reported distros")
+		})
+	}
+}
+
+// readStoredTasks returns the serialized task queues of all distros in the storage. Submission and
+// expiry times are removed so that the queues can be compared with golden files.
+//
+//nolint:revive // testing.T always first!
+func readStoredTasks(t *testing.T, ctx context.Context, storageDir string) []string {
+	t.Helper()
+
+	s, err := storage.Open(ctx, storageDir)
+	require.NoError(t, err, "Could not open the storage")
+	defer s.Close()
+
+	stored, err := worker.StoredTasks(s)
+	require.NoError(t, err, "Could not read the stored tasks")
+
+	out := make([]string, 0, len(stored))
+	for _, tasks := range stored {
+		envelopes, undecodable, err := task.UnmarshalEnvelopes(tasks)
+		require.NoError(t, err, "Could not parse the stored tasks")
+		require.Empty(t, undecodable, "All stored tasks should be decodable")
+		// Submission times, expiry times and IDs are not deterministic.
+		for i := range envelopes {
+			envelopes[i].Submitted = time.Time{}
+			envelopes[i].Expires = time.Time{}
+			envelopes[i].ID = ""
+		}
+
+		tasks, err = task.MarshalEnvelopes(envelopes)
+		require.NoError(t, err, "Could not serialize the stored tasks")
+		out = append(out, string(tasks))
+	}
+	return out
+}
+
 func executeLandscapeConfigTemplate(t *testing.T, in string, certPath string, url string) string {
 	t.Helper()
 
@@ -1213,6 +1417,9 @@ type mockConfig struct {
 	landscapeConfigErr bool
 	landscapeUIDErr    bool
 	setLandscapeUIDErr bool
+	proxyErr           bool
+
+	rollout *rollout.Controller
 
 	mu sync.Mutex
 }
@@ -1261,3 +1468,35 @@ func (m *mockConfig) SetLandscapeAgentUID(ctx context.Context, uid string) error
 	m.landscapeAgentUID = uid
 	return nil
 }
+
+func (m *mockConfig) Proxy() (config.Proxy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.proxyErr {
+		return config.Proxy{}, errors.New("Mock error")
+	}
+	return config.Proxy{}, nil
+}
+
+func (m *mockConfig) PreviousLandscapeClientConfig() (string, error) {
+	return "", errors.New("Mock error: no previous Landscape configuration")
+}
+
+func (m *mockConfig) RollBackLandscapeConfig() error {
+	return errors.New("Mock error: no previous Landscape configuration")
+}
+
+func (m *mockConfig) RolloutPolicy() rollout.Policy {
+	return rollout.Policy{}
+}
+
+func (m *mockConfig) LandscapeRollout() *rollout.Controller {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.rollout == nil {
+		m.rollout = rollout.New(context.Background())
+	}
+	return m.rollout
+}
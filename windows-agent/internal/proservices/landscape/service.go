@@ -15,6 +15,9 @@ import (
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/rollout"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/ubuntu/decorate"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
@@ -66,6 +69,13 @@ type Config interface {
 
 	LandscapeAgentUID() (string, error)
 	SetLandscapeAgentUID(context.Context, string) error
+
+	Proxy() (config.Proxy, error)
+
+	PreviousLandscapeClientConfig() (string, error)
+	RollBackLandscapeConfig() error
+	RolloutPolicy() rollout.Policy
+	LandscapeRollout() *rollout.Controller
 }
 
 // CloudInit is a cloud-init user data writer.
@@ -447,24 +457,56 @@ func (s *Service) NotifyUbuntuProUpdate(ctx context.Context, token string) {
 	s.reconnectIfNewSettings(ctx)
 }
 
+// NotifyProxyUpdate is called when the proxy settings change. It will trigger a reconnection if needed.
+func (s *Service) NotifyProxyUpdate(ctx context.Context) {
+	s.reconnectIfNewSettings(ctx)
+}
+
 // NotifyConfigUpdate is called when the configuration changes. It will trigger a reconnection if needed.
 func (s *Service) NotifyConfigUpdate(ctx context.Context, landscapeConf, agentUID string) {
-	// We only enable Landscape if there is a UID. Otherwise we disable it (by sending an empty config).
-	if agentUID == "" {
-		landscapeConf = ""
+	landscapeConf, err := clientConfig(landscapeConf, agentUID)
+	if err != nil {
+		log.Errorf(ctx, "Landscape: could not notify config changes: %v", err)
+		return
 	}
 
-	if landscapeConf != "" {
-		var err error
-		landscapeConf, err = filterClientSection(landscapeConf)
-		if err != nil {
-			log.Errorf(ctx, "Landscape: could not notify config changes: %v", err)
-			return
-		}
+	s.distributeConfig(ctx, landscapeConf, agentUID)
+	s.reconnectIfNewSettings(ctx)
+}
+
+// distributeConfig rolls the Landscape configuration out to all distros according to the rollout policy.
+// If the rollout fails, the previous configuration is put back in effect, both in the distros and in the agent.
+func (s *Service) distributeConfig(ctx context.Context, landscapeConf, agentUID string) {
+	instances := s.db.GetAll()
+	distros := make([]rollout.Distro, 0, len(instances))
+	for _, d := range instances {
+		distros = append(distros, d)
 	}
 
-	distributeConfig(ctx, s.db, landscapeConf)
-	s.reconnectIfNewSettings(ctx)
+	s.conf.LandscapeRollout().Start(ctx, s.conf.RolloutPolicy(), distros, rollout.Change{
+		Name: "Landscape configuration",
+		Apply: func(rollout.Distro) (task.Task, error) {
+			return tasks.LandscapeConfigure{Config: landscapeConf}, nil
+		},
+		Revert: func(rollout.Distro) (task.Task, error) {
+			prev, err := s.conf.PreviousLandscapeClientConfig()
+			if err != nil {
+				return nil, err
+			}
+			prev, err = clientConfig(prev, agentUID)
+			if err != nil {
+				return nil, err
+			}
+			return tasks.LandscapeConfigure{Config: prev}, nil
+		},
+		RollBack: func() error {
+			if err := s.conf.RollBackLandscapeConfig(); err != nil {
+				return err
+			}
+			s.reconnectIfNewSettings(s.ctx)
+			return nil
+		},
+	})
 }
 
 func (s *Service) reconnectIfNewSettings(ctx context.Context) {
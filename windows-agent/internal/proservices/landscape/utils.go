@@ -13,9 +13,9 @@ import (
 
 	landscapeapi "github.com/canonical/landscape-hostagent-api"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
-	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/ubuntu/decorate"
 	"github.com/ubuntu/gowsl"
 	"google.golang.org/grpc/credentials"
@@ -30,6 +30,11 @@ type landscapeHostConf struct {
 	registrationKey string
 	hostagentURL    string
 	ubuntuProToken  string
+	proxy           config.Proxy
+
+	// labels is a comma-separated list that restricts the managed distros reported to
+	// Landscape to those carrying all of them.
+	labels string
 }
 
 type noConfigError struct {
@@ -45,7 +50,8 @@ func (e noConfigError) Is(target error) bool {
 	return ok
 }
 
-// newHostAgentInfo assembles a HostAgentInfo message.
+// newHostAgentInfo assembles a HostAgentInfo message. The hostagent API has no field for the dead letters of
+// the instances: they are queried with Controller.DeadLetters instead.
 func newHostAgentInfo(ctx context.Context, c serviceData) (info *landscapeapi.HostAgentInfo, err error) {
 	defer decorate.OnError(&err, "could not assemble HostAgentInfo message")
 
@@ -54,7 +60,7 @@ func newHostAgentInfo(ctx context.Context, c serviceData) (info *landscapeapi.Ho
 		return info, err
 	}
 
-	distros := c.database().GetAll()
+	distros := reportedDistros(c, conf)
 	var instances []*landscapeapi.HostAgentInfo_InstanceInfo
 	for _, d := range distros {
 		instanceInfo, err := newInstanceInfo(d)
@@ -120,6 +126,13 @@ func newHostAgentInfo(ctx context.Context, c serviceData) (info *landscapeapi.Ho
 	return info, nil
 }
 
+// reportedDistros returns the managed distros reported to Landscape, which are those carrying all the labels
+// of the configuration.
+func reportedDistros(c serviceData, conf landscapeHostConf) []*distro.Distro {
+	// Without labels, the query selects all distros.
+	return c.database().Query(database.Query{Labels: strings.Split(conf.labels, ",")})
+}
+
 type transportCredentialsType struct{}
 
 // InsecureCredentials is the key used in tests for insecure credentials.
@@ -230,6 +243,15 @@ func newLandscapeHostConf(config Config) (conf landscapeHostConf, err error) {
 	}
 	conf.hostagentURL = urlKey.String()
 
+	if k, err := sec.GetKey("labels"); err == nil {
+		conf.labels = k.String()
+	}
+
+	conf.proxy, err = config.Proxy()
+	if err != nil {
+		return conf, err
+	}
+
 	return conf, nil
 }
 
@@ -316,18 +338,14 @@ func tryDistroState(d *distro.Distro, interval, timeout time.Duration) (state go
 	return state, nil
 }
 
-func distributeConfig(ctx context.Context, db *database.DistroDB, landscapeConf string) {
-	var err error
-	for _, distro := range db.GetAll() {
-		t := tasks.LandscapeConfigure{
-			Config: landscapeConf,
-		}
-		err = errors.Join(err, distro.SubmitTasks(t))
+// clientConfig returns the Landscape configuration to send to the distros. Landscape is only enabled if there
+// is an agent UID. Otherwise, it is disabled by sending an empty config.
+func clientConfig(landscapeConf, agentUID string) (string, error) {
+	if agentUID == "" || landscapeConf == "" {
+		return "", nil
 	}
 
-	if err != nil {
-		log.Warningf(ctx, "Landscape: could not submit configuration tasks: %v", err)
-	}
+	return filterClientSection(landscapeConf)
 }
 
 // filterClientSection removes all sections from the Landscape configuration except the [client] section.
@@ -3,6 +3,7 @@ package proservices
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	agent_api "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
@@ -14,8 +15,10 @@ import (
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/adoption"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/landscape"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/registrywatcher"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/schedules"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/ui"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/wslinstance"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
@@ -33,6 +36,8 @@ type Manager struct {
 	wslInstanceService *wslinstance.Service
 	landscapeService   *landscape.Service
 	registryWatcher    *registrywatcher.Service
+	adoptionService    *adoption.Service
+	schedulesService   *schedules.Service
 	db                 *database.DistroDB
 
 	creds credentials.TransportCredentials
@@ -40,7 +45,9 @@ type Manager struct {
 
 // options are the configurable functional options for the daemon.
 type options struct {
-	registry registrywatcher.Registry
+	registry            registrywatcher.Registry
+	maxConcurrentStarts int
+	fileData            config.FileData
 }
 
 // Option is the function signature we are passing to tweak the daemon creation.
@@ -53,6 +60,22 @@ func WithRegistry(registry registrywatcher.Registry) func(o *options) {
 	}
 }
 
+// WithMaxConcurrentStarts sets the number of distros allowed to start at the same time.
+// Values lower than 1 leave the default.
+func WithMaxConcurrentStarts(n int) func(o *options) {
+	return func(o *options) {
+		o.maxConcurrentStarts = n
+	}
+}
+
+// WithConfigFileData sets the settings read from the configuration file of the agent.
+// The registry takes precedence over them.
+func WithConfigFileData(data config.FileData) func(o *options) {
+	return func(o *options) {
+		o.fileData = data
+	}
+}
+
 // New returns a new GRPC services manager.
 // It instantiates both ui and wsl instance services.
 //
@@ -80,6 +103,7 @@ func New(ctx context.Context, publicDir, privateDir string, args ...Option) (s M
 	InitWSLAPI()
 
 	conf := config.New(ctx, privateDir)
+	conf.SetFileData(opts.fileData)
 
 	cloudInit, err := cloudinit.New(ctx, conf, publicDir)
 	if err != nil {
@@ -99,6 +123,19 @@ func New(ctx context.Context, publicDir, privateDir string, args ...Option) (s M
 		return s, err
 	}
 	s.db = db
+	db.SetMaxConcurrentStarts(opts.maxConcurrentStarts)
+
+	// Renamed distros keep their cloud-init data.
+	db.SetOnRename(func(oldName, newName string) {
+		if err := cloudInit.RenameDistroData(oldName, newName); err != nil {
+			log.Warningf(ctx, "Could not move distro data of renamed distro: %v", err)
+		}
+	})
+
+	// New distros are stored along with their initial tasks, so that they cannot be added without them.
+	db.SetNewDistroTasks(func(d *distro.Distro) ([]task.Task, error) {
+		return newInstanceTasks(conf, d.Name(), d.Labels(), d.Properties())
+	})
 
 	w := registrywatcher.New(ctx, conf, s.db, registrywatcher.WithRegistry(opts.registry))
 	s.registryWatcher = &w
@@ -119,25 +156,39 @@ func New(ctx context.Context, publicDir, privateDir string, args ...Option) (s M
 			log.Warningf(ctx, "Failed to deliver initial tasks for new instance %q: %v", props.DistroID, err)
 		})
 		// When a new instance connects to the wslinstance service we'll greet it with some tasks.
-		dtasks, e := newInstanceTasks(conf, props)
-		if e != nil || len(dtasks) == 0 {
+		dtasks, e := newInstanceTasks(conf, d.Name(), d.Labels(), props)
+		if e != nil {
 			return
 		}
+		// The package inventory is refreshed on every connection, as packages may have changed since the last one.
+		dtasks = append(dtasks, tasks.PackageInventory{})
 		e = d.SubmitDeferredTasks(dtasks...)
 	}
 
 	s.wslInstanceService = wslinstance.New(ctx, s.db, onNewInstance, s.landscapeService.Controller())
+	// Tokens are rolled out to the distros in stages, which a new token cancels.
 	conf.SetUbuntuProNotifier(func(ctx context.Context, token string) {
-		ubuntupro.Distribute(ctx, s.db, token)
+		ubuntupro.Distribute(ctx, s.db, conf, conf.SubscriptionRollout())
+		distributeProServices(ctx, s.db, conf)
 		landscape.NotifyUbuntuProUpdate(ctx, token)
 		cloudInit.Update(ctx)
 	})
 
+	conf.SetProServicesNotifier(func(ctx context.Context) {
+		distributeProServices(ctx, s.db, conf)
+	})
+
 	conf.SetLandscapeNotifier(func(ctx context.Context, conf, uid string) {
 		landscape.NotifyConfigUpdate(ctx, conf, uid)
 		cloudInit.Update(ctx)
 	})
 
+	conf.SetProxyNotifier(func(ctx context.Context, proxy config.Proxy) {
+		distributeProxy(ctx, s.db, proxy)
+		landscape.NotifyProxyUpdate(ctx)
+		cloudInit.Update(ctx)
+	})
+
 	// All notifications have been set up: starting the registry watcher before any services.
 	s.registryWatcher.Start()
 
@@ -153,34 +204,117 @@ func New(ctx context.Context, publicDir, privateDir string, args ...Option) (s M
 	if err != nil {
 		return s, fmt.Errorf("failed to create certificates: %s", err)
 	}
-
 	s.creds = credentials.NewTLS(tlsConfig)
+
+	// The services acting on the distros in the background start last, once nothing else can fail.
+	s.adoptionService = adoption.New(ctx, conf, s.db, privateDir)
+	s.adoptionService.Start()
+
+	s.schedulesService = schedules.New(ctx, conf, s.db, privateDir)
+	s.schedulesService.Start()
+
 	return s, nil
 }
 
 // newInstanceTasks returns the initial tasks to be executed when a new instance connects to the WSLInstance service.
-func newInstanceTasks(conf *config.Config, p distro.Properties) (t []task.Task, err error) {
+func newInstanceTasks(conf *config.Config, name string, labels []string, p distro.Properties) (t []task.Task, err error) {
 	defer decorate.OnError(&err, "when new instance %q connected to WSLInstance service", p.DistroID)
 
-	pro, source, err := conf.Subscription()
+	// The proxy goes first, so that the following tasks can reach the outside world.
+	proxy, err := conf.Proxy()
+	if err != nil {
+		return nil, err
+	}
+	if !proxy.IsZero() {
+		t = append(t, tasks.ProxyConfig{HTTPProxy: proxy.HTTP, HTTPSProxy: proxy.HTTPS, NoProxy: proxy.NoProxy})
+	}
+
+	pro, err := conf.ContractFor(name, labels...)
 	if err != nil {
 		return nil, err
 	}
 	// There is a Pro subscription but the instance is not attached.
-	if pro != "" && source != config.SourceNone && !p.ProAttached {
-		t = append(t, tasks.ProAttachment{Token: pro})
+	if pro.Token != "" && pro.Source != config.SourceNone && !p.ProAttached {
+		t = append(t, tasks.ProAttachment{Token: pro.Token})
 	}
-	l, source, err := conf.LandscapeClientConfig()
+
+	// Services can only be enabled in attached instances.
+	if pro.Token != "" {
+		services, err := conf.ProServicesFor(name, labels...)
+		if err != nil {
+			return nil, err
+		}
+		if !services.IsZero() {
+			t = append(t, tasks.ProServices{Enable: services.Enable, Disable: services.Disable})
+		}
+	}
+
+	l, source, err := conf.LandscapeClientConfigFor(name)
 	if err == nil && l != "" && source != config.SourceNone {
 		t = append(t, tasks.LandscapeConfigure{Config: l})
 	}
 	return t, err
 }
 
+// distributeProxy submits the proxy settings to all distros. Empty settings remove the proxy.
+func distributeProxy(ctx context.Context, db *database.DistroDB, proxy config.Proxy) {
+	var err error
+	instances := db.GetAll()
+	log.Debugf(ctx, "Distributing proxy settings to %d distros", len(instances))
+	for _, d := range instances {
+		err = errors.Join(err, d.SubmitTasks(tasks.ProxyConfig{HTTPProxy: proxy.HTTP, HTTPSProxy: proxy.HTTPS, NoProxy: proxy.NoProxy}))
+	}
+
+	if err != nil {
+		log.Warningf(ctx, "could not submit proxy settings to all distros: %v", err)
+	}
+}
+
+// distributeProServices submits to all distros with an Ubuntu Pro token the services that must be
+// enabled and disabled in each of them.
+func distributeProServices(ctx context.Context, db *database.DistroDB, conf *config.Config) {
+	var err error
+	instances := db.GetAll()
+	log.Debugf(ctx, "Distributing Ubuntu Pro services to %d distros", len(instances))
+	for _, d := range instances {
+		contract, e := conf.ContractFor(d.Name(), d.Labels()...)
+		if e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+		if contract.Token == "" {
+			continue
+		}
+
+		services, e := conf.ProServicesFor(d.Name(), d.Labels()...)
+		if e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+		if services.IsZero() {
+			continue
+		}
+
+		err = errors.Join(err, d.SubmitTasks(tasks.ProServices{Enable: services.Enable, Disable: services.Disable}))
+	}
+
+	if err != nil {
+		log.Warningf(ctx, "could not submit Ubuntu Pro services to all distros: %v", err)
+	}
+}
+
 // Stop deallocates resources in the services.
 func (m Manager) Stop(ctx context.Context) {
 	log.Info(ctx, "Stopping GRPC services manager")
 
+	if m.adoptionService != nil {
+		m.adoptionService.Stop()
+	}
+
+	if m.schedulesService != nil {
+		m.schedulesService.Stop()
+	}
+
 	if m.landscapeService != nil {
 		m.landscapeService.Stop(ctx)
 	}
@@ -241,7 +241,7 @@ func TestOnNewInstanceCreatesTask(t *testing.T) {
 			const wantToken = "test-pro-token"
 			if tc.breakConfig {
 				path := filepath.Join(privateDir, "config")
-				require.NoError(t, os.Remove(path), "Setup: could not remove the config file")
+				require.NoError(t, os.RemoveAll(path), "Setup: could not remove the config file")
 				require.NoError(t, os.Mkdir(path, 0640), "Setup: could not break the config file")
 			} else {
 				err = reg.WriteValue(k, "UbuntuProToken", wantToken, false)
@@ -280,7 +280,7 @@ func TestOnNewInstanceCreatesTask(t *testing.T) {
 
 			wslClient := agentapi.NewWSLInstanceClient(conn)
 
-			// Open all three streams that the server requires before making the connection "ready",
+			// Open all the streams that the server requires before making the connection "ready",
 			// effectively mocking the wsl-pro-service unit inside an instance.
 			connStream, err := wslClient.Connected(ctx)
 			require.NoError(t, err, "Setup: could not open Connected stream")
@@ -291,8 +291,26 @@ func TestOnNewInstanceCreatesTask(t *testing.T) {
 			lpeStream, err := wslClient.LandscapeConfigCommands(ctx)
 			require.NoError(t, err, "Setup: could not open LandscapeConfigCommands stream")
 
+			proxyStream, err := wslClient.ProxyConfigCommands(ctx)
+			require.NoError(t, err, "Setup: could not open ProxyConfigCommands stream")
+
+			runStream, err := wslClient.RunCommandCommands(ctx)
+			require.NoError(t, err, "Setup: could not open RunCommandCommands stream")
+
+			svcStream, err := wslClient.ProServicesCommands(ctx)
+			require.NoError(t, err, "Setup: could not open ProServicesCommands stream")
+
+			secStream, err := wslClient.SecurityUpdateCommands(ctx)
+			require.NoError(t, err, "Setup: could not open SecurityUpdateCommands stream")
+
+			invStream, err := wslClient.PackageInventoryCommands(ctx)
+			require.NoError(t, err, "Setup: could not open PackageInventoryCommands stream")
+
+			cancelStream, err := wslClient.CancelCommands(ctx)
+			require.NoError(t, err, "Setup: could not open CancelCommands stream")
+
 			// Perform the handshakes. Order must match what the wslinstance server expects: first send
-			// DistroInfo on the Connected stream, then send the WSL name on the two command streams.
+			// DistroInfo on the Connected stream, then send the WSL name on the command streams.
 			err = connStream.Send(&agentapi.DistroInfo{WslName: distroName, ProAttached: tc.alreadyAttached})
 			require.NoError(t, err, "Setup: could not send DistroInfo on Connected stream")
 
@@ -303,6 +321,12 @@ func TestOnNewInstanceCreatesTask(t *testing.T) {
 			}
 			sendWslNameMsg(proStream.Send)
 			sendWslNameMsg(lpeStream.Send)
+			sendWslNameMsg(proxyStream.Send)
+			sendWslNameMsg(runStream.Send)
+			sendWslNameMsg(svcStream.Send)
+			sendWslNameMsg(secStream.Send)
+			sendWslNameMsg(invStream.Send)
+			sendWslNameMsg(cancelStream.Send)
 
 			// The server sends a ProAttachCmd once all streams are ready and onNewInstance runs.
 			// Receive it with a generous timeout to avoid flakiness.
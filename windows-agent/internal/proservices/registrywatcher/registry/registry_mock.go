@@ -20,6 +20,7 @@ type Mock struct {
 	// registry contains the registry key database.
 	ubuntuPro key
 	ubuntu    key
+	lxss      key
 	keyExists bool
 
 	// keyHandles contains the handles to the keys. The Win32API returns void pointers to the
@@ -147,6 +148,12 @@ func NewMock() *Mock {
 			intData: make(map[string]uint64),
 			events:  make([]Event, 0),
 		},
+		lxss: key{
+			mu:      &sync.RWMutex{},
+			data:    make(map[string]string),
+			intData: make(map[string]uint64),
+			events:  make([]Event, 0),
+		},
 	}
 
 	m.keyHandles.data = make(map[Key]*keyHandle)
@@ -163,6 +170,12 @@ func (r *Mock) UbuntuProKeyExists() bool {
 	return r.keyExists
 }
 
+// NotifyLxss triggers the events watching the key where WSL registers the distros, as if a distro
+// had been registered, unregistered or renamed.
+func (r *Mock) NotifyLxss() {
+	r.notify(&r.lxss)
+}
+
 // RequireNoLeaks is a test helper to ensure we freed all allocations.
 func (r *Mock) RequireNoLeaks(t *testing.T) {
 	t.Helper()
@@ -210,6 +223,11 @@ var validUbuntuPaths = []string{
 	`Software/Canonical/Ubuntu`,
 }
 
+var validLxssPaths = []string{
+	`Software\Microsoft\Windows\CurrentVersion\Lxss`,
+	`Software/Microsoft/Windows/CurrentVersion/Lxss`,
+}
+
 func (r *Mock) getKey(path string) *key {
 	path = filepath.Clean(path)
 	if slices.Contains(validProPaths, path) {
@@ -218,6 +236,9 @@ func (r *Mock) getKey(path string) *key {
 	if slices.Contains(validUbuntuPaths, path) {
 		return &r.ubuntu
 	}
+	if slices.Contains(validLxssPaths, path) {
+		return &r.lxss
+	}
 	panic(fmt.Sprintf("Attempting to access key outside of UbuntuPro: %s", path))
 }
 
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
@@ -18,7 +19,7 @@ import (
 // Software/Canonical/UbuntuPro.
 //
 // If a change is detected, the new contents of the registry key are pushed to the
-// config.
+// config. It also notifies the database when WSL registers, unregisters or renames distros.
 type Service struct {
 	ctx  context.Context
 	stop func()
@@ -40,6 +41,13 @@ const registryParentPath = `Software\`
 // registryPath is the path to the registry key that Ubuntu for WSL uses in general.
 const registryTelemetryPath = `Software\Canonical\Ubuntu`
 
+// lxssPath is the path to the registry key where WSL registers the distros.
+const lxssPath = `Software\Microsoft\Windows\CurrentVersion\Lxss`
+
+// lxssParentPath is the path to the first parent of lxssPath that we can guarantee exists.
+// We watch this key if WSL was never used, so that lxssPath does not exist yet.
+const lxssParentPath = `Software\Microsoft\Windows\CurrentVersion`
+
 // Registry is an interface to the Windows registry.
 type Registry interface {
 	HKCUOpenKey(path string) (registry.Key, error)
@@ -122,14 +130,27 @@ func (s *Service) Stop() {
 // run is the blocking registry watcher.
 func (s *Service) run() {
 	defer close(s.running)
+
+	log.Info(s.ctx, "Registry watcher: started watching")
+	defer log.Info(s.ctx, "Registry watcher: stopped watching")
+
+	var wg sync.WaitGroup
+	wg.Go(func() { s.watch(s.readThenPushRegistryData, registryPath, registryParentPath) })
+	wg.Go(func() { s.watch(s.notifyRegistrationsChanged, lxssPath, lxssParentPath) })
+	wg.Wait()
+}
+
+// watch calls onChange every time the key at the first of the paths that exists, or one of its children,
+// changes. It blocks until the service is stopped.
+func (s *Service) watch(onChange func(context.Context), paths ...string) {
 	/*
-		When we detect a change we don't immediately read the registry and push
-		the new data. Instead, we wait until we're watching again. This way we
-		avoid silent changes in between ending and starting successive watches.
+		When we detect a change we don't immediately call onChange. Instead, we
+		wait until we're watching again. This way we avoid silent changes in
+		between ending and starting successive watches.
 
-		In the case we fail to watch, we still push changes just in case. False
+		In the case we fail to watch, we still call onChange just in case. False
 		positives don't matter much because the config will ignore data that are
-		not new.
+		not new, and the database ignores notifications when no distro changed.
 	*/
 
 	// These rates are NOT how often we look at the registry. Registry updates are
@@ -142,9 +163,6 @@ func (s *Service) run() {
 	)
 	retryRate := minRate
 
-	log.Info(s.ctx, "Registry watcher: started watching")
-	defer log.Info(s.ctx, "Registry watcher: stopped watching")
-
 	for {
 		select {
 		case <-s.ctx.Done():
@@ -157,14 +175,17 @@ func (s *Service) run() {
 			ctx, cancel := context.WithCancel(s.ctx)
 			defer cancel()
 
-			path := registryPath
-			k, err := s.registry.HKCUOpenKey(path)
-			if errors.Is(err, registry.ErrKeyNotExist) {
-				// Watch the Software/ key instead, which we're almost guaranteed exists
-				path = registryParentPath
-				k, err = s.registry.HKCUOpenKey(path)
+			var path string
+			var k registry.Key
+			var err error
+			for _, path = range paths {
+				// Watch the parent keys instead if the key does not exist.
 				// ^This is not covered in tests because it significantly
 				// complicates the mock registry.
+				k, err = s.registry.HKCUOpenKey(path)
+				if !errors.Is(err, registry.ErrKeyNotExist) {
+					break
+				}
 			}
 			if err != nil {
 				return fmt.Errorf(`could not open registry key HKCU\%s: %v`, path, err)
@@ -181,7 +202,7 @@ func (s *Service) run() {
 			log.Debugf(ctx, `Registry watcher: watching key HKCU\%s`, path)
 
 			// Push update right after having started to watch
-			s.readThenPushRegistryData(ctx)
+			onChange(ctx)
 
 			// Wait until the key is modified or the context is cancelled, whichever one happens first
 			if err := s.waitForSingleObject(ctx, event); err != nil {
@@ -194,7 +215,7 @@ func (s *Service) run() {
 
 		if err != nil {
 			log.Warningf(s.ctx, "Registry watcher: %v", err)
-			s.readThenPushRegistryData(s.ctx)
+			onChange(s.ctx)
 
 			select {
 			case <-s.ctx.Done():
@@ -246,10 +267,36 @@ func (s *Service) readThenPushRegistryData(ctx context.Context) {
 	}
 }
 
+// notifyRegistrationsChanged tells the database that distros may have been registered, unregistered or renamed.
+func (s *Service) notifyRegistrationsChanged(context.Context) {
+	s.db.RegistrationsChanged()
+}
+
 // #nosec G101 // These are not credentials
 const (
-	ubuntuProTokenField  = "UbuntuProToken"
-	landscapeConfigField = "LandscapeConfig"
+	ubuntuProTokenField    = "UbuntuProToken"
+	ubuntuProTokenMapField = "UbuntuProTokenMap"
+	landscapeConfigField   = "LandscapeConfig"
+
+	ubuntuProServicesField    = "UbuntuProServices"
+	ubuntuProServicesMapField = "UbuntuProServicesMap"
+
+	httpProxyField  = "HTTPProxy"
+	httpsProxyField = "HTTPSProxy"
+	noProxyField    = "NoProxy"
+
+	adoptUnmanagedField = "AdoptUnmanagedInstances"
+
+	maintenanceWindowsField = "MaintenanceWindows"
+	maxAwakeDistrosField    = "MaxAwakeDistros"
+	neverWakeDistrosField   = "NeverWakeDistros"
+
+	rolloutCanariesField         = "RolloutCanaries"
+	rolloutBatchSizeField        = "RolloutBatchSize"
+	rolloutSuccessThresholdField = "RolloutSuccessThreshold"
+	rolloutMaxFailuresField      = "RolloutMaxFailures"
+
+	scheduledTasksField = "ScheduledTasks"
 
 	telemetryConsentField = "UbuntuInsightsConsent"
 )
@@ -272,14 +319,106 @@ func loadRegistry(reg Registry) (data config.RegistryData, err error) {
 		return data, err
 	}
 
+	tokenMap, err := readFromRegistry(reg, k, ubuntuProTokenMapField)
+	if err != nil {
+		return data, err
+	}
+
+	services, err := readFromRegistry(reg, k, ubuntuProServicesField)
+	if err != nil {
+		return data, err
+	}
+
+	servicesMap, err := readFromRegistry(reg, k, ubuntuProServicesMapField)
+	if err != nil {
+		return data, err
+	}
+
 	conf, err := readFromRegistry(reg, k, landscapeConfigField)
 	if err != nil {
 		return data, err
 	}
 
+	httpProxy, err := readFromRegistry(reg, k, httpProxyField)
+	if err != nil {
+		return data, err
+	}
+
+	httpsProxy, err := readFromRegistry(reg, k, httpsProxyField)
+	if err != nil {
+		return data, err
+	}
+
+	noProxy, err := readFromRegistry(reg, k, noProxyField)
+	if err != nil {
+		return data, err
+	}
+
+	adopt, err := readDWordFromRegistry(reg, k, adoptUnmanagedField)
+	if err != nil {
+		return data, err
+	}
+
+	windows, err := readFromRegistry(reg, k, maintenanceWindowsField)
+	if err != nil {
+		return data, err
+	}
+
+	maxAwake, err := readDWordFromRegistry(reg, k, maxAwakeDistrosField)
+	if err != nil {
+		return data, err
+	}
+
+	neverWake, err := readDWordFromRegistry(reg, k, neverWakeDistrosField)
+	if err != nil {
+		return data, err
+	}
+
+	canaries, err := readDWordFromRegistry(reg, k, rolloutCanariesField)
+	if err != nil {
+		return data, err
+	}
+
+	batchSize, err := readDWordFromRegistry(reg, k, rolloutBatchSizeField)
+	if err != nil {
+		return data, err
+	}
+
+	successThreshold, err := readDWordFromRegistry(reg, k, rolloutSuccessThresholdField)
+	if err != nil {
+		return data, err
+	}
+
+	maxFailures, err := readDWordFromRegistry(reg, k, rolloutMaxFailuresField)
+	if err != nil {
+		return data, err
+	}
+
+	schedules, err := readFromRegistry(reg, k, scheduledTasksField)
+	if err != nil {
+		return data, err
+	}
+
 	return config.RegistryData{
-		UbuntuProToken:  proToken,
-		LandscapeConfig: conf,
+		UbuntuProToken:       proToken,
+		UbuntuProTokenMap:    tokenMap,
+		UbuntuProServices:    services,
+		UbuntuProServicesMap: servicesMap,
+		LandscapeConfig:      conf,
+		HTTPProxy:            httpProxy,
+		HTTPSProxy:           httpsProxy,
+		NoProxy:              noProxy,
+		AdoptUnmanaged:       adopt == 1,
+		MaintenanceWindows:   windows,
+		MaxAwakeDistros:      int(maxAwake),
+		NeverWakeDistros:     neverWake == 1,
+
+		RolloutCanaries:         int(canaries),
+		RolloutBatchSize:        int(batchSize),
+		RolloutSuccessThreshold: int(successThreshold),
+		RolloutMaxFailures:      int(maxFailures),
+
+		ScheduledTasks: schedules,
 	}, nil
 }
 
@@ -306,7 +445,11 @@ func setDefaultRegistry(r Registry) (err error) {
 
 	err = errors.Join(err,
 		createIfNotExist(r, k, ubuntuProTokenField, false),
+		createIfNotExist(r, k, ubuntuProTokenMapField, true),
 		createIfNotExist(r, k, landscapeConfigField, true),
+		createIfNotExist(r, k, httpProxyField, false),
+		createIfNotExist(r, k, httpsProxyField, false),
+		createIfNotExist(r, k, noProxyField, false),
 		setDefaultTelemetryConsent(r),
 	)
 
@@ -358,3 +501,15 @@ func setDefaultTelemetryConsent(r Registry) (err error) {
 
 	return nil
 }
+
+func readDWordFromRegistry(r Registry, key registry.Key, field string) (uint64, error) {
+	value, err := r.ReadDWordValue(key, field)
+	if errors.Is(err, registry.ErrFieldNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not read field %q", field)
+	}
+
+	return value, nil
+}
@@ -3,10 +3,15 @@ package registrywatcher_test
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/registrywatcher"
@@ -21,10 +26,16 @@ func TestRegistryWatcher(t *testing.T) {
 
 	const (
 		defaultProToken        = "DefaultProToken"
+		defaultProTokenMap     = "Customer*=CustomerProToken"
 		defaultLandscapeConfig = "DefaultLandscapeConfig"
+		defaultProServices     = "esm-apps,-usg"
+		defaultProServicesMap  = "Customer*=fips"
+		defaultHTTPSProxy      = "http://proxy.example.com:3128"
+		defaultNoProxy         = "localhost,.example.com"
 
 		newProToken        = "NewProToken"
 		newLandscapeConfig = "NewLandscapeConfig"
+		newHTTPSProxy      = "http://new-proxy.example.com:3128"
 	)
 
 	const maxUpdateTime = 5 * time.Second
@@ -66,10 +77,25 @@ func TestRegistryWatcher(t *testing.T) {
 			reg := registry.NewMock()
 			defer reg.RequireNoLeaks(t)
 
-			var startingProToken, startingLandscapeConfig string
+			var startingProToken, startingProTokenMap, startingLandscapeConfig, startingHTTPSProxy, startingNoProxy string
+			var startingProServices, startingProServicesMap string
+			var startingAdoptUnmanaged, startingNeverWake bool
+			var startingMaintenanceWindows, startingScheduledTasks string
+			var startingMaxAwake, startingCanaries int
 			if !tc.startEmptyRegistry {
 				startingProToken = defaultProToken
+				startingProTokenMap = defaultProTokenMap
 				startingLandscapeConfig = defaultLandscapeConfig
+				startingProServices = defaultProServices
+				startingProServicesMap = defaultProServicesMap
+				startingHTTPSProxy = defaultHTTPSProxy
+				startingNoProxy = defaultNoProxy
+				startingAdoptUnmanaged = true
+				startingMaintenanceWindows = "22:00-06:00"
+				startingMaxAwake = 2
+				startingNeverWake = true
+				startingCanaries = 1
+				startingScheduledTasks = "*=daily@02:00 security-update"
 
 				func() {
 					k, err := reg.HKCUCreateKey("Software/Canonical/UbuntuPro")
@@ -79,8 +105,41 @@ func TestRegistryWatcher(t *testing.T) {
 					err = reg.WriteValue(k, "UbuntuProToken", startingProToken, false)
 					require.NoError(t, err, "Setup: could not write UbuntuProToken into the registry")
 
+					err = reg.WriteValue(k, "UbuntuProTokenMap", startingProTokenMap, true)
+					require.NoError(t, err, "Setup: could not write UbuntuProTokenMap into the registry")
+
+					err = reg.WriteValue(k, "UbuntuProServices", startingProServices, false)
+					require.NoError(t, err, "Setup: could not write UbuntuProServices into the registry")
+
+					err = reg.WriteValue(k, "UbuntuProServicesMap", startingProServicesMap, true)
+					require.NoError(t, err, "Setup: could not write UbuntuProServicesMap into the registry")
+
 					err = reg.WriteValue(k, "LandscapeConfig", startingLandscapeConfig, true)
 					require.NoError(t, err, "Setup: could not write LandscapeConfig into the registry")
+
+					err = reg.WriteValue(k, "HTTPSProxy", startingHTTPSProxy, false)
+					require.NoError(t, err, "Setup: could not write HTTPSProxy into the registry")
+
+					err = reg.WriteValue(k, "NoProxy", startingNoProxy, false)
+					require.NoError(t, err, "Setup: could not write NoProxy into the registry")
+
+					err = reg.SetDWordValue(k, "AdoptUnmanagedInstances", 1)
+					require.NoError(t, err, "Setup: could not write AdoptUnmanagedInstances into the registry")
+
+					err = reg.WriteValue(k, "MaintenanceWindows", startingMaintenanceWindows, false)
+					require.NoError(t, err, "Setup: could not write MaintenanceWindows into the registry")
+
+					err = reg.SetDWordValue(k, "MaxAwakeDistros", uint32(startingMaxAwake))
+					require.NoError(t, err, "Setup: could not write MaxAwakeDistros into the registry")
+
+					err = reg.SetDWordValue(k, "NeverWakeDistros", 1)
+					require.NoError(t, err, "Setup: could not write NeverWakeDistros into the registry")
+
+					err = reg.SetDWordValue(k, "RolloutCanaries", uint32(startingCanaries))
+					require.NoError(t, err, "Setup: could not write RolloutCanaries into the registry")
+
+					err = reg.WriteValue(k, "ScheduledTasks", startingScheduledTasks, true)
+					require.NoError(t, err, "Setup: could not write ScheduledTasks into the registry")
 				}()
 			}
 
@@ -124,7 +183,19 @@ func TestRegistryWatcher(t *testing.T) {
 				wantMsgLen++
 				require.Equal(t, wantMsgLen, conf.ReceivedLen(), "Registry watcher should have updated the config")
 				require.Equal(t, startingProToken, conf.LatestReceived().UbuntuProToken, "Ubuntu Pro token config should have contained the registry value")
+				require.Equal(t, startingProTokenMap, conf.LatestReceived().UbuntuProTokenMap, "Ubuntu Pro token map config should have contained the registry value")
+				require.Equal(t, startingProServices, conf.LatestReceived().UbuntuProServices, "Ubuntu Pro services should have contained the registry value")
+				require.Equal(t, startingProServicesMap, conf.LatestReceived().UbuntuProServicesMap, "Ubuntu Pro services map should have contained the registry value")
 				require.Equal(t, startingLandscapeConfig, conf.LatestReceived().LandscapeConfig, "Landscape config should have contained the registry value")
+				require.Empty(t, conf.LatestReceived().HTTPProxy, "HTTP proxy should have contained the registry value")
+				require.Equal(t, startingHTTPSProxy, conf.LatestReceived().HTTPSProxy, "HTTPS proxy should have contained the registry value")
+				require.Equal(t, startingNoProxy, conf.LatestReceived().NoProxy, "No proxy list should have contained the registry value")
+				require.Equal(t, startingAdoptUnmanaged, conf.LatestReceived().AdoptUnmanaged, "Adoption policy should have contained the registry value")
+				require.Equal(t, startingMaintenanceWindows, conf.LatestReceived().MaintenanceWindows, "Maintenance windows should have contained the registry value")
+				require.Equal(t, startingMaxAwake, conf.LatestReceived().MaxAwakeDistros, "Maximum number of awake distros should have contained the registry value")
+				require.Equal(t, startingNeverWake, conf.LatestReceived().NeverWakeDistros, "Never wake policy should have contained the registry value")
+				require.Equal(t, startingCanaries, conf.LatestReceived().RolloutCanaries, "Number of rollout canaries should have contained the registry value")
+				require.Equal(t, startingScheduledTasks, conf.LatestReceived().ScheduledTasks, "Scheduled tasks should have contained the registry value")
 			}
 
 			// The watcher makes a redundant config push when it starts watching, except if readValue was broken.
@@ -165,10 +236,78 @@ func TestRegistryWatcher(t *testing.T) {
 				maxUpdateTime, 100*time.Millisecond, "Registry watcher should have updated the config after changing the registry")
 			require.Equal(t, newProToken, conf.LatestReceived().UbuntuProToken, "Ubuntu Pro token config should have contained the new registry value")
 			require.Equal(t, newLandscapeConfig, conf.LatestReceived().LandscapeConfig, "Landscape config should have contained the new registry value")
+
+			wantMsgLen = conf.ReceivedLen() + 1
+			err = reg.WriteValue(k, "HTTPSProxy", newHTTPSProxy, false)
+			require.NoError(t, err, "Setup: could not write HTTPSProxy into the registry")
+
+			require.Eventually(t, func() bool { return conf.ReceivedLen() >= wantMsgLen },
+				maxUpdateTime, 100*time.Millisecond, "Registry watcher should have updated the config after changing the registry")
+			require.Equal(t, newHTTPSProxy, conf.LatestReceived().HTTPSProxy, "HTTPS proxy should have contained the new registry value")
+			require.Equal(t, startingNoProxy, conf.LatestReceived().NoProxy, "No proxy list should have contained the registry value")
+
+			err = reg.SetDWordValue(k, "AdoptUnmanagedInstances", 2)
+			require.NoError(t, err, "Setup: could not write AdoptUnmanagedInstances into the registry")
+
+			err = reg.SetDWordValue(k, "NeverWakeDistros", 2)
+			require.NoError(t, err, "Setup: could not write NeverWakeDistros into the registry")
+
+			// When watching is broken, the retry delay has grown past maxUpdateTime by now.
+			policiesDisabled := func() bool {
+				got := conf.LatestReceived()
+				return got.HTTPSProxy == newHTTPSProxy && !got.AdoptUnmanaged && !got.NeverWakeDistros
+			}
+			require.Eventually(t, policiesDisabled, time.Minute, 100*time.Millisecond, "Adoption and never wake policies should only be enabled by the value 1")
 		})
 	}
 }
 
+func TestDistroRegistrations(t *testing.T) {
+	if !wsl.MockAvailable() {
+		t.Skip("This test can only run with the mock")
+	}
+	t.Parallel()
+
+	uncRoot := t.TempDir()
+	ctx := database.WithUNCRootPath(wsl.WithMock(context.Background(), wslmock.New()), uncRoot)
+
+	db, err := database.New(ctx, t.TempDir())
+	require.NoError(t, err, "Setup: could not create empty DB")
+	defer db.Close(ctx)
+	require.Empty(t, db.GetUnmanagedDistros(), "Setup: there should be no unmanaged distros yet")
+
+	reg := registry.NewMock()
+	defer reg.RequireNoLeaks(t)
+
+	w := registrywatcher.New(ctx, &mockConfig{}, db, registrywatcher.WithRegistry(reg))
+	w.Start()
+	defer w.Stop()
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	// Stopped distros are only listed once they were seen running, as they are not known to be Ubuntu before.
+	testutils.WriteOsRelease(t, uncRoot, strings.ToLower(distroName), "ubuntu-os-release")
+	etc := filepath.Join(uncRoot, strings.ToLower(distroName), "etc")
+	require.NoError(t, os.MkdirAll(etc, 0750), "Setup: could not create the etc directory of the distro")
+	require.NoError(t, os.WriteFile(filepath.Join(etc, "hostname"), []byte("cool-host\n"), 0600), "Setup: could not write the hostname file")
+
+	runCtx, stop := context.WithCancel(ctx)
+	d := wsl.NewDistro(runCtx, distroName)
+	cmd := d.Command(runCtx, "sleep infinity")
+	require.NoError(t, cmd.Start(), "Setup: could not start a process in the distro")
+	defer func() {
+		stop()
+		_ = cmd.Wait()
+	}()
+
+	// The watcher may not be watching the key yet, so the notification is repeated.
+	require.Eventually(t, func() bool {
+		reg.NotifyLxss()
+		return len(db.GetUnmanagedDistros()) == 1
+	}, 5*time.Second, 100*time.Millisecond, "The database should have been notified of the new distro")
+	require.True(t, strings.EqualFold(distroName, db.GetUnmanagedDistros()[0].Name), "Unexpected unmanaged distro")
+}
+
 func TestDefaultTelemetryConsent(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,23 @@
+package schedules
+
+import "time"
+
+// Tick is a wrapper around tick so as to make it accessible to tests.
+func (s *Service) Tick() error {
+	return s.tick()
+}
+
+// SetNow overrides the clock of the service.
+func (s *Service) SetNow(now func() time.Time) {
+	s.now = now
+}
+
+// Records returns the history of the runs of every schedule.
+func (s *Service) Records() (map[string]Record, error) {
+	return s.records()
+}
+
+// SetRecords overrides the history of the runs of the schedules.
+func (s *Service) SetRecords(records map[string]Record) error {
+	return s.setRecords(records)
+}
@@ -0,0 +1,311 @@
+// Package schedules submits recurring tasks to the distros, as set by the registry policy. Tasks are
+// deferred, so stopped distros are never woken up for them: they run the next time the distro starts.
+//
+// The time of the last run of each schedule is stored, so that runs missed while the agent was not
+// running are caught up deterministically: a schedule that is due runs once, however many runs it missed.
+package schedules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
+	"github.com/ubuntu/decorate"
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// tickInterval is how often the schedules are checked for runs that are due.
+	tickInterval = time.Minute
+
+	// schedulesBucket is the storage bucket where the runs of the schedules are kept, indexed by schedule.
+	schedulesBucket = "schedules"
+)
+
+// Record is the history of the runs of a schedule.
+type Record struct {
+	// FirstSeen is when the schedule was first found in the policy. Schedules that are not aligned
+	// to a time of the day run for the first time then.
+	FirstSeen time.Time `yaml:"first_seen"`
+
+	// LastRun is when the tasks of the schedule were last submitted.
+	LastRun time.Time `yaml:"last_run,omitempty"`
+}
+
+// Next returns when the schedule must run next.
+func (r Record) Next(s config.Schedule) time.Time {
+	if !s.Aligned {
+		if r.LastRun.IsZero() {
+			return r.FirstSeen
+		}
+		return r.LastRun.Add(s.Every)
+	}
+
+	if r.LastRun.IsZero() {
+		return nextTimeOfDay(r.FirstSeen, s.At)
+	}
+
+	// The last run may have been a late catch-up: we go back to the time of the day it was due.
+	next := nextTimeOfDay(r.LastRun.Add(s.Every-24*time.Hour), s.At)
+	if !next.After(r.LastRun) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
+
+// nextTimeOfDay returns the first time at the given offset from midnight that is not before t, in local time.
+func nextTimeOfDay(t time.Time, at time.Duration) time.Time {
+	t = t.Local()
+	y, m, d := t.Date()
+
+	next := time.Date(y, m, d, 0, 0, 0, 0, time.Local).Add(at)
+	if next.Before(t) {
+		next = time.Date(y, m, d+1, 0, 0, 0, 0, time.Local).Add(at)
+	}
+
+	return next
+}
+
+// Config is the configuration the schedules service depends on.
+type Config interface {
+	Schedules() ([]config.Schedule, error)
+	LandscapeClientConfigFor(distroName string) (string, config.Source, error)
+}
+
+// Service submits the scheduled tasks to the distros in the background.
+type Service struct {
+	ctx    context.Context
+	cancel func()
+
+	conf       Config
+	db         *database.DistroDB
+	storageDir string
+
+	// now returns the current time. It is overridden in tests.
+	now func() time.Time
+
+	// running is closed when the background loop returns. It is nil if the service was never started.
+	running chan struct{}
+}
+
+// New creates a new schedules service. Call Start to begin submitting scheduled tasks.
+func New(ctx context.Context, conf Config, db *database.DistroDB, storageDir string) *Service {
+	ctx, cancel := context.WithCancel(ctx)
+
+	return &Service{
+		ctx:        ctx,
+		cancel:     cancel,
+		conf:       conf,
+		db:         db,
+		storageDir: storageDir,
+		now:        time.Now,
+	}
+}
+
+// Start begins submitting the scheduled tasks in the background.
+func (s *Service) Start() {
+	s.running = make(chan struct{})
+	go s.run()
+}
+
+// Stop stops submitting the scheduled tasks, and waits for the ongoing submissions to finish.
+func (s *Service) Stop() {
+	s.cancel()
+	if s.running != nil {
+		<-s.running
+	}
+}
+
+func (s *Service) run() {
+	defer close(s.running)
+
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.tick(); err != nil {
+			log.Warningf(s.ctx, "Schedules: %v", err)
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick submits the tasks of the schedules that are due, and records their runs.
+// Records of the schedules that are no longer in the policy are removed, unless the policy is invalid.
+func (s *Service) tick() (err error) {
+	schedules, policyErr := s.conf.Schedules()
+
+	records, err := s.records()
+	if err != nil {
+		return err
+	}
+
+	now := s.now()
+	changed := false
+	keep := make(map[string]Record, len(schedules))
+
+	for _, sch := range schedules {
+		if s.ctx.Err() != nil {
+			return nil
+		}
+
+		key := sch.String()
+		if _, dup := keep[key]; dup {
+			changed = true
+			continue
+		}
+
+		r, ok := records[key]
+		if !ok {
+			r.FirstSeen = now
+			changed = true
+		}
+
+		if !now.Before(r.Next(sch)) {
+			if e := s.submit(sch); e != nil {
+				// Failures are not retried until the next run, as the distros would likely fail again.
+				err = errors.Join(err, e)
+			}
+			r.LastRun = now
+			changed = true
+		}
+
+		keep[key] = r
+	}
+
+	if policyErr != nil {
+		// The schedules missing from the last valid policy may be in the invalid one, so their records are kept.
+		log.Warningf(s.ctx, "Schedules: keeping the records of every schedule: %v", policyErr)
+		for key, r := range records {
+			if _, ok := keep[key]; !ok {
+				keep[key] = r
+			}
+		}
+	}
+
+	if !changed && len(keep) == len(records) {
+		return err
+	}
+
+	return errors.Join(err, s.setRecords(keep))
+}
+
+// submit submits the task of the schedule to the distros it applies to.
+func (s *Service) submit(sch config.Schedule) (err error) {
+	defer decorate.OnError(&err, "could not submit scheduled %s to all distros", sch.Task)
+
+	var n int
+	for _, d := range s.db.GetAll() {
+		if !sch.Matches(d.Name(), d.Labels()) {
+			continue
+		}
+
+		t, e := s.newTask(sch.Task, d.Name())
+		if e != nil {
+			err = errors.Join(err, e)
+			continue
+		}
+		if t == nil {
+			continue
+		}
+
+		// Deferred tasks never wake the distro up.
+		err = errors.Join(err, d.SubmitDeferredTasks(t))
+		n++
+	}
+
+	log.Debugf(s.ctx, "Schedules: submitted %s to %d distros", sch.Task, n)
+	return err
+}
+
+// newTask returns the task of the given kind for the distro. It returns nil if there is nothing to submit.
+func (s *Service) newTask(kind, distroName string) (task.Task, error) {
+	switch kind {
+	case config.ScheduleProRefresh:
+		return tasks.RunCommand{Argv: []string{"pro", "refresh"}}, nil
+	case config.ScheduleSecurityUpdate:
+		return tasks.SecurityUpdate{}, nil
+	case config.SchedulePackageInventory:
+		return tasks.PackageInventory{}, nil
+	case config.ScheduleLandscapeConfig:
+		l, source, err := s.conf.LandscapeClientConfigFor(distroName)
+		if err != nil {
+			return nil, err
+		}
+		if l == "" || source == config.SourceNone {
+			log.Debugf(s.ctx, "Schedules: no Landscape configuration to send to %q", distroName)
+			return nil, nil
+		}
+		return tasks.LandscapeConfigure{Config: l}, nil
+	}
+
+	return nil, fmt.Errorf("unknown kind of task %q", kind)
+}
+
+// records returns the history of the runs of every schedule, indexed by schedule.
+func (s *Service) records() (records map[string]Record, err error) {
+	store, err := storage.Open(s.ctx, s.storageDir)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Close()
+
+	records = make(map[string]Record)
+	err = store.View(func(tx storage.Tx) error {
+		return tx.ForEach(schedulesBucket, func(key string, value []byte) error {
+			var r Record
+			if err := yaml.Unmarshal(value, &r); err != nil {
+				// The record is replaced as if the schedule was new, rather than blocking every other one.
+				log.Warningf(s.ctx, "Schedules: ignoring record of schedule %q: %v", key, err)
+				return nil
+			}
+			records[key] = r
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// setRecords replaces the history of the runs of the schedules.
+func (s *Service) setRecords(records map[string]Record) error {
+	store, err := storage.Open(s.ctx, s.storageDir)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	return store.Update(func(tx storage.Tx) error {
+		if err := tx.DeleteBucket(schedulesBucket); err != nil {
+			return err
+		}
+
+		for key, r := range records {
+			out, err := yaml.Marshal(r)
+			if err != nil {
+				return fmt.Errorf("could not marshal record of schedule %q: %v", key, err)
+			}
+			if err := tx.Put(schedulesBucket, key, out); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
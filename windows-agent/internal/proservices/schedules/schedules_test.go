@@ -0,0 +1,215 @@
+package schedules_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/schedules"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
+	"github.com/stretchr/testify/require"
+	wsl "github.com/ubuntu/gowsl"
+	wslmock "github.com/ubuntu/gowsl/mock"
+)
+
+func TestTick(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	// Aligned schedules run at a time of the day in local time.
+	now := time.Date(2024, time.January, 10, 12, 0, 0, 0, time.Local)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.January, day, hour, minute, 0, 0, time.Local)
+	}
+
+	daily := config.Schedule{Pattern: "*", Task: config.ScheduleSecurityUpdate, Every: 24 * time.Hour}
+	nightly := config.Schedule{Pattern: "*", Task: config.ScheduleSecurityUpdate, Every: 24 * time.Hour, At: 2 * time.Hour, Aligned: true}
+	weekly := config.Schedule{Pattern: "*", Task: config.ScheduleProRefresh, Every: 7 * 24 * time.Hour}
+	landscape := config.Schedule{Pattern: "*", Task: config.ScheduleLandscapeConfig, Every: 24 * time.Hour}
+
+	testCases := map[string]struct {
+		schedule  config.Schedule
+		landscape string
+		previous  *schedules.Record
+		removed   *config.Schedule
+		invalid   bool
+
+		wantRun  bool
+		wantTask task.Task
+	}{
+		"Success running a new schedule at once":                        {schedule: daily, wantTask: tasks.SecurityUpdate{}, wantRun: true},
+		"Success running a schedule that is due":                        {schedule: weekly, previous: &schedules.Record{FirstSeen: at(1, 0, 0), LastRun: at(3, 12, 0)}, wantTask: tasks.RunCommand{Argv: []string{"pro", "refresh"}}, wantRun: true},
+		"Success catching up missed runs only once":                     {schedule: daily, previous: &schedules.Record{FirstSeen: at(1, 0, 0), LastRun: at(5, 0, 0)}, wantTask: tasks.SecurityUpdate{}, wantRun: true},
+		"Success running an aligned schedule that is due":               {schedule: nightly, previous: &schedules.Record{FirstSeen: at(1, 0, 0), LastRun: at(9, 2, 0)}, wantTask: tasks.SecurityUpdate{}, wantRun: true},
+		"Success running a new aligned schedule at its time of the day": {schedule: nightly, previous: &schedules.Record{FirstSeen: at(9, 3, 0)}, wantTask: tasks.SecurityUpdate{}, wantRun: true},
+		"Success catching up an aligned schedule after a late run":      {schedule: nightly, previous: &schedules.Record{FirstSeen: at(1, 0, 0), LastRun: at(9, 23, 0)}, wantTask: tasks.SecurityUpdate{}, wantRun: true},
+		"Success sending the Landscape config":                          {schedule: landscape, landscape: "[client]\nuser=JohnDoe", wantTask: tasks.LandscapeConfigure{Config: "[client]\nuser=JohnDoe"}, wantRun: true},
+		"Success removing the records of removed schedules":             {schedule: daily, removed: &weekly, wantTask: tasks.SecurityUpdate{}, wantRun: true},
+		"Success keeping the records of removed schedules when invalid": {schedule: daily, removed: &weekly, invalid: true, wantTask: tasks.SecurityUpdate{}, wantRun: true},
+
+		"No run before the period elapses":                     {schedule: daily, previous: &schedules.Record{FirstSeen: at(1, 0, 0), LastRun: at(10, 6, 0)}},
+		"No run before the time of the day":                    {schedule: nightly},
+		"No run of an aligned schedule that already ran today": {schedule: nightly, previous: &schedules.Record{FirstSeen: at(1, 0, 0), LastRun: at(10, 2, 0)}},
+		"No task in distros that do not match":                 {schedule: config.Schedule{Pattern: "label:ci", Task: config.ScheduleSecurityUpdate, Every: time.Hour}, wantRun: true},
+		"No task without Landscape config":                     {schedule: landscape, wantRun: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if wsl.MockAvailable() {
+				t.Parallel()
+				ctx = wsl.WithMock(ctx, wslmock.New())
+			}
+
+			storageDir := t.TempDir()
+			db, err := database.New(ctx, storageDir)
+			require.NoError(t, err, "Setup: could not create empty database")
+			defer db.Close(ctx)
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: GetDistroAndUpdateProperties should return no error")
+
+			conf := mockConfig{schedules: []config.Schedule{tc.schedule}, landscape: tc.landscape}
+			if tc.invalid {
+				conf.schedulesErr = errors.New("mock error")
+			}
+
+			s := schedules.New(ctx, conf, db, storageDir)
+			defer s.Stop()
+			s.SetNow(func() time.Time { return now })
+
+			previous := map[string]schedules.Record{}
+			if tc.previous != nil {
+				previous[tc.schedule.String()] = *tc.previous
+			}
+			if tc.removed != nil {
+				previous[tc.removed.String()] = schedules.Record{FirstSeen: at(1, 0, 0)}
+			}
+			require.NoError(t, s.SetRecords(previous), "Setup: could not store the previous records")
+
+			require.NoError(t, s.Tick(), "Tick should return no error")
+
+			var got []task.Task
+			for _, q := range d.QueuedTasks() {
+				require.True(t, q.Deferred, "Scheduled tasks should be deferred so as not to wake the distro up")
+				got = append(got, q.Task)
+			}
+
+			records, err := s.Records()
+			require.NoError(t, err, "Records should return no error")
+			if tc.invalid {
+				require.Contains(t, records, tc.removed.String(), "Records should not be removed while the schedules are invalid")
+				delete(records, tc.removed.String())
+			}
+			require.Len(t, records, 1, "Only the record of the current schedule should be kept")
+			r := records[tc.schedule.String()]
+
+			if tc.previous != nil {
+				require.True(t, tc.previous.FirstSeen.Equal(r.FirstSeen), "The time the schedule was first seen should not change")
+			} else {
+				require.True(t, now.Equal(r.FirstSeen), "New schedules should be recorded as first seen now")
+			}
+
+			if !tc.wantRun {
+				require.Empty(t, got, "No task should have been submitted")
+				if tc.previous != nil {
+					require.True(t, tc.previous.LastRun.Equal(r.LastRun), "The last run should not change")
+				}
+				return
+			}
+
+			if tc.wantTask != nil {
+				require.Equal(t, []task.Task{tc.wantTask}, got, "The scheduled task should have been submitted once")
+			} else {
+				require.Empty(t, got, "No task should have been submitted")
+			}
+			// Schedules count as run even if there was nothing to submit, so that they are not attempted every tick.
+			require.True(t, now.Equal(r.LastRun), "The run should have been recorded")
+
+			// The next tick must not run the schedule again.
+			require.NoError(t, s.Tick(), "Tick should return no error")
+			require.Len(t, d.QueuedTasks(), len(got), "The schedule should not run twice in a row")
+		})
+	}
+}
+
+func TestTickAfterRestart(t *testing.T) {
+	if wsl.MockAvailable() {
+		t.Parallel()
+	}
+
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	storageDir := t.TempDir()
+	db, err := database.New(ctx, storageDir)
+	require.NoError(t, err, "Setup: could not create empty database")
+	defer db.Close(ctx)
+
+	distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+	require.NoError(t, err, "Setup: GetDistroAndUpdateProperties should return no error")
+
+	sch := config.Schedule{Pattern: "*", Task: config.SchedulePackageInventory, Every: time.Hour}
+	conf := mockConfig{schedules: []config.Schedule{sch}}
+	now := time.Now()
+
+	lastRun := func(s *schedules.Service) time.Time {
+		t.Helper()
+
+		records, err := s.Records()
+		require.NoError(t, err, "Records should return no error")
+		return records[sch.String()].LastRun
+	}
+
+	s := schedules.New(ctx, conf, db, storageDir)
+	s.SetNow(func() time.Time { return now })
+	require.NoError(t, s.Tick(), "Tick should return no error")
+	s.Stop()
+	require.Len(t, d.QueuedTasks(), 1, "The new schedule should have run")
+
+	// The agent restarts within the hour: the schedule is not due yet.
+	s = schedules.New(ctx, conf, db, storageDir)
+	defer s.Stop()
+	s.SetNow(func() time.Time { return now.Add(30 * time.Minute) })
+	require.NoError(t, s.Tick(), "Tick should return no error")
+	require.True(t, now.Equal(lastRun(s)), "The schedule should not run again after a restart before it is due")
+
+	// The agent was down for several periods: the missed runs are caught up once.
+	s.SetNow(func() time.Time { return now.Add(5 * time.Hour) })
+	require.NoError(t, s.Tick(), "Tick should return no error")
+	require.True(t, now.Add(5*time.Hour).Equal(lastRun(s)), "The missed runs should have been caught up")
+
+	s.SetNow(func() time.Time { return now.Add(5*time.Hour + time.Minute) })
+	require.NoError(t, s.Tick(), "Tick should return no error")
+	require.True(t, now.Add(5*time.Hour).Equal(lastRun(s)), "The missed runs should have been caught up only once")
+	require.Len(t, d.QueuedTasks(), 1, "The scheduled task should replace the one still queued")
+}
+
+type mockConfig struct {
+	schedules    []config.Schedule
+	schedulesErr error
+	landscape    string
+}
+
+func (c mockConfig) Schedules() ([]config.Schedule, error) {
+	return c.schedules, c.schedulesErr
+}
+
+func (c mockConfig) LandscapeClientConfigFor(string) (string, config.Source, error) {
+	if c.landscape == "" {
+		return "", config.SourceNone, nil
+	}
+	return c.landscape, config.SourceRegistry, nil
+}
@@ -0,0 +1,214 @@
+package ui
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
+	"github.com/ubuntu/decorate"
+)
+
+// Formats of the exported package inventories.
+const (
+	inventoryFormatJSON = "json"
+	inventoryFormatCSV  = "csv"
+)
+
+// Kinds of changes between two package inventories.
+const (
+	packageAdded   = "added"
+	packageRemoved = "removed"
+	packageChanged = "changed"
+)
+
+// ExportPackageInventory handles the gRPC call to export the latest package inventory of the requested distro,
+// or of all of them if none is requested. With diff, the changes since the previous inventory are exported instead.
+func (s *Service) ExportPackageInventory(ctx context.Context, req *agentapi.InventoryRequest) (_ *agentapi.InventoryExport, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: ExportPackageInventory")
+
+	log.Infof(ctx, "UI service: received ExportPackageInventory message for distro %q", req.GetWslName())
+
+	format := req.GetFormat()
+	if format == "" {
+		format = inventoryFormatJSON
+	}
+	if format != inventoryFormatJSON && format != inventoryFormatCSV {
+		return nil, fmt.Errorf("unknown format %q: use %q or %q", format, inventoryFormatJSON, inventoryFormatCSV)
+	}
+
+	inventories, err := s.db.PackageInventories()
+	if err != nil {
+		return nil, err
+	}
+
+	names := slices.Sorted(maps.Keys(inventories))
+	if name := req.GetWslName(); name != "" {
+		if _, ok := inventories[name]; !ok {
+			return nil, fmt.Errorf("no package inventory collected from distro %q", name)
+		}
+		names = []string{name}
+	}
+
+	var data []byte
+	if req.GetDiff() {
+		data, err = exportInventoryDiffs(format, names, inventories)
+	} else {
+		data, err = exportInventories(format, names, inventories)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &agentapi.InventoryExport{Data: data}, nil
+}
+
+// exportedInventory is the JSON export of the package inventory of a distro.
+type exportedInventory struct {
+	Distro    string            `json:"distro"`
+	Collected time.Time         `json:"collected"`
+	Packages  []exportedPackage `json:"packages"`
+}
+
+// exportedPackage is the JSON export of an installed package.
+type exportedPackage struct {
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Architecture string `json:"architecture"`
+	Origin       string `json:"origin"`
+	ESM          bool   `json:"esm"`
+}
+
+// exportInventories renders the current package inventory of the named distros in the requested format.
+func exportInventories(format string, names []string, inventories map[string]worker.Inventories) ([]byte, error) {
+	if format == inventoryFormatCSV {
+		rows := [][]string{{"distro", "name", "version", "architecture", "origin", "esm"}}
+		for _, name := range names {
+			for _, p := range inventories[name].Current.Packages {
+				rows = append(rows, []string{name, p.Name, p.Version, p.Architecture, p.Origin, strconv.FormatBool(p.ESM)})
+			}
+		}
+		return writeCSV(rows)
+	}
+
+	out := make([]exportedInventory, 0, len(names))
+	for _, name := range names {
+		inv := inventories[name].Current
+		exported := exportedInventory{Distro: name, Collected: inv.Collected, Packages: make([]exportedPackage, 0, len(inv.Packages))}
+		for _, p := range inv.Packages {
+			exported.Packages = append(exported.Packages, exportedPackage(p))
+		}
+		out = append(out, exported)
+	}
+	return writeJSON(out)
+}
+
+// exportedDiff is the JSON export of the changes between the last two package inventories of a distro.
+type exportedDiff struct {
+	Distro string `json:"distro"`
+	// From is when the previous inventory was collected. Nil if there is none, in which case all packages are added.
+	From    *time.Time      `json:"from,omitempty"`
+	To      time.Time       `json:"to"`
+	Changes []packageChange `json:"changes"`
+}
+
+// packageChange is a package that was added, removed or changed between two inventories.
+type packageChange struct {
+	Change       string `json:"change"`
+	Name         string `json:"name"`
+	Architecture string `json:"architecture"`
+	OldVersion   string `json:"old_version,omitempty"`
+	NewVersion   string `json:"new_version,omitempty"`
+}
+
+// exportInventoryDiffs renders the changes between the last two package inventories of the named distros
+// in the requested format.
+func exportInventoryDiffs(format string, names []string, inventories map[string]worker.Inventories) ([]byte, error) {
+	if format == inventoryFormatCSV {
+		rows := [][]string{{"distro", "change", "name", "architecture", "old_version", "new_version"}}
+		for _, name := range names {
+			for _, c := range diffInventories(inventories[name]) {
+				rows = append(rows, []string{name, c.Change, c.Name, c.Architecture, c.OldVersion, c.NewVersion})
+			}
+		}
+		return writeCSV(rows)
+	}
+
+	out := make([]exportedDiff, 0, len(names))
+	for _, name := range names {
+		inv := inventories[name]
+		d := exportedDiff{Distro: name, To: inv.Current.Collected, Changes: diffInventories(inv)}
+		if inv.Previous != nil {
+			d.From = &inv.Previous.Collected
+		}
+		out = append(out, d)
+	}
+	return writeJSON(out)
+}
+
+// diffInventories returns the packages added, removed or changed from the previous inventory to the current one,
+// sorted by name and architecture. Without a previous inventory, all packages are added.
+func diffInventories(inv worker.Inventories) []packageChange {
+	// Packages of different architectures can be installed side by side.
+	type key struct{ name, arch string }
+
+	previous := make(map[key]task.Package)
+	if inv.Previous != nil {
+		for _, p := range inv.Previous.Packages {
+			previous[key{p.Name, p.Architecture}] = p
+		}
+	}
+
+	changes := make([]packageChange, 0)
+	for _, p := range inv.Current.Packages {
+		k := key{p.Name, p.Architecture}
+		old, found := previous[k]
+		delete(previous, k)
+
+		switch {
+		case !found:
+			changes = append(changes, packageChange{Change: packageAdded, Name: p.Name, Architecture: p.Architecture, NewVersion: p.Version})
+		case old.Version != p.Version:
+			changes = append(changes, packageChange{Change: packageChanged, Name: p.Name, Architecture: p.Architecture, OldVersion: old.Version, NewVersion: p.Version})
+		}
+	}
+
+	for _, p := range previous {
+		changes = append(changes, packageChange{Change: packageRemoved, Name: p.Name, Architecture: p.Architecture, OldVersion: p.Version})
+	}
+
+	slices.SortFunc(changes, func(a, b packageChange) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Architecture, b.Architecture))
+	})
+	return changes
+}
+
+// writeJSON renders the value as indented JSON, ending with a newline like the CSV exports.
+func writeJSON(v any) ([]byte, error) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not write JSON: %v", err)
+	}
+	return append(out, '\n'), nil
+}
+
+// writeCSV renders the rows as CSV.
+func writeCSV(rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("could not write CSV: %v", err)
+	}
+	return buf.Bytes(), nil
+}
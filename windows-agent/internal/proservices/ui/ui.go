@@ -5,12 +5,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/startscheduler"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
 	"github.com/ubuntu/decorate"
@@ -23,8 +32,10 @@ type Config interface {
 	SetUserSubscription(ctx context.Context, token string) error
 	SetStoreSubscription(ctx context.Context, token string) error
 	Subscription() (string, config.Source, error)
+	ContractFor(distroName string, labels ...string) (config.Contract, error)
 	SetUserLandscapeConfig(ctx context.Context, token string) error
 	LandscapeClientConfig() (string, config.Source, error)
+	Proxy() (config.Proxy, error)
 }
 
 // Service it the UI GRPC service implementation.
@@ -202,6 +213,338 @@ func (s *Service) GetConfigSources(ctx context.Context, empty *agentapi.Empty) (
 	return src, nil
 }
 
+// GetDistroContracts handles the gRPC call to return the Ubuntu Pro contract that applies to each distro.
+func (s *Service) GetDistroContracts(ctx context.Context, empty *agentapi.Empty) (_ *agentapi.DistroContracts, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetDistroContracts")
+
+	log.Info(ctx, "UI service: received GetDistroContracts message")
+
+	distros := s.db.GetAll()
+	slices.SortFunc(distros, func(a, b *distro.Distro) int { return strings.Compare(a.Name(), b.Name()) })
+
+	resp := &agentapi.DistroContracts{}
+	for _, d := range distros {
+		c, err := s.config.ContractFor(d.Name(), d.Labels()...)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.Distros = append(resp.Distros, &agentapi.DistroContract{
+			WslName: d.Name(),
+			Token:   common.Obfuscate(c.Token),
+			Pattern: c.Pattern,
+		})
+	}
+
+	return resp, nil
+}
+
+// SetDistroLabels handles the gRPC call to replace the labels assigned by the user to a distro.
+func (s *Service) SetDistroLabels(ctx context.Context, req *agentapi.DistroLabels) (_ *agentapi.Empty, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: SetDistroLabels")
+
+	name := req.GetWslName()
+	log.Infof(ctx, "UI service: received SetDistroLabels message for distro %q", name)
+
+	d, ok := s.db.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("distro %q not found", name)
+	}
+
+	before, err := s.config.ContractFor(d.Name(), d.Labels()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.SetLabels(name, req.GetLabels()); err != nil {
+		return nil, err
+	}
+
+	// The token map may select distros by label, so the contract may have changed.
+	after, err := s.config.ContractFor(d.Name(), d.Labels()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if after.Token != before.Token {
+		if err := d.SubmitTasks(tasks.ProAttachment{Token: after.Token}); err != nil {
+			log.Warningf(ctx, "UI service: could not submit Ubuntu Pro token to distro %q: %v", name, err)
+		}
+	}
+
+	return &agentapi.Empty{}, nil
+}
+
+// GetDeadLetters handles the gRPC call to return the tasks that were given up on after exhausting their retry policy.
+func (s *Service) GetDeadLetters(ctx context.Context, empty *agentapi.Empty) (_ *agentapi.DeadLetters, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetDeadLetters")
+
+	log.Info(ctx, "UI service: received GetDeadLetters message")
+
+	letters, err := s.db.DeadLetters()
+	if err != nil {
+		return nil, err
+	}
+
+	names := slices.Sorted(maps.Keys(letters))
+
+	resp := &agentapi.DeadLetters{}
+	for _, name := range names {
+		for _, dl := range letters[name] {
+			resp.Letters = append(resp.Letters, &agentapi.DeadLetter{
+				WslName:  name,
+				Id:       dl.ID,
+				Type:     dl.Type,
+				Task:     dl.Task,
+				Attempts: int32(min(dl.Attempts, math.MaxInt32)),
+				Error:    dl.Error,
+				Time:     dl.Time.Unix(),
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// GetQuarantinedTasks handles the gRPC call to return the stored tasks that could not be read back, such as the ones
+// of a type this version of the agent does not know about.
+func (s *Service) GetQuarantinedTasks(ctx context.Context, empty *agentapi.Empty) (_ *agentapi.QuarantinedTasks, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetQuarantinedTasks")
+
+	log.Info(ctx, "UI service: received GetQuarantinedTasks message")
+
+	quarantined, err := s.db.QuarantinedTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	names := slices.Sorted(maps.Keys(quarantined))
+
+	resp := &agentapi.QuarantinedTasks{}
+	for _, name := range names {
+		for _, q := range quarantined[name] {
+			resp.Tasks = append(resp.Tasks, &agentapi.QuarantinedTask{
+				WslName: name,
+				Task:    q.Task,
+				Error:   q.Error,
+				Time:    q.Time.Unix(),
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// GetTaskHistory handles the gRPC call to return the most recent outcomes of the tasks of a distro, or of all distros
+// if no name is specified.
+func (s *Service) GetTaskHistory(ctx context.Context, req *agentapi.TaskHistoryRequest) (_ *agentapi.TaskHistory, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetTaskHistory")
+
+	log.Infof(ctx, "UI service: received GetTaskHistory message for distro %q", req.GetWslName())
+
+	history, err := s.db.TaskHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	names := slices.Sorted(maps.Keys(history))
+	if name := req.GetWslName(); name != "" {
+		names = []string{name}
+	}
+
+	resp := &agentapi.TaskHistory{}
+	for _, name := range names {
+		for _, h := range history[name] {
+			resp.Entries = append(resp.Entries, &agentapi.TaskHistoryEntry{
+				WslName:   name,
+				Type:      h.Type,
+				Summary:   h.Summary,
+				Submitted: unixOrZero(h.Submitted),
+				Started:   h.Started.Unix(),
+				Ended:     h.Ended.Unix(),
+				Attempts:  int32(min(h.Attempts, math.MaxInt32)),
+				Result:    string(h.Result),
+				Error:     h.Error,
+				Details:   h.Details,
+				Id:        h.ID,
+
+				RebootRequired: h.RebootRequired,
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// GetQueuedTasks handles the gRPC call to return the tasks of a distro that did not complete yet, or the ones of
+// all distros if no name is specified.
+func (s *Service) GetQueuedTasks(ctx context.Context, req *agentapi.TaskHistoryRequest) (_ *agentapi.QueuedTasks, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: GetQueuedTasks")
+
+	log.Infof(ctx, "UI service: received GetQueuedTasks message for distro %q", req.GetWslName())
+
+	distros := s.db.GetAll()
+	if name := req.GetWslName(); name != "" {
+		d, ok := s.db.Get(name)
+		if !ok {
+			return nil, fmt.Errorf("distro %q not found", name)
+		}
+		distros = []*distro.Distro{d}
+	}
+	slices.SortFunc(distros, func(a, b *distro.Distro) int { return strings.Compare(a.Name(), b.Name()) })
+
+	resp := &agentapi.QueuedTasks{}
+	for _, d := range distros {
+		for _, q := range d.QueuedTasks() {
+			resp.Tasks = append(resp.Tasks, &agentapi.QueuedTask{
+				WslName:   d.Name(),
+				Id:        q.ID,
+				Type:      task.TypeID(q.Task),
+				Summary:   fmt.Sprint(q.Task),
+				Submitted: unixOrZero(q.Submitted),
+				Running:   q.Running,
+				Deferred:  q.Deferred,
+			})
+		}
+	}
+
+	return resp, nil
+}
+
+// CancelTask handles the gRPC call to cancel a task of a distro. A queued task is removed from the queue, and a
+// running one is interrupted. The cancellation is recorded in the task history.
+func (s *Service) CancelTask(ctx context.Context, req *agentapi.CancelTaskRequest) (_ *agentapi.Empty, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: CancelTask")
+
+	log.Infof(ctx, "UI service: received CancelTask message for task %q of distro %q", req.GetId(), req.GetWslName())
+
+	d, ok := s.db.Get(req.GetWslName())
+	if !ok {
+		return nil, fmt.Errorf("distro %q not found", req.GetWslName())
+	}
+
+	if err := d.CancelTask(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+
+	return &agentapi.Empty{}, nil
+}
+
+// ApplySecurityUpdates handles the gRPC call to apply the pending security updates in the distros requested
+// by name or by label, or in all of them if none is requested. The updates are applied by queued tasks: their
+// outcome is recorded in the task history.
+func (s *Service) ApplySecurityUpdates(ctx context.Context, req *agentapi.SecurityUpdateRequest) (_ *agentapi.Empty, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: ApplySecurityUpdates")
+
+	log.Infof(ctx, "UI service: received ApplySecurityUpdates message for distros %q with labels %q", req.GetWslNames(), req.GetLabels())
+
+	if err := s.submitTo(ctx, req.GetWslNames(), req.GetLabels(), tasks.SecurityUpdate{}); err != nil {
+		return nil, err
+	}
+
+	return &agentapi.Empty{}, nil
+}
+
+// RunCommand handles the gRPC call to run a command in the distros requested by name or by label, or in all
+// of them if none is requested. The command is run by queued tasks: its outcome and the beginning of its output
+// are recorded in the task history.
+func (s *Service) RunCommand(ctx context.Context, req *agentapi.RunCommandRequest) (_ *agentapi.Empty, err error) {
+	defer decorate.LogOnError(&err)
+	defer decorate.OnError(&err, "UI service: RunCommand")
+
+	// Only the executable is logged, as the arguments may contain secrets.
+	argv := req.GetArgv()
+	if len(argv) == 0 {
+		return nil, errors.New("no command specified")
+	}
+	log.Infof(ctx, "UI service: received RunCommand message to run %q in distros %q with labels %q", argv[0], req.GetWslNames(), req.GetLabels())
+
+	if req.GetTimeoutSeconds() < 0 {
+		return nil, fmt.Errorf("invalid timeout: %ds", req.GetTimeoutSeconds())
+	}
+
+	cmd := tasks.RunCommand{
+		Argv:       argv,
+		Env:        req.GetEnv(),
+		WorkingDir: req.GetWorkingDir(),
+		Timeout:    time.Duration(req.GetTimeoutSeconds()) * time.Second,
+		User:       req.GetUser(),
+	}
+
+	if err := s.submitTo(ctx, req.GetWslNames(), req.GetLabels(), cmd); err != nil {
+		return nil, err
+	}
+
+	return &agentapi.Empty{}, nil
+}
+
+// GetStartMetrics handles the gRPC call to return how the starts of the distros are being admitted.
+func (s *Service) GetStartMetrics(ctx context.Context, empty *agentapi.Empty) (*agentapi.StartMetrics, error) {
+	log.Info(ctx, "UI service: received GetStartMetrics message")
+
+	m := s.db.StartMetrics()
+
+	return &agentapi.StartMetrics{
+		Parallelism:       int64(m.Parallelism),
+		Running:           int64(m.Running),
+		QueuedInteractive: int64(m.Queued[startscheduler.Interactive]),
+		QueuedBackground:  int64(m.Queued[startscheduler.Background]),
+		MaxQueued:         int64(m.MaxQueued),
+		Admitted:          int64(m.Admitted),
+		TimedOut:          int64(m.TimedOut),
+	}, nil
+}
+
+// submitTo submits the task to the distros with the specified names or, if none is specified, to those that
+// carry all the specified labels. Named distros are all looked up first, so that nothing is submitted if any is missing.
+func (s *Service) submitTo(ctx context.Context, names, labels []string, t task.Task) (err error) {
+	if len(names) == 0 {
+		n, err := s.db.SubmitTasksTo(database.Query{Labels: labels}, t)
+		log.Debugf(ctx, "UI service: submitted task %s to %d distros", task.TypeID(t), n)
+		return err
+	}
+
+	if len(labels) != 0 {
+		return errors.New("distros cannot be selected both by name and by label")
+	}
+
+	distros := make([]*distro.Distro, 0, len(names))
+	for _, name := range names {
+		d, ok := s.db.Get(name)
+		if !ok {
+			return fmt.Errorf("distro %q not found", name)
+		}
+		distros = append(distros, d)
+	}
+
+	for _, d := range distros {
+		if e := d.SubmitTasks(t); e != nil {
+			err = errors.Join(err, fmt.Errorf("distro %q: %v", d.Name(), e))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("could not submit tasks to all selected distros: %v", err)
+	}
+
+	return nil
+}
+
+// unixOrZero returns the time in seconds since the Unix epoch, or zero if the time is unknown.
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
 func (s *Service) getSubscriptionSource() (*agentapi.SubscriptionInfo, error) {
 	info := &agentapi.SubscriptionInfo{}
 
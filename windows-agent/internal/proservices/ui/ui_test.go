@@ -7,22 +7,31 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/canonical/ubuntu-pro-for-wsl/common"
+	"github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/common/wsltestutils"
 	"github.com/canonical/ubuntu-pro-for-wsl/mocks/contractserver/contractsmockserver"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/worker"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proservices/ui"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
 	"github.com/stretchr/testify/require"
 	wsl "github.com/ubuntu/gowsl"
 	wslmock "github.com/ubuntu/gowsl/mock"
+	"go.yaml.in/yaml/v3"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestNew(t *testing.T) {
@@ -187,6 +196,688 @@ func TestGetConfigSources(t *testing.T) {
 	}
 }
 
+//nolint:tparallel // Subtests are parallel but the test itself is not due to the calls to RegisterDistro.
+func TestGetDistroContracts(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	mappedDistro, _ := wsltestutils.RegisterDistro(t, ctx, false)
+	defaultDistro, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+	//#nosec G101 // These are not real credentials
+	const (
+		defaultToken = "DEFAULT_PRO_TOKEN"
+		mappedToken  = "CUSTOMER_PRO_TOKEN"
+	)
+
+	testCases := map[string]struct {
+		noDistros       bool
+		subscriptionErr bool
+
+		wantErr bool
+	}{
+		"Success":                     {},
+		"Success with no distros":     {noDistros: true},
+		"Error when config bails out": {subscriptionErr: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			if !tc.noDistros {
+				for _, d := range []string{mappedDistro, defaultDistro} {
+					_, err := db.GetDistroAndUpdateProperties(ctx, d, distro.Properties{})
+					require.NoError(t, err, "Setup: could not add %q to the database", d)
+				}
+			}
+
+			conf := &mockConfig{
+				token:           defaultToken,
+				proSource:       config.SourceUser,
+				subscriptionErr: tc.subscriptionErr,
+				contracts: map[string]config.Contract{
+					mappedDistro: {Token: mappedToken, Source: config.SourceRegistry, Pattern: mappedDistro},
+				},
+			}
+			service := ui.New(ctx, conf, db)
+
+			got, err := service.GetDistroContracts(ctx, &agentapi.Empty{})
+			if tc.wantErr {
+				require.Error(t, err, "GetDistroContracts should return an error")
+				return
+			}
+			require.NoError(t, err, "GetDistroContracts should return no errors")
+
+			if tc.noDistros {
+				require.Empty(t, got.GetDistros(), "GetDistroContracts should return no contracts when there are no distros")
+				return
+			}
+
+			contracts := make(map[string]*agentapi.DistroContract)
+			for _, c := range got.GetDistros() {
+				contracts[c.GetWslName()] = c
+			}
+			require.Len(t, contracts, 2, "GetDistroContracts should return one contract per distro")
+
+			require.Equal(t, common.Obfuscate(mappedToken), contracts[mappedDistro].GetToken(), "Mapped distro should use the mapped token, obfuscated")
+			require.Equal(t, mappedDistro, contracts[mappedDistro].GetPattern(), "Mapped distro should report the pattern that selected its token")
+
+			require.Equal(t, common.Obfuscate(defaultToken), contracts[defaultDistro].GetToken(), "Unmapped distro should use the default token, obfuscated")
+			require.Empty(t, contracts[defaultDistro].GetPattern(), "Unmapped distro should not report any pattern")
+		})
+	}
+}
+
+func TestSetDistroLabels(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	//#nosec G101 // These are not real credentials
+	const customerToken = "CUSTOMER_PRO_TOKEN"
+
+	testCases := map[string]struct {
+		initialLabels   []string
+		labels          []string
+		notInDatabase   bool
+		subscriptionErr bool
+
+		wantLabels []string
+		wantErr    bool
+	}{
+		"Success":                     {labels: []string{"CI", "sandbox"}, wantLabels: []string{"ci", "sandbox"}},
+		"Success removing all labels": {initialLabels: []string{"ci"}, wantLabels: []string{}},
+		"Success with labels that select a contract": {labels: []string{"customer"}, wantLabels: []string{"customer"}},
+
+		"Error when the distro is not in the database": {notInDatabase: true, labels: []string{"ci"}, wantErr: true},
+		"Error when config bails out":                  {subscriptionErr: true, labels: []string{"ci"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if wsl.MockAvailable() {
+				t.Parallel()
+			}
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			if !tc.notInDatabase {
+				_, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+				require.NoError(t, err, "Setup: could not add %q to the database", distroName)
+				require.NoError(t, db.SetLabels(distroName, tc.initialLabels), "Setup: could not set the initial labels")
+			}
+
+			conf := &mockConfig{
+				token:           "DEFAULT_PRO_TOKEN",
+				proSource:       config.SourceUser,
+				subscriptionErr: tc.subscriptionErr,
+				contracts: map[string]config.Contract{
+					"label:customer": {Token: customerToken, Source: config.SourceRegistry, Pattern: "label:customer"},
+				},
+			}
+			service := ui.New(ctx, conf, db)
+
+			_, err = service.SetDistroLabels(ctx, &agentapi.DistroLabels{WslName: distroName, Labels: tc.labels})
+			if tc.wantErr {
+				require.Error(t, err, "SetDistroLabels should return an error")
+				return
+			}
+			require.NoError(t, err, "SetDistroLabels should return no errors")
+
+			d, ok := db.Get(distroName)
+			require.True(t, ok, "Distro should still be in the database")
+			require.Equal(t, tc.wantLabels, d.AssignedLabels(), "Distro labels should have been replaced")
+
+			contracts, err := service.GetDistroContracts(ctx, &agentapi.Empty{})
+			require.NoError(t, err, "GetDistroContracts should return no errors")
+			require.Len(t, contracts.GetDistros(), 1, "GetDistroContracts should return the only distro")
+
+			wantToken := common.Obfuscate(conf.token)
+			if slices.Contains(tc.wantLabels, "customer") {
+				wantToken = common.Obfuscate(customerToken)
+			}
+			require.Equal(t, wantToken, contracts.GetDistros()[0].GetToken(), "The contract of the distro should follow its labels")
+		})
+	}
+}
+
+func TestApplySecurityUpdates(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	testCases := map[string]struct {
+		request []string
+		labels  []string
+
+		wantSubmitted []bool
+		wantErr       bool
+	}{
+		"Success updating all distros":                          {wantSubmitted: []bool{true, true}},
+		"Success updating the requested distro":                 {request: []string{"first"}, wantSubmitted: []bool{true, false}},
+		"Success updating the distros with the requested label": {labels: []string{"ci"}, wantSubmitted: []bool{true, false}},
+		"Success updating no distro when none has the label":    {labels: []string{"sandbox"}, wantSubmitted: []bool{false, false}},
+
+		"Error when a requested distro is not in the database":      {request: []string{"first", "unknown"}, wantSubmitted: []bool{false, false}, wantErr: true},
+		"Error when distros are selected both by name and by label": {request: []string{"first"}, labels: []string{"ci"}, wantSubmitted: []bool{false, false}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if wsl.MockAvailable() {
+				t.Parallel()
+			}
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+
+			var distroNames []string
+			for range 2 {
+				distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+				d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+				require.NoError(t, err, "Setup: could not add %q to the database", distroName)
+				// Stop the worker right away so that the tasks remain stored.
+				d.Cleanup(ctx)
+				distroNames = append(distroNames, distroName)
+			}
+			require.NoError(t, db.SetLabels(distroNames[0], []string{"ci"}), "Setup: could not label the first distro")
+
+			var request []string
+			for _, r := range tc.request {
+				switch r {
+				case "first":
+					request = append(request, distroNames[0])
+				default:
+					request = append(request, wsltestutils.RandomDistroName(t))
+				}
+			}
+
+			service := ui.New(ctx, &mockConfig{}, db)
+
+			_, err = service.ApplySecurityUpdates(ctx, &agentapi.SecurityUpdateRequest{WslNames: request, Labels: tc.labels})
+			if tc.wantErr {
+				require.Error(t, err, "ApplySecurityUpdates should return an error")
+			} else {
+				require.NoError(t, err, "ApplySecurityUpdates should return no errors")
+			}
+
+			db.Close(ctx)
+
+			s, err := storage.Open(ctx, dir)
+			require.NoError(t, err, "Setup: could not open the storage")
+			defer s.Close()
+
+			stored, err := worker.StoredTasks(s)
+			require.NoError(t, err, "Could not read the stored tasks")
+
+			for i, distroName := range distroNames {
+				queued := string(stored[distroName])
+				require.Equal(t, tc.wantSubmitted[i], strings.Contains(queued, "type: security-update"),
+					"Mismatch in the security updates queued for distro %q:\n%s", distroName, queued)
+			}
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	testCases := map[string]struct {
+		request    []string
+		labels     []string
+		noArgv     bool
+		badTimeout bool
+
+		wantSubmitted []bool
+		wantErr       bool
+	}{
+		"Success running in all distros":                          {wantSubmitted: []bool{true, true}},
+		"Success running in the requested distro":                 {request: []string{"first"}, wantSubmitted: []bool{true, false}},
+		"Success running in the distros with the requested label": {labels: []string{"ci"}, wantSubmitted: []bool{true, false}},
+
+		"Error when a requested distro is not in the database":      {request: []string{"first", "unknown"}, wantSubmitted: []bool{false, false}, wantErr: true},
+		"Error when no command is specified":                        {noArgv: true, wantSubmitted: []bool{false, false}, wantErr: true},
+		"Error when distros are selected both by name and by label": {request: []string{"first"}, labels: []string{"ci"}, wantSubmitted: []bool{false, false}, wantErr: true},
+		"Error when the timeout is negative":                        {badTimeout: true, wantSubmitted: []bool{false, false}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if wsl.MockAvailable() {
+				t.Parallel()
+			}
+
+			dir := t.TempDir()
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+
+			var distroNames []string
+			for range 2 {
+				distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+				d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+				require.NoError(t, err, "Setup: could not add %q to the database", distroName)
+				// Stop the worker right away so that the tasks remain stored.
+				d.Cleanup(ctx)
+				distroNames = append(distroNames, distroName)
+			}
+			require.NoError(t, db.SetLabels(distroNames[0], []string{"ci"}), "Setup: could not label the first distro")
+
+			req := &agentapi.RunCommandRequest{Argv: []string{"apt-get", "clean"}, TimeoutSeconds: 60, Labels: tc.labels}
+			for _, r := range tc.request {
+				switch r {
+				case "first":
+					req.WslNames = append(req.WslNames, distroNames[0])
+				default:
+					req.WslNames = append(req.WslNames, wsltestutils.RandomDistroName(t))
+				}
+			}
+			if tc.noArgv {
+				req.Argv = nil
+			}
+			if tc.badTimeout {
+				req.TimeoutSeconds = -1
+			}
+
+			service := ui.New(ctx, &mockConfig{}, db)
+
+			_, err = service.RunCommand(ctx, req)
+			if tc.wantErr {
+				require.Error(t, err, "RunCommand should return an error")
+			} else {
+				require.NoError(t, err, "RunCommand should return no errors")
+			}
+
+			db.Close(ctx)
+
+			s, err := storage.Open(ctx, dir)
+			require.NoError(t, err, "Setup: could not open the storage")
+			defer s.Close()
+
+			stored, err := worker.StoredTasks(s)
+			require.NoError(t, err, "Could not read the stored tasks")
+
+			for i, distroName := range distroNames {
+				queued := string(stored[distroName])
+				require.Equal(t, tc.wantSubmitted[i], strings.Contains(queued, "type: run-command"),
+					"Mismatch in the commands queued for distro %q:\n%s", distroName, queued)
+			}
+		})
+	}
+}
+
+func TestGetDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	failedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		stored map[string]string
+
+		want    []*agentapi.DeadLetter
+		wantErr bool
+	}{
+		"Success with no dead letters": {},
+		"Success with dead letters sorted by distro": {
+			stored: map[string]string{
+				"Ubuntu-24.04": deadLetters(t, worker.DeadLetter{ID: "task-1", Type: "pro-attachment", Task: "Attach Pro", Attempts: 10, Error: "timeout", Time: failedAt}),
+				"Ubuntu":       deadLetters(t, worker.DeadLetter{Type: "landscape-configure", Task: "Configure Landscape", Attempts: 3, Error: "refused", Time: failedAt}),
+			},
+			want: []*agentapi.DeadLetter{
+				{WslName: "Ubuntu", Type: "landscape-configure", Task: "Configure Landscape", Attempts: 3, Error: "refused", Time: failedAt.Unix()},
+				{WslName: "Ubuntu-24.04", Id: "task-1", Type: "pro-attachment", Task: "Attach Pro", Attempts: 10, Error: "timeout", Time: failedAt.Unix()},
+			},
+		},
+
+		"Error when the dead letters cannot be read": {stored: map[string]string{"Ubuntu": "[this is not valid YAML"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			dir := t.TempDir()
+
+			s, err := storage.Open(ctx, dir)
+			require.NoError(t, err, "Setup: could not open the storage")
+			err = s.Update(func(tx storage.Tx) error {
+				for distroName, letters := range tc.stored {
+					if err := tx.Put("deadletters", distroName, []byte(letters)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			require.NoError(t, err, "Setup: could not store the dead letters")
+			require.NoError(t, s.Close(), "Setup: could not close the storage")
+
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			service := ui.New(ctx, &mockConfig{}, db)
+
+			got, err := service.GetDeadLetters(ctx, &agentapi.Empty{})
+			if tc.wantErr {
+				require.Error(t, err, "GetDeadLetters should return an error")
+				return
+			}
+			require.NoError(t, err, "GetDeadLetters should return no errors")
+
+			require.Len(t, got.GetLetters(), len(tc.want), "GetDeadLetters should return all dead letters")
+			for i := range tc.want {
+				require.True(t, proto.Equal(tc.want[i], got.GetLetters()[i]), "Mismatch in dead letter #%d. Want: %v. Got: %v", i, tc.want[i], got.GetLetters()[i])
+			}
+		})
+	}
+}
+
+// deadLetters serializes the dead letters as the worker stores them.
+func deadLetters(t *testing.T, letters ...worker.DeadLetter) string {
+	t.Helper()
+
+	out, err := yaml.Marshal(letters)
+	require.NoError(t, err, "Setup: could not marshal dead letters")
+	return string(out)
+}
+
+func TestGetQuarantinedTasks(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		stored map[string]string
+
+		want    []*agentapi.QuarantinedTask
+		wantErr bool
+	}{
+		"Success with no quarantined tasks": {},
+		"Success with quarantined tasks sorted by distro": {
+			stored: map[string]string{
+				"Ubuntu-24.04": quarantined(t, worker.QuarantinedTask{Task: "type: future-task", Error: "unknown type", Time: at}),
+				"Ubuntu":       quarantined(t, worker.QuarantinedTask{Task: "type: other-task", Error: "unknown type", Time: at}),
+			},
+			want: []*agentapi.QuarantinedTask{
+				{WslName: "Ubuntu", Task: "type: other-task", Error: "unknown type", Time: at.Unix()},
+				{WslName: "Ubuntu-24.04", Task: "type: future-task", Error: "unknown type", Time: at.Unix()},
+			},
+		},
+
+		"Error when the quarantined tasks cannot be read": {stored: map[string]string{"Ubuntu": "[this is not valid YAML"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			dir := t.TempDir()
+
+			s, err := storage.Open(ctx, dir)
+			require.NoError(t, err, "Setup: could not open the storage")
+			err = s.Update(func(tx storage.Tx) error {
+				for distroName, tasks := range tc.stored {
+					if err := tx.Put("quarantine", distroName, []byte(tasks)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			require.NoError(t, err, "Setup: could not store the quarantined tasks")
+			require.NoError(t, s.Close(), "Setup: could not close the storage")
+
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			service := ui.New(ctx, &mockConfig{}, db)
+
+			got, err := service.GetQuarantinedTasks(ctx, &agentapi.Empty{})
+			if tc.wantErr {
+				require.Error(t, err, "GetQuarantinedTasks should return an error")
+				return
+			}
+			require.NoError(t, err, "GetQuarantinedTasks should return no errors")
+
+			require.Len(t, got.GetTasks(), len(tc.want), "GetQuarantinedTasks should return all quarantined tasks")
+			for i := range tc.want {
+				require.True(t, proto.Equal(tc.want[i], got.GetTasks()[i]), "Mismatch in quarantined task #%d. Want: %v. Got: %v", i, tc.want[i], got.GetTasks()[i])
+			}
+		})
+	}
+}
+
+// quarantined serializes the quarantined tasks as the worker stores them.
+func quarantined(t *testing.T, tasks ...worker.QuarantinedTask) string {
+	t.Helper()
+
+	out, err := yaml.Marshal(tasks)
+	require.NoError(t, err, "Setup: could not marshal quarantined tasks")
+	return string(out)
+}
+
+func TestGetTaskHistory(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	attached := worker.HistoryEntry{ID: "attach-id", Type: "pro-attachment", Summary: "Attach Pro", Submitted: at, Started: at, Ended: at.Add(time.Minute), Attempts: 1, Result: worker.ResultSucceeded}
+	configured := worker.HistoryEntry{Type: "landscape-configure", Summary: "Configure Landscape", Started: at, Ended: at, Attempts: 2, Result: worker.ResultRetrying, Error: "refused"}
+	updated := worker.HistoryEntry{Type: "security-update", Summary: "Apply security updates", Started: at, Ended: at, Attempts: 1, Result: worker.ResultSucceeded, Details: "upgraded 1 packages: curl 8.5.0-2ubuntu10.1 -> 8.5.0-2ubuntu10.4", RebootRequired: true}
+
+	testCases := map[string]struct {
+		stored map[string]string
+		distro string
+
+		want    []*agentapi.TaskHistoryEntry
+		wantErr bool
+	}{
+		"Success with no history": {},
+		"Success with the history of all distros sorted by distro": {
+			stored: map[string]string{
+				"Ubuntu-24.04": history(t, attached, updated),
+				"Ubuntu":       history(t, configured),
+			},
+			want: []*agentapi.TaskHistoryEntry{
+				{WslName: "Ubuntu", Type: "landscape-configure", Summary: "Configure Landscape", Started: at.Unix(), Ended: at.Unix(), Attempts: 2, Result: "retrying", Error: "refused"},
+				{WslName: "Ubuntu-24.04", Id: "attach-id", Type: "pro-attachment", Summary: "Attach Pro", Submitted: at.Unix(), Started: at.Unix(), Ended: at.Add(time.Minute).Unix(), Attempts: 1, Result: "succeeded"},
+				{WslName: "Ubuntu-24.04", Type: "security-update", Summary: "Apply security updates", Started: at.Unix(), Ended: at.Unix(), Attempts: 1, Result: "succeeded", Details: "upgraded 1 packages: curl 8.5.0-2ubuntu10.1 -> 8.5.0-2ubuntu10.4", RebootRequired: true},
+			},
+		},
+		"Success with the history of a single distro": {
+			stored: map[string]string{
+				"Ubuntu-24.04": history(t, attached),
+				"Ubuntu":       history(t, configured),
+			},
+			distro: "Ubuntu-24.04",
+			want: []*agentapi.TaskHistoryEntry{
+				{WslName: "Ubuntu-24.04", Id: "attach-id", Type: "pro-attachment", Summary: "Attach Pro", Submitted: at.Unix(), Started: at.Unix(), Ended: at.Add(time.Minute).Unix(), Attempts: 1, Result: "succeeded"},
+			},
+		},
+		"Success with the history of a distro with none": {
+			stored: map[string]string{"Ubuntu": history(t, configured)},
+			distro: "Ubuntu-24.04",
+		},
+
+		"Error when the history cannot be read": {stored: map[string]string{"Ubuntu": "[this is not valid YAML"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			dir := t.TempDir()
+
+			s, err := storage.Open(ctx, dir)
+			require.NoError(t, err, "Setup: could not open the storage")
+			err = s.Update(func(tx storage.Tx) error {
+				for distroName, h := range tc.stored {
+					if err := tx.Put("history", distroName, []byte(h)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			require.NoError(t, err, "Setup: could not store the history")
+			require.NoError(t, s.Close(), "Setup: could not close the storage")
+
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			service := ui.New(ctx, &mockConfig{}, db)
+
+			got, err := service.GetTaskHistory(ctx, &agentapi.TaskHistoryRequest{WslName: tc.distro})
+			if tc.wantErr {
+				require.Error(t, err, "GetTaskHistory should return an error")
+				return
+			}
+			require.NoError(t, err, "GetTaskHistory should return no errors")
+
+			require.Len(t, got.GetEntries(), len(tc.want), "GetTaskHistory should return all history entries")
+			for i := range tc.want {
+				require.True(t, proto.Equal(tc.want[i], got.GetEntries()[i]), "Mismatch in history entry #%d. Want: %v. Got: %v", i, tc.want[i], got.GetEntries()[i])
+			}
+		})
+	}
+}
+
+// history serializes the history entries as the worker stores them.
+func history(t *testing.T, entries ...worker.HistoryEntry) string {
+	t.Helper()
+
+	out, err := yaml.Marshal(entries)
+	require.NoError(t, err, "Setup: could not marshal task history")
+	return string(out)
+}
+
+func TestCancelQueuedTask(t *testing.T) {
+	ctx := context.Background()
+	if wsl.MockAvailable() {
+		t.Parallel()
+		ctx = wsl.WithMock(ctx, wslmock.New())
+	}
+
+	testCases := map[string]struct {
+		unknownDistro bool
+		unknownTask   bool
+
+		wantErr bool
+	}{
+		"Success cancelling a queued task": {},
+
+		"Error when the distro is not in the database": {unknownDistro: true, wantErr: true},
+		"Error when the task is not queued":            {unknownTask: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if wsl.MockAvailable() {
+				t.Parallel()
+			}
+
+			db, err := database.New(ctx, t.TempDir())
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			distroName, _ := wsltestutils.RegisterDistro(t, ctx, false)
+			d, err := db.GetDistroAndUpdateProperties(ctx, distroName, distro.Properties{})
+			require.NoError(t, err, "Setup: could not add %q to the database", distroName)
+			// Stop the worker right away so that the tasks remain queued.
+			d.Cleanup(ctx)
+
+			service := ui.New(ctx, &mockConfig{}, db)
+
+			_, err = service.ApplySecurityUpdates(ctx, &agentapi.SecurityUpdateRequest{WslNames: []string{distroName}})
+			require.NoError(t, err, "Setup: ApplySecurityUpdates should return no errors")
+
+			queued, err := service.GetQueuedTasks(ctx, &agentapi.TaskHistoryRequest{WslName: distroName})
+			require.NoError(t, err, "GetQueuedTasks should return no errors")
+			require.Len(t, queued.GetTasks(), 1, "GetQueuedTasks should return the queued task")
+
+			got := queued.GetTasks()[0]
+			require.Equal(t, distroName, got.GetWslName(), "GetQueuedTasks should return the distro of the task")
+			require.Equal(t, "security-update", got.GetType(), "GetQueuedTasks should return the type ID of the task")
+			require.NotEmpty(t, got.GetId(), "GetQueuedTasks should return the ID of the task")
+			require.False(t, got.GetRunning(), "GetQueuedTasks should not report a stopped distro's task as running")
+
+			all, err := service.GetQueuedTasks(ctx, &agentapi.TaskHistoryRequest{})
+			require.NoError(t, err, "GetQueuedTasks should return no errors")
+			require.Len(t, all.GetTasks(), 1, "GetQueuedTasks should return the tasks of all distros")
+
+			req := &agentapi.CancelTaskRequest{WslName: distroName, Id: got.GetId()}
+			if tc.unknownDistro {
+				req.WslName = wsltestutils.RandomDistroName(t)
+			}
+			if tc.unknownTask {
+				req.Id = "not-a-queued-task"
+			}
+
+			_, err = service.CancelTask(ctx, req)
+			if tc.wantErr {
+				require.Error(t, err, "CancelTask should return an error")
+				return
+			}
+			require.NoError(t, err, "CancelTask should return no errors")
+
+			queued, err = service.GetQueuedTasks(ctx, &agentapi.TaskHistoryRequest{WslName: distroName})
+			require.NoError(t, err, "GetQueuedTasks should return no errors")
+			require.Empty(t, queued.GetTasks(), "The cancelled task should no longer be queued")
+		})
+	}
+
+	t.Run("Error when getting the tasks of a distro not in the database", func(t *testing.T) {
+		db, err := database.New(ctx, t.TempDir())
+		require.NoError(t, err, "Setup: empty database New() should return no error")
+		defer db.Close(ctx)
+
+		service := ui.New(ctx, &mockConfig{}, db)
+		_, err = service.GetQueuedTasks(ctx, &agentapi.TaskHistoryRequest{WslName: wsltestutils.RandomDistroName(t)})
+		require.Error(t, err, "GetQueuedTasks should return an error")
+	})
+}
+
+func TestGetStartMetrics(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	db, err := database.New(ctx, t.TempDir())
+	require.NoError(t, err, "Setup: empty database New() should return no error")
+	defer db.Close(ctx)
+
+	db.SetMaxConcurrentStarts(3)
+
+	service := ui.New(ctx, &mockConfig{}, db)
+
+	got, err := service.GetStartMetrics(ctx, &agentapi.Empty{})
+	require.NoError(t, err, "GetStartMetrics should return no errors")
+
+	want := &agentapi.StartMetrics{Parallelism: 3}
+	require.True(t, proto.Equal(want, got), "Mismatch in start metrics. Want: %v. Got: %v", want, got)
+}
+
 func TestNotifyPurchase(t *testing.T) {
 	t.Parallel()
 
@@ -384,9 +1075,10 @@ type mockConfig struct {
 	setUserLandscapeConfigErr bool // Config errors out in SetUserLandscapeConfig function
 	landscapeErr              bool // Config errors out in LandscapeClientConfig function
 
-	token           string        // stores the configured Pro token
-	proSource       config.Source // stores the configured subscription source.
-	landscapeSource config.Source // stores the configured landscape source.
+	token           string                     // stores the configured Pro token
+	proSource       config.Source              // stores the configured subscription source.
+	contracts       map[string]config.Contract // stores the contracts of the distros with their own token.
+	landscapeSource config.Source              // stores the configured landscape source.
 
 	landscapeListener func() // stores the function that will be called as a Landscape connection notification.
 
@@ -443,6 +1135,21 @@ func (m mockConfig) Subscription() (string, config.Source, error) {
 	return m.token, m.proSource, nil
 }
 
+func (m mockConfig) ContractFor(distroName string, labels ...string) (config.Contract, error) {
+	if m.subscriptionErr {
+		return config.Contract{}, errors.New("ContractFor error")
+	}
+	if c, ok := m.contracts[distroName]; ok {
+		return c, nil
+	}
+	for _, l := range labels {
+		if c, ok := m.contracts["label:"+l]; ok {
+			return c, nil
+		}
+	}
+	return config.Contract{Token: m.token, Source: m.proSource}, nil
+}
+
 func (m mockConfig) LandscapeClientConfig() (string, config.Source, error) {
 	if m.landscapeErr {
 		return "", config.SourceNone, errors.New("LandscapeClientConfig error")
@@ -453,6 +1160,99 @@ func (m mockConfig) LandscapeClientConfig() (string, config.Source, error) {
 	return "[host]", m.landscapeSource, nil
 }
 
+func (m mockConfig) Proxy() (config.Proxy, error) {
+	return config.Proxy{}, nil
+}
+
+func TestExportPackageInventory(t *testing.T) {
+	t.Parallel()
+
+	before := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	after := before.Add(24 * time.Hour)
+
+	previous := task.PackageInventory{Collected: before, Packages: []task.Package{
+		{Name: "curl", Version: "8.5.0-2ubuntu10.1", Architecture: "amd64", Origin: "noble-updates"},
+		{Name: "libc6", Version: "2.39-0ubuntu8", Architecture: "amd64", Origin: "noble"},
+		{Name: "libc6", Version: "2.39-0ubuntu8", Architecture: "i386", Origin: "noble"},
+		{Name: "telnet", Version: "0.17+2.5-3ubuntu4", Architecture: "amd64", Origin: "noble"},
+	}}
+	current := task.PackageInventory{Collected: after, Packages: []task.Package{
+		{Name: "curl", Version: "8.5.0-2ubuntu10.6", Architecture: "amd64", Origin: "noble-infra-security", ESM: true},
+		{Name: "hello", Version: "2.10-3build1", Architecture: "amd64", Origin: "noble"},
+		{Name: "libc6", Version: "2.39-0ubuntu8", Architecture: "amd64", Origin: "noble"},
+		{Name: "libc6", Version: "2.39-0ubuntu8", Architecture: "i386", Origin: "noble"},
+		{Name: "mytool", Version: "1.0", Architecture: "amd64"},
+	}}
+
+	testCases := map[string]struct {
+		stored map[string]string
+		distro string
+		format string
+		diff   bool
+
+		wantErr bool
+	}{
+		"Success with no inventories":                      {},
+		"Success exporting JSON by default":                {stored: map[string]string{"Ubuntu": inventories(t, current, &previous), "Ubuntu-24.04": inventories(t, previous, nil)}},
+		"Success exporting CSV":                            {stored: map[string]string{"Ubuntu": inventories(t, current, &previous), "Ubuntu-24.04": inventories(t, previous, nil)}, format: "csv"},
+		"Success exporting the requested distro":           {stored: map[string]string{"Ubuntu": inventories(t, current, &previous), "Ubuntu-24.04": inventories(t, previous, nil)}, distro: "Ubuntu-24.04", format: "csv"},
+		"Success exporting the diff as JSON":               {stored: map[string]string{"Ubuntu": inventories(t, current, &previous), "Ubuntu-24.04": inventories(t, previous, nil)}, diff: true},
+		"Success exporting the diff as CSV":                {stored: map[string]string{"Ubuntu": inventories(t, current, &previous), "Ubuntu-24.04": inventories(t, previous, nil)}, diff: true, format: "csv"},
+		"Success exporting the diff without previous data": {stored: map[string]string{"Ubuntu-24.04": inventories(t, previous, nil)}, diff: true, format: "csv"},
+
+		"Error when the format is unknown":          {stored: map[string]string{"Ubuntu": inventories(t, current, nil)}, format: "xml", wantErr: true},
+		"Error when the distro has no inventory":    {stored: map[string]string{"Ubuntu": inventories(t, current, nil)}, distro: "Ubuntu-24.04", wantErr: true},
+		"Error when the inventories cannot be read": {stored: map[string]string{"Ubuntu": "[this is not valid YAML"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+
+			dir := t.TempDir()
+
+			s, err := storage.Open(ctx, dir)
+			require.NoError(t, err, "Setup: could not open the storage")
+			err = s.Update(func(tx storage.Tx) error {
+				for distroName, inv := range tc.stored {
+					if err := tx.Put("inventory", distroName, []byte(inv)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			require.NoError(t, err, "Setup: could not store the package inventories")
+			require.NoError(t, s.Close(), "Setup: could not close the storage")
+
+			db, err := database.New(ctx, dir)
+			require.NoError(t, err, "Setup: empty database New() should return no error")
+			defer db.Close(ctx)
+
+			service := ui.New(ctx, &mockConfig{}, db)
+
+			got, err := service.ExportPackageInventory(ctx, &agentapi.InventoryRequest{WslName: tc.distro, Format: tc.format, Diff: tc.diff})
+			if tc.wantErr {
+				require.Error(t, err, "ExportPackageInventory should return an error")
+				return
+			}
+			require.NoError(t, err, "ExportPackageInventory should return no errors")
+
+			want := testutils.LoadWithUpdateFromGolden(t, string(got.GetData()))
+			require.Equal(t, want, string(got.GetData()), "Mismatch in the exported package inventory")
+		})
+	}
+}
+
+// inventories serializes the package inventories as the worker stores them.
+func inventories(t *testing.T, current task.PackageInventory, previous *task.PackageInventory) string {
+	t.Helper()
+
+	out, err := yaml.Marshal(worker.Inventories{Current: current, Previous: previous})
+	require.NoError(t, err, "Setup: could not marshal package inventories")
+	return string(out)
+}
+
 //nolint:revive // Testing t comes before the context.
 func setupMockContracts(t *testing.T, ctx context.Context) (opts []contracts.Option, stop func()) {
 	t.Helper()
@@ -0,0 +1,128 @@
+package wslinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/ubuntu/decorate"
+	"google.golang.org/protobuf/proto"
+)
+
+// cancelGracePeriod is how long the distro has to reply to a cancelled command. Past that, the
+// connection is dropped, as its streams can no longer be trusted to be in sync.
+var cancelGracePeriod = 30 * time.Second
+
+// CancelCommands serves the homonymous stream.
+func (s *Service) CancelCommands(stream agentapi.WSLInstance_CancelCommandsServer) (err error) {
+	defer decorate.OnError(&err, "WslInstance: could not handle cancel commands")
+	ctx := stream.Context()
+
+	client, err := commandHandshake(ctx, s, stream.Recv)
+	if err != nil {
+		return err
+	}
+	if err := client.SetCancelStream(stream); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.WaitReady(ctx); err != nil {
+		return err
+	}
+
+	// Block until the connection drops
+	client.WaitDone(ctx)
+	return nil
+}
+
+// SetCancelStream sets the cancel stream for the client.
+// This step is necessary for WaitReady to return.
+func (c *client) SetCancelStream(stream agentapi.WSLInstance_CancelCommandsServer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cancelStream != nil {
+		return errors.New("stream already connected")
+	}
+
+	c.cancelStream = stream
+	close(c.cancelReady)
+	return nil
+}
+
+// sendCancel asks the client to cancel the command it is running, and waits for it to acknowledge.
+// It must be called with the read lock held.
+func (c *client) sendCancel(command string) error {
+	c.cancelMu.Lock()
+	defer c.cancelMu.Unlock()
+
+	if c.cancelStream == nil {
+		return errors.New("no cancel stream")
+	}
+
+	if err := c.cancelStream.Send(&agentapi.CancelCmd{Command: command}); err != nil {
+		return fmt.Errorf("could not send cancellation: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, cancelGracePeriod)
+	defer cancel()
+
+	msg, err := recvContext(ctx, c.cancelStream.Recv)
+	if err != nil {
+		return fmt.Errorf("could not receive cancellation result: %v", err)
+	}
+
+	ok, err := msgToError(msg)
+	if !ok {
+		return fmt.Errorf("did not receive cancellation result: %v", err)
+	}
+	return err
+}
+
+// recvResult waits for the reply to a command of type CommandT sent to the client. If ctx is cancelled
+// first, the client is asked to cancel that command, whose reply is still awaited so that the stream
+// stays in sync. It must be called with the read lock held.
+func recvResult[CommandT proto.Message, MessageT any](ctx context.Context, c *client, recv func() (*MessageT, error)) (*MessageT, error) {
+	type tuple struct {
+		msg *MessageT
+		err error
+	}
+
+	ch := make(chan tuple, 1)
+	go func() {
+		m, err := recv()
+		ch <- tuple{m, err}
+	}()
+
+	select {
+	case <-c.ctx.Done():
+		return nil, c.ctx.Err()
+	case m := <-ch:
+		return m.msg, m.err
+	case <-ctx.Done():
+	}
+
+	var cmd CommandT
+	command := string(cmd.ProtoReflect().Descriptor().Name())
+
+	log.Infof(ctx, "Distro %q: cancelling running %s: %v", c.name, command, context.Cause(ctx))
+	if err := c.sendCancel(command); err != nil {
+		log.Warningf(ctx, "Distro %q: %v", c.name, err)
+	}
+
+	timer := time.NewTimer(cancelGracePeriod)
+	defer timer.Stop()
+
+	select {
+	case <-c.ctx.Done():
+		return nil, c.ctx.Err()
+	case m := <-ch:
+		return m.msg, m.err
+	case <-timer.C:
+		return nil, errors.New("no reply to the cancelled command")
+	}
+}
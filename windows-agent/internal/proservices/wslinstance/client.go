@@ -3,6 +3,7 @@ package wslinstance
 import (
 	"context"
 	"errors"
+	"slices"
 	"sync"
 	"time"
 
@@ -19,12 +20,35 @@ type client struct {
 	connStream agentapi.WSLInstance_ConnectedServer
 	connReady  chan struct{}
 
+	// capabilities are the full method names of the optional streams that the distro announced it opens.
+	capabilities []string
+
 	proStream agentapi.WSLInstance_ProAttachmentCommandsServer
 	proReady  chan struct{}
 
 	lpeStream agentapi.WSLInstance_LandscapeConfigCommandsServer
 	lpeReady  chan struct{}
 
+	proxyStream agentapi.WSLInstance_ProxyConfigCommandsServer
+	proxyReady  chan struct{}
+
+	runStream agentapi.WSLInstance_RunCommandCommandsServer
+	runReady  chan struct{}
+
+	svcStream agentapi.WSLInstance_ProServicesCommandsServer
+	svcReady  chan struct{}
+
+	secStream agentapi.WSLInstance_SecurityUpdateCommandsServer
+	secReady  chan struct{}
+
+	invStream agentapi.WSLInstance_PackageInventoryCommandsServer
+	invReady  chan struct{}
+
+	cancelStream agentapi.WSLInstance_CancelCommandsServer
+	cancelReady  chan struct{}
+	// cancelMu prevents concurrent cancellations from mixing up their replies.
+	cancelMu sync.Mutex
+
 	mu sync.RWMutex
 }
 
@@ -46,22 +70,31 @@ func (s *Service) client(ctx context.Context, name string) *client {
 		cancel:  cancel,
 		service: s,
 
-		connReady: make(chan struct{}),
-		proReady:  make(chan struct{}),
-		lpeReady:  make(chan struct{}),
+		connReady:  make(chan struct{}),
+		proReady:   make(chan struct{}),
+		lpeReady:   make(chan struct{}),
+		proxyReady: make(chan struct{}),
+		runReady:   make(chan struct{}),
+		svcReady:   make(chan struct{}),
+		secReady:   make(chan struct{}),
+		invReady:   make(chan struct{}),
+
+		cancelReady: make(chan struct{}),
 	}
 
 	s.clients[name] = c
 	return c
 }
 
-// WaitReady waits for all three streams to be connected.
+// WaitReady waits for the streams to be connected: the base ones, which every version of the
+// WSL-Pro-Service opens, and the optional ones that the distro announced in its capabilities.
 func (c *client) WaitReady(ctx context.Context) (err error) {
 	defer decorate.OnError(&err, "could not wait for all streams to connect")
 
-	for _, ready := range []chan struct{}{c.connReady, c.proReady, c.lpeReady} {
+	wait := func(ready chan struct{}) error {
 		select {
 		case <-ready:
+			return nil
 		case <-c.ctx.Done():
 			return errors.New("client closed")
 		case <-ctx.Done():
@@ -71,9 +104,50 @@ func (c *client) WaitReady(ctx context.Context) (err error) {
 		}
 	}
 
+	// The capabilities are only known once the Connected stream is ready.
+	if err := wait(c.connReady); err != nil {
+		return err
+	}
+
+	for _, ready := range c.expectedStreams() {
+		if err := wait(ready); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// expectedStreams returns the ready channels of the streams that the distro is expected to open.
+// Optional streams that it did not announce are left out: older versions of the WSL-Pro-Service
+// do not know about them, so the commands that need them are unsupported by the distro.
+func (c *client) expectedStreams() []chan struct{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	expected := []chan struct{}{c.proReady, c.lpeReady}
+
+	optional := []struct {
+		method string
+		ready  chan struct{}
+	}{
+		{agentapi.WSLInstance_ProxyConfigCommands_FullMethodName, c.proxyReady},
+		{agentapi.WSLInstance_RunCommandCommands_FullMethodName, c.runReady},
+		{agentapi.WSLInstance_ProServicesCommands_FullMethodName, c.svcReady},
+		{agentapi.WSLInstance_SecurityUpdateCommands_FullMethodName, c.secReady},
+		{agentapi.WSLInstance_PackageInventoryCommands_FullMethodName, c.invReady},
+		{agentapi.WSLInstance_CancelCommands_FullMethodName, c.cancelReady},
+	}
+
+	for _, s := range optional {
+		if slices.Contains(c.capabilities, s.method) {
+			expected = append(expected, s.ready)
+		}
+	}
+
+	return expected
+}
+
 // WaitDone waits for the client to be closed.
 func (c *client) WaitDone(ctx context.Context) {
 	select {
@@ -112,9 +186,9 @@ func msgToError(message *agentapi.MSG) (bool, error) {
 	return true, nil
 }
 
-// SetConnectedStream sets the Connected stream for the client.
-// This step is necessary for WaitReady to return.
-func (c *client) SetConnectedStream(stream agentapi.WSLInstance_ConnectedServer) error {
+// SetConnectedStream sets the Connected stream for the client, along with the capabilities
+// the distro announced in its handshake. This step is necessary for WaitReady to return.
+func (c *client) SetConnectedStream(stream agentapi.WSLInstance_ConnectedServer, capabilities []string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -123,6 +197,7 @@ func (c *client) SetConnectedStream(stream agentapi.WSLInstance_ConnectedServer)
 	}
 
 	c.connStream = stream
+	c.capabilities = capabilities
 	close(c.connReady)
 	return nil
 }
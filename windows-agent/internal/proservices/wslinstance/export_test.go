@@ -1,3 +1,7 @@
 package wslinstance
 
 var PropsFromInfo = propsFromInfo
+
+var DecodeInventory = decodeInventory
+
+const MaxInventorySize = maxInventorySize
@@ -1,6 +1,7 @@
 package wslinstance
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -51,7 +52,7 @@ func (c *client) SetLandscapeConfigStream(stream agentapi.WSLInstance_LandscapeC
 // Do not use before the client is ready.
 //
 //nolint:dupl // The structure of this function is similar, but the contents are not identical, between tasks.
-func (c *client) SendLandscapeConfig(config string) error {
+func (c *client) SendLandscapeConfig(ctx context.Context, config string) error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -74,7 +75,7 @@ func (c *client) SendLandscapeConfig(config string) error {
 		return errors.New("could not send landscape config: disconnected")
 	}
 
-	result, err := recvContext(c.ctx, c.lpeStream.Recv)
+	result, err := recvResult[*agentapi.LandscapeConfigCmd](ctx, c, c.lpeStream.Recv)
 	if err != nil {
 		c.Close()
 		log.Warningf(c.lpeStream.Context(), "LandscapeConfig stream could not receive: %v", err)
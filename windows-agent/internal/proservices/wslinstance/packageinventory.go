@@ -0,0 +1,135 @@
+package wslinstance
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/ubuntu/decorate"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxInventorySize is the maximum size of a decompressed package inventory.
+const maxInventorySize = 64 * 1024 * 1024
+
+// PackageInventoryCommands serves the homonymous stream.
+func (s *Service) PackageInventoryCommands(stream agentapi.WSLInstance_PackageInventoryCommandsServer) (err error) {
+	defer decorate.OnError(&err, "WslInstance: could not handle package inventory commands")
+	ctx := stream.Context()
+
+	client, err := commandHandshake(ctx, s, stream.Recv)
+	if err != nil {
+		return err
+	}
+	if err := client.SetPackageInventoryStream(stream); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.WaitReady(ctx); err != nil {
+		return err
+	}
+
+	// Block until the connection drops
+	client.WaitDone(ctx)
+	return nil
+}
+
+// SetPackageInventoryStream sets the package inventory stream for the client.
+// This step is necessary for WaitReady to return.
+func (c *client) SetPackageInventoryStream(stream agentapi.WSLInstance_PackageInventoryCommandsServer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.invStream != nil {
+		return errors.New("stream already connected")
+	}
+
+	c.invStream = stream
+	close(c.invReady)
+	return nil
+}
+
+// SendPackageInventory asks the client for the list of its installed packages.
+// Do not use before the client is ready.
+func (c *client) SendPackageInventory(ctx context.Context) ([]task.Package, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	select {
+	case <-c.ctx.Done():
+		return nil, errors.New("client closed")
+	default:
+	}
+
+	if c.invStream == nil {
+		return nil, fmt.Errorf("no package inventory stream: %w", task.ErrUnsupported)
+	}
+
+	if err := c.invStream.Send(&agentapi.PackageInventoryCmd{}); err != nil {
+		c.Close()
+		log.Warningf(c.invStream.Context(), "PackageInventory stream could not send: %v", err)
+		return nil, errors.New("could not send package inventory request: disconnected")
+	}
+
+	result, err := recvResult[*agentapi.PackageInventoryCmd](ctx, c, c.invStream.Recv)
+	if err != nil {
+		c.Close()
+		log.Warningf(c.invStream.Context(), "PackageInventory stream could not receive: %v", err)
+		return nil, errors.New("could not receive package inventory: disconnected")
+	}
+
+	if out := result.GetPackageInventory(); out != nil {
+		return decodeInventory(out.GetPackages())
+	}
+
+	ok, err := msgToError(result)
+	if !ok {
+		return nil, fmt.Errorf("did not receive package inventory: %v", err)
+	} else if err == nil {
+		return nil, errors.New("did not receive package inventory: empty result")
+	}
+	return nil, err
+}
+
+// decodeInventory decompresses and parses the package list sent by the client.
+func decodeInventory(compressed []byte) (packages []task.Package, err error) {
+	defer decorate.OnError(&err, "could not decode package inventory")
+
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(io.LimitReader(r, maxInventorySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxInventorySize {
+		return nil, fmt.Errorf("inventory exceeds %d bytes", maxInventorySize)
+	}
+
+	var list agentapi.PackageList
+	if err := proto.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	packages = make([]task.Package, 0, len(list.GetPackages()))
+	for _, p := range list.GetPackages() {
+		packages = append(packages, task.Package{
+			Name:         p.GetName(),
+			Version:      p.GetVersion(),
+			Architecture: p.GetArchitecture(),
+			Origin:       p.GetOrigin(),
+			ESM:          p.GetEsm(),
+		})
+	}
+	return packages, nil
+}
@@ -1,6 +1,7 @@
 package wslinstance
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -36,7 +37,7 @@ func (s *Service) ProAttachmentCommands(stream agentapi.WSLInstance_ProAttachmen
 // Do not use before the client is ready.
 //
 //nolint:dupl // The structure of this function is similar, but the contents are not identical, between tasks.
-func (c *client) SendProAttachment(proToken string) error {
+func (c *client) SendProAttachment(ctx context.Context, proToken string) error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -59,7 +60,7 @@ func (c *client) SendProAttachment(proToken string) error {
 		return errors.New("could not send pro attachment: disconnected")
 	}
 
-	msg, err := recvContext(c.ctx, c.proStream.Recv)
+	msg, err := recvResult[*agentapi.ProAttachCmd](ctx, c, c.proStream.Recv)
 	if err != nil {
 		c.Close()
 		log.Warningf(c.proStream.Context(), "ProAttachmentCommands stream could not receive: %v", err)
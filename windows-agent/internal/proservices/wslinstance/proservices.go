@@ -0,0 +1,100 @@
+package wslinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/ubuntu/decorate"
+)
+
+// ProServicesCommands serves the homonymous stream.
+func (s *Service) ProServicesCommands(stream agentapi.WSLInstance_ProServicesCommandsServer) (err error) {
+	defer decorate.OnError(&err, "WslInstance: could not handle Pro services commands")
+	ctx := stream.Context()
+
+	client, err := commandHandshake(ctx, s, stream.Recv)
+	if err != nil {
+		return err
+	}
+	if err := client.SetProServicesStream(stream); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.WaitReady(ctx); err != nil {
+		return err
+	}
+
+	// Block until the connection drops
+	client.WaitDone(ctx)
+	return nil
+}
+
+// SetProServicesStream sets the Pro services stream for the client.
+// This step is necessary for WaitReady to return.
+func (c *client) SetProServicesStream(stream agentapi.WSLInstance_ProServicesCommandsServer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.svcStream != nil {
+		return errors.New("stream already connected")
+	}
+
+	c.svcStream = stream
+	close(c.svcReady)
+	return nil
+}
+
+// SendProServices sends the Ubuntu Pro services to enable and disable to the client, and returns
+// the outcome for each of them. Do not use before the client is ready.
+func (c *client) SendProServices(ctx context.Context, enable, disable []string) ([]task.ServiceResult, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	select {
+	case <-c.ctx.Done():
+		return nil, errors.New("client closed")
+	default:
+	}
+
+	if c.svcStream == nil {
+		return nil, fmt.Errorf("no Pro services stream: %w", task.ErrUnsupported)
+	}
+
+	err := c.svcStream.Send(&agentapi.ProServicesCmd{
+		Enable:  enable,
+		Disable: disable,
+	})
+	if err != nil {
+		c.Close()
+		log.Warningf(c.svcStream.Context(), "ProServices stream could not send: %v", err)
+		return nil, errors.New("could not send Pro services: disconnected")
+	}
+
+	result, err := recvResult[*agentapi.ProServicesCmd](ctx, c, c.svcStream.Recv)
+	if err != nil {
+		c.Close()
+		log.Warningf(c.svcStream.Context(), "ProServices stream could not receive: %v", err)
+		return nil, errors.New("could not receive Pro services result: disconnected")
+	}
+
+	if out := result.GetProServices(); out != nil {
+		services := make([]task.ServiceResult, 0, len(out.GetServices()))
+		for _, svc := range out.GetServices() {
+			services = append(services, task.ServiceResult{Name: svc.GetName(), Enable: svc.GetEnable(), Error: svc.GetError()})
+		}
+		return services, nil
+	}
+
+	ok, err := msgToError(result)
+	if !ok {
+		return nil, fmt.Errorf("did not receive Pro services result: %v", err)
+	} else if err == nil {
+		return nil, errors.New("did not receive Pro services result: empty result")
+	}
+	return nil, err
+}
@@ -0,0 +1,93 @@
+package wslinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/ubuntu/decorate"
+)
+
+// ProxyConfigCommands serves the homonymous stream.
+func (s *Service) ProxyConfigCommands(stream agentapi.WSLInstance_ProxyConfigCommandsServer) (err error) {
+	defer decorate.OnError(&err, "WslInstance: could not handle proxy config commands")
+	ctx := stream.Context()
+
+	client, err := commandHandshake(ctx, s, stream.Recv)
+	if err != nil {
+		return err
+	}
+	if err := client.SetProxyConfigStream(stream); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.WaitReady(ctx); err != nil {
+		return err
+	}
+
+	// Block until the connection drops
+	client.WaitDone(ctx)
+	return nil
+}
+
+// SetProxyConfigStream sets the proxy config stream for the client.
+// This step is necessary for WaitReady to return.
+func (c *client) SetProxyConfigStream(stream agentapi.WSLInstance_ProxyConfigCommandsServer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.proxyStream != nil {
+		return errors.New("stream already connected")
+	}
+
+	c.proxyStream = stream
+	close(c.proxyReady)
+	return nil
+}
+
+// SendProxyConfig sends the proxy settings to the client. Empty settings remove the proxy.
+// Do not use before the client is ready.
+//
+//nolint:dupl // The structure of this function is similar, but the contents are not identical, between tasks.
+func (c *client) SendProxyConfig(ctx context.Context, httpProxy, httpsProxy, noProxy string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	select {
+	case <-c.ctx.Done():
+		return errors.New("client closed")
+	default:
+	}
+
+	if c.proxyStream == nil {
+		return fmt.Errorf("no proxy config stream: %w", task.ErrUnsupported)
+	}
+
+	err := c.proxyStream.Send(&agentapi.ProxyConfigCmd{
+		HttpProxy:  httpProxy,
+		HttpsProxy: httpsProxy,
+		NoProxy:    noProxy,
+	})
+	if err != nil {
+		c.Close()
+		log.Warningf(c.proxyStream.Context(), "ProxyConfig stream could not send: %v", err)
+		return errors.New("could not send proxy config: disconnected")
+	}
+
+	result, err := recvResult[*agentapi.ProxyConfigCmd](ctx, c, c.proxyStream.Recv)
+	if err != nil {
+		c.Close()
+		log.Warningf(c.proxyStream.Context(), "ProxyConfig stream could not receive: %v", err)
+		return errors.New("could not receive proxy config result: disconnected")
+	}
+
+	ok, err := msgToError(result)
+	if !ok {
+		return fmt.Errorf("did not receive proxy config result: %v", err)
+	}
+	return err
+}
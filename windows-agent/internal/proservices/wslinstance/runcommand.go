@@ -0,0 +1,106 @@
+package wslinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/ubuntu/decorate"
+)
+
+// RunCommandCommands serves the homonymous stream.
+func (s *Service) RunCommandCommands(stream agentapi.WSLInstance_RunCommandCommandsServer) (err error) {
+	defer decorate.OnError(&err, "WslInstance: could not handle run command commands")
+	ctx := stream.Context()
+
+	client, err := commandHandshake(ctx, s, stream.Recv)
+	if err != nil {
+		return err
+	}
+	if err := client.SetRunCommandStream(stream); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.WaitReady(ctx); err != nil {
+		return err
+	}
+
+	// Block until the connection drops
+	client.WaitDone(ctx)
+	return nil
+}
+
+// SetRunCommandStream sets the run command stream for the client.
+// This step is necessary for WaitReady to return.
+func (c *client) SetRunCommandStream(stream agentapi.WSLInstance_RunCommandCommandsServer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.runStream != nil {
+		return errors.New("stream already connected")
+	}
+
+	c.runStream = stream
+	close(c.runReady)
+	return nil
+}
+
+// SendRunCommand sends a command to the client, and returns its output once it exits.
+// Do not use before the client is ready.
+func (c *client) SendRunCommand(ctx context.Context, cmd task.Command) (task.CommandOutput, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	select {
+	case <-c.ctx.Done():
+		return task.CommandOutput{}, errors.New("client closed")
+	default:
+	}
+
+	if c.runStream == nil {
+		return task.CommandOutput{}, fmt.Errorf("no run command stream: %w", task.ErrUnsupported)
+	}
+
+	err := c.runStream.Send(&agentapi.RunCommandCmd{
+		Argv:           cmd.Argv,
+		Env:            cmd.Env,
+		WorkingDir:     cmd.WorkingDir,
+		TimeoutSeconds: int64((cmd.Timeout + time.Second - 1) / time.Second), // Rounded up, so that short timeouts are not lost.
+		User:           cmd.User,
+		Stdin:          cmd.Stdin,
+	})
+	if err != nil {
+		c.Close()
+		log.Warningf(c.runStream.Context(), "RunCommand stream could not send: %v", err)
+		return task.CommandOutput{}, errors.New("could not send command: disconnected")
+	}
+
+	result, err := recvResult[*agentapi.RunCommandCmd](ctx, c, c.runStream.Recv)
+	if err != nil {
+		c.Close()
+		log.Warningf(c.runStream.Context(), "RunCommand stream could not receive: %v", err)
+		return task.CommandOutput{}, errors.New("could not receive command output: disconnected")
+	}
+
+	if out := result.GetCommandOutput(); out != nil {
+		return task.CommandOutput{
+			ExitCode:  int(out.GetExitCode()),
+			Stdout:    out.GetStdout(),
+			Stderr:    out.GetStderr(),
+			Truncated: out.GetTruncated(),
+		}, nil
+	}
+
+	ok, err := msgToError(result)
+	if !ok {
+		return task.CommandOutput{}, fmt.Errorf("did not receive command output: %v", err)
+	} else if err == nil {
+		return task.CommandOutput{}, errors.New("did not receive command output: empty result")
+	}
+	return task.CommandOutput{}, err
+}
@@ -0,0 +1,100 @@
+package wslinstance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+	"github.com/ubuntu/decorate"
+)
+
+// SecurityUpdateCommands serves the homonymous stream.
+func (s *Service) SecurityUpdateCommands(stream agentapi.WSLInstance_SecurityUpdateCommandsServer) (err error) {
+	defer decorate.OnError(&err, "WslInstance: could not handle security update commands")
+	ctx := stream.Context()
+
+	client, err := commandHandshake(ctx, s, stream.Recv)
+	if err != nil {
+		return err
+	}
+	if err := client.SetSecurityUpdateStream(stream); err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.WaitReady(ctx); err != nil {
+		return err
+	}
+
+	// Block until the connection drops
+	client.WaitDone(ctx)
+	return nil
+}
+
+// SetSecurityUpdateStream sets the security update stream for the client.
+// This step is necessary for WaitReady to return.
+func (c *client) SetSecurityUpdateStream(stream agentapi.WSLInstance_SecurityUpdateCommandsServer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.secStream != nil {
+		return errors.New("stream already connected")
+	}
+
+	c.secStream = stream
+	close(c.secReady)
+	return nil
+}
+
+// SendSecurityUpdate asks the client to apply its pending security updates, and returns what was
+// upgraded. Do not use before the client is ready.
+func (c *client) SendSecurityUpdate(ctx context.Context) (task.SecurityUpdateReport, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	select {
+	case <-c.ctx.Done():
+		return task.SecurityUpdateReport{}, errors.New("client closed")
+	default:
+	}
+
+	if c.secStream == nil {
+		return task.SecurityUpdateReport{}, fmt.Errorf("no security update stream: %w", task.ErrUnsupported)
+	}
+
+	if err := c.secStream.Send(&agentapi.SecurityUpdateCmd{}); err != nil {
+		c.Close()
+		log.Warningf(c.secStream.Context(), "SecurityUpdate stream could not send: %v", err)
+		return task.SecurityUpdateReport{}, errors.New("could not send security update: disconnected")
+	}
+
+	result, err := recvResult[*agentapi.SecurityUpdateCmd](ctx, c, c.secStream.Recv)
+	if err != nil {
+		c.Close()
+		log.Warningf(c.secStream.Context(), "SecurityUpdate stream could not receive: %v", err)
+		return task.SecurityUpdateReport{}, errors.New("could not receive security update result: disconnected")
+	}
+
+	if out := result.GetSecurityUpdate(); out != nil {
+		report := task.SecurityUpdateReport{RebootRequired: out.GetRebootRequired()}
+		for _, p := range out.GetPackages() {
+			report.Packages = append(report.Packages, task.UpgradedPackage{
+				Name:       p.GetName(),
+				OldVersion: p.GetOldVersion(),
+				NewVersion: p.GetNewVersion(),
+			})
+		}
+		return report, nil
+	}
+
+	ok, err := msgToError(result)
+	if !ok {
+		return task.SecurityUpdateReport{}, fmt.Errorf("did not receive security update result: %v", err)
+	} else if err == nil {
+		return task.SecurityUpdateReport{}, errors.New("did not receive security update result: empty result")
+	}
+	return task.SecurityUpdateReport{}, err
+}
@@ -59,7 +59,7 @@ func (s *Service) Connected(stream agentapi.WSLInstance_ConnectedServer) (err er
 		return err
 	}
 
-	if err := client.SetConnectedStream(stream); err != nil {
+	if err := client.SetConnectedStream(stream, info.GetCapabilities()); err != nil {
 		return err
 	}
 	defer client.Close()
@@ -95,6 +95,8 @@ func (s *Service) Connected(stream agentapi.WSLInstance_ConnectedServer) (err er
 	//nolint:errcheck // We don't care about this error because we're cleaning up
 	defer d.SetConnection(nil)
 
+	d.RecordConnection(ctx, info.GetServiceVersion())
+
 	log.Debug(ctx, "connection to Linux-side WSL service established")
 
 	// Blocking connection for the lifetime of the WSL service.
@@ -109,10 +111,8 @@ func (s *Service) Connected(stream agentapi.WSLInstance_ConnectedServer) (err er
 			return fmt.Errorf("invalid DistroInfo: %v", err)
 		}
 
-		if d.SetProperties(props) {
-			if err := s.db.Dump(); err != nil {
-				log.Warningf(ctx, "updating properties: %v", err)
-			}
+		if err := s.db.UpdateProperties(d, props); err != nil {
+			log.Warningf(ctx, "updating properties: %v", err)
 		}
 
 		s.landscapeHostagentSendUpdatedInfo(ctx)
@@ -132,6 +132,7 @@ func propsFromInfo(info *agentapi.DistroInfo) (props distro.Properties, err erro
 		PrettyName:  info.GetPrettyName(),
 		ProAttached: info.GetProAttached(),
 		Hostname:    info.GetHostname(),
+		Labels:      info.GetLabels(),
 	}, nil
 }
 
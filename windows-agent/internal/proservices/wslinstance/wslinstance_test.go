@@ -1,10 +1,14 @@
 package wslinstance_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
 	"net"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -22,6 +26,7 @@ import (
 	wslmock "github.com/ubuntu/gowsl/mock"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestMain(m *testing.M) {
@@ -44,13 +49,21 @@ func TestServe(t *testing.T) {
 		skipConnectedHandshake bool
 		skipProHandshake       bool
 		skipLandscapeHandshake bool
-
+		skipProxyHandshake     bool
+		skipRunHandshake       bool
+		skipServicesHandshake  bool
+		skipSecurityHandshake  bool
+		skipInventoryHandshake bool
+		skipCancelHandshake    bool
+
+		baseStreamsOnly bool
 		duplicateStream bool
 
 		wantNeverInDatabase         bool
 		wantConnectionNeverAttached bool
 	}{
 		"Success": {},
+		"Success with a service that only opens the base streams": {baseStreamsOnly: true},
 
 		// Partial failure: only one stream connects
 		"Error when two streams connect under the same name": {duplicateStream: true},
@@ -61,8 +74,14 @@ func TestServe(t *testing.T) {
 		"Error when Connected never performs the handshake": {skipConnectedHandshake: true, wantNeverInDatabase: true},
 
 		// Late failure: during wait for other streams
-		"Error when Pro never performs the handshake":       {skipProHandshake: true, wantConnectionNeverAttached: true},
-		"Error when Landscape never performs the handshake": {skipLandscapeHandshake: true, wantConnectionNeverAttached: true},
+		"Error when Pro never performs the handshake":              {skipProHandshake: true, wantConnectionNeverAttached: true},
+		"Error when Landscape never performs the handshake":        {skipLandscapeHandshake: true, wantConnectionNeverAttached: true},
+		"Error when Proxy never performs the handshake":            {skipProxyHandshake: true, wantConnectionNeverAttached: true},
+		"Error when RunCommand never performs the handshake":       {skipRunHandshake: true, wantConnectionNeverAttached: true},
+		"Error when ProServices never performs the handshake":      {skipServicesHandshake: true, wantConnectionNeverAttached: true},
+		"Error when SecurityUpdate never performs the handshake":   {skipSecurityHandshake: true, wantConnectionNeverAttached: true},
+		"Error when PackageInventory never performs the handshake": {skipInventoryHandshake: true, wantConnectionNeverAttached: true},
+		"Error when Cancel never performs the handshake":           {skipCancelHandshake: true, wantConnectionNeverAttached: true},
 	}
 
 	for name, tc := range testCases {
@@ -117,6 +136,14 @@ func TestServe(t *testing.T) {
 				noHandshakeConnected:         tc.skipConnectedHandshake,
 				noHandshakeProCommands:       tc.skipProHandshake,
 				noHandshakeLandscapeCommands: tc.skipLandscapeHandshake,
+				noHandshakeProxyCommands:     tc.skipProxyHandshake,
+				noHandshakeRunCommands:       tc.skipRunHandshake,
+				noHandshakeServicesCommands:  tc.skipServicesHandshake,
+				noHandshakeSecurityCommands:  tc.skipSecurityHandshake,
+				noHandshakeInventoryCommands: tc.skipInventoryHandshake,
+				noHandshakeCancelCommands:    tc.skipCancelHandshake,
+
+				baseStreamsOnly: tc.baseStreamsOnly,
 			})
 			defer wps.Stop()
 
@@ -171,6 +198,28 @@ func TestServe(t *testing.T) {
 				return conn != nil
 			}, timeout, time.Second, "Distro never got assigned a connection")
 
+			require.Eventually(t, func() bool {
+				d, _ := db.Get(distroName)
+				return !d.Records().LastConnected.IsZero()
+			}, timeout, time.Second, "Distro connection was never recorded")
+
+			d, _ := db.Get(distroName)
+			require.Equal(t, "TEST_SERVICE_VERSION", d.Records().ServiceVersion, "Mismatch between sent and recorded service version")
+
+			if tc.baseStreamsOnly {
+				conn, err := d.Connection()
+				require.NoError(t, err, "Connection should return no error")
+
+				err = conn.SendProxyConfig(ctx, "http://proxy:3128", "", "")
+				require.ErrorIs(t, err, task.ErrUnsupported, "Commands through optional streams should be unsupported by older services")
+
+				_, err = conn.SendRunCommand(ctx, task.Command{Argv: []string{"echo"}})
+				require.ErrorIs(t, err, task.ErrUnsupported, "Commands through optional streams should be unsupported by older services")
+
+				err = conn.SendProAttachment(ctx, "hello123")
+				require.NoError(t, err, "Commands through the base streams should be supported by older services")
+			}
+
 			wps.sendInfo(t, &agentapi.DistroInfo{
 				WslName:     distroName,
 				Id:          "TEST_ID",
@@ -178,19 +227,22 @@ func TestServe(t *testing.T) {
 				PrettyName:  "TEST_PRETTY_NAME",
 				ProAttached: true,
 				Hostname:    "TEST_HOSTNAME",
+				Labels:      []string{"CI", "sandbox"},
 			})
 
 			require.Eventually(t, func() bool {
 				return landscape.updateCount.Load() > 1
 			}, 10*time.Second, time.Second, "Landscape was never notified after sending info")
 
-			d, _ := db.Get(distroName)
+			d, _ = db.Get(distroName)
 			props := d.Properties()
 			require.Equal(t, "TEST_ID", props.DistroID, "Mismatch between sent and stored properties")
 			require.Equal(t, "TEST_VERSION_ID", props.VersionID, "Mismatch between sent and stored properties")
 			require.Equal(t, "TEST_PRETTY_NAME", props.PrettyName, "Mismatch between sent and stored properties")
 			require.True(t, props.ProAttached, "Mismatch between sent and stored properties")
 			require.Equal(t, "TEST_HOSTNAME", props.Hostname, "Mismatch between sent and stored properties")
+			require.Equal(t, []string{"ci", "sandbox"}, props.Labels, "Mismatch between sent and stored properties")
+			require.True(t, d.HasLabels("ci", "sandbox"), "Labels declared from inside the distro should apply to it")
 		})
 	}
 }
@@ -258,25 +310,142 @@ func TestSendCommands(t *testing.T) {
 	require.NoError(t, err, "distro.Connection should return no error")
 	require.NotNil(t, conn, "Connection should not have been nil")
 
-	err = conn.SendProAttachment("hello123")
+	err = conn.SendProAttachment(ctx, "hello123")
 	require.NoError(t, err, "SendProAttachment should return no error")
 
-	err = conn.SendProAttachment("MOCK_ERROR")
+	err = conn.SendProAttachment(ctx, "MOCK_ERROR")
 	require.Error(t, err, "SendProAttachment should have returned an error")
 
-	err = conn.SendLandscapeConfig("hello=world")
+	err = conn.SendLandscapeConfig(ctx, "hello=world")
 	require.NoError(t, err, "SendLandscapeConfig should return no error")
 
-	err = conn.SendLandscapeConfig("MOCK_ERROR")
+	err = conn.SendLandscapeConfig(ctx, "MOCK_ERROR")
 	require.Error(t, err, "SendLandscapeConfig should have returned an error")
 
+	err = conn.SendProxyConfig(ctx, "http://proxy:3128", "http://proxy:3128", "localhost")
+	require.NoError(t, err, "SendProxyConfig should return no error")
+
+	err = conn.SendProxyConfig(ctx, "", "MOCK_ERROR", "")
+	require.Error(t, err, "SendProxyConfig should have returned an error")
+
+	out, err := conn.SendRunCommand(ctx, task.Command{Argv: []string{"echo", "hello"}})
+	require.NoError(t, err, "SendRunCommand should return no error")
+	require.Equal(t, task.CommandOutput{ExitCode: 0, Stdout: []byte("hello")}, out, "SendRunCommand should return the output of the command")
+
+	out, err = conn.SendRunCommand(ctx, task.Command{Argv: []string{"false"}})
+	require.NoError(t, err, "SendRunCommand should return no error when the command exits with a non-zero code")
+	require.Equal(t, 1, out.ExitCode, "SendRunCommand should return the exit code of the command")
+
+	_, err = conn.SendRunCommand(ctx, task.Command{Argv: []string{"MOCK_ERROR"}})
+	require.Error(t, err, "SendRunCommand should have returned an error")
+
+	services, err := conn.SendProServices(ctx, []string{"esm-apps"}, []string{"usg"})
+	require.NoError(t, err, "SendProServices should return no error")
+	require.Equal(t, []task.ServiceResult{{Name: "usg"}, {Name: "esm-apps", Enable: true}}, services, "SendProServices should return the result of each service")
+
+	_, err = conn.SendProServices(ctx, []string{"MOCK_ERROR"}, nil)
+	require.Error(t, err, "SendProServices should have returned an error")
+
+	report, err := conn.SendSecurityUpdate(ctx)
+	require.NoError(t, err, "SendSecurityUpdate should return no error")
+	require.Equal(t, task.SecurityUpdateReport{
+		Packages:       []task.UpgradedPackage{{Name: "libssl3", OldVersion: "3.0.2-0ubuntu1.15", NewVersion: "3.0.2-0ubuntu1.18"}},
+		RebootRequired: true,
+	}, report, "SendSecurityUpdate should return the upgraded packages")
+
+	packages, err := conn.SendPackageInventory(ctx)
+	require.NoError(t, err, "SendPackageInventory should return no error")
+	require.Equal(t, []task.Package{
+		{Name: "libssl3", Version: "3.0.2-0ubuntu1.18", Architecture: "amd64", Origin: "jammy-security"},
+		{Name: "curl", Version: "7.81.0-1ubuntu1.20", Architecture: "amd64", Origin: "jammy-infra-security", ESM: true},
+	}, packages, "SendPackageInventory should return the installed packages")
+
+	runCtx, runCancel := context.WithCancel(ctx)
+	time.AfterFunc(500*time.Millisecond, runCancel)
+	_, err = conn.SendRunCommand(runCtx, task.Command{Argv: []string{"MOCK_BLOCK"}})
+	require.ErrorContains(t, err, "command cancelled", "SendRunCommand should return the cancellation reported by the distro")
+
+	out, err = conn.SendRunCommand(ctx, task.Command{Argv: []string{"echo", "again"}})
+	require.NoError(t, err, "SendRunCommand should return no error after a cancellation")
+	require.Equal(t, []byte("again"), out.Stdout, "Streams should stay in sync after a cancellation")
+
 	wps.Stop()
 
-	err = conn.SendProAttachment("hello123")
+	err = conn.SendProAttachment(ctx, "hello123")
 	require.Error(t, err, "SendProAttachment should return an error after disconnecting")
 
-	err = conn.SendLandscapeConfig("hello123")
+	err = conn.SendLandscapeConfig(ctx, "hello123")
 	require.Error(t, err, "SendLandscapeConfig should return an error after disconnecting")
+
+	err = conn.SendProxyConfig(ctx, "", "", "")
+	require.Error(t, err, "SendProxyConfig should return an error after disconnecting")
+
+	_, err = conn.SendRunCommand(ctx, task.Command{Argv: []string{"echo"}})
+	require.Error(t, err, "SendRunCommand should return an error after disconnecting")
+
+	_, err = conn.SendProServices(ctx, []string{"esm-apps"}, nil)
+	require.Error(t, err, "SendProServices should return an error after disconnecting")
+
+	_, err = conn.SendSecurityUpdate(ctx)
+	require.Error(t, err, "SendSecurityUpdate should return an error after disconnecting")
+
+	_, err = conn.SendPackageInventory(ctx)
+	require.Error(t, err, "SendPackageInventory should return an error after disconnecting")
+}
+
+func TestDecodeInventory(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		data []byte
+
+		want    []task.Package
+		wantErr bool
+	}{
+		"Success with an empty inventory": {data: compressInventory(t, &agentapi.PackageList{}), want: []task.Package{}},
+		"Success with packages": {
+			data: compressInventory(t, &agentapi.PackageList{Packages: []*agentapi.InstalledPackage{{Name: "hello", Version: "2.10-2ubuntu4", Architecture: "amd64"}}}),
+			want: []task.Package{{Name: "hello", Version: "2.10-2ubuntu4", Architecture: "amd64"}},
+		},
+
+		"Error when the inventory is not compressed":     {data: []byte("not gzip"), wantErr: true},
+		"Error when the inventory is not a package list": {data: gzipped(t, []byte("not a protobuf")), wantErr: true},
+		"Error when the inventory is too big":            {data: gzipped(t, make([]byte, wslinstance.MaxInventorySize+1)), wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := wslinstance.DecodeInventory(tc.data)
+			if tc.wantErr {
+				require.Error(t, err, "DecodeInventory should have returned an error")
+				return
+			}
+			require.NoError(t, err, "DecodeInventory should return no error")
+			require.Equal(t, tc.want, got, "Unexpected decoded packages")
+		})
+	}
+}
+
+// compressInventory serializes and compresses the package list as the WSL-Pro-Service does.
+func compressInventory(t *testing.T, list *agentapi.PackageList) []byte {
+	t.Helper()
+
+	data, err := proto.Marshal(list)
+	require.NoError(t, err, "Setup: could not marshal package list")
+	return gzipped(t, data)
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	require.NoError(t, err, "Setup: could not compress data")
+	require.NoError(t, w.Close(), "Setup: could not compress data")
+	return buf.Bytes()
 }
 
 // landscapeCtlMock mocks the landscape client.
@@ -308,6 +477,15 @@ type mockWSLProService struct {
 	connStream agentapi.WSLInstance_ConnectedClient
 	proStream  agentapi.WSLInstance_ProAttachmentCommandsClient
 	lpeStream  agentapi.WSLInstance_LandscapeConfigCommandsClient
+	proxStream agentapi.WSLInstance_ProxyConfigCommandsClient
+	runStream  agentapi.WSLInstance_RunCommandCommandsClient
+	svcStream  agentapi.WSLInstance_ProServicesCommandsClient
+	secStream  agentapi.WSLInstance_SecurityUpdateCommandsClient
+	invStream  agentapi.WSLInstance_PackageInventoryCommandsClient
+	canStream  agentapi.WSLInstance_CancelCommandsClient
+
+	// cancelled receives a value every time the agent asks to cancel the running commands.
+	cancelled chan struct{}
 
 	cancel  func()
 	conn    *grpc.ClientConn
@@ -321,6 +499,15 @@ type mockWslProServiceOptions struct {
 	noHandshakeConnected         bool
 	noHandshakeProCommands       bool
 	noHandshakeLandscapeCommands bool
+	noHandshakeProxyCommands     bool
+	noHandshakeRunCommands       bool
+	noHandshakeServicesCommands  bool
+	noHandshakeSecurityCommands  bool
+	noHandshakeInventoryCommands bool
+	noHandshakeCancelCommands    bool
+
+	// baseStreamsOnly mimics an older WSL-Pro-Service, which knows none of the optional streams.
+	baseStreamsOnly bool
 }
 
 // newMockWSLProService creates a wslDistroMock, establishing a connection to the control stream.
@@ -329,7 +516,7 @@ type mockWslProServiceOptions struct {
 func newMockWSLProService(t *testing.T, ctx context.Context, opt mockWslProServiceOptions) (mock *mockWSLProService) {
 	t.Helper()
 
-	mock = &mockWSLProService{}
+	mock = &mockWSLProService{cancelled: make(chan struct{}, 1)}
 
 	conn, err := grpc.NewClient(opt.address, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	require.NoError(t, err, "wslDistroMock: could not setup a control address client")
@@ -346,7 +533,18 @@ func newMockWSLProService(t *testing.T, ctx context.Context, opt mockWslProServi
 	require.NoError(t, err, "wslDistroMock: could not connect to Connected stream")
 
 	if !opt.noHandshakeConnected {
-		err = mock.connStream.Send(&agentapi.DistroInfo{WslName: opt.distroName})
+		info := &agentapi.DistroInfo{WslName: opt.distroName, ServiceVersion: "TEST_SERVICE_VERSION"}
+		if !opt.baseStreamsOnly {
+			info.Capabilities = []string{
+				agentapi.WSLInstance_ProxyConfigCommands_FullMethodName,
+				agentapi.WSLInstance_RunCommandCommands_FullMethodName,
+				agentapi.WSLInstance_ProServicesCommands_FullMethodName,
+				agentapi.WSLInstance_SecurityUpdateCommands_FullMethodName,
+				agentapi.WSLInstance_PackageInventoryCommands_FullMethodName,
+				agentapi.WSLInstance_CancelCommands_FullMethodName,
+			}
+		}
+		err = mock.connStream.Send(info)
 		require.NoError(t, err, "wslDistroMock: could not send info via Connected stream")
 	}
 
@@ -368,6 +566,60 @@ func newMockWSLProService(t *testing.T, ctx context.Context, opt mockWslProServi
 	go mock.replyProAttachmentCommands(t)
 	go mock.replyLandscapeConfigCommands(t)
 
+	if opt.baseStreamsOnly {
+		return mock
+	}
+
+	mock.proxStream, err = c.ProxyConfigCommands(ctx)
+	require.NoError(t, err, "wslDistroMock: could not connect to ProxyConfigCommands stream")
+	if !opt.noHandshakeProxyCommands {
+		err = sendWslName(mock.proxStream.Send, opt.distroName)
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via ProxyConfigCommands stream")
+	}
+
+	mock.runStream, err = c.RunCommandCommands(ctx)
+	require.NoError(t, err, "wslDistroMock: could not connect to RunCommandCommands stream")
+	if !opt.noHandshakeRunCommands {
+		err = sendWslName(mock.runStream.Send, opt.distroName)
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via RunCommandCommands stream")
+	}
+
+	mock.svcStream, err = c.ProServicesCommands(ctx)
+	require.NoError(t, err, "wslDistroMock: could not connect to ProServicesCommands stream")
+	if !opt.noHandshakeServicesCommands {
+		err = sendWslName(mock.svcStream.Send, opt.distroName)
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via ProServicesCommands stream")
+	}
+
+	mock.secStream, err = c.SecurityUpdateCommands(ctx)
+	require.NoError(t, err, "wslDistroMock: could not connect to SecurityUpdateCommands stream")
+	if !opt.noHandshakeSecurityCommands {
+		err = sendWslName(mock.secStream.Send, opt.distroName)
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via SecurityUpdateCommands stream")
+	}
+
+	mock.invStream, err = c.PackageInventoryCommands(ctx)
+	require.NoError(t, err, "wslDistroMock: could not connect to PackageInventoryCommands stream")
+	if !opt.noHandshakeInventoryCommands {
+		err = sendWslName(mock.invStream.Send, opt.distroName)
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via PackageInventoryCommands stream")
+	}
+
+	mock.canStream, err = c.CancelCommands(ctx)
+	require.NoError(t, err, "wslDistroMock: could not connect to CancelCommands stream")
+	if !opt.noHandshakeCancelCommands {
+		err = sendWslName(mock.canStream.Send, opt.distroName)
+		require.NoError(t, err, "wslDistroMock: could not send wsl name via CancelCommands stream")
+	}
+
+	mock.running.Add(6)
+	go mock.replyProxyConfigCommands(t)
+	go mock.replyRunCommandCommands(t)
+	go mock.replyProServicesCommands(t)
+	go mock.replySecurityUpdateCommands(t)
+	go mock.replyPackageInventoryCommands(t)
+	go mock.replyCancelCommands(t)
+
 	return mock
 }
 
@@ -469,6 +721,183 @@ func (m *mockWSLProService) replyLandscapeConfigCommands(t *testing.T) {
 	}
 }
 
+func (m *mockWSLProService) replyProxyConfigCommands(t *testing.T) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	for {
+		msg, err := m.proxStream.Recv()
+		if err != nil {
+			log.Warningf("%s: Could not receive proxy command: %v", t.Name(), err)
+			return
+		}
+
+		var send error
+		if msg.GetHttpsProxy() == "MOCK_ERROR" {
+			send = errors.New("mock error")
+		}
+
+		err = sendResult(m.proxStream.Send, send)
+		if err != nil {
+			log.Warningf("%s: Could not send proxy command result: %v", t.Name(), err)
+			m.Stop()
+			return
+		}
+	}
+}
+
+func (m *mockWSLProService) replyRunCommandCommands(t *testing.T) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	for {
+		msg, err := m.runStream.Recv()
+		if err != nil {
+			log.Warningf("%s: Could not receive run command: %v", t.Name(), err)
+			return
+		}
+
+		switch msg.GetArgv()[0] {
+		case "MOCK_ERROR":
+			err = sendResult(m.runStream.Send, errors.New("mock error"))
+		case "MOCK_BLOCK":
+			<-m.cancelled
+			err = sendResult(m.runStream.Send, errors.New("command cancelled"))
+		case "false":
+			err = m.runStream.Send(&agentapi.MSG{Data: &agentapi.MSG_CommandOutput{
+				CommandOutput: &agentapi.CommandOutput{ExitCode: 1},
+			}})
+		default:
+			err = m.runStream.Send(&agentapi.MSG{Data: &agentapi.MSG_CommandOutput{
+				CommandOutput: &agentapi.CommandOutput{Stdout: []byte(strings.Join(msg.GetArgv()[1:], " "))},
+			}})
+		}
+		if err != nil {
+			log.Warningf("%s: Could not send run command output: %v", t.Name(), err)
+			m.Stop()
+			return
+		}
+	}
+}
+
+func (m *mockWSLProService) replyProServicesCommands(t *testing.T) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	for {
+		msg, err := m.svcStream.Recv()
+		if err != nil {
+			log.Warningf("%s: Could not receive Pro services command: %v", t.Name(), err)
+			return
+		}
+
+		if slices.Contains(msg.GetEnable(), "MOCK_ERROR") {
+			err = sendResult(m.svcStream.Send, errors.New("mock error"))
+		} else {
+			var results []*agentapi.ProServiceResult
+			for _, name := range msg.GetDisable() {
+				results = append(results, &agentapi.ProServiceResult{Name: name})
+			}
+			for _, name := range msg.GetEnable() {
+				results = append(results, &agentapi.ProServiceResult{Name: name, Enable: true})
+			}
+			err = m.svcStream.Send(&agentapi.MSG{Data: &agentapi.MSG_ProServices{
+				ProServices: &agentapi.ProServicesResult{Services: results},
+			}})
+		}
+		if err != nil {
+			log.Warningf("%s: Could not send Pro services result: %v", t.Name(), err)
+			m.Stop()
+			return
+		}
+	}
+}
+
+func (m *mockWSLProService) replySecurityUpdateCommands(t *testing.T) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	for {
+		if _, err := m.secStream.Recv(); err != nil {
+			log.Warningf("%s: Could not receive security update command: %v", t.Name(), err)
+			return
+		}
+
+		err := m.secStream.Send(&agentapi.MSG{Data: &agentapi.MSG_SecurityUpdate{
+			SecurityUpdate: &agentapi.SecurityUpdateResult{
+				Packages:       []*agentapi.UpgradedPackage{{Name: "libssl3", OldVersion: "3.0.2-0ubuntu1.15", NewVersion: "3.0.2-0ubuntu1.18"}},
+				RebootRequired: true,
+			},
+		}})
+		if err != nil {
+			log.Warningf("%s: Could not send security update result: %v", t.Name(), err)
+			m.Stop()
+			return
+		}
+	}
+}
+
+func (m *mockWSLProService) replyPackageInventoryCommands(t *testing.T) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	inventory := compressInventory(t, &agentapi.PackageList{Packages: []*agentapi.InstalledPackage{
+		{Name: "libssl3", Version: "3.0.2-0ubuntu1.18", Architecture: "amd64", Origin: "jammy-security"},
+		{Name: "curl", Version: "7.81.0-1ubuntu1.20", Architecture: "amd64", Origin: "jammy-infra-security", Esm: true},
+	}})
+
+	for {
+		if _, err := m.invStream.Recv(); err != nil {
+			log.Warningf("%s: Could not receive package inventory command: %v", t.Name(), err)
+			return
+		}
+
+		err := m.invStream.Send(&agentapi.MSG{Data: &agentapi.MSG_PackageInventory{
+			PackageInventory: &agentapi.PackageInventory{Packages: inventory},
+		}})
+		if err != nil {
+			log.Warningf("%s: Could not send package inventory: %v", t.Name(), err)
+			m.Stop()
+			return
+		}
+	}
+}
+
+func (m *mockWSLProService) replyCancelCommands(t *testing.T) {
+	t.Helper()
+	defer m.running.Done()
+	defer m.cancel()
+
+	for {
+		cmd, err := m.canStream.Recv()
+		if err != nil {
+			log.Warningf("%s: Could not receive cancel command: %v", t.Name(), err)
+			return
+		}
+
+		// Only the command being run can be cancelled.
+		if cmd.GetCommand() != "RunCommandCmd" {
+			log.Warningf("%s: Received cancellation of unexpected command %q", t.Name(), cmd.GetCommand())
+		} else {
+			select {
+			case m.cancelled <- struct{}{}:
+			default:
+			}
+		}
+
+		if err := sendResult(m.canStream.Send, nil); err != nil {
+			log.Warningf("%s: Could not send cancel command result: %v", t.Name(), err)
+			m.Stop()
+			return
+		}
+	}
+}
+
 // sendInfo sends the specified info from the Linux-side client to the wslinstance service.
 func (m *mockWSLProService) sendInfo(t *testing.T, info *agentapi.DistroInfo) {
 	t.Helper()
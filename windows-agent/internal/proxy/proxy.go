@@ -0,0 +1,158 @@
+// Package proxy builds the network clients the agent uses to reach the outside world,
+// so that all of them honour the same proxy settings.
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/ubuntu/decorate"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Func returns a function that selects the proxy for each request, suitable for http.Transport.
+// If the settings are empty, the environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY are used instead.
+func Func(p config.Proxy) func(*http.Request) (*url.URL, error) {
+	settings := httpproxy.FromEnvironment()
+	if !p.IsZero() {
+		settings = &httpproxy.Config{
+			HTTPProxy:  p.HTTP,
+			HTTPSProxy: p.HTTPS,
+			NoProxy:    p.NoProxy,
+		}
+	}
+
+	proxyFunc := settings.ProxyFunc()
+	return func(r *http.Request) (*url.URL, error) {
+		return proxyFunc(r.URL)
+	}
+}
+
+// HTTPClient returns an HTTP client that goes through the proxy.
+func HTTPClient(p config.Proxy, timeout time.Duration) *http.Client {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		transport = &http.Transport{}
+	}
+	transport = transport.Clone()
+	transport.Proxy = Func(p)
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}
+}
+
+// Dialer returns a function to open TCP connections to addr ("host:port"), suitable for
+// grpc.WithContextDialer. If a proxy applies to addr, the connection is tunnelled through
+// the proxy with the CONNECT method.
+//
+// As with gRPC's default dialer, the HTTPS proxy is used because the connection is secured by TLS.
+func Dialer(p config.Proxy) func(ctx context.Context, addr string) (net.Conn, error) {
+	proxyFunc := Func(p)
+
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		proxyURL, err := proxyFunc(&http.Request{URL: &url.URL{Scheme: "https", Host: addr}})
+		if err != nil {
+			return nil, fmt.Errorf("could not select proxy for %q: %v", addr, err)
+		}
+
+		if proxyURL == nil {
+			var d net.Dialer
+			return d.DialContext(ctx, "tcp", addr)
+		}
+
+		return dialThroughProxy(ctx, proxyURL, addr)
+	}
+}
+
+// dialThroughProxy opens a tunnel to addr via an HTTP CONNECT request to the proxy.
+func dialThroughProxy(ctx context.Context, proxyURL *url.URL, addr string) (_ net.Conn, err error) {
+	defer decorate.OnError(&err, "could not connect to %q via proxy %q", addr, proxyURL.Redacted())
+
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+
+	var d net.Dialer
+	c, err := d.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			c.Close()
+		}
+	}()
+
+	if proxyURL.Scheme == "https" {
+		c = tls.Client(c, &tls.Config{ServerName: proxyURL.Hostname(), MinVersion: tls.VersionTLS12})
+	}
+
+	// The handshake must not outlive the context.
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+		defer func() { _ = c.SetDeadline(time.Time{}) }()
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u := proxyURL.User; u != nil {
+		password, _ := u.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(u.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(c); err != nil {
+		return nil, fmt.Errorf("could not send CONNECT request: %v", err)
+	}
+
+	r := bufio.NewReader(c)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CONNECT response: %v", err)
+	}
+
+	// The body of a successful response is the tunnel itself, so it must not be closed.
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New(resp.Status)
+	}
+
+	if r.Buffered() > 0 {
+		// The server spoke first through the tunnel: we must not lose what was already read.
+		return &bufferedConn{Conn: c, r: r}, nil
+	}
+
+	return c, nil
+}
+
+// bufferedConn is a net.Conn whose first bytes were read ahead into a buffer.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,238 @@
+package proxy_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proxy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunc(t *testing.T) {
+	t.Parallel()
+
+	settings := config.Proxy{
+		HTTP:    "http://http-proxy:3128",
+		HTTPS:   "http://https-proxy:3129",
+		NoProxy: ".internal.example.com,10.0.0.0/8",
+	}
+
+	testCases := map[string]struct {
+		url string
+
+		want string
+	}{
+		"HTTP requests use the HTTP proxy":   {url: "http://archive.ubuntu.com/ubuntu", want: "http://http-proxy:3128"},
+		"HTTPS requests use the HTTPS proxy": {url: "https://contracts.canonical.com", want: "http://https-proxy:3129"},
+
+		"No proxy for hosts in no_proxy":        {url: "https://landscape.internal.example.com"},
+		"No proxy for IP addresses in no_proxy": {url: "https://10.1.2.3:6554"},
+		"No proxy for localhost":                {url: "http://localhost:8080"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			u, err := url.Parse(tc.url)
+			require.NoError(t, err, "Setup: could not parse URL")
+
+			got, err := proxy.Func(settings)(&http.Request{URL: u})
+			require.NoError(t, err, "Func should return no error")
+
+			if tc.want == "" {
+				require.Nil(t, got, "Func should not have selected a proxy")
+				return
+			}
+			require.NotNil(t, got, "Func should have selected a proxy")
+			require.Equal(t, tc.want, got.String(), "Func selected the wrong proxy")
+		})
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	t.Parallel()
+
+	p := newMockProxy(t, "")
+
+	client := proxy.HTTPClient(config.Proxy{HTTP: p.URL}, 10*time.Second)
+
+	resp, err := client.Get("http://rootfs.example.com/jammy.tar.gz")
+	require.NoError(t, err, "Get should return no error")
+	defer resp.Body.Close()
+
+	out, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, "Could not read response body")
+
+	require.Equal(t, http.StatusOK, resp.StatusCode, "Unexpected status code")
+	require.Equal(t, "proxied GET http://rootfs.example.com/jammy.tar.gz", string(out), "Request should have gone through the proxy")
+}
+
+func TestDialer(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		noProxy     bool
+		credentials string
+		proxyDown   bool
+
+		wantProxied bool
+		wantErr     bool
+	}{
+		"Success tunnelling through the proxy":                   {wantProxied: true},
+		"Success tunnelling through a proxy with authentication": {credentials: "user:pass", wantProxied: true},
+		"Success dialing directly when the host is in no_proxy":  {noProxy: true},
+
+		"Error when the proxy rejects the credentials": {credentials: "user:wrong", wantErr: true},
+		"Error when the proxy cannot be reached":       {proxyDown: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			// A server that greets every connection.
+			target, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err, "Setup: could not listen")
+			defer target.Close()
+			go func() {
+				for {
+					conn, err := target.Accept()
+					if err != nil {
+						return
+					}
+					_, _ = conn.Write([]byte("hello"))
+					conn.Close()
+				}
+			}()
+
+			var want string
+			if tc.credentials != "" {
+				want = "user:pass"
+			}
+			p := newMockProxy(t, want)
+			p.tunnelTo = target.Addr().String()
+
+			proxyURL, err := url.Parse(p.URL)
+			require.NoError(t, err, "Setup: could not parse proxy URL")
+			if tc.credentials != "" {
+				user, pass, _ := strings.Cut(tc.credentials, ":")
+				proxyURL.User = url.UserPassword(user, pass)
+			}
+			if tc.proxyDown {
+				p.Close()
+			}
+
+			// The target must not be a loopback address, otherwise the proxy is skipped.
+			addr := "landscape.example.com:6554"
+			settings := config.Proxy{HTTPS: proxyURL.String()}
+			if tc.noProxy {
+				addr = target.Addr().String()
+				settings.NoProxy = "127.0.0.1"
+			}
+
+			conn, err := proxy.Dialer(settings)(ctx, addr)
+			if tc.wantErr {
+				require.Error(t, err, "Dialer should return an error")
+				return
+			}
+			require.NoError(t, err, "Dialer should return no error")
+			defer conn.Close()
+
+			out, err := io.ReadAll(conn)
+			require.NoError(t, err, "Could not read from the connection")
+			require.Equal(t, "hello", string(out), "Unexpected data received through the connection")
+
+			if tc.wantProxied {
+				require.Equal(t, []string{addr}, p.connects(), "Connection should have been tunnelled through the proxy")
+			} else {
+				require.Empty(t, p.connects(), "Connection should not have been tunnelled through the proxy")
+			}
+		})
+	}
+}
+
+// mockProxy is an HTTP proxy that answers plain requests itself and tunnels every CONNECT request to tunnelTo.
+type mockProxy struct {
+	*httptest.Server
+
+	credentials string
+	tunnelTo    string
+
+	mu          sync.Mutex
+	connectReqs []string
+}
+
+func newMockProxy(t *testing.T, credentials string) *mockProxy {
+	t.Helper()
+
+	p := &mockProxy{credentials: credentials}
+	p.Server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+func (p *mockProxy) connects() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.connectReqs
+}
+
+func (p *mockProxy) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		_, _ = io.WriteString(w, "proxied "+r.Method+" "+r.URL.String())
+		return
+	}
+
+	if p.credentials != "" {
+		user, pass, ok := parseProxyAuth(r)
+		if !ok || user+":"+pass != p.credentials {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+	}
+
+	p.mu.Lock()
+	p.connectReqs = append(p.connectReqs, r.Host)
+	p.mu.Unlock()
+
+	backend, err := net.Dial("tcp", p.tunnelTo)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer backend.Close()
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	client, _, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	_, _ = io.WriteString(client, "HTTP/1.1 200 Connection established\r\n\r\n")
+	_, _ = io.Copy(client, backend)
+}
+
+// parseProxyAuth parses the Proxy-Authorization header the same way Request.BasicAuth parses Authorization.
+func parseProxyAuth(r *http.Request) (string, string, bool) {
+	fake := &http.Request{Header: http.Header{"Authorization": r.Header.Values("Proxy-Authorization")}}
+	return fake.BasicAuth()
+}
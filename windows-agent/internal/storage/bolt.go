@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/consts"
+	"github.com/ubuntu/decorate"
+	bolt "go.etcd.io/bbolt"
+	bolterrors "go.etcd.io/bbolt/errors"
+)
+
+// boltDB is a bbolt database shared by all the stores opened on the same file.
+type boltDB struct {
+	*bolt.DB
+	path string
+	refs int
+}
+
+var (
+	// openBoltDBs contains the databases currently open, indexed by path. The file is locked
+	// while it is open, so all components of the agent must share the same database.
+	openBoltDBs   = make(map[string]*boltDB)
+	openBoltDBsMu sync.Mutex
+)
+
+// boltStore is a Store backed by a bbolt database.
+type boltStore struct {
+	db     *boltDB
+	closed atomic.Bool
+}
+
+// Bolt is the default Backend. It opens the store as a bbolt database in the directory.
+// Opening the same directory several times returns stores that share the same database,
+// which is closed when all of them are.
+func Bolt(dir string) (_ Store, err error) {
+	defer decorate.OnError(&err, "could not open storage in %q", dir)
+
+	path, err := filepath.Abs(filepath.Join(dir, consts.StorageFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	openBoltDBsMu.Lock()
+	defer openBoltDBsMu.Unlock()
+
+	db, ok := openBoltDBs[path]
+	if !ok {
+		bdb, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+		if err != nil {
+			return nil, err
+		}
+		db = &boltDB{DB: bdb, path: path}
+		openBoltDBs[path] = db
+	}
+	db.refs++
+
+	return &boltStore{db: db}, nil
+}
+
+// View runs fn in a read-only transaction.
+func (s *boltStore) View(fn func(Tx) error) error {
+	if s.closed.Load() {
+		return errors.New("storage is closed")
+	}
+
+	return s.db.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+// Update runs fn in a read-write transaction.
+func (s *boltStore) Update(fn func(Tx) error) error {
+	if s.closed.Load() {
+		return errors.New("storage is closed")
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+// Close releases the store, and closes the database if no other store uses it.
+func (s *boltStore) Close() error {
+	if !s.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	openBoltDBsMu.Lock()
+	defer openBoltDBsMu.Unlock()
+
+	s.db.refs--
+	if s.db.refs > 0 {
+		return nil
+	}
+
+	delete(openBoltDBs, s.db.path)
+	return s.db.Close()
+}
+
+// boltTx is a Tx backed by a bbolt transaction.
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+// Get returns a copy of the value, because bbolt values are only valid during the transaction.
+func (t boltTx) Get(bucket, key string) []byte {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+
+	return bytes.Clone(b.Get([]byte(key)))
+}
+
+func (t boltTx) Put(bucket, key string, value []byte) error {
+	b, err := t.tx.CreateBucketIfNotExists([]byte(bucket))
+	if err != nil {
+		return err
+	}
+
+	return b.Put([]byte(key), value)
+}
+
+func (t boltTx) Delete(bucket, key string) error {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+
+	return b.Delete([]byte(key))
+}
+
+func (t boltTx) DeleteBucket(bucket string) error {
+	err := t.tx.DeleteBucket([]byte(bucket))
+	if errors.Is(err, bolterrors.ErrBucketNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (t boltTx) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	b := t.tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+
+	return b.ForEach(func(k, v []byte) error {
+		return fn(string(k), bytes.Clone(v))
+	})
+}
@@ -0,0 +1,115 @@
+// Package storage implements the transactional key-value store where the agent keeps its
+// state, so that related changes across the database, the task queues and the configuration
+// can be committed atomically.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/ubuntu/decorate"
+)
+
+// Store is a transactional key-value store. Keys are grouped into buckets, which are
+// created the first time a key is written into them.
+type Store interface {
+	// View runs fn in a read-only transaction.
+	View(fn func(Tx) error) error
+
+	// Update runs fn in a read-write transaction. The transaction is committed if fn
+	// returns nil, and rolled back otherwise.
+	//
+	// A transaction must not be started from within another one.
+	Update(fn func(Tx) error) error
+
+	// Close releases the store. It must be called once per call to Open.
+	Close() error
+}
+
+// Tx is a transaction on the store. Values returned by it remain valid after the
+// transaction ends.
+type Tx interface {
+	// Get returns the value of a key, or nil if the key does not exist.
+	Get(bucket, key string) []byte
+
+	// Put sets the value of a key.
+	Put(bucket, key string, value []byte) error
+
+	// Delete removes a key. Removing a key that does not exist is not an error.
+	Delete(bucket, key string) error
+
+	// DeleteBucket removes a bucket and all its keys. Removing a bucket that does not exist is not an error.
+	DeleteBucket(bucket string) error
+
+	// ForEach calls fn for every key in the bucket, sorted in byte order.
+	ForEach(bucket string, fn func(key string, value []byte) error) error
+}
+
+// Backend is a function that opens the store in a directory.
+type Backend func(dir string) (Store, error)
+
+type backendKeyT struct{}
+
+var backendKey = backendKeyT{}
+
+// WithBackend returns a child context that will make Open use the supplied backend
+// instead of the default one. This is only meant for testing.
+func WithBackend(ctx context.Context, backend Backend) context.Context {
+	return context.WithValue(ctx, backendKey, backend)
+}
+
+// Open opens the store in the specified directory, creating it if it does not exist.
+// The store is opened with the backend selected by the context, or Bolt otherwise.
+func Open(ctx context.Context, dir string) (Store, error) {
+	if backend, ok := ctx.Value(backendKey).(Backend); ok {
+		return backend(dir)
+	}
+	return Bolt(dir)
+}
+
+// importsBucket keeps track of the files that were imported into the store.
+const importsBucket = "imports"
+
+// ImportFile imports a file written by an older version of the agent into the store, then
+// removes it. Files are imported only once: a file that reappears after its import is
+// removed without being imported again. A missing file is not an error.
+//
+// importFn receives the contents of the file and must write them in the transaction.
+func ImportFile(ctx context.Context, s Store, path string, importFn func(tx Tx, contents []byte) error) (err error) {
+	defer decorate.OnError(&err, "could not import %q into the storage", path)
+
+	out, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	key := filepath.Base(path)
+	err = s.Update(func(tx Tx) error {
+		if tx.Get(importsBucket, key) != nil {
+			return nil
+		}
+
+		if err := importFn(tx, out); err != nil {
+			return err
+		}
+
+		return tx.Put(importsBucket, key, []byte(time.Now().UTC().Format(time.RFC3339)))
+	})
+	if err != nil {
+		return err
+	}
+
+	// The file is already marked as imported, so failing to remove it only leaves clutter behind.
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Warningf(ctx, "Storage: could not remove imported file %q: %v", path, err)
+	}
+
+	return nil
+}
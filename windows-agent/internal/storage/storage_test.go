@@ -0,0 +1,276 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactions(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		failUpdate bool
+
+		want map[string]string
+	}{
+		"Success committing a transaction":  {want: map[string]string{"a": "new", "c": "3"}},
+		"Rolling back a failed transaction": {failUpdate: true, want: map[string]string{"a": "1", "b": "2"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := storage.Bolt(t.TempDir())
+			require.NoError(t, err, "Setup: Bolt should return no error")
+			defer s.Close()
+
+			err = s.Update(func(tx storage.Tx) error {
+				return errors.Join(tx.Put("bucket", "a", []byte("1")), tx.Put("bucket", "b", []byte("2")))
+			})
+			require.NoError(t, err, "Setup: initial Update should return no error")
+
+			err = s.Update(func(tx storage.Tx) error {
+				if err := tx.Put("bucket", "a", []byte("new")); err != nil {
+					return err
+				}
+				if err := tx.Delete("bucket", "b"); err != nil {
+					return err
+				}
+				if err := tx.Put("bucket", "c", []byte("3")); err != nil {
+					return err
+				}
+				if tc.failUpdate {
+					return errors.New("mock error")
+				}
+				return nil
+			})
+			if tc.failUpdate {
+				require.Error(t, err, "Update should return the error of the transaction")
+			} else {
+				require.NoError(t, err, "Update should return no error")
+			}
+
+			got := make(map[string]string)
+			err = s.View(func(tx storage.Tx) error {
+				return tx.ForEach("bucket", func(key string, value []byte) error {
+					got[key] = string(value)
+					return nil
+				})
+			})
+			require.NoError(t, err, "View should return no error")
+			require.Equal(t, tc.want, got, "Unexpected contents of the storage")
+		})
+	}
+}
+
+func TestTx(t *testing.T) {
+	t.Parallel()
+
+	s, err := storage.Bolt(t.TempDir())
+	require.NoError(t, err, "Setup: Bolt should return no error")
+	defer s.Close()
+
+	err = s.Update(func(tx storage.Tx) error {
+		require.Nil(t, tx.Get("missing", "key"), "Get should return nil for a missing bucket")
+		require.NoError(t, tx.Delete("missing", "key"), "Delete should not fail for a missing bucket")
+		require.NoError(t, tx.DeleteBucket("missing"), "DeleteBucket should not fail for a missing bucket")
+		require.NoError(t, tx.ForEach("missing", func(string, []byte) error {
+			require.Fail(t, "ForEach should not iterate over a missing bucket")
+			return nil
+		}), "ForEach should not fail for a missing bucket")
+
+		require.NoError(t, tx.Put("bucket", "empty", []byte{}), "Put should not fail")
+		require.NotNil(t, tx.Get("bucket", "empty"), "Get should tell an empty value from a missing key")
+		require.Nil(t, tx.Get("bucket", "missing"), "Get should return nil for a missing key")
+
+		require.NoError(t, tx.DeleteBucket("bucket"), "DeleteBucket should not fail")
+		require.Nil(t, tx.Get("bucket", "empty"), "Get should return nil after the bucket was deleted")
+		return nil
+	})
+	require.NoError(t, err, "Update should return no error")
+
+	err = s.View(func(tx storage.Tx) error {
+		return tx.Put("bucket", "key", []byte("value"))
+	})
+	require.Error(t, err, "Put should fail in a read-only transaction")
+}
+
+func TestBoltIsShared(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	s1, err := storage.Bolt(dir)
+	require.NoError(t, err, "Bolt should return no error")
+
+	s2, err := storage.Bolt(dir)
+	require.NoError(t, err, "Bolt should return no error when the storage is open already")
+
+	err = s1.Update(func(tx storage.Tx) error { return tx.Put("bucket", "key", []byte("value")) })
+	require.NoError(t, err, "Update should return no error")
+
+	require.NoError(t, s1.Close(), "Close should return no error")
+	require.NoError(t, s1.Close(), "Close should be idempotent")
+	require.Error(t, s1.View(func(storage.Tx) error { return nil }), "View should fail after Close")
+
+	var got []byte
+	err = s2.View(func(tx storage.Tx) error {
+		got = tx.Get("bucket", "key")
+		return nil
+	})
+	require.NoError(t, err, "Storage should remain usable until every store is closed")
+	require.Equal(t, "value", string(got), "Stores opened in the same directory should share their contents")
+
+	require.NoError(t, s2.Close(), "Close should return no error")
+
+	// Reopening after all stores were closed.
+	s3, err := storage.Bolt(dir)
+	require.NoError(t, err, "Bolt should return no error when reopening the storage")
+	defer s3.Close()
+
+	err = s3.View(func(tx storage.Tx) error {
+		got = tx.Get("bucket", "key")
+		return nil
+	})
+	require.NoError(t, err, "View should return no error")
+	require.Equal(t, "value", string(got), "Contents should persist after the storage is closed")
+}
+
+func TestOpen(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		withBackend bool
+		backendErr  bool
+
+		wantErr bool
+	}{
+		"Success with the default backend":  {},
+		"Success with the supplied backend": {withBackend: true},
+
+		"Error when the backend fails": {withBackend: true, backendErr: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			dir := t.TempDir()
+
+			var backendCalled bool
+			if tc.withBackend {
+				ctx = storage.WithBackend(ctx, func(d string) (storage.Store, error) {
+					backendCalled = true
+					require.Equal(t, dir, d, "Backend should be called with the directory passed to Open")
+					if tc.backendErr {
+						return nil, errors.New("mock error")
+					}
+					return storage.Bolt(d)
+				})
+			}
+
+			s, err := storage.Open(ctx, dir)
+			if tc.wantErr {
+				require.Error(t, err, "Open should return an error")
+				return
+			}
+			require.NoError(t, err, "Open should return no error")
+			defer s.Close()
+
+			require.Equal(t, tc.withBackend, backendCalled, "The supplied backend should be used if and only if there is one")
+			require.FileExists(t, filepath.Join(dir, "agent.db"), "Storage file should have been created")
+		})
+	}
+}
+
+func TestImportFile(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		noFile          bool
+		fileIsDir       bool
+		importedAlready bool
+		importErr       bool
+
+		wantImported bool
+		wantFileKept bool
+		wantErr      bool
+	}{
+		"Success importing a file":                          {wantImported: true},
+		"Success doing nothing when there is no file":       {noFile: true},
+		"Success removing a file that was imported already": {importedAlready: true},
+
+		"Error when the file cannot be read": {fileIsDir: true, wantFileKept: true, wantErr: true},
+		"Error when the import fails":        {importErr: true, wantFileKept: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			dir := t.TempDir()
+			path := filepath.Join(dir, "legacy")
+
+			s, err := storage.Bolt(dir)
+			require.NoError(t, err, "Setup: Bolt should return no error")
+			defer s.Close()
+
+			importFn := func(tx storage.Tx, contents []byte) error {
+				if tc.importErr {
+					return errors.New("mock error")
+				}
+				return tx.Put("bucket", "key", contents)
+			}
+
+			if tc.importedAlready {
+				require.NoError(t, os.WriteFile(path, []byte("old contents"), 0600), "Setup: could not write file")
+				require.NoError(t, storage.ImportFile(ctx, s, path, importFn), "Setup: first import should return no error")
+			}
+
+			switch {
+			case tc.noFile:
+			case tc.fileIsDir:
+				require.NoError(t, os.Mkdir(path, 0700), "Setup: could not create directory")
+			default:
+				require.NoError(t, os.WriteFile(path, []byte("contents"), 0600), "Setup: could not write file")
+			}
+
+			err = storage.ImportFile(ctx, s, path, importFn)
+			if tc.wantErr {
+				require.Error(t, err, "ImportFile should return an error")
+			} else {
+				require.NoError(t, err, "ImportFile should return no error")
+			}
+
+			if tc.wantFileKept {
+				_, err := os.Stat(path)
+				require.NoError(t, err, "File should not be removed when it was not imported")
+			} else {
+				require.NoFileExists(t, path, "File should be removed")
+			}
+
+			var got []byte
+			err = s.View(func(tx storage.Tx) error {
+				got = tx.Get("bucket", "key")
+				return nil
+			})
+			require.NoError(t, err, "View should return no error")
+
+			switch {
+			case tc.wantImported:
+				require.Equal(t, "contents", string(got), "File should have been imported")
+			case tc.importedAlready:
+				require.Equal(t, "old contents", string(got), "File should not have been imported a second time")
+			default:
+				require.Nil(t, got, "Nothing should have been imported")
+			}
+		})
+	}
+}
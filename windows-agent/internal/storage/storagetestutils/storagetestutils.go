@@ -0,0 +1,58 @@
+// Package storagetestutils exports test helpers to be used in other packages that need to interfere with the storage.
+package storagetestutils
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/common/testdetection"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/storage"
+)
+
+// Breaker makes the write transactions of the stores opened with its backend fail while it is broken.
+type Breaker struct {
+	broken atomic.Bool
+}
+
+// Break makes all subsequent write transactions fail.
+func (b *Breaker) Break() {
+	b.broken.Store(true)
+}
+
+// Repair makes write transactions succeed again.
+func (b *Breaker) Repair() {
+	b.broken.Store(false)
+}
+
+// WithBreakableBackend returns a child context that makes storage.Open return stores that
+// can be broken via the returned Breaker.
+func WithBreakableBackend(ctx context.Context) (context.Context, *Breaker) {
+	testdetection.MustBeTesting()
+
+	b := &Breaker{}
+	return storage.WithBackend(ctx, func(dir string) (storage.Store, error) {
+		s, err := storage.Bolt(dir)
+		if err != nil {
+			return nil, err
+		}
+		return b.Wrap(s), nil
+	}), b
+}
+
+// Wrap returns a store whose write transactions fail while the breaker is broken.
+func (b *Breaker) Wrap(s storage.Store) storage.Store {
+	return breakableStore{Store: s, breaker: b}
+}
+
+type breakableStore struct {
+	storage.Store
+	breaker *Breaker
+}
+
+func (s breakableStore) Update(fn func(storage.Tx) error) error {
+	if s.breaker.broken.Load() {
+		return errors.New("mock error: storage is broken")
+	}
+	return s.Store.Update(fn)
+}
@@ -2,12 +2,13 @@ package tasks
 
 import (
 	"context"
+	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 )
 
 func init() {
-	task.Register[LandscapeConfigure]()
+	task.Register[LandscapeConfigure](task.WithTypeID("landscape-configure"))
 }
 
 // LandscapeConfigure is a task that registers/disables Landscape in a distro:
@@ -21,7 +22,7 @@ type LandscapeConfigure struct {
 // registered in Landscape.
 func (t LandscapeConfigure) Execute(ctx context.Context, client task.Connection) error {
 	// First value is a dummy message, we ignore it. We only care about success/failure.
-	err := client.SendLandscapeConfig(t.Config)
+	err := client.SendLandscapeConfig(ctx, t.Config)
 	if err != nil {
 		return task.NeedsRetryError{SourceErr: err}
 	}
@@ -40,3 +41,14 @@ func (t LandscapeConfigure) Is(other task.Task) bool {
 	_, ok := other.(LandscapeConfigure)
 	return ok
 }
+
+// TTL discards a LandscapeConfigure that could not run within a day: the configuration in effect is sent
+// again when the distro connects, so an old one would only be outdated.
+func (t LandscapeConfigure) TTL() time.Duration {
+	return 24 * time.Hour
+}
+
+// DependsOn makes LandscapeConfigure wait for the queued proxy settings, so that the distro can reach the outside world.
+func (t LandscapeConfigure) DependsOn() []task.Task {
+	return []task.Task{ProxyConfig{}}
+}
@@ -0,0 +1,60 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[PackageInventory](task.WithTypeID("package-inventory"))
+}
+
+// PackageInventory is a task that collects the list of packages installed in a distro.
+type PackageInventory struct{}
+
+// Execute asks the target WSL-Pro-Service for the installed packages and records them as the
+// latest inventory of the distro. Failures to collect the inventory are retried.
+func (t PackageInventory) Execute(ctx context.Context, conn task.Connection) error {
+	packages, err := conn.SendPackageInventory(ctx)
+	if errors.Is(err, task.ErrUnsupported) {
+		return err
+	}
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+
+	if err := task.RecordInventory(ctx, task.PackageInventory{Collected: time.Now(), Packages: packages}); err != nil {
+		return fmt.Errorf("could not record the package inventory: %v", err)
+	}
+
+	task.Report(ctx, "collected %d packages", len(packages))
+	return nil
+}
+
+// String returns the name of the task.
+func (t PackageInventory) String() string {
+	return fmt.Sprintf("%T task", t)
+}
+
+// Is is a custom comparator. All PackageInventory tasks are considered equivalent, as they all
+// collect whatever is installed when they run.
+func (t PackageInventory) Is(other task.Task) bool {
+	_, ok := other.(PackageInventory)
+	return ok
+}
+
+// DependsOn makes PackageInventory wait for the queued security updates, so that the inventory
+// reflects the packages they upgrade.
+func (t PackageInventory) DependsOn() []task.Task {
+	return []task.Task{SecurityUpdate{}}
+}
+
+// Priority makes PackageInventory run after the other queued tasks, so that the inventory reflects
+// the packages they install.
+func (t PackageInventory) Priority() int {
+	return -1
+}
@@ -10,7 +10,7 @@ import (
 )
 
 func init() {
-	task.Register[ProAttachment]()
+	task.Register[ProAttachment](task.WithTypeID("pro-attachment"))
 }
 
 // ProAttachment is a task that attaches/dettaches Ubuntu Pro to a distro:
@@ -22,7 +22,7 @@ type ProAttachment struct {
 
 // Execute is needed to fulfil Task.
 func (t ProAttachment) Execute(ctx context.Context, conn task.Connection) error {
-	err := conn.SendProAttachment(t.Token)
+	err := conn.SendProAttachment(ctx, t.Token)
 	if err != nil {
 		return task.NeedsRetryError{SourceErr: err}
 	}
@@ -39,3 +39,14 @@ func (t ProAttachment) Is(other task.Task) bool {
 	_, ok := other.(ProAttachment)
 	return ok
 }
+
+// DependsOn makes ProAttachment wait for the queued proxy settings, so that the distro can reach the outside world.
+func (t ProAttachment) DependsOn() []task.Task {
+	return []task.Task{ProxyConfig{}}
+}
+
+// Priority makes ProAttachment run before the other queued tasks, except for the proxy settings, so that
+// the distro is attached before it registers to Landscape.
+func (t ProAttachment) Priority() int {
+	return 1
+}
@@ -0,0 +1,70 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[ProServices](task.WithTypeID("pro-services"))
+}
+
+// ProServices is a task that enables and disables Ubuntu Pro services in a distro.
+// Services in neither list are left as they are.
+type ProServices struct {
+	Enable  []string `yaml:",omitempty"`
+	Disable []string `yaml:",omitempty"`
+}
+
+// Execute sends the services to the target WSL-Pro-Service. The task fails if any of the
+// services could not be enabled or disabled, but only communication errors are retried.
+func (t ProServices) Execute(ctx context.Context, conn task.Connection) error {
+	results, err := conn.SendProServices(ctx, t.Enable, t.Disable)
+	if errors.Is(err, task.ErrUnsupported) {
+		return err
+	}
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+
+	var failed []string
+	for _, r := range results {
+		if r.Error == "" {
+			continue
+		}
+
+		action := "disable"
+		if r.Enable {
+			action = "enable"
+		}
+		failed = append(failed, fmt.Sprintf("could not %s %s: %s", action, r.Name, r.Error))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("some Ubuntu Pro services failed: %s", strings.Join(failed, "; "))
+	}
+
+	return nil
+}
+
+// String returns the name of the task.
+func (t ProServices) String() string {
+	return fmt.Sprintf("%T task enabling %q and disabling %q", t, t.Enable, t.Disable)
+}
+
+// Is is a custom comparator. All ProServices tasks are considered equivalent. In other words: newer
+// services will override old ones.
+func (t ProServices) Is(other task.Task) bool {
+	_, ok := other.(ProServices)
+	return ok
+}
+
+// DependsOn makes ProServices wait for the queued proxy settings and Pro attachment, as services can
+// only be enabled in attached distros that can reach the outside world.
+func (t ProServices) DependsOn() []task.Task {
+	return []task.Task{ProxyConfig{}, ProAttachment{}}
+}
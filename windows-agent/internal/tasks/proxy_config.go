@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[ProxyConfig](task.WithTypeID("proxy-config"))
+}
+
+// ProxyConfig is a task that sets the proxy settings of a distro:
+// - to set a proxy: send the settings.
+// - to remove the proxy: send empty settings.
+type ProxyConfig struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+}
+
+// Execute sends the proxy settings to the target WSL-Pro-Service so that
+// the distro can reach the outside world.
+func (t ProxyConfig) Execute(ctx context.Context, client task.Connection) error {
+	err := client.SendProxyConfig(ctx, t.HTTPProxy, t.HTTPSProxy, t.NoProxy)
+	if errors.Is(err, task.ErrUnsupported) {
+		return err
+	}
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+
+	return nil
+}
+
+// String returns the name of the task.
+func (t ProxyConfig) String() string {
+	return "ProxyConfig"
+}
+
+// Is is a custom comparator. All ProxyConfig tasks are considered equivalent. In other words: newer
+// settings will override old ones.
+func (t ProxyConfig) Is(other task.Task) bool {
+	_, ok := other.(ProxyConfig)
+	return ok
+}
+
+// Priority makes ProxyConfig run before any other queued task, so that they can reach the outside world.
+func (t ProxyConfig) Priority() int {
+	return 2
+}
+
+// TTL discards a ProxyConfig that could not run within a day, as the settings in effect are sent again
+// when the distro connects.
+func (t ProxyConfig) TTL() time.Duration {
+	return 24 * time.Hour
+}
@@ -0,0 +1,81 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[RunCommand](task.WithTypeID("run-command"))
+}
+
+// maxErrorOutput is the number of bytes of the standard error of a failed command that are reported in its error.
+const maxErrorOutput = 256
+
+// maxReportedOutput is the number of bytes of the standard output of a command that are reported in the task history.
+const maxReportedOutput = 4 * 1024
+
+// RunCommand is a task that runs a command in a distro.
+//
+// Commands are not idempotent, so failed attempts are never retried.
+type RunCommand struct {
+	// Argv is the executable followed by its arguments.
+	Argv []string
+	// Env is a list of KEY=VALUE pairs added to the environment of the command.
+	Env []string `yaml:",omitempty"`
+	// WorkingDir is the directory the command runs in. Empty means the default one.
+	WorkingDir string `yaml:",omitempty"`
+	// Timeout is how long the command may run before being killed. Zero means no timeout.
+	Timeout time.Duration `yaml:",omitempty"`
+	// User is the Linux user that runs the command. Empty means root.
+	User string `yaml:",omitempty"`
+	// Stdin is fed to the standard input of the command.
+	Stdin []byte `yaml:",omitempty"`
+}
+
+// Execute sends the command to the target WSL-Pro-Service and waits for it to exit. The beginning of its
+// standard output is reported in the task history. Commands exiting with a non-zero code are considered failed.
+func (t RunCommand) Execute(ctx context.Context, conn task.Connection) error {
+	out, err := conn.SendRunCommand(ctx, task.Command{
+		Argv:       t.Argv,
+		Env:        t.Env,
+		WorkingDir: t.WorkingDir,
+		Timeout:    t.Timeout,
+		User:       t.User,
+		Stdin:      t.Stdin,
+	})
+	if err != nil {
+		return err
+	}
+
+	stdout := strings.TrimSpace(string(out.Stdout[:min(len(out.Stdout), maxReportedOutput)]))
+	if out.Truncated || len(out.Stdout) > maxReportedOutput {
+		stdout += "\n[output truncated]"
+	}
+	task.Report(ctx, "%s", stdout)
+
+	if out.ExitCode != 0 {
+		stderr := strings.TrimSpace(string(out.Stderr[:min(len(out.Stderr), maxErrorOutput)]))
+		return fmt.Errorf("command exited with code %d: %s", out.ExitCode, stderr)
+	}
+
+	return nil
+}
+
+// String returns the name of the task. Only the executable is shown, as the arguments may contain secrets.
+func (t RunCommand) String() string {
+	if len(t.Argv) == 0 {
+		return fmt.Sprintf("%T task with no command", t)
+	}
+	return fmt.Sprintf("%T task with command %q", t, t.Argv[0])
+}
+
+// Is is a custom comparator. RunCommand tasks are only equivalent to identical ones.
+func (t RunCommand) Is(other task.Task) bool {
+	return reflect.DeepEqual(t, other)
+}
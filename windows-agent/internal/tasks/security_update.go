@@ -0,0 +1,66 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
+)
+
+func init() {
+	task.Register[SecurityUpdate](task.WithTypeID("security-update"))
+}
+
+// SecurityUpdate is a task that applies the pending security updates in a distro.
+// Other pending upgrades are left alone.
+type SecurityUpdate struct{}
+
+// Execute asks the target WSL-Pro-Service to apply the pending security updates, and reports
+// which packages were upgraded. Failures are retried, as they are mostly caused by the network
+// or by another package manager holding the lock.
+func (t SecurityUpdate) Execute(ctx context.Context, conn task.Connection) error {
+	report, err := conn.SendSecurityUpdate(ctx)
+	if errors.Is(err, task.ErrUnsupported) {
+		return err
+	}
+	if err != nil {
+		return task.NeedsRetryError{SourceErr: err}
+	}
+
+	if len(report.Packages) == 0 {
+		task.Report(ctx, "no pending security updates")
+		return nil
+	}
+
+	upgraded := make([]string, 0, len(report.Packages))
+	for _, p := range report.Packages {
+		upgraded = append(upgraded, fmt.Sprintf("%s %s -> %s", p.Name, p.OldVersion, p.NewVersion))
+	}
+
+	task.Report(ctx, "upgraded %d packages: %s", len(upgraded), strings.Join(upgraded, ", "))
+	if report.RebootRequired {
+		task.RequireReboot(ctx)
+	}
+
+	return nil
+}
+
+// String returns the name of the task.
+func (t SecurityUpdate) String() string {
+	return fmt.Sprintf("%T task", t)
+}
+
+// Is is a custom comparator. All SecurityUpdate tasks are considered equivalent, as they all
+// apply whatever updates are pending when they run.
+func (t SecurityUpdate) Is(other task.Task) bool {
+	_, ok := other.(SecurityUpdate)
+	return ok
+}
+
+// DependsOn makes SecurityUpdate wait for the queued proxy settings and Pro attachment, as the
+// updates are downloaded from the outside world, and some are only available to attached distros.
+func (t SecurityUpdate) DependsOn() []task.Task {
+	return []task.Task{ProxyConfig{}, ProAttachment{}}
+}
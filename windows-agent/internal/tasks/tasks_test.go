@@ -3,8 +3,13 @@ package tasks_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/stretchr/testify/require"
 )
@@ -93,9 +98,246 @@ func TestLandscapeConfigure(t *testing.T) {
 	}
 }
 
-type mockConnection struct{}
+func TestProxyConfig(t *testing.T) {
+	testcases := map[string]struct {
+		httpsProxy string
+
+		wantErr bool
+	}{
+		"Success":                    {httpsProxy: "http://proxy.example.com:3128"},
+		"Success removing the proxy": {},
+
+		"Error when the connection fails to send a task": {httpsProxy: "MOCK_ERROR", wantErr: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// Create a new ProxyConfig task.
+			proxyConfig := tasks.ProxyConfig{
+				HTTPSProxy: tc.httpsProxy,
+			}
+
+			conn := mockConnection{}
+			err := proxyConfig.Execute(context.Background(), conn)
+			if tc.wantErr {
+				require.Error(t, err, "Execute should have failed")
+			} else {
+				require.NoError(t, err, "Execute should have succeeded")
+			}
+
+			// Comparison and stringyfication
+			another := tasks.ProxyConfig{HTTPProxy: "http://another.example.com:8080"}
+			require.True(t, proxyConfig.Is(another), "All ProxyConfig tasks should be considered equivalent")
+			require.Equal(t, "ProxyConfig", proxyConfig.String(), "Unexpected ProxyConfig name")
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	testcases := map[string]struct {
+		argv []string
+
+		wantReport   string
+		wantErr      bool
+		wantInErrMsg string
+	}{
+		"Success":                         {argv: []string{"echo", "hello"}, wantReport: "hello"},
+		"Success with a truncated output": {argv: []string{"MOCK_TRUNCATED", "partial"}, wantReport: "partial\n[output truncated]"},
+
+		"Error when the command exits with a non-zero code": {argv: []string{"MOCK_EXIT_CODE", "oops"}, wantErr: true, wantInErrMsg: "code 42: oops"},
+		"Error when the connection fails to send a task":    {argv: []string{"MOCK_ERROR"}, wantErr: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// Create a new RunCommand task.
+			runCommand := tasks.RunCommand{
+				Argv: tc.argv,
+				Env:  []string{"SECRET=hunter2"},
+			}
+
+			var report string
+			ctx := task.WithReporter(context.Background(), func(r string) { report = r })
+
+			conn := mockConnection{}
+			err := runCommand.Execute(ctx, conn)
+			if tc.wantErr {
+				require.Error(t, err, "Execute should have failed")
+				require.NotErrorAs(t, err, &task.NeedsRetryError{}, "RunCommand should never be retried")
+				require.ErrorContains(t, err, tc.wantInErrMsg, "Execute error should report the output of the command")
+			} else {
+				require.NoError(t, err, "Execute should have succeeded")
+			}
+			require.Equal(t, tc.wantReport, report, "Execute should report the standard output of the command")
+
+			// Comparison and stringyfication
+			same := tasks.RunCommand{Argv: slices.Clone(tc.argv), Env: []string{"SECRET=hunter2"}}
+			require.True(t, runCommand.Is(same), "Identical RunCommand tasks should be considered equivalent")
+
+			another := tasks.RunCommand{Argv: tc.argv, Env: []string{"SECRET=another"}}
+			require.False(t, runCommand.Is(another), "Different RunCommand tasks should not be considered equivalent")
+			require.False(t, runCommand.Is(tasks.ProxyConfig{}), "RunCommand tasks should not be equivalent to other tasks")
+
+			require.Contains(t, runCommand.String(), tc.argv[0], "RunCommand name should contain the executable")
+			if len(tc.argv) > 1 {
+				require.NotContains(t, runCommand.String(), tc.argv[1], "RunCommand name should not contain the arguments")
+			}
+			require.NotContains(t, runCommand.String(), "hunter2", "RunCommand name should not contain the environment")
+		})
+	}
+}
+
+func TestProServices(t *testing.T) {
+	testcases := map[string]struct {
+		enable []string
+
+		wantErr   bool
+		wantRetry bool
+	}{
+		"Success":                  {enable: []string{"esm-apps"}},
+		"Success with no services": {},
+
+		"Error when a service fails":                     {enable: []string{"esm-apps", "MOCK_SERVICE_ERROR"}, wantErr: true},
+		"Error when the connection fails to send a task": {enable: []string{"MOCK_ERROR"}, wantErr: true, wantRetry: true},
+	}
 
-func (m mockConnection) SendProAttachment(proToken string) error {
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			// Create a new ProServices task.
+			proServices := tasks.ProServices{
+				Enable:  tc.enable,
+				Disable: []string{"usg"},
+			}
+
+			conn := mockConnection{}
+			err := proServices.Execute(context.Background(), conn)
+			if tc.wantErr {
+				require.Error(t, err, "Execute should have failed")
+				require.Equal(t, tc.wantRetry, errors.As(err, &task.NeedsRetryError{}), "Only communication errors should be retried")
+			} else {
+				require.NoError(t, err, "Execute should have succeeded")
+			}
+
+			// Comparison and stringyfication
+			another := tasks.ProServices{Enable: []string{"esm-infra"}}
+			require.True(t, proServices.Is(another), "All ProServices tasks should be considered equivalent")
+			require.Contains(t, proServices.String(), "usg", "ProServices name should contain the services")
+		})
+	}
+}
+
+func TestSecurityUpdate(t *testing.T) {
+	testcases := map[string]struct {
+		report  task.SecurityUpdateReport
+		sendErr bool
+
+		wantReport []string
+		wantReboot bool
+		wantErr    bool
+	}{
+		"Success with no pending updates": {wantReport: []string{"no pending security updates"}},
+		"Success with upgraded packages": {
+			report:     task.SecurityUpdateReport{Packages: []task.UpgradedPackage{{Name: "libssl3", OldVersion: "3.0.2-0ubuntu1.15", NewVersion: "3.0.2-0ubuntu1.18"}}},
+			wantReport: []string{"upgraded 1 packages", "libssl3 3.0.2-0ubuntu1.15 -> 3.0.2-0ubuntu1.18"},
+		},
+		"Success with a reboot required": {
+			report:     task.SecurityUpdateReport{Packages: []task.UpgradedPackage{{Name: "libc6"}}, RebootRequired: true},
+			wantReport: []string{"upgraded 1 packages"},
+			wantReboot: true,
+		},
+
+		"Error when the connection fails to send a task": {sendErr: true, wantErr: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var report string
+			var reboot bool
+			ctx := task.WithReporter(context.Background(), func(r string) { report = r })
+			ctx = task.WithRebootNotifier(ctx, func() { reboot = true })
+
+			conn := mockConnection{securityUpdate: tc.report, securityUpdateErr: tc.sendErr}
+			err := tasks.SecurityUpdate{}.Execute(ctx, conn)
+			if tc.wantErr {
+				require.Error(t, err, "Execute should have failed")
+				require.ErrorAs(t, err, &task.NeedsRetryError{}, "Failed updates should be retried")
+				return
+			}
+			require.NoError(t, err, "Execute should have succeeded")
+
+			for _, want := range tc.wantReport {
+				require.Contains(t, report, want, "Unexpected report of the upgraded packages")
+			}
+			require.Equal(t, tc.wantReboot, reboot, "Mismatch in whether a reboot was required")
+
+			require.True(t, tasks.SecurityUpdate{}.Is(tasks.SecurityUpdate{}), "All SecurityUpdate tasks should be considered equivalent")
+			require.False(t, tasks.SecurityUpdate{}.Is(tasks.ProServices{}), "SecurityUpdate tasks should not be equivalent to other tasks")
+		})
+	}
+}
+
+func TestPackageInventory(t *testing.T) {
+	testcases := map[string]struct {
+		packages  []task.Package
+		sendErr   bool
+		recordErr bool
+
+		wantReport string
+		wantErr    bool
+		wantRetry  bool
+	}{
+		"Success with no packages": {wantReport: "collected 0 packages"},
+		"Success with packages": {
+			packages:   []task.Package{{Name: "curl", Version: "8.5.0-2ubuntu10.6", Architecture: "amd64", Origin: "noble-infra-security", ESM: true}},
+			wantReport: "collected 1 packages",
+		},
+
+		"Error when the connection fails to send a task": {sendErr: true, wantErr: true, wantRetry: true},
+		"Error when the inventory cannot be recorded":    {recordErr: true, wantErr: true},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			var report string
+			var recorded *task.PackageInventory
+			ctx := task.WithReporter(context.Background(), func(r string) { report = r })
+			ctx = task.WithInventoryRecorder(ctx, func(inv task.PackageInventory) error {
+				if tc.recordErr {
+					return errors.New("mock error")
+				}
+				recorded = &inv
+				return nil
+			})
+
+			conn := mockConnection{packages: tc.packages, packagesErr: tc.sendErr}
+			err := tasks.PackageInventory{}.Execute(ctx, conn)
+			if tc.wantErr {
+				require.Error(t, err, "Execute should have failed")
+				require.Equal(t, tc.wantRetry, errors.As(err, &task.NeedsRetryError{}), "Only communication errors should be retried")
+				require.Nil(t, recorded, "No inventory should have been recorded")
+				return
+			}
+			require.NoError(t, err, "Execute should have succeeded")
+
+			require.NotNil(t, recorded, "The inventory should have been recorded")
+			require.Equal(t, tc.packages, recorded.Packages, "Unexpected packages recorded")
+			require.False(t, recorded.Collected.IsZero(), "The collection time should have been recorded")
+			require.Equal(t, tc.wantReport, report, "Unexpected report of the collected packages")
+
+			require.True(t, tasks.PackageInventory{}.Is(tasks.PackageInventory{}), "All PackageInventory tasks should be considered equivalent")
+			require.False(t, tasks.PackageInventory{}.Is(tasks.SecurityUpdate{}), "PackageInventory tasks should not be equivalent to other tasks")
+		})
+	}
+}
+
+type mockConnection struct {
+	securityUpdate    task.SecurityUpdateReport
+	securityUpdateErr bool
+	packages          []task.Package
+	packagesErr       bool
+}
+
+func (m mockConnection) SendProAttachment(ctx context.Context, proToken string) error {
 	switch proToken {
 	case "MOCK_ERROR":
 		return errors.New("mock error")
@@ -104,7 +346,7 @@ func (m mockConnection) SendProAttachment(proToken string) error {
 	}
 }
 
-func (m mockConnection) SendLandscapeConfig(lpeConfig string) error {
+func (m mockConnection) SendLandscapeConfig(ctx context.Context, lpeConfig string) error {
 	switch lpeConfig {
 	case "MOCK_ERROR":
 		return errors.New("mock error")
@@ -112,3 +354,135 @@ func (m mockConnection) SendLandscapeConfig(lpeConfig string) error {
 		return nil
 	}
 }
+
+func (m mockConnection) SendProxyConfig(ctx context.Context, httpProxy, httpsProxy, noProxy string) error {
+	if httpsProxy == "MOCK_ERROR" {
+		return errors.New("mock error")
+	}
+	return nil
+}
+
+func (m mockConnection) SendProServices(ctx context.Context, enable, disable []string) ([]task.ServiceResult, error) {
+	if slices.Contains(enable, "MOCK_ERROR") {
+		return nil, errors.New("mock error")
+	}
+
+	var results []task.ServiceResult
+	for _, name := range disable {
+		results = append(results, task.ServiceResult{Name: name})
+	}
+	for _, name := range enable {
+		r := task.ServiceResult{Name: name, Enable: true}
+		if name == "MOCK_SERVICE_ERROR" {
+			r.Error = "mock error"
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func (m mockConnection) SendRunCommand(ctx context.Context, cmd task.Command) (task.CommandOutput, error) {
+	switch cmd.Argv[0] {
+	case "MOCK_ERROR":
+		return task.CommandOutput{}, errors.New("mock error")
+	case "MOCK_EXIT_CODE":
+		return task.CommandOutput{ExitCode: 42, Stderr: []byte(strings.Join(cmd.Argv[1:], " ") + "\n")}, nil
+	case "MOCK_TRUNCATED":
+		return task.CommandOutput{Stdout: []byte(strings.Join(cmd.Argv[1:], " ")), Truncated: true}, nil
+	default:
+		return task.CommandOutput{Stdout: []byte(strings.Join(cmd.Argv[1:], " "))}, nil
+	}
+}
+
+func (m mockConnection) SendSecurityUpdate(context.Context) (task.SecurityUpdateReport, error) {
+	if m.securityUpdateErr {
+		return task.SecurityUpdateReport{}, errors.New("mock error")
+	}
+	return m.securityUpdate, nil
+}
+
+func (m mockConnection) SendPackageInventory(context.Context) ([]task.Package, error) {
+	if m.packagesErr {
+		return nil, errors.New("mock error")
+	}
+	return m.packages, nil
+}
+
+func TestPriorities(t *testing.T) {
+	t.Parallel()
+
+	proxy := task.NewEnvelope(tasks.ProxyConfig{}).Priority
+	landscape := task.NewEnvelope(tasks.LandscapeConfigure{}).Priority
+	pro := task.NewEnvelope(tasks.ProAttachment{}).Priority
+	inventory := task.NewEnvelope(tasks.PackageInventory{}).Priority
+
+	require.Greater(t, proxy, pro, "ProxyConfig should run before ProAttachment so that it can reach the outside world")
+	require.Greater(t, pro, landscape, "ProAttachment should run before LandscapeConfigure so that the distro is attached when it registers")
+	require.Greater(t, landscape, inventory, "PackageInventory should run after the other tasks so that it lists the packages they install")
+}
+
+func TestExpiry(t *testing.T) {
+	t.Parallel()
+
+	for _, tk := range []task.Task{tasks.ProxyConfig{}, tasks.LandscapeConfigure{}} {
+		e := task.NewEnvelope(tk)
+		require.False(t, e.Expires.IsZero(), "%s should expire, as the settings in effect are sent again when the distro connects", tk)
+		require.False(t, e.Expired(time.Now()), "%s should not expire right after it is submitted", tk)
+	}
+
+	require.True(t, task.NewEnvelope(tasks.ProAttachment{}).Expires.IsZero(), "ProAttachment should never expire, as attached distros do not get the token again when they connect")
+	require.True(t, task.NewEnvelope(tasks.SecurityUpdate{}).Expires.IsZero(), "SecurityUpdate should never expire")
+}
+
+func TestDependencies(t *testing.T) {
+	t.Parallel()
+
+	for _, tk := range []task.Task{tasks.LandscapeConfigure{}, tasks.ProAttachment{}, tasks.ProServices{}, tasks.SecurityUpdate{}} {
+		deps := task.DependenciesOf(tk)
+		require.True(t, slices.ContainsFunc(deps, func(d task.Task) bool { return task.Is(d, tasks.ProxyConfig{}) }),
+			"%s should wait for the proxy settings so that it can reach the outside world", tk)
+	}
+
+	for _, tk := range []task.Task{tasks.ProServices{}, tasks.SecurityUpdate{}} {
+		deps := task.DependenciesOf(tk)
+		require.True(t, slices.ContainsFunc(deps, func(d task.Task) bool { return task.Is(d, tasks.ProAttachment{}) }),
+			"%s should wait for the Pro attachment so that it can use the Pro services", tk)
+	}
+
+	deps := task.DependenciesOf(tasks.PackageInventory{})
+	require.True(t, slices.ContainsFunc(deps, func(d task.Task) bool { return task.Is(d, tasks.SecurityUpdate{}) }),
+		"PackageInventory should wait for the security updates so that it lists the upgraded packages")
+}
+
+func TestStoredTypes(t *testing.T) {
+	t.Parallel()
+
+	all := []task.Task{
+		tasks.ProxyConfig{},
+		tasks.LandscapeConfigure{},
+		tasks.ProAttachment{},
+		tasks.ProServices{},
+		tasks.RunCommand{Argv: []string{"true"}},
+		tasks.SecurityUpdate{},
+		tasks.PackageInventory{},
+	}
+
+	serial, err := task.MarshalYAML(all)
+	require.NoError(t, err, "MarshalYAML should return no error")
+	require.NotContains(t, string(serial), "tasks.", "Tasks should be stored with their type ID rather than their Go type name")
+
+	got, err := task.UnmarshalYAML(serial)
+	require.NoError(t, err, "Tasks stored with their type ID should be readable")
+	require.Equal(t, all, got, "Mismatch in the tasks read back")
+
+	// Tasks stored by former versions of the agent are identified by their Go type name.
+	for _, tk := range all {
+		serial, err := task.MarshalYAML([]task.Task{tk})
+		require.NoError(t, err, "Setup: MarshalYAML should return no error")
+		legacy := strings.Replace(string(serial), "type: "+task.TypeID(tk), fmt.Sprintf("type: %T", tk), 1)
+
+		got, err := task.UnmarshalYAML([]byte(legacy))
+		require.NoError(t, err, "Tasks stored with their Go type name should be readable:\n%s", legacy)
+		require.Equal(t, []task.Task{tk}, got, "Mismatch in the task read back:\n%s", legacy)
+	}
+}
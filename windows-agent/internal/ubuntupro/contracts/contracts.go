@@ -5,17 +5,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/storeapi/go-wrapper/microsoftstore"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/proxy"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contractclient"
 	"github.com/ubuntu/decorate"
 )
 
 type options struct {
 	proURL         *url.URL
+	proxy          config.Proxy
 	microsoftStore MicrosoftStore
 }
 
@@ -29,6 +31,13 @@ func WithProURL(proURL *url.URL) Option {
 	}
 }
 
+// WithProxy sets the proxy settings used to reach the Ubuntu Pro contract server.
+func WithProxy(p config.Proxy) Option {
+	return func(o *options) {
+		o.proxy = p
+	}
+}
+
 // WithMockMicrosoftStore overrides the storeAPI-backed Microsoft Store.
 func WithMockMicrosoftStore(store MicrosoftStore) Option {
 	return func(o *options) {
@@ -105,7 +114,7 @@ func NewProToken(ctx context.Context, args ...Option) (token string, err error)
 		opts.proURL = url
 	}
 
-	contractClient := contractclient.New(opts.proURL, &http.Client{Timeout: 30 * time.Second})
+	contractClient := contractclient.New(opts.proURL, proxy.HTTPClient(opts.proxy, 30*time.Second))
 	msftStore := opts.microsoftStore
 
 	adToken, err := contractClient.GetServerAccessToken(ctx)
@@ -3,40 +3,60 @@ package ubuntupro
 
 import (
 	"context"
-	"errors"
 	"fmt"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/rollout"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/task"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/tasks"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
 	"github.com/ubuntu/decorate"
 )
 
-// Distribute sends the current subscription token to all distros.
-func Distribute(ctx context.Context, db *database.DistroDB, ubuntuProToken string) {
-	task := tasks.ProAttachment{
-		Token: ubuntuProToken,
-	}
+// ContractResolver picks the Ubuntu Pro token that applies to each distro, and keeps the ones
+// that applied before the last change so that they can be rolled back to.
+type ContractResolver interface {
+	ContractFor(distroName string, labels ...string) (config.Contract, error)
+	PreviousContractFor(distroName string, labels ...string) (config.Contract, error)
+	RollBackSubscription() error
+	RolloutPolicy() rollout.Policy
+}
 
-	var err error
+// Distribute sends to all distros the subscription token that applies to each of them, rolling it
+// out according to the rollout policy. It supersedes the previous rollout of the controller, if any.
+func Distribute(ctx context.Context, db *database.DistroDB, conf ContractResolver, rollouts *rollout.Controller) {
 	instances := db.GetAll()
-	log.Debugf(ctx, "Distributing Ubuntu Pro token to %d distros", len(instances))
-	for _, distro := range instances {
-		err = errors.Join(err, distro.SubmitTasks(task))
+	distros := make([]rollout.Distro, 0, len(instances))
+	for _, d := range instances {
+		distros = append(distros, d)
 	}
 
-	if err != nil {
-		log.Warningf(ctx, "could not submit tasks to all distros: %v", err)
+	attach := func(contractFor func(string, ...string) (config.Contract, error)) func(rollout.Distro) (task.Task, error) {
+		return func(d rollout.Distro) (task.Task, error) {
+			contract, err := contractFor(d.Name(), d.Labels()...)
+			if err != nil {
+				return nil, err
+			}
+			return tasks.ProAttachment{Token: contract.Token}, nil
+		}
 	}
+
+	rollouts.Start(ctx, conf.RolloutPolicy(), distros, rollout.Change{
+		Name:     "Ubuntu Pro token",
+		Apply:    attach(conf.ContractFor),
+		Revert:   attach(conf.PreviousContractFor),
+		RollBack: conf.RollBackSubscription,
+	})
 }
 
 // Config is a configuration manager for the Windows Agent.
 type Config interface {
 	Subscription() (string, config.Source, error)
 	SetStoreSubscription(context.Context, string) error
+	Proxy() (config.Proxy, error)
 }
 
 // FetchFromMicrosoftStore contacts Ubuntu Pro's contract server and the Microsoft Store
@@ -72,6 +92,13 @@ func FetchFromMicrosoftStore(ctx context.Context, conf Config, db *database.Dist
 
 	log.Debug(ctx, "Config: attempting to obtain Ubuntu Pro token from the Microsoft Store")
 
+	proxy, err := conf.Proxy()
+	if err != nil {
+		return fmt.Errorf("could not get proxy settings: %v", err)
+	}
+	// Options passed by the caller take precedence.
+	args = append([]contracts.Option{contracts.WithProxy(proxy)}, args...)
+
 	proToken, err := contracts.NewProToken(ctx, args...)
 	if err != nil {
 		err = fmt.Errorf("could not get the Ubuntu Pro token from the Microsoft Store: %v", err)
@@ -13,6 +13,7 @@ import (
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/config"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/database"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/distro"
+	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/distros/rollout"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro"
 	"github.com/canonical/ubuntu-pro-for-wsl/windows-agent/internal/ubuntupro/contracts"
 	"github.com/stretchr/testify/require"
@@ -56,7 +57,10 @@ func TestDistribute(t *testing.T) {
 				dist.Invalidate(ctx)
 			}
 
-			ubuntupro.Distribute(ctx, db, "super_token")
+			conf := config.New(ctx, t.TempDir())
+			require.NoError(t, conf.SetUserSubscription(ctx, "super_token"), "Setup: SetUserSubscription should return no error")
+
+			ubuntupro.Distribute(ctx, db, conf, rollout.New(ctx))
 		})
 	}
 }
@@ -76,6 +80,7 @@ func TestFetchFromMicrosoftStore(t *testing.T) {
 	testCases := map[string]struct {
 		breakSubscription     bool
 		breakSetStoreProToken bool
+		breakProxy            bool
 
 		alreadyHaveToken    bool
 		hasOrgToken         bool
@@ -95,6 +100,7 @@ func TestFetchFromMicrosoftStore(t *testing.T) {
 		// Config errors
 		"Error when the current subscription cannot be obtained": {breakSubscription: true, wantErr: true},
 		"Error when the new subscription cannot be set":          {breakSetStoreProToken: true, wantErr: true},
+		"Error when the proxy settings cannot be obtained":       {breakProxy: true, wantErr: true},
 
 		// Contract server errors
 		"Error when the Microsoft Store cannot provide the JWT":             {msStoreJWTErr: true, wantErr: true},
@@ -112,6 +118,7 @@ func TestFetchFromMicrosoftStore(t *testing.T) {
 			conf := &mockConfig{
 				subscriptionErr:     tc.breakSubscription,
 				setStoreProTokenErr: tc.breakSetStoreProToken,
+				proxyErr:            tc.breakProxy,
 			}
 
 			if tc.hasOrgToken {
@@ -192,6 +199,7 @@ type mockConfig struct {
 
 	subscriptionErr     bool
 	setStoreProTokenErr bool
+	proxyErr            bool
 }
 
 func (c mockConfig) Subscription() (string, config.Source, error) {
@@ -218,3 +226,11 @@ func (c *mockConfig) SetStoreSubscription(ctx context.Context, token string) err
 	c.storeProToken = token
 	return nil
 }
+
+func (c mockConfig) Proxy() (config.Proxy, error) {
+	if c.proxyErr {
+		return config.Proxy{}, errors.New("mock config Proxy: mock error")
+	}
+
+	return config.Proxy{}, nil
+}
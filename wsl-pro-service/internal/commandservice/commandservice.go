@@ -63,3 +63,78 @@ func (s Service) ApplyLandscapeConfig(ctx context.Context, msg *agentapi.Landsca
 
 	return nil
 }
+
+// ApplyProxyConfig serves ProxyConfig messages sent by the agent.
+func (s Service) ApplyProxyConfig(ctx context.Context, msg *agentapi.ProxyConfigCmd) (err error) {
+	if msg.GetHttpProxy() == "" && msg.GetHttpsProxy() == "" {
+		log.Info(ctx, "ApplyProxyConfig: received empty proxy settings: unsetting")
+	} else {
+		log.Info(ctx, "ApplyProxyConfig: received proxy settings: setting")
+	}
+
+	if err := s.system.ProSetProxy(ctx, msg.GetHttpProxy(), msg.GetHttpsProxy(), msg.GetNoProxy()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RunCommand serves RunCommand messages sent by the agent.
+func (s Service) RunCommand(ctx context.Context, msg *agentapi.RunCommandCmd) (*agentapi.CommandOutput, error) {
+	// Only the executable is logged: the arguments, environment and input may contain secrets.
+	if argv := msg.GetArgv(); len(argv) > 0 {
+		log.Infof(ctx, "RunCommand: received command %q: running", argv[0])
+	}
+
+	out, err := s.system.RunCommand(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof(ctx, "RunCommand: command exited with code %d", out.GetExitCode())
+	return out, nil
+}
+
+// ApplyProServices serves ProServices messages sent by the agent.
+// Services are disabled first, then enabled. Failures of individual services are reported in the result.
+func (s Service) ApplyProServices(ctx context.Context, msg *agentapi.ProServicesCmd) (*agentapi.ProServicesResult, error) {
+	log.Infof(ctx, "ApplyProServices: received services: enabling %q, disabling %q", msg.GetEnable(), msg.GetDisable())
+
+	disabled, err := s.system.ProDisableServices(ctx, msg.GetDisable()...)
+	if err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.system.ProEnableServices(ctx, msg.GetEnable()...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &agentapi.ProServicesResult{Services: append(disabled, enabled...)}, nil
+}
+
+// ApplySecurityUpdates serves SecurityUpdate messages sent by the agent.
+func (s Service) ApplySecurityUpdates(ctx context.Context, msg *agentapi.SecurityUpdateCmd) (*agentapi.SecurityUpdateResult, error) {
+	log.Info(ctx, "ApplySecurityUpdates: received security update request")
+
+	result, err := s.system.SecurityUpdate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof(ctx, "ApplySecurityUpdates: upgraded %d packages, reboot required: %t", len(result.GetPackages()), result.GetRebootRequired())
+	return result, nil
+}
+
+// CollectPackageInventory serves PackageInventory messages sent by the agent.
+func (s Service) CollectPackageInventory(ctx context.Context, msg *agentapi.PackageInventoryCmd) (*agentapi.PackageInventory, error) {
+	log.Info(ctx, "CollectPackageInventory: received package inventory request")
+
+	inventory, err := s.system.PackageInventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof(ctx, "CollectPackageInventory: collected %d compressed bytes", len(inventory.GetPackages()))
+	return inventory, nil
+}
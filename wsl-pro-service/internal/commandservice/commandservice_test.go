@@ -148,7 +148,235 @@ func TestApplyLandscapeConfig(t *testing.T) {
 	}
 }
 
+func TestApplyProxyConfig(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		emptySettings bool
+		breakProxy    bool
+
+		wantErr bool
+	}{
+		"Success setting the proxy":   {},
+		"Success unsetting the proxy": {emptySettings: true},
+
+		"Error calling pro config": {breakProxy: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			msg := &agentapi.ProxyConfigCmd{
+				HttpProxy:  "http://proxy.example.com:3128",
+				HttpsProxy: "http://proxy.example.com:3129",
+				NoProxy:    "localhost",
+			}
+			if tc.emptySettings {
+				msg = &agentapi.ProxyConfigCmd{}
+			}
+
+			sys, mock := testutils.MockSystem(t)
+
+			if tc.breakProxy {
+				mock.SetControlArg(testutils.ProConfigErr)
+			}
+
+			svc := commandservice.New(sys)
+
+			err := svc.ApplyProxyConfig(context.Background(), msg)
+			if tc.wantErr {
+				require.Error(t, err, "ApplyProxyConfig call should return an error")
+				return
+			}
+			require.NoError(t, err, "ApplyProxyConfig call should return no error")
+
+			p := mock.Path("/.pro-config-https_proxy")
+			if tc.emptySettings {
+				assert.NoFileExists(t, p, "Pro executable should have been called to unset the proxy")
+			} else {
+				assert.FileExists(t, p, "Pro executable should have been called to set the proxy")
+			}
+		})
+	}
+}
+
+func TestApplyProServices(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		enable  []string
+		disable []string
+
+		unattached bool
+
+		wantResults []*agentapi.ProServiceResult
+		wantErr     bool
+	}{
+		"Success enabling and disabling services": {
+			enable: []string{"esm-apps"}, disable: []string{"usg"},
+			wantResults: []*agentapi.ProServiceResult{{Name: "usg"}, {Name: "esm-apps", Enable: true}},
+		},
+		"Success with no services": {},
+		"Success reporting a failed service": {
+			enable:      []string{"esm-apps", "not-a-service"},
+			wantResults: []*agentapi.ProServiceResult{{Name: "esm-apps", Enable: true}, {Name: "not-a-service", Enable: true, Error: "invalid-service-or-failure: Cannot enable unknown service"}},
+		},
+
+		"Error when the distro is not attached": {enable: []string{"esm-apps"}, unattached: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sys, mock := testutils.MockSystem(t)
+			if tc.unattached {
+				mock.SetControlArg(testutils.ProServicesUnattached)
+			}
+
+			svc := commandservice.New(sys)
+
+			got, err := svc.ApplyProServices(context.Background(), &agentapi.ProServicesCmd{Enable: tc.enable, Disable: tc.disable})
+			if tc.wantErr {
+				require.Error(t, err, "ApplyProServices call should return an error")
+				return
+			}
+			require.NoError(t, err, "ApplyProServices call should return no error")
+
+			require.Len(t, got.GetServices(), len(tc.wantResults), "ApplyProServices should return one result per service")
+			for i, want := range tc.wantResults {
+				require.Equal(t, want.GetName(), got.GetServices()[i].GetName(), "Mismatch in the name of the service")
+				require.Equal(t, want.GetEnable(), got.GetServices()[i].GetEnable(), "Mismatch in the action on the service")
+				require.Equal(t, want.GetError(), got.GetServices()[i].GetError(), "Mismatch in the error of the service")
+			}
+		})
+	}
+}
+
+func TestApplySecurityUpdates(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		noUpgrades  bool
+		breakUpdate bool
+
+		wantPackages []string
+		wantErr      bool
+	}{
+		"Success upgrading packages":              {wantPackages: []string{"libssl3t64", "curl"}},
+		"Success with no upgrades":                {noUpgrades: true},
+		"Error when the update cannot be applied": {breakUpdate: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sys, mock := testutils.MockSystem(t)
+			if tc.noUpgrades {
+				mock.SetControlArg(testutils.AptNoUpgrades)
+			}
+			if tc.breakUpdate {
+				mock.SetControlArg(testutils.AptUpdateErr)
+			}
+
+			svc := commandservice.New(sys)
+
+			got, err := svc.ApplySecurityUpdates(context.Background(), &agentapi.SecurityUpdateCmd{})
+			if tc.wantErr {
+				require.Error(t, err, "ApplySecurityUpdates call should return an error")
+				return
+			}
+			require.NoError(t, err, "ApplySecurityUpdates call should return no error")
+
+			var names []string
+			for _, p := range got.GetPackages() {
+				names = append(names, p.GetName())
+			}
+			require.Equal(t, tc.wantPackages, names, "Mismatch in the upgraded packages")
+			require.False(t, got.GetRebootRequired(), "No reboot should be required")
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		argv []string
+
+		wantExitCode int32
+		wantStdout   string
+		wantStderr   string
+		wantErr      bool
+	}{
+		"Success running a command":                           {argv: []string{"echo", "hello"}, wantStdout: "hello\n"},
+		"Success running a command with a non-zero exit code": {argv: []string{"fail", "oops"}, wantExitCode: 99, wantStderr: "oops\n"},
+
+		"Error with no command": {wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sys, _ := testutils.MockSystem(t)
+			svc := commandservice.New(sys)
+
+			out, err := svc.RunCommand(context.Background(), &agentapi.RunCommandCmd{Argv: tc.argv})
+			if tc.wantErr {
+				require.Error(t, err, "RunCommand call should return an error")
+				return
+			}
+			require.NoError(t, err, "RunCommand call should return no error")
+
+			require.Equal(t, tc.wantExitCode, out.GetExitCode(), "RunCommand should return the exit code of the command")
+			require.Equal(t, tc.wantStdout, string(out.GetStdout()), "RunCommand should return the standard output of the command")
+			require.Equal(t, tc.wantStderr, string(out.GetStderr()), "RunCommand should return the standard error of the command")
+		})
+	}
+}
+
+func TestCollectPackageInventory(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		breakDpkgQuery bool
+
+		wantErr bool
+	}{
+		"Success collecting the inventory":             {},
+		"Error when the inventory cannot be collected": {breakDpkgQuery: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sys, mock := testutils.MockSystem(t)
+			if tc.breakDpkgQuery {
+				mock.SetControlArg(testutils.DpkgQueryErr)
+			}
+
+			svc := commandservice.New(sys)
+
+			got, err := svc.CollectPackageInventory(context.Background(), &agentapi.PackageInventoryCmd{})
+			if tc.wantErr {
+				require.Error(t, err, "CollectPackageInventory call should return an error")
+				return
+			}
+			require.NoError(t, err, "CollectPackageInventory call should return no error")
+			require.NotEmpty(t, got.GetPackages(), "CollectPackageInventory should return the compressed inventory")
+		})
+	}
+}
+
 func TestWithProMock(t *testing.T)             { testutils.ProMock(t) }
 func TestWithLandscapeConfigMock(t *testing.T) { testutils.LandscapeConfigMock(t) }
 func TestWithWslPathMock(t *testing.T)         { testutils.WslPathMock(t) }
 func TestWithWslInfoMock(t *testing.T)         { testutils.WslInfoMock(t) }
+func TestWithCommandMock(t *testing.T)         { testutils.CommandMock(t) }
+func TestWithAptGetMock(t *testing.T)          { testutils.AptGetMock(t) }
+func TestWithAptCacheMock(t *testing.T)        { testutils.AptCacheMock(t) }
+func TestWithDpkgQueryMock(t *testing.T)       { testutils.DpkgQueryMock(t) }
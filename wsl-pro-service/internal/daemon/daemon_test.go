@@ -517,6 +517,26 @@ func (s *mockService) ApplyLandscapeConfig(ctx context.Context, msg *agentapi.La
 	return nil
 }
 
+func (s *mockService) ApplyProxyConfig(ctx context.Context, msg *agentapi.ProxyConfigCmd) error {
+	return nil
+}
+
+func (s *mockService) RunCommand(ctx context.Context, msg *agentapi.RunCommandCmd) (*agentapi.CommandOutput, error) {
+	return &agentapi.CommandOutput{}, nil
+}
+
+func (s *mockService) ApplyProServices(ctx context.Context, msg *agentapi.ProServicesCmd) (*agentapi.ProServicesResult, error) {
+	return &agentapi.ProServicesResult{}, nil
+}
+
+func (s *mockService) ApplySecurityUpdates(ctx context.Context, msg *agentapi.SecurityUpdateCmd) (*agentapi.SecurityUpdateResult, error) {
+	return &agentapi.SecurityUpdateResult{}, nil
+}
+
+func (s *mockService) CollectPackageInventory(ctx context.Context, msg *agentapi.PackageInventoryCmd) (*agentapi.PackageInventory, error) {
+	return &agentapi.PackageInventory{}, nil
+}
+
 func TestWithProMock(t *testing.T)     { testutils.ProMock(t) }
 func TestWithWslPathMock(t *testing.T) { testutils.WslPathMock(t) }
 func TestWithWslInfoMock(t *testing.T) { testutils.WslInfoMock(t) }
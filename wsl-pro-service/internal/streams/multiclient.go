@@ -3,8 +3,10 @@ package streams
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"google.golang.org/grpc"
 )
 
@@ -15,9 +17,18 @@ type multiClient struct {
 	mainStream agentapi.WSLInstance_ConnectedClient
 	proStream  agentapi.WSLInstance_ProAttachmentCommandsClient
 	lpeStream  agentapi.WSLInstance_LandscapeConfigCommandsClient
+	proxStream agentapi.WSLInstance_ProxyConfigCommandsClient
+	runStream  agentapi.WSLInstance_RunCommandCommandsClient
+	svcStream  agentapi.WSLInstance_ProServicesCommandsClient
+	secStream  agentapi.WSLInstance_SecurityUpdateCommandsClient
+	invStream  agentapi.WSLInstance_PackageInventoryCommandsClient
+	canStream  agentapi.WSLInstance_CancelCommandsClient
+
+	// capabilities are the full method names of the optional streams that were opened.
+	capabilities []string
 }
 
-// connect connects to the three streams. Call Close to release resources.
+// connect connects to all the streams. Call Close to release resources.
 func connect(ctx context.Context, conn *grpc.ClientConn) (c *multiClient, err error) {
 	client := agentapi.NewWSLInstanceClient(conn)
 
@@ -39,21 +50,58 @@ func connect(ctx context.Context, conn *grpc.ClientConn) (c *multiClient, err er
 	}
 	defer closeOnError(&err, lpeStream)
 
+	// Older agents do not implement the optional streams: failing to open them is not fatal.
+	var capabilities []string
+	proxStream := openOptional(ctx, client.ProxyConfigCommands, agentapi.WSLInstance_ProxyConfigCommands_FullMethodName, &capabilities)
+	runStream := openOptional(ctx, client.RunCommandCommands, agentapi.WSLInstance_RunCommandCommands_FullMethodName, &capabilities)
+	svcStream := openOptional(ctx, client.ProServicesCommands, agentapi.WSLInstance_ProServicesCommands_FullMethodName, &capabilities)
+	secStream := openOptional(ctx, client.SecurityUpdateCommands, agentapi.WSLInstance_SecurityUpdateCommands_FullMethodName, &capabilities)
+	invStream := openOptional(ctx, client.PackageInventoryCommands, agentapi.WSLInstance_PackageInventoryCommands_FullMethodName, &capabilities)
+	canStream := openOptional(ctx, client.CancelCommands, agentapi.WSLInstance_CancelCommands_FullMethodName, &capabilities)
+
 	return &multiClient{
 		mainStream: mainStream,
 		proStream:  proStream,
 		lpeStream:  lpeStream,
+		proxStream: proxStream,
+		runStream:  runStream,
+		svcStream:  svcStream,
+		secStream:  secStream,
+		invStream:  invStream,
+		canStream:  canStream,
+
+		capabilities: capabilities,
 	}, nil
 }
 
+// openOptional opens an optional stream, and appends its full method name to the capabilities. If it
+// cannot be opened, it returns a nil stream and the capabilities are left unchanged.
+func openOptional[Stream any](ctx context.Context, open func(context.Context, ...grpc.CallOption) (Stream, error), method string, capabilities *[]string) Stream {
+	s, err := open(ctx)
+	if err != nil {
+		log.Warningf(ctx, "could not connect to stream %s: %v", method, err)
+		var none Stream
+		return none
+	}
+
+	*capabilities = append(*capabilities, method)
+	return s
+}
+
+// supports returns true if the optional stream with the specified full method name was opened.
+func (s *multiClient) supports(method string) bool {
+	return slices.Contains(s.capabilities, method)
+}
+
 func closeOnError(err *error, closer interface{ CloseSend() error }) {
 	if *err != nil {
 		_ = closer.CloseSend()
 	}
 }
 
-// SendInfo sends the distro info via the connected stream.
+// SendInfo sends the distro info via the connected stream, announcing the optional streams that were opened.
 func (s *multiClient) SendInfo(info *agentapi.DistroInfo) error {
+	info.Capabilities = s.capabilities
 	return s.mainStream.Send(info)
 }
 
@@ -71,6 +119,48 @@ func (s *multiClient) LandscapeConfigStream() stream[agentapi.LandscapeConfigCmd
 	}
 }
 
+// ProxyConfigStream is a getter for the ProxyConfigCmd stream.
+func (s *multiClient) ProxyConfigStream() stream[agentapi.ProxyConfigCmd] {
+	return stream[agentapi.ProxyConfigCmd]{
+		grpcStream: s.proxStream,
+	}
+}
+
+// RunCommandStream is a getter for the RunCommandCmd stream.
+func (s *multiClient) RunCommandStream() stream[agentapi.RunCommandCmd] {
+	return stream[agentapi.RunCommandCmd]{
+		grpcStream: s.runStream,
+	}
+}
+
+// ProServicesStream is a getter for the ProServicesCmd stream.
+func (s *multiClient) ProServicesStream() stream[agentapi.ProServicesCmd] {
+	return stream[agentapi.ProServicesCmd]{
+		grpcStream: s.svcStream,
+	}
+}
+
+// SecurityUpdateStream is a getter for the SecurityUpdateCmd stream.
+func (s *multiClient) SecurityUpdateStream() stream[agentapi.SecurityUpdateCmd] {
+	return stream[agentapi.SecurityUpdateCmd]{
+		grpcStream: s.secStream,
+	}
+}
+
+// PackageInventoryStream is a getter for the PackageInventoryCmd stream.
+func (s *multiClient) PackageInventoryStream() stream[agentapi.PackageInventoryCmd] {
+	return stream[agentapi.PackageInventoryCmd]{
+		grpcStream: s.invStream,
+	}
+}
+
+// CancelStream is a getter for the CancelCmd stream.
+func (s *multiClient) CancelStream() stream[agentapi.CancelCmd] {
+	return stream[agentapi.CancelCmd]{
+		grpcStream: s.canStream,
+	}
+}
+
 type grpcStream[Command any] interface {
 	Context() context.Context
 	Recv() (*Command, error)
@@ -83,16 +173,21 @@ type stream[Command any] struct {
 }
 
 func (s stream[Command]) SendResult(err error) error {
+	return s.Send(resultMsg(err))
+}
+
+// resultMsg builds the message reporting the outcome of a command. An empty result means success.
+func resultMsg(err error) *agentapi.MSG {
 	var errMsg string
 	if err != nil {
 		errMsg = err.Error()
 	}
 
-	return s.Send(&agentapi.MSG{
+	return &agentapi.MSG{
 		Data: &agentapi.MSG_Result{
 			Result: errMsg,
 		},
-	})
+	}
 }
 
 func (s stream[Command]) SendWslName(wslName string) error {
@@ -79,6 +79,12 @@ func TestConnect(t *testing.T) {
 
 			require.NotNil(t, client.ProAttachStream(), "ProAttachStream should not return nil")
 			require.NotNil(t, client.LandscapeConfigStream(), "LandscapeConfigStream should not return nil")
+			require.NotNil(t, client.ProxyConfigStream(), "ProxyConfigStream should not return nil")
+			require.NotNil(t, client.RunCommandStream(), "RunCommandStream should not return nil")
+			require.NotNil(t, client.ProServicesStream(), "ProServicesStream should not return nil")
+			require.NotNil(t, client.SecurityUpdateStream(), "SecurityUpdateStream should not return nil")
+			require.NotNil(t, client.PackageInventoryStream(), "PackageInventoryStream should not return nil")
+			require.NotNil(t, client.CancelStream(), "CancelStream should not return nil")
 		})
 	}
 }
@@ -117,7 +123,13 @@ func TestSendAndRecv(t *testing.T) {
 		connReady := service.connected.callCount.Load() > 0
 		proReady := service.proattachment.callCount.Load() > 0
 		lpeReady := service.landscapeConfig.callCount.Load() > 0
-		return connReady && proReady && lpeReady
+		proxyReady := service.proxyConfig.callCount.Load() > 0
+		runReady := service.runCommand.callCount.Load() > 0
+		svcReady := service.proServices.callCount.Load() > 0
+		secReady := service.securityUpdate.callCount.Load() > 0
+		invReady := service.packageInventory.callCount.Load() > 0
+		canReady := service.cancel.callCount.Load() > 0
+		return connReady && proReady && lpeReady && proxyReady && runReady && svcReady && secReady && invReady && canReady
 	}, 10*time.Second, 100*time.Millisecond, "Setup: streams never connected")
 
 	// Test sending messages Server->Client
@@ -135,6 +147,45 @@ func TestSendAndRecv(t *testing.T) {
 	require.NoError(t, err, "LandscapeConfigStream.Recv should not return error")
 	require.Equal(t, "[client]\nhello=world", lpeMsg.GetConfig(), "Mismatch between sent and received Landscape config")
 
+	err = service.SendProxyConfig("http://proxy.example.com:3128")
+	require.NoError(t, err, "Sending commands should not fail")
+
+	proxyMsg, err := client.ProxyConfigStream().Recv()
+	require.NoError(t, err, "ProxyConfigStream.Recv should not return error")
+	require.Equal(t, "http://proxy.example.com:3128", proxyMsg.GetHttpsProxy(), "Mismatch between sent and received proxy config")
+
+	err = service.SendRunCommand("echo", "hello")
+	require.NoError(t, err, "Sending commands should not fail")
+
+	runMsg, err := client.RunCommandStream().Recv()
+	require.NoError(t, err, "RunCommandStream.Recv should not return error")
+	require.Equal(t, []string{"echo", "hello"}, runMsg.GetArgv(), "Mismatch between sent and received command")
+
+	err = service.SendProServices("esm-apps")
+	require.NoError(t, err, "Sending commands should not fail")
+
+	svcMsg, err := client.ProServicesStream().Recv()
+	require.NoError(t, err, "ProServicesStream.Recv should not return error")
+	require.Equal(t, []string{"esm-apps"}, svcMsg.GetEnable(), "Mismatch between sent and received services")
+
+	err = service.SendSecurityUpdate()
+	require.NoError(t, err, "Sending commands should not fail")
+
+	_, err = client.SecurityUpdateStream().Recv()
+	require.NoError(t, err, "SecurityUpdateStream.Recv should not return error")
+
+	err = service.SendPackageInventory()
+	require.NoError(t, err, "Sending commands should not fail")
+
+	_, err = client.PackageInventoryStream().Recv()
+	require.NoError(t, err, "PackageInventoryStream.Recv should not return error")
+
+	err = service.SendCancel()
+	require.NoError(t, err, "Sending commands should not fail")
+
+	_, err = client.CancelStream().Recv()
+	require.NoError(t, err, "CancelStream.Recv should not return error")
+
 	// Test sending messages Client->Server
 	err = client.SendInfo(&agentapi.DistroInfo{})
 	require.NoError(t, err, "SendInfo should not return error")
@@ -151,6 +202,36 @@ func TestSendAndRecv(t *testing.T) {
 	require.Eventually(t, func() bool { return service.landscapeConfig.recvCount.Load() >= 1 },
 		5*time.Second, 100*time.Millisecond, "The server should have received a result message via the Landscape stream")
 
+	err = client.ProxyConfigStream().SendResult(nil)
+	require.NoError(t, err, "ProxyConfigStream.SendResult should not return error")
+	require.Eventually(t, func() bool { return service.proxyConfig.recvCount.Load() >= 1 },
+		5*time.Second, 100*time.Millisecond, "The server should have received a result message via the proxy stream")
+
+	err = client.RunCommandStream().SendResult(nil)
+	require.NoError(t, err, "RunCommandStream.SendResult should not return error")
+	require.Eventually(t, func() bool { return service.runCommand.recvCount.Load() >= 1 },
+		5*time.Second, 100*time.Millisecond, "The server should have received a result message via the run command stream")
+
+	err = client.ProServicesStream().SendResult(nil)
+	require.NoError(t, err, "ProServicesStream.SendResult should not return error")
+	require.Eventually(t, func() bool { return service.proServices.recvCount.Load() >= 1 },
+		5*time.Second, 100*time.Millisecond, "The server should have received a result message via the Pro services stream")
+
+	err = client.SecurityUpdateStream().SendResult(nil)
+	require.NoError(t, err, "SecurityUpdateStream.SendResult should not return error")
+	require.Eventually(t, func() bool { return service.securityUpdate.recvCount.Load() >= 1 },
+		5*time.Second, 100*time.Millisecond, "The server should have received a result message via the security update stream")
+
+	err = client.PackageInventoryStream().SendResult(nil)
+	require.NoError(t, err, "PackageInventoryStream.SendResult should not return error")
+	require.Eventually(t, func() bool { return service.packageInventory.recvCount.Load() >= 1 },
+		5*time.Second, 100*time.Millisecond, "The server should have received a result message via the package inventory stream")
+
+	err = client.CancelStream().SendResult(nil)
+	require.NoError(t, err, "CancelStream.SendResult should not return error")
+	require.Eventually(t, func() bool { return service.cancel.recvCount.Load() >= 1 },
+		5*time.Second, 100*time.Millisecond, "The server should have received a result message via the cancel stream")
+
 	// Disconnect to exercise error cases
 	conn.Close()
 
@@ -167,6 +248,9 @@ func TestSendAndRecv(t *testing.T) {
 	err = client.LandscapeConfigStream().SendResult(nil)
 	require.Error(t, err, "LandscapeConfigStream.SendResult should return an error after disconnecting")
 
+	err = client.ProxyConfigStream().SendResult(nil)
+	require.Error(t, err, "ProxyConfigStream.SendResult should return an error after disconnecting")
+
 	// Test receiving messages after disconnecting
 	_, err = client.ProAttachStream().Recv()
 	require.Error(t, err, "ProAttachStream.Recv should return an error after disconnecting")
@@ -178,9 +262,15 @@ func TestSendAndRecv(t *testing.T) {
 type agentAPIServer struct {
 	agentapi.UnimplementedWSLInstanceServer
 
-	connected       stream
-	proattachment   stream
-	landscapeConfig stream
+	connected        stream
+	proattachment    stream
+	landscapeConfig  stream
+	proxyConfig      stream
+	runCommand       stream
+	proServices      stream
+	securityUpdate   stream
+	packageInventory stream
+	cancel           stream
 }
 
 type stream struct {
@@ -231,6 +321,90 @@ func (s *agentAPIServer) LandscapeConfigCommands(stream agentapi.WSLInstance_Lan
 	}
 }
 
+func (s *agentAPIServer) ProxyConfigCommands(stream agentapi.WSLInstance_ProxyConfigCommandsServer) error {
+	s.proxyConfig.callCount.Add(1)
+	s.proxyConfig.stream.Store(stream)
+
+	for {
+		_, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		s.proxyConfig.recvCount.Add(1)
+	}
+}
+
+func (s *agentAPIServer) RunCommandCommands(stream agentapi.WSLInstance_RunCommandCommandsServer) error {
+	s.runCommand.callCount.Add(1)
+	s.runCommand.stream.Store(stream)
+
+	for {
+		_, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		s.runCommand.recvCount.Add(1)
+	}
+}
+
+func (s *agentAPIServer) ProServicesCommands(stream agentapi.WSLInstance_ProServicesCommandsServer) error {
+	s.proServices.callCount.Add(1)
+	s.proServices.stream.Store(stream)
+
+	for {
+		_, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		s.proServices.recvCount.Add(1)
+	}
+}
+
+func (s *agentAPIServer) SecurityUpdateCommands(stream agentapi.WSLInstance_SecurityUpdateCommandsServer) error {
+	s.securityUpdate.callCount.Add(1)
+	s.securityUpdate.stream.Store(stream)
+
+	for {
+		_, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		s.securityUpdate.recvCount.Add(1)
+	}
+}
+
+func (s *agentAPIServer) PackageInventoryCommands(stream agentapi.WSLInstance_PackageInventoryCommandsServer) error {
+	s.packageInventory.callCount.Add(1)
+	s.packageInventory.stream.Store(stream)
+
+	for {
+		_, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		s.packageInventory.recvCount.Add(1)
+	}
+}
+
+func (s *agentAPIServer) CancelCommands(stream agentapi.WSLInstance_CancelCommandsServer) error {
+	s.cancel.callCount.Add(1)
+	s.cancel.stream.Store(stream)
+
+	for {
+		_, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		s.cancel.recvCount.Add(1)
+	}
+}
+
 func (s *agentAPIServer) SendProAttachmentCmd(token string) error {
 	stream := s.proattachment.stream.Load()
 	if stream == nil {
@@ -252,3 +426,69 @@ func (s *agentAPIServer) SendLandscapeConfig(config string) error {
 		Config: config,
 	})
 }
+
+func (s *agentAPIServer) SendProxyConfig(httpsProxy string) error {
+	stream := s.proxyConfig.stream.Load()
+	if stream == nil {
+		return errors.New("stream not connected")
+	}
+
+	//nolint:forcetypeassert // This value is always this type (or nil, which we checked already)
+	return stream.(agentapi.WSLInstance_ProxyConfigCommandsServer).Send(&agentapi.ProxyConfigCmd{
+		HttpsProxy: httpsProxy,
+	})
+}
+
+func (s *agentAPIServer) SendRunCommand(argv ...string) error {
+	stream := s.runCommand.stream.Load()
+	if stream == nil {
+		return errors.New("stream not connected")
+	}
+
+	//nolint:forcetypeassert // This value is always this type (or nil, which we checked already)
+	return stream.(agentapi.WSLInstance_RunCommandCommandsServer).Send(&agentapi.RunCommandCmd{
+		Argv: argv,
+	})
+}
+
+func (s *agentAPIServer) SendProServices(enable ...string) error {
+	stream := s.proServices.stream.Load()
+	if stream == nil {
+		return errors.New("stream not connected")
+	}
+
+	//nolint:forcetypeassert // This value is always this type (or nil, which we checked already)
+	return stream.(agentapi.WSLInstance_ProServicesCommandsServer).Send(&agentapi.ProServicesCmd{
+		Enable: enable,
+	})
+}
+
+func (s *agentAPIServer) SendSecurityUpdate() error {
+	stream := s.securityUpdate.stream.Load()
+	if stream == nil {
+		return errors.New("stream not connected")
+	}
+
+	//nolint:forcetypeassert // This value is always this type (or nil, which we checked already)
+	return stream.(agentapi.WSLInstance_SecurityUpdateCommandsServer).Send(&agentapi.SecurityUpdateCmd{})
+}
+
+func (s *agentAPIServer) SendPackageInventory() error {
+	stream := s.packageInventory.stream.Load()
+	if stream == nil {
+		return errors.New("stream not connected")
+	}
+
+	//nolint:forcetypeassert // This value is always this type (or nil, which we checked already)
+	return stream.(agentapi.WSLInstance_PackageInventoryCommandsServer).Send(&agentapi.PackageInventoryCmd{})
+}
+
+func (s *agentAPIServer) SendCancel() error {
+	stream := s.cancel.stream.Load()
+	if stream == nil {
+		return errors.New("stream not connected")
+	}
+
+	//nolint:forcetypeassert // This value is always this type (or nil, which we checked already)
+	return stream.(agentapi.WSLInstance_CancelCommandsServer).Send(&agentapi.CancelCmd{})
+}
@@ -13,12 +13,19 @@ import (
 	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // CommandService is the interface that the real service must implement to handle the commands received from the control stream.
 type CommandService interface {
 	ApplyProToken(ctx context.Context, msg *agentapi.ProAttachCmd) error
 	ApplyLandscapeConfig(ctx context.Context, msg *agentapi.LandscapeConfigCmd) error
+	ApplyProxyConfig(ctx context.Context, msg *agentapi.ProxyConfigCmd) error
+	RunCommand(ctx context.Context, msg *agentapi.RunCommandCmd) (*agentapi.CommandOutput, error)
+	ApplyProServices(ctx context.Context, msg *agentapi.ProServicesCmd) (*agentapi.ProServicesResult, error)
+	ApplySecurityUpdates(ctx context.Context, msg *agentapi.SecurityUpdateCmd) (*agentapi.SecurityUpdateResult, error)
+	CollectPackageInventory(ctx context.Context, msg *agentapi.PackageInventoryCmd) (*agentapi.PackageInventory, error)
 }
 
 // Server is a struct that mimics a unary call server. It is backed by a bi-directional gRPC stream.
@@ -37,8 +44,16 @@ type Server struct {
 	// This context will be used for graceful stopping the server, i.e. waiting the streams to finish their current activities.
 	gracefulCtx    context.Context
 	gracefulCancel context.CancelFunc
+
+	// running holds the cancellation functions of the commands being handled, indexed by the name of their
+	// message, so that the agent can interrupt them. Each stream handles a single command at a time.
+	running   map[string]context.CancelCauseFunc
+	runningMu sync.Mutex
 }
 
+// errCommandCancelled is the result reported for commands interrupted at the request of the agent.
+var errCommandCancelled = errors.New("command cancelled")
+
 // SystemError is an error caused by a misconfiguration of the system, rather than
 // originated from Ubuntu Pro for WSL.
 type SystemError struct {
@@ -76,6 +91,8 @@ func NewServer(ctx context.Context, sys *system.System, conn *grpc.ClientConn) *
 
 		gracefulCtx:    gCtx,
 		gracefulCancel: gCancel,
+
+		running: make(map[string]context.CancelCauseFunc),
 	}
 
 	return s
@@ -111,20 +128,27 @@ func (s *Server) Serve(service CommandService) error {
 	ch := make(chan error)
 	var wg sync.WaitGroup
 
-	for _, h := range []handler{
-		newHandler(client.ProAttachStream(), service.ApplyProToken),
-		newHandler(client.LandscapeConfigStream(), service.ApplyLandscapeConfig),
-	} {
+	serve := func(h handler) {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			ch <- h.run(s, client)
+
+			err := h.run(s, client)
+			if status.Code(err) == codes.Unimplemented {
+				// The agent predates this stream: the other ones keep being served.
+				log.Warningf(s.ctx, "Server: %v", err)
+				return
+			}
+			ch <- err
 
 			// Gracefully stop other handlers once any of them exits.
 			s.gracefulCancel()
 		}()
 	}
 
+	serve(newHandler(client.ProAttachStream(), service.ApplyProToken))
+	serve(newHandler(client.LandscapeConfigStream(), service.ApplyLandscapeConfig))
+
 	// Notify Agent that we are ready
 	info, err := s.system.Info(s.ctx)
 	if err != nil {
@@ -144,6 +168,32 @@ func (s *Server) Serve(service CommandService) error {
 		return fmt.Errorf("could not serve: could not send first LandscapeConfigCmd message: %v", err)
 	}
 
+	optional := []struct {
+		method  string
+		preface func(wslName string) error
+		handler handler
+	}{
+		{agentapi.WSLInstance_ProxyConfigCommands_FullMethodName, client.ProxyConfigStream().SendWslName, newHandler(client.ProxyConfigStream(), service.ApplyProxyConfig)},
+		{agentapi.WSLInstance_RunCommandCommands_FullMethodName, client.RunCommandStream().SendWslName, newOutputHandler(client.RunCommandStream(), service.RunCommand, commandOutputMsg)},
+		{agentapi.WSLInstance_ProServicesCommands_FullMethodName, client.ProServicesStream().SendWslName, newOutputHandler(client.ProServicesStream(), service.ApplyProServices, proServicesMsg)},
+		{agentapi.WSLInstance_SecurityUpdateCommands_FullMethodName, client.SecurityUpdateStream().SendWslName, newOutputHandler(client.SecurityUpdateStream(), service.ApplySecurityUpdates, securityUpdateMsg)},
+		{agentapi.WSLInstance_PackageInventoryCommands_FullMethodName, client.PackageInventoryStream().SendWslName, newOutputHandler(client.PackageInventoryStream(), service.CollectPackageInventory, packageInventoryMsg)},
+		{agentapi.WSLInstance_CancelCommands_FullMethodName, client.CancelStream().SendWslName, &cancelLoop{stream: client.CancelStream()}},
+	}
+
+	for _, o := range optional {
+		if !client.supports(o.method) {
+			continue
+		}
+
+		if err := o.preface(info.GetWslName()); err != nil {
+			log.Warningf(s.ctx, "Server: not serving %s, which the agent may not implement: could not send first message: %v", o.method, err)
+			continue
+		}
+
+		serve(o.handler)
+	}
+
 	log.Debug(s.ctx, "Server: sent preface messages to all streams")
 
 	go func() {
@@ -171,15 +221,70 @@ type handler interface {
 // This is essentially a handler factory.
 func newHandler[Command any](stream stream[Command], callback func(context.Context, *Command) error) handler {
 	return &handlingLoop[Command]{
-		stream:   stream,
-		callback: callback,
+		stream: stream,
+		callback: func(ctx context.Context, msg *Command) *agentapi.MSG {
+			return resultMsg(callback(ctx, msg))
+		},
+	}
+}
+
+// newOutputHandler is like newHandler, but for commands whose callback produces an output to send back
+// instead of just an error. The output is wrapped into a message with toMsg.
+func newOutputHandler[Command, Output any](stream stream[Command], callback func(context.Context, *Command) (*Output, error), toMsg func(*Output) *agentapi.MSG) handler {
+	return &handlingLoop[Command]{
+		stream: stream,
+		callback: func(ctx context.Context, msg *Command) *agentapi.MSG {
+			out, err := callback(ctx, msg)
+			if err != nil {
+				return resultMsg(err)
+			}
+			return toMsg(out)
+		},
+	}
+}
+
+// commandOutputMsg wraps the output of a RunCommandCmd into a message.
+func commandOutputMsg(out *agentapi.CommandOutput) *agentapi.MSG {
+	return &agentapi.MSG{
+		Data: &agentapi.MSG_CommandOutput{
+			CommandOutput: out,
+		},
+	}
+}
+
+// proServicesMsg wraps the result of a ProServicesCmd into a message.
+func proServicesMsg(out *agentapi.ProServicesResult) *agentapi.MSG {
+	return &agentapi.MSG{
+		Data: &agentapi.MSG_ProServices{
+			ProServices: out,
+		},
+	}
+}
+
+// securityUpdateMsg wraps the result of a SecurityUpdateCmd into a message.
+func securityUpdateMsg(out *agentapi.SecurityUpdateResult) *agentapi.MSG {
+	return &agentapi.MSG{
+		Data: &agentapi.MSG_SecurityUpdate{
+			SecurityUpdate: out,
+		},
+	}
+}
+
+// packageInventoryMsg wraps the result of a PackageInventoryCmd into a message.
+func packageInventoryMsg(out *agentapi.PackageInventory) *agentapi.MSG {
+	return &agentapi.MSG{
+		Data: &agentapi.MSG_PackageInventory{
+			PackageInventory: out,
+		},
 	}
 }
 
 // handlingLoop implements the logic of the request handling loop.
 type handlingLoop[Command any] struct {
-	stream   stream[Command]
-	callback func(context.Context, *Command) error
+	stream stream[Command]
+
+	// callback handles the command and returns the reply to send back.
+	callback func(context.Context, *Command) *agentapi.MSG
 }
 
 func (h *handlingLoop[Command]) run(s *Server, client *multiClient) error {
@@ -199,22 +304,23 @@ func (h *handlingLoop[Command]) run(s *Server, client *multiClient) error {
 		// Handle a single command responsive to the cancellation of s.gracefulCtx.
 		msg, ok, err := receiveWithContext(s.gracefulCtx, h.stream.Recv)
 		if err != nil {
-			return fmt.Errorf("could not receive ProAttachCmd: %w", err)
+			return fmt.Errorf("could not receive %s: %w", reflect.TypeFor[Command]().Name(), err)
 		} else if !ok {
 			// Non-erroneous exit. Probably a graceful stop.
 			return nil
 		}
 
-		result := h.callback(ctx, msg)
+		reply := h.handle(s, ctx, msg)
 
-		if err := h.stream.SendResult(result); err != nil {
-			return fmt.Errorf("could not send ProAttachCmd result: %w", err)
+		if err := h.stream.Send(reply); err != nil {
+			return fmt.Errorf("could not send %s result: %w", reflect.TypeFor[Command]().Name(), err)
 		}
 
 		// Send back updated info after command completion
 		info, err := s.system.Info(ctx)
 		if err != nil {
 			log.Warningf(ctx, "Streamserver: could not gather info after command completion: %v", err)
+			continue
 		}
 
 		if err = client.SendInfo(info); err != nil {
@@ -223,6 +329,71 @@ func (h *handlingLoop[Command]) run(s *Server, client *multiClient) error {
 	}
 }
 
+// handle runs the callback under a context that the agent can cancel via the cancel stream.
+func (h *handlingLoop[Command]) handle(s *Server, ctx context.Context, msg *Command) *agentapi.MSG {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	command := reflect.TypeFor[Command]().Name()
+
+	s.runningMu.Lock()
+	s.running[command] = cancel
+	s.runningMu.Unlock()
+
+	reply := h.callback(ctx, msg)
+
+	s.runningMu.Lock()
+	delete(s.running, command)
+	s.runningMu.Unlock()
+
+	if errors.Is(context.Cause(ctx), errCommandCancelled) {
+		log.Infof(ctx, "Streamserver: %s was cancelled", reflect.TypeFor[Command]().Name())
+		return resultMsg(errCommandCancelled)
+	}
+
+	return reply
+}
+
+// cancelRunning interrupts the command being handled whose message has the specified name. An empty name,
+// as sent by older agents, interrupts all of them.
+func (s *Server) cancelRunning(command string) {
+	s.runningMu.Lock()
+	defer s.runningMu.Unlock()
+
+	for name, cancel := range s.running {
+		if command == "" || name == command {
+			cancel(errCommandCancelled)
+		}
+	}
+}
+
+// cancelLoop serves the requests of the agent to cancel the commands being handled.
+type cancelLoop struct {
+	stream stream[agentapi.CancelCmd]
+}
+
+func (h *cancelLoop) run(s *Server, _ *multiClient) error {
+	ctx := h.stream.Context()
+	log.Debug(ctx, "Started serving cancellation requests")
+
+	for {
+		cmd, ok, err := receiveWithContext(s.gracefulCtx, h.stream.Recv)
+		if err != nil {
+			return fmt.Errorf("could not receive CancelCmd: %w", err)
+		} else if !ok {
+			log.Debug(ctx, "Stopping serving cancellation requests")
+			return nil
+		}
+
+		log.Infof(ctx, "Streamserver: cancelling running %q at the request of the agent", cmd.GetCommand())
+		s.cancelRunning(cmd.GetCommand())
+
+		if err := h.stream.SendResult(nil); err != nil {
+			return fmt.Errorf("could not send CancelCmd result: %w", err)
+		}
+	}
+}
+
 // Receive with context calls the recv receiver asyncronously.
 // Returns (message, message error) if recv returned.
 // Returns (nil, context error) if the context was cancelled.
@@ -3,7 +3,10 @@ package streams_test
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -77,6 +80,178 @@ func TestServe(t *testing.T) {
 	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the Pro attach command")
 	require.NotEmpty(t, agent.Service.LandscapeConfig.History()[2].GetResult(), "LandscapeConfig should return an error result")
 
+	// Test receiving proxy settings and returning success
+	err = agent.Service.ProxyConfig.Send(&agentapi.ProxyConfigCmd{HttpsProxy: "http://proxy.example.com:3128"})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.ProxyConfig.History()) > 1
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the proxy config command")
+	require.Empty(t, agent.Service.ProxyConfig.History()[1].GetResult(), "ProxyConfig should return a successful result")
+
+	// Test receiving proxy settings and returning error
+	err = agent.Service.ProxyConfig.Send(&agentapi.ProxyConfigCmd{HttpsProxy: "HARDCODED_FAILURE"})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.ProxyConfig.History()) > 2
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the proxy config command")
+	require.NotEmpty(t, agent.Service.ProxyConfig.History()[2].GetResult(), "ProxyConfig should return an error result")
+
+	// Test running a command and returning its output
+	err = agent.Service.RunCommand.Send(&agentapi.RunCommandCmd{Argv: []string{"echo", "hello"}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.RunCommand.History()) > 1
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the run command")
+	out := agent.Service.RunCommand.History()[1].GetCommandOutput()
+	require.NotNil(t, out, "RunCommand should return the command output")
+	require.Equal(t, "hello", string(out.GetStdout()), "RunCommand should return the output of the command")
+
+	// Test running a command and returning error
+	err = agent.Service.RunCommand.Send(&agentapi.RunCommandCmd{Argv: []string{"HARDCODED_FAILURE"}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.RunCommand.History()) > 2
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the run command")
+	require.NotEmpty(t, agent.Service.RunCommand.History()[2].GetResult(), "RunCommand should return an error result")
+
+	// Test applying Pro services and returning their results
+	err = agent.Service.ProServices.Send(&agentapi.ProServicesCmd{Enable: []string{"esm-apps"}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.ProServices.History()) > 1
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the Pro services command")
+	services := agent.Service.ProServices.History()[1].GetProServices()
+	require.NotNil(t, services, "ProServices should return the results of the services")
+	require.Len(t, services.GetServices(), 1, "ProServices should return one result per service")
+	require.Equal(t, "esm-apps", services.GetServices()[0].GetName(), "ProServices should return the result of the service")
+
+	// Test applying Pro services and returning error
+	err = agent.Service.ProServices.Send(&agentapi.ProServicesCmd{Enable: []string{"HARDCODED_FAILURE"}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.ProServices.History()) > 2
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the Pro services command")
+	require.NotEmpty(t, agent.Service.ProServices.History()[2].GetResult(), "ProServices should return an error result")
+
+	// Test applying security updates and returning their results
+	err = agent.Service.SecurityUpdate.Send(&agentapi.SecurityUpdateCmd{})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.SecurityUpdate.History()) > 1
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the security update command")
+	update := agent.Service.SecurityUpdate.History()[1].GetSecurityUpdate()
+	require.NotNil(t, update, "SecurityUpdate should return the result of the update")
+	require.Len(t, update.GetPackages(), 1, "SecurityUpdate should return the upgraded packages")
+	require.True(t, update.GetRebootRequired(), "SecurityUpdate should return whether a reboot is required")
+
+	// Test collecting the package inventory
+	err = agent.Service.PackageInventory.Send(&agentapi.PackageInventoryCmd{})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.PackageInventory.History()) > 1
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the package inventory command")
+	inventory := agent.Service.PackageInventory.History()[1].GetPackageInventory()
+	require.NotNil(t, inventory, "PackageInventory should return the inventory")
+	require.Equal(t, []byte("MOCK_INVENTORY"), inventory.GetPackages(), "PackageInventory should return the collected inventory")
+
+	// Test cancelling a running command
+	err = agent.Service.RunCommand.Send(&agentapi.RunCommandCmd{Argv: []string{"HARDCODED_BLOCK"}})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return service.running.Load()
+	}, 20*time.Second, 100*time.Millisecond, "Server did not start running the command")
+
+	// Cancelling another command leaves the running one alone
+	err = agent.Service.Cancel.Send(&agentapi.CancelCmd{Command: "ProServicesCmd"})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Cancel.History()) > 1
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the cancel command")
+	require.Empty(t, agent.Service.Cancel.History()[1].GetResult(), "Cancel should return a successful result")
+	require.True(t, service.running.Load(), "Cancelling another command should not interrupt the running one")
+	require.Len(t, agent.Service.RunCommand.History(), 3, "Cancelling another command should not interrupt the running one")
+
+	err = agent.Service.Cancel.Send(&agentapi.CancelCmd{Command: "RunCommandCmd"})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.Cancel.History()) > 2
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the cancel command")
+	require.Empty(t, agent.Service.Cancel.History()[2].GetResult(), "Cancel should return a successful result")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.RunCommand.History()) > 3
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the cancelled command")
+	require.Equal(t, "command cancelled", agent.Service.RunCommand.History()[3].GetResult(), "RunCommand should report the cancellation")
+
+	server.GracefulStop()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err, "Serve should not return an error when gracefully stopped")
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "GracefulStop should interrupt Serve")
+	}
+}
+
+func TestServeOlderAgent(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	sys, _ := testutils.MockSystem(t)
+
+	optional := []string{
+		agentapi.WSLInstance_ProxyConfigCommands_FullMethodName,
+		agentapi.WSLInstance_RunCommandCommands_FullMethodName,
+		agentapi.WSLInstance_ProServicesCommands_FullMethodName,
+		agentapi.WSLInstance_SecurityUpdateCommands_FullMethodName,
+		agentapi.WSLInstance_PackageInventoryCommands_FullMethodName,
+		agentapi.WSLInstance_CancelCommands_FullMethodName,
+	}
+
+	agent := testutils.NewMockWindowsAgent(t, ctx, t.TempDir(), testutils.WithUnimplemented(optional...))
+	defer agent.Stop()
+
+	conn, err := grpc.NewClient(agent.Listener.Addr().String(),
+		grpc.WithTransportCredentials(agent.ClientCredentials))
+	require.NoError(t, err, "Setup: could not create a client to the mock windows agent")
+	defer conn.Close()
+
+	server := streams.NewServer(ctx, sys, conn)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(&mockService{})
+		close(errCh)
+	}()
+
+	require.Eventually(t, agent.Service.BaseConnected, 20*time.Second, 500*time.Millisecond, "Setup: Agent service never became ready")
+
+	// The optional streams failing must not stop the base ones from being served.
+	time.Sleep(time.Second)
+	select {
+	case err := <-errCh:
+		require.Fail(t, "Serve should not return when the agent does not implement the optional streams", "Serve returned: %v", err)
+	default:
+	}
+
+	err = agent.Service.ProAttachment.Send(&agentapi.ProAttachCmd{Token: "token345"})
+	require.NoError(t, err, "Send should return no error")
+
+	require.Eventually(t, func() bool {
+		return len(agent.Service.ProAttachment.History()) > 1
+	}, 20*time.Second, 100*time.Millisecond, "Server did not send a response to the Pro attach command")
+	require.Empty(t, agent.Service.ProAttachment.History()[1].GetResult(), "ProAttachment should return a successful result")
+
 	server.GracefulStop()
 	select {
 	case err := <-errCh:
@@ -139,6 +314,9 @@ type mockService struct {
 	mu            sync.RWMutex
 
 	ctx context.Context
+
+	// running is set while a blocking command runs.
+	running atomic.Bool
 }
 
 func (s *mockService) setBlocking(ctx context.Context) {
@@ -193,6 +371,52 @@ func (s *mockService) ApplyLandscapeConfig(ctx context.Context, msg *agentapi.La
 	return nil
 }
 
+func (s *mockService) ApplyProxyConfig(ctx context.Context, msg *agentapi.ProxyConfigCmd) error {
+	if msg.GetHttpsProxy() == "HARDCODED_FAILURE" {
+		return errors.New("mock error")
+	}
+
+	return nil
+}
+
+func (s *mockService) RunCommand(ctx context.Context, msg *agentapi.RunCommandCmd) (*agentapi.CommandOutput, error) {
+	if msg.GetArgv()[0] == "HARDCODED_FAILURE" {
+		return nil, errors.New("mock error")
+	}
+
+	if msg.GetArgv()[0] == "HARDCODED_BLOCK" {
+		s.running.Store(true)
+		defer s.running.Store(false)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	return &agentapi.CommandOutput{Stdout: []byte(strings.Join(msg.GetArgv()[1:], " "))}, nil
+}
+
+func (s *mockService) ApplyProServices(ctx context.Context, msg *agentapi.ProServicesCmd) (*agentapi.ProServicesResult, error) {
+	if slices.Contains(msg.GetEnable(), "HARDCODED_FAILURE") {
+		return nil, errors.New("mock error")
+	}
+
+	var results []*agentapi.ProServiceResult
+	for _, name := range msg.GetEnable() {
+		results = append(results, &agentapi.ProServiceResult{Name: name, Enable: true})
+	}
+	return &agentapi.ProServicesResult{Services: results}, nil
+}
+
+func (s *mockService) ApplySecurityUpdates(ctx context.Context, msg *agentapi.SecurityUpdateCmd) (*agentapi.SecurityUpdateResult, error) {
+	return &agentapi.SecurityUpdateResult{
+		Packages:       []*agentapi.UpgradedPackage{{Name: "libssl3t64", OldVersion: "1", NewVersion: "2"}},
+		RebootRequired: true,
+	}, nil
+}
+
+func (s *mockService) CollectPackageInventory(ctx context.Context, msg *agentapi.PackageInventoryCmd) (*agentapi.PackageInventory, error) {
+	return &agentapi.PackageInventory{Packages: []byte("MOCK_INVENTORY")}, nil
+}
+
 func TestWithProMock(t *testing.T)     { testutils.ProMock(t) }
 func TestWithWslPathMock(t *testing.T) { testutils.WslPathMock(t) }
 func TestWithWslInfoMock(t *testing.T) { testutils.WslInfoMock(t) }
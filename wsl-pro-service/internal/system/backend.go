@@ -52,6 +52,29 @@ func (b realBackend) WslinfoExecutable(ctx context.Context, args ...string) *exe
 	return exec.CommandContext(ctx, "wslinfo", args...)
 }
 
+// AptGetExecutable returns the full command to run the apt-get executable with the provided arguments,
+// without prompting for input.
+func (b realBackend) AptGetExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	//#nosec G204 // We control the input variables, there is no risk of command injection.
+	cmd := exec.CommandContext(ctx, "apt-get", args...)
+
+	// Package scripts need the full environment of the service.
+	cmd.Env = append(os.Environ(), "DEBIAN_FRONTEND=noninteractive")
+	return cmd
+}
+
+// AptCacheExecutable returns the full command to run the apt-cache executable with the provided arguments.
+func (b realBackend) AptCacheExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	//#nosec G204 // We control the input variables, there is no risk of command injection.
+	return exec.CommandContext(ctx, "apt-cache", args...)
+}
+
+// DpkgQueryExecutable returns the full command to run the dpkg-query executable with the provided arguments.
+func (b realBackend) DpkgQueryExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	//#nosec G204 // We control the input variables, there is no risk of command injection.
+	return exec.CommandContext(ctx, "dpkg-query", args...)
+}
+
 func (b realBackend) CmdExe(ctx context.Context, path string, args ...string) *exec.Cmd {
 	//#nosec G204 // We control the input variables, there is no risk of command injection.
 	cmd := exec.CommandContext(ctx, path, args...)
@@ -62,6 +85,17 @@ func (b realBackend) CmdExe(ctx context.Context, path string, args ...string) *e
 	return cmd
 }
 
+// CommandExecutable returns the full command to run an arbitrary command as the provided user, or as the user
+// of the service if empty.
+func (b realBackend) CommandExecutable(ctx context.Context, user string, argv ...string) *exec.Cmd {
+	if user != "" {
+		argv = append([]string{"runuser", "--user", user, "--"}, argv...)
+	}
+
+	//#nosec G204 // Running the commands sent by the agent is the purpose of this function.
+	return exec.CommandContext(ctx, argv[0], argv[1:]...)
+}
+
 func (b realBackend) LookupGroup(name string) (*user.Group, error) {
 	return user.LookupGroup("landscape")
 }
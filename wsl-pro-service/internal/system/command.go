@@ -0,0 +1,95 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"time"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/ubuntu/decorate"
+)
+
+// maxCommandOutput is the number of bytes of each of the outputs of a command that are sent back to the agent.
+const maxCommandOutput = 64 * 1024
+
+// RunCommand runs a command sent by the agent, and returns its exit code along with the beginning of its outputs.
+// Commands that ran, no matter their exit code, return no error.
+func (s *System) RunCommand(ctx context.Context, msg *agentapi.RunCommandCmd) (out *agentapi.CommandOutput, err error) {
+	argv := msg.GetArgv()
+	if len(argv) == 0 {
+		return nil, errors.New("could not run command: no command specified")
+	}
+	defer decorate.OnError(&err, "could not run command %q", argv[0])
+
+	if timeout := msg.GetTimeoutSeconds(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	cmd := s.backend.CommandExecutable(ctx, msg.GetUser(), argv...)
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, msg.GetEnv()...)
+
+	if dir := msg.GetWorkingDir(); dir != "" {
+		cmd.Dir = s.backend.Path(dir)
+	}
+
+	cmd.Stdin = bytes.NewReader(msg.GetStdin())
+
+	stdout := &truncatingBuffer{limit: maxCommandOutput}
+	stderr := &truncatingBuffer{limit: maxCommandOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return nil, fmt.Errorf("timed out after %ds", msg.GetTimeoutSeconds())
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("cancelled: %v", context.Cause(ctx))
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, err
+	}
+
+	return &agentapi.CommandOutput{
+		ExitCode:  int32(max(min(cmd.ProcessState.ExitCode(), math.MaxInt32), math.MinInt32)),
+		Stdout:    stdout.Bytes(),
+		Stderr:    stderr.Bytes(),
+		Truncated: stdout.truncated || stderr.truncated,
+	}, nil
+}
+
+// truncatingBuffer is a writer that keeps the first bytes written to it, up to a limit, and discards the rest.
+type truncatingBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write never fails, so that the command is not interrupted when its output is too long.
+func (b *truncatingBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		b.buf.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// Bytes returns the bytes that were kept.
+func (b *truncatingBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
@@ -0,0 +1,151 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/ubuntu/decorate"
+	"google.golang.org/protobuf/proto"
+)
+
+// esmHost is the host of the Expanded Security Maintenance archives.
+const esmHost = "esm.ubuntu.com"
+
+// dpkgInventoryFormat is the format of each line of the dpkg-query output parsed by PackageInventory.
+const dpkgInventoryFormat = `${db:Status-Status}\t${binary:Package}\t${Package}\t${Version}\t${Architecture}\n`
+
+// PackageInventory lists the packages installed in the distro, along with the archive their installed
+// version comes from. The list is returned compressed.
+func (s *System) PackageInventory(ctx context.Context) (inventory *agentapi.PackageInventory, err error) {
+	defer decorate.OnError(&err, "could not collect the package inventory")
+
+	out, err := runCommand(s.backend.DpkgQueryExecutable(ctx, "--show", "--showformat="+dpkgInventoryFormat))
+	if err != nil {
+		return nil, err
+	}
+
+	var packages []*agentapi.InstalledPackage
+	var names []string
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.Split(sc.Text(), "\t")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected dpkg-query output: %q", sc.Text())
+		}
+
+		// Removed packages whose configuration files remain are listed too.
+		if fields[0] != "installed" {
+			continue
+		}
+
+		names = append(names, fields[1])
+		packages = append(packages, &agentapi.InstalledPackage{
+			Name:         fields[2],
+			Version:      fields[3],
+			Architecture: fields[4],
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(names) > 0 {
+		out, err = runCommand(s.backend.AptCacheExecutable(ctx, append([]string{"policy"}, names...)...))
+		if err != nil {
+			return nil, err
+		}
+
+		origins := installedOrigins(out)
+		for _, p := range packages {
+			o, ok := origins[p.GetName()+":"+p.GetArchitecture()]
+			if !ok {
+				o = origins[p.GetName()]
+			}
+			p.Origin = o.suite
+			p.Esm = o.esm
+		}
+	}
+
+	list, err := proto.Marshal(&agentapi.PackageList{Packages: packages})
+	if err != nil {
+		return nil, err
+	}
+
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	if _, err := w.Write(list); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return &agentapi.PackageInventory{Packages: compressed.Bytes()}, nil
+}
+
+// packageOrigin is the archive the installed version of a package comes from.
+type packageOrigin struct {
+	suite string
+	esm   bool
+}
+
+// installedOrigins parses the output of `apt-cache policy`, such as:
+//
+//	libssl3t64:
+//	  Installed: 3.0.13-0ubuntu3.4
+//	  Candidate: 3.0.13-0ubuntu3.4
+//	  Version table:
+//	 *** 3.0.13-0ubuntu3.4 500
+//	        500 http://archive.ubuntu.com/ubuntu noble-updates/main amd64 Packages
+//	        100 /var/lib/dpkg/status
+//	     3.0.13-0ubuntu3 500
+//	        500 http://archive.ubuntu.com/ubuntu noble/main amd64 Packages
+//
+// and returns the preferred archive of the installed version of each package, indexed by package name.
+// Packages of a foreign architecture are indexed by name:arch. Packages whose installed version is not
+// available in any archive are left out.
+func installedOrigins(out []byte) map[string]packageOrigin {
+	origins := make(map[string]packageOrigin)
+
+	var pkg string
+	var installed bool
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, " ") {
+			pkg = strings.TrimSuffix(line, ":")
+			installed = false
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) > 0 && fields[0] == "***":
+			installed = true
+		case len(fields) == 2:
+			// Another version in the table
+			installed = false
+		case installed && len(fields) >= 3:
+			if _, found := origins[pkg]; found {
+				continue
+			}
+
+			// Sources look like: 500 http://archive.ubuntu.com/ubuntu noble-updates/main amd64 Packages
+			u, err := url.Parse(fields[1])
+			if err != nil || u.Scheme == "" {
+				// Not an archive, such as /var/lib/dpkg/status
+				continue
+			}
+			suite, _, _ := strings.Cut(fields[2], "/")
+			origins[pkg] = packageOrigin{suite: suite, esm: u.Hostname() == esmHost}
+		}
+	}
+
+	return origins
+}
@@ -1,10 +1,13 @@
 package system
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	"github.com/ubuntu/decorate"
 )
 
@@ -78,3 +81,117 @@ func (s *System) ProDetach(ctx context.Context) (err error) {
 	}
 	return nil
 }
+
+// ProSetProxy configures the proxies that pro uses to reach the contracts server.
+// Empty proxies are unset.
+func (s *System) ProSetProxy(ctx context.Context, httpProxy, httpsProxy, noProxy string) (err error) {
+	defer decorate.OnError(&err, "pro config")
+
+	for _, setting := range []struct{ key, value string }{
+		{"http_proxy", httpProxy},
+		{"https_proxy", httpsProxy},
+	} {
+		args := []string{"config", "unset", setting.key}
+		if setting.value != "" {
+			args = []string{"config", "set", setting.key + "=" + setting.value}
+		}
+
+		cmd := s.backend.ProExecutable(ctx, args...)
+		// Pro validates the proxy before setting it, so it needs to know which hosts bypass it.
+		cmd.Env = append(cmd.Env, "NO_PROXY="+noProxy, "no_proxy="+noProxy)
+		if _, err := runCommand(cmd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ProEnableServices enables the Ubuntu Pro services and returns the outcome for each of them.
+// Services that could not be enabled are reported in the results rather than as an error.
+func (s *System) ProEnableServices(ctx context.Context, services ...string) (results []*agentapi.ProServiceResult, err error) {
+	defer decorate.OnError(&err, "pro enable")
+	return s.proServices(ctx, "enable", services)
+}
+
+// ProDisableServices disables the Ubuntu Pro services and returns the outcome for each of them.
+// Services that could not be disabled are reported in the results rather than as an error.
+func (s *System) ProDisableServices(ctx context.Context, services ...string) (results []*agentapi.ProServiceResult, err error) {
+	defer decorate.OnError(&err, "pro disable")
+	return s.proServices(ctx, "disable", services)
+}
+
+// alreadyDoneCodes are the message codes with which pro reports as failed the services that were in the
+// requested state already.
+var alreadyDoneCodes = []string{"service-already-enabled", "service-already-disabled"}
+
+// proServices runs `pro enable` or `pro disable` on the services and parses the per-service results.
+func (s *System) proServices(ctx context.Context, verb string, services []string) ([]*agentapi.ProServiceResult, error) {
+	if len(services) == 0 {
+		return nil, nil
+	}
+
+	args := append([]string{verb}, services...)
+	cmd := s.backend.ProExecutable(ctx, append(args, "--assume-yes", "--format=json")...)
+
+	// Pro exits with an error if any of the services fails, but still reports every service.
+	out, runErr := runCommand(cmd)
+
+	// Pro may print warnings before the JSON, so only the last line is parsed.
+	if i := bytes.LastIndexByte(out, '\n'); i != -1 {
+		out = out[i+1:]
+	}
+
+	var report struct {
+		Errors []struct {
+			MessageCode string `json:"message_code"`
+			Message     string
+			Service     string
+		}
+		FailedServices    []string `json:"failed_services"`
+		ProcessedServices []string `json:"processed_services"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		if runErr != nil {
+			return nil, runErr
+		}
+		return nil, fmt.Errorf("could not parse output: %v. Output: %s", err, string(out))
+	}
+
+	if runErr != nil && len(report.FailedServices) == 0 {
+		// The command failed as a whole, for instance because the distro is not attached.
+		if len(report.Errors) == 0 {
+			return nil, runErr
+		}
+		return nil, fmt.Errorf("command returned error: %s: %s", report.Errors[0].MessageCode, report.Errors[0].Message)
+	}
+
+	results := make([]*agentapi.ProServiceResult, 0, len(services))
+	for _, name := range services {
+		r := &agentapi.ProServiceResult{Name: name, Enable: verb == "enable"}
+
+		switch {
+		case slices.Contains(report.FailedServices, name):
+			r.Error = "failed"
+			for _, e := range report.Errors {
+				if e.Service != name {
+					continue
+				}
+				if slices.Contains(alreadyDoneCodes, e.MessageCode) {
+					// The service is in the requested state already.
+					r.Error = ""
+					break
+				}
+				r.Error = fmt.Sprintf("%s: %s", e.MessageCode, e.Message)
+				break
+			}
+		case slices.Contains(report.ProcessedServices, name):
+		default:
+			r.Error = "not processed"
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
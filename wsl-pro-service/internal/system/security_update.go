@@ -0,0 +1,91 @@
+package system
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	"github.com/ubuntu/decorate"
+)
+
+// rebootRequiredFile is created by the packages whose upgrades only take effect after a restart.
+const rebootRequiredFile = "/run/reboot-required"
+
+// simulatedUpgrade matches the upgrades in the output of `apt-get --simulate`, such as:
+//
+//	Inst libssl3t64 [3.0.13-0ubuntu3.1] (3.0.13-0ubuntu3.4 Ubuntu:24.04/noble-updates, Ubuntu:24.04/noble-security [amd64])
+//
+// Packages that are installed for the first time have no current version, so they do not match.
+var simulatedUpgrade = regexp.MustCompile(`^Inst (\S+) \[([^\]]+)\] \((\S+) ([^\[]*)`)
+
+// SecurityUpdate refreshes the package lists and upgrades the packages with pending security updates,
+// including the ones from Expanded Security Maintenance when the distro is attached to Ubuntu Pro.
+// Other pending upgrades are left alone.
+func (s *System) SecurityUpdate(ctx context.Context) (result *agentapi.SecurityUpdateResult, err error) {
+	defer decorate.OnError(&err, "could not apply security updates")
+
+	if _, err := runCommand(s.backend.AptGetExecutable(ctx, "update", "--quiet")); err != nil {
+		return nil, err
+	}
+
+	out, err := runCommand(s.backend.AptGetExecutable(ctx, "dist-upgrade", "--simulate", "--quiet"))
+	if err != nil {
+		return nil, err
+	}
+
+	upgrades := securityUpgrades(out)
+	if len(upgrades) > 0 {
+		args := []string{"install", "--quiet", "--assume-yes", "--only-upgrade",
+			"-o", "Dpkg::Options::=--force-confdef", "-o", "Dpkg::Options::=--force-confold"}
+		// Versions are pinned, so that upgrades published since the simulation are left alone.
+		for _, p := range upgrades {
+			args = append(args, p.GetName()+"="+p.GetNewVersion())
+		}
+
+		if _, err := runCommand(s.backend.AptGetExecutable(ctx, args...)); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = os.Stat(s.backend.Path(rebootRequiredFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return &agentapi.SecurityUpdateResult{
+		Packages:       upgrades,
+		RebootRequired: err == nil,
+	}, nil
+}
+
+// securityUpgrades parses the output of `apt-get --simulate` and returns the upgrades that come from a
+// security pocket, such as noble-security or the ESM noble-apps-security.
+func securityUpgrades(out []byte) (upgrades []*agentapi.UpgradedPackage) {
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		m := simulatedUpgrade.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+
+		for origin := range strings.SplitSeq(m[4], ",") {
+			// Origins look like Ubuntu:24.04/noble-security
+			if strings.HasSuffix(strings.TrimSpace(origin), "-security") {
+				upgrades = append(upgrades, &agentapi.UpgradedPackage{
+					Name:       m[1],
+					OldVersion: m[2],
+					NewVersion: m[3],
+				})
+				break
+			}
+		}
+	}
+
+	return upgrades
+}
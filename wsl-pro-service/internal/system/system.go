@@ -9,6 +9,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"os/user"
@@ -17,6 +18,8 @@ import (
 	"unicode/utf16"
 
 	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
+	log "github.com/canonical/ubuntu-pro-for-wsl/common/grpc/logstreamer"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/consts"
 	"github.com/ubuntu/decorate"
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
@@ -47,8 +50,12 @@ type Backend interface {
 	LandscapeConfigExecutable(ctx context.Context, args ...string) *exec.Cmd
 	WslpathExecutable(ctx context.Context, args ...string) *exec.Cmd
 	WslinfoExecutable(ctx context.Context, args ...string) *exec.Cmd
+	AptGetExecutable(ctx context.Context, args ...string) *exec.Cmd
+	AptCacheExecutable(ctx context.Context, args ...string) *exec.Cmd
+	DpkgQueryExecutable(ctx context.Context, args ...string) *exec.Cmd
 
 	CmdExe(ctx context.Context, path string, args ...string) *exec.Cmd
+	CommandExecutable(ctx context.Context, user string, argv ...string) *exec.Cmd
 }
 
 type options struct {
@@ -100,18 +107,60 @@ func (s System) Info(ctx context.Context) (*agentapi.DistroInfo, error) {
 	}
 
 	info := &agentapi.DistroInfo{
-		WslName:     distroName,
-		ProAttached: pro,
-		Hostname:    hostname,
+		WslName:        distroName,
+		ProAttached:    pro,
+		Hostname:       hostname,
+		ServiceVersion: consts.Version,
 	}
 
 	if err := s.fillOsRelease(info); err != nil {
 		return nil, err
 	}
 
+	// Labels are not worth losing the connection over: the distro is reported without them instead.
+	if err := s.fillLabels(info); err != nil {
+		log.Warningf(ctx, "Could not obtain the labels of the distro: %v", err)
+	}
+
 	return info, nil
 }
 
+// labelsFileName is the file where the labels of the distro are declared, either one per
+// line or separated by commas. Lines starting with '#' are ignored.
+const labelsFileName = "/etc/wsl-pro-service/labels"
+
+// fillLabels fills the info with the labels declared in the labels file, if it exists.
+func (s System) fillLabels(info *agentapi.DistroInfo) error {
+	out, err := os.ReadFile(s.backend.Path(labelsFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("could not read %s: %v", labelsFileName, err)
+	}
+
+	var labels []string
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, l := range strings.Split(line, ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				labels = append(labels, l)
+			}
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("could not parse %s: %v", labelsFileName, err)
+	}
+
+	info.Labels = labels
+	return nil
+}
+
 // fillOSRelease fills the info with os-release file content.
 func (s System) fillOsRelease(info *agentapi.DistroInfo) error {
 	const fileName = "/etc/os-release"
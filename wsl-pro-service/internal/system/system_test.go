@@ -1,22 +1,29 @@
 package system_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 	"unicode/utf16"
 
+	agentapi "github.com/canonical/ubuntu-pro-for-wsl/agentapi/go"
 	commontestutils "github.com/canonical/ubuntu-pro-for-wsl/common/testutils"
+	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/consts"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/testutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/text/transform"
+	"google.golang.org/protobuf/proto"
 )
 
 type mockBehaviour int
@@ -72,12 +79,18 @@ func TestInfo(t *testing.T) {
 		badWslDistroName bool
 		proStatusCommand mockBehaviour
 		osRelease        mockBehaviour
+		labelsFile       string
+		breakLabelsFile  bool
 
 		hostnameErr bool
+		wantLabels  []string
 
 		wantErr bool
 	}{
-		"Success": {},
+		"Success":                {},
+		"Success with labels":    {labelsFile: "# Purpose of this distro\nCI, sandbox\n\n  customer-x  \n", wantLabels: []string{"CI", "sandbox", "customer-x"}},
+		"Success with no labels": {labelsFile: "# Nothing to see here\n"},
+		"Success without labels when the labels file cannot be read": {breakLabelsFile: true},
 
 		"Error when WslDistroName fails": {badWslDistroName: true, wantErr: true},
 
@@ -128,6 +141,16 @@ func TestInfo(t *testing.T) {
 				require.Failf(t, "Unknown enum value for osRelease", "Value: %d", tc.osRelease)
 			}
 
+			if tc.labelsFile != "" || tc.breakLabelsFile {
+				path := mock.Path("/etc/wsl-pro-service/labels")
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700), "Setup: could not create the labels file directory")
+				if tc.breakLabelsFile {
+					require.NoError(t, os.MkdirAll(path, 0700), "Setup: could not break the labels file")
+				} else {
+					require.NoError(t, os.WriteFile(path, []byte(tc.labelsFile), 0600), "Setup: could not write the labels file")
+				}
+			}
+
 			info, err := system.Info(ctx)
 			if tc.wantErr {
 				require.Error(t, err, "Expected Info() to return an error")
@@ -141,6 +164,8 @@ func TestInfo(t *testing.T) {
 			assert.Equal(t, "Ubuntu 22.04.1 LTS", info.GetPrettyName(), "PrettyName does not match expected value")
 			assert.Equal(t, "TEST_DISTRO_HOSTNAME", info.GetHostname(), "Hostname does not match expected value")
 			assert.True(t, info.GetProAttached(), "ProAttached does not match expected value")
+			assert.Equal(t, consts.Version, info.GetServiceVersion(), "ServiceVersion does not match expected value")
+			assert.Equal(t, tc.wantLabels, info.GetLabels(), "Labels do not match expected value")
 		})
 	}
 }
@@ -441,6 +466,341 @@ func TestProDetach(t *testing.T) {
 	}
 }
 
+func TestProServices(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		disable     bool
+		services    []string
+		alreadyDone bool
+
+		unattached bool
+		badJSON    bool
+
+		wantErrors map[string]bool
+		wantErr    bool
+	}{
+		"Success enabling services":                                         {services: []string{"esm-apps", "usg"}},
+		"Success disabling services":                                        {disable: true, services: []string{"esm-apps", "usg"}},
+		"Success with no services":                                          {},
+		"Success reporting a failed service":                                {services: []string{"esm-apps", "not-a-service"}, wantErrors: map[string]bool{"not-a-service": true}},
+		"Success reporting a failed disabling":                              {disable: true, services: []string{"not-a-service", "usg"}, wantErrors: map[string]bool{"not-a-service": true}},
+		"Success enabling services already enabled":                         {services: []string{"esm-apps", "usg"}, alreadyDone: true},
+		"Success disabling services already disabled":                       {disable: true, services: []string{"esm-apps", "usg"}, alreadyDone: true},
+		"Success reporting a failed service along with one already enabled": {services: []string{"not-a-service", "usg"}, alreadyDone: true, wantErrors: map[string]bool{"not-a-service": true}},
+
+		"Error when the distro is not attached":  {services: []string{"esm-apps"}, unattached: true, wantErr: true},
+		"Error when the output cannot be parsed": {services: []string{"esm-apps"}, badJSON: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			system, mock := testutils.MockSystem(t)
+			if tc.unattached {
+				mock.SetControlArg(testutils.ProServicesUnattached)
+			}
+			if tc.badJSON {
+				mock.SetControlArg(testutils.ProServicesBadJSON)
+			}
+
+			f := system.ProEnableServices
+			if tc.disable {
+				f = system.ProDisableServices
+			}
+
+			// Services are enabled beforehand so that disabling them can be observed, or so that they are
+			// enabled already.
+			if tc.disable != tc.alreadyDone {
+				for _, service := range tc.services {
+					err := os.WriteFile(mock.Path("/.pro-enabled-"+service), []byte{}, 0600)
+					require.NoError(t, err, "Setup: could not mock an enabled service")
+				}
+			}
+
+			results, err := f(context.Background(), tc.services...)
+			if tc.wantErr {
+				require.Error(t, err, "Expected the services not to be processed")
+				return
+			}
+			require.NoError(t, err, "Expected the services to be processed")
+
+			require.Len(t, results, len(tc.services), "Expected a result for each service")
+			for i, r := range results {
+				require.Equal(t, tc.services[i], r.GetName(), "Results should be in the same order as the services")
+				require.Equal(t, !tc.disable, r.GetEnable(), "Results should report whether the service was being enabled")
+
+				if tc.wantErrors[r.GetName()] {
+					require.NotEmpty(t, r.GetError(), "Expected service %q to fail", r.GetName())
+					continue
+				}
+				require.Empty(t, r.GetError(), "Expected service %q to succeed", r.GetName())
+
+				if tc.disable {
+					require.NoFileExists(t, mock.Path("/.pro-enabled-"+r.GetName()), "Expected service %q to be disabled", r.GetName())
+				} else {
+					require.FileExists(t, mock.Path("/.pro-enabled-"+r.GetName()), "Expected service %q to be enabled", r.GetName())
+				}
+			}
+		})
+	}
+}
+
+func TestProSetProxy(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		httpProxy  string
+		httpsProxy string
+		proErr     bool
+
+		wantErr bool
+	}{
+		"Success setting both proxies":  {httpProxy: "http://proxy:3128", httpsProxy: "http://proxy:3129"},
+		"Success setting only one":      {httpsProxy: "http://proxy:3129"},
+		"Success unsetting the proxies": {},
+
+		"Error on 'pro config' error": {httpsProxy: "http://proxy:3129", proErr: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			system, mock := testutils.MockSystem(t)
+			if tc.proErr {
+				mock.SetControlArg(testutils.ProConfigErr)
+			}
+
+			// A previous proxy must be overridden or removed.
+			for _, key := range []string{"http_proxy", "https_proxy"} {
+				err := os.WriteFile(mock.Path("/.pro-config-"+key), []byte("http://old-proxy:8080"), 0600)
+				require.NoError(t, err, "Setup: could not write previous proxy setting")
+			}
+
+			err := system.ProSetProxy(context.Background(), tc.httpProxy, tc.httpsProxy, "localhost")
+			if tc.wantErr {
+				require.Error(t, err, "Expected ProSetProxy to return an error")
+				return
+			}
+			require.NoError(t, err, "Expected ProSetProxy to return no errors")
+
+			for key, want := range map[string]string{"http_proxy": tc.httpProxy, "https_proxy": tc.httpsProxy} {
+				p := mock.Path("/.pro-config-" + key)
+				if want == "" {
+					require.NoFileExists(t, p, "Proxy %s should have been unset", key)
+					continue
+				}
+				got, err := os.ReadFile(p)
+				require.NoError(t, err, "Proxy %s should have been set", key)
+				require.Equal(t, want, string(got), "Proxy %s was not set to the expected value", key)
+			}
+		})
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		cmd    *agentapi.RunCommandCmd
+		cancel bool
+
+		wantExitCode  int32
+		wantStdout    string
+		wantStderr    string
+		wantTruncated bool
+		wantErr       bool
+		wantErrMsg    string
+	}{
+		"Success":                              {cmd: &agentapi.RunCommandCmd{Argv: []string{"echo", "hello", "world"}}, wantStdout: "hello world\n"},
+		"Success with stdin":                   {cmd: &agentapi.RunCommandCmd{Argv: []string{"cat"}, Stdin: []byte("from stdin")}, wantStdout: "from stdin"},
+		"Success with environment variables":   {cmd: &agentapi.RunCommandCmd{Argv: []string{"env", "GREETING"}, Env: []string{"GREETING=hi"}}, wantStdout: "hi\n"},
+		"Success with a working directory":     {cmd: &agentapi.RunCommandCmd{Argv: []string{"pwd"}, WorkingDir: "/etc"}, wantStdout: "/etc\n"},
+		"Success as root by default":           {cmd: &agentapi.RunCommandCmd{Argv: []string{"whoami"}}, wantStdout: "root\n"},
+		"Success as another user":              {cmd: &agentapi.RunCommandCmd{Argv: []string{"whoami"}, User: "ubuntu"}, wantStdout: "ubuntu\n"},
+		"Success within the timeout":           {cmd: &agentapi.RunCommandCmd{Argv: []string{"sleep", "10ms"}, TimeoutSeconds: 60}},
+		"Success with a non-zero exit code":    {cmd: &agentapi.RunCommandCmd{Argv: []string{"fail", "oops"}}, wantExitCode: 99, wantStderr: "oops\n"},
+		"Success truncating very long outputs": {cmd: &agentapi.RunCommandCmd{Argv: []string{"flood", "100000"}}, wantStdout: strings.Repeat("x", 64*1024), wantTruncated: true},
+
+		"Error with no command":                 {cmd: &agentapi.RunCommandCmd{}, wantErr: true},
+		"Error when the command times out":      {cmd: &agentapi.RunCommandCmd{Argv: []string{"sleep", "1m"}, TimeoutSeconds: 1}, wantErr: true, wantErrMsg: "timed out"},
+		"Error when the command is cancelled":   {cmd: &agentapi.RunCommandCmd{Argv: []string{"sleep", "1m"}, TimeoutSeconds: 60}, cancel: true, wantErr: true, wantErrMsg: "cancelled"},
+		"Error when the working dir is missing": {cmd: &agentapi.RunCommandCmd{Argv: []string{"pwd"}, WorkingDir: "/does/not/exist"}, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			system, mock := testutils.MockSystem(t)
+
+			ctx := context.Background()
+			if tc.cancel {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithCancel(ctx)
+				defer cancel()
+				time.AfterFunc(100*time.Millisecond, cancel)
+			}
+
+			out, err := system.RunCommand(ctx, tc.cmd)
+			if tc.wantErr {
+				require.Error(t, err, "RunCommand should return an error")
+				require.ErrorContains(t, err, tc.wantErrMsg, "RunCommand should tell timeouts from cancellations")
+				return
+			}
+			require.NoError(t, err, "RunCommand should return no error")
+
+			wantStdout := tc.wantStdout
+			if tc.cmd.GetWorkingDir() != "" {
+				wantStdout = mock.Path(tc.cmd.GetWorkingDir()) + "\n"
+			}
+
+			require.Equal(t, tc.wantExitCode, out.GetExitCode(), "Mismatch in the exit code")
+			require.Equal(t, wantStdout, string(out.GetStdout()), "Mismatch in the standard output")
+			require.Equal(t, tc.wantStderr, string(out.GetStderr()), "Mismatch in the standard error")
+			require.Equal(t, tc.wantTruncated, out.GetTruncated(), "Mismatch in whether the output was truncated")
+		})
+	}
+}
+
+func TestSecurityUpdate(t *testing.T) {
+	t.Parallel()
+
+	securityUpgrades := []*agentapi.UpgradedPackage{
+		{Name: "libssl3t64", OldVersion: "3.0.13-0ubuntu3.1", NewVersion: "3.0.13-0ubuntu3.4"},
+		{Name: "curl", OldVersion: "8.5.0-2ubuntu10.1", NewVersion: "8.5.0-2ubuntu10.6+esm1"},
+	}
+
+	testCases := map[string]struct {
+		noUpgrades     bool
+		rebootRequired bool
+
+		breakUpdate   bool
+		breakSimulate bool
+		breakInstall  bool
+
+		wantPackages []*agentapi.UpgradedPackage
+		wantErr      bool
+	}{
+		"Success upgrading only security updates":    {wantPackages: securityUpgrades},
+		"Success when a reboot is required":          {rebootRequired: true, wantPackages: securityUpgrades},
+		"Success when there are no pending upgrades": {noUpgrades: true},
+
+		"Error when the package lists cannot be refreshed": {breakUpdate: true, wantErr: true},
+		"Error when the upgrade cannot be simulated":       {breakSimulate: true, wantErr: true},
+		"Error when the packages cannot be upgraded":       {breakInstall: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			system, mock := testutils.MockSystem(t)
+
+			if tc.noUpgrades {
+				mock.SetControlArg(testutils.AptNoUpgrades)
+			}
+			if tc.breakUpdate {
+				mock.SetControlArg(testutils.AptUpdateErr)
+			}
+			if tc.breakSimulate {
+				mock.SetControlArg(testutils.AptSimulateErr)
+			}
+			if tc.breakInstall {
+				mock.SetControlArg(testutils.AptInstallErr)
+			}
+			if tc.rebootRequired {
+				path := mock.Path("/run/reboot-required")
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750), "Setup: could not create /run")
+				require.NoError(t, os.WriteFile(path, []byte("*** System restart required ***\n"), 0600), "Setup: could not write reboot-required file")
+			}
+
+			got, err := system.SecurityUpdate(context.Background())
+			if tc.wantErr {
+				require.Error(t, err, "SecurityUpdate should return an error")
+				return
+			}
+			require.NoError(t, err, "SecurityUpdate should return no error")
+
+			require.Len(t, got.GetPackages(), len(tc.wantPackages), "Mismatch in the number of upgraded packages")
+			for i, p := range got.GetPackages() {
+				require.Equal(t, tc.wantPackages[i].GetName(), p.GetName(), "Mismatch in the name of upgraded package %d", i)
+				require.Equal(t, tc.wantPackages[i].GetOldVersion(), p.GetOldVersion(), "Mismatch in the previous version of package %q", p.GetName())
+				require.Equal(t, tc.wantPackages[i].GetNewVersion(), p.GetNewVersion(), "Mismatch in the new version of package %q", p.GetName())
+			}
+			require.Equal(t, tc.rebootRequired, got.GetRebootRequired(), "Mismatch in whether a reboot is required")
+		})
+	}
+}
+
+func TestPackageInventory(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]struct {
+		breakDpkgQuery     bool
+		badDpkgQueryOutput bool
+		breakAptCache      bool
+
+		want    []*agentapi.InstalledPackage
+		wantErr bool
+	}{
+		"Success listing the installed packages": {
+			want: []*agentapi.InstalledPackage{
+				{Name: "base-files", Version: "13ubuntu10.1", Architecture: "amd64", Origin: "noble-updates"},
+				{Name: "libc6", Version: "2.39-0ubuntu8.3", Architecture: "amd64", Origin: "noble-security"},
+				{Name: "libc6", Version: "2.39-0ubuntu8.3", Architecture: "i386", Origin: "noble-security"},
+				{Name: "curl", Version: "8.5.0-2ubuntu10.6+esm1", Architecture: "amd64", Origin: "noble-apps-security", Esm: true},
+				{Name: "tzdata", Version: "2024a-3ubuntu1.1", Architecture: "all", Origin: "noble-updates"},
+				{Name: "mytool", Version: "1.0", Architecture: "amd64"},
+			},
+		},
+
+		"Error when dpkg-query fails":                   {breakDpkgQuery: true, wantErr: true},
+		"Error when dpkg-query output cannot be parsed": {badDpkgQueryOutput: true, wantErr: true},
+		"Error when apt-cache fails":                    {breakAptCache: true, wantErr: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			system, mock := testutils.MockSystem(t)
+
+			if tc.breakDpkgQuery {
+				mock.SetControlArg(testutils.DpkgQueryErr)
+			}
+			if tc.badDpkgQueryOutput {
+				mock.SetControlArg(testutils.DpkgQueryBadOutput)
+			}
+			if tc.breakAptCache {
+				mock.SetControlArg(testutils.AptCacheErr)
+			}
+
+			got, err := system.PackageInventory(context.Background())
+			if tc.wantErr {
+				require.Error(t, err, "PackageInventory should return an error")
+				return
+			}
+			require.NoError(t, err, "PackageInventory should return no error")
+
+			r, err := gzip.NewReader(bytes.NewReader(got.GetPackages()))
+			require.NoError(t, err, "The package inventory should be compressed")
+			out, err := io.ReadAll(r)
+			require.NoError(t, err, "The package inventory should be decompressed")
+
+			var list agentapi.PackageList
+			require.NoError(t, proto.Unmarshal(out, &list), "The package inventory should be a PackageList")
+
+			require.Len(t, list.GetPackages(), len(tc.want), "Mismatch in the number of installed packages")
+			for i, p := range list.GetPackages() {
+				require.True(t, proto.Equal(tc.want[i], p), "Mismatch in package %d. Want: %v. Got: %v", i, tc.want[i], p)
+			}
+		})
+	}
+}
+
 func TestLandscapeEnable(t *testing.T) {
 	t.Parallel()
 
@@ -888,6 +1248,14 @@ func TestRealBackend(t *testing.T) {
 	assertBasePath(t, "wslinfo", winfo.Path, "WslinfoExecutable did not return the expected command")
 	assert.Equal(t, []string{"wslinfo", "arg1", "arg2"}, winfo.Args, "WslinfoExecutable did not return the expected arguments")
 
+	run := b.CommandExecutable(ctx, "", "ls", "arg1")
+	assertBasePath(t, "ls", run.Path, "CommandExecutable did not return the expected command")
+	assert.Equal(t, []string{"ls", "arg1"}, run.Args, "CommandExecutable did not return the expected arguments")
+
+	runAs := b.CommandExecutable(ctx, "ubuntu", "ls", "arg1")
+	assertBasePath(t, "runuser", runAs.Path, "CommandExecutable did not return the expected command to run as another user")
+	assert.Equal(t, []string{"runuser", "--user", "ubuntu", "--", "ls", "arg1"}, runAs.Args, "CommandExecutable did not return the expected arguments to run as another user")
+
 	cmd := b.CmdExe(ctx, "/mnt/c/WINDOWS/whatever/cmd.exe", "arg1", "arg2")
 	assert.Equal(t, "/mnt/c/WINDOWS/whatever", cmd.Dir, "CmdExe did not set the expected directory")
 	assert.Equal(t, "/mnt/c/WINDOWS/whatever/cmd.exe", cmd.Path, "CmdExe did not return the expected command")
@@ -905,3 +1273,7 @@ func TestWithProMock(t *testing.T)             { testutils.ProMock(t) }
 func TestWithLandscapeConfigMock(t *testing.T) { testutils.LandscapeConfigMock(t) }
 func TestWithWslPathMock(t *testing.T)         { testutils.WslPathMock(t) }
 func TestWithWslInfoMock(t *testing.T)         { testutils.WslInfoMock(t) }
+func TestWithCommandMock(t *testing.T)         { testutils.CommandMock(t) }
+func TestWithAptGetMock(t *testing.T)          { testutils.AptGetMock(t) }
+func TestWithAptCacheMock(t *testing.T)        { testutils.AptCacheMock(t) }
+func TestWithDpkgQueryMock(t *testing.T)       { testutils.DpkgQueryMock(t) }
@@ -10,6 +10,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"testing"
 
@@ -20,7 +21,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/ubuntu/decorate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
 // MockWindowsAgent mocks the windows agent server.
@@ -42,9 +45,14 @@ type MockWindowsAgent struct {
 // You can stop it manually, otherwise it'll stop during cleanup.
 //
 //nolint:revive // testing.T should go before context, regardless of what these linters say.
-func NewMockWindowsAgent(t *testing.T, ctx context.Context, publicDir string) *MockWindowsAgent {
+func NewMockWindowsAgent(t *testing.T, ctx context.Context, publicDir string, args ...MockAgentOption) *MockWindowsAgent {
 	t.Helper()
 
+	var opts mockAgentOptions
+	for _, f := range args {
+		f(&opts)
+	}
+
 	var cfg net.ListenConfig
 	lis, err := cfg.Listen(ctx, "tcp4", "localhost:0")
 	require.NoError(t, err, "Setup: could not listen to agent address")
@@ -53,7 +61,7 @@ func NewMockWindowsAgent(t *testing.T, ctx context.Context, publicDir string) *M
 
 	m := MockWindowsAgent{
 		Listener:          lis,
-		Server:            grpc.NewServer(grpc.Creds(serverCreds)),
+		Server:            grpc.NewServer(grpc.Creds(serverCreds), grpc.StreamInterceptor(opts.unimplementedInterceptor)),
 		Service:           &mockWSLInstanceService{},
 		ClientCredentials: clientCreds,
 		Started:           make(chan struct{}),
@@ -90,6 +98,28 @@ func NewMockWindowsAgent(t *testing.T, ctx context.Context, publicDir string) *M
 	return &m
 }
 
+type mockAgentOptions struct {
+	unimplemented []string
+}
+
+// MockAgentOption is an optional parameter for NewMockWindowsAgent.
+type MockAgentOption func(*mockAgentOptions)
+
+// WithUnimplemented makes the mock agent answer the streams with the given full method names as
+// unimplemented, mimicking an older agent.
+func WithUnimplemented(methods ...string) MockAgentOption {
+	return func(o *mockAgentOptions) {
+		o.unimplemented = append(o.unimplemented, methods...)
+	}
+}
+
+func (o mockAgentOptions) unimplementedInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if slices.Contains(o.unimplemented, info.FullMethod) {
+		return status.Errorf(codes.Unimplemented, "method %s not implemented", info.FullMethod)
+	}
+	return handler(srv, ss)
+}
+
 // agentTLSCreds is a helper that creates a pair of TLS credentials for the agent and the WSL Pro service for testing.
 // It builds the ephemeral PKI in memory and writes the three publishable files directly to destDir,
 // mimicking what the real agent publishes through the securefiles custodian.
@@ -144,17 +174,28 @@ func (m *MockWindowsAgent) Stop() {
 type mockWSLInstanceService struct {
 	agentapi.UnimplementedWSLInstanceServer
 
-	Connect         channel[agentapi.DistroInfo, int, agentapi.WSLInstance_ConnectedServer]
-	ProAttachment   channel[agentapi.MSG, agentapi.ProAttachCmd, agentapi.WSLInstance_ProAttachmentCommandsServer]
-	LandscapeConfig channel[agentapi.MSG, agentapi.LandscapeConfigCmd, agentapi.WSLInstance_LandscapeConfigCommandsServer]
+	Connect          channel[agentapi.DistroInfo, int, agentapi.WSLInstance_ConnectedServer]
+	ProAttachment    channel[agentapi.MSG, agentapi.ProAttachCmd, agentapi.WSLInstance_ProAttachmentCommandsServer]
+	LandscapeConfig  channel[agentapi.MSG, agentapi.LandscapeConfigCmd, agentapi.WSLInstance_LandscapeConfigCommandsServer]
+	ProxyConfig      channel[agentapi.MSG, agentapi.ProxyConfigCmd, agentapi.WSLInstance_ProxyConfigCommandsServer]
+	RunCommand       channel[agentapi.MSG, agentapi.RunCommandCmd, agentapi.WSLInstance_RunCommandCommandsServer]
+	ProServices      channel[agentapi.MSG, agentapi.ProServicesCmd, agentapi.WSLInstance_ProServicesCommandsServer]
+	SecurityUpdate   channel[agentapi.MSG, agentapi.SecurityUpdateCmd, agentapi.WSLInstance_SecurityUpdateCommandsServer]
+	PackageInventory channel[agentapi.MSG, agentapi.PackageInventoryCmd, agentapi.WSLInstance_PackageInventoryCommandsServer]
+	Cancel           channel[agentapi.MSG, agentapi.CancelCmd, agentapi.WSLInstance_CancelCommandsServer]
 }
 
 func (s *mockWSLInstanceService) AllConnected() bool {
+	return s.Connect.connected() && s.ProAttachment.connected() && s.LandscapeConfig.connected() && s.ProxyConfig.connected() && s.RunCommand.connected() && s.ProServices.connected() && s.SecurityUpdate.connected() && s.PackageInventory.connected() && s.Cancel.connected()
+}
+
+// BaseConnected returns true when the streams that every version of the service opens are connected.
+func (s *mockWSLInstanceService) BaseConnected() bool {
 	return s.Connect.connected() && s.ProAttachment.connected() && s.LandscapeConfig.connected()
 }
 
 func (s *mockWSLInstanceService) AnyConnected() bool {
-	return s.Connect.connected() || s.ProAttachment.connected() || s.LandscapeConfig.connected()
+	return s.Connect.connected() || s.ProAttachment.connected() || s.LandscapeConfig.connected() || s.ProxyConfig.connected() || s.RunCommand.connected() || s.ProServices.connected() || s.SecurityUpdate.connected() || s.PackageInventory.connected() || s.Cancel.connected()
 }
 
 type receiver[Recv any] interface {
@@ -333,3 +374,159 @@ func (s *mockWSLInstanceService) LandscapeConfigCommands(stream agentapi.WSLInst
 		}
 	}
 }
+
+func (s *mockWSLInstanceService) ProxyConfigCommands(stream agentapi.WSLInstance_ProxyConfigCommandsServer) (err error) {
+	defer decorate.LogOnError(&err)
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	} else if msg.GetWslName() == "" {
+		return errors.New("MockWindowsAgent: WSL name not provided")
+	}
+
+	s.ProxyConfig.set(stream, msg)
+	defer s.ProxyConfig.reset()
+
+	log.Info(stream.Context(), "MockWindowsAgent: ProxyConfigCommands ready")
+
+	for {
+		_, err := s.ProxyConfig.recv()
+		if errors.Is(err, io.EOF) {
+			log.Info(stream.Context(), "MockWindowsAgent: ProxyConfigCommands finished")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("MockWindowsAgent: ProxyConfigCommands stopped: %v", err)
+		}
+	}
+}
+
+func (s *mockWSLInstanceService) RunCommandCommands(stream agentapi.WSLInstance_RunCommandCommandsServer) (err error) {
+	defer decorate.LogOnError(&err)
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	} else if msg.GetWslName() == "" {
+		return errors.New("MockWindowsAgent: WSL name not provided")
+	}
+
+	s.RunCommand.set(stream, msg)
+	defer s.RunCommand.reset()
+
+	log.Info(stream.Context(), "MockWindowsAgent: RunCommandCommands ready")
+
+	for {
+		_, err := s.RunCommand.recv()
+		if errors.Is(err, io.EOF) {
+			log.Info(stream.Context(), "MockWindowsAgent: RunCommandCommands finished")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("MockWindowsAgent: RunCommandCommands stopped: %v", err)
+		}
+	}
+}
+
+func (s *mockWSLInstanceService) ProServicesCommands(stream agentapi.WSLInstance_ProServicesCommandsServer) (err error) {
+	defer decorate.LogOnError(&err)
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	} else if msg.GetWslName() == "" {
+		return errors.New("MockWindowsAgent: WSL name not provided")
+	}
+
+	s.ProServices.set(stream, msg)
+	defer s.ProServices.reset()
+
+	log.Info(stream.Context(), "MockWindowsAgent: ProServicesCommands ready")
+
+	for {
+		_, err := s.ProServices.recv()
+		if errors.Is(err, io.EOF) {
+			log.Info(stream.Context(), "MockWindowsAgent: ProServicesCommands finished")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("MockWindowsAgent: ProServicesCommands stopped: %v", err)
+		}
+	}
+}
+
+func (s *mockWSLInstanceService) SecurityUpdateCommands(stream agentapi.WSLInstance_SecurityUpdateCommandsServer) (err error) {
+	defer decorate.LogOnError(&err)
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	} else if msg.GetWslName() == "" {
+		return errors.New("MockWindowsAgent: WSL name not provided")
+	}
+
+	s.SecurityUpdate.set(stream, msg)
+	defer s.SecurityUpdate.reset()
+
+	log.Info(stream.Context(), "MockWindowsAgent: SecurityUpdateCommands ready")
+
+	for {
+		_, err := s.SecurityUpdate.recv()
+		if errors.Is(err, io.EOF) {
+			log.Info(stream.Context(), "MockWindowsAgent: SecurityUpdateCommands finished")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("MockWindowsAgent: SecurityUpdateCommands stopped: %v", err)
+		}
+	}
+}
+
+func (s *mockWSLInstanceService) PackageInventoryCommands(stream agentapi.WSLInstance_PackageInventoryCommandsServer) (err error) {
+	defer decorate.LogOnError(&err)
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	} else if msg.GetWslName() == "" {
+		return errors.New("MockWindowsAgent: WSL name not provided")
+	}
+
+	s.PackageInventory.set(stream, msg)
+	defer s.PackageInventory.reset()
+
+	log.Info(stream.Context(), "MockWindowsAgent: PackageInventoryCommands ready")
+
+	for {
+		_, err := s.PackageInventory.recv()
+		if errors.Is(err, io.EOF) {
+			log.Info(stream.Context(), "MockWindowsAgent: PackageInventoryCommands finished")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("MockWindowsAgent: PackageInventoryCommands stopped: %v", err)
+		}
+	}
+}
+
+func (s *mockWSLInstanceService) CancelCommands(stream agentapi.WSLInstance_CancelCommandsServer) (err error) {
+	defer decorate.LogOnError(&err)
+
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	} else if msg.GetWslName() == "" {
+		return errors.New("MockWindowsAgent: WSL name not provided")
+	}
+
+	s.Cancel.set(stream, msg)
+	defer s.Cancel.reset()
+
+	log.Info(stream.Context(), "MockWindowsAgent: CancelCommands ready")
+
+	for {
+		_, err := s.Cancel.recv()
+		if errors.Is(err, io.EOF) {
+			log.Info(stream.Context(), "MockWindowsAgent: CancelCommands finished")
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("MockWindowsAgent: CancelCommands stopped: %v", err)
+		}
+	}
+}
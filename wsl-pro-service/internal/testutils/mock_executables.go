@@ -5,15 +5,19 @@ package testutils
 import (
 	"context"
 	_ "embed"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/canonical/ubuntu-pro-for-wsl/common"
 	"github.com/canonical/ubuntu-pro-for-wsl/wsl-pro-service/internal/system"
@@ -87,6 +91,20 @@ const (
 	ProDetachErrGeneric         = "UP4W_PRO_DETACH_ERR_GENERIC"
 	ProDetachErrNoReason        = "UP4W_PRO_DETACH_ERR_UNKNOWN"
 
+	ProConfigErr = "UP4W_PRO_CONFIG_ERR"
+
+	ProServicesBadJSON    = "UP4W_PRO_SERVICES_BAD_JSON"
+	ProServicesUnattached = "UP4W_PRO_SERVICES_UNATTACHED"
+
+	AptUpdateErr   = "UP4W_APT_UPDATE_ERR"
+	AptSimulateErr = "UP4W_APT_SIMULATE_ERR"
+	AptInstallErr  = "UP4W_APT_INSTALL_ERR"
+	AptNoUpgrades  = "UP4W_APT_NO_UPGRADES"
+
+	DpkgQueryErr       = "UP4W_DPKG_QUERY_ERR"
+	DpkgQueryBadOutput = "UP4W_DPKG_QUERY_BAD_OUTPUT"
+	AptCacheErr        = "UP4W_APT_CACHE_ERR"
+
 	LandscapeEnableErr  = "UP4W_LANDSCAPE_ENABLE_ERR"
 	LandscapeDisableErr = "UP4W_LANDSCAPE_DISABLE_ERR"
 
@@ -112,6 +130,9 @@ const (
 	// We cannot rely on WSL_DISTRO_NAME because one of the mock options disables it.
 	wslpathDistroName = "UP4W_WSLPATH_DISTRONAME"
 
+	// commandUser informs the mock command of the user it would run as.
+	commandUser = "UP4W_COMMAND_USER"
+
 	// mockExecutable is an environement variable used so the mock executables now they need to
 	// be executed instead of being ignored as faux tests.
 	mockExecutable = "UP4W_MOCK_EXECUTABLE"
@@ -256,6 +277,28 @@ func (m *SystemMock) WslinfoExecutable(ctx context.Context, args ...string) *exe
 	return m.mockExec(ctx, "TestWithWslInfoMock", args...)
 }
 
+// AptGetExecutable mocks `apt-get $args...`.
+func (m *SystemMock) AptGetExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return m.mockExec(ctx, "TestWithAptGetMock", args...)
+}
+
+// AptCacheExecutable mocks `apt-cache $args...`.
+func (m *SystemMock) AptCacheExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return m.mockExec(ctx, "TestWithAptCacheMock", args...)
+}
+
+// DpkgQueryExecutable mocks `dpkg-query $args...`.
+func (m *SystemMock) DpkgQueryExecutable(ctx context.Context, args ...string) *exec.Cmd {
+	return m.mockExec(ctx, "TestWithDpkgQueryMock", args...)
+}
+
+// CommandExecutable mocks running `$argv...` as the user.
+func (m *SystemMock) CommandExecutable(ctx context.Context, user string, argv ...string) *exec.Cmd {
+	cmd := m.mockExec(ctx, "TestWithCommandMock", argv...)
+	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", commandUser, user))
+	return cmd
+}
+
 type exitCode int
 
 const (
@@ -354,6 +397,133 @@ func ProMock(t *testing.T) {
 			}
 
 			return exitOk
+
+		case "config":
+			if envExists(ProConfigErr) {
+				fmt.Fprintln(os.Stderr, "This error is produced by a mock instructed to fail on pro config")
+				return exitError
+			}
+
+			if len(argv) != 3 {
+				fmt.Fprintln(os.Stderr, "Pro config expects a subcommand and a key")
+				return exitBadUsage
+			}
+
+			// Proving that this executable has run: each key is stored in its own file.
+			root := os.Getenv(FileSystemRoot)
+			if root == "" {
+				fmt.Fprintf(os.Stderr, "Missing environment variable %s\n", FileSystemRoot)
+				return exitBadUsage
+			}
+
+			switch argv[1] {
+			case "set":
+				key, value, ok := strings.Cut(argv[2], "=")
+				if !ok {
+					fmt.Fprintf(os.Stderr, "Expected key=value, got %q\n", argv[2])
+					return exitBadUsage
+				}
+
+				p := filepath.Join(root, ".pro-config-"+key)
+				//#nosec G703 // We control the inputs because this mock is only executed in tests.
+				if err := os.WriteFile(p, []byte(value), 0600); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: could not write file: %v", err)
+				}
+			case "unset":
+				p := filepath.Join(root, ".pro-config-"+argv[2])
+				//#nosec G703 // We control the inputs because this mock is only executed in tests.
+				if err := os.RemoveAll(p); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: could not remove file: %v", err)
+				}
+			default:
+				fmt.Fprintf(os.Stderr, "Unknown config subcommand %q", argv[1])
+				return exitBadUsage
+			}
+
+			return exitOk
+
+		case "enable", "disable":
+			if envExists(ProServicesBadJSON) {
+				fmt.Fprintln(os.Stdout, "invalid\nJSON")
+				return exitError
+			}
+
+			if envExists(ProServicesUnattached) {
+				fmt.Fprintln(os.Stdout, `{"errors": [{"message": "This machine is not attached to an Ubuntu Pro subscription.", "message_code": "unattached", "service": null, "type": "system"}], "failed_services": [], "processed_services": []}`)
+				return exitError
+			}
+
+			root := os.Getenv(FileSystemRoot)
+			if root == "" {
+				fmt.Fprintf(os.Stderr, "Missing environment variable %s\n", FileSystemRoot)
+				return exitBadUsage
+			}
+
+			type proError struct {
+				Message     string `json:"message"`
+				MessageCode string `json:"message_code"`
+				Service     string `json:"service"`
+			}
+
+			report := struct {
+				Errors            []proError `json:"errors"`
+				FailedServices    []string   `json:"failed_services"`
+				ProcessedServices []string   `json:"processed_services"`
+			}{Errors: []proError{}, FailedServices: []string{}, ProcessedServices: []string{}}
+
+			for _, service := range argv[1:] {
+				if strings.HasPrefix(service, "-") {
+					continue
+				}
+
+				// Services named like this mock a failure to enable or disable them.
+				if service == "not-a-service" {
+					report.FailedServices = append(report.FailedServices, service)
+					report.Errors = append(report.Errors, proError{Message: "Cannot " + argv[0] + " unknown service", MessageCode: "invalid-service-or-failure", Service: service})
+					continue
+				}
+
+				// Proving that this executable has run: each enabled service has its own file.
+				p := filepath.Join(root, ".pro-enabled-"+service)
+
+				// Like pro, services already in the requested state are reported as failed.
+				_, err := os.Stat(p)
+				if enabled := err == nil; enabled == (argv[0] == "enable") {
+					report.FailedServices = append(report.FailedServices, service)
+					report.Errors = append(report.Errors, proError{Message: service + " is already " + argv[0] + "d", MessageCode: "service-already-" + argv[0] + "d", Service: service})
+					continue
+				}
+
+				if argv[0] == "enable" {
+					//#nosec G703 // We control the inputs because this mock is only executed in tests.
+					err = os.WriteFile(p, []byte{}, 0600)
+				} else {
+					//#nosec G703 // We control the inputs because this mock is only executed in tests.
+					err = os.RemoveAll(p)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: could not update file: %v", err)
+					return exitBadUsage
+				}
+
+				report.ProcessedServices = append(report.ProcessedServices, service)
+			}
+
+			out, err := json.Marshal(report)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not marshal report: %v", err)
+				return exitBadUsage
+			}
+
+			// Pro may print some warnings before the report.
+			fmt.Fprintln(os.Stdout, "One moment, checking your subscription first")
+			fmt.Fprintln(os.Stdout, string(out))
+
+			if len(report.FailedServices) > 0 {
+				return exitError
+			}
+			return exitOk
+
 		default:
 			fmt.Fprintf(os.Stderr, "Unknown verb %q", argv[0])
 			return exitBadUsage
@@ -599,6 +769,322 @@ func WslInfoMock(t *testing.T) {
 	})
 }
 
+// CommandMock mocks the arbitrary commands sent by the agent. The verb selects the behaviour:
+//   - echo ARGS...: prints the arguments to stdout.
+//   - cat: copies stdin to stdout.
+//   - pwd: prints the working directory.
+//   - env KEY: prints the value of the environment variable.
+//   - whoami: prints the user the command runs as.
+//   - fail ARGS...: prints the arguments to stderr and exits with an error.
+//   - sleep DURATION: waits for that long.
+//   - flood SIZE: prints that many bytes to stdout.
+//
+// Add it to your package_test with:
+//
+//	func TestWithCommandMock(t *testing.T) { testutils.CommandMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock arbitrary executables
+func CommandMock(t *testing.T) {
+	if t.Name() != "TestWithCommandMock" {
+		panic("The CommandMock faux test must be named TestWithCommandMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		if len(argv) == 0 {
+			fmt.Fprintln(os.Stderr, "Command mock expects a verb")
+			return exitBadUsage
+		}
+
+		switch argv[0] {
+		case "echo":
+			fmt.Fprintln(os.Stdout, strings.Join(argv[1:], " "))
+			return exitOk
+		case "cat":
+			if _, err := io.Copy(os.Stdout, os.Stdin); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not copy stdin: %v", err)
+				return exitBadUsage
+			}
+			return exitOk
+		case "pwd":
+			wd, err := os.Getwd()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not get working directory: %v", err)
+				return exitBadUsage
+			}
+			fmt.Fprintln(os.Stdout, wd)
+			return exitOk
+		case "env":
+			if len(argv) != 2 {
+				fmt.Fprintln(os.Stderr, "env expects a variable name")
+				return exitBadUsage
+			}
+			fmt.Fprintln(os.Stdout, os.Getenv(argv[1]))
+			return exitOk
+		case "whoami":
+			user := os.Getenv(commandUser)
+			if user == "" {
+				user = "root"
+			}
+			fmt.Fprintln(os.Stdout, user)
+			return exitOk
+		case "fail":
+			fmt.Fprintln(os.Stderr, strings.Join(argv[1:], " "))
+			return exitError
+		case "sleep":
+			if len(argv) != 2 {
+				fmt.Fprintln(os.Stderr, "sleep expects a duration")
+				return exitBadUsage
+			}
+			d, err := time.ParseDuration(argv[1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not parse duration: %v", err)
+				return exitBadUsage
+			}
+			time.Sleep(d)
+			return exitOk
+		case "flood":
+			if len(argv) != 2 {
+				fmt.Fprintln(os.Stderr, "flood expects a size")
+				return exitBadUsage
+			}
+			n, err := strconv.Atoi(argv[1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not parse size: %v", err)
+				return exitBadUsage
+			}
+			fmt.Fprint(os.Stdout, strings.Repeat("x", n))
+			return exitOk
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown verb %q", argv[0])
+			return exitBadUsage
+		}
+	})
+}
+
+// aptSimulatedUpgrade is the output of the mocked `apt-get dist-upgrade --simulate`. Only libssl3t64
+// and curl have security upgrades.
+const aptSimulatedUpgrade = `NOTE: This is only a simulation!
+Reading package lists...
+Building dependency tree...
+Calculating upgrade...
+The following NEW packages will be installed:
+  libnew1
+The following packages will be upgraded:
+  curl libssl3t64 vim
+3 upgraded, 1 newly installed, 0 to remove and 0 not upgraded.
+Inst libnew1 (1.0-1 Ubuntu:24.04/noble-security [amd64])
+Inst libssl3t64 [3.0.13-0ubuntu3.1] (3.0.13-0ubuntu3.4 Ubuntu:24.04/noble-updates, Ubuntu:24.04/noble-security [amd64])
+Inst vim [2:9.1.0016-1ubuntu7] (2:9.1.0016-1ubuntu7.2 Ubuntu:24.04/noble-updates [amd64])
+Inst curl [8.5.0-2ubuntu10.1] (8.5.0-2ubuntu10.6+esm1 UbuntuESMApps:24.04/noble-apps-security [amd64])
+Conf libnew1 (1.0-1 Ubuntu:24.04/noble-security [amd64])
+Conf libssl3t64 (3.0.13-0ubuntu3.4 Ubuntu:24.04/noble-updates, Ubuntu:24.04/noble-security [amd64])
+Conf vim (2:9.1.0016-1ubuntu7.2 Ubuntu:24.04/noble-updates [amd64])
+Conf curl (8.5.0-2ubuntu10.6+esm1 UbuntuESMApps:24.04/noble-apps-security [amd64])
+`
+
+// AptGetMock mocks the executable for `apt-get`.
+// Add it to your package_test with:
+//
+//	func TestWithAptGetMock(t *testing.T) { testutils.AptGetMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock the executable `apt-get`
+func AptGetMock(t *testing.T) {
+	if t.Name() != "TestWithAptGetMock" {
+		panic("The AptGetMock faux test must be named TestWithAptGetMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		if len(argv) == 0 {
+			fmt.Fprintln(os.Stderr, "apt-get command expects a verb")
+			return exitBadUsage
+		}
+
+		switch argv[0] {
+		case "update":
+			if envExists(AptUpdateErr) {
+				fmt.Fprintln(os.Stderr, "E: Failed to fetch http://archive.ubuntu.com/ubuntu/dists/noble/InRelease")
+				return exitError
+			}
+			fmt.Fprintln(os.Stdout, "Reading package lists...")
+			return exitOk
+		case "dist-upgrade":
+			if !slices.Contains(argv, "--simulate") {
+				fmt.Fprintln(os.Stderr, "dist-upgrade must only be simulated")
+				return exitBadUsage
+			}
+			if envExists(AptSimulateErr) {
+				fmt.Fprintln(os.Stderr, "E: Unable to correct problems, you have held broken packages.")
+				return exitError
+			}
+			if envExists(AptNoUpgrades) {
+				fmt.Fprintln(os.Stdout, "0 upgraded, 0 newly installed, 0 to remove and 0 not upgraded.")
+				return exitOk
+			}
+			fmt.Fprint(os.Stdout, aptSimulatedUpgrade)
+			return exitOk
+		case "install":
+			if !slices.Contains(argv, "--only-upgrade") || !slices.Contains(argv, "--assume-yes") {
+				fmt.Fprintln(os.Stderr, "install must only upgrade packages without prompting")
+				return exitBadUsage
+			}
+			for i := 1; i < len(argv); i++ {
+				if argv[i] == "-o" {
+					i++
+					continue
+				}
+				if strings.HasPrefix(argv[i], "-") {
+					continue
+				}
+
+				pkg, version, pinned := strings.Cut(argv[i], "=")
+				if !pinned || version == "" {
+					fmt.Fprintf(os.Stderr, "package %s must be pinned to the version of the simulation", pkg)
+					return exitBadUsage
+				}
+				if pkg == "vim" || pkg == "libnew1" {
+					fmt.Fprintf(os.Stderr, "package %s has no security upgrade", pkg)
+					return exitBadUsage
+				}
+			}
+			if envExists(AptInstallErr) {
+				fmt.Fprintln(os.Stderr, "E: Sub-process /usr/bin/dpkg returned an error code (1)")
+				return exitError
+			}
+			fmt.Fprintln(os.Stdout, "Setting up the upgraded packages...")
+			return exitOk
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown verb %q", argv[0])
+			return exitBadUsage
+		}
+	})
+}
+
+// dpkgInstalledPackages is the output of the mocked `dpkg-query --show`. The configuration files of
+// oldpkg remain, but the package is not installed.
+const dpkgInstalledPackages = "installed\tbase-files\tbase-files\t13ubuntu10.1\tamd64\n" +
+	"installed\tlibc6:amd64\tlibc6\t2.39-0ubuntu8.3\tamd64\n" +
+	"installed\tlibc6:i386\tlibc6\t2.39-0ubuntu8.3\ti386\n" +
+	"installed\tcurl\tcurl\t8.5.0-2ubuntu10.6+esm1\tamd64\n" +
+	"installed\ttzdata\ttzdata\t2024a-3ubuntu1.1\tall\n" +
+	"installed\tmytool\tmytool\t1.0\tamd64\n" +
+	"config-files\toldpkg\toldpkg\t0.9\tamd64\n"
+
+// aptCachePolicy is the output of the mocked `apt-cache policy` for the packages in dpkgInstalledPackages.
+// mytool was installed from a local .deb file.
+const aptCachePolicy = `base-files:
+  Installed: 13ubuntu10.1
+  Candidate: 13ubuntu10.1
+  Version table:
+ *** 13ubuntu10.1 500
+        500 http://archive.ubuntu.com/ubuntu noble-updates/main amd64 Packages
+        100 /var/lib/dpkg/status
+     13ubuntu10 500
+        500 http://archive.ubuntu.com/ubuntu noble/main amd64 Packages
+libc6:
+  Installed: 2.39-0ubuntu8.3
+  Candidate: 2.39-0ubuntu8.4
+  Version table:
+     2.39-0ubuntu8.4 500
+        500 http://archive.ubuntu.com/ubuntu noble-updates/main amd64 Packages
+ *** 2.39-0ubuntu8.3 500
+        500 http://security.ubuntu.com/ubuntu noble-security/main amd64 Packages
+        100 /var/lib/dpkg/status
+libc6:i386:
+  Installed: 2.39-0ubuntu8.3
+  Candidate: 2.39-0ubuntu8.3
+  Version table:
+ *** 2.39-0ubuntu8.3 500
+        500 http://security.ubuntu.com/ubuntu noble-security/main i386 Packages
+        100 /var/lib/dpkg/status
+curl:
+  Installed: 8.5.0-2ubuntu10.6+esm1
+  Candidate: 8.5.0-2ubuntu10.6+esm1
+  Version table:
+ *** 8.5.0-2ubuntu10.6+esm1 510
+        510 https://esm.ubuntu.com/apps/ubuntu noble-apps-security/main amd64 Packages
+        100 /var/lib/dpkg/status
+     8.5.0-2ubuntu10.6 500
+        500 http://archive.ubuntu.com/ubuntu noble-updates/main amd64 Packages
+tzdata:
+  Installed: 2024a-3ubuntu1.1
+  Candidate: 2024a-3ubuntu1.1
+  Version table:
+ *** 2024a-3ubuntu1.1 500
+        500 http://archive.ubuntu.com/ubuntu noble-updates/main amd64 Packages
+        500 http://security.ubuntu.com/ubuntu noble-security/main amd64 Packages
+        100 /var/lib/dpkg/status
+mytool:
+  Installed: 1.0
+  Candidate: 1.0
+  Version table:
+ *** 1.0 100
+        100 /var/lib/dpkg/status
+`
+
+// DpkgQueryMock mocks the executable for `dpkg-query`.
+// Add it to your package_test with:
+//
+//	func TestWithDpkgQueryMock(t *testing.T) { testutils.DpkgQueryMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock the executable `dpkg-query`
+func DpkgQueryMock(t *testing.T) {
+	if t.Name() != "TestWithDpkgQueryMock" {
+		panic("The DpkgQueryMock faux test must be named TestWithDpkgQueryMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		if len(argv) == 0 || argv[0] != "--show" {
+			fmt.Fprintln(os.Stderr, "dpkg-query mock only supports --show")
+			return exitBadUsage
+		}
+
+		if envExists(DpkgQueryErr) {
+			fmt.Fprintln(os.Stderr, "dpkg-query: error: database is locked")
+			return exitError
+		}
+
+		if envExists(DpkgQueryBadOutput) {
+			fmt.Fprintln(os.Stdout, "this is not the requested format")
+			return exitOk
+		}
+
+		fmt.Fprint(os.Stdout, dpkgInstalledPackages)
+		return exitOk
+	})
+}
+
+// AptCacheMock mocks the executable for `apt-cache`.
+// Add it to your package_test with:
+//
+//	func TestWithAptCacheMock(t *testing.T) { testutils.AptCacheMock(t) }
+//
+//nolint:thelper // This is a faux test used to mock the executable `apt-cache`
+func AptCacheMock(t *testing.T) {
+	if t.Name() != "TestWithAptCacheMock" {
+		panic("The AptCacheMock faux test must be named TestWithAptCacheMock")
+	}
+
+	mockMain(t, func(argv []string) exitCode {
+		if len(argv) == 0 || argv[0] != "policy" {
+			fmt.Fprintln(os.Stderr, "apt-cache mock only supports policy")
+			return exitBadUsage
+		}
+
+		if envExists(AptCacheErr) {
+			fmt.Fprintln(os.Stderr, "E: Could not open lock file /var/lib/apt/lists/lock")
+			return exitError
+		}
+
+		if slices.Contains(argv, "oldpkg") {
+			fmt.Fprintln(os.Stderr, "packages that are not installed should not be queried")
+			return exitBadUsage
+		}
+
+		fmt.Fprint(os.Stdout, aptCachePolicy)
+		return exitOk
+	})
+}
+
 func envExists(arg controlArg) bool {
 	return os.Getenv(string(arg)) != ""
 }